@@ -2,12 +2,16 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
@@ -16,11 +20,42 @@ import (
 	"github.com/go-chi/cors"
 	"github.com/sirupsen/logrus"
 
+	"github.com/avvvet/cdnbuddy-api/internal/admin"
+	"github.com/avvvet/cdnbuddy-api/internal/api/handlers"
 	"github.com/avvvet/cdnbuddy-api/internal/config"
+	"github.com/avvvet/cdnbuddy-api/internal/features"
+	apimw "github.com/avvvet/cdnbuddy-api/internal/middleware"
 	"github.com/avvvet/cdnbuddy-api/internal/models"
+	"github.com/avvvet/cdnbuddy-api/internal/services/apikeys"
+	"github.com/avvvet/cdnbuddy-api/internal/services/artifacts"
+	"github.com/avvvet/cdnbuddy-api/internal/services/audit"
+	"github.com/avvvet/cdnbuddy-api/internal/services/branding"
+	"github.com/avvvet/cdnbuddy-api/internal/services/callbacks"
 	"github.com/avvvet/cdnbuddy-api/internal/services/cdn"
+	"github.com/avvvet/cdnbuddy-api/internal/services/cms"
+	"github.com/avvvet/cdnbuddy-api/internal/services/compliance"
+	"github.com/avvvet/cdnbuddy-api/internal/services/diagnostics"
+	"github.com/avvvet/cdnbuddy-api/internal/services/dns"
+	"github.com/avvvet/cdnbuddy-api/internal/services/intentcache"
+	"github.com/avvvet/cdnbuddy-api/internal/services/intentstats"
+	"github.com/avvvet/cdnbuddy-api/internal/services/leader"
+	"github.com/avvvet/cdnbuddy-api/internal/services/logingest"
 	"github.com/avvvet/cdnbuddy-api/internal/services/messaging"
+	"github.com/avvvet/cdnbuddy-api/internal/services/operations"
+	"github.com/avvvet/cdnbuddy-api/internal/services/ownership"
 	"github.com/avvvet/cdnbuddy-api/internal/services/planstorage"
+	"github.com/avvvet/cdnbuddy-api/internal/services/ratelimit"
+	"github.com/avvvet/cdnbuddy-api/internal/services/reminders"
+	"github.com/avvvet/cdnbuddy-api/internal/services/sandbox"
+	"github.com/avvvet/cdnbuddy-api/internal/services/scheduler"
+	"github.com/avvvet/cdnbuddy-api/internal/services/sessions"
+	"github.com/avvvet/cdnbuddy-api/internal/services/share"
+	"github.com/avvvet/cdnbuddy-api/internal/services/speech"
+	"github.com/avvvet/cdnbuddy-api/internal/services/transcript"
+	"github.com/avvvet/cdnbuddy-api/internal/services/usage"
+	"github.com/avvvet/cdnbuddy-api/internal/services/users"
+	"github.com/avvvet/cdnbuddy-api/internal/services/webhooks"
+	"github.com/avvvet/cdnbuddy-api/internal/services/widget"
 )
 
 func main() {
@@ -35,17 +70,111 @@ func main() {
 
 	logrus.Info("🚀 Starting CDNBuddy API Server...")
 
-	// Initialize CacheFly provider
-	cacheFlyProvider, err := cdn.NewCacheFlyProvider()
+	// Provider API rate budgets, shared by all workers (and replicas via Redis)
+	rateBudgets, err := ratelimit.ParseBudgets(cfg.ProviderRateLimits)
 	if err != nil {
-		logrus.Fatalf("Failed to initialize CacheFly provider: %v", err)
+		logrus.Fatalf("Failed to parse PROVIDER_RATE_LIMITS: %v", err)
+	}
+	var rateLimiter cdn.RateLimiter
+	if len(rateBudgets) > 0 {
+		if cfg.ProviderRateLimitRedisURL != "" {
+			limiter, err := ratelimit.NewRedis(cfg.ProviderRateLimitRedisURL, rateBudgets)
+			if err != nil {
+				logrus.Fatalf("Failed to initialize shared rate budgets: %v", err)
+			}
+			defer limiter.Close()
+			rateLimiter = limiter
+		} else {
+			rateLimiter = ratelimit.NewLocal(rateBudgets)
+		}
+		logrus.WithField("budgets", cfg.ProviderRateLimits).Info("🚦 Provider rate budgets enabled")
 	}
 
-	// Initialize CDN service
-	cdnService := cdn.NewService(cacheFlyProvider)
+	// Retries for transient provider failures (429/5xx), per operation class
+	retryPolicies, err := cdn.ParseRetryPolicies(cfg.ProviderRetries)
+	if err != nil {
+		logrus.Fatalf("Failed to parse PROVIDER_RETRIES: %v", err)
+	}
+
+	// Every provider mutation is journaled for support tickets
+	providerJournal := cdn.NewJournal(cfg.ProviderJournalMaxEntries, cfg.ProviderJournalRetention)
+
+	// Shared by every provider; stops calling a provider for a while once it keeps failing
+	callPolicy := cdn.NewCallPolicy(rateLimiter, retryPolicies, cdn.BreakerSettings{
+		Threshold: cfg.ProviderBreakerThreshold,
+		Cooldown:  cfg.ProviderBreakerCooldown,
+	}, providerJournal)
+
+	// Clarification analytics for tuning intent prompts and defaults
+	intentStats := intentstats.NewTracker(cfg.IntentAbandonTimeout)
+
+	// Initialize configured CDN providers
+	registry := cdn.NewProviderRegistry(cdn.ParseProvider(cfg.DefaultCDNProvider))
+	for _, name := range strings.Split(cfg.CDNProviders, ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		provider, err := cdn.NewProvider(cdn.ParseProvider(name), callPolicy)
+		if err != nil {
+			logrus.Fatalf("Failed to initialize %s provider: %v", name, err)
+		}
+		registry.Register(cdn.ParseProvider(name), provider)
+		logrus.WithField("provider", name).Info("🔌 CDN provider registered")
+	}
+
+	// Per-tenant provider modes, so new integrations can run read-only first
+	providerModes, err := features.ParseProviderModes(cfg.ProviderModes)
+	if err != nil {
+		logrus.Fatalf("Failed to parse PROVIDER_MODES: %v", err)
+	}
+	flags := features.NewFlags(providerModes)
 
 	// Initialize plan storage
-	planStorage := planstorage.NewStorage()
+	planStorage := planstorage.NewStorage(cfg.PlanStorageMaxPlans)
+
+	// Initialize intent response cache
+	intentCache := intentcache.NewCache(cfg.IntentCacheTTL, cfg.IntentCacheMaxEntries)
+
+	// Initialize AI usage tracker with configured tier quotas
+	quotas := make(map[string]usage.Quota)
+	if cfg.AIQuotaFreeRequests > 0 {
+		q := usage.DefaultQuotas[usage.TierFree]
+		q.RequestsPerDay = cfg.AIQuotaFreeRequests
+		quotas[usage.TierFree] = q
+	}
+	if cfg.AIQuotaProRequests > 0 {
+		q := usage.DefaultQuotas[usage.TierPro]
+		q.RequestsPerDay = cfg.AIQuotaProRequests
+		quotas[usage.TierPro] = q
+	}
+	orgTiers, err := usage.ParseTiers(cfg.AIOrgTiers)
+	if err != nil {
+		logrus.Fatalf("Failed to parse AI_ORG_TIERS: %v", err)
+	}
+	usageTracker, err := usage.NewTracker(quotas, orgTiers, cfg.UsageMaxRecords)
+	if err != nil {
+		logrus.Fatalf("Invalid AI_ORG_TIERS: %v", err)
+	}
+
+	// Initialize demo sandbox tenants
+	sandboxes := sandbox.NewManager(cfg.SandboxTTL, cfg.SandboxMaxTenants)
+
+	// Initialize audit log of executed changes
+	auditLog := audit.NewLog(cfg.AuditMaxEntries)
+
+	// Executed plans, looked up by ID and searched
+	operationStore := operations.NewStore(cfg.OperationsMaxEntries)
+	operationOverrides, err := operations.ParsePolicies(cfg.OperationPolicies)
+	if err != nil {
+		logrus.Fatalf("Failed to parse OPERATION_POLICIES: %v", err)
+	}
+	operationPolicies := operations.NewPolicies(operationOverrides)
+	operationDurations := operations.NewDurations(cfg.OperationDurationSamples)
+	operationQueue := operations.NewQueue(operationStore, operationDurations)
+
+	// Services managed by CDNBuddy, including ones adopted from existing accounts
+	ownershipStore := ownership.NewStore()
+	importer := ownership.NewImporter(ownershipStore)
 
 	// Initialize database
 	/*
@@ -62,39 +191,339 @@ func main() {
 
 	*/
 
-	// Initialize NATS messaging
-	logrus.Info("📡 Connecting to NATS...")
-	msgClient, err := messaging.NewClient(cfg.NATSUrl)
+	// Select messaging backend (NATS keeps using NATS_URL)
+	busConfig := messaging.BusConfig{
+		Backend:      cfg.MessagingBackend,
+		URL:          cfg.MessagingURL,
+		ConsumerName: cfg.MessagingConsumerName,
+		Envelopes:    cfg.MessagingEnvelopes,
+		Encoding:     cfg.MessagingEncoding,
+	}
+	if busConfig.Backend == messaging.BackendNATS {
+		busConfig.URL = cfg.NATSUrl
+	}
+
+	// Run NATS in-process for single-binary deployments
+	if busConfig.Backend == messaging.BackendNATS && busConfig.URL == messaging.EmbeddedURL {
+		logrus.Info("🧩 Starting embedded NATS server...")
+		embedded, err := messaging.StartEmbeddedServer(cfg.NATSEmbeddedListen, cfg.NATSEmbeddedStoreDir)
+		if err != nil {
+			logrus.Fatalf("Failed to start embedded NATS: %v", err)
+		}
+		defer embedded.Shutdown()
+		busConfig.URL = embedded.ClientURL()
+	}
+
+	// Initialize messaging
+	logrus.Infof("📡 Connecting to %s messaging...", busConfig.Backend)
+	msgClient, err := messaging.NewClientWithConfig(busConfig)
 	if err != nil {
-		logrus.Fatalf("Failed to connect to NATS: %v", err)
+		logrus.Fatalf("Failed to connect to %s: %v", busConfig.Backend, err)
 	}
 	defer msgClient.Close()
-	logrus.Info("✅ NATS connected")
+	logrus.Infof("✅ %s messaging connected", busConfig.Backend)
 
 	publisher := msgClient.Publisher()
 
+	// Cache rule recommendations from origin headers, remembered per chat session
+	ttlAdvisor := diagnostics.NewTTLAdvisor(diagnostics.NewTester())
+
+	// User profiles; their setup defaults are offered to the intent service
+	userStore := users.NewStore()
+
+	// White-label settings applied to chat responses, notifications and exports;
+	// chat doesn't carry an org yet, so it uses the deployment's own
+	brandingStore := branding.NewStore()
+	msgClient.SetResponseFormatter(func(orgID, message string) string {
+		if orgID == "" {
+			orgID = reminders.DefaultOrgID
+		}
+		return brandingStore.Apply(orgID, message)
+	})
+
+	// Pause automated changes to a service when an option keeps flipping,
+	// and tell its owner
+	changeGuard := cdn.NewChangeGuard(cfg.ChangeGuardMaxChanges, cfg.ChangeGuardWindow, func(pause cdn.Pause) {
+		owner := ownership.Record{ServiceID: pause.ServiceID, OrgID: reminders.DefaultOrgID}
+		for _, provider := range registry.Names() {
+			if record, ok := ownershipStore.Get(provider, pause.ServiceID); ok {
+				owner = record
+				break
+			}
+		}
+
+		err := publisher.PublishNotification(messaging.NotificationEvent{
+			Type:      messaging.EventChangesPaused,
+			OrgID:     owner.OrgID,
+			UserID:    owner.UserID,
+			ServiceID: pause.ServiceID,
+			Title:     "Automated changes paused",
+			Message: fmt.Sprintf("%s on service %s changed %d times within %s, which looks like an automation loop. Further automated changes to the service are paused until you acknowledge.",
+				pause.Option, pause.ServiceID, pause.Changes, pause.Window),
+			Level: "warning",
+			Data: map[string]interface{}{
+				"pause": pause,
+			},
+		})
+		if err != nil {
+			logrus.WithError(err).WithField("service_id", pause.ServiceID).Error("❌ Failed to send change guard notification")
+		}
+	})
+
+	// Initialize CDN service
+	cdnService, err := cdn.NewServiceWithRegistry(registry, callPolicy, changeGuard)
+	if err != nil {
+		logrus.Fatalf("Failed to initialize CDN service: %v", err)
+	}
+
+	// Vanity CNAME chains shown in DNS instructions instead of provider
+	// hostnames; records are created through Cloudflare when configured
+	var dnsAdapter dns.Adapter
+	if cfg.CloudflareToken != "" {
+		cloudflare, err := dns.NewCloudflareAdapter(cfg.CloudflareToken, cfg.CloudflareZoneID)
+		if err != nil {
+			logrus.Fatalf("Failed to create DNS adapter: %v", err)
+		}
+		dnsAdapter = cloudflare
+	}
+	vanity := dns.NewVanity(dnsAdapter)
+
+	// Users who opt in get AI responses on every device they have open
+	sessionRegistry := sessions.NewRegistry(cfg.SessionActiveWindow)
+	msgClient.SetResponseRecipients(sessionRegistry.Recipients)
+
+	// Long chats are summarized by the intent service to bound request size
+	transcripts := transcript.NewStore(cfg.TranscriptSummaryThreshold, cfg.TranscriptKeepRecent,
+		func(ctx context.Context, sessionID, previous string, messages []models.ConversationMessage) (string, error) {
+			resp, err := msgClient.RequestSummary(ctx, models.SummaryRequest{
+				SessionID:       sessionID,
+				PreviousSummary: previous,
+				Messages:        messages,
+			})
+			if err != nil {
+				return "", err
+			}
+			return resp.Summary, nil
+		})
+
+	// Singleton background jobs (reminders, compliance scans, log ingestion)
+	// run only on the replica holding the leader lease. The plan scheduler and
+	// the in-memory janitors keep running everywhere: scheduled jobs, plans and
+	// sandboxes live in the memory of the replica that accepted them.
+	leaderURL := cfg.LeaderElectionURL
+	if leaderURL == "" && cfg.LeaderElection == "nats" {
+		if cfg.NATSUrl == messaging.EmbeddedURL {
+			logrus.Fatal("LEADER_ELECTION=nats needs a shared NATS server with JetStream; set LEADER_ELECTION_URL")
+		}
+		leaderURL = cfg.NATSUrl
+	}
+	lease, err := leader.New(cfg.LeaderElection, leaderURL, "background-jobs")
+	if err != nil {
+		logrus.Fatalf("Failed to initialize leader election: %v", err)
+	}
+	defer lease.Close()
+	replicaID := cfg.ReplicaID
+	if replicaID == "" {
+		replicaID = leader.DefaultReplicaID()
+	}
+	elector := leader.NewElector(lease, replicaID, cfg.LeaderLeaseTTL)
+	leaderCtx, stopLeader := context.WithCancel(context.Background())
+	leaderDone := make(chan struct{})
+	go func() {
+		defer close(leaderDone)
+		elector.Run(leaderCtx)
+	}()
+	defer func() {
+		stopLeader()
+		<-leaderDone // release the lease before its connection closes
+	}()
+	logrus.WithFields(logrus.Fields{
+		"backend":    cfg.LeaderElection,
+		"replica_id": replicaID,
+	}).Info("👑 Leader election configured")
+
+	// Periodic TTL review reminders for services with stale rules and low hit ratios
+	reviewer := reminders.NewReviewer(publisher, auditLog)
+	reminderSettings := reminders.DefaultSettings
+	reminderSettings.Enabled = cfg.ReminderEnabled
+	reviewer.RegisterOrg(reminders.DefaultOrgID, cdnService, reminderSettings)
+	reviewCtx, stopReview := context.WithCancel(context.Background())
+	defer stopReview()
+	go elector.RunSingleton(reviewCtx, "reminders", func(ctx context.Context) {
+		reviewer.Start(ctx, cfg.ReminderInterval)
+	})
+
+	// Nightly compliance scans (TLS floor, HSTS, open methods) with drift notifications
+	compliancePolicy := compliance.DefaultPolicy
+	if cfg.CompliancePolicyFile != "" {
+		if compliancePolicy, err = compliance.LoadPolicy(cfg.CompliancePolicyFile); err != nil {
+			logrus.Fatalf("Failed to load compliance policy: %v", err)
+		}
+	}
+	complianceScanner := compliance.NewScanner(publisher, compliancePolicy)
+	complianceScanner.RegisterOrg(reminders.DefaultOrgID, cdnService, cfg.ComplianceNotifyUserID)
+	if cfg.ComplianceEnabled {
+		complianceCtx, stopCompliance := context.WithCancel(context.Background())
+		defer stopCompliance()
+		go elector.RunSingleton(complianceCtx, "compliance", func(ctx context.Context) {
+			complianceScanner.Start(ctx, cfg.ComplianceScanHour)
+		})
+	}
+
+	// Generated reports linked from chat responses
+	artifactStore := artifacts.NewStore(cfg.ArtifactTTL, cfg.ArtifactsMaxEntries, "/api/v1/artifacts")
+
+	// Signed links to a read-only status page of one service
+	shareSigner, err := share.NewSigner(cfg.ShareLinkSecret, "/share", cfg.ShareLinkTTL)
+	if err != nil {
+		logrus.Fatalf("Failed to create share link signer: %v", err)
+	}
+
+	// Short-lived tokens for the chat widget customers embed on their own pages
+	widgetTokens, err := widget.NewIssuer(cfg.WidgetTokenSecret)
+	if err != nil {
+		logrus.Fatalf("Failed to create widget token issuer: %v", err)
+	}
+
+	// Voice notes are transcribed and fed into the chat pipeline
+	var transcriber speech.Transcriber
+	if cfg.STTProvider != "" {
+		if transcriber, err = speech.New(cfg.STTProvider, cfg.STTAPIURL, cfg.STTAPIKey, cfg.STTModel); err != nil {
+			logrus.Fatalf("Failed to configure STT provider: %v", err)
+		}
+		logrus.WithField("provider", transcriber.Name()).Info("🎙️ Voice notes enabled")
+	}
+
+	// Batch rapid-fire operation progress into periodic chat messages
+	digester := messaging.NewDigester(publisher.PublishAIResponse, messaging.DigestSettings{
+		Enabled:  cfg.ProgressDigestInterval > 0,
+		Interval: cfg.ProgressDigestInterval,
+	})
+	defer digester.Close()
+
+	// Plans confirmed for a maintenance window run later, then get verified
+	executePlan := newPlanExecutor(cdnService, flags, sandboxes, auditLog, operationQueue, operationPolicies, importer, vanity, publisher.PublishAIResponse)
+	planScheduler := scheduler.NewScheduler(publisher,
+		func(ctx context.Context, job scheduler.Job) (string, error) {
+			return executePlan(cdn.WithChangeSource(ctx, cdn.SourceSchedule), job.Plan, job.UserID, job.SessionID, job.SandboxID)
+		},
+		func(ctx context.Context, job scheduler.Job) []scheduler.Check {
+			svc, err := handlers.ResolveCDNService(sandboxes, cdnService, flags, reminders.DefaultOrgID, job.SandboxID, "")
+			if err != nil {
+				return []scheduler.Check{{Name: "service_active", Detail: err.Error()}}
+			}
+			return scheduler.VerifyService(ctx, svc, job.ServiceID, job.Domain)
+		},
+		cfg.ScheduleReminderLead)
+	scheduleCtx, stopSchedule := context.WithCancel(context.Background())
+	defer stopSchedule()
+	go planScheduler.Start(scheduleCtx, 30*time.Second)
+
+	// Flag operations running far longer than the same action usually takes
+	watchdog := operations.NewWatchdog(operationStore, operationDurations, operationPolicies)
+	if cfg.OperationWatchdogInterval > 0 {
+		watchdogCtx, stopWatchdog := context.WithCancel(context.Background())
+		defer stopWatchdog()
+		go watchdog.Run(watchdogCtx, cfg.OperationWatchdogInterval)
+	}
+
+	// Pull delivered access logs to compute our own analytics
+	logWorker := logingest.NewWorker(cdnService)
+	if cfg.LogIngestEnabled {
+		ingestCtx, stopIngest := context.WithCancel(context.Background())
+		defer stopIngest()
+		go elector.RunSingleton(ingestCtx, "log_ingest", func(ctx context.Context) {
+			logWorker.Start(ctx, cfg.LogIngestInterval)
+		})
+	}
+
 	// Setup event handlers for AI Intent Service responses
-	setupEventHandlers(msgClient, cdnService, planStorage)
+	setupEventHandlers(msgClient, cdnService, flags, planStorage, intentCache, usageTracker, sandboxes, auditLog, executePlan, planScheduler, digester, artifactStore, intentStats, transcripts, sessionRegistry, ttlAdvisor, widgetTokens, userStore, operationQueue, ownershipStore)
+
+	// Forward service, domain, cache and operation events to the endpoints
+	// registered by the org owning the service
+	webhookStore := webhooks.NewStore()
+	webhookDispatcher := webhooks.NewDispatcher(webhookStore, func(serviceID string) string {
+		for _, provider := range registry.Names() {
+			if record, ok := ownershipStore.Get(provider, serviceID); ok {
+				return record.OrgID
+			}
+		}
+		return reminders.DefaultOrgID
+	})
+	defer webhookDispatcher.Close()
+	if err := webhookDispatcher.Subscribe(msgClient.Subscriber()); err != nil {
+		logrus.WithError(err).Error("Failed to subscribe webhook dispatcher")
+	}
+
+	// Per-route timeouts and body limits, reloaded from file on change
+	routeLimits, err := apimw.NewRouteLimits(apimw.DefaultRouteLimits)
+	if err != nil {
+		logrus.Fatalf("Failed to initialize route limits: %v", err)
+	}
+	watchCtx, stopWatch := context.WithCancel(context.Background())
+	defer stopWatch()
+	if cfg.RouteLimitsFile != "" {
+		if err := routeLimits.Load(cfg.RouteLimitsFile); err != nil {
+			logrus.Fatalf("Failed to load route limits: %v", err)
+		}
+		go routeLimits.Watch(watchCtx, cfg.RouteLimitsFile, cfg.RouteLimitsReload)
+	}
+
+	// Our dashboards are allowed globally; orgs add their own embedded frontends
+	corsRoutes := apimw.DefaultCORSRoutes
+	if cfg.CORSTenantRoutes != "" {
+		if corsRoutes, err = apimw.ParseCORSRoutes(cfg.CORSTenantRoutes); err != nil {
+			logrus.Fatalf("Failed to parse CORS_TENANT_ROUTES: %v", err)
+		}
+	}
+	corsPolicy, err := apimw.NewCORS(strings.Split(cfg.CORSAllowedOrigins, ","), corsRoutes, handlers.OrgIDFromQuery)
+	if err != nil {
+		logrus.Fatalf("Failed to configure CORS: %v", err)
+	}
 
 	// Create Chi router
 	r := chi.NewRouter()
 
 	// Global middleware
 	r.Use(middleware.RequestID)
+	r.Use(func(next http.Handler) http.Handler {
+		// Provider calls made for a request carry its ID in the mutation journal
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := cdn.WithCorrelationID(r.Context(), middleware.GetReqID(r.Context()))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	})
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
-	r.Use(middleware.Timeout(60 * time.Second))
+	r.Use(routeLimits.Middleware)
 
 	// CORS middleware
+	r.Use(brandingStore.Middleware)
+	r.Use(corsPolicy.Middleware)
 	r.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   []string{"http://localhost:5173", "http://localhost:3000"},
+		AllowOriginFunc:  corsPolicy.AllowOrigin,
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "If-None-Match", apimw.APIKeyHeader},
+		ExposedHeaders:   []string{"ETag", "Link", "X-Total-Count"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
 
+	// API keys with scopes; the key's org replaces org_id in handlers
+	apiKeys := apikeys.NewStore()
+	if cfg.APIBootstrapKey != "" {
+		if _, err := apiKeys.Import(reminders.DefaultOrgID, "bootstrap", cfg.APIBootstrapKey, []apikeys.Scope{apikeys.ScopeAdmin}, nil); err != nil {
+			logrus.Fatalf("Failed to load API_BOOTSTRAP_KEY: %v", err)
+		}
+	}
+	apiKeyAuth, err := apimw.NewAPIKeyAuth(apiKeys, auditLog, apimw.DefaultScopeRoutes, cfg.APIKeyMode)
+	if err != nil {
+		logrus.Fatalf("Failed to configure API keys: %v", err)
+	}
+	r.Use(apiKeyAuth.Middleware)
+	logrus.WithField("mode", cfg.APIKeyMode).Info("🔑 API key authentication configured")
+
 	// Custom middleware for logging request details
 	r.Use(func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -108,16 +537,41 @@ func main() {
 		})
 	})
 
-	// Setup routes
-	setupRoutes(r, publisher) // I will add db object here
+	// CMS webhooks mapped to targeted purges of the services serving the content
+	cmsHooks := cms.NewStore()
 
-	// Create HTTP server
+	// Provider callbacks about certificates, domain validation and purges
+	callbackSecrets, err := callbacks.ParseSecrets(cfg.ProviderCallbackSecrets)
+	if err != nil {
+		logrus.Fatalf("Failed to parse PROVIDER_CALLBACK_SECRETS: %v", err)
+	}
+	providerCallbacks := callbacks.NewReceiver(callbackSecrets)
+
+	// Setup routes
+	handlers.NewRouter(
+		handlers.NewHealthHandler(msgClient, cdnService),
+		handlers.NewCDNHandler(cdnService, flags, sandboxes, logWorker, ownershipStore, importer, ttlAdvisor, brandingStore, shareSigner, changeGuard, publisher, operationQueue),
+		handlers.NewOperationHandler(operationStore, operationQueue, planStorage, planScheduler, auditLog, publisher),
+		handlers.NewAccountHandler(cdnService, flags, sandboxes, usageTracker, auditLog, operationStore, reviewer, digester, artifactStore, complianceScanner, userStore),
+		handlers.NewOrgHandler(cdnService, flags, sandboxes, corsPolicy, brandingStore, vanity, shareSigner, widgetTokens),
+		handlers.NewChatHandler(publisher, sandboxes, transcriber, sessionRegistry, transcripts),
+		handlers.NewIntegrationHandler(cdnService, flags, sandboxes, cmsHooks, providerCallbacks, publisher),
+		handlers.NewWebhookHandler(webhookStore, webhookDispatcher),
+		handlers.NewAPIKeyHandler(apiKeys),
+	).Mount(r)
+
+	// Admin/ops listener: health, metrics, pprof and system insight on an internal port
+	adminSrv := newAdminServer(cfg,
+		handlers.NewOpsHandler(msgClient, cdnService, flags, elector, providerJournal, intentStats, operationDurations, watchdog, usageTracker),
+		handlers.NewAdminHandler(msgClient, planStorage, operationStore, ownershipStore, cdnService),
+	)
+
+	// Create HTTP server; read/write deadlines are set per route by routeLimits
 	srv := &http.Server{
-		Addr:         ":" + cfg.Port,
-		Handler:      r,
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		IdleTimeout:  60 * time.Second,
+		Addr:              ":" + cfg.Port,
+		Handler:           r,
+		ReadHeaderTimeout: 15 * time.Second,
+		IdleTimeout:       60 * time.Second,
 	}
 
 	// Start server in a goroutine
@@ -136,6 +590,15 @@ func main() {
 		}
 	}()
 
+	// Start admin server in a goroutine
+	go func() {
+		logrus.WithField("addr", cfg.AdminAddr).Info("🛠️ Admin listener started")
+
+		if err := adminSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logrus.Fatalf("Failed to start admin server: %v", err)
+		}
+	}()
+
 	// Wait for interrupt signal to gracefully shutdown
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -151,6 +614,9 @@ func main() {
 	if err := srv.Shutdown(ctx); err != nil {
 		logrus.Fatalf("Server forced to shutdown: %v", err)
 	}
+	if err := adminSrv.Shutdown(ctx); err != nil {
+		logrus.WithError(err).Warn("⚠️ Admin server forced to shutdown")
+	}
 
 	logrus.Info("✅ CDNBuddy API Server exited gracefully")
 }
@@ -182,82 +648,8 @@ func setupLogger(logLevel, environment string) {
 	}
 }
 
-// setupRoutes configures the API routes
-func setupRoutes(r chi.Router, publisher *messaging.Publisher) {
-	// Health check endpoint
-	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte(`{
-            "status": "healthy",
-            "service": "cdnbuddy-api",
-            "timestamp": "` + time.Now().UTC().Format(time.RFC3339) + `"
-        }`))
-	})
-
-	// API version 1 routes
-	r.Route("/api/v1", func(r chi.Router) {
-		// Health check
-		r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusOK)
-			w.Write([]byte(`{
-                "status": "healthy",
-                "version": "v1",
-                "service": "cdnbuddy-api"
-            }`))
-		})
-
-		// CDN services endpoints
-		r.Route("/cdn", func(r chi.Router) {
-			r.Get("/services", func(w http.ResponseWriter, r *http.Request) {
-				logrus.Info("📋 Listing CDN services")
-				w.Header().Set("Content-Type", "application/json")
-				w.WriteHeader(http.StatusOK)
-				w.Write([]byte(`{"services": [], "message": "CDN services endpoint ready"}`))
-			})
-
-			r.Post("/services", func(w http.ResponseWriter, r *http.Request) {
-				logrus.Info("➕ Creating CDN service")
-				w.Header().Set("Content-Type", "application/json")
-				w.WriteHeader(http.StatusCreated)
-				w.Write([]byte(`{"message": "CDN service creation endpoint ready"}`))
-			})
-
-			r.Get("/services/{serviceID}", func(w http.ResponseWriter, r *http.Request) {
-				serviceID := chi.URLParam(r, "serviceID")
-				logrus.WithField("service_id", serviceID).Info("📄 Getting CDN service details")
-				w.Header().Set("Content-Type", "application/json")
-				w.WriteHeader(http.StatusOK)
-				w.Write([]byte(`{"service_id": "` + serviceID + `", "message": "Service details endpoint ready"}`))
-			})
-		})
-
-		// Operations endpoints (for execution plans from AI)
-		r.Route("/operations", func(r chi.Router) {
-			r.Get("/{operationID}", func(w http.ResponseWriter, r *http.Request) {
-				operationID := chi.URLParam(r, "operationID")
-				logrus.WithField("operation_id", operationID).Info("📊 Getting operation status")
-				w.Header().Set("Content-Type", "application/json")
-				w.WriteHeader(http.StatusOK)
-				w.Write([]byte(`{"operation_id": "` + operationID + `", "status": "pending"}`))
-			})
-
-			r.Post("/{operationID}/execute", func(w http.ResponseWriter, r *http.Request) {
-				operationID := chi.URLParam(r, "operationID")
-				logrus.WithField("operation_id", operationID).Info("⚡ Executing operation")
-				w.Header().Set("Content-Type", "application/json")
-				w.WriteHeader(http.StatusAccepted)
-				w.Write([]byte(`{"operation_id": "` + operationID + `", "status": "executing"}`))
-			})
-		})
-	})
-
-	logrus.Info("✅ Routes configured")
-}
-
-// setupEventHandlers configures NATS event subscribers for AI Intent Service integration
-func setupEventHandlers(msgClient *messaging.Client, cdnService *cdn.Service, planStorage *planstorage.Storage) {
+// setupEventHandlers configures event subscribers for AI Intent Service integration
+func setupEventHandlers(msgClient *messaging.Client, cdnService *cdn.Service, flags *features.Flags, planStorage *planstorage.Storage, intentCache *intentcache.Cache, usageTracker *usage.Tracker, sandboxes *sandbox.Manager, auditLog *audit.Log, executePlan planExecutor, planScheduler *scheduler.Scheduler, digester *messaging.Digester, artifactStore *artifacts.Store, intentStats *intentstats.Tracker, transcripts *transcript.Store, sessionRegistry *sessions.Registry, ttlAdvisor *diagnostics.TTLAdvisor, widgetTokens *widget.Issuer, userStore *users.Store, operationQueue *operations.Queue, ownershipStore *ownership.Store) {
 	subscriber := msgClient.Subscriber()
 
 	// Handle AI Intent Service responses (execution plans)
@@ -281,31 +673,139 @@ func setupEventHandlers(msgClient *messaging.Client, cdnService *cdn.Service, pl
 		logrus.WithFields(logrus.Fields{
 			"user_id":    event.UserID,
 			"session_id": event.SessionID,
+			"source":     event.Source,
 		}).Info("💬 Chat message received")
 
-		// Request intent analysis
-		intentResponse, err := msgClient.RequestIntentAnalysis(
-			context.Background(),
-			event.SessionID,
-			event.Message,
-		)
-		if err != nil {
-			logrus.WithError(err).Error("❌ Failed to get response from intent service")
+		// Widget messages act for the org the token was issued to, and only
+		// from the page and user it was issued for
+		orgID := reminders.DefaultOrgID
+		var widgetOrgID string
+		if event.WidgetToken != "" {
+			claims, err := widgetTokens.Verify(event.WidgetToken, event.Origin)
+			if err == nil && claims.UserID != event.UserID {
+				err = widget.ErrInvalidToken
+			}
+			if err != nil {
+				logrus.WithError(err).WithFields(logrus.Fields{
+					"user_id": event.UserID,
+					"origin":  event.Origin,
+				}).Warn("🚫 Widget chat message rejected")
+				return msgClient.SendAIResponse(
+					context.Background(),
+					event.UserID,
+					event.SessionID,
+					"Your chat session has expired. Please reload the page to continue.",
+				)
+			}
+			orgID = claims.OrgID
+			widgetOrgID = claims.OrgID
+		}
+
+		// A session linked from another device continues that device's conversation
+		sessionRegistry.Touch(event.UserID, event.SessionID, event.Source)
+		conversationID := sessionRegistry.Conversation(event.SessionID)
 
-			// Send fallback message to user
+		// Demo visitors chat against their own sandbox tenant
+		svc, err := handlers.ResolveCDNService(sandboxes, cdnService, flags, orgID, event.SandboxID, "")
+		if err != nil {
 			return msgClient.SendAIResponse(
 				context.Background(),
 				event.UserID,
 				event.SessionID,
-				"I'm sorry, I'm having trouble processing your request right now. Please try again.",
+				"Your demo sandbox has expired. Please start a new one to keep exploring.",
 			)
 		}
 
+		// Answer repeated read-only questions of the same account from cache to
+		// skip the LLM round trip; the account state is only hashed when needed
+		cacheKey := intentcache.Key(orgID+"/"+event.SandboxID, event.Message)
+		stateHash := func() (string, error) { return svc.StateHash(context.Background()) }
+
+		intentResponse, cached := intentCache.Get(cacheKey, event.SessionID, stateHash)
+		if cached {
+			logrus.WithField("session_id", event.SessionID).Info("⚡ Serving intent response from cache")
+		} else {
+			// Enforce the user's daily AI quota before calling the LLM
+			if err := usageTracker.Reserve(orgID, event.UserID, event.SessionID); err != nil {
+				logrus.WithField("user_id", event.UserID).Warn("🚫 AI usage quota exceeded")
+				return msgClient.SendAIResponse(
+					context.Background(),
+					event.UserID,
+					event.SessionID,
+					"You've reached your daily AI assistant limit. Please try again tomorrow or upgrade your plan.",
+				)
+			}
+
+			// Offer what can be inferred from the account so the user isn't asked for it
+			intentContext, err := svc.InferParameters(context.Background())
+			if err != nil {
+				logrus.WithError(err).Warn("⚠️ Failed to infer intent parameters")
+			}
+			ttlAdvisor.Suggest(conversationID, intentContext)
+			userStore.Get(event.UserID).Defaults.Enrich(intentContext)
+
+			// Request intent analysis with the summary of a long chat instead of all of it
+			summary, history := transcripts.Context(conversationID)
+			requestStart := time.Now()
+			intentResponse, err = msgClient.RequestIntentAnalysis(context.Background(), models.IntentRequest{
+				SessionID:           conversationID,
+				UserMessage:         event.Message,
+				ConversationHistory: history,
+				Summary:             summary,
+				Context:             intentContext,
+			})
+			if err != nil {
+				logrus.WithError(err).Error("❌ Failed to get response from intent service")
+
+				// Send fallback message to user
+				return msgClient.SendAIResponse(
+					context.Background(),
+					event.UserID,
+					event.SessionID,
+					"I'm sorry, I'm having trouble processing your request right now. Please try again.",
+				)
+			}
+
+			// Account AI usage for the user session
+			var tokens int64
+			if intentResponse.Usage != nil {
+				tokens = intentResponse.Usage.TotalTokens
+			}
+			usageTracker.Record(orgID, event.UserID, event.SessionID, tokens, time.Since(requestStart))
+
+			intentCache.Put(cacheKey, intentResponse, stateHash)
+
+			if filled := intentContext.Fill(intentResponse); len(filled) > 0 {
+				logrus.WithFields(logrus.Fields{
+					"session_id": event.SessionID,
+					"parameters": filled,
+				}).Info("🧠 Filled missing parameters from account state")
+			}
+		}
+
 		logrus.WithFields(logrus.Fields{
 			"session_id": event.SessionID,
 			"status":     intentResponse.Status,
 			"action":     intentResponse.Action,
+			"cached":     cached,
 		}).Info("📥 Received response from intent service")
+		intentStats.Record(conversationID, intentResponse)
+
+		// Widget users only reach their own org's services, read or write
+		if widgetOrgID != "" && intentResponse.Status == "READY" && intentResponse.Action != nil {
+			if err := widget.CheckScope(ownershipStore, widgetOrgID, svc.ProviderOf(intentResponse.Parameters), intentResponse.Parameters); err != nil {
+				logrus.WithError(err).WithFields(logrus.Fields{
+					"org_id": widgetOrgID,
+					"action": *intentResponse.Action,
+				}).Warn("🚫 Widget intent outside the org's services rejected")
+				return msgClient.SendAIResponse(
+					context.Background(),
+					event.UserID,
+					event.SessionID,
+					"I can only help with your organization's own CDN services here. Please name one of them by its service ID.",
+				)
+			}
+		}
 
 		// Step 3: Handle the response based on status
 		var responseMessage string
@@ -334,6 +834,62 @@ func setupEventHandlers(msgClient *messaging.Client, cdnService *cdn.Service, pl
 			}).Info("🔍 Requesting more information from user")
 
 		case "READY":
+			// Attribution questions are answered from the audit log, no plan needed
+			if intentResponse.Action != nil && *intentResponse.Action == "WHO_CHANGED" {
+				responseMessage = auditLog.Attribution(
+					getIntentParam(intentResponse.Parameters, "domain"),
+					getIntentParam(intentResponse.Parameters, "setting"),
+				)
+				break
+			}
+
+			// Cache rule recommendations are a suggestion; applying them is a regular
+			// UPDATE_CACHE_RULES plan with the rules filled in from the suggestion
+			if intentResponse.Action != nil && *intentResponse.Action == "RECOMMEND_CACHE_RULES" {
+				report, err := ttlAdvisor.Recommend(context.Background(), svc, getIntentParam(intentResponse.Parameters, "service_id"), nil)
+				if err != nil {
+					logrus.WithError(err).Warn("⚠️ Failed to recommend cache rules")
+					responseMessage = fmt.Sprintf("❌ I couldn't inspect the origin: %v", err)
+					break
+				}
+				ttlAdvisor.Remember(conversationID, report)
+				responseMessage = report.Summary()
+				break
+			}
+
+			// Missing or invalid parameters go back to the intent service as a
+			// structured document, so it can ask the user for exactly those
+			if invalid := cdn.ValidateIntent(intentResponse); invalid != nil {
+				logrus.WithFields(logrus.Fields{
+					"session_id": event.SessionID,
+					"action":     invalid.Action,
+					"problems":   len(invalid.Problems),
+				}).Warn("⚠️ Intent parameters failed validation")
+				if err := msgClient.Publisher().PublishIntentValidation(messaging.IntentValidationEvent{
+					UserID:     event.UserID,
+					SessionID:  event.SessionID,
+					Action:     invalid.Action,
+					Parameters: intentResponse.Parameters,
+					Problems:   invalid.Problems,
+				}); err != nil {
+					logrus.WithError(err).Error("❌ Failed to publish intent validation error")
+				}
+				responseMessage = invalid.UserMessage()
+				break
+			}
+
+			// Destructive actions need the service name typed back before a plan is offered;
+			// ExecuteIntent checks the phrase itself as well
+			if intentResponse.Action != nil && cdn.IsDestructive(*intentResponse.Action) &&
+				getIntentParam(intentResponse.Parameters, cdn.ConfirmationParam) == "" {
+				target := getIntentParam(intentResponse.Parameters, "domain")
+				if target == "" {
+					target = getIntentParam(intentResponse.Parameters, "service_id")
+				}
+				responseMessage = fmt.Sprintf("⚠️ This can't be undone. Type the service name (%s) to confirm.", target)
+				break
+			}
+
 			// LLM has enough info - create execution plan (DON'T execute yet)
 			if intentResponse.Action != nil {
 				logrus.WithFields(logrus.Fields{
@@ -344,9 +900,37 @@ func setupEventHandlers(msgClient *messaging.Client, cdnService *cdn.Service, pl
 
 				// Build execution plan from intent response
 				plan := models.BuildExecutionPlan(intentResponse)
+				plan.SandboxID = event.SandboxID
+				plan.WidgetOrgID = widgetOrgID
+
+				// Cache rule changes carry a simulated preview of their effect
+				if plan.Action == "UPDATE_CACHE_RULES" {
+					preview, err := cdn.PreviewCacheRulesIntent(plan.Parameters)
+					if err != nil {
+						logrus.WithError(err).Warn("⚠️ Failed to simulate cache rules")
+					} else {
+						plan.Preview = preview
+					}
+				}
+
+				// Show exactly what would be sent to the provider
+				if dryRun, err := svc.DryRunIntent(context.Background(), intentResponse); err != nil {
+					logrus.WithError(err).Warn("⚠️ Failed to dry-run execution plan")
+				} else if dryRun != nil {
+					plan.Changes = dryRun
+				} else if plan.Action != "IMPORT_SERVICES" {
+					// Other actions are simulated against the provider's current state
+					if steps, _, err := svc.SimulateIntent(context.Background(), intentResponse); err != nil {
+						logrus.WithError(err).Warn("⚠️ Failed to simulate execution plan")
+					} else if len(steps) > 0 {
+						plan.Changes = steps
+					}
+				}
 
 				// Store plan for later execution
-				if err := planStorage.Store(plan); err != nil {
+				if err := planStorage.Store(plan); errors.Is(err, planstorage.ErrFull) {
+					responseMessage = "Too many plans are waiting for confirmation right now. Please try again in a few minutes."
+				} else if err != nil {
 					logrus.WithError(err).Error("❌ Failed to store execution plan")
 					responseMessage = "Sorry, I couldn't prepare the execution plan. Please try again."
 				} else {
@@ -359,6 +943,8 @@ func setupEventHandlers(msgClient *messaging.Client, cdnService *cdn.Service, pl
 						EstimatedDuration: plan.EstimatedDuration,
 						Action:            plan.Action,
 						Parameters:        plan.Parameters,
+						Preview:           plan.Preview,
+						Changes:           plan.Changes,
 						CreatedAt:         plan.CreatedAt,
 						ExpiresAt:         plan.ExpiresAt,
 					}
@@ -391,6 +977,9 @@ func setupEventHandlers(msgClient *messaging.Client, cdnService *cdn.Service, pl
 			responseMessage = intentResponse.UserMessage
 		}
 
+		transcripts.Append(conversationID, "user", event.Message)
+		transcripts.Append(conversationID, "assistant", responseMessage)
+
 		// Send the response back to the user
 		return msgClient.SendAIResponse(
 			context.Background(),
@@ -412,38 +1001,24 @@ func setupEventHandlers(msgClient *messaging.Client, cdnService *cdn.Service, pl
 			"user_id":      event.UserID,
 		}).Info("⚙️ CDN Operation event")
 
+		sessionID := event.SessionID
+		if sessionID == "" {
+			sessionID = "current_session"
+		}
+
+		// Progress is batched per session; start/complete/fail go out immediately
 		switch event.Type {
 		case messaging.EventOperationStarted:
-			return msgClient.SendAIResponse(
-				context.Background(),
-				event.UserID,
-				"current_session",
-				"🔄 Starting operation: "+event.OpType,
-			)
+			return digester.Immediate(event.UserID, sessionID, event.OperationID, "🔄 Starting operation: "+event.OpType)
 
 		case messaging.EventOperationProgress:
-			return msgClient.SendAIResponse(
-				context.Background(),
-				event.UserID,
-				"current_session",
-				"📊 Progress: "+event.Progress,
-			)
+			return digester.Progress(event.UserID, sessionID, event.OperationID, event.OpType, event.Progress)
 
 		case messaging.EventOperationCompleted:
-			return msgClient.SendAIResponse(
-				context.Background(),
-				event.UserID,
-				"current_session",
-				"✅ Operation completed successfully!",
-			)
+			return digester.Immediate(event.UserID, sessionID, event.OperationID, "✅ Operation completed successfully!")
 
 		case messaging.EventOperationFailed:
-			return msgClient.SendAIResponse(
-				context.Background(),
-				event.UserID,
-				"current_session",
-				"❌ Operation failed: "+event.Error,
-			)
+			return digester.Immediate(event.UserID, sessionID, event.OperationID, "❌ Operation failed: "+event.Error)
 		}
 		return nil
 	})
@@ -553,10 +1128,21 @@ func setupEventHandlers(msgClient *messaging.Client, cdnService *cdn.Service, pl
 			"user_id":    event.UserID,
 			"session_id": event.SessionID,
 		}).Info("📡 CDN status request received")
+		sessionRegistry.Touch(event.UserID, event.SessionID, "")
 
-		// Fetch real services from CacheFly
+		// Fetch real services from CacheFly (or the visitor's sandbox)
 		ctx := context.Background()
-		services, err := cdnService.ListServices(ctx)
+		svc, err := handlers.ResolveCDNService(sandboxes, cdnService, flags, reminders.DefaultOrgID, event.SandboxID, event.Provider)
+		if err != nil {
+			logrus.WithError(err).Warn("⚠️ Sandbox or provider not available for status request")
+			return msgClient.Publisher().PublishStatusResponse(event.UserID, event.SessionID, []messaging.ServiceStatus{})
+		}
+
+		status, err := cdn.ParseStatusFilter(event.Status)
+		if err != nil {
+			status = cdn.StatusActive
+		}
+		services, err := svc.ListServicesByStatus(ctx, status)
 		if err != nil {
 			logrus.WithError(err).Error("❌ Failed to fetch CDN services")
 			// Send empty response on error
@@ -593,7 +1179,8 @@ func setupEventHandlers(msgClient *messaging.Client, cdnService *cdn.Service, pl
 		logrus.WithError(err).Error("Failed to register status request handler")
 	}
 
-	// Subscribe to execution commands
+	// Subscribe to execution commands. Failures that running the command again
+	// can't fix are permanent; only an unavailable provider is retried.
 	err = subscriber.RegisterExecuteCommandHandler(func(cmd messaging.ExecuteCommand) error {
 		logrus.WithFields(logrus.Fields{
 			"user_id":    cmd.UserID,
@@ -601,12 +1188,21 @@ func setupEventHandlers(msgClient *messaging.Client, cdnService *cdn.Service, pl
 			"session_id": cmd.SessionID,
 		}).Info("🚀 Execute command received")
 
-		// Retrieve plan from storage
+		// An operation the API accepted fails with the command if it never runs
+		failAccepted := func(err error) {
+			if cmd.OperationID != "" {
+				operationQueue.Finish(cmd.OperationID, "", err)
+			}
+		}
+
+		// Retrieve plan from storage. Plans are kept in memory only, so a command
+		// redelivered after this replica restarted finds none and fails here.
 		plan, err := planStorage.Get(cmd.PlanID)
 		if err != nil {
 			logrus.WithError(err).Error("❌ Failed to retrieve execution plan")
 			msgClient.Publisher().PublishAIResponse(cmd.UserID, cmd.SessionID, "Execution plan not found or expired. Please create a new plan.")
-			return err
+			failAccepted(err)
+			return messaging.Permanent(err)
 		}
 
 		logrus.WithFields(logrus.Fields{
@@ -614,31 +1210,109 @@ func setupEventHandlers(msgClient *messaging.Client, cdnService *cdn.Service, pl
 			"action":  plan.Action,
 		}).Info("📋 Retrieved execution plan from storage")
 
+		// A plan runs only where it was made: a sandbox plan never reaches the
+		// real account, whatever sandbox_id the command carries
+		if err := plan.CheckSandbox(cmd.SandboxID); err != nil {
+			logrus.WithFields(logrus.Fields{
+				"plan_id":         plan.ID,
+				"plan_sandbox_id": plan.SandboxID,
+				"sandbox_id":      cmd.SandboxID,
+			}).Warn("⚠️ Execute command from another sandbox rejected")
+			msgClient.Publisher().PublishAIResponse(cmd.UserID, cmd.SessionID, "This plan was made in another session and can't be run from here.")
+			failAccepted(err)
+			return messaging.Permanent(err)
+		}
+
+		// A widget user's plan is checked again, in case the service changed hands
+		if plan.WidgetOrgID != "" {
+			if err := widget.CheckScope(ownershipStore, plan.WidgetOrgID, cdnService.ProviderOf(plan.Parameters), plan.Parameters); err != nil {
+				logrus.WithError(err).WithField("plan_id", plan.ID).Warn("🚫 Widget plan outside the org's services rejected")
+				msgClient.Publisher().PublishAIResponse(cmd.UserID, cmd.SessionID, "This plan reaches beyond your organization's services and can't be run.")
+				failAccepted(err)
+				return messaging.Permanent(err)
+			}
+		}
+
 		// Convert plan back to IntentResponse format for execution
-		intentResponse := plan.IntentResponse
-		if intentResponse == nil {
+		if plan.IntentResponse == nil {
 			logrus.Error("❌ Intent response is nil in stored plan")
 			msgClient.Publisher().PublishAIResponse(cmd.UserID, cmd.SessionID, "Execution plan is invalid.")
-			return fmt.Errorf("intent response is nil")
+			err := fmt.Errorf("intent response is nil")
+			failAccepted(err)
+			return messaging.Permanent(err)
+		}
+
+		// Confirmed for a maintenance window: hand the plan to the scheduler
+		if cmd.RunAt != "" {
+			runAt, err := scheduler.ParseRunAt(cmd.RunAt, time.Now())
+			if err != nil {
+				msgClient.Publisher().PublishAIResponse(cmd.UserID, cmd.SessionID, fmt.Sprintf("❌ %v", err))
+				failAccepted(err)
+				return messaging.Permanent(err)
+			}
+			entry := audit.EntryFromIntent(cmd.UserID, cmd.SessionID, plan.ID, plan.Action, plan.Parameters)
+			job, err := planScheduler.Schedule(scheduler.Job{
+				UserID:    cmd.UserID,
+				SessionID: cmd.SessionID,
+				SandboxID: plan.SandboxID,
+				ServiceID: entry.ServiceID,
+				Domain:    entry.Domain,
+				Plan:      plan,
+			}, runAt, time.Duration(cmd.WindowMinutes)*time.Minute)
+			if err != nil {
+				msgClient.Publisher().PublishAIResponse(cmd.UserID, cmd.SessionID, fmt.Sprintf("❌ Could not schedule the change: %v", err))
+				failAccepted(err)
+				return messaging.Permanent(err)
+			}
+
+			// The accepted operation ends with the scheduling; the run in the
+			// window is recorded as an operation of its own
+			if cmd.OperationID != "" {
+				operationQueue.Finish(cmd.OperationID, fmt.Sprintf("Scheduled for %s UTC (job %s)", job.RunAt.Format("2006-01-02 15:04"), job.ID), nil)
+			}
+			planStorage.Delete(cmd.PlanID)
+			msgClient.Publisher().PublishAIResponse(cmd.UserID, cmd.SessionID,
+				fmt.Sprintf("🗓️ Scheduled for %s UTC. I'll remind you beforehand; cancel any time before then (job %s).", job.RunAt.Format("2006-01-02 15:04"), job.ID))
+			return nil
 		}
 
 		// Execute the CDN operation
 		logrus.Info("🎯 Executing CDN operation")
-		result, err := cdnService.ExecuteIntent(context.Background(), intentResponse)
+		ctx := operations.WithID(cdn.WithChangeSource(context.Background(), cdn.SourceChat), cmd.OperationID)
+		result, err := executePlan(ctx, plan, cmd.UserID, cmd.SessionID, plan.SandboxID)
+		if errors.Is(err, errSandboxUnavailable) {
+			logrus.WithError(err).Warn("⚠️ Sandbox not available for execution")
+			msgClient.Publisher().PublishAIResponse(cmd.UserID, cmd.SessionID, "Your demo sandbox has expired. Please start a new one.")
+			return messaging.Permanent(err)
+		}
+		if errors.Is(err, cdn.ErrProviderUnavailable) {
+			logrus.WithError(err).Warn("⚠️ Provider unavailable, execution skipped")
+			msgClient.Publisher().PublishAIResponse(cmd.UserID, cmd.SessionID,
+				"⏳ The CDN provider is temporarily unavailable, so nothing was changed yet. I'll try again shortly.")
+			return err
+		}
 		if err != nil {
+			// Provider calls were already retried; running a half-applied plan
+			// again could repeat changes
 			logrus.WithError(err).Error("❌ Execution failed")
 			failureMsg := fmt.Sprintf("❌ Execution failed: %v", err)
 			msgClient.Publisher().PublishAIResponse(cmd.UserID, cmd.SessionID, failureMsg)
-			return err
+			return messaging.Permanent(err)
 		}
 
 		logrus.WithFields(logrus.Fields{
 			"result": result,
 		}).Info("✅ Execution completed successfully")
 
-		// Send success message
+		// Send success message, with tables/reports for actions that have them
 		successMsg := fmt.Sprintf("✅ %s", result)
-		msgClient.Publisher().PublishAIResponse(cmd.UserID, cmd.SessionID, successMsg)
+		attachments := resultAttachments(context.Background(), plan, artifactStore, func() (*cdn.Service, error) {
+			return handlers.ResolveCDNService(sandboxes, cdnService, flags, reminders.DefaultOrgID, plan.SandboxID, "")
+		})
+		if err := msgClient.SendAIResponseWithAttachments(context.Background(), cmd.UserID, cmd.SessionID, successMsg, attachments); err != nil {
+			logrus.WithError(err).Warn("⚠️ Failed to send attachments, sending plain response")
+			msgClient.Publisher().PublishAIResponse(cmd.UserID, cmd.SessionID, successMsg)
+		}
 
 		// Delete plan from storage after successful execution
 		planStorage.Delete(cmd.PlanID)
@@ -652,3 +1326,169 @@ func setupEventHandlers(msgClient *messaging.Client, cdnService *cdn.Service, pl
 
 	logrus.Info("✅ Event handlers configured for AI Intent Service integration")
 }
+
+// errSandboxUnavailable is returned by a planExecutor when the plan's sandbox has expired
+var errSandboxUnavailable = errors.New("sandbox not available")
+
+// planExecutor runs a confirmed plan, recording it as an operation and in the audit log
+type planExecutor func(ctx context.Context, plan *models.ExecutionPlan, userID, sessionID, sandboxID string) (string, error)
+
+func newPlanExecutor(cdnService *cdn.Service, flags *features.Flags, sandboxes *sandbox.Manager, auditLog *audit.Log, queue *operations.Queue, policies *operations.Policies, importer *ownership.Importer, vanity *dns.Vanity, notify func(userID, sessionID, message string) error) planExecutor {
+	return func(ctx context.Context, plan *models.ExecutionPlan, userID, sessionID, sandboxID string) (string, error) {
+		if plan.IntentResponse == nil {
+			return "", fmt.Errorf("intent response is nil")
+		}
+
+		svc, err := handlers.ResolveCDNService(sandboxes, cdnService, flags, reminders.DefaultOrgID, sandboxID, "")
+		if err != nil {
+			err = fmt.Errorf("%w: %v", errSandboxUnavailable, err)
+			if id := operations.IDFrom(ctx); id != "" {
+				queue.Finish(id, "", err)
+			}
+			return "", err
+		}
+
+		// Provider mutations are journaled under the plan ID
+		ctx = cdn.WithCorrelationID(ctx, plan.ID)
+
+		// Real setups point domains at the org's vanity hostnames; sandboxes
+		// and dry runs must not create DNS records
+		if sandboxID == "" && !plan.DryRun() {
+			ctx = cdn.WithCNAMETarget(ctx, vanity.Resolver(reminders.DefaultOrgID))
+		}
+
+		// Record who ran what for "who changed this setting" lookups
+		entry := audit.EntryFromIntent(userID, sessionID, plan.ID, plan.Action, plan.Parameters)
+
+		op := queue.Enqueue(operations.Operation{
+			ID:         operations.IDFrom(ctx), // accepted by the API, if it was
+			PlanID:     plan.ID,
+			UserID:     userID,
+			SessionID:  sessionID,
+			Action:     plan.Action,
+			Provider:   string(svc.ProviderOf(plan.Parameters)),
+			Title:      plan.Title,
+			ServiceID:  entry.ServiceID,
+			Domain:     entry.Domain,
+			Parameters: entry.Parameters,
+		})
+		if op.Status == operations.StatusCancelled {
+			return "", operations.ErrCancelled
+		}
+		defer queue.Done(op.ID)
+		ctx = queue.Cancellable(ctx, op.ID)
+
+		// Changes to the same service run one at a time; tell the user where they stand
+		if op.Status == operations.StatusQueued {
+			notify(userID, sessionID, fmt.Sprintf("⏳ Another change to this service is in progress. Yours is #%d in line, expected to start around %s and finish around %s.",
+				op.QueuePosition, op.EstimatedStart.UTC().Format("15:04:05 MST"), op.EstimatedFinish.UTC().Format("15:04:05 MST")))
+			if err := queue.Wait(ctx, op.ID); err != nil {
+				queue.Finish(op.ID, "", err)
+				return "", err
+			}
+		}
+		// Timeouts and retries depend on the action
+		result, err := operations.Run(ctx, plan.Action, policies.For(plan.Action), cdn.IsTransient, func(ctx context.Context) (string, error) {
+			if plan.Action == "IMPORT_SERVICES" {
+				return importer.ExecuteIntent(ctx, svc, reminders.DefaultOrgID, userID, plan.Parameters)
+			}
+			return svc.ExecuteIntent(ctx, plan.IntentResponse)
+		})
+		queue.Finish(op.ID, result, err)
+
+		// Dry runs change nothing, so they stay out of the audit log
+		if plan.DryRun() {
+			return result, err
+		}
+
+		entry.Success = err == nil
+		if err != nil {
+			entry.Error = err.Error()
+		}
+		auditLog.Record(entry)
+
+		return result, err
+	}
+}
+
+// newAdminServer builds the internal admin/ops listener. It runs on its own port
+// so it can be firewalled separately, with timeouts sized for profile captures.
+func newAdminServer(cfg *config.Config, ops *handlers.OpsHandler, insight *handlers.AdminHandler) *http.Server {
+	r := chi.NewRouter()
+	r.Use(middleware.Recoverer)
+
+	// Unauthenticated probes for orchestrators
+	ops.ProbeRoutes(r)
+
+	r.Group(func(r chi.Router) {
+		r.Use(admin.RequireToken(cfg.AdminToken))
+		ops.Routes(r)
+		insight.Routes(r)
+	})
+
+	// Metrics, pprof and admin APIs, protected by ADMIN_TOKEN
+	r.Mount("/", admin.NewRouter(cfg.AdminToken))
+
+	return &http.Server{
+		Addr:         cfg.AdminAddr,
+		Handler:      r,
+		ReadTimeout:  cfg.AdminReadTimeout,
+		WriteTimeout: cfg.AdminWriteTimeout,
+		IdleTimeout:  60 * time.Second,
+	}
+}
+
+// resultAttachments builds the structured attachments for an executed plan:
+// a table and CSV download for service listings, and the predicted effect of
+// cache rule changes. Failures only drop the attachment.
+func resultAttachments(ctx context.Context, plan *models.ExecutionPlan, artifactStore *artifacts.Store, service func() (*cdn.Service, error)) []messaging.Attachment {
+	attachments := make([]messaging.Attachment, 0)
+
+	switch plan.Action {
+	case "LIST_SERVICES":
+		svc, err := service()
+		if err != nil {
+			return attachments
+		}
+		services, err := svc.ListServices(ctx)
+		if err != nil {
+			logrus.WithError(err).Warn("⚠️ Failed to list services for attachment")
+			return attachments
+		}
+
+		columns := []string{"Name", "Provider", "Status", "ID"}
+		rows := make([][]string, 0, len(services))
+		for _, s := range services {
+			rows = append(rows, []string{s.Name, string(s.Provider), s.Status, s.ID})
+		}
+		attachments = append(attachments, messaging.NewTableAttachment("CDN services", columns, rows))
+
+		var buf bytes.Buffer
+		writer := csv.NewWriter(&buf)
+		writer.Write(columns)
+		writer.WriteAll(rows)
+		if artifact, err := artifactStore.Put("services.csv", "text/csv", buf.Bytes()); err == nil {
+			attachments = append(attachments, messaging.NewFileAttachment("Download services report", messaging.FileRef{
+				URL:         artifactStore.URL(artifact),
+				Filename:    artifact.Filename,
+				ContentType: artifact.ContentType,
+				Size:        len(artifact.Data),
+			}))
+		}
+
+	case "UPDATE_CACHE_RULES":
+		if plan.Preview != nil {
+			attachments = append(attachments, messaging.NewJSONAttachment("Predicted effect of the new rules", plan.Preview))
+		}
+	}
+
+	return attachments
+}
+
+// getIntentParam returns an intent parameter or "" if it is missing
+func getIntentParam(params map[string]*string, key string) string {
+	if val, ok := params[key]; ok && val != nil {
+		return *val
+	}
+	return ""
+}
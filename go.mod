@@ -6,19 +6,35 @@ require (
 	github.com/cachefly/cachefly-go-sdk v0.0.0-00010101000000-000000000000
 	github.com/google/uuid v1.6.0
 	github.com/joho/godotenv v1.5.1
+	github.com/nats-io/nats-server/v2 v2.11.6
 	github.com/nats-io/nats.go v1.43.0
+	github.com/redis/go-redis/v9 v9.11.0
+	github.com/segmentio/kafka-go v0.4.48
+	golang.org/x/sync v0.16.0
+	google.golang.org/protobuf v1.36.9
 )
 
 require github.com/sirupsen/logrus v1.9.3
 
+require (
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
+	github.com/google/go-tpm v0.9.5 // indirect
+	github.com/minio/highwayhash v1.0.3 // indirect
+	github.com/nats-io/jwt/v2 v2.7.4 // indirect
+	github.com/pierrec/lz4/v4 v4.1.15 // indirect
+	go.uber.org/automaxprocs v1.6.0 // indirect
+	golang.org/x/time v0.12.0 // indirect
+)
+
 require (
 	github.com/go-chi/chi/v5 v5.2.2
 	github.com/go-chi/cors v1.2.1
 	github.com/klauspost/compress v1.18.0 // indirect
 	github.com/nats-io/nkeys v0.4.11 // indirect
 	github.com/nats-io/nuid v1.0.1 // indirect
-	golang.org/x/crypto v0.37.0 // indirect
-	golang.org/x/sys v0.32.0 // indirect
+	golang.org/x/crypto v0.39.0 // indirect
+	golang.org/x/sys v0.33.0 // indirect
 )
 
 replace github.com/cachefly/cachefly-go-sdk => /var/repo/clients/cachefly/cachefly-go-sdk
@@ -0,0 +1,158 @@
+// Package admin serves operational endpoints (profiling, runtime stats, expvar)
+// that must never be reachable without the admin token.
+package admin
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"runtime/trace"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sirupsen/logrus"
+
+	"github.com/avvvet/cdnbuddy-api/internal/api/problem"
+)
+
+// maxTraceDuration caps on-demand execution trace captures
+const maxTraceDuration = 30 * time.Second
+
+// traceMu allows only one execution trace at a time (runtime/trace is process-wide)
+var traceMu sync.Mutex
+
+// NewRouter returns the admin router. Every route requires "Authorization: Bearer <token>";
+// with an empty token all routes answer 403 so admin endpoints are off by default.
+func NewRouter(token string) chi.Router {
+	r := chi.NewRouter()
+	r.Use(RequireToken(token))
+
+	// expvar counters and latencies from internal/metrics
+	r.Handle("/debug/vars", expvar.Handler())
+
+	// net/http/pprof
+	r.HandleFunc("/debug/pprof/", pprof.Index)
+	r.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	r.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	r.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	r.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	r.HandleFunc("/debug/pprof/{profile}", func(w http.ResponseWriter, r *http.Request) {
+		pprof.Handler(chi.URLParam(r, "profile")).ServeHTTP(w, r)
+	})
+
+	r.Get("/debug/runtime", handleRuntimeStats)
+	r.Get("/debug/trace", handleTraceCapture)
+
+	return r
+}
+
+// RequireToken rejects requests without the admin bearer token
+func RequireToken(token string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			provided := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if token == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+				logrus.WithFields(logrus.Fields{
+					"path":   r.URL.Path,
+					"remote": r.RemoteAddr,
+				}).Warn("🚫 Rejected admin request")
+				problem.Error(w, http.StatusForbidden, "", "admin token required")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RuntimeStats is a snapshot of goroutine, heap and GC statistics
+type RuntimeStats struct {
+	Goroutines     int       `json:"goroutines"`
+	HeapAllocBytes uint64    `json:"heap_alloc_bytes"`
+	HeapInuseBytes uint64    `json:"heap_inuse_bytes"`
+	HeapObjects    uint64    `json:"heap_objects"`
+	SysBytes       uint64    `json:"sys_bytes"`
+	NumGC          uint32    `json:"num_gc"`
+	LastGC         time.Time `json:"last_gc"`
+	PauseTotalMs   float64   `json:"gc_pause_total_ms"`
+	RecentPausesMs []float64 `json:"gc_recent_pauses_ms"` // newest first, up to 16
+	GCCPUFraction  float64   `json:"gc_cpu_fraction"`
+}
+
+func handleRuntimeStats(w http.ResponseWriter, r *http.Request) {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	stats := RuntimeStats{
+		Goroutines:     runtime.NumGoroutine(),
+		HeapAllocBytes: m.HeapAlloc,
+		HeapInuseBytes: m.HeapInuse,
+		HeapObjects:    m.HeapObjects,
+		SysBytes:       m.Sys,
+		NumGC:          m.NumGC,
+		PauseTotalMs:   float64(m.PauseTotalNs) / 1e6,
+		GCCPUFraction:  m.GCCPUFraction,
+	}
+	if m.LastGC > 0 {
+		stats.LastGC = time.Unix(0, int64(m.LastGC))
+	}
+
+	// PauseNs is a circular buffer; the most recent pause is at (NumGC+255)%256
+	for i := uint32(0); i < 16 && i < m.NumGC; i++ {
+		idx := (m.NumGC - 1 - i) % uint32(len(m.PauseNs))
+		stats.RecentPausesMs = append(stats.RecentPausesMs, float64(m.PauseNs[idx])/1e6)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
+
+// handleTraceCapture records an execution trace for ?seconds=N (default 5, max 30)
+// and returns it as a download for `go tool trace`
+func handleTraceCapture(w http.ResponseWriter, r *http.Request) {
+	duration := 5 * time.Second
+	if s := r.URL.Query().Get("seconds"); s != "" {
+		secs, err := strconv.Atoi(s)
+		if err != nil || secs <= 0 {
+			problem.Error(w, http.StatusBadRequest, "", "seconds must be a positive integer")
+			return
+		}
+		duration = time.Duration(secs) * time.Second
+	}
+	if duration > maxTraceDuration {
+		duration = maxTraceDuration
+	}
+
+	if !traceMu.TryLock() {
+		problem.Error(w, http.StatusConflict, "", "a trace capture is already running")
+		return
+	}
+	defer traceMu.Unlock()
+
+	// The capture outlives the default route timeout
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Now().Add(duration + 10*time.Second))
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="trace-%d.out"`, time.Now().Unix()))
+
+	if err := trace.Start(w); err != nil {
+		w.Header().Del("Content-Disposition")
+		problem.Error(w, http.StatusInternalServerError, "", fmt.Sprintf("failed to start trace: %v", err))
+		return
+	}
+
+	logrus.WithField("duration", duration).Info("🔬 Capturing execution trace")
+
+	select {
+	case <-time.After(duration):
+	case <-r.Context().Done():
+	}
+	trace.Stop()
+}
@@ -0,0 +1,345 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sirupsen/logrus"
+
+	"github.com/avvvet/cdnbuddy-api/internal/features"
+	"github.com/avvvet/cdnbuddy-api/internal/services/artifacts"
+	"github.com/avvvet/cdnbuddy-api/internal/services/audit"
+	"github.com/avvvet/cdnbuddy-api/internal/services/backup"
+	"github.com/avvvet/cdnbuddy-api/internal/services/cdn"
+	"github.com/avvvet/cdnbuddy-api/internal/services/compliance"
+	"github.com/avvvet/cdnbuddy-api/internal/services/messaging"
+	"github.com/avvvet/cdnbuddy-api/internal/services/operations"
+	"github.com/avvvet/cdnbuddy-api/internal/services/reminders"
+	"github.com/avvvet/cdnbuddy-api/internal/services/sandbox"
+	"github.com/avvvet/cdnbuddy-api/internal/services/search"
+	"github.com/avvvet/cdnbuddy-api/internal/services/usage"
+	"github.com/avvvet/cdnbuddy-api/internal/services/users"
+)
+
+// AccountHandler serves account-wide search, backups, usage, reminders,
+// compliance, artifacts, notification settings and user profiles
+type AccountHandler struct {
+	cdnService        *cdn.Service
+	flags             *features.Flags
+	sandboxes         *sandbox.Manager
+	usageTracker      *usage.Tracker
+	auditLog          *audit.Log
+	operationStore    *operations.Store
+	reviewer          *reminders.Reviewer
+	digester          *messaging.Digester
+	artifactStore     *artifacts.Store
+	complianceScanner *compliance.Scanner
+	userStore         *users.Store
+}
+
+// NewAccountHandler creates the handler
+func NewAccountHandler(cdnService *cdn.Service, flags *features.Flags, sandboxes *sandbox.Manager, usageTracker *usage.Tracker, auditLog *audit.Log, operationStore *operations.Store, reviewer *reminders.Reviewer, digester *messaging.Digester, artifactStore *artifacts.Store, complianceScanner *compliance.Scanner, userStore *users.Store) *AccountHandler {
+	return &AccountHandler{
+		cdnService:        cdnService,
+		flags:             flags,
+		sandboxes:         sandboxes,
+		usageTracker:      usageTracker,
+		auditLog:          auditLog,
+		operationStore:    operationStore,
+		reviewer:          reviewer,
+		digester:          digester,
+		artifactStore:     artifactStore,
+		complianceScanner: complianceScanner,
+		userStore:         userStore,
+	}
+}
+
+// Routes registers the handler's routes on the /api/v1 router
+func (h *AccountHandler) Routes(r chi.Router) {
+	// Account-wide search across services, domains, operations and audit entries
+	searcher := search.NewSearcher(h.operationStore, h.auditLog)
+	r.Get("/search", func(w http.ResponseWriter, r *http.Request) {
+		types, err := search.ParseTypes(r.URL.Query().Get("types"))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+		svc, err := ResolveCDNService(h.sandboxes, h.cdnService, h.flags, OrgIDFromQuery(r), r.URL.Query().Get("sandbox_id"), r.URL.Query().Get("provider"))
+		if err != nil {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+
+		resp, err := searcher.Search(r.Context(), svc, r.URL.Query().Get("q"), search.Options{Types: types, Limit: limit})
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		writeJSON(w, http.StatusOK, resp)
+	})
+
+	// Account export and restore for disaster recovery and account moves
+	backups := backup.NewManager(h.operationStore)
+	r.Get("/backup", func(w http.ResponseWriter, r *http.Request) {
+		svc, err := ResolveCDNService(h.sandboxes, h.cdnService, h.flags, OrgIDFromQuery(r), r.URL.Query().Get("sandbox_id"), r.URL.Query().Get("provider"))
+		if err != nil {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+
+		export, err := backups.Export(r.Context(), svc)
+		if err != nil {
+			logrus.WithError(err).Error("❌ Failed to export account")
+			if writeProviderUnavailable(w, err) {
+				return
+			}
+			writeError(w, http.StatusBadGateway, err.Error())
+			return
+		}
+
+		filename := fmt.Sprintf("cdnbuddy-backup-%s.json", export.CreatedAt.UTC().Format("20060102-150405"))
+		w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+		writeJSON(w, http.StatusOK, export)
+	})
+
+	r.Post("/restore", func(w http.ResponseWriter, r *http.Request) {
+		svc, err := ResolveCDNService(h.sandboxes, h.cdnService, h.flags, OrgIDFromQuery(r), r.URL.Query().Get("sandbox_id"), "")
+		if err != nil {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+
+		var export backup.Backup
+		if err := json.NewDecoder(r.Body).Decode(&export); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid backup file")
+			return
+		}
+
+		report, err := backups.Restore(r.Context(), svc, &export, backup.RestoreOptions{
+			UserID:       r.URL.Query().Get("user_id"),
+			Provider:     cdn.ParseProvider(r.URL.Query().Get("provider")),
+			DryRun:       r.URL.Query().Get("dry_run") == "true",
+			SkipExisting: r.URL.Query().Get("skip_existing") != "false",
+		})
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		writeJSON(w, http.StatusOK, report)
+	})
+
+	// AI usage endpoints
+	r.Get("/usage", func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := requireUserID(w, r)
+		if !ok {
+			return
+		}
+
+		// Only usage in the caller's org is visible
+		orgID := OrgIDFromQuery(r)
+		logrus.WithFields(logrus.Fields{"org_id": orgID, "user_id": userID}).Info("🧮 Getting AI usage")
+		writeJSON(w, http.StatusOK, h.usageTracker.Summary(orgID, userID))
+	})
+
+	// TTL review reminders
+	r.Route("/reminders", func(r chi.Router) {
+		r.Get("/settings", func(w http.ResponseWriter, r *http.Request) {
+			settings, err := h.reviewer.Settings(OrgIDFromQuery(r))
+			if err != nil {
+				writeError(w, http.StatusNotFound, "org not found")
+				return
+			}
+
+			writeJSON(w, http.StatusOK, settings)
+		})
+
+		r.Put("/settings", func(w http.ResponseWriter, r *http.Request) {
+			var settings reminders.Settings
+			if !decodeJSON(w, r, &settings) {
+				return
+			}
+
+			if err := h.reviewer.SetSettings(OrgIDFromQuery(r), settings); err != nil {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+
+			writeJSON(w, http.StatusOK, settings)
+		})
+
+		// Preview which services would get a reminder right now (no notifications sent)
+		r.Get("/preview", func(w http.ResponseWriter, r *http.Request) {
+			due, err := h.reviewer.ReviewOrg(r.Context(), OrgIDFromQuery(r), false)
+			if err != nil {
+				logrus.WithError(err).Error("❌ Failed to review TTLs")
+				writeError(w, http.StatusInternalServerError, "failed to review services")
+				return
+			}
+
+			writeJSON(w, http.StatusOK, map[string]interface{}{"reminders": due})
+		})
+	})
+
+	r.Route("/compliance", func(r chi.Router) {
+		// Violations found by the latest scan
+		r.Get("/report", func(w http.ResponseWriter, r *http.Request) {
+			report, err := h.complianceScanner.Report(OrgIDFromQuery(r))
+			if err != nil {
+				writeError(w, http.StatusNotFound, err.Error())
+				return
+			}
+
+			writeJSON(w, http.StatusOK, report)
+		})
+
+		// Scan now instead of waiting for the nightly run
+		r.Post("/scan", func(w http.ResponseWriter, r *http.Request) {
+			report, err := h.complianceScanner.ScanOrg(r.Context(), OrgIDFromQuery(r))
+			if err != nil {
+				logrus.WithError(err).Error("❌ Compliance scan failed")
+				if writeProviderUnavailable(w, err) {
+					return
+				}
+				writeError(w, http.StatusBadGateway, err.Error())
+				return
+			}
+
+			writeJSON(w, http.StatusOK, report)
+		})
+	})
+
+	// Downloadable reports linked from chat attachments
+	r.Get("/artifacts/{artifactID}", func(w http.ResponseWriter, r *http.Request) {
+		artifact, err := h.artifactStore.Get(chi.URLParam(r, "artifactID"))
+		if err != nil {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+
+		w.Header().Set("Content-Type", artifact.ContentType)
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", artifact.Filename))
+		w.WriteHeader(http.StatusOK)
+		w.Write(artifact.Data)
+	})
+
+	// The calling user's profile, notification preferences and setup
+	// defaults; the user is named by ?user_id=
+	r.Route("/me", func(r chi.Router) {
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+			userID, ok := requireUserID(w, r)
+			if !ok {
+				return
+			}
+			writeJSON(w, http.StatusOK, h.userStore.Get(userID))
+		})
+
+		r.Put("/", func(w http.ResponseWriter, r *http.Request) {
+			userID, ok := requireUserID(w, r)
+			if !ok {
+				return
+			}
+			var req users.Profile
+			if !decodeJSON(w, r, &req) {
+				return
+			}
+
+			profile, err := h.userStore.SetContact(userID, req)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			writeJSON(w, http.StatusOK, profile)
+		})
+
+		r.Put("/notifications", func(w http.ResponseWriter, r *http.Request) {
+			userID, ok := requireUserID(w, r)
+			if !ok {
+				return
+			}
+			var req users.Notifications
+			if !decodeJSON(w, r, &req) {
+				return
+			}
+			writeJSON(w, http.StatusOK, h.userStore.SetNotifications(userID, req))
+		})
+
+		r.Put("/defaults", func(w http.ResponseWriter, r *http.Request) {
+			userID, ok := requireUserID(w, r)
+			if !ok {
+				return
+			}
+			var req users.Defaults
+			if !decodeJSON(w, r, &req) {
+				return
+			}
+			if req.Provider != "" {
+				_, err := h.cdnService.ForProvider(cdn.ParseProvider(req.Provider))
+				var v validator
+				v.check("provider", err == nil, "is not a configured provider")
+				if v.failed(w) {
+					return
+				}
+			}
+
+			profile, err := h.userStore.SetDefaults(userID, req)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			logrus.WithFields(logrus.Fields{
+				"user_id":  userID,
+				"provider": profile.Defaults.Provider,
+				"profile":  profile.Defaults.Profile,
+			}).Info("👤 User defaults updated")
+			writeJSON(w, http.StatusOK, profile)
+		})
+	})
+
+	// Per-user batching of operation progress messages
+	r.Route("/notifications", func(r chi.Router) {
+		r.Get("/digest", func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, http.StatusOK, digestSettingsResponse(h.digester.Settings(r.URL.Query().Get("user_id"))))
+		})
+
+		r.Put("/digest", func(w http.ResponseWriter, r *http.Request) {
+			var req struct {
+				UserID   string `json:"user_id"`
+				Enabled  bool   `json:"enabled"`
+				Interval string `json:"interval"` // e.g. "30s"
+			}
+			if !decodeJSON(w, r, &req) {
+				return
+			}
+			var v validator
+			v.required("user_id", req.UserID)
+			if v.failed(w) {
+				return
+			}
+
+			settings := messaging.DigestSettings{Enabled: req.Enabled}
+			if req.Interval != "" {
+				interval, err := time.ParseDuration(req.Interval)
+				if err != nil {
+					writeError(w, http.StatusBadRequest, "invalid interval")
+					return
+				}
+				settings.Interval = interval
+			}
+
+			if err := h.digester.SetSettings(req.UserID, settings); err != nil {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+
+			writeJSON(w, http.StatusOK, digestSettingsResponse(settings))
+		})
+	})
+}
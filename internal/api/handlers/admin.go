@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/avvvet/cdnbuddy-api/internal/services/cdn"
+	"github.com/avvvet/cdnbuddy-api/internal/services/messaging"
+	"github.com/avvvet/cdnbuddy-api/internal/services/operations"
+	"github.com/avvvet/cdnbuddy-api/internal/services/ownership"
+	"github.com/avvvet/cdnbuddy-api/internal/services/planstorage"
+)
+
+// AdminHandler gives operators insight into the running system. Its routes
+// are served on the admin listener, behind ADMIN_TOKEN.
+type AdminHandler struct {
+	msgClient      *messaging.Client
+	planStorage    *planstorage.Storage
+	operationStore *operations.Store
+	ownershipStore *ownership.Store
+	cdnService     *cdn.Service
+}
+
+// NewAdminHandler creates the handler
+func NewAdminHandler(msgClient *messaging.Client, planStorage *planstorage.Storage, operationStore *operations.Store, ownershipStore *ownership.Store, cdnService *cdn.Service) *AdminHandler {
+	return &AdminHandler{
+		msgClient:      msgClient,
+		planStorage:    planStorage,
+		operationStore: operationStore,
+		ownershipStore: ownershipStore,
+		cdnService:     cdnService,
+	}
+}
+
+// tenantCount is the number of services one org manages
+type tenantCount struct {
+	OrgID    string `json:"org_id"`
+	Services int    `json:"services"`
+}
+
+// Routes registers the handler's routes; mount them behind admin.RequireToken
+func (h *AdminHandler) Routes(r chi.Router) {
+	r.Route("/admin", func(r chi.Router) {
+		// Connection state and traffic of the message bus
+		r.Get("/messaging", func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, http.StatusOK, h.msgClient.GetStats())
+		})
+
+		r.Get("/plans", func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, http.StatusOK, map[string]int{"pending": h.planStorage.Count()})
+		})
+
+		// Queued and running operations of every org
+		r.Get("/operations", func(w http.ResponseWriter, r *http.Request) {
+			inFlight := append(h.operationStore.Query(operations.Filter{Status: operations.StatusRunning}),
+				h.operationStore.Query(operations.Filter{Status: operations.StatusQueued})...)
+			running := len(inFlight)
+			for _, op := range inFlight {
+				if op.Status == operations.StatusQueued {
+					running--
+				}
+			}
+
+			ops, ok := paginate(w, r, inFlight, listSpec[operations.Operation]{
+				sorts: map[string]func(a, b operations.Operation) int{
+					"started_at": byTime(func(op operations.Operation) time.Time { return op.StartedAt }),
+					"action":     byString(func(op operations.Operation) string { return op.Action }),
+				},
+				status:   func(op operations.Operation) string { return string(op.Status) },
+				provider: func(op operations.Operation) string { return op.Provider },
+			})
+			if !ok {
+				return
+			}
+
+			writeJSON(w, http.StatusOK, map[string]interface{}{
+				"running":    running,
+				"queued":     len(inFlight) - running,
+				"operations": ops,
+			})
+		})
+
+		// Call counts and error rates per provider, with their circuit breakers
+		r.Get("/providers", func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, http.StatusOK, map[string]interface{}{
+				"calls":    cdn.ProviderCallStats(),
+				"breakers": h.cdnService.BreakerStates(),
+			})
+		})
+
+		// Managed services per org, most first
+		r.Get("/tenants", func(w http.ResponseWriter, r *http.Request) {
+			tenants := make([]tenantCount, 0)
+			for orgID, count := range h.ownershipStore.CountByOrg() {
+				tenants = append(tenants, tenantCount{OrgID: orgID, Services: count})
+			}
+			sort.Slice(tenants, func(i, j int) bool {
+				if tenants[i].Services != tenants[j].Services {
+					return tenants[i].Services > tenants[j].Services
+				}
+				return tenants[i].OrgID < tenants[j].OrgID
+			})
+
+			writeJSON(w, http.StatusOK, map[string]interface{}{"tenants": tenants})
+		})
+	})
+}
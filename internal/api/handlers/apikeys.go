@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sirupsen/logrus"
+
+	"github.com/avvvet/cdnbuddy-api/internal/api/problem"
+	"github.com/avvvet/cdnbuddy-api/internal/services/apikeys"
+)
+
+// APIKeyHandler manages an org's API keys
+type APIKeyHandler struct {
+	keys *apikeys.Store
+}
+
+// NewAPIKeyHandler creates the handler
+func NewAPIKeyHandler(keys *apikeys.Store) *APIKeyHandler {
+	return &APIKeyHandler{keys: keys}
+}
+
+// Routes registers the handler's routes on the /api/v1 router
+func (h *APIKeyHandler) Routes(r chi.Router) {
+	r.Route("/api-keys", func(r chi.Router) {
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+			keys, ok := paginate(w, r, h.keys.List(OrgIDFromQuery(r)), listSpec[apikeys.Key]{
+				sorts: map[string]func(a, b apikeys.Key) int{
+					"created_at": byTime(func(k apikeys.Key) time.Time { return k.CreatedAt }),
+					"name":       byString(func(k apikeys.Key) string { return k.Name }),
+				},
+				text: func(k apikeys.Key) []string { return []string{k.Name, k.Prefix} },
+			})
+			if !ok {
+				return
+			}
+
+			writeJSON(w, http.StatusOK, map[string]interface{}{
+				"api_keys": keys,
+			})
+		})
+
+		// The key is returned once; only its hash is kept. Only an admin key
+		// creates keys, for its own org, so none are minted anonymously.
+		r.Post("/", func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := apikeys.PrincipalFrom(r.Context())
+			if !ok || !principal.Allows(apikeys.ScopeAdmin) {
+				problem.Error(w, http.StatusForbidden, problem.CodeInsufficientScope, "creating API keys needs an admin API key")
+				return
+			}
+
+			var req struct {
+				Name      string     `json:"name"`
+				Scopes    []string   `json:"scopes"`
+				ExpiresAt *time.Time `json:"expires_at,omitempty"`
+			}
+			if !decodeJSON(w, r, &req) {
+				return
+			}
+
+			scopes, err := apikeys.ParseScopes(req.Scopes)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+
+			key, raw, err := h.keys.Create(principal.OrgID, req.Name, scopes, req.ExpiresAt)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+
+			logrus.WithFields(logrus.Fields{
+				"key_id": key.ID,
+				"org_id": key.OrgID,
+				"scopes": key.Scopes,
+			}).Info("🔑 API key created")
+			writeJSON(w, http.StatusCreated, map[string]interface{}{
+				"api_key": key,
+				"key":     raw,
+			})
+		})
+
+		r.Delete("/{keyID}", func(w http.ResponseWriter, r *http.Request) {
+			keyID := chi.URLParam(r, "keyID")
+			if err := h.keys.Revoke(OrgIDFromQuery(r), keyID); err != nil {
+				status := http.StatusBadRequest
+				if errors.Is(err, apikeys.ErrUnknownKey) {
+					status = http.StatusNotFound
+				}
+				writeError(w, status, err.Error())
+				return
+			}
+
+			logrus.WithField("key_id", keyID).Info("🔑 API key revoked")
+			w.WriteHeader(http.StatusNoContent)
+		})
+	})
+}
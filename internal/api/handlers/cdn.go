@@ -0,0 +1,1547 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sirupsen/logrus"
+
+	"github.com/avvvet/cdnbuddy-api/internal/domain"
+	"github.com/avvvet/cdnbuddy-api/internal/features"
+	"github.com/avvvet/cdnbuddy-api/internal/services/branding"
+	"github.com/avvvet/cdnbuddy-api/internal/services/cdn"
+	"github.com/avvvet/cdnbuddy-api/internal/services/diagnostics"
+	"github.com/avvvet/cdnbuddy-api/internal/services/logingest"
+	"github.com/avvvet/cdnbuddy-api/internal/services/messaging"
+	"github.com/avvvet/cdnbuddy-api/internal/services/operations"
+	"github.com/avvvet/cdnbuddy-api/internal/services/ownership"
+	"github.com/avvvet/cdnbuddy-api/internal/services/sandbox"
+	"github.com/avvvet/cdnbuddy-api/internal/services/share"
+)
+
+// CDNHandler serves CDN service configuration, exports and diagnostics
+type CDNHandler struct {
+	cdnService     *cdn.Service
+	flags          *features.Flags
+	sandboxes      *sandbox.Manager
+	logWorker      *logingest.Worker
+	ownershipStore *ownership.Store
+	importer       *ownership.Importer
+	ttlAdvisor     *diagnostics.TTLAdvisor
+	brandingStore  *branding.Store
+	shareSigner    *share.Signer
+	changeGuard    *cdn.ChangeGuard
+	publisher      *messaging.Publisher
+	operationQueue *operations.Queue
+	varyTester     *diagnostics.Tester
+}
+
+// NewCDNHandler creates the handler
+func NewCDNHandler(cdnService *cdn.Service, flags *features.Flags, sandboxes *sandbox.Manager, logWorker *logingest.Worker, ownershipStore *ownership.Store, importer *ownership.Importer, ttlAdvisor *diagnostics.TTLAdvisor, brandingStore *branding.Store, shareSigner *share.Signer, changeGuard *cdn.ChangeGuard, publisher *messaging.Publisher, operationQueue *operations.Queue) *CDNHandler {
+	return &CDNHandler{
+		cdnService:     cdnService,
+		flags:          flags,
+		sandboxes:      sandboxes,
+		logWorker:      logWorker,
+		ownershipStore: ownershipStore,
+		importer:       importer,
+		ttlAdvisor:     ttlAdvisor,
+		brandingStore:  brandingStore,
+		shareSigner:    shareSigner,
+		changeGuard:    changeGuard,
+		publisher:      publisher,
+		operationQueue: operationQueue,
+		varyTester:     diagnostics.NewTester(),
+	}
+}
+
+// Routes registers the handler's routes on the /api/v1 router
+func (h *CDNHandler) Routes(r chi.Router) {
+	// CDN services endpoints
+	r.Route("/cdn", func(r chi.Router) {
+		// ?status=ACTIVE (default), INACTIVE or ALL, plus the list convention in paging.go
+		r.Get("/services", func(w http.ResponseWriter, r *http.Request) {
+			logrus.Info("📋 Listing CDN services")
+			services, status, ok := h.listServices(w, r)
+			if !ok {
+				return
+			}
+
+			writeJSONWithETag(w, r, map[string]interface{}{
+				"services": services,
+				"status":   status,
+			})
+		})
+
+		r.Post("/services/{serviceID}/reactivate", func(w http.ResponseWriter, r *http.Request) {
+			serviceID := chi.URLParam(r, "serviceID")
+			svc, err := ResolveCDNService(h.sandboxes, h.cdnService, h.flags, OrgIDFromQuery(r), r.URL.Query().Get("sandbox_id"), r.URL.Query().Get("provider"))
+			if err != nil {
+				writeError(w, http.StatusNotFound, err.Error())
+				return
+			}
+
+			svc, sim := simulateIfDryRun(svc, r)
+			if err := svc.ReactivateService(r.Context(), serviceID); err != nil {
+				writeCDNError(w, serviceID, err)
+				return
+			}
+			if sim != nil {
+				writeDryRun(w, sim, map[string]string{"service_id": serviceID, "status": "ACTIVE"})
+				return
+			}
+
+			logrus.WithField("service_id", serviceID).Info("♻️ Service reactivated")
+			writeJSON(w, http.StatusOK, map[string]string{"service_id": serviceID, "status": "ACTIVE"})
+		})
+
+		// Copy a service's configuration into a new one, e.g. staging -> production
+		r.Post("/services/{serviceID}/clone", func(w http.ResponseWriter, r *http.Request) {
+			serviceID := chi.URLParam(r, "serviceID")
+			svc, err := ResolveCDNService(h.sandboxes, h.cdnService, h.flags, OrgIDFromQuery(r), r.URL.Query().Get("sandbox_id"), r.URL.Query().Get("provider"))
+			if err != nil {
+				writeError(w, http.StatusNotFound, err.Error())
+				return
+			}
+
+			var req struct {
+				Name    string   `json:"name"`
+				Domains []string `json:"domains,omitempty"` // added to the clone; the source keeps its own
+			}
+			if !decodeJSON(w, r, &req) {
+				return
+			}
+			var v validator
+			v.required("name", req.Name)
+			v.domains("domains", req.Domains)
+			if v.failed(w) {
+				return
+			}
+
+			svc, sim := simulateIfDryRun(svc, r)
+			result, err := svc.CloneService(r.Context(), serviceID, req.Name, req.Domains)
+			if err != nil {
+				writeCDNError(w, serviceID, err)
+				return
+			}
+			if sim != nil {
+				writeDryRun(w, sim, result)
+				return
+			}
+
+			logrus.WithFields(logrus.Fields{
+				"source_id":  serviceID,
+				"service_id": result.Service.ID,
+				"copied":     result.Copied,
+			}).Info("🧬 Service cloned")
+			writeJSON(w, http.StatusCreated, result)
+		})
+
+		// Expiring public link to the service's status and recent metrics
+		r.Post("/services/{serviceID}/share", func(w http.ResponseWriter, r *http.Request) {
+			serviceID := chi.URLParam(r, "serviceID")
+			svc, err := ResolveCDNService(h.sandboxes, h.cdnService, h.flags, OrgIDFromQuery(r), "", r.URL.Query().Get("provider"))
+			if err != nil {
+				writeError(w, http.StatusNotFound, err.Error())
+				return
+			}
+
+			var req struct {
+				TTL string `json:"ttl,omitempty"` // e.g. "72h"; default SHARE_LINK_TTL
+			}
+			var ttl time.Duration
+			if r.ContentLength != 0 {
+				if !decodeJSON(w, r, &req) {
+					return
+				}
+			}
+			if req.TTL != "" {
+				if ttl, err = time.ParseDuration(req.TTL); err != nil {
+					writeError(w, http.StatusBadRequest, "invalid ttl")
+					return
+				}
+			}
+
+			service, err := svc.GetService(r.Context(), serviceID)
+			if err != nil {
+				writeCDNError(w, serviceID, err)
+				return
+			}
+			link, err := h.shareSigner.Issue(OrgIDFromQuery(r), service.ID, service.Provider, ttl)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+
+			logrus.WithFields(logrus.Fields{
+				"service_id": serviceID,
+				"link_id":    link.ID,
+				"expires_at": link.ExpiresAt,
+			}).Info("🔗 Share link issued")
+			writeJSON(w, http.StatusCreated, link)
+		})
+
+		// The options a service had before its last update, which a rollback restores
+		r.Get("/services/{serviceID}/rollback", func(w http.ResponseWriter, r *http.Request) {
+			serviceID := chi.URLParam(r, "serviceID")
+			svc, err := ResolveCDNService(h.sandboxes, h.cdnService, h.flags, OrgIDFromQuery(r), r.URL.Query().Get("sandbox_id"), r.URL.Query().Get("provider"))
+			if err != nil {
+				writeError(w, http.StatusNotFound, err.Error())
+				return
+			}
+
+			change, err := svc.LastConfigChange(r.Context(), serviceID)
+			if err != nil {
+				writeCDNError(w, serviceID, err)
+				return
+			}
+
+			writeJSON(w, http.StatusOK, change)
+		})
+
+		r.Post("/services/{serviceID}/rollback", func(w http.ResponseWriter, r *http.Request) {
+			serviceID := chi.URLParam(r, "serviceID")
+			svc, err := ResolveCDNService(h.sandboxes, h.cdnService, h.flags, OrgIDFromQuery(r), r.URL.Query().Get("sandbox_id"), r.URL.Query().Get("provider"))
+			if err != nil {
+				writeError(w, http.StatusNotFound, err.Error())
+				return
+			}
+
+			svc, sim := simulateIfDryRun(svc, r)
+			change, err := svc.RollbackConfig(r.Context(), serviceID)
+			if err != nil {
+				writeCDNError(w, serviceID, err)
+				return
+			}
+			if sim != nil {
+				writeDryRun(w, sim, change)
+				return
+			}
+
+			logrus.WithFields(logrus.Fields{
+				"service_id": serviceID,
+				"operation":  change.Operation,
+				"changed_at": change.ChangedAt,
+			}).Info("↩️ Configuration rolled back")
+			writeJSON(w, http.StatusOK, change)
+		})
+
+		// Purge specific paths, or everything with {"purge_all": true}
+		r.Post("/services/{serviceID}/purge", func(w http.ResponseWriter, r *http.Request) {
+			serviceID := chi.URLParam(r, "serviceID")
+			svc, err := ResolveCDNService(h.sandboxes, h.cdnService, h.flags, OrgIDFromQuery(r), r.URL.Query().Get("sandbox_id"), r.URL.Query().Get("provider"))
+			if err != nil {
+				writeError(w, http.StatusNotFound, err.Error())
+				return
+			}
+
+			var req purgeRequest
+			if !decodeJSON(w, r, &req) {
+				return
+			}
+			var v validator
+			req.validate(&v)
+			if v.failed(w) {
+				return
+			}
+
+			svc, sim := simulateIfDryRun(svc, r)
+			paths := []string{"/*"}
+			if req.PurgeAll {
+				err = svc.PurgeAll(r.Context(), serviceID)
+			} else {
+				paths, err = svc.PurgeCache(r.Context(), serviceID, req.Paths)
+			}
+			if err != nil {
+				writeCDNError(w, serviceID, err)
+				return
+			}
+			result := map[string]interface{}{
+				"service_id": serviceID,
+				"paths":      paths,
+				"purge_all":  req.PurgeAll,
+			}
+			if sim != nil {
+				writeDryRun(w, sim, result)
+				return
+			}
+
+			if err := h.publisher.PublishCachePurged(serviceID, req.UserID, paths); err != nil {
+				logrus.WithError(err).WithField("service_id", serviceID).Error("❌ Failed to publish cache purge event")
+			}
+
+			logrus.WithFields(logrus.Fields{
+				"service_id": serviceID,
+				"paths":      len(paths),
+				"purge_all":  req.PurgeAll,
+			}).Info("🧹 Cache purged")
+			writeJSON(w, http.StatusOK, result)
+		})
+
+		// Services whose automated changes were paused for changing an option too often
+		r.Get("/change-guard", func(w http.ResponseWriter, r *http.Request) {
+			pauses, ok := paginate(w, r, h.changeGuard.Pauses(), listSpec[cdn.Pause]{
+				sorts: map[string]func(a, b cdn.Pause) int{
+					"paused_at": byTime(func(p cdn.Pause) time.Time { return p.PausedAt }),
+					"option":    byString(func(p cdn.Pause) string { return p.Option }),
+				},
+				text: func(p cdn.Pause) []string { return []string{p.ServiceID, p.Option} },
+			})
+			if !ok {
+				return
+			}
+
+			writeJSON(w, http.StatusOK, map[string]interface{}{
+				"paused": pauses,
+			})
+		})
+
+		// Acknowledge a pause once the automation behind it is fixed
+		r.Post("/services/{serviceID}/change-guard/ack", func(w http.ResponseWriter, r *http.Request) {
+			serviceID := chi.URLParam(r, "serviceID")
+			pause, ok := h.changeGuard.Acknowledge(serviceID)
+			if !ok {
+				writeError(w, http.StatusNotFound, "automated changes to this service are not paused")
+				return
+			}
+
+			logrus.WithFields(logrus.Fields{
+				"service_id": serviceID,
+				"option":     pause.Option,
+			}).Info("▶️ Automated changes resumed")
+			writeJSON(w, http.StatusOK, map[string]interface{}{
+				"resumed": true,
+				"pause":   pause,
+			})
+		})
+
+		r.Post("/services", func(w http.ResponseWriter, r *http.Request) {
+			logrus.Info("➕ Creating CDN service")
+			writeJSON(w, http.StatusCreated, map[string]string{"message": "CDN service creation endpoint ready"})
+		})
+
+		// Discover services that already exist in the provider account and
+		// adopt selected ones; ?provider= limits discovery to one provider
+		r.Get("/import", func(w http.ResponseWriter, r *http.Request) {
+			svc, err := ResolveCDNService(h.sandboxes, h.cdnService, h.flags, OrgIDFromQuery(r), "", r.URL.Query().Get("provider"))
+			if err != nil {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+
+			candidates, err := h.importer.Discover(r.Context(), svc)
+			if err != nil {
+				writeCDNError(w, "", err)
+				return
+			}
+
+			writeJSON(w, http.StatusOK, map[string]interface{}{"services": candidates})
+		})
+
+		r.Post("/import", func(w http.ResponseWriter, r *http.Request) {
+			svc, err := ResolveCDNService(h.sandboxes, h.cdnService, h.flags, OrgIDFromQuery(r), "", r.URL.Query().Get("provider"))
+			if err != nil {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+
+			var req struct {
+				UserID     string   `json:"user_id"`
+				ServiceIDs []string `json:"service_ids"`
+			}
+			if !decodeJSON(w, r, &req) {
+				return
+			}
+			var v validator
+			v.check("service_ids", len(req.ServiceIDs) > 0, "is required")
+			if v.failed(w) {
+				return
+			}
+
+			result, err := h.importer.Adopt(r.Context(), svc, OrgIDFromQuery(r), req.UserID, req.ServiceIDs)
+			if err != nil {
+				writeCDNError(w, "", err)
+				return
+			}
+
+			writeJSON(w, http.StatusOK, result)
+		})
+
+		r.Get("/managed", func(w http.ResponseWriter, r *http.Request) {
+			records, ok := paginate(w, r, h.ownershipStore.List(OrgIDFromQuery(r)), listSpec[ownership.Record]{
+				sorts: map[string]func(a, b ownership.Record) int{
+					"name":       byString(func(rec ownership.Record) string { return rec.Name }),
+					"provider":   byString(func(rec ownership.Record) string { return string(rec.Provider) }),
+					"adopted_at": byTime(func(rec ownership.Record) time.Time { return rec.AdoptedAt }),
+				},
+				provider: func(rec ownership.Record) string { return string(rec.Provider) },
+				text:     func(rec ownership.Record) []string { return []string{rec.Name, rec.ServiceID} },
+			})
+			if !ok {
+				return
+			}
+
+			writeJSON(w, http.StatusOK, map[string]interface{}{
+				"org_id":   OrgIDFromQuery(r),
+				"services": records,
+			})
+		})
+
+		// Declarative desired state: create or update a service until it
+		// matches the spec; ?dry_run=true only reports the changes
+		r.Put("/apply", func(w http.ResponseWriter, r *http.Request) {
+			svc, err := ResolveCDNService(h.sandboxes, h.cdnService, h.flags, OrgIDFromQuery(r), r.URL.Query().Get("sandbox_id"), r.URL.Query().Get("provider"))
+			if err != nil {
+				writeError(w, http.StatusNotFound, err.Error())
+				return
+			}
+
+			var spec cdn.ServiceSpec
+			if !decodeJSON(w, r, &spec) {
+				return
+			}
+			var v validator
+			v.check("name", spec.Name != "" || spec.ServiceID != "", "name or service_id is required")
+			v.origin("origin", spec.Origin)
+			v.domains("domains", spec.Domains)
+			v.cacheRules("rules", spec.Rules)
+			if v.failed(w) {
+				return
+			}
+
+			apply := svc.Apply
+			if r.URL.Query().Get("dry_run") == "true" {
+				apply = svc.PlanApply
+			}
+			result, err := apply(r.Context(), spec)
+			if err != nil {
+				writeCDNError(w, spec.ServiceID, err)
+				return
+			}
+
+			logrus.WithFields(logrus.Fields{
+				"service_id": result.ServiceID,
+				"created":    result.Created,
+				"changed":    result.Changed(),
+				"dry_run":    result.DryRun,
+			}).Info("📐 Applied service spec")
+			writeJSON(w, http.StatusOK, result)
+		})
+
+		// Configured providers; pass ?provider= to other endpoints to pick one
+		r.Get("/providers", func(w http.ResponseWriter, r *http.Request) {
+			providers := h.cdnService.Providers()
+			writeJSON(w, http.StatusOK, map[string]interface{}{
+				"providers": providers,
+				"modes":     h.flags.ProviderModes(OrgIDFromQuery(r), providers),
+				"breakers":  h.cdnService.BreakerStates(),
+			})
+		})
+
+		// Plan, quota usage, service counts and incidents per connected account
+		r.Get("/account", func(w http.ResponseWriter, r *http.Request) {
+			svc, err := ResolveCDNService(h.sandboxes, h.cdnService, h.flags, OrgIDFromQuery(r), r.URL.Query().Get("sandbox_id"), r.URL.Query().Get("provider"))
+			if err != nil {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+
+			summaries, err := svc.GetAccountSummaries(r.Context())
+			if err != nil {
+				writeCDNError(w, "", err)
+				return
+			}
+
+			writeJSON(w, http.StatusOK, map[string]interface{}{"accounts": summaries})
+		})
+
+		// Workload profiles selectable when creating a service
+		r.Get("/profiles", func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, http.StatusOK, map[string]interface{}{
+				"default":  cdn.DefaultProfile,
+				"profiles": cdn.ProfileDescriptions(),
+			})
+		})
+
+		r.Get("/overview", func(w http.ResponseWriter, r *http.Request) {
+			logrus.Info("🗺️ Building account overview")
+			svc, err := ResolveCDNService(h.sandboxes, h.cdnService, h.flags, OrgIDFromQuery(r), "", r.URL.Query().Get("provider"))
+			if err != nil {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+
+			overview, err := svc.GetAccountOverview(r.Context())
+			if err != nil {
+				logrus.WithError(err).Error("❌ Failed to build account overview")
+				if writeProviderUnavailable(w, err) {
+					return
+				}
+				writeError(w, http.StatusBadGateway, "failed to fetch services from provider")
+				return
+			}
+
+			writeJSON(w, http.StatusOK, overview)
+		})
+
+		// Service and domain reads carry an ETag and answer If-None-Match with 304
+		r.Get("/services/{serviceID}", func(w http.ResponseWriter, r *http.Request) {
+			serviceID := chi.URLParam(r, "serviceID")
+			logrus.WithField("service_id", serviceID).Info("📄 Getting CDN service details")
+			svc, err := ResolveCDNService(h.sandboxes, h.cdnService, h.flags, OrgIDFromQuery(r), r.URL.Query().Get("sandbox_id"), r.URL.Query().Get("provider"))
+			if err != nil {
+				writeError(w, http.StatusNotFound, err.Error())
+				return
+			}
+
+			service, err := svc.GetService(r.Context(), serviceID)
+			if err != nil {
+				writeCDNError(w, serviceID, err)
+				return
+			}
+
+			writeJSONWithETag(w, r, service)
+		})
+
+		r.Get("/services/{serviceID}/domains", func(w http.ResponseWriter, r *http.Request) {
+			serviceID := chi.URLParam(r, "serviceID")
+			svc, err := ResolveCDNService(h.sandboxes, h.cdnService, h.flags, OrgIDFromQuery(r), r.URL.Query().Get("sandbox_id"), r.URL.Query().Get("provider"))
+			if err != nil {
+				writeError(w, http.StatusNotFound, err.Error())
+				return
+			}
+
+			service, err := svc.GetService(r.Context(), serviceID)
+			if err != nil {
+				writeCDNError(w, serviceID, err)
+				return
+			}
+			domains, err := svc.ListServiceDomains(r.Context(), *service)
+			if err != nil {
+				writeCDNError(w, serviceID, err)
+				return
+			}
+
+			writeJSONWithETag(w, r, map[string]interface{}{
+				"service_id": serviceID,
+				"domains":    domains,
+			})
+		})
+
+		// Whether a domain's DNS points at the service yet
+		r.Get("/services/{serviceID}/domains/{domain}/dns-check", func(w http.ResponseWriter, r *http.Request) {
+			serviceID, domainName := chi.URLParam(r, "serviceID"), strings.ToLower(chi.URLParam(r, "domain"))
+			svc, err := ResolveCDNService(h.sandboxes, h.cdnService, h.flags, OrgIDFromQuery(r), r.URL.Query().Get("sandbox_id"), r.URL.Query().Get("provider"))
+			if err != nil {
+				writeError(w, http.StatusNotFound, err.Error())
+				return
+			}
+
+			service, err := svc.GetService(r.Context(), serviceID)
+			if err != nil {
+				writeCDNError(w, serviceID, err)
+				return
+			}
+			domains, err := svc.ListServiceDomains(r.Context(), *service)
+			if err != nil {
+				writeCDNError(w, serviceID, err)
+				return
+			}
+			if !slices.ContainsFunc(domains, func(d domain.Domain) bool { return strings.EqualFold(d.Name, domainName) }) {
+				writeError(w, http.StatusNotFound, fmt.Sprintf("domain %s is not on service %s", domainName, serviceID))
+				return
+			}
+
+			check := h.varyTester.CheckDNS(r.Context(), domainName, cdn.ProviderCNAMETarget(*service))
+			logrus.WithFields(logrus.Fields{
+				"service_id": serviceID,
+				"domain":     domainName,
+				"status":     check.Status,
+			}).Info("🧭 DNS check completed")
+			writeJSON(w, http.StatusOK, check)
+		})
+
+		// One request through the CDN to the service's test URL, optionally
+		// for {"path": "/some/asset"}: is the CDN actually serving it?
+		r.Post("/services/{serviceID}/probe", func(w http.ResponseWriter, r *http.Request) {
+			serviceID := chi.URLParam(r, "serviceID")
+			svc, err := ResolveCDNService(h.sandboxes, h.cdnService, h.flags, OrgIDFromQuery(r), r.URL.Query().Get("sandbox_id"), r.URL.Query().Get("provider"))
+			if err != nil {
+				writeError(w, http.StatusNotFound, err.Error())
+				return
+			}
+
+			var req struct {
+				Path string `json:"path"`
+			}
+			if r.ContentLength > 0 && !decodeJSON(w, r, &req) {
+				return
+			}
+			if req.Path != "" {
+				var v validator
+				v.path("path", req.Path)
+				if v.failed(w) {
+					return
+				}
+			}
+
+			service, err := svc.GetService(r.Context(), serviceID)
+			if err != nil {
+				writeCDNError(w, serviceID, err)
+				return
+			}
+
+			result := h.varyTester.Probe(r.Context(), cdn.ProviderTestURL(*service)+req.Path)
+			writeJSON(w, http.StatusOK, result)
+		})
+
+		// Fetch URLs through the CDN so edges cache them after a purge or
+		// deploy: "urls" are paths of the service's test URL or absolute URLs
+		// on it or the service's domains, "sitemap_url" adds a sitemap's pages.
+		// Runs as an operation, after other changes to the service, publishing
+		// progress events.
+		r.Post("/services/{serviceID}/prewarm", func(w http.ResponseWriter, r *http.Request) {
+			serviceID := chi.URLParam(r, "serviceID")
+			svc, err := ResolveCDNService(h.sandboxes, h.cdnService, h.flags, OrgIDFromQuery(r), r.URL.Query().Get("sandbox_id"), r.URL.Query().Get("provider"))
+			if err != nil {
+				writeError(w, http.StatusNotFound, err.Error())
+				return
+			}
+
+			var req struct {
+				URLs       []string `json:"urls"`
+				SitemapURL string   `json:"sitemap_url"`
+				UserID     string   `json:"user_id"`
+				SessionID  string   `json:"session_id"`
+			}
+			if !decodeJSON(w, r, &req) {
+				return
+			}
+			var v validator
+			v.check("urls", len(req.URLs) > 0 || req.SitemapURL != "", "urls or sitemap_url is required")
+			v.check("urls", len(req.URLs) <= diagnostics.MaxPrewarmURLs, fmt.Sprintf("at most %d URLs can be warmed at once", diagnostics.MaxPrewarmURLs))
+			if v.failed(w) {
+				return
+			}
+
+			service, err := svc.GetService(r.Context(), serviceID)
+			if err != nil {
+				writeCDNError(w, serviceID, err)
+				return
+			}
+
+			// Only the service's own hosts are fetched, the sitemap included
+			domains, err := svc.ListServiceDomains(r.Context(), *service)
+			if err != nil {
+				writeCDNError(w, serviceID, err)
+				return
+			}
+			hosts := make([]string, 0, len(domains))
+			for _, d := range domains {
+				hosts = append(hosts, d.Name)
+			}
+			base := cdn.ProviderTestURL(*service)
+
+			urls := req.URLs
+			if limit := diagnostics.MaxPrewarmURLs - len(urls); req.SitemapURL != "" && limit > 0 {
+				sitemap, err := diagnostics.PrewarmURLs(base, hosts, []string{req.SitemapURL})
+				if err != nil {
+					writeError(w, http.StatusBadRequest, err.Error())
+					return
+				}
+				pages, err := h.varyTester.FetchSitemap(r.Context(), sitemap[0], limit)
+				if err != nil {
+					writeError(w, http.StatusBadRequest, err.Error())
+					return
+				}
+				urls = append(urls, pages...)
+			}
+			urls, err = diagnostics.PrewarmURLs(base, hosts, urls)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+
+			op := h.operationQueue.Enqueue(operations.Operation{
+				UserID:     req.UserID,
+				SessionID:  req.SessionID,
+				Action:     "PREWARM_CACHE",
+				Provider:   string(service.Provider),
+				Title:      fmt.Sprintf("Prewarm %d URLs", len(urls)),
+				ServiceID:  serviceID,
+				Parameters: map[string]string{"urls": strconv.Itoa(len(urls))},
+			})
+			go h.runPrewarm(op, urls)
+
+			logrus.WithFields(logrus.Fields{
+				"service_id":   serviceID,
+				"operation_id": op.ID,
+				"urls":         len(urls),
+			}).Info("🔥 Cache prewarm started")
+			writeJSON(w, http.StatusAccepted, op)
+		})
+
+		// Portable copy of a service's configuration: a spec accepted by
+		// PUT /apply, or ?format=terraform for HCL
+		r.Get("/services/{serviceID}/export", func(w http.ResponseWriter, r *http.Request) {
+			serviceID := chi.URLParam(r, "serviceID")
+			svc, err := ResolveCDNService(h.sandboxes, h.cdnService, h.flags, OrgIDFromQuery(r), r.URL.Query().Get("sandbox_id"), r.URL.Query().Get("provider"))
+			if err != nil {
+				writeError(w, http.StatusNotFound, err.Error())
+				return
+			}
+
+			export, err := svc.ExportService(r.Context(), serviceID)
+			if err != nil {
+				writeCDNError(w, serviceID, err)
+				return
+			}
+
+			switch r.URL.Query().Get("format") {
+			case "", "json":
+				writeJSON(w, http.StatusOK, export)
+			case "terraform", "hcl":
+				w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+				w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="cdnbuddy-%s.tf"`, serviceID))
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(h.brandingStore.Rebrand(OrgIDFromQuery(r), cdn.RenderTerraform(export))))
+			default:
+				writeError(w, http.StatusBadRequest, "format must be json or terraform")
+			}
+		})
+
+		// Cache key customization (query params, vary headers/cookies, device split)
+		r.Get("/cache-key/support", func(w http.ResponseWriter, r *http.Request) {
+			svc, err := ResolveCDNService(h.sandboxes, h.cdnService, h.flags, OrgIDFromQuery(r), r.URL.Query().Get("sandbox_id"), r.URL.Query().Get("provider"))
+			if err != nil {
+				writeError(w, http.StatusNotFound, err.Error())
+				return
+			}
+
+			writeJSON(w, http.StatusOK, svc.CacheKeySupport())
+		})
+
+		r.Get("/services/{serviceID}/cache-key", func(w http.ResponseWriter, r *http.Request) {
+			serviceID := chi.URLParam(r, "serviceID")
+			svc, err := ResolveCDNService(h.sandboxes, h.cdnService, h.flags, OrgIDFromQuery(r), r.URL.Query().Get("sandbox_id"), r.URL.Query().Get("provider"))
+			if err != nil {
+				writeError(w, http.StatusNotFound, err.Error())
+				return
+			}
+
+			config, err := svc.GetCacheKey(r.Context(), serviceID)
+			if err != nil {
+				writeCDNError(w, serviceID, err)
+				return
+			}
+
+			writeJSON(w, http.StatusOK, config)
+		})
+
+		r.Put("/services/{serviceID}/cache-key", func(w http.ResponseWriter, r *http.Request) {
+			serviceID := chi.URLParam(r, "serviceID")
+			svc, err := ResolveCDNService(h.sandboxes, h.cdnService, h.flags, OrgIDFromQuery(r), r.URL.Query().Get("sandbox_id"), r.URL.Query().Get("provider"))
+			if err != nil {
+				writeError(w, http.StatusNotFound, err.Error())
+				return
+			}
+
+			var config cdn.CacheKeyConfig
+			if !decodeJSON(w, r, &config) {
+				return
+			}
+
+			svc, sim := simulateIfDryRun(svc, r)
+			if err := svc.UpdateCacheKey(r.Context(), serviceID, config); err != nil {
+				writeCDNError(w, serviceID, err)
+				return
+			}
+			if sim != nil {
+				writeDryRun(w, sim, config)
+				return
+			}
+
+			logrus.WithField("service_id", serviceID).Info("🔑 Updated cache key configuration")
+			writeJSON(w, http.StatusOK, config)
+		})
+
+		// Stale-while-revalidate / stale-if-error policy, service-wide
+		r.Get("/services/{serviceID}/stale-policy", func(w http.ResponseWriter, r *http.Request) {
+			serviceID := chi.URLParam(r, "serviceID")
+			svc, err := ResolveCDNService(h.sandboxes, h.cdnService, h.flags, OrgIDFromQuery(r), r.URL.Query().Get("sandbox_id"), r.URL.Query().Get("provider"))
+			if err != nil {
+				writeError(w, http.StatusNotFound, err.Error())
+				return
+			}
+
+			policy, err := svc.GetStalePolicy(r.Context(), serviceID)
+			if err != nil {
+				writeCDNError(w, serviceID, err)
+				return
+			}
+
+			writeJSON(w, http.StatusOK, map[string]interface{}{
+				"policy":      policy,
+				"explanation": policy.Explain(),
+			})
+		})
+
+		r.Put("/services/{serviceID}/stale-policy", func(w http.ResponseWriter, r *http.Request) {
+			serviceID := chi.URLParam(r, "serviceID")
+			svc, err := ResolveCDNService(h.sandboxes, h.cdnService, h.flags, OrgIDFromQuery(r), r.URL.Query().Get("sandbox_id"), r.URL.Query().Get("provider"))
+			if err != nil {
+				writeError(w, http.StatusNotFound, err.Error())
+				return
+			}
+
+			var policy cdn.StalePolicy
+			if !decodeJSON(w, r, &policy) {
+				return
+			}
+
+			svc, sim := simulateIfDryRun(svc, r)
+			if err := svc.UpdateStalePolicy(r.Context(), serviceID, policy); err != nil {
+				writeCDNError(w, serviceID, err)
+				return
+			}
+			if sim != nil {
+				writeDryRun(w, sim, map[string]interface{}{
+					"policy":      policy,
+					"explanation": policy.Explain(),
+				})
+				return
+			}
+
+			logrus.WithField("service_id", serviceID).Info("🕰️ Updated stale content policy")
+			writeJSON(w, http.StatusOK, map[string]interface{}{
+				"policy":      policy,
+				"explanation": policy.Explain(),
+			})
+		})
+
+		// Origin shield and request coalescing
+		r.Get("/services/{serviceID}/origin-load", func(w http.ResponseWriter, r *http.Request) {
+			serviceID := chi.URLParam(r, "serviceID")
+			svc, err := ResolveCDNService(h.sandboxes, h.cdnService, h.flags, OrgIDFromQuery(r), r.URL.Query().Get("sandbox_id"), r.URL.Query().Get("provider"))
+			if err != nil {
+				writeError(w, http.StatusNotFound, err.Error())
+				return
+			}
+
+			options, err := svc.GetOriginLoad(r.Context(), serviceID)
+			if err != nil {
+				writeCDNError(w, serviceID, err)
+				return
+			}
+
+			writeJSON(w, http.StatusOK, map[string]interface{}{
+				"options": options,
+				"support": svc.OriginLoadSupport(),
+			})
+		})
+
+		r.Put("/services/{serviceID}/origin-load", func(w http.ResponseWriter, r *http.Request) {
+			serviceID := chi.URLParam(r, "serviceID")
+			svc, err := ResolveCDNService(h.sandboxes, h.cdnService, h.flags, OrgIDFromQuery(r), r.URL.Query().Get("sandbox_id"), r.URL.Query().Get("provider"))
+			if err != nil {
+				writeError(w, http.StatusNotFound, err.Error())
+				return
+			}
+
+			var options cdn.OriginLoadOptions
+			if !decodeJSON(w, r, &options) {
+				return
+			}
+
+			svc, sim := simulateIfDryRun(svc, r)
+			if err := svc.UpdateOriginLoad(r.Context(), serviceID, options); err != nil {
+				writeCDNError(w, serviceID, err)
+				return
+			}
+			if sim != nil {
+				writeDryRun(w, sim, options)
+				return
+			}
+
+			logrus.WithField("service_id", serviceID).Info("🛡️ Updated origin shield settings")
+			writeJSON(w, http.StatusOK, options)
+		})
+
+		// Firewall / WAF rules
+		r.Get("/services/{serviceID}/security", func(w http.ResponseWriter, r *http.Request) {
+			serviceID := chi.URLParam(r, "serviceID")
+			svc, err := ResolveCDNService(h.sandboxes, h.cdnService, h.flags, OrgIDFromQuery(r), r.URL.Query().Get("sandbox_id"), r.URL.Query().Get("provider"))
+			if err != nil {
+				writeError(w, http.StatusNotFound, err.Error())
+				return
+			}
+
+			config, err := svc.GetFirewall(r.Context(), serviceID)
+			if err != nil {
+				writeCDNError(w, serviceID, err)
+				return
+			}
+
+			writeJSON(w, http.StatusOK, map[string]interface{}{
+				"firewall": config,
+				"support":  svc.FirewallSupport(),
+			})
+		})
+
+		r.Put("/services/{serviceID}/security", func(w http.ResponseWriter, r *http.Request) {
+			serviceID := chi.URLParam(r, "serviceID")
+			svc, err := ResolveCDNService(h.sandboxes, h.cdnService, h.flags, OrgIDFromQuery(r), r.URL.Query().Get("sandbox_id"), r.URL.Query().Get("provider"))
+			if err != nil {
+				writeError(w, http.StatusNotFound, err.Error())
+				return
+			}
+
+			var config cdn.FirewallConfig
+			if !decodeJSON(w, r, &config) {
+				return
+			}
+
+			svc, sim := simulateIfDryRun(svc, r)
+			if err := svc.UpdateFirewall(r.Context(), serviceID, config); err != nil {
+				writeCDNError(w, serviceID, err)
+				return
+			}
+			if sim != nil {
+				writeDryRun(w, sim, config)
+				return
+			}
+
+			logrus.WithField("service_id", serviceID).Info("🛡️ Updated firewall settings")
+			writeJSON(w, http.StatusOK, config)
+		})
+
+		// Hotlink protection (referrer rules)
+		r.Get("/services/{serviceID}/hotlink", func(w http.ResponseWriter, r *http.Request) {
+			serviceID := chi.URLParam(r, "serviceID")
+			svc, err := ResolveCDNService(h.sandboxes, h.cdnService, h.flags, OrgIDFromQuery(r), r.URL.Query().Get("sandbox_id"), r.URL.Query().Get("provider"))
+			if err != nil {
+				writeError(w, http.StatusNotFound, err.Error())
+				return
+			}
+
+			config, err := svc.GetHotlinkProtection(r.Context(), serviceID)
+			if err != nil {
+				writeCDNError(w, serviceID, err)
+				return
+			}
+
+			writeJSON(w, http.StatusOK, config)
+		})
+
+		r.Put("/services/{serviceID}/hotlink", func(w http.ResponseWriter, r *http.Request) {
+			serviceID := chi.URLParam(r, "serviceID")
+			svc, err := ResolveCDNService(h.sandboxes, h.cdnService, h.flags, OrgIDFromQuery(r), r.URL.Query().Get("sandbox_id"), r.URL.Query().Get("provider"))
+			if err != nil {
+				writeError(w, http.StatusNotFound, err.Error())
+				return
+			}
+
+			var config cdn.HotlinkConfig
+			if !decodeJSON(w, r, &config) {
+				return
+			}
+
+			svc, sim := simulateIfDryRun(svc, r)
+			if err := svc.UpdateHotlinkProtection(r.Context(), serviceID, config); err != nil {
+				writeCDNError(w, serviceID, err)
+				return
+			}
+			if sim != nil {
+				writeDryRun(w, sim, config)
+				return
+			}
+
+			logrus.WithField("service_id", serviceID).Info("🔒 Updated hotlink protection")
+			writeJSON(w, http.StatusOK, config)
+		})
+
+		// Custom and security response headers
+		r.Get("/services/{serviceID}/headers", func(w http.ResponseWriter, r *http.Request) {
+			serviceID := chi.URLParam(r, "serviceID")
+			svc, err := ResolveCDNService(h.sandboxes, h.cdnService, h.flags, OrgIDFromQuery(r), r.URL.Query().Get("sandbox_id"), r.URL.Query().Get("provider"))
+			if err != nil {
+				writeError(w, http.StatusNotFound, err.Error())
+				return
+			}
+
+			headers, err := svc.GetResponseHeaders(r.Context(), serviceID)
+			if err != nil {
+				writeCDNError(w, serviceID, err)
+				return
+			}
+
+			writeJSON(w, http.StatusOK, map[string]interface{}{"headers": headers})
+		})
+
+		r.Put("/services/{serviceID}/headers", func(w http.ResponseWriter, r *http.Request) {
+			serviceID := chi.URLParam(r, "serviceID")
+			svc, err := ResolveCDNService(h.sandboxes, h.cdnService, h.flags, OrgIDFromQuery(r), r.URL.Query().Get("sandbox_id"), r.URL.Query().Get("provider"))
+			if err != nil {
+				writeError(w, http.StatusNotFound, err.Error())
+				return
+			}
+
+			var req struct {
+				Headers []cdn.ResponseHeader `json:"headers"`
+			}
+			if !decodeJSON(w, r, &req) {
+				return
+			}
+
+			svc, sim := simulateIfDryRun(svc, r)
+			if err := svc.UpdateResponseHeaders(r.Context(), serviceID, req.Headers); err != nil {
+				writeCDNError(w, serviceID, err)
+				return
+			}
+			if sim != nil {
+				writeDryRun(w, sim, req)
+				return
+			}
+
+			logrus.WithField("service_id", serviceID).Info("📨 Updated response headers")
+			writeJSON(w, http.StatusOK, req)
+		})
+
+		// Minimum TLS version and HTTP/2, HTTP/3 toggles
+		r.Get("/services/{serviceID}/tls", func(w http.ResponseWriter, r *http.Request) {
+			serviceID := chi.URLParam(r, "serviceID")
+			svc, err := ResolveCDNService(h.sandboxes, h.cdnService, h.flags, OrgIDFromQuery(r), r.URL.Query().Get("sandbox_id"), r.URL.Query().Get("provider"))
+			if err != nil {
+				writeError(w, http.StatusNotFound, err.Error())
+				return
+			}
+
+			policy, err := svc.GetTLSPolicy(r.Context(), serviceID)
+			if err != nil {
+				writeCDNError(w, serviceID, err)
+				return
+			}
+
+			writeJSON(w, http.StatusOK, map[string]interface{}{
+				"tls":     policy,
+				"support": svc.TLSSupport(),
+			})
+		})
+
+		r.Put("/services/{serviceID}/tls", func(w http.ResponseWriter, r *http.Request) {
+			serviceID := chi.URLParam(r, "serviceID")
+			svc, err := ResolveCDNService(h.sandboxes, h.cdnService, h.flags, OrgIDFromQuery(r), r.URL.Query().Get("sandbox_id"), r.URL.Query().Get("provider"))
+			if err != nil {
+				writeError(w, http.StatusNotFound, err.Error())
+				return
+			}
+
+			var policy cdn.TLSPolicy
+			if !decodeJSON(w, r, &policy) {
+				return
+			}
+
+			svc, sim := simulateIfDryRun(svc, r)
+			if err := svc.UpdateTLSPolicy(r.Context(), serviceID, policy); err != nil {
+				writeCDNError(w, serviceID, err)
+				return
+			}
+			if sim != nil {
+				writeDryRun(w, sim, policy)
+				return
+			}
+
+			logrus.WithField("service_id", serviceID).Info("🔐 Updated TLS policy")
+			writeJSON(w, http.StatusOK, policy)
+		})
+
+		// Custom certificate as PEM, either JSON {"certificate", "private_key"}
+		// or a multipart form with certificate and private_key files or fields
+		r.Post("/services/{serviceID}/certificates", func(w http.ResponseWriter, r *http.Request) {
+			serviceID := chi.URLParam(r, "serviceID")
+			svc, err := ResolveCDNService(h.sandboxes, h.cdnService, h.flags, OrgIDFromQuery(r), r.URL.Query().Get("sandbox_id"), r.URL.Query().Get("provider"))
+			if err != nil {
+				writeError(w, http.StatusNotFound, err.Error())
+				return
+			}
+
+			var cert cdn.Certificate
+			r.Body = http.MaxBytesReader(w, r.Body, maxCertificateBytes)
+			if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+				if err := r.ParseMultipartForm(maxCertificateBytes); err != nil {
+					writeError(w, http.StatusBadRequest, "invalid upload: expected a multipart form with certificate and private_key")
+					return
+				}
+				defer r.MultipartForm.RemoveAll()
+				cert.CertificatePEM, cert.PrivateKeyPEM = formText(r, "certificate"), formText(r, "private_key")
+			} else if !decodeJSON(w, r, &cert) {
+				return
+			}
+			var v validator
+			v.required("certificate", cert.CertificatePEM)
+			v.required("private_key", cert.PrivateKeyPEM)
+			if v.failed(w) {
+				return
+			}
+
+			svc, sim := simulateIfDryRun(svc, r)
+			info, err := svc.UploadCertificate(r.Context(), serviceID, cert)
+			if err != nil {
+				writeCDNError(w, serviceID, err)
+				return
+			}
+			if sim != nil {
+				writeDryRun(w, sim, info)
+				return
+			}
+
+			logrus.WithFields(logrus.Fields{
+				"service_id":  serviceID,
+				"fingerprint": info.Fingerprint,
+				"not_after":   info.NotAfter,
+			}).Info("📜 Certificate uploaded")
+			writeJSON(w, http.StatusCreated, info)
+		})
+
+		// Cache rules recommended from the origin's caching headers;
+		// ?paths=/,/app.js samples those paths instead of the home page and its assets
+		r.Get("/services/{serviceID}/ttl-recommendations", func(w http.ResponseWriter, r *http.Request) {
+			serviceID := chi.URLParam(r, "serviceID")
+			svc, err := ResolveCDNService(h.sandboxes, h.cdnService, h.flags, OrgIDFromQuery(r), r.URL.Query().Get("sandbox_id"), r.URL.Query().Get("provider"))
+			if err != nil {
+				writeError(w, http.StatusNotFound, err.Error())
+				return
+			}
+
+			var paths []string
+			if raw := r.URL.Query().Get("paths"); raw != "" {
+				paths = strings.Split(raw, ",")
+			}
+
+			report, err := h.ttlAdvisor.Recommend(r.Context(), svc, serviceID, paths)
+			if err != nil {
+				writeCDNError(w, serviceID, err)
+				return
+			}
+
+			writeJSON(w, http.StatusOK, report)
+		})
+
+		// Cache rules, each optionally with its own stale policy
+		r.Put("/services/{serviceID}/cache-rules", func(w http.ResponseWriter, r *http.Request) {
+			serviceID := chi.URLParam(r, "serviceID")
+			svc, err := ResolveCDNService(h.sandboxes, h.cdnService, h.flags, OrgIDFromQuery(r), r.URL.Query().Get("sandbox_id"), r.URL.Query().Get("provider"))
+			if err != nil {
+				writeError(w, http.StatusNotFound, err.Error())
+				return
+			}
+
+			var req struct {
+				Rules []cdn.CacheRule `json:"rules"`
+			}
+			if !decodeJSON(w, r, &req) {
+				return
+			}
+			var v validator
+			v.cacheRules("rules", req.Rules)
+			if v.failed(w) {
+				return
+			}
+
+			// ?dry_run=true returns the provider requests without sending them
+			if r.URL.Query().Get("dry_run") == "true" {
+				dryRun, err := svc.DryRunCacheRules(r.Context(), serviceID, req.Rules)
+				if err != nil {
+					writeCDNError(w, serviceID, err)
+					return
+				}
+
+				writeJSON(w, http.StatusOK, dryRun)
+				return
+			}
+
+			if err := svc.UpdateCacheRules(r.Context(), serviceID, req.Rules); err != nil {
+				writeCDNError(w, serviceID, err)
+				return
+			}
+
+			logrus.WithField("service_id", serviceID).Info("📏 Updated cache rules")
+			writeJSON(w, http.StatusOK, req)
+		})
+
+		// Access log delivery and the analytics computed from ingested logs
+		r.Get("/services/{serviceID}/log-delivery", func(w http.ResponseWriter, r *http.Request) {
+			serviceID := chi.URLParam(r, "serviceID")
+			svc, err := ResolveCDNService(h.sandboxes, h.cdnService, h.flags, OrgIDFromQuery(r), r.URL.Query().Get("sandbox_id"), r.URL.Query().Get("provider"))
+			if err != nil {
+				writeError(w, http.StatusNotFound, err.Error())
+				return
+			}
+
+			delivery, err := svc.GetLogDelivery(r.Context(), serviceID)
+			if err != nil {
+				writeCDNError(w, serviceID, err)
+				return
+			}
+
+			writeJSON(w, http.StatusOK, delivery)
+		})
+
+		r.Put("/services/{serviceID}/log-delivery", func(w http.ResponseWriter, r *http.Request) {
+			serviceID := chi.URLParam(r, "serviceID")
+			svc, err := ResolveCDNService(h.sandboxes, h.cdnService, h.flags, OrgIDFromQuery(r), r.URL.Query().Get("sandbox_id"), r.URL.Query().Get("provider"))
+			if err != nil {
+				writeError(w, http.StatusNotFound, err.Error())
+				return
+			}
+
+			var delivery cdn.LogDelivery
+			if !decodeJSON(w, r, &delivery) {
+				return
+			}
+
+			svc, sim := simulateIfDryRun(svc, r)
+			if err := svc.UpdateLogDelivery(r.Context(), serviceID, delivery); err != nil {
+				writeCDNError(w, serviceID, err)
+				return
+			}
+			if sim != nil {
+				writeDryRun(w, sim, delivery)
+				return
+			}
+
+			logrus.WithField("service_id", serviceID).Info("🪵 Updated log delivery")
+			writeJSON(w, http.StatusOK, delivery)
+		})
+
+		r.Get("/services/{serviceID}/log-analytics", func(w http.ResponseWriter, r *http.Request) {
+			serviceID := chi.URLParam(r, "serviceID")
+			analytics, ok := h.logWorker.Analytics(serviceID)
+			if !ok {
+				writeError(w, http.StatusNotFound, "no access logs ingested for this service yet")
+				return
+			}
+
+			writeJSON(w, http.StatusOK, analytics)
+		})
+
+		// Per-day traffic and hit ratio trend, maintained as logs are ingested
+		r.Get("/services/{serviceID}/log-analytics/daily", func(w http.ResponseWriter, r *http.Request) {
+			serviceID := chi.URLParam(r, "serviceID")
+			days := 30
+			if v := r.URL.Query().Get("days"); v != "" {
+				n, err := strconv.Atoi(v)
+				if err != nil || n < 1 || n > logingest.MaxDailyRollups {
+					writeError(w, http.StatusBadRequest, fmt.Sprintf("days must be between 1 and %d", logingest.MaxDailyRollups))
+					return
+				}
+				days = n
+			}
+
+			totals, ok := h.logWorker.Daily(serviceID, days)
+			if !ok {
+				writeError(w, http.StatusNotFound, "no access logs ingested for this service yet")
+				return
+			}
+
+			writeJSON(w, http.StatusOK, map[string]interface{}{
+				"service_id": serviceID,
+				"days":       totals,
+			})
+		})
+
+		// Bucketed provider analytics; ?start=&end= are RFC 3339 (default the
+		// last 24 hours), ?metrics= a comma-separated subset, ?interval= a duration
+		r.Get("/services/{serviceID}/analytics", func(w http.ResponseWriter, r *http.Request) {
+			serviceID := chi.URLParam(r, "serviceID")
+			req, interval, err := parseAnalyticsRequest(r, serviceID)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+
+			svc, err := ResolveCDNService(h.sandboxes, h.cdnService, h.flags, OrgIDFromQuery(r), r.URL.Query().Get("sandbox_id"), r.URL.Query().Get("provider"))
+			if err != nil {
+				writeError(w, http.StatusNotFound, err.Error())
+				return
+			}
+
+			buckets, err := svc.GetAnalytics(r.Context(), serviceID, req.StartTime, req.EndTime, interval)
+			if err != nil {
+				writeCDNError(w, serviceID, err)
+				return
+			}
+
+			data := make(map[string]interface{}, len(req.Metrics))
+			for metric, points := range cdn.AnalyticsSeries(buckets, req.Metrics) {
+				data[metric] = points
+			}
+			writeJSON(w, http.StatusOK, messaging.AnalyticsResponse{
+				ServiceID: serviceID,
+				Data:      data,
+				Period:    interval.String(),
+				Timestamp: time.Now(),
+			})
+		})
+
+		// Downloadable analytics report, one row per bucket; takes the
+		// analytics query plus ?format=csv|json (default csv)
+		r.Get("/services/{serviceID}/metrics/export", func(w http.ResponseWriter, r *http.Request) {
+			serviceID := chi.URLParam(r, "serviceID")
+			req, interval, err := parseAnalyticsRequest(r, serviceID)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			format := r.URL.Query().Get("format")
+			if format == "" {
+				format = "csv"
+			}
+			if format != "csv" && format != "json" {
+				writeError(w, http.StatusBadRequest, "format must be csv or json")
+				return
+			}
+
+			svc, err := ResolveCDNService(h.sandboxes, h.cdnService, h.flags, OrgIDFromQuery(r), r.URL.Query().Get("sandbox_id"), r.URL.Query().Get("provider"))
+			if err != nil {
+				writeError(w, http.StatusNotFound, err.Error())
+				return
+			}
+
+			buckets, err := svc.GetAnalytics(r.Context(), serviceID, req.StartTime, req.EndTime, interval)
+			if err != nil {
+				writeCDNError(w, serviceID, err)
+				return
+			}
+			export := cdn.NewAnalyticsExport(serviceID, req.StartTime, req.EndTime, interval, req.Metrics, buckets)
+
+			filename := fmt.Sprintf("cdnbuddy-%s-metrics-%s-%s.%s", serviceID,
+				export.Start.Format("20060102"), export.End.Format("20060102"), format)
+			w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+			if format == "json" {
+				writeJSON(w, http.StatusOK, export)
+				return
+			}
+			w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+			w.WriteHeader(http.StatusOK)
+			if err := export.WriteCSV(w); err != nil {
+				logrus.WithError(err).WithField("service_id", serviceID).Error("❌ Failed to write metrics export")
+			}
+		})
+
+		// Predict effective TTLs and hit ratio of proposed rules before applying them
+		r.Post("/simulate", func(w http.ResponseWriter, r *http.Request) {
+			var req struct {
+				Rules        []cdn.CacheRule `json:"rules"`
+				CurrentRules []cdn.CacheRule `json:"current_rules,omitempty"`
+				URLs         []cdn.SampleURL `json:"urls,omitempty"`
+				SitemapURL   string          `json:"sitemap_url,omitempty"`
+			}
+			if !decodeJSON(w, r, &req) {
+				return
+			}
+
+			if req.SitemapURL != "" {
+				urls, err := h.varyTester.FetchSitemap(r.Context(), req.SitemapURL, 0)
+				if err != nil {
+					writeError(w, http.StatusBadRequest, err.Error())
+					return
+				}
+				for _, u := range urls {
+					req.URLs = append(req.URLs, cdn.SampleURL{URL: u})
+				}
+			}
+
+			result, err := cdn.SimulateRules(req.CurrentRules, req.Rules, req.URLs)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+
+			writeJSON(w, http.StatusOK, result)
+		})
+	})
+
+	// Live cache debugging against URLs served through the CDN
+	r.Route("/diagnostics", func(r chi.Router) {
+		r.Post("/vary-test", func(w http.ResponseWriter, r *http.Request) {
+			var req diagnostics.VaryTestRequest
+			if !decodeJSON(w, r, &req) {
+				return
+			}
+
+			report, err := h.varyTester.Run(r.Context(), req)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+
+			writeJSON(w, http.StatusOK, report)
+		})
+	})
+}
+
+// runPrewarm runs a prewarm operation once its service is free, publishing
+// listServices returns a page of the services matching the request's status
+// (ACTIVE by default) and provider, answering bad queries and provider errors
+// itself
+func (h *CDNHandler) listServices(w http.ResponseWriter, r *http.Request) ([]domain.CDNService, cdn.StatusFilter, bool) {
+	status, err := cdn.ParseStatusFilter(r.URL.Query().Get("status"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return nil, status, false
+	}
+
+	svc, err := ResolveCDNService(h.sandboxes, h.cdnService, h.flags, OrgIDFromQuery(r), r.URL.Query().Get("sandbox_id"), r.URL.Query().Get("provider"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return nil, status, false
+	}
+
+	services, err := svc.ListServicesByStatus(r.Context(), status)
+	if err != nil {
+		logrus.WithError(err).Error("❌ Failed to list CDN services")
+		if writeProviderUnavailable(w, err) {
+			return nil, status, false
+		}
+		writeError(w, http.StatusBadGateway, "failed to fetch services from provider")
+		return nil, status, false
+	}
+
+	// status and provider already scoped the listing
+	services, ok := paginate(w, r, services, listSpec[domain.CDNService]{
+		sorts: map[string]func(a, b domain.CDNService) int{
+			"name":       byString(func(s domain.CDNService) string { return s.Name }),
+			"status":     byString(func(s domain.CDNService) string { return s.Status }),
+			"provider":   byString(func(s domain.CDNService) string { return string(s.Provider) }),
+			"created_at": byTime(func(s domain.CDNService) time.Time { return s.CreatedAt }),
+			"updated_at": byTime(func(s domain.CDNService) time.Time { return s.UpdatedAt }),
+		},
+		text: func(s domain.CDNService) []string { return []string{s.Name, s.ID} },
+	})
+	return services, status, ok
+}
+
+// purgeRequest purges paths, or everything with purge_all
+type purgeRequest struct {
+	Paths    []string `json:"paths"`
+	PurgeAll bool     `json:"purge_all"`
+	UserID   string   `json:"user_id"`
+}
+
+func (req purgeRequest) validate(v *validator) {
+	v.check("paths", req.PurgeAll != (len(req.Paths) > 0), "either paths or purge_all is required")
+	for i, path := range req.Paths {
+		v.path(fmt.Sprintf("paths[%d]", i), path)
+	}
+}
+
+// operation events as it goes
+func (h *CDNHandler) runPrewarm(op operations.Operation, urls []string) {
+	defer h.operationQueue.Done(op.ID)
+	ctx := h.operationQueue.Cancellable(context.Background(), op.ID)
+	if err := h.operationQueue.Wait(ctx, op.ID); err != nil {
+		h.operationQueue.Finish(op.ID, "", err)
+		return
+	}
+
+	event := &domain.CDNOperation{
+		ID:        op.ID,
+		Type:      op.Action,
+		Status:    string(operations.StatusRunning),
+		Params:    map[string]interface{}{"service_id": op.ServiceID, "user_id": op.UserID, "urls": len(urls)},
+		CreatedAt: op.StartedAt,
+		UpdatedAt: time.Now(),
+	}
+	h.publisher.PublishOperationStarted(event)
+
+	// A progress event per tenth of the URLs
+	step := max(1, len(urls)/10)
+	report := h.varyTester.Prewarm(ctx, urls, func(done, total int) {
+		if done%step == 0 || done == total {
+			h.publisher.PublishOperationProgress(event, fmt.Sprintf("%d of %d URLs fetched", done, total))
+		}
+	})
+
+	var err error
+	switch {
+	case ctx.Err() != nil:
+		err = context.Cause(ctx)
+	case report.Warmed == 0:
+		err = fmt.Errorf("none of the %d URLs could be fetched through the CDN", report.Total)
+	}
+	h.operationQueue.Finish(op.ID, report.Summary(), err)
+
+	event.UpdatedAt = time.Now()
+	if err != nil {
+		h.publisher.PublishOperationFailed(event, err.Error())
+		return
+	}
+	event.Status = string(operations.StatusSucceeded)
+	event.Result = map[string]interface{}{"total": report.Total, "warmed": report.Warmed, "failed": report.Failed}
+	h.publisher.PublishOperationCompleted(event)
+
+	logrus.WithFields(logrus.Fields{
+		"service_id":   op.ServiceID,
+		"operation_id": op.ID,
+		"warmed":       report.Warmed,
+		"failed":       report.Failed,
+	}).Info("🔥 Cache prewarm finished")
+}
+
+// parseAnalyticsRequest reads the range, metrics and bucket interval of an
+// analytics query
+func parseAnalyticsRequest(r *http.Request, serviceID string) (messaging.AnalyticsRequest, time.Duration, error) {
+	query := r.URL.Query()
+	req := messaging.AnalyticsRequest{
+		ServiceID: serviceID,
+		UserID:    query.Get("user_id"),
+		EndTime:   time.Now(),
+		Metrics:   cdn.AnalyticsMetrics,
+	}
+
+	if v := query.Get("end"); v != "" {
+		end, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return req, 0, fmt.Errorf("end must be an RFC 3339 time")
+		}
+		req.EndTime = end
+	}
+	req.StartTime = req.EndTime.Add(-24 * time.Hour)
+	if v := query.Get("start"); v != "" {
+		start, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return req, 0, fmt.Errorf("start must be an RFC 3339 time")
+		}
+		req.StartTime = start
+	}
+
+	if v := query.Get("metrics"); v != "" {
+		req.Metrics = nil
+		for _, metric := range strings.Split(v, ",") {
+			metric = strings.TrimSpace(metric)
+			if !slices.Contains(cdn.AnalyticsMetrics, metric) {
+				return req, 0, fmt.Errorf("unknown metric %q (expected %s)", metric, strings.Join(cdn.AnalyticsMetrics, ", "))
+			}
+			if !slices.Contains(req.Metrics, metric) {
+				req.Metrics = append(req.Metrics, metric)
+			}
+		}
+	}
+
+	interval := cdn.AnalyticsInterval(req.StartTime, req.EndTime)
+	if v := query.Get("interval"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return req, 0, fmt.Errorf("interval must be a duration like 5m, 1h or 24h")
+		}
+		interval = d
+	}
+	return req, interval, nil
+}
@@ -0,0 +1,301 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sirupsen/logrus"
+
+	"github.com/avvvet/cdnbuddy-api/internal/services/messaging"
+	"github.com/avvvet/cdnbuddy-api/internal/services/sandbox"
+	"github.com/avvvet/cdnbuddy-api/internal/services/sessions"
+	"github.com/avvvet/cdnbuddy-api/internal/services/speech"
+	"github.com/avvvet/cdnbuddy-api/internal/services/transcript"
+)
+
+// ChatHandler serves chat sessions and their history, voice notes and demo
+// sandboxes
+type ChatHandler struct {
+	publisher       *messaging.Publisher
+	sandboxes       *sandbox.Manager
+	transcriber     speech.Transcriber
+	sessionRegistry *sessions.Registry
+	transcripts     *transcript.Store
+}
+
+// NewChatHandler creates the handler
+func NewChatHandler(publisher *messaging.Publisher, sandboxes *sandbox.Manager, transcriber speech.Transcriber, sessionRegistry *sessions.Registry, transcripts *transcript.Store) *ChatHandler {
+	return &ChatHandler{
+		publisher:       publisher,
+		sandboxes:       sandboxes,
+		transcriber:     transcriber,
+		sessionRegistry: sessionRegistry,
+		transcripts:     transcripts,
+	}
+}
+
+// Routes registers the handler's routes on the /api/v1 router
+func (h *ChatHandler) Routes(r chi.Router) {
+	// Cross-device sessions: a session can continue the conversation of
+	// another session of the same user, and responses can reach all of them
+	r.Route("/sessions", func(r chi.Router) {
+		// Every session of a user from the last day, connected or not
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+			userID, ok := requireUserID(w, r)
+			if !ok {
+				return
+			}
+
+			list, ok := paginate(w, r, h.sessionRegistry.List(userID), listSpec[sessions.Session]{
+				sorts: map[string]func(a, b sessions.Session) int{
+					"last_seen": byTime(func(s sessions.Session) time.Time { return s.LastSeen }),
+				},
+				defaultSort: "-last_seen",
+			})
+			if !ok {
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string]interface{}{
+				"user_id":  userID,
+				"sessions": list,
+			})
+		})
+
+		// The conversation a session is part of: its summary and the
+		// messages since
+		r.Get("/{sessionID}/history", func(w http.ResponseWriter, r *http.Request) {
+			userID, ok := requireUserID(w, r)
+			if !ok {
+				return
+			}
+			session, err := h.sessionRegistry.Get(userID, chi.URLParam(r, "sessionID"))
+			if err != nil {
+				writeError(w, http.StatusNotFound, err.Error())
+				return
+			}
+
+			summary, messages := h.transcripts.Context(session.ConversationID)
+			writeJSON(w, http.StatusOK, map[string]interface{}{
+				"session_id":      session.SessionID,
+				"conversation_id": session.ConversationID,
+				"summary":         summary,
+				"messages":        messages,
+			})
+		})
+
+		// Start over: the session leaves any conversation it continued and
+		// its own is cleared, here and in the intent service. Sessions that
+		// continued this one's conversation see it cleared too.
+		r.Post("/{sessionID}/reset", func(w http.ResponseWriter, r *http.Request) {
+			var req struct {
+				UserID string `json:"user_id"`
+			}
+			if !decodeJSON(w, r, &req) {
+				return
+			}
+			var v validator
+			v.required("user_id", req.UserID)
+			if v.failed(w) {
+				return
+			}
+
+			sessionID := chi.URLParam(r, "sessionID")
+			previous, err := h.sessionRegistry.Reset(req.UserID, sessionID)
+			if err != nil {
+				writeError(w, http.StatusNotFound, err.Error())
+				return
+			}
+			h.transcripts.Clear(sessionID)
+			if err := h.publisher.PublishSessionReset(messaging.SessionResetEvent{
+				UserID:         req.UserID,
+				SessionID:      sessionID,
+				ConversationID: sessionID,
+			}); err != nil {
+				logrus.WithError(err).WithField("session_id", sessionID).Warn("⚠️ Failed to publish session reset")
+			}
+
+			logrus.WithFields(logrus.Fields{
+				"user_id":    req.UserID,
+				"session_id": sessionID,
+			}).Info("🧹 Session reset")
+
+			writeJSON(w, http.StatusOK, map[string]interface{}{
+				"session_id":               sessionID,
+				"conversation_id":          sessionID,
+				"previous_conversation_id": previous.ConversationID,
+			})
+		})
+
+		r.Get("/active", func(w http.ResponseWriter, r *http.Request) {
+			userID := r.URL.Query().Get("user_id")
+			if userID == "" {
+				writeError(w, http.StatusBadRequest, "user_id is required")
+				return
+			}
+
+			writeJSON(w, http.StatusOK, map[string]interface{}{
+				"user_id":  userID,
+				"sync":     h.sessionRegistry.SyncEnabled(userID),
+				"sessions": h.sessionRegistry.Active(userID),
+			})
+		})
+
+		r.Post("/{sessionID}/link", func(w http.ResponseWriter, r *http.Request) {
+			var req struct {
+				UserID         string `json:"user_id"`
+				ConversationID string `json:"conversation_id"` // session to continue
+			}
+			if !decodeJSON(w, r, &req) {
+				return
+			}
+			var v validator
+			v.required("user_id", req.UserID)
+			v.required("conversation_id", req.ConversationID)
+			if v.failed(w) {
+				return
+			}
+
+			session, err := h.sessionRegistry.Link(req.UserID, chi.URLParam(r, "sessionID"), req.ConversationID)
+			if err != nil {
+				writeError(w, http.StatusNotFound, err.Error())
+				return
+			}
+
+			logrus.WithFields(logrus.Fields{
+				"user_id":         req.UserID,
+				"session_id":      session.SessionID,
+				"conversation_id": session.ConversationID,
+			}).Info("🔗 Session linked")
+
+			writeJSON(w, http.StatusOK, session)
+		})
+
+		r.Put("/sync", func(w http.ResponseWriter, r *http.Request) {
+			var req struct {
+				UserID  string `json:"user_id"`
+				Enabled bool   `json:"enabled"`
+			}
+			if !decodeJSON(w, r, &req) {
+				return
+			}
+			var v validator
+			v.required("user_id", req.UserID)
+			if v.failed(w) {
+				return
+			}
+
+			h.sessionRegistry.SetSync(req.UserID, req.Enabled)
+
+			writeJSON(w, http.StatusOK, map[string]interface{}{"user_id": req.UserID, "sync": req.Enabled})
+		})
+	})
+
+	// Voice notes: multipart upload with an "audio" file plus user_id,
+	// session_id and optional sandbox_id and language fields
+	r.Post("/chat/voice", func(w http.ResponseWriter, r *http.Request) {
+		if h.transcriber == nil {
+			writeError(w, http.StatusServiceUnavailable, "voice notes are not enabled")
+			return
+		}
+		if err := r.ParseMultipartForm(speech.MaxAudioBytes); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid upload: expected multipart form with an audio file")
+			return
+		}
+		defer r.MultipartForm.RemoveAll()
+
+		userID, sessionID := r.FormValue("user_id"), r.FormValue("session_id")
+		if userID == "" || sessionID == "" {
+			writeError(w, http.StatusBadRequest, "user_id and session_id are required")
+			return
+		}
+
+		file, header, err := r.FormFile("audio")
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "audio file is required")
+			return
+		}
+		defer file.Close()
+
+		data, err := io.ReadAll(io.LimitReader(file, speech.MaxAudioBytes+1))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "failed to read audio")
+			return
+		}
+		audio := speech.Audio{
+			Data:        data,
+			Filename:    header.Filename,
+			ContentType: header.Header.Get("Content-Type"),
+			Language:    r.FormValue("language"),
+		}
+		if err := audio.Validate(); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		transcript, err := speech.Transcribe(r.Context(), h.transcriber, audio)
+		if errors.Is(err, speech.ErrNoSpeech) {
+			writeError(w, http.StatusUnprocessableEntity, err.Error())
+			return
+		}
+		if err != nil {
+			logrus.WithError(err).WithField("user_id", userID).Error("❌ Failed to transcribe voice note")
+			writeError(w, http.StatusBadGateway, "failed to transcribe voice note")
+			return
+		}
+
+		if err := h.publisher.PublishVoiceMessage(userID, sessionID, r.FormValue("sandbox_id"), transcript); err != nil {
+			logrus.WithError(err).Error("❌ Failed to forward voice note to chat")
+			writeError(w, http.StatusInternalServerError, "failed to send voice note to chat")
+			return
+		}
+
+		logrus.WithFields(logrus.Fields{
+			"user_id":    userID,
+			"session_id": sessionID,
+			"bytes":      len(data),
+		}).Info("🎙️ Voice note transcribed")
+
+		writeJSON(w, http.StatusAccepted, map[string]string{
+			"transcript": transcript,
+			"session_id": sessionID,
+		})
+	})
+
+	// Demo sandbox endpoints (mock provider, no account required)
+	r.Route("/sandbox", func(r chi.Router) {
+		r.Post("/", func(w http.ResponseWriter, r *http.Request) {
+			sb, err := h.sandboxes.Create(r.Context())
+			if err != nil {
+				logrus.WithError(err).Error("❌ Failed to create sandbox")
+				writeError(w, http.StatusInternalServerError, "failed to create sandbox")
+				return
+			}
+
+			writeJSON(w, http.StatusCreated, sb)
+		})
+
+		r.Get("/{sandboxID}/overview", func(w http.ResponseWriter, r *http.Request) {
+			sb, err := h.sandboxes.Get(chi.URLParam(r, "sandboxID"))
+			if err != nil {
+				writeError(w, http.StatusNotFound, "sandbox not found or expired")
+				return
+			}
+
+			overview, err := sb.Service.GetAccountOverview(r.Context())
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, "failed to build sandbox overview")
+				return
+			}
+
+			writeJSON(w, http.StatusOK, overview)
+		})
+
+		r.Delete("/{sandboxID}", func(w http.ResponseWriter, r *http.Request) {
+			h.sandboxes.Delete(chi.URLParam(r, "sandboxID"))
+			w.WriteHeader(http.StatusNoContent)
+		})
+	})
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/avvvet/cdnbuddy-api/internal/services/cdn"
+	"github.com/avvvet/cdnbuddy-api/internal/services/messaging"
+)
+
+// Summary states of the deep health check, as in messaging.HealthCheckResponse
+const (
+	healthHealthy   = "healthy"
+	healthDegraded  = "degraded"
+	healthUnhealthy = "unhealthy"
+)
+
+// deepHealthTimeout bounds each dependency check of the deep health endpoint
+const deepHealthTimeout = 5 * time.Second
+
+// HealthHandler serves the liveness endpoints and the deep dependency check
+type HealthHandler struct {
+	service    string
+	msgClient  *messaging.Client
+	cdnService *cdn.Service
+}
+
+// NewHealthHandler creates the health handler
+func NewHealthHandler(msgClient *messaging.Client, cdnService *cdn.Service) *HealthHandler {
+	return &HealthHandler{
+		service:    "cdnbuddy-api",
+		msgClient:  msgClient,
+		cdnService: cdnService,
+	}
+}
+
+// Health reports the service as healthy with the current time
+func (h *HealthHandler) Health(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{
+		"status":    "healthy",
+		"service":   h.service,
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	})
+}
+
+// APIHealth reports the v1 API as healthy
+func (h *HealthHandler) APIHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{
+		"status":  "healthy",
+		"version": "v1",
+		"service": h.service,
+	})
+}
+
+// dependencyCheck is the outcome of checking one dependency. Without a
+// critical dependency the API can't do its job; others only degrade it.
+type dependencyCheck struct {
+	name       string
+	critical   bool
+	configured bool
+	latency    time.Duration
+	err        error
+}
+
+// DeepHealth actively checks each dependency: a message bus round trip, a
+// call to every provider API and the database. It answers 503 when a critical
+// dependency is down so load balancers can take the replica out.
+func (h *HealthHandler) DeepHealth(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), deepHealthTimeout)
+	defer cancel()
+
+	var (
+		mu     sync.Mutex
+		checks []dependencyCheck
+		wg     sync.WaitGroup
+	)
+	add := func(check dependencyCheck) {
+		mu.Lock()
+		checks = append(checks, check)
+		mu.Unlock()
+	}
+
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		latency, err := h.msgClient.Ping(ctx)
+		add(dependencyCheck{name: "messaging", critical: true, configured: true, latency: latency, err: err})
+	}()
+	go func() {
+		defer wg.Done()
+		for _, ping := range h.cdnService.PingProviders(ctx) {
+			name := "provider"
+			if ping.Provider != "" {
+				name += "." + string(ping.Provider)
+			}
+			add(dependencyCheck{name: name, configured: true, latency: ping.Latency, err: ping.Err})
+		}
+	}()
+	wg.Wait()
+
+	// Persistence is in memory until the Postgres storage is wired up
+	add(dependencyCheck{name: "database"})
+
+	resp := deepHealthResponse(h.service, checks, time.Now().UTC())
+	status := http.StatusOK
+	if resp.Status == healthUnhealthy {
+		status = http.StatusServiceUnavailable
+	}
+	if resp.Status != healthHealthy {
+		logrus.WithField("details", resp.Details).Warnf("🩺 Deep health check %s", resp.Status)
+	}
+	writeJSON(w, status, resp)
+}
+
+// deepHealthResponse summarizes dependency checks: unhealthy when a critical
+// dependency failed, degraded when any other did. Details map each dependency
+// to "ok" or its error, with its latency under <name>_latency_ms.
+func deepHealthResponse(service string, checks []dependencyCheck, now time.Time) messaging.HealthCheckResponse {
+	resp := messaging.HealthCheckResponse{
+		Service:   service,
+		Status:    healthHealthy,
+		Details:   make(map[string]string),
+		Timestamp: now,
+	}
+	for _, check := range checks {
+		if !check.configured {
+			resp.Details[check.name] = "not configured"
+			continue
+		}
+		resp.Details[check.name+"_latency_ms"] = strconv.FormatInt(check.latency.Milliseconds(), 10)
+		if check.err == nil {
+			resp.Details[check.name] = "ok"
+			continue
+		}
+		resp.Details[check.name] = fmt.Sprintf("error: %v", check.err)
+		switch {
+		case check.critical:
+			resp.Status = healthUnhealthy
+		case resp.Status == healthHealthy:
+			resp.Status = healthDegraded
+		}
+	}
+	return resp
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"errors"
+	"maps"
+	"testing"
+	"time"
+)
+
+func TestDeepHealthResponse(t *testing.T) {
+	messagingOK := dependencyCheck{name: "messaging", critical: true, configured: true, latency: 3 * time.Millisecond}
+	providerOK := dependencyCheck{name: "provider.cachefly", configured: true, latency: 120 * time.Millisecond}
+	database := dependencyCheck{name: "database"}
+
+	tests := []struct {
+		name        string
+		checks      []dependencyCheck
+		wantStatus  string
+		wantDetails map[string]string
+	}{
+		{
+			name:       "all up",
+			checks:     []dependencyCheck{messagingOK, providerOK, database},
+			wantStatus: "healthy",
+			wantDetails: map[string]string{
+				"messaging": "ok", "messaging_latency_ms": "3",
+				"provider.cachefly": "ok", "provider.cachefly_latency_ms": "120",
+				"database": "not configured",
+			},
+		},
+		{
+			name: "provider down",
+			checks: []dependencyCheck{messagingOK, {
+				name: "provider.cachefly", configured: true, latency: 5 * time.Second, err: errors.New("context deadline exceeded"),
+			}},
+			wantStatus: "degraded",
+			wantDetails: map[string]string{
+				"messaging": "ok", "messaging_latency_ms": "3",
+				"provider.cachefly": "error: context deadline exceeded", "provider.cachefly_latency_ms": "5000",
+			},
+		},
+		{
+			name: "messaging down",
+			checks: []dependencyCheck{{
+				name: "messaging", critical: true, configured: true, err: errors.New("nats: connection closed"),
+			}, {
+				name: "provider.cachefly", configured: true, err: errors.New("401 unauthorized"),
+			}},
+			wantStatus: "unhealthy",
+			wantDetails: map[string]string{
+				"messaging": "error: nats: connection closed", "messaging_latency_ms": "0",
+				"provider.cachefly": "error: 401 unauthorized", "provider.cachefly_latency_ms": "0",
+			},
+		},
+	}
+
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := deepHealthResponse("cdnbuddy-api", tt.checks, now)
+			if resp.Status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", resp.Status, tt.wantStatus)
+			}
+			if !maps.Equal(resp.Details, tt.wantDetails) {
+				t.Errorf("details = %v, want %v", resp.Details, tt.wantDetails)
+			}
+			if resp.Service != "cdnbuddy-api" || !resp.Timestamp.Equal(now) {
+				t.Errorf("service, timestamp = %q, %v", resp.Service, resp.Timestamp)
+			}
+		})
+	}
+}
@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sirupsen/logrus"
+
+	"github.com/avvvet/cdnbuddy-api/internal/features"
+	"github.com/avvvet/cdnbuddy-api/internal/services/callbacks"
+	"github.com/avvvet/cdnbuddy-api/internal/services/cdn"
+	"github.com/avvvet/cdnbuddy-api/internal/services/cms"
+	"github.com/avvvet/cdnbuddy-api/internal/services/messaging"
+	"github.com/avvvet/cdnbuddy-api/internal/services/sandbox"
+)
+
+// maxWebhookBody bounds CMS webhook deliveries; they carry one post or entry
+const maxWebhookBody = 1 << 20
+
+// IntegrationHandler serves CMS webhook mappings and receives their deliveries,
+// and receives provider callbacks
+type IntegrationHandler struct {
+	cdnService *cdn.Service
+	flags      *features.Flags
+	sandboxes  *sandbox.Manager
+	cmsHooks   *cms.Store
+	callbacks  *callbacks.Receiver
+	publisher  *messaging.Publisher
+}
+
+// NewIntegrationHandler creates the handler
+func NewIntegrationHandler(cdnService *cdn.Service, flags *features.Flags, sandboxes *sandbox.Manager, cmsHooks *cms.Store, callbacks *callbacks.Receiver, publisher *messaging.Publisher) *IntegrationHandler {
+	return &IntegrationHandler{
+		cdnService: cdnService,
+		flags:      flags,
+		sandboxes:  sandboxes,
+		cmsHooks:   cmsHooks,
+		callbacks:  callbacks,
+		publisher:  publisher,
+	}
+}
+
+// PublicRoutes registers routes served outside /api/v1 without authentication
+func (h *IntegrationHandler) PublicRoutes(r chi.Router) {
+	// CMS content changes; deliveries carry the hook secret or a signature
+	r.Post("/hooks/cms/{hookID}", func(w http.ResponseWriter, r *http.Request) {
+		hookID := chi.URLParam(r, "hookID")
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBody))
+		if err != nil {
+			writeError(w, http.StatusRequestEntityTooLarge, "webhook body too large")
+			return
+		}
+
+		delivery, err := h.cmsHooks.Receive(hookID, r.Header, body)
+		if err != nil {
+			status := http.StatusBadRequest
+			switch {
+			case errors.Is(err, cms.ErrUnknownHook):
+				status = http.StatusNotFound
+			case errors.Is(err, cms.ErrUnauthorized):
+				status = http.StatusUnauthorized
+			}
+			writeError(w, status, err.Error())
+			return
+		}
+		if delivery.Ignored != "" {
+			writeJSON(w, http.StatusAccepted, delivery)
+			return
+		}
+
+		hook := delivery.Hook
+		svc, err := ResolveCDNService(h.sandboxes, h.cdnService, h.flags, hook.OrgID, "", string(hook.Provider))
+		if err != nil {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+
+		paths := []string{"/*"}
+		if delivery.PurgeAll {
+			err = svc.PurgeAll(r.Context(), hook.ServiceID)
+		} else {
+			paths, err = svc.PurgeCache(r.Context(), hook.ServiceID, delivery.Paths)
+			delivery.Paths = paths
+		}
+		if err != nil {
+			writeCDNError(w, hook.ServiceID, err)
+			return
+		}
+
+		if err := h.publisher.PublishCachePurged(hook.ServiceID, "cms:"+string(hook.Platform), paths); err != nil {
+			logrus.WithError(err).WithField("service_id", hook.ServiceID).Error("❌ Failed to publish cache purge event")
+		}
+
+		logrus.WithFields(logrus.Fields{
+			"hook_id":    hook.ID,
+			"platform":   hook.Platform,
+			"service_id": hook.ServiceID,
+			"paths":      len(paths),
+		}).Info("📰 CMS change purged")
+		writeJSON(w, http.StatusOK, delivery)
+	})
+}
+
+// Routes registers the handler's routes on the /api/v1 router
+func (h *IntegrationHandler) Routes(r chi.Router) {
+	// Provider callbacks; authenticated by the provider's callback secret
+	r.Post("/hooks/{provider}", func(w http.ResponseWriter, r *http.Request) {
+		provider := cdn.ParseProvider(chi.URLParam(r, "provider"))
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBody))
+		if err != nil {
+			writeError(w, http.StatusRequestEntityTooLarge, "callback body too large")
+			return
+		}
+
+		notification, err := h.callbacks.Receive(provider, r.Header, body)
+		if err != nil {
+			status := http.StatusBadRequest
+			switch {
+			case errors.Is(err, callbacks.ErrUnknownProvider):
+				status = http.StatusNotFound
+			case errors.Is(err, callbacks.ErrUnauthorized):
+				status = http.StatusUnauthorized
+			}
+			writeError(w, status, err.Error())
+			return
+		}
+		if notification.Ignored != "" {
+			writeJSON(w, http.StatusAccepted, notification)
+			return
+		}
+
+		svc, err := h.cdnService.ForProvider(provider)
+		if err != nil {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		if err := callbacks.Apply(r.Context(), svc, h.publisher, notification); err != nil {
+			logrus.WithError(err).WithFields(logrus.Fields{
+				"provider":   provider,
+				"event":      notification.Event,
+				"service_id": notification.ServiceID,
+			}).Warn("⚠️ Failed to apply provider callback")
+			writeCDNError(w, notification.ServiceID, err)
+			return
+		}
+
+		logrus.WithFields(logrus.Fields{
+			"provider":   provider,
+			"kind":       notification.Kind,
+			"service_id": notification.ServiceID,
+			"domain":     notification.Domain,
+		}).Info("📬 Provider callback applied")
+		writeJSON(w, http.StatusOK, notification)
+	})
+
+	// Mappings from CMS webhooks to purges; ?service_id= narrows the list
+	r.Route("/integrations/cms/hooks", func(r chi.Router) {
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+			hooks, ok := paginate(w, r, h.cmsHooks.List(OrgIDFromQuery(r), r.URL.Query().Get("service_id")), listSpec[cms.Hook]{
+				sorts: map[string]func(a, b cms.Hook) int{
+					"created_at": byTime(func(hook cms.Hook) time.Time { return hook.CreatedAt }),
+					"platform":   byString(func(hook cms.Hook) string { return string(hook.Platform) }),
+				},
+				provider: func(hook cms.Hook) string { return string(hook.Provider) },
+				text:     func(hook cms.Hook) []string { return []string{hook.ServiceID, string(hook.Platform), hook.URL} },
+			})
+			if !ok {
+				return
+			}
+
+			writeJSON(w, http.StatusOK, map[string]interface{}{
+				"hooks": hooks,
+			})
+		})
+
+		r.Post("/", func(w http.ResponseWriter, r *http.Request) {
+			var hook cms.Hook
+			if !decodeJSON(w, r, &hook) {
+				return
+			}
+			hook.OrgID = OrgIDFromQuery(r)
+
+			// The service must exist before deliveries can purge it
+			svc, err := ResolveCDNService(h.sandboxes, h.cdnService, h.flags, hook.OrgID, "", string(hook.Provider))
+			if err != nil {
+				writeError(w, http.StatusNotFound, err.Error())
+				return
+			}
+			if _, err := svc.GetService(r.Context(), hook.ServiceID); err != nil {
+				writeCDNError(w, hook.ServiceID, err)
+				return
+			}
+
+			hook, err = h.cmsHooks.Create(hook)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+
+			logrus.WithFields(logrus.Fields{
+				"hook_id":    hook.ID,
+				"platform":   hook.Platform,
+				"service_id": hook.ServiceID,
+			}).Info("🔗 CMS webhook created")
+			writeJSON(w, http.StatusCreated, hook)
+		})
+
+		r.Put("/{hookID}", func(w http.ResponseWriter, r *http.Request) {
+			hookID := chi.URLParam(r, "hookID")
+			if current, ok := h.cmsHooks.Get(hookID); !ok || current.OrgID != OrgIDFromQuery(r) {
+				writeError(w, http.StatusNotFound, "webhook not found")
+				return
+			}
+
+			var hook cms.Hook
+			if !decodeJSON(w, r, &hook) {
+				return
+			}
+
+			hook, err := h.cmsHooks.Update(hookID, hook)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+
+			logrus.WithField("hook_id", hookID).Info("🔗 CMS webhook updated")
+			writeJSON(w, http.StatusOK, hook)
+		})
+
+		r.Delete("/{hookID}", func(w http.ResponseWriter, r *http.Request) {
+			hookID := chi.URLParam(r, "hookID")
+			if current, ok := h.cmsHooks.Get(hookID); !ok || current.OrgID != OrgIDFromQuery(r) || !h.cmsHooks.Delete(hookID) {
+				writeError(w, http.StatusNotFound, "webhook not found")
+				return
+			}
+
+			logrus.WithField("hook_id", hookID).Info("🔗 CMS webhook deleted")
+			w.WriteHeader(http.StatusNoContent)
+		})
+	})
+}
@@ -0,0 +1,335 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sirupsen/logrus"
+
+	"github.com/avvvet/cdnbuddy-api/internal/services/audit"
+	"github.com/avvvet/cdnbuddy-api/internal/services/messaging"
+	"github.com/avvvet/cdnbuddy-api/internal/services/operations"
+	"github.com/avvvet/cdnbuddy-api/internal/services/planstorage"
+	"github.com/avvvet/cdnbuddy-api/internal/services/scheduler"
+)
+
+// OperationHandler serves pending plans, operations, scheduled plans and the audit log
+type OperationHandler struct {
+	operationStore *operations.Store
+	operationQueue *operations.Queue
+	planStorage    *planstorage.Storage
+	planScheduler  *scheduler.Scheduler
+	auditLog       *audit.Log
+	publisher      *messaging.Publisher
+}
+
+// NewOperationHandler creates the handler
+func NewOperationHandler(operationStore *operations.Store, operationQueue *operations.Queue, planStorage *planstorage.Storage, planScheduler *scheduler.Scheduler, auditLog *audit.Log, publisher *messaging.Publisher) *OperationHandler {
+	return &OperationHandler{
+		operationStore: operationStore,
+		operationQueue: operationQueue,
+		planStorage:    planStorage,
+		planScheduler:  planScheduler,
+		auditLog:       auditLog,
+		publisher:      publisher,
+	}
+}
+
+// Routes registers the handler's routes on the /api/v1 router
+func (h *OperationHandler) Routes(r chi.Router) {
+	// Plans proposed in chat, so the web UI can confirm them directly
+	r.Route("/plans", func(r chi.Router) {
+		r.Get("/{planID}", func(w http.ResponseWriter, r *http.Request) {
+			plan, err := h.planStorage.Get(chi.URLParam(r, "planID"))
+			if err != nil {
+				writeError(w, http.StatusNotFound, err.Error())
+				return
+			}
+
+			writeJSON(w, http.StatusOK, plan)
+		})
+
+		// Approving sends the same execute command as confirming in chat; the
+		// result is delivered to the session. run_at schedules it instead.
+		r.Post("/{planID}/approve", func(w http.ResponseWriter, r *http.Request) {
+			planID := chi.URLParam(r, "planID")
+			var cmd messaging.ExecuteCommand
+			if err := json.NewDecoder(r.Body).Decode(&cmd); err != nil && !errors.Is(err, io.EOF) {
+				writeError(w, http.StatusBadRequest, "invalid request body")
+				return
+			}
+			cmd.PlanID = planID
+
+			plan, err := h.planStorage.Get(planID)
+			if err != nil {
+				writeError(w, http.StatusNotFound, err.Error())
+				return
+			}
+			if err := plan.CheckSandbox(cmd.SandboxID); err != nil {
+				writeError(w, http.StatusConflict, err.Error())
+				return
+			}
+			if cmd.RunAt != "" {
+				if _, err := scheduler.ParseRunAt(cmd.RunAt, time.Now()); err != nil {
+					writeError(w, http.StatusBadRequest, err.Error())
+					return
+				}
+			}
+
+			if err := h.publisher.PublishExecuteCommand(cmd); err != nil {
+				logrus.WithError(err).WithField("plan_id", planID).Error("❌ Failed to publish execute command")
+				writeError(w, http.StatusServiceUnavailable, "failed to submit plan for execution")
+				return
+			}
+
+			logrus.WithFields(logrus.Fields{
+				"plan_id": planID,
+				"user_id": cmd.UserID,
+			}).Info("👍 Plan approved")
+			writeJSON(w, http.StatusAccepted, map[string]string{"plan_id": planID, "status": "approved", "run_at": cmd.RunAt})
+		})
+
+		r.Post("/{planID}/reject", func(w http.ResponseWriter, r *http.Request) {
+			planID := chi.URLParam(r, "planID")
+			var req struct {
+				UserID    string `json:"user_id"`
+				SessionID string `json:"session_id"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+				writeError(w, http.StatusBadRequest, "invalid request body")
+				return
+			}
+
+			plan, err := h.planStorage.Get(planID)
+			if err != nil {
+				writeError(w, http.StatusNotFound, err.Error())
+				return
+			}
+			h.planStorage.Delete(planID)
+
+			// Keep the chat in step with the UI
+			if req.SessionID != "" {
+				if err := h.publisher.PublishAIResponse(req.UserID, req.SessionID, fmt.Sprintf("❎ Discarded the plan \"%s\". Nothing was changed.", plan.Title)); err != nil {
+					logrus.WithError(err).WithField("plan_id", planID).Warn("⚠️ Failed to tell the session about the rejected plan")
+				}
+			}
+
+			logrus.WithField("plan_id", planID).Info("👎 Plan rejected")
+			writeJSON(w, http.StatusOK, map[string]string{"plan_id": planID, "status": "rejected"})
+		})
+	})
+
+	// Executions of confirmed plans, most recently updated first
+	r.Route("/operations", func(r chi.Router) {
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+			ops, ok := h.listOperations(w, r)
+			if !ok {
+				return
+			}
+
+			writeJSON(w, http.StatusOK, map[string]interface{}{"operations": ops})
+		})
+
+		r.Get("/{operationID}", func(w http.ResponseWriter, r *http.Request) {
+			operationID := chi.URLParam(r, "operationID")
+			logrus.WithField("operation_id", operationID).Info("📊 Getting operation status")
+
+			op, err := h.operationStore.Get(operationID)
+			if err != nil {
+				writeError(w, http.StatusNotFound, err.Error())
+				return
+			}
+
+			writeJSON(w, http.StatusOK, op)
+		})
+
+		// Queued operations are dropped; running ones are interrupted and show
+		// cancel_requested until they stop
+		r.Post("/{operationID}/cancel", func(w http.ResponseWriter, r *http.Request) {
+			operationID := chi.URLParam(r, "operationID")
+			op, err := h.operationQueue.Cancel(operationID, r.URL.Query().Get("user_id"))
+			if err != nil {
+				writeOperationCancelError(w, err)
+				return
+			}
+
+			writeJSON(w, http.StatusOK, op)
+		})
+	})
+
+	// Plans scheduled to run in a maintenance window
+	r.Route("/schedules", func(r chi.Router) {
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+			jobs, ok := paginate(w, r, h.planScheduler.List(r.URL.Query().Get("user_id")), listSpec[scheduler.Job]{
+				sorts: map[string]func(a, b scheduler.Job) int{
+					"run_at":     byTime(func(j scheduler.Job) time.Time { return j.RunAt }),
+					"created_at": byTime(func(j scheduler.Job) time.Time { return j.CreatedAt }),
+				},
+				status: func(j scheduler.Job) string { return string(j.Status) },
+				text:   func(j scheduler.Job) []string { return []string{j.Title, j.Action, j.Domain, j.ServiceID} },
+			})
+			if !ok {
+				return
+			}
+
+			writeJSON(w, http.StatusOK, map[string]interface{}{"schedules": jobs})
+		})
+
+		r.Post("/", func(w http.ResponseWriter, r *http.Request) {
+			var req struct {
+				PlanID        string `json:"plan_id"`
+				UserID        string `json:"user_id"`
+				SessionID     string `json:"session_id"`
+				SandboxID     string `json:"sandbox_id"`
+				RunAt         string `json:"run_at"`
+				WindowMinutes int    `json:"window_minutes"`
+			}
+			if !decodeJSON(w, r, &req) {
+				return
+			}
+
+			plan, err := h.planStorage.Get(req.PlanID)
+			if err != nil {
+				writeError(w, http.StatusNotFound, err.Error())
+				return
+			}
+
+			runAt, err := scheduler.ParseRunAt(req.RunAt, time.Now())
+			if err != nil {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+
+			entry := audit.EntryFromIntent(req.UserID, req.SessionID, plan.ID, plan.Action, plan.Parameters)
+			job, err := h.planScheduler.Schedule(scheduler.Job{
+				UserID:    req.UserID,
+				SessionID: req.SessionID,
+				SandboxID: req.SandboxID,
+				ServiceID: entry.ServiceID,
+				Domain:    entry.Domain,
+				Plan:      plan,
+			}, runAt, time.Duration(req.WindowMinutes)*time.Minute)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			h.planStorage.Delete(req.PlanID)
+
+			writeJSON(w, http.StatusCreated, job)
+		})
+
+		r.Get("/{jobID}", func(w http.ResponseWriter, r *http.Request) {
+			job, err := h.planScheduler.Get(chi.URLParam(r, "jobID"))
+			if err != nil {
+				writeError(w, http.StatusNotFound, err.Error())
+				return
+			}
+
+			writeJSON(w, http.StatusOK, job)
+		})
+
+		// Cancel a change that hasn't started yet
+		r.Delete("/{jobID}", func(w http.ResponseWriter, r *http.Request) {
+			jobID := chi.URLParam(r, "jobID")
+			job, err := h.planScheduler.Cancel(jobID, r.URL.Query().Get("user_id"))
+			if err != nil {
+				status := http.StatusConflict
+				if _, getErr := h.planScheduler.Get(jobID); getErr != nil {
+					status = http.StatusNotFound
+				}
+				writeError(w, status, err.Error())
+				return
+			}
+
+			writeJSON(w, http.StatusOK, job)
+		})
+	})
+
+	// Audit log of executed changes
+	r.Route("/audit", func(r chi.Router) {
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+			q := r.URL.Query()
+			entries, ok := paginate(w, r, h.auditLog.Query(audit.Filter{
+				UserID:  q.Get("user_id"),
+				Domain:  q.Get("domain"),
+				Setting: q.Get("setting"),
+				Action:  q.Get("action"),
+			}), listSpec[audit.Entry]{
+				sorts: map[string]func(a, b audit.Entry) int{
+					"timestamp": byTime(func(e audit.Entry) time.Time { return e.Timestamp }),
+					"action":    byString(func(e audit.Entry) string { return e.Action }),
+				},
+				status: func(e audit.Entry) string {
+					if e.Success {
+						return "succeeded"
+					}
+					return "failed"
+				},
+				text: func(e audit.Entry) []string { return []string{e.Action, e.Domain, e.Setting, e.ServiceID, e.UserID} },
+			})
+			if !ok {
+				return
+			}
+
+			writeJSON(w, http.StatusOK, map[string]interface{}{"entries": entries})
+		})
+
+		// GET /audit/who-changed?domain=example.com&setting=brotli
+		r.Get("/who-changed", func(w http.ResponseWriter, r *http.Request) {
+			domainName := r.URL.Query().Get("domain")
+			setting := r.URL.Query().Get("setting")
+			if domainName == "" || setting == "" {
+				writeError(w, http.StatusBadRequest, "domain and setting are required")
+				return
+			}
+
+			entry, _ := h.auditLog.WhoChanged(domainName, setting)
+			writeJSON(w, http.StatusOK, map[string]interface{}{
+				"answer":  h.auditLog.Attribution(domainName, setting),
+				"change":  entry,
+				"history": h.auditLog.History(domainName, setting),
+			})
+		})
+	})
+}
+
+// listOperations returns a page of the operations matching the request's
+// user_id, service_id and status, answering bad queries itself
+func (h *OperationHandler) listOperations(w http.ResponseWriter, r *http.Request) ([]operations.Operation, bool) {
+	status := operations.Status(r.URL.Query().Get("status"))
+	switch status {
+	case "", operations.StatusQueued, operations.StatusRunning, operations.StatusSucceeded, operations.StatusFailed, operations.StatusCancelled:
+	default:
+		writeError(w, http.StatusBadRequest, "status must be queued, running, succeeded, failed or cancelled")
+		return nil, false
+	}
+
+	return paginate(w, r, h.operationStore.Query(operations.Filter{
+		UserID:    r.URL.Query().Get("user_id"),
+		ServiceID: r.URL.Query().Get("service_id"),
+		Status:    status,
+	}), listSpec[operations.Operation]{
+		sorts: map[string]func(a, b operations.Operation) int{
+			"started_at": byTime(func(op operations.Operation) time.Time { return op.StartedAt }),
+			"action":     byString(func(op operations.Operation) string { return op.Action }),
+			"status":     byString(func(op operations.Operation) string { return string(op.Status) }),
+		},
+		provider: func(op operations.Operation) string { return op.Provider },
+		text:     func(op operations.Operation) []string { return []string{op.Title, op.Action, op.Domain, op.ServiceID} },
+	})
+}
+
+// writeOperationCancelError answers a failed cancel: 409 if the operation is
+// past cancelling, 404 otherwise
+func writeOperationCancelError(w http.ResponseWriter, err error) {
+	status := http.StatusNotFound
+	if errors.Is(err, operations.ErrNotCancellable) {
+		status = http.StatusConflict
+	}
+	writeError(w, status, err.Error())
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sirupsen/logrus"
+
+	"github.com/avvvet/cdnbuddy-api/internal/features"
+	"github.com/avvvet/cdnbuddy-api/internal/services/cdn"
+	"github.com/avvvet/cdnbuddy-api/internal/services/intentstats"
+	"github.com/avvvet/cdnbuddy-api/internal/services/leader"
+	"github.com/avvvet/cdnbuddy-api/internal/services/messaging"
+	"github.com/avvvet/cdnbuddy-api/internal/services/operations"
+	"github.com/avvvet/cdnbuddy-api/internal/services/usage"
+)
+
+// OpsHandler serves the internal admin listener: orchestrator probes, and
+// behind the admin token, provider modes, analytics, the provider journal and
+// AI usage tiers
+type OpsHandler struct {
+	msgClient          *messaging.Client
+	cdnService         *cdn.Service
+	flags              *features.Flags
+	elector            *leader.Elector
+	providerJournal    *cdn.Journal
+	intentStats        *intentstats.Tracker
+	operationDurations *operations.Durations
+	watchdog           *operations.Watchdog
+	usageTracker       *usage.Tracker
+}
+
+// NewOpsHandler creates the handler
+func NewOpsHandler(msgClient *messaging.Client, cdnService *cdn.Service, flags *features.Flags, elector *leader.Elector, providerJournal *cdn.Journal, intentStats *intentstats.Tracker, operationDurations *operations.Durations, watchdog *operations.Watchdog, usageTracker *usage.Tracker) *OpsHandler {
+	return &OpsHandler{
+		msgClient:          msgClient,
+		cdnService:         cdnService,
+		flags:              flags,
+		elector:            elector,
+		providerJournal:    providerJournal,
+		intentStats:        intentStats,
+		operationDurations: operationDurations,
+		watchdog:           watchdog,
+		usageTracker:       usageTracker,
+	}
+}
+
+// ProbeRoutes registers the unauthenticated probes for orchestrators
+func (h *OpsHandler) ProbeRoutes(r chi.Router) {
+	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "healthy"})
+	})
+	r.Get("/ready", func(w http.ResponseWriter, r *http.Request) {
+		if !h.msgClient.IsHealthy() {
+			writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "messaging unavailable"})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ready"})
+	})
+
+	// Whether this replica runs the singleton background jobs
+	r.Get("/leader", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"replica_id": h.elector.ID(),
+			"leader":     h.elector.IsLeader(),
+		})
+	})
+}
+
+// Routes registers the operator routes; mount them behind admin.RequireToken
+func (h *OpsHandler) Routes(r chi.Router) {
+	// Per-tenant provider modes for dark-launching integrations
+	r.Get("/features/providers", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"org_id": OrgIDFromQuery(r),
+			"modes":  h.flags.ProviderModes(OrgIDFromQuery(r), h.cdnService.Providers()),
+		})
+	})
+
+	r.Put("/features/providers/{provider}", func(w http.ResponseWriter, r *http.Request) {
+		provider := cdn.ParseProvider(chi.URLParam(r, "provider"))
+		var req struct {
+			Mode cdn.ProviderMode `json:"mode"`
+		}
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+
+		if err := h.flags.SetProviderMode(OrgIDFromQuery(r), provider, req.Mode); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		logrus.WithFields(logrus.Fields{
+			"org_id":   OrgIDFromQuery(r),
+			"provider": provider,
+			"mode":     req.Mode,
+		}).Info("🚦 Provider mode changed")
+		writeJSON(w, http.StatusOK, map[string]interface{}{"provider": provider, "mode": req.Mode})
+	})
+
+	r.Delete("/features/providers/{provider}", func(w http.ResponseWriter, r *http.Request) {
+		provider := cdn.ParseProvider(chi.URLParam(r, "provider"))
+		h.flags.ClearProviderMode(OrgIDFromQuery(r), provider)
+
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"provider": provider,
+			"mode":     h.flags.ProviderMode(OrgIDFromQuery(r), provider),
+		})
+	})
+
+	// How often intents need clarification, which parameters are missing
+	// and how many clarifications are abandoned, per action
+	r.Get("/intent-analytics", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, h.intentStats.Report())
+	})
+
+	// Execution durations per action and provider, and operations that look stuck
+	r.Get("/operation-stats", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"durations": h.operationDurations.Report(),
+			"stuck":     h.watchdog.Stuck(),
+		})
+	})
+
+	// What CDNBuddy sent to providers: ?provider=, correlation_id= (request
+	// or plan ID), service_id=, since= (RFC 3339) and limit=
+	r.Get("/provider-journal", func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		filter := cdn.JournalFilter{
+			Provider:      query.Get("provider"),
+			CorrelationID: query.Get("correlation_id"),
+			ServiceID:     query.Get("service_id"),
+			Limit:         100,
+		}
+		if v := query.Get("since"); v != "" {
+			since, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, "since must be an RFC 3339 time")
+				return
+			}
+			filter.Since = since
+		}
+		if v := query.Get("limit"); v != "" {
+			if n, err := strconv.Atoi(v); err == nil && n > 0 && n <= 1000 {
+				filter.Limit = n
+			}
+		}
+
+		entries := h.providerJournal.Query(filter)
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"entries": entries,
+			"count":   len(entries),
+		})
+	})
+
+	// The AI usage tier of an org, which decides its users' daily quota
+	r.Put("/usage/tiers/{org_id}", func(w http.ResponseWriter, r *http.Request) {
+		orgID := chi.URLParam(r, "org_id")
+		var req struct {
+			Tier string `json:"tier"`
+		}
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+
+		if err := h.usageTracker.SetTier(orgID, req.Tier); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		logrus.WithFields(logrus.Fields{"org_id": orgID, "tier": req.Tier}).Info("🧮 AI usage tier changed")
+		writeJSON(w, http.StatusOK, map[string]interface{}{"org_id": orgID, "tier": req.Tier})
+	})
+}
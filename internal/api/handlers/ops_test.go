@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/avvvet/cdnbuddy-api/internal/admin"
+	"github.com/avvvet/cdnbuddy-api/internal/api/problem"
+	"github.com/avvvet/cdnbuddy-api/internal/services/cdn"
+	"github.com/avvvet/cdnbuddy-api/internal/services/ownership"
+	"github.com/avvvet/cdnbuddy-api/internal/services/planstorage"
+	"github.com/avvvet/cdnbuddy-api/internal/services/usage"
+)
+
+func TestOpsRoutes(t *testing.T) {
+	tracker, err := usage.NewTracker(nil, nil, 100)
+	if err != nil {
+		t.Fatalf("tracker: %v", err)
+	}
+	h := NewOpsHandler(nil, nil, nil, nil, cdn.NewJournal(10, time.Hour), nil, nil, nil, tracker)
+	insight := NewAdminHandler(nil, planstorage.NewStorage(10), nil, ownership.NewStore(), nil)
+	r := chi.NewRouter()
+	r.Group(func(r chi.Router) {
+		r.Use(admin.RequireToken("secret"))
+		h.Routes(r)
+		insight.Routes(r)
+	})
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		token      string
+		body       string
+		wantStatus int
+		wantCode   string // empty = not a problem
+	}{
+		{name: "missing token", method: http.MethodGet, path: "/provider-journal", wantStatus: http.StatusForbidden, wantCode: "forbidden"},
+		{name: "journal", method: http.MethodGet, path: "/provider-journal", token: "secret", wantStatus: http.StatusOK},
+		{name: "invalid since", method: http.MethodGet, path: "/provider-journal?since=yesterday", token: "secret", wantStatus: http.StatusBadRequest, wantCode: "bad_request"},
+		{name: "tier", method: http.MethodPut, path: "/usage/tiers/org-1", token: "secret", body: `{"tier":"pro"}`, wantStatus: http.StatusOK},
+		{name: "unknown tier", method: http.MethodPut, path: "/usage/tiers/org-1", token: "secret", body: `{"tier":"gold"}`, wantStatus: http.StatusBadRequest, wantCode: "bad_request"},
+		{name: "invalid body", method: http.MethodPut, path: "/usage/tiers/org-1", token: "secret", body: `{`, wantStatus: http.StatusBadRequest, wantCode: problem.CodeInvalidBody},
+		{name: "admin insight without token", method: http.MethodGet, path: "/admin/plans", wantStatus: http.StatusForbidden, wantCode: "forbidden"},
+		{name: "admin insight with wrong token", method: http.MethodGet, path: "/admin/tenants", token: "guess", wantStatus: http.StatusForbidden, wantCode: "forbidden"},
+		{name: "admin insight", method: http.MethodGet, path: "/admin/plans", token: "secret", wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			isProblem := w.Header().Get("Content-Type") == problem.ContentType
+			if isProblem != (tt.wantCode != "") {
+				t.Fatalf("content type = %q, want a problem %v", w.Header().Get("Content-Type"), tt.wantCode != "")
+			}
+			if !isProblem {
+				return
+			}
+			var p problem.Problem
+			if err := json.NewDecoder(w.Body).Decode(&p); err != nil {
+				t.Fatalf("invalid problem: %v", err)
+			}
+			if p.Code != tt.wantCode || p.Status != tt.wantStatus {
+				t.Errorf("problem = %+v, want code %s", p, tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,287 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sirupsen/logrus"
+
+	"github.com/avvvet/cdnbuddy-api/internal/features"
+	apimw "github.com/avvvet/cdnbuddy-api/internal/middleware"
+	"github.com/avvvet/cdnbuddy-api/internal/services/branding"
+	"github.com/avvvet/cdnbuddy-api/internal/services/cdn"
+	"github.com/avvvet/cdnbuddy-api/internal/services/dns"
+	"github.com/avvvet/cdnbuddy-api/internal/services/sandbox"
+	"github.com/avvvet/cdnbuddy-api/internal/services/share"
+	"github.com/avvvet/cdnbuddy-api/internal/services/widget"
+)
+
+// OrgHandler serves an org's branding, vanity DNS, widget tokens, CORS
+// origins and public share links
+type OrgHandler struct {
+	cdnService    *cdn.Service
+	flags         *features.Flags
+	sandboxes     *sandbox.Manager
+	corsPolicy    *apimw.CORS
+	brandingStore *branding.Store
+	vanity        *dns.Vanity
+	shareSigner   *share.Signer
+	widgetTokens  *widget.Issuer
+}
+
+// NewOrgHandler creates the handler
+func NewOrgHandler(cdnService *cdn.Service, flags *features.Flags, sandboxes *sandbox.Manager, corsPolicy *apimw.CORS, brandingStore *branding.Store, vanity *dns.Vanity, shareSigner *share.Signer, widgetTokens *widget.Issuer) *OrgHandler {
+	return &OrgHandler{
+		cdnService:    cdnService,
+		flags:         flags,
+		sandboxes:     sandboxes,
+		corsPolicy:    corsPolicy,
+		brandingStore: brandingStore,
+		vanity:        vanity,
+		shareSigner:   shareSigner,
+		widgetTokens:  widgetTokens,
+	}
+}
+
+// PublicRoutes registers routes served outside /api/v1 without authentication
+func (h *OrgHandler) PublicRoutes(r chi.Router) {
+	// Public status of a shared service; the signed token is the only credential
+	r.Get("/share/{token}", func(w http.ResponseWriter, r *http.Request) {
+		claims, err := h.shareSigner.Verify(chi.URLParam(r, "token"))
+		if err != nil {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+
+		svc, err := ResolveCDNService(h.sandboxes, h.cdnService, h.flags, claims.OrgID, "", string(claims.Provider))
+		if err != nil {
+			writeError(w, http.StatusNotFound, "shared service is no longer available")
+			return
+		}
+		overview, err := svc.GetServiceOverview(r.Context(), claims.ServiceID)
+		if err != nil {
+			if writeProviderUnavailable(w, err) {
+				return
+			}
+			writeError(w, http.StatusNotFound, "shared service is no longer available")
+			return
+		}
+
+		w.Header().Set("Cache-Control", "private, max-age=60")
+		w.Header().Set("X-Robots-Tag", "noindex")
+		writeJSON(w, http.StatusOK, share.NewView(overview.Service, overview.Domains, overview.Metrics, claims))
+	})
+}
+
+// Routes registers the handler's routes on the /api/v1 router
+func (h *OrgHandler) Routes(r chi.Router) {
+	// White-label branding of an org; public so status pages can render it
+	r.Route("/branding", func(r chi.Router) {
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, http.StatusOK, h.brandingStore.Get(OrgIDFromQuery(r)))
+		})
+
+		r.Put("/", func(w http.ResponseWriter, r *http.Request) {
+			var settings branding.Settings
+			if !decodeJSON(w, r, &settings) {
+				return
+			}
+
+			settings, err := h.brandingStore.Set(OrgIDFromQuery(r), settings)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+
+			logrus.WithFields(logrus.Fields{
+				"org_id":        OrgIDFromQuery(r),
+				"product_name":  settings.ProductName,
+				"custom_domain": settings.CustomDomain,
+			}).Info("🎨 Branding updated")
+
+			writeJSON(w, http.StatusOK, settings)
+		})
+	})
+
+	// Vanity zone whose hostnames DNS instructions show instead of the
+	// provider's, and the chains created in it
+	r.Route("/dns/vanity", func(r chi.Router) {
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+			orgID := OrgIDFromQuery(r)
+			zone, _ := h.vanity.Zone(orgID)
+			writeJSON(w, http.StatusOK, map[string]interface{}{
+				"org_id":  orgID,
+				"zone":    zone,
+				"managed": h.vanity.Managed(),
+				"chains":  h.vanity.Chains(orgID),
+			})
+		})
+
+		r.Put("/", func(w http.ResponseWriter, r *http.Request) {
+			var req struct {
+				Zone string `json:"zone"`
+			}
+			if !decodeJSON(w, r, &req) {
+				return
+			}
+
+			orgID := OrgIDFromQuery(r)
+			zone, err := h.vanity.SetZone(orgID, req.Zone)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+
+			logrus.WithFields(logrus.Fields{
+				"org_id": orgID,
+				"zone":   zone,
+			}).Info("🏷️ Vanity zone set")
+
+			writeJSON(w, http.StatusOK, map[string]interface{}{
+				"org_id":  orgID,
+				"zone":    zone,
+				"managed": h.vanity.Managed(),
+			})
+		})
+
+		r.Delete("/", func(w http.ResponseWriter, r *http.Request) {
+			if err := h.vanity.RemoveZone(r.Context(), OrgIDFromQuery(r)); err != nil {
+				writeError(w, http.StatusBadGateway, err.Error())
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+
+		// Re-check that provider certificates cover the h.vanity hostnames
+		r.Post("/check", func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, http.StatusOK, map[string]interface{}{
+				"org_id": OrgIDFromQuery(r),
+				"chains": h.vanity.CheckCertificates(OrgIDFromQuery(r)),
+			})
+		})
+	})
+
+	// Tokens for the embedded chat widget. The page origin must be registered
+	// under /cors/origins first so the widget can reach the API from it.
+	r.Route("/widget/tokens", func(r chi.Router) {
+		r.Post("/", func(w http.ResponseWriter, r *http.Request) {
+			var req struct {
+				Origin string `json:"origin"`
+				UserID string `json:"user_id,omitempty"` // the customer's user; generated if empty
+				TTL    string `json:"ttl,omitempty"`     // e.g. "30m"; at most 1h
+			}
+			if !decodeJSON(w, r, &req) {
+				return
+			}
+			var v validator
+			v.required("origin", req.Origin)
+			if v.failed(w) {
+				return
+			}
+
+			orgID := OrgIDFromQuery(r)
+			origin, err := apimw.NormalizeOrigin(req.Origin)
+			if err == nil && !slices.Contains(h.corsPolicy.Origins(orgID), origin) {
+				err = fmt.Errorf("origin %s is not registered for this org; add it under /api/v1/cors/origins first", origin)
+			}
+			var ttl time.Duration
+			if err == nil && req.TTL != "" {
+				if ttl, err = time.ParseDuration(req.TTL); err != nil {
+					err = fmt.Errorf("invalid ttl")
+				}
+			}
+			var token *widget.Token
+			if err == nil {
+				token, err = h.widgetTokens.Issue(orgID, origin, req.UserID, ttl)
+			}
+			if err != nil {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+
+			logrus.WithFields(logrus.Fields{
+				"org_id":     orgID,
+				"origin":     origin,
+				"user_id":    token.UserID,
+				"expires_at": token.ExpiresAt,
+			}).Info("🎟️ Widget token issued")
+			writeJSON(w, http.StatusCreated, token)
+		})
+
+		// For the socket server to check a token when a widget connects
+		r.Post("/verify", func(w http.ResponseWriter, r *http.Request) {
+			var req struct {
+				Token  string `json:"token"`
+				Origin string `json:"origin"`
+			}
+			if !decodeJSON(w, r, &req) {
+				return
+			}
+			var v validator
+			v.required("token", req.Token)
+			if v.failed(w) {
+				return
+			}
+
+			claims, err := h.widgetTokens.Verify(req.Token, req.Origin)
+			if err != nil {
+				writeError(w, http.StatusUnauthorized, err.Error())
+				return
+			}
+
+			writeJSON(w, http.StatusOK, claims)
+		})
+	})
+
+	// Origins of an org's embedded or white-label frontends; see CORS_TENANT_ROUTES
+	// for the methods they may use per route
+	r.Route("/cors/origins", func(r chi.Router) {
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, http.StatusOK, map[string]interface{}{
+				"org_id":  OrgIDFromQuery(r),
+				"origins": h.corsPolicy.Origins(OrgIDFromQuery(r)),
+				"routes":  h.corsPolicy.Routes(),
+			})
+		})
+
+		r.Post("/", func(w http.ResponseWriter, r *http.Request) {
+			var req struct {
+				Origin string `json:"origin"`
+			}
+			if !decodeJSON(w, r, &req) {
+				return
+			}
+
+			origin, err := h.corsPolicy.AddOrigin(OrgIDFromQuery(r), req.Origin)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+
+			logrus.WithFields(logrus.Fields{
+				"org_id": OrgIDFromQuery(r),
+				"origin": origin,
+			}).Info("🌐 CORS origin registered")
+
+			writeJSON(w, http.StatusCreated, map[string]interface{}{
+				"org_id":  OrgIDFromQuery(r),
+				"origins": h.corsPolicy.Origins(OrgIDFromQuery(r)),
+			})
+		})
+
+		r.Delete("/", func(w http.ResponseWriter, r *http.Request) {
+			if !h.corsPolicy.RemoveOrigin(OrgIDFromQuery(r), r.URL.Query().Get("origin")) {
+				writeError(w, http.StatusNotFound, "origin not registered")
+				return
+			}
+
+			writeJSON(w, http.StatusOK, map[string]interface{}{
+				"org_id":  OrgIDFromQuery(r),
+				"origins": h.corsPolicy.Origins(OrgIDFromQuery(r)),
+			})
+		})
+	})
+}
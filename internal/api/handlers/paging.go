@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"cmp"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// List endpoints share one query convention:
+//
+//	limit     page size, default 100, at most 500
+//	cursor    opaque position returned in the Link header of the previous page
+//	sort      a sortable field of the endpoint, prefixed with - for descending
+//	status    exact status match
+//	provider  exact provider match
+//	q         case-insensitive substring of the item's searchable text
+//
+// Responses carry X-Total-Count and a Link header with rel="next" and
+// rel="first" URLs; the body keeps the endpoint's own shape.
+const (
+	defaultPageSize = 100
+	maxPageSize     = 500
+)
+
+// listSpec describes how the items of one endpoint are filtered and sorted.
+// Nil status/provider functions mean the endpoint filters those itself.
+type listSpec[T any] struct {
+	sorts       map[string]func(a, b T) int
+	defaultSort string
+	status      func(T) string
+	provider    func(T) string
+	text        func(T) []string
+}
+
+// listQuery is a parsed list query
+type listQuery struct {
+	limit    int
+	offset   int
+	sort     string
+	desc     bool
+	status   string
+	provider string
+	q        string
+}
+
+// parseListQuery reads the shared list parameters; sort must be one of fields
+func parseListQuery(r *http.Request, sortFields []string, defaultSort string) (listQuery, error) {
+	query := r.URL.Query()
+	lq := listQuery{
+		limit:    defaultPageSize,
+		status:   query.Get("status"),
+		provider: query.Get("provider"),
+		q:        strings.ToLower(strings.TrimSpace(query.Get("q"))),
+	}
+
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxPageSize {
+			return lq, fmt.Errorf("limit must be between 1 and %d", maxPageSize)
+		}
+		lq.limit = n
+	}
+
+	if v := query.Get("cursor"); v != "" {
+		offset, err := decodeCursor(v)
+		if err != nil {
+			return lq, fmt.Errorf("invalid cursor")
+		}
+		lq.offset = offset
+	}
+
+	sort := query.Get("sort")
+	if sort == "" {
+		sort = defaultSort
+	}
+	lq.desc = strings.HasPrefix(sort, "-")
+	lq.sort = strings.TrimPrefix(sort, "-")
+	if lq.sort != "" && !slices.Contains(sortFields, lq.sort) {
+		return lq, fmt.Errorf("sort must be one of %s, optionally prefixed with -", strings.Join(sortFields, ", "))
+	}
+	return lq, nil
+}
+
+// paginate filters, sorts and pages items by the request's list query and
+// sets the paging headers. It writes a 400 and returns false for bad queries.
+func paginate[T any](w http.ResponseWriter, r *http.Request, items []T, spec listSpec[T]) ([]T, bool) {
+	fields := make([]string, 0, len(spec.sorts))
+	for field := range spec.sorts {
+		fields = append(fields, field)
+	}
+	slices.Sort(fields)
+
+	lq, err := parseListQuery(r, fields, spec.defaultSort)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+
+	filtered := make([]T, 0, len(items))
+	for _, item := range items {
+		if lq.status != "" && spec.status != nil && !strings.EqualFold(spec.status(item), lq.status) {
+			continue
+		}
+		if lq.provider != "" && spec.provider != nil && !strings.EqualFold(spec.provider(item), lq.provider) {
+			continue
+		}
+		if lq.q != "" && spec.text != nil && !matchesText(spec.text(item), lq.q) {
+			continue
+		}
+		filtered = append(filtered, item)
+	}
+
+	if compare, ok := spec.sorts[lq.sort]; ok {
+		slices.SortStableFunc(filtered, func(a, b T) int {
+			if lq.desc {
+				return compare(b, a)
+			}
+			return compare(a, b)
+		})
+	}
+
+	total := len(filtered)
+	start := min(lq.offset, total)
+	end := min(start+lq.limit, total)
+	setPageHeaders(w, r, total, end)
+	return filtered[start:end], true
+}
+
+// setPageHeaders links to the first page and, if items remain after next, to the next one
+func setPageHeaders(w http.ResponseWriter, r *http.Request, total, next int) {
+	link := func(cursor, rel string) string {
+		u := url.URL{Path: r.URL.Path}
+		query := r.URL.Query()
+		query.Del("cursor")
+		if cursor != "" {
+			query.Set("cursor", cursor)
+		}
+		u.RawQuery = query.Encode()
+		return fmt.Sprintf("<%s>; rel=%q", u.String(), rel)
+	}
+
+	links := []string{link("", "first")}
+	if next < total {
+		links = append(links, link(encodeCursor(next), "next"))
+	}
+	w.Header().Set("Link", strings.Join(links, ", "))
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+}
+
+func matchesText(values []string, q string) bool {
+	for _, v := range values {
+		if strings.Contains(strings.ToLower(v), q) {
+			return true
+		}
+	}
+	return false
+}
+
+// Cursors are opaque to clients; today they encode an offset
+func encodeCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte("o:" + strconv.Itoa(offset)))
+}
+
+func decodeCursor(cursor string) (int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, err
+	}
+	v, ok := strings.CutPrefix(string(raw), "o:")
+	if !ok {
+		return 0, fmt.Errorf("unknown cursor format")
+	}
+	offset, err := strconv.Atoi(v)
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("invalid cursor offset")
+	}
+	return offset, nil
+}
+
+// byString and byTime build sort comparisons from a field accessor
+func byString[T any](field func(T) string) func(a, b T) int {
+	return func(a, b T) int { return cmp.Compare(strings.ToLower(field(a)), strings.ToLower(field(b))) }
+}
+
+func byTime[T any](field func(T) time.Time) func(a, b T) int {
+	return func(a, b T) int { return field(a).Compare(field(b)) }
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+type pagedItem struct {
+	name   string
+	status string
+}
+
+func TestPaginate(t *testing.T) {
+	items := []pagedItem{
+		{name: "charlie", status: "active"},
+		{name: "alpha", status: "pending"},
+		{name: "bravo", status: "active"},
+		{name: "delta", status: "active"},
+	}
+	spec := listSpec[pagedItem]{
+		sorts: map[string]func(a, b pagedItem) int{
+			"name": byString(func(i pagedItem) string { return i.name }),
+		},
+		status: func(i pagedItem) string { return i.status },
+		text:   func(i pagedItem) []string { return []string{i.name} },
+	}
+
+	tests := []struct {
+		name      string
+		query     string
+		wantNames []string
+		wantTotal string
+		wantNext  bool
+		wantCode  int
+	}{
+		{name: "defaults keep order", query: "", wantNames: []string{"charlie", "alpha", "bravo", "delta"}, wantTotal: "4"},
+		{name: "sort ascending", query: "sort=name", wantNames: []string{"alpha", "bravo", "charlie", "delta"}, wantTotal: "4"},
+		{name: "sort descending with limit", query: "sort=-name&limit=2", wantNames: []string{"delta", "charlie"}, wantTotal: "4", wantNext: true},
+		{name: "status filter", query: "status=ACTIVE&sort=name", wantNames: []string{"bravo", "charlie", "delta"}, wantTotal: "3"},
+		{name: "text search", query: "q=LT", wantNames: []string{"delta"}, wantTotal: "1"},
+		{name: "cursor past the end", query: "cursor=" + encodeCursor(10), wantNames: []string{}, wantTotal: "4"},
+		{name: "unknown sort", query: "sort=size", wantCode: http.StatusBadRequest},
+		{name: "limit too large", query: "limit=501", wantCode: http.StatusBadRequest},
+		{name: "malformed cursor", query: "cursor=!!", wantCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/items?"+tt.query, nil)
+
+			page, ok := paginate(w, r, items, spec)
+			if tt.wantCode != 0 {
+				if ok || w.Code != tt.wantCode {
+					t.Fatalf("paginate() ok = %v, code = %d, want code %d", ok, w.Code, tt.wantCode)
+				}
+				return
+			}
+			if !ok {
+				t.Fatalf("paginate() rejected the query: %s", w.Body.String())
+			}
+
+			names := make([]string, 0, len(page))
+			for _, item := range page {
+				names = append(names, item.name)
+			}
+			if !slices.Equal(names, tt.wantNames) {
+				t.Errorf("paginate() = %v, want %v", names, tt.wantNames)
+			}
+			if got := w.Header().Get("X-Total-Count"); got != tt.wantTotal {
+				t.Errorf("X-Total-Count = %s, want %s", got, tt.wantTotal)
+			}
+			if got := strings.Contains(w.Header().Get("Link"), `rel="next"`); got != tt.wantNext {
+				t.Errorf("Link has next = %v, want %v: %s", got, tt.wantNext, w.Header().Get("Link"))
+			}
+		})
+	}
+}
+
+func TestPaginateFollowsNextLink(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5}
+	spec := listSpec[int]{}
+
+	var got []int
+	target := "/items?limit=2"
+	for pages := 0; target != ""; pages++ {
+		if pages > 5 {
+			t.Fatal("next links never ran out")
+		}
+		w := httptest.NewRecorder()
+		page, ok := paginate(w, httptest.NewRequest(http.MethodGet, target, nil), items, spec)
+		if !ok {
+			t.Fatalf("paginate() rejected %s", target)
+		}
+		got = append(got, page...)
+
+		target = ""
+		for _, link := range strings.Split(w.Header().Get("Link"), ", ") {
+			if url, ok := strings.CutSuffix(link, `>; rel="next"`); ok {
+				target = strings.TrimPrefix(url, "<")
+			}
+		}
+	}
+
+	if !slices.Equal(got, items) {
+		t.Errorf("walking next links returned %v, want %v", got, items)
+	}
+}
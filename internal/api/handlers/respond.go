@@ -0,0 +1,198 @@
+// Package handlers holds the HTTP handlers of the public API, grouped by
+// area into structs that get their dependencies injected, and the router
+// that mounts them.
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/avvvet/cdnbuddy-api/internal/api/problem"
+	"github.com/avvvet/cdnbuddy-api/internal/features"
+	"github.com/avvvet/cdnbuddy-api/internal/services/apikeys"
+	"github.com/avvvet/cdnbuddy-api/internal/services/cdn"
+	"github.com/avvvet/cdnbuddy-api/internal/services/messaging"
+	"github.com/avvvet/cdnbuddy-api/internal/services/reminders"
+	"github.com/avvvet/cdnbuddy-api/internal/services/sandbox"
+)
+
+// ResolveCDNService returns the CDN service for a request: the visitor's sandbox
+// tenant when a sandbox ID is given, otherwise the real account with the
+// tenant's provider modes applied, scoped to the named provider (empty = all)
+func ResolveCDNService(sandboxes *sandbox.Manager, cdnService *cdn.Service, flags *features.Flags, tenantID, sandboxID, provider string) (*cdn.Service, error) {
+	if sandboxID == "" {
+		scoped, err := cdnService.Scoped(flags.ModeFunc(tenantID))
+		if err != nil {
+			return nil, err
+		}
+		return scoped.ForProvider(cdn.ParseProvider(provider))
+	}
+
+	sb, err := sandboxes.Get(sandboxID)
+	if err != nil {
+		return nil, err
+	}
+	return sb.Service, nil
+}
+
+// digestSettingsResponse renders digest settings with a readable interval
+func digestSettingsResponse(s messaging.DigestSettings) map[string]interface{} {
+	return map[string]interface{}{
+		"enabled":  s.Enabled,
+		"interval": s.Interval.String(),
+	}
+}
+
+// OrgIDFromQuery returns the org of the request's API key, otherwise the
+// org_id query parameter, defaulting to the deployment's own org
+func OrgIDFromQuery(r *http.Request) string {
+	if principal, ok := apikeys.PrincipalFrom(r.Context()); ok {
+		return principal.OrgID
+	}
+	if orgID := r.URL.Query().Get("org_id"); orgID != "" {
+		return orgID
+	}
+	return reminders.DefaultOrgID
+}
+
+// requireUserID returns the user_id query parameter, answering with a
+// validation problem when it's missing
+func requireUserID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	userID := r.URL.Query().Get("user_id")
+	var v validator
+	v.required("user_id", userID)
+	return userID, !v.failed(w)
+}
+
+// writeJSON responds with v encoded as JSON. Every success response goes
+// through it and every error through writeError or problem.Error.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError responds with an RFC 7807 problem carrying the default code for status
+func writeError(w http.ResponseWriter, status int, detail string) {
+	problem.Error(w, status, "", detail)
+}
+
+// maxCertificateBytes bounds certificate uploads; chains are a few KB
+const maxCertificateBytes = 1 << 20
+
+// formText returns a multipart field, read from an uploaded file of that name
+// when there is one
+func formText(r *http.Request, name string) string {
+	if file, _, err := r.FormFile(name); err == nil {
+		defer file.Close()
+		data, err := io.ReadAll(file)
+		if err == nil {
+			return string(data)
+		}
+	}
+	return r.FormValue(name)
+}
+
+// writeProviderUnavailable answers 503 with Retry-After when err comes from an
+// open provider circuit breaker; it reports whether it wrote a response
+func writeProviderUnavailable(w http.ResponseWriter, err error) bool {
+	var unavailable *cdn.UnavailableError
+	if !errors.As(err, &unavailable) {
+		return false
+	}
+
+	retryAfter := max(1, int(time.Until(unavailable.RetryAt).Seconds())+1)
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	problem.Error(w, http.StatusServiceUnavailable, problem.CodeProviderUnavailable, err.Error())
+	return true
+}
+
+// simulateIfDryRun swaps svc for a simulation of it when the request has
+// ?dry_run=true; the returned simulation is nil otherwise
+func simulateIfDryRun(svc *cdn.Service, r *http.Request) (*cdn.Service, *cdn.Simulation) {
+	if r.URL.Query().Get("dry_run") != "true" {
+		return svc, nil
+	}
+	return svc.Simulate()
+}
+
+// writeDryRun responds with the provider writes a simulated request would
+// have made and the result it would have returned
+func writeDryRun(w http.ResponseWriter, sim *cdn.Simulation, result interface{}) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"dry_run": true,
+		"changes": sim.Steps(),
+		"result":  result,
+	})
+}
+
+// writeCDNError maps CDN configuration errors to status codes: unsupported
+// features are 422, writes to read-only providers 403, an unavailable provider
+// 503, provider failures 502 and anything else a validation error
+func writeCDNError(w http.ResponseWriter, serviceID string, err error) {
+	if writeProviderUnavailable(w, err) {
+		return
+	}
+
+	status, code := http.StatusBadRequest, ""
+	var apiErr *cdn.APIError
+	switch {
+	case errors.Is(err, cdn.ErrNotSupported):
+		status, code = http.StatusUnprocessableEntity, problem.CodeNotSupported
+	case errors.Is(err, cdn.ErrReadOnly):
+		status, code = http.StatusForbidden, problem.CodeReadOnly
+	case errors.Is(err, cdn.ErrNothingToRollBack):
+		status, code = http.StatusNotFound, problem.CodeNothingToRollBack
+	case errors.Is(err, cdn.ErrChangesPaused):
+		status, code = http.StatusConflict, problem.CodeChangesPaused
+	case errors.As(err, &apiErr):
+		status, code = http.StatusBadGateway, problem.CodeProviderError
+		logrus.WithError(err).WithField("service_id", serviceID).Error("❌ CDN provider request failed")
+	}
+
+	problem.Error(w, status, code, err.Error())
+}
+
+// writeJSONWithETag responds with v and an ETag of the body, or with 304 Not
+// Modified when If-None-Match already names it, so pollers only download
+// resources that changed
+func writeJSONWithETag(w http.ResponseWriter, r *http.Request, v interface{}) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(append(body, '\n'))
+}
+
+// etagMatches compares an If-None-Match list weakly, as RFC 9110 asks for GETs
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
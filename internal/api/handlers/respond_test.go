@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWriteJSONWithETag(t *testing.T) {
+	service := map[string]string{"id": "svc-1", "status": "ACTIVE"}
+	serve := func(ifNoneMatch string, v interface{}) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/cdn/services/svc-1", nil)
+		if ifNoneMatch != "" {
+			r.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		writeJSONWithETag(w, r, v)
+		return w
+	}
+
+	first := serve("", service)
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" || first.Body.Len() == 0 {
+		t.Fatalf("first response = %d with ETag %q and %d bytes, want 200 with an ETag and a body", first.Code, etag, first.Body.Len())
+	}
+
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		body        interface{}
+		wantStatus  int
+	}{
+		{name: "unchanged", ifNoneMatch: etag, body: service, wantStatus: http.StatusNotModified},
+		{name: "weak validator", ifNoneMatch: "W/" + etag, body: service, wantStatus: http.StatusNotModified},
+		{name: "one of several", ifNoneMatch: `"stale", ` + etag, body: service, wantStatus: http.StatusNotModified},
+		{name: "any", ifNoneMatch: "*", body: service, wantStatus: http.StatusNotModified},
+		{name: "changed", ifNoneMatch: etag, body: map[string]string{"id": "svc-1", "status": "DEPLOYING"}, wantStatus: http.StatusOK},
+		{name: "other tag", ifNoneMatch: `"stale"`, body: service, wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(tt.ifNoneMatch, tt.body)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if w.Header().Get("ETag") == "" {
+				t.Error("response has no ETag")
+			}
+			if tt.wantStatus == http.StatusNotModified && w.Body.Len() != 0 {
+				t.Errorf("304 has a %d byte body", w.Body.Len())
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/sirupsen/logrus"
+)
+
+// Router mounts every handler of the public API
+type Router struct {
+	health       *HealthHandler
+	cdn          *CDNHandler
+	operations   *OperationHandler
+	account      *AccountHandler
+	org          *OrgHandler
+	chat         *ChatHandler
+	integrations *IntegrationHandler
+	webhooks     *WebhookHandler
+	apiKeys      *APIKeyHandler
+}
+
+// NewRouter creates the API router from its handlers
+func NewRouter(health *HealthHandler, cdn *CDNHandler, operations *OperationHandler, account *AccountHandler, org *OrgHandler, chat *ChatHandler, integrations *IntegrationHandler, webhooks *WebhookHandler, apiKeys *APIKeyHandler) *Router {
+	return &Router{
+		health:       health,
+		cdn:          cdn,
+		operations:   operations,
+		account:      account,
+		org:          org,
+		chat:         chat,
+		integrations: integrations,
+		webhooks:     webhooks,
+		apiKeys:      apiKeys,
+	}
+}
+
+// Mount registers the API routes on r
+func (rt *Router) Mount(r chi.Router) {
+	r.Get("/health", rt.health.Health)
+	rt.org.PublicRoutes(r)
+	rt.integrations.PublicRoutes(r)
+
+	// API version 1 routes
+	r.Route("/api/v1", func(r chi.Router) {
+		r.Get("/health", rt.health.APIHealth)
+		r.Get("/health/deep", rt.health.DeepHealth)
+
+		rt.cdn.Routes(r)
+		rt.operations.Routes(r)
+		rt.account.Routes(r)
+		rt.org.Routes(r)
+		rt.chat.Routes(r)
+		rt.integrations.Routes(r)
+		rt.webhooks.Routes(r)
+		rt.apiKeys.Routes(r)
+	})
+
+	// API version 2: one async resource model, see v2.go
+	r.Route("/api/v2", func(r chi.Router) {
+		rt.cdn.RoutesV2(r)
+		rt.operations.RoutesV2(r)
+	})
+
+	logrus.Info("✅ Routes configured")
+}
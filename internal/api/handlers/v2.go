@@ -0,0 +1,274 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sirupsen/logrus"
+
+	"github.com/avvvet/cdnbuddy-api/internal/domain"
+	"github.com/avvvet/cdnbuddy-api/internal/services/audit"
+	"github.com/avvvet/cdnbuddy-api/internal/services/cdn"
+	"github.com/avvvet/cdnbuddy-api/internal/services/messaging"
+	"github.com/avvvet/cdnbuddy-api/internal/services/operations"
+)
+
+// API version 2 gives services, plans and operations one resource model:
+//
+//   - a read returns the resource itself, never wrapped
+//   - a list returns {"data": [...]}, paged as described in paging.go
+//   - a change runs asynchronously as an operation and is answered with
+//     201 Created, a Location header naming the operation and the operation
+//     itself, to be polled until it finishes
+//   - errors are RFC 7807 problems, as in v1
+//
+// v1 keeps its shapes; both versions share the handlers' dependencies.
+
+// pollAfter is the Retry-After of unfinished operations, in seconds
+const pollAfter = "2"
+
+// v2Page is the body of a v2 list
+type v2Page[T any] struct {
+	Data []T `json:"data"`
+}
+
+// writeOperationCreated answers a change accepted as op
+func writeOperationCreated(w http.ResponseWriter, op operations.Operation) {
+	w.Header().Set("Location", "/api/v2/operations/"+url.PathEscape(op.ID))
+	writeJSON(w, http.StatusCreated, op)
+}
+
+// RoutesV2 registers the handler's services on the /api/v2 router
+func (h *CDNHandler) RoutesV2(r chi.Router) {
+	r.Route("/services", func(r chi.Router) {
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+			services, _, ok := h.listServices(w, r)
+			if !ok {
+				return
+			}
+
+			writeJSONWithETag(w, r, v2Page[domain.CDNService]{Data: services})
+		})
+
+		r.Get("/{serviceID}", func(w http.ResponseWriter, r *http.Request) {
+			serviceID := chi.URLParam(r, "serviceID")
+			svc, err := ResolveCDNService(h.sandboxes, h.cdnService, h.flags, OrgIDFromQuery(r), r.URL.Query().Get("sandbox_id"), r.URL.Query().Get("provider"))
+			if err != nil {
+				writeError(w, http.StatusNotFound, err.Error())
+				return
+			}
+
+			service, err := svc.GetService(r.Context(), serviceID)
+			if err != nil {
+				writeCDNError(w, serviceID, err)
+				return
+			}
+
+			writeJSONWithETag(w, r, service)
+		})
+
+		// A purge runs after other changes to the service, like plan executions
+		r.Post("/{serviceID}/purges", func(w http.ResponseWriter, r *http.Request) {
+			serviceID := chi.URLParam(r, "serviceID")
+			svc, err := ResolveCDNService(h.sandboxes, h.cdnService, h.flags, OrgIDFromQuery(r), r.URL.Query().Get("sandbox_id"), r.URL.Query().Get("provider"))
+			if err != nil {
+				writeError(w, http.StatusNotFound, err.Error())
+				return
+			}
+
+			var req purgeRequest
+			if !decodeJSON(w, r, &req) {
+				return
+			}
+			var v validator
+			req.validate(&v)
+			if v.failed(w) {
+				return
+			}
+
+			service, err := svc.GetService(r.Context(), serviceID)
+			if err != nil {
+				writeCDNError(w, serviceID, err)
+				return
+			}
+
+			action, title := "PURGE_CACHE", fmt.Sprintf("Purge %d paths", len(req.Paths))
+			if req.PurgeAll {
+				action, title = "PURGE_ALL", "Purge all cached content"
+			}
+			op := h.operationQueue.Enqueue(operations.Operation{
+				UserID:    req.UserID,
+				Action:    action,
+				Provider:  string(service.Provider),
+				Title:     title,
+				ServiceID: serviceID,
+			})
+			go h.runPurge(svc, op, req)
+
+			logrus.WithFields(logrus.Fields{
+				"service_id":   serviceID,
+				"operation_id": op.ID,
+			}).Info("🧹 Cache purge accepted")
+			writeOperationCreated(w, op)
+		})
+	})
+}
+
+// runPurge runs an accepted purge once its service is free
+func (h *CDNHandler) runPurge(svc *cdn.Service, op operations.Operation, req purgeRequest) {
+	defer h.operationQueue.Done(op.ID)
+	ctx := h.operationQueue.Cancellable(cdn.WithCorrelationID(context.Background(), op.ID), op.ID)
+	if err := h.operationQueue.Wait(ctx, op.ID); err != nil {
+		h.operationQueue.Finish(op.ID, "", err)
+		return
+	}
+
+	paths := []string{"/*"}
+	var err error
+	if req.PurgeAll {
+		err = svc.PurgeAll(ctx, op.ServiceID)
+	} else {
+		paths, err = svc.PurgeCache(ctx, op.ServiceID, req.Paths)
+	}
+	if err != nil {
+		h.operationQueue.Finish(op.ID, "", err)
+		logrus.WithError(err).WithField("service_id", op.ServiceID).Error("❌ Cache purge failed")
+		return
+	}
+	h.operationQueue.Finish(op.ID, fmt.Sprintf("Purged %d paths", len(paths)), nil)
+
+	if err := h.publisher.PublishCachePurged(op.ServiceID, req.UserID, paths); err != nil {
+		logrus.WithError(err).WithField("service_id", op.ServiceID).Error("❌ Failed to publish cache purge event")
+	}
+	logrus.WithFields(logrus.Fields{
+		"service_id":   op.ServiceID,
+		"operation_id": op.ID,
+		"paths":        len(paths),
+	}).Info("🧹 Cache purged")
+}
+
+// RoutesV2 registers the handler's plans and operations on the /api/v2 router
+func (h *OperationHandler) RoutesV2(r chi.Router) {
+	r.Route("/plans", func(r chi.Router) {
+		r.Get("/{planID}", func(w http.ResponseWriter, r *http.Request) {
+			plan, err := h.planStorage.Get(chi.URLParam(r, "planID"))
+			if err != nil {
+				writeError(w, http.StatusNotFound, err.Error())
+				return
+			}
+
+			writeJSON(w, http.StatusOK, plan)
+		})
+
+		// Executing a plan is accepted as an operation right away; the
+		// executor runs it under that ID. Use /api/v1/schedules to run later.
+		r.Post("/{planID}/executions", func(w http.ResponseWriter, r *http.Request) {
+			planID := chi.URLParam(r, "planID")
+			var req struct {
+				UserID    string `json:"user_id"`
+				SessionID string `json:"session_id"`
+				SandboxID string `json:"sandbox_id"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+				writeError(w, http.StatusBadRequest, "invalid request body")
+				return
+			}
+
+			plan, err := h.planStorage.Get(planID)
+			if err != nil {
+				writeError(w, http.StatusNotFound, err.Error())
+				return
+			}
+			if err := plan.CheckSandbox(req.SandboxID); err != nil {
+				writeError(w, http.StatusConflict, err.Error())
+				return
+			}
+
+			entry := audit.EntryFromIntent(req.UserID, req.SessionID, plan.ID, plan.Action, plan.Parameters)
+			op := h.operationQueue.Accept(operations.Operation{
+				PlanID:     plan.ID,
+				UserID:     req.UserID,
+				SessionID:  req.SessionID,
+				Action:     plan.Action,
+				Title:      plan.Title,
+				ServiceID:  entry.ServiceID,
+				Domain:     entry.Domain,
+				Parameters: entry.Parameters,
+			})
+
+			err = h.publisher.PublishExecuteCommand(messaging.ExecuteCommand{
+				UserID:      req.UserID,
+				SessionID:   req.SessionID,
+				SandboxID:   req.SandboxID,
+				PlanID:      plan.ID,
+				OperationID: op.ID,
+			})
+			if err != nil {
+				h.operationQueue.Finish(op.ID, "", err)
+				logrus.WithError(err).WithField("plan_id", planID).Error("❌ Failed to publish execute command")
+				writeError(w, http.StatusServiceUnavailable, "failed to submit plan for execution")
+				return
+			}
+
+			logrus.WithFields(logrus.Fields{
+				"plan_id":      planID,
+				"operation_id": op.ID,
+				"user_id":      req.UserID,
+			}).Info("👍 Plan execution accepted")
+			writeOperationCreated(w, op)
+		})
+
+		r.Delete("/{planID}", func(w http.ResponseWriter, r *http.Request) {
+			planID := chi.URLParam(r, "planID")
+			if _, err := h.planStorage.Get(planID); err != nil {
+				writeError(w, http.StatusNotFound, err.Error())
+				return
+			}
+			h.planStorage.Delete(planID)
+
+			logrus.WithField("plan_id", planID).Info("👎 Plan rejected")
+			w.WriteHeader(http.StatusNoContent)
+		})
+	})
+
+	r.Route("/operations", func(r chi.Router) {
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+			ops, ok := h.listOperations(w, r)
+			if !ok {
+				return
+			}
+
+			writeJSON(w, http.StatusOK, v2Page[operations.Operation]{Data: ops})
+		})
+
+		r.Get("/{operationID}", func(w http.ResponseWriter, r *http.Request) {
+			op, err := h.operationStore.Get(chi.URLParam(r, "operationID"))
+			if err != nil {
+				writeError(w, http.StatusNotFound, err.Error())
+				return
+			}
+
+			// Tell pollers when to look again
+			if op.FinishedAt == nil {
+				w.Header().Set("Retry-After", pollAfter)
+			}
+			writeJSON(w, http.StatusOK, op)
+		})
+
+		r.Post("/{operationID}/cancellation", func(w http.ResponseWriter, r *http.Request) {
+			op, err := h.operationQueue.Cancel(chi.URLParam(r, "operationID"), r.URL.Query().Get("user_id"))
+			if err != nil {
+				writeOperationCancelError(w, err)
+				return
+			}
+
+			writeJSON(w, http.StatusOK, op)
+		})
+	})
+}
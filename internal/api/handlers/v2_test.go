@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/avvvet/cdnbuddy-api/internal/models"
+	"github.com/avvvet/cdnbuddy-api/internal/services/messaging"
+	"github.com/avvvet/cdnbuddy-api/internal/services/operations"
+	"github.com/avvvet/cdnbuddy-api/internal/services/planstorage"
+	"github.com/avvvet/cdnbuddy-api/internal/testutil"
+)
+
+func TestV2PlanExecution(t *testing.T) {
+	bus := testutil.StartNATS(t)
+	commands := make(chan messaging.ExecuteCommand, 1)
+	err := messaging.NewSubscriber(bus).RegisterExecuteCommandHandler(func(cmd messaging.ExecuteCommand) error {
+		commands <- cmd
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantStatus int
+	}{
+		{name: "execution is accepted as an operation", method: http.MethodPost, path: "/api/v2/plans/plan-1/executions", wantStatus: http.StatusCreated},
+		{name: "unknown plan", method: http.MethodPost, path: "/api/v2/plans/plan-2/executions", wantStatus: http.StatusNotFound},
+		{name: "sandbox plan can't run on the real account", method: http.MethodPost, path: "/api/v2/plans/plan-3/executions", wantStatus: http.StatusConflict},
+		{name: "rejecting deletes the plan", method: http.MethodDelete, path: "/api/v2/plans/plan-1", wantStatus: http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := operations.NewStore(10)
+			plans := planstorage.NewStorage(10)
+			plans.Store(models.ExecutionPlan{ID: "plan-1", Title: "Purge shop", Action: "PURGE_ALL", ExpiresAt: time.Now().Add(time.Minute)})
+			plans.Store(models.ExecutionPlan{ID: "plan-3", Title: "Purge demo", Action: "PURGE_ALL", SandboxID: "sandbox-1", ExpiresAt: time.Now().Add(time.Minute)})
+			h := NewOperationHandler(store, operations.NewQueue(store, operations.NewDurations(10)), plans, nil, nil, messaging.NewPublisher(bus))
+			r := chi.NewRouter()
+			r.Route("/api/v2", h.RoutesV2)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, strings.NewReader(`{"user_id": "user-1"}`)))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusCreated {
+				return
+			}
+
+			var op operations.Operation
+			if err := json.NewDecoder(w.Body).Decode(&op); err != nil {
+				t.Fatalf("failed to decode operation: %v", err)
+			}
+			if loc := w.Header().Get("Location"); loc != "/api/v2/operations/"+op.ID {
+				t.Errorf("Location = %q, want the operation %s", loc, op.ID)
+			}
+			if op.Status != operations.StatusQueued || op.PlanID != "plan-1" || op.UserID != "user-1" {
+				t.Errorf("operation = %s plan %q user %q, want queued plan-1 by user-1", op.Status, op.PlanID, op.UserID)
+			}
+			if cmd := testutil.Await(t, commands); cmd.OperationID != op.ID || cmd.PlanID != "plan-1" {
+				t.Errorf("execute command = plan %q operation %q, want plan-1 operation %s", cmd.PlanID, cmd.OperationID, op.ID)
+			}
+
+			// The operation resource is there to poll
+			w = httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v2/operations/"+op.ID, nil))
+			if w.Code != http.StatusOK || w.Header().Get("Retry-After") == "" {
+				t.Errorf("polling = %d with Retry-After %q, want 200 with a Retry-After", w.Code, w.Header().Get("Retry-After"))
+			}
+		})
+	}
+}
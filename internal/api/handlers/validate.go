@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/avvvet/cdnbuddy-api/internal/api/problem"
+	"github.com/avvvet/cdnbuddy-api/internal/services/cdn"
+)
+
+// maxTTL bounds cache TTLs to a year, the longest any provider accepts
+const maxTTL = 365 * 24 * 60 * 60
+
+// decodeJSON decodes the request body into v and answers with an invalid_body
+// problem when it isn't valid JSON for v; it reports whether decoding succeeded
+func decodeJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	err := json.NewDecoder(r.Body).Decode(v)
+	if err == nil {
+		return true
+	}
+
+	p := problem.New(http.StatusBadRequest, problem.CodeInvalidBody, "invalid request body")
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.Is(err, io.EOF):
+		p = problem.New(http.StatusBadRequest, problem.CodeInvalidBody, "request body is required")
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		p.Fields = []problem.FieldError{{Field: fieldPath(typeErr.Field), Message: "must be a " + jsonKind(typeErr.Type.Kind().String())}}
+	}
+	problem.Write(w, p)
+	return false
+}
+
+// fieldPath writes the decoder's rules.0.ttl as rules[0].ttl, like the
+// validator does
+func fieldPath(field string) string {
+	var b strings.Builder
+	for i, part := range strings.Split(field, ".") {
+		if _, err := strconv.Atoi(part); err == nil && i > 0 {
+			b.WriteString("[" + part + "]")
+			continue
+		}
+		if i > 0 {
+			b.WriteString(".")
+		}
+		b.WriteString(part)
+	}
+	return b.String()
+}
+
+// jsonKind names a Go kind the way API clients know it
+func jsonKind(kind string) string {
+	switch {
+	case strings.HasPrefix(kind, "int"), strings.HasPrefix(kind, "uint"), strings.HasPrefix(kind, "float"):
+		return "number"
+	case kind == "slice", kind == "array":
+		return "list"
+	case kind == "map", kind == "struct", kind == "ptr":
+		return "object"
+	case kind == "bool":
+		return "boolean"
+	}
+	return kind
+}
+
+// validator collects field errors so a client sees every problem at once
+// rather than the first one
+type validator struct {
+	errors []problem.FieldError
+}
+
+func (v *validator) add(field, format string, args ...interface{}) {
+	v.errors = append(v.errors, problem.FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// check records message for field unless ok
+func (v *validator) check(field string, ok bool, message string) {
+	if !ok {
+		v.add(field, "%s", message)
+	}
+}
+
+func (v *validator) required(field, value string) {
+	v.check(field, strings.TrimSpace(value) != "", "is required")
+}
+
+// domain checks a DNS name a CDN serves, e.g. cdn.example.com or *.example.com
+func (v *validator) domain(field, value string) {
+	if value != "" && !validDomain(strings.TrimPrefix(value, "*.")) {
+		v.add(field, "%q is not a valid domain name", value)
+	}
+}
+
+func (v *validator) domains(field string, values []string) {
+	for i, value := range values {
+		v.required(fmt.Sprintf("%s[%d]", field, i), value)
+		v.domain(fmt.Sprintf("%s[%d]", field, i), value)
+	}
+}
+
+// host checks an origin host, which may also be an IP address
+func (v *validator) host(field, value string) {
+	if value != "" && net.ParseIP(value) == nil && !validDomain(value) {
+		v.add(field, "%q is not a valid host name or IP address", value)
+	}
+}
+
+func (v *validator) ttl(field string, seconds int) {
+	if seconds < 0 || seconds > maxTTL {
+		v.add(field, "must be between 0 and %d seconds", maxTTL)
+	}
+}
+
+// path checks a URL path or path pattern like /static/*
+func (v *validator) path(field, value string) {
+	if !strings.HasPrefix(value, "/") || strings.ContainsAny(value, " \t\n") {
+		v.add(field, "must be a path starting with /")
+	}
+}
+
+// failed answers with a validation_failed problem listing the collected field
+// errors, if any, and reports whether it did
+func (v *validator) failed(w http.ResponseWriter) bool {
+	if len(v.errors) == 0 {
+		return false
+	}
+	p := problem.New(http.StatusBadRequest, problem.CodeValidationFailed, "validation failed")
+	p.Fields = v.errors
+	problem.Write(w, p)
+	return true
+}
+
+// validDomain reports whether name is a fully qualified DNS name: two or more
+// labels of letters, digits and inner hyphens, at most 253 characters
+func validDomain(name string) bool {
+	name = strings.TrimSuffix(name, ".")
+	if len(name) == 0 || len(name) > 253 {
+		return false
+	}
+	labels := strings.Split(name, ".")
+	if len(labels) < 2 {
+		return false
+	}
+	for _, label := range labels {
+		if len(label) == 0 || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, c := range label {
+			if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// origin checks an origin a provider will fetch from
+func (v *validator) origin(field string, origin cdn.OriginConfig) {
+	v.required(field+".host", origin.Host)
+	v.host(field+".host", origin.Host)
+	v.check(field+".port", origin.Port >= 0 && origin.Port <= 65535, "must be between 0 and 65535")
+	v.check(field+".protocol", origin.Protocol == "" || origin.Protocol == "http" || origin.Protocol == "https", "must be http or https")
+}
+
+func (v *validator) cacheRules(field string, rules []cdn.CacheRule) {
+	for i, rule := range rules {
+		prefix := fmt.Sprintf("%s[%d]", field, i)
+		v.path(prefix+".path", rule.Path)
+		v.ttl(prefix+".ttl", rule.TTL)
+		v.ttl(prefix+".browser_ttl", rule.BrowserTTL)
+	}
+}
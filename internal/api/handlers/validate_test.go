@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/avvvet/cdnbuddy-api/internal/api/problem"
+	"github.com/avvvet/cdnbuddy-api/internal/services/cdn"
+)
+
+func TestValidation(t *testing.T) {
+	// The apply endpoint's checks, which cover every validator
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var spec cdn.ServiceSpec
+		if !decodeJSON(w, r, &spec) {
+			return
+		}
+		var v validator
+		v.check("name", spec.Name != "" || spec.ServiceID != "", "name or service_id is required")
+		v.origin("origin", spec.Origin)
+		v.domains("domains", spec.Domains)
+		v.cacheRules("rules", spec.Rules)
+		if v.failed(w) {
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantError  string
+		wantFields []string
+	}{
+		{
+			name:       "valid spec",
+			body:       `{"name": "shop", "origin": {"host": "origin.example.com", "protocol": "https"}, "domains": ["cdn.example.com", "*.example.com"], "rules": [{"path": "/static/*", "ttl": 86400}]}`,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "origin may be an IP",
+			body:       `{"name": "shop", "origin": {"host": "203.0.113.10"}}`,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "every problem is reported",
+			body:       `{"origin": {"host": "https://origin.example.com", "port": 70000, "protocol": "ftp"}, "domains": ["cdn..example.com", "localhost", ""], "rules": [{"path": "static", "ttl": -1, "browser_ttl": 999999999}]}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "validation failed",
+			wantFields: []string{"name", "origin.host", "origin.port", "origin.protocol", "domains[0]", "domains[1]", "domains[2]", "rules[0].path", "rules[0].ttl", "rules[0].browser_ttl"},
+		},
+		{
+			name:       "wrong type",
+			body:       `{"name": "shop", "origin": {"host": "origin.example.com"}, "rules": [{"path": "/", "ttl": "1h"}]}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "invalid request body",
+			wantFields: []string{"rules[0].ttl"},
+		},
+		{
+			name:       "malformed JSON",
+			body:       `{"name": `,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "invalid request body",
+		},
+		{
+			name:       "empty body",
+			body:       ``,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "request body is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/api/v1/cdn/apply", strings.NewReader(tt.body)))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus == http.StatusOK {
+				return
+			}
+
+			if ct := w.Header().Get("Content-Type"); ct != problem.ContentType {
+				t.Errorf("Content-Type = %q, want %q", ct, problem.ContentType)
+			}
+			var resp problem.Problem
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.Detail != tt.wantError || resp.Error != tt.wantError || resp.Status != tt.wantStatus {
+				t.Errorf("problem = %d %q (error %q), want %d %q", resp.Status, resp.Detail, resp.Error, tt.wantStatus, tt.wantError)
+			}
+			var fields []string
+			for _, f := range resp.Fields {
+				if !slices.Contains(fields, f.Field) {
+					fields = append(fields, f.Field)
+				}
+			}
+			if !slices.Equal(fields, tt.wantFields) {
+				t.Errorf("fields = %v, want %v", fields, tt.wantFields)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sirupsen/logrus"
+
+	"github.com/avvvet/cdnbuddy-api/internal/services/webhooks"
+)
+
+// WebhookHandler serves the endpoints an org receives events on
+type WebhookHandler struct {
+	webhookStore *webhooks.Store
+	dispatcher   *webhooks.Dispatcher
+}
+
+// NewWebhookHandler creates the handler
+func NewWebhookHandler(webhookStore *webhooks.Store, dispatcher *webhooks.Dispatcher) *WebhookHandler {
+	return &WebhookHandler{
+		webhookStore: webhookStore,
+		dispatcher:   dispatcher,
+	}
+}
+
+// Routes registers the handler's routes on the /api/v1 router
+func (h *WebhookHandler) Routes(r chi.Router) {
+	r.Route("/webhooks", func(r chi.Router) {
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+			endpoints, ok := paginate(w, r, h.webhookStore.List(OrgIDFromQuery(r)), listSpec[webhooks.Endpoint]{
+				sorts: map[string]func(a, b webhooks.Endpoint) int{
+					"created_at": byTime(func(e webhooks.Endpoint) time.Time { return e.CreatedAt }),
+					"url":        byString(func(e webhooks.Endpoint) string { return e.URL }),
+				},
+				text: func(e webhooks.Endpoint) []string { return []string{e.URL, e.Description, e.ServiceID} },
+			})
+			if !ok {
+				return
+			}
+
+			writeJSON(w, http.StatusOK, map[string]interface{}{
+				"webhooks": endpoints,
+			})
+		})
+
+		// The event types endpoints can subscribe to
+		r.Get("/events", func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, http.StatusOK, map[string]interface{}{
+				"events": webhooks.EventTypes,
+			})
+		})
+
+		r.Post("/", func(w http.ResponseWriter, r *http.Request) {
+			var endpoint webhooks.Endpoint
+			if !decodeJSON(w, r, &endpoint) {
+				return
+			}
+			endpoint.OrgID = OrgIDFromQuery(r)
+
+			endpoint, err := h.webhookStore.Create(endpoint)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+
+			logrus.WithFields(logrus.Fields{
+				"endpoint_id": endpoint.ID,
+				"org_id":      endpoint.OrgID,
+				"events":      endpoint.Events,
+			}).Info("🪝 Webhook endpoint created")
+			writeJSON(w, http.StatusCreated, endpoint)
+		})
+
+		r.Route("/{endpointID}", func(r chi.Router) {
+			r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+				endpoint, ok := h.endpoint(w, r)
+				if !ok {
+					return
+				}
+				endpoint.Secret = ""
+
+				writeJSON(w, http.StatusOK, endpoint)
+			})
+
+			r.Put("/", func(w http.ResponseWriter, r *http.Request) {
+				current, ok := h.endpoint(w, r)
+				if !ok {
+					return
+				}
+
+				var endpoint webhooks.Endpoint
+				if !decodeJSON(w, r, &endpoint) {
+					return
+				}
+
+				endpoint, err := h.webhookStore.Update(current.ID, endpoint)
+				if err != nil {
+					writeError(w, http.StatusBadRequest, err.Error())
+					return
+				}
+
+				logrus.WithField("endpoint_id", endpoint.ID).Info("🪝 Webhook endpoint updated")
+				writeJSON(w, http.StatusOK, endpoint)
+			})
+
+			r.Delete("/", func(w http.ResponseWriter, r *http.Request) {
+				endpoint, ok := h.endpoint(w, r)
+				if !ok {
+					return
+				}
+				h.webhookStore.Delete(endpoint.ID)
+				h.dispatcher.Forget(endpoint.ID)
+
+				logrus.WithField("endpoint_id", endpoint.ID).Info("🪝 Webhook endpoint deleted")
+				w.WriteHeader(http.StatusNoContent)
+			})
+
+			// Recent deliveries, newest first
+			r.Get("/deliveries", func(w http.ResponseWriter, r *http.Request) {
+				endpoint, ok := h.endpoint(w, r)
+				if !ok {
+					return
+				}
+
+				writeJSON(w, http.StatusOK, map[string]interface{}{
+					"deliveries": h.dispatcher.Deliveries(endpoint.ID),
+				})
+			})
+
+			// Send a ping event; the delivery runs in the background
+			r.Post("/ping", func(w http.ResponseWriter, r *http.Request) {
+				endpoint, ok := h.endpoint(w, r)
+				if !ok {
+					return
+				}
+
+				delivery, err := h.dispatcher.Ping(endpoint.ID)
+				if err != nil {
+					writeError(w, http.StatusNotFound, err.Error())
+					return
+				}
+
+				writeJSON(w, http.StatusAccepted, delivery)
+			})
+		})
+	})
+}
+
+// endpoint loads the endpoint named in the URL, writing a 404 when it doesn't
+// exist or belongs to another org
+func (h *WebhookHandler) endpoint(w http.ResponseWriter, r *http.Request) (webhooks.Endpoint, bool) {
+	endpoint, ok := h.webhookStore.Get(chi.URLParam(r, "endpointID"))
+	if !ok || endpoint.OrgID != OrgIDFromQuery(r) {
+		writeError(w, http.StatusNotFound, "webhook endpoint not found")
+		return webhooks.Endpoint{}, false
+	}
+	return endpoint, true
+}
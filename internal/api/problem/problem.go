@@ -0,0 +1,92 @@
+// Package problem writes API errors as RFC 7807 problem details
+// (application/problem+json) with a machine-readable code.
+package problem
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// ContentType is the media type of problem responses
+const ContentType = "application/problem+json"
+
+// Codes for problems that aren't just their status
+const (
+	CodeInvalidBody         = "invalid_body"
+	CodeValidationFailed    = "validation_failed"
+	CodeNotSupported        = "not_supported"
+	CodeReadOnly            = "read_only"
+	CodeNothingToRollBack   = "nothing_to_roll_back"
+	CodeChangesPaused       = "changes_paused"
+	CodeProviderError       = "provider_error"
+	CodeProviderUnavailable = "provider_unavailable"
+	CodeAPIKeyRequired      = "api_key_required"
+	CodeInvalidAPIKey       = "invalid_api_key"
+	CodeInsufficientScope   = "insufficient_scope"
+	CodeOriginNotAllowed    = "origin_not_allowed"
+)
+
+// statusCodes are the default codes by HTTP status
+var statusCodes = map[int]string{
+	http.StatusBadRequest:            "bad_request",
+	http.StatusUnauthorized:          "unauthorized",
+	http.StatusForbidden:             "forbidden",
+	http.StatusNotFound:              "not_found",
+	http.StatusConflict:              "conflict",
+	http.StatusRequestEntityTooLarge: "payload_too_large",
+	http.StatusUnsupportedMediaType:  "unsupported_media_type",
+	http.StatusUnprocessableEntity:   "unprocessable",
+	http.StatusTooManyRequests:       "rate_limited",
+	http.StatusInternalServerError:   "internal_error",
+	http.StatusBadGateway:            "bad_gateway",
+	http.StatusServiceUnavailable:    "unavailable",
+	http.StatusGatewayTimeout:        "timeout",
+}
+
+// FieldError is one problem with a request body field
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Problem is an RFC 7807 problem detail. Error repeats Detail for clients
+// written against the earlier {"error": "..."} bodies.
+type Problem struct {
+	Type   string       `json:"type"`
+	Title  string       `json:"title"`
+	Status int          `json:"status"`
+	Detail string       `json:"detail,omitempty"`
+	Code   string       `json:"code"`
+	Fields []FieldError `json:"fields,omitempty"`
+	Error  string       `json:"error"`
+}
+
+// New creates a problem; an empty code defaults to one for the status
+func New(status int, code, detail string) *Problem {
+	if code == "" {
+		code = statusCodes[status]
+	}
+	if code == "" {
+		code = "error"
+	}
+	return &Problem{
+		Type:   "urn:cdnbuddy:problem:" + code,
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+		Error:  detail,
+	}
+}
+
+// Write sends p as the response
+func Write(w http.ResponseWriter, p *Problem) {
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
+
+// Error writes a problem for status with detail
+func Error(w http.ResponseWriter, status int, code, detail string) {
+	Write(w, New(status, code, detail))
+}
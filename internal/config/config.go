@@ -2,6 +2,8 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -13,6 +15,79 @@ type Config struct {
 	DatabaseURL string
 	NATSUrl     string
 
+	// Messaging backend: nats (default), redis or kafka
+	MessagingBackend string
+	MessagingURL     string // Redis URL or comma-separated Kafka brokers
+
+	// Durable consumer of execution plans and commands, and on Redis and Kafka
+	// this replica's consumer groups; distinct per replica and kept across
+	// restarts, so a crashed replica gets what it missed
+	MessagingConsumerName string
+
+	// Publish events in their envelope; off until the socket server and the
+	// intent service unwrap them
+	MessagingEnvelopes bool
+
+	// Message encoding on NATS: json (default) or protobuf
+	MessagingEncoding string
+
+	// Listen address of the in-process NATS server when NATS_URL=embedded, and
+	// where its JetStream keeps messages (empty = no durable subscriptions)
+	NATSEmbeddedListen   string
+	NATSEmbeddedStoreDir string
+
+	// Leader election so singleton background jobs run on one replica:
+	// none (default, single replica), nats (JetStream KV) or redis
+	LeaderElection    string
+	LeaderElectionURL string // defaults to NATS_URL for nats
+	LeaderLeaseTTL    time.Duration
+	ReplicaID         string // defaults to hostname plus a random suffix
+
+	// CDN providers managed by this deployment (comma-separated) and the one
+	// used when a request doesn't name a provider
+	CDNProviders       string
+	DefaultCDNProvider string
+	ProviderModes      string // e.g. "keycdn=read_only" to dark-launch an integration
+
+	// Provider API rate budgets, e.g. "cachefly=10:20" (requests/second:burst),
+	// shared across replicas through Redis when a URL is set
+	ProviderRateLimits        string
+	ProviderRateLimitRedisURL string
+
+	// Retry policies per operation class, e.g. "read=4:200ms:5s,write=2"
+	ProviderRetries string
+
+	// Secrets providers sign callbacks to /api/v1/hooks/{provider} with,
+	// e.g. "cachefly=s3cret,keycdn=other" (providers without one can't call back)
+	ProviderCallbackSecrets string
+
+	// Circuit breaker: consecutive provider failures before calls are refused
+	// for the cooldown (0 disables it)
+	ProviderBreakerThreshold int
+	ProviderBreakerCooldown  time.Duration
+
+	// Journal of provider API mutations shown to support in the admin API
+	ProviderJournalMaxEntries int
+	ProviderJournalRetention  time.Duration
+
+	// Executor timeouts and retries per action, e.g. "SETUP_CDN=10m,PURGE_CACHE=20s:3:500ms"
+	OperationPolicies string
+
+	// Recent durations kept per action and provider, for ETAs and the stuck-operation watchdog
+	OperationDurationSamples  int
+	OperationWatchdogInterval time.Duration
+
+	// Clarifications unanswered for this long count as abandoned in intent analytics
+	IntentAbandonTimeout time.Duration
+
+	// Sessions with more unsummarized messages than this get a rolling summary
+	// (0 = off); the most recent messages are always sent verbatim
+	TranscriptSummaryThreshold int
+	TranscriptKeepRecent       int
+
+	// Chat sessions seen within this window count as connected devices
+	SessionActiveWindow time.Duration
+
 	// CDN Provider credentials
 	CacheFlyToken    string
 	CloudflareToken  string
@@ -20,6 +95,92 @@ type Config struct {
 
 	// JWT
 	JWTSecret string
+
+	// API keys (X-API-Key): off, optional (checked when sent) or required on
+	// every /api/v1 route. Admin routes, key management included, need one
+	// unless off; the bootstrap key gets the admin scope for the deployment's
+	// own org so the first keys can be created.
+	APIKeyMode      string
+	APIBootstrapKey string
+
+	// Admin/ops listener (health, metrics, pprof); bind to an internal interface
+	AdminAddr         string
+	AdminReadTimeout  time.Duration
+	AdminWriteTimeout time.Duration
+
+	// Bearer token for admin/profiling endpoints (empty disables them)
+	AdminToken string
+
+	// AI intent handling
+	IntentCacheTTL time.Duration
+
+	// Daily AI request quotas per tier (0 = tier default)
+	AIQuotaFreeRequests int64
+	AIQuotaProRequests  int64
+	AIOrgTiers          string // e.g. "org-1=pro,org-2=enterprise"; other orgs are free
+
+	// Origins of our own dashboards, comma-separated; orgs register theirs via the API
+	CORSAllowedOrigins string
+	// Methods org-registered origins may use per route, e.g. "/api/v1/audit*=GET,/api/v1/backup="
+	// (empty = built-in defaults)
+	CORSTenantRoutes string
+
+	// Per-route HTTP timeouts and body limits (JSON file, reloaded on change)
+	RouteLimitsFile   string
+	RouteLimitsReload time.Duration
+
+	// Demo sandbox tenants
+	SandboxTTL time.Duration
+
+	// TTL review reminders
+	ReminderEnabled  bool
+	ReminderInterval time.Duration
+
+	// Generated reports linked from chat responses
+	ArtifactTTL         time.Duration
+	ArtifactsMaxEntries int
+
+	// Public read-only links to one service's status; the secret is required
+	ShareLinkSecret string
+	ShareLinkTTL    time.Duration
+
+	// Tokens for the chat widget embedded on customer pages; the secret is required
+	WidgetTokenSecret string
+
+	// Automated changes to a service pause once an option changes more than
+	// ChangeGuardMaxChanges times within ChangeGuardWindow
+	ChangeGuardMaxChanges int
+	ChangeGuardWindow     time.Duration
+
+	// Batch operation progress chat messages per session (0 = send each update)
+	ProgressDigestInterval time.Duration
+
+	// Nightly compliance scans against a JSON policy file (empty = built-in policy)
+	ComplianceEnabled      bool
+	CompliancePolicyFile   string
+	ComplianceScanHour     int // UTC
+	ComplianceNotifyUserID string
+
+	// Scheduled (maintenance window) plans: remind users this long before they run
+	ScheduleReminderLead time.Duration
+
+	// Speech-to-text for voice notes sent to chat (empty provider disables them)
+	STTProvider string
+	STTAPIURL   string
+	STTAPIKey   string
+	STTModel    string
+
+	// Access log ingestion from provider log delivery
+	LogIngestEnabled  bool
+	LogIngestInterval time.Duration
+
+	// Caps for in-memory stores; the least recently used entries are evicted
+	IntentCacheMaxEntries int
+	PlanStorageMaxPlans   int // new plans are rejected instead, so pending ones stay confirmable
+	UsageMaxRecords       int
+	SandboxMaxTenants     int
+	AuditMaxEntries       int
+	OperationsMaxEntries  int
 }
 
 func Load() (*Config, error) {
@@ -33,11 +194,115 @@ func Load() (*Config, error) {
 		DatabaseURL: getEnv("DATABASE_URL", "postgres://localhost/cdnbuddy?sslmode=disable"),
 		NATSUrl:     getEnv("NATS_URL", "nats://localhost:4222"),
 
+		MessagingBackend: getEnv("MESSAGING_BACKEND", "nats"),
+		MessagingURL:     getEnv("MESSAGING_URL", ""),
+
+		MessagingConsumerName: getEnv("MESSAGING_CONSUMER_NAME", "cdnbuddy-api"),
+		MessagingEnvelopes:    getEnv("MESSAGING_ENVELOPES", "false") == "true",
+		MessagingEncoding:     getEnv("MESSAGING_ENCODING", "json"),
+
+		NATSEmbeddedListen:   getEnv("NATS_EMBEDDED_LISTEN", "127.0.0.1:4222"),
+		NATSEmbeddedStoreDir: getEnv("NATS_EMBEDDED_STORE_DIR", "data/nats"),
+
+		LeaderElection:    getEnv("LEADER_ELECTION", "none"),
+		LeaderElectionURL: getEnv("LEADER_ELECTION_URL", ""),
+		LeaderLeaseTTL:    getEnvDuration("LEADER_LEASE_TTL", 15*time.Second),
+		ReplicaID:         getEnv("REPLICA_ID", ""),
+
+		CDNProviders:       getEnv("CDN_PROVIDERS", "cachefly"),
+		DefaultCDNProvider: getEnv("DEFAULT_CDN_PROVIDER", "cachefly"),
+		ProviderModes:      getEnv("PROVIDER_MODES", ""),
+
+		ProviderRateLimits:        getEnv("PROVIDER_RATE_LIMITS", ""),
+		ProviderRateLimitRedisURL: getEnv("PROVIDER_RATE_LIMIT_REDIS_URL", ""),
+
+		ProviderRetries: getEnv("PROVIDER_RETRIES", ""),
+
+		ProviderCallbackSecrets: getEnv("PROVIDER_CALLBACK_SECRETS", ""),
+
+		ProviderBreakerThreshold: int(getEnvInt("PROVIDER_BREAKER_THRESHOLD", 5)),
+		ProviderBreakerCooldown:  getEnvDuration("PROVIDER_BREAKER_COOLDOWN", 30*time.Second),
+
+		ProviderJournalMaxEntries: int(getEnvInt("PROVIDER_JOURNAL_MAX_ENTRIES", 10000)),
+		ProviderJournalRetention:  getEnvDuration("PROVIDER_JOURNAL_RETENTION", 72*time.Hour),
+
+		OperationPolicies: getEnv("OPERATION_POLICIES", ""),
+
+		OperationDurationSamples:  int(getEnvInt("OPERATION_DURATION_SAMPLES", 500)),
+		OperationWatchdogInterval: getEnvDuration("OPERATION_WATCHDOG_INTERVAL", time.Minute),
+
+		IntentAbandonTimeout: getEnvDuration("INTENT_ABANDON_TIMEOUT", 30*time.Minute),
+
+		TranscriptSummaryThreshold: int(getEnvInt("TRANSCRIPT_SUMMARY_THRESHOLD", 20)),
+		TranscriptKeepRecent:       int(getEnvInt("TRANSCRIPT_KEEP_RECENT", 6)),
+
+		SessionActiveWindow: getEnvDuration("SESSION_ACTIVE_WINDOW", 30*time.Minute),
+
 		CacheFlyToken:    getEnv("CACHEFLY_TOKEN", ""),
 		CloudflareToken:  getEnv("CLOUDFLARE_TOKEN", ""),
 		CloudflareZoneID: getEnv("CLOUDFLARE_ZONE_ID", ""),
 
 		JWTSecret: getEnv("JWT_SECRET", "your-secret-key"),
+
+		APIKeyMode:      getEnv("API_KEY_MODE", "optional"),
+		APIBootstrapKey: getEnv("API_BOOTSTRAP_KEY", ""),
+
+		AdminAddr:         getEnv("ADMIN_ADDR", "127.0.0.1:9091"),
+		AdminReadTimeout:  getEnvDuration("ADMIN_READ_TIMEOUT", 10*time.Second),
+		AdminWriteTimeout: getEnvDuration("ADMIN_WRITE_TIMEOUT", 90*time.Second),
+		AdminToken:        getEnv("ADMIN_TOKEN", ""),
+
+		IntentCacheTTL: getEnvDuration("INTENT_CACHE_TTL", 60*time.Second),
+
+		AIQuotaFreeRequests: getEnvInt("AI_QUOTA_FREE_REQUESTS", 0),
+		AIQuotaProRequests:  getEnvInt("AI_QUOTA_PRO_REQUESTS", 0),
+		AIOrgTiers:          getEnv("AI_ORG_TIERS", ""),
+
+		CORSAllowedOrigins: getEnv("CORS_ALLOWED_ORIGINS", "http://localhost:5173,http://localhost:3000"),
+		CORSTenantRoutes:   getEnv("CORS_TENANT_ROUTES", ""),
+
+		RouteLimitsFile:   getEnv("ROUTE_LIMITS_FILE", ""),
+		RouteLimitsReload: getEnvDuration("ROUTE_LIMITS_RELOAD", 10*time.Second),
+
+		SandboxTTL: getEnvDuration("SANDBOX_TTL", 2*time.Hour),
+
+		ReminderEnabled:  getEnv("REMINDERS_ENABLED", "true") == "true",
+		ReminderInterval: getEnvDuration("REMINDER_INTERVAL", 24*time.Hour),
+
+		ArtifactTTL:         getEnvDuration("ARTIFACT_TTL", 24*time.Hour),
+		ArtifactsMaxEntries: int(getEnvInt("ARTIFACTS_MAX_ENTRIES", 1000)),
+
+		ShareLinkSecret: getEnv("SHARE_LINK_SECRET", ""),
+		ShareLinkTTL:    getEnvDuration("SHARE_LINK_TTL", 7*24*time.Hour),
+
+		WidgetTokenSecret: getEnv("WIDGET_TOKEN_SECRET", ""),
+
+		ChangeGuardMaxChanges: int(getEnvInt("CHANGE_GUARD_MAX_CHANGES", 10)),
+		ChangeGuardWindow:     getEnvDuration("CHANGE_GUARD_WINDOW", time.Hour),
+
+		ProgressDigestInterval: getEnvDuration("PROGRESS_DIGEST_INTERVAL", 10*time.Second),
+
+		ComplianceEnabled:      getEnv("COMPLIANCE_ENABLED", "true") == "true",
+		CompliancePolicyFile:   getEnv("COMPLIANCE_POLICY_FILE", ""),
+		ComplianceScanHour:     int(getEnvInt("COMPLIANCE_SCAN_HOUR", 2)),
+		ComplianceNotifyUserID: getEnv("COMPLIANCE_NOTIFY_USER_ID", ""),
+
+		ScheduleReminderLead: getEnvDuration("SCHEDULE_REMINDER_LEAD", time.Hour),
+
+		STTProvider: getEnv("STT_PROVIDER", ""),
+		STTAPIURL:   getEnv("STT_API_URL", ""),
+		STTAPIKey:   getEnv("STT_API_KEY", ""),
+		STTModel:    getEnv("STT_MODEL", ""),
+
+		LogIngestEnabled:  getEnv("LOG_INGEST_ENABLED", "false") == "true",
+		LogIngestInterval: getEnvDuration("LOG_INGEST_INTERVAL", 15*time.Minute),
+
+		IntentCacheMaxEntries: int(getEnvInt("INTENT_CACHE_MAX_ENTRIES", 10000)),
+		PlanStorageMaxPlans:   int(getEnvInt("PLAN_STORAGE_MAX_PLANS", 10000)),
+		UsageMaxRecords:       int(getEnvInt("USAGE_MAX_RECORDS", 100000)),
+		SandboxMaxTenants:     int(getEnvInt("SANDBOX_MAX_TENANTS", 500)),
+		AuditMaxEntries:       int(getEnvInt("AUDIT_MAX_ENTRIES", 50000)),
+		OperationsMaxEntries:  int(getEnvInt("OPERATIONS_MAX_ENTRIES", 10000)),
 	}, nil
 }
 
@@ -47,3 +312,21 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return defaultValue
+}
+
+func getEnvInt(key string, defaultValue int64) int64 {
+	if value := os.Getenv(key); value != "" {
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return n
+		}
+	}
+	return defaultValue
+}
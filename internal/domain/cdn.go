@@ -9,6 +9,9 @@ type CDNProvider string
 const (
 	ProviderCacheFly   CDNProvider = "cachefly"
 	ProviderCloudflare CDNProvider = "cloudflare"
+	ProviderKeyCDN     CDNProvider = "keycdn"
+	ProviderCDN77      CDNProvider = "cdn77"
+	ProviderMock       CDNProvider = "mock" // sandbox tenants
 )
 
 type CDNService struct {
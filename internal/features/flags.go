@@ -0,0 +1,105 @@
+// Package features holds per-tenant feature flags, such as which CDN
+// providers a tenant may use and whether they may write to them.
+package features
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/avvvet/cdnbuddy-api/internal/domain"
+	"github.com/avvvet/cdnbuddy-api/internal/services/cdn"
+)
+
+// Flags resolves provider modes per tenant: a tenant override wins over the
+// deployment default, and providers without either are fully enabled
+type Flags struct {
+	defaults map[domain.CDNProvider]cdn.ProviderMode
+	tenants  map[string]map[domain.CDNProvider]cdn.ProviderMode
+	mu       sync.RWMutex
+}
+
+// NewFlags creates flags with deployment-wide default provider modes
+func NewFlags(defaults map[domain.CDNProvider]cdn.ProviderMode) *Flags {
+	if defaults == nil {
+		defaults = make(map[domain.CDNProvider]cdn.ProviderMode)
+	}
+	return &Flags{
+		defaults: defaults,
+		tenants:  make(map[string]map[domain.CDNProvider]cdn.ProviderMode),
+	}
+}
+
+// ParseProviderModes parses "keycdn=read_only,cdn77=disabled"
+func ParseProviderModes(spec string) (map[domain.CDNProvider]cdn.ProviderMode, error) {
+	modes := make(map[domain.CDNProvider]cdn.ProviderMode)
+	for _, pair := range strings.Split(spec, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		name, mode, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid provider mode %q (expected provider=mode)", pair)
+		}
+		m, err := cdn.ParseProviderMode(strings.TrimSpace(mode))
+		if err != nil {
+			return nil, err
+		}
+		modes[cdn.ParseProvider(name)] = m
+	}
+	return modes, nil
+}
+
+// ProviderMode returns the mode of a provider for a tenant
+func (f *Flags) ProviderMode(tenantID string, provider domain.CDNProvider) cdn.ProviderMode {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if mode, ok := f.tenants[tenantID][provider]; ok {
+		return mode
+	}
+	if mode, ok := f.defaults[provider]; ok {
+		return mode
+	}
+	return cdn.ProviderEnabled
+}
+
+// ModeFunc returns the tenant's provider modes in the form cdn.Service.Scoped takes
+func (f *Flags) ModeFunc(tenantID string) func(domain.CDNProvider) cdn.ProviderMode {
+	return func(provider domain.CDNProvider) cdn.ProviderMode {
+		return f.ProviderMode(tenantID, provider)
+	}
+}
+
+// SetProviderMode overrides a provider's mode for one tenant
+func (f *Flags) SetProviderMode(tenantID string, provider domain.CDNProvider, mode cdn.ProviderMode) error {
+	if _, err := cdn.ParseProviderMode(string(mode)); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.tenants[tenantID] == nil {
+		f.tenants[tenantID] = make(map[domain.CDNProvider]cdn.ProviderMode)
+	}
+	f.tenants[tenantID][provider] = mode
+	return nil
+}
+
+// ClearProviderMode removes a tenant override so the default applies again
+func (f *Flags) ClearProviderMode(tenantID string, provider domain.CDNProvider) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.tenants[tenantID], provider)
+}
+
+// ProviderModes returns the effective mode of each named provider for a tenant
+func (f *Flags) ProviderModes(tenantID string, providers []domain.CDNProvider) map[domain.CDNProvider]cdn.ProviderMode {
+	modes := make(map[domain.CDNProvider]cdn.ProviderMode, len(providers))
+	for _, p := range providers {
+		modes[p] = f.ProviderMode(tenantID, p)
+	}
+	return modes
+}
@@ -0,0 +1,208 @@
+// Package lru provides a bounded, TTL-aware LRU map for in-memory stores, so
+// long-running processes can't grow caches and registries without limit.
+package lru
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/avvvet/cdnbuddy-api/internal/metrics"
+)
+
+// Cache is a concurrency-safe LRU map with optional per-entry expiry.
+// When full, Put evicts the least recently used entry.
+type Cache[K comparable, V any] struct {
+	name     string
+	capacity int           // 0 = unbounded
+	ttl      time.Duration // default TTL for Put, 0 = no expiry
+	items    map[K]*list.Element
+	order    *list.List // front = most recently used
+	mu       sync.Mutex
+}
+
+type item[K comparable, V any] struct {
+	key       K
+	value     V
+	expiresAt time.Time // zero = never
+}
+
+// New creates a cache; name is used for eviction metrics (lru.<name>.evicted/expired)
+func New[K comparable, V any](name string, capacity int, ttl time.Duration) *Cache[K, V] {
+	return &Cache[K, V]{
+		name:     name,
+		capacity: capacity,
+		ttl:      ttl,
+		items:    make(map[K]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Get returns the value for key and marks it recently used
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var zero V
+	el, ok := c.items[key]
+	if !ok {
+		return zero, false
+	}
+
+	it := el.Value.(*item[K, V])
+	if !it.expiresAt.IsZero() && time.Now().After(it.expiresAt) {
+		c.remove(el)
+		metrics.Inc("lru." + c.name + ".expired")
+		return zero, false
+	}
+
+	c.order.MoveToFront(el)
+	return it.value, true
+}
+
+// Put stores a value with the cache's default TTL
+func (c *Cache[K, V]) Put(key K, value V) {
+	var expiresAt time.Time
+	if c.ttl > 0 {
+		expiresAt = time.Now().Add(c.ttl)
+	}
+	c.PutUntil(key, value, expiresAt)
+}
+
+// PutUntil stores a value that expires at expiresAt (zero = never)
+func (c *Cache[K, V]) PutUntil(key K, value V, expiresAt time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		it := el.Value.(*item[K, V])
+		it.value = value
+		it.expiresAt = expiresAt
+		c.order.MoveToFront(el)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&item[K, V]{key: key, value: value, expiresAt: expiresAt})
+
+	for c.capacity > 0 && c.order.Len() > c.capacity {
+		c.remove(c.order.Back())
+		metrics.Inc("lru." + c.name + ".evicted")
+	}
+}
+
+// TryPutUntil stores a value like PutUntil but never evicts a live entry:
+// when the cache is full it drops expired entries, and reports false if there
+// is still no room. Replacing an existing key always succeeds.
+func (c *Cache[K, V]) TryPutUntil(key K, value V, expiresAt time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		it := el.Value.(*item[K, V])
+		it.value = value
+		it.expiresAt = expiresAt
+		c.order.MoveToFront(el)
+		return true
+	}
+
+	if c.capacity > 0 && c.order.Len() >= c.capacity {
+		now := time.Now()
+		for el := c.order.Back(); el != nil; {
+			prev := el.Prev()
+			if it := el.Value.(*item[K, V]); !it.expiresAt.IsZero() && now.After(it.expiresAt) {
+				c.remove(el)
+				metrics.Inc("lru." + c.name + ".expired")
+			}
+			el = prev
+		}
+		if c.order.Len() >= c.capacity {
+			metrics.Inc("lru." + c.name + ".rejected")
+			return false
+		}
+	}
+
+	c.items[key] = c.order.PushFront(&item[K, V]{key: key, value: value, expiresAt: expiresAt})
+	return true
+}
+
+// Delete removes a key
+func (c *Cache[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.remove(el)
+	}
+}
+
+// Len returns the number of entries, including expired ones not yet pruned
+func (c *Cache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// Range calls fn for each live entry, most recently used first, without
+// changing recency. fn must not call back into the cache.
+func (c *Cache[K, V]) Range(fn func(key K, value V) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for el := c.order.Front(); el != nil; el = el.Next() {
+		it := el.Value.(*item[K, V])
+		if !it.expiresAt.IsZero() && now.After(it.expiresAt) {
+			continue
+		}
+		if !fn(it.key, it.value) {
+			return
+		}
+	}
+}
+
+// PruneExpired removes expired entries and returns how many were removed
+func (c *Cache[K, V]) PruneExpired() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	count := 0
+	for el := c.order.Front(); el != nil; {
+		next := el.Next()
+		it := el.Value.(*item[K, V])
+		if !it.expiresAt.IsZero() && now.After(it.expiresAt) {
+			c.remove(el)
+			count++
+		}
+		el = next
+	}
+
+	if count > 0 {
+		metrics.Add("lru."+c.name+".expired", int64(count))
+	}
+	return count
+}
+
+// PruneFunc removes entries for which fn returns true and returns how many were removed
+func (c *Cache[K, V]) PruneFunc(fn func(key K, value V) bool) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	count := 0
+	for el := c.order.Front(); el != nil; {
+		next := el.Next()
+		it := el.Value.(*item[K, V])
+		if fn(it.key, it.value) {
+			c.remove(el)
+			count++
+		}
+		el = next
+	}
+	return count
+}
+
+func (c *Cache[K, V]) remove(el *list.Element) {
+	it := el.Value.(*item[K, V])
+	delete(c.items, it.key)
+	c.order.Remove(el)
+}
@@ -0,0 +1,147 @@
+package lru
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	past := time.Now().Add(-time.Minute)
+	future := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name     string
+		capacity int
+		run      func(c *Cache[string, int]) bool // returns false if a step failed
+		wantKeys []string                         // live keys, most recently used first
+		wantLen  int
+	}{
+		{
+			name:     "evicts the least recently used",
+			capacity: 2,
+			run: func(c *Cache[string, int]) bool {
+				c.Put("a", 1)
+				c.Put("b", 2)
+				_, ok := c.Get("a")
+				c.Put("c", 3)
+				return ok
+			},
+			wantKeys: []string{"c", "a"},
+			wantLen:  2,
+		},
+		{
+			name:     "replacing a key doesn't evict",
+			capacity: 2,
+			run: func(c *Cache[string, int]) bool {
+				c.Put("a", 1)
+				c.Put("b", 2)
+				c.Put("a", 10)
+				v, ok := c.Get("a")
+				return ok && v == 10
+			},
+			wantKeys: []string{"a", "b"},
+			wantLen:  2,
+		},
+		{
+			name: "expired entries are hidden until pruned",
+			run: func(c *Cache[string, int]) bool {
+				c.PutUntil("old", 1, past)
+				c.PutUntil("new", 2, future)
+				return c.Len() == 2 && c.PruneExpired() == 1
+			},
+			wantKeys: []string{"new"},
+			wantLen:  1,
+		},
+		{
+			name: "get drops an expired entry",
+			run: func(c *Cache[string, int]) bool {
+				c.PutUntil("old", 1, past)
+				_, ok := c.Get("old")
+				return !ok
+			},
+			wantKeys: []string{},
+			wantLen:  0,
+		},
+		{
+			name: "prune by predicate",
+			run: func(c *Cache[string, int]) bool {
+				c.Put("a", 1)
+				c.Put("b", 2)
+				c.Put("c", 3)
+				return c.PruneFunc(func(_ string, v int) bool { return v%2 == 1 }) == 2
+			},
+			wantKeys: []string{"b"},
+			wantLen:  1,
+		},
+		{
+			name:     "try put refuses when full of live entries",
+			capacity: 2,
+			run: func(c *Cache[string, int]) bool {
+				c.PutUntil("a", 1, future)
+				c.PutUntil("b", 2, future)
+				return !c.TryPutUntil("c", 3, future)
+			},
+			wantKeys: []string{"b", "a"},
+			wantLen:  2,
+		},
+		{
+			name:     "try put makes room by dropping expired entries",
+			capacity: 2,
+			run: func(c *Cache[string, int]) bool {
+				c.PutUntil("a", 1, past)
+				c.PutUntil("b", 2, future)
+				return c.TryPutUntil("c", 3, future)
+			},
+			wantKeys: []string{"c", "b"},
+			wantLen:  2,
+		},
+		{
+			name:     "try put replaces a key when full",
+			capacity: 2,
+			run: func(c *Cache[string, int]) bool {
+				c.PutUntil("a", 1, future)
+				c.PutUntil("b", 2, future)
+				return c.TryPutUntil("a", 10, future)
+			},
+			wantKeys: []string{"a", "b"},
+			wantLen:  2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New[string, int]("test", tt.capacity, 0)
+			if !tt.run(c) {
+				t.Fatal("step failed")
+			}
+
+			keys := make([]string, 0)
+			c.Range(func(k string, _ int) bool {
+				keys = append(keys, k)
+				return true
+			})
+			if !reflect.DeepEqual(keys, tt.wantKeys) {
+				t.Errorf("keys = %v, want %v", keys, tt.wantKeys)
+			}
+			if c.Len() != tt.wantLen {
+				t.Errorf("len = %d, want %d", c.Len(), tt.wantLen)
+			}
+		})
+	}
+}
+
+func TestCacheDefaultTTL(t *testing.T) {
+	c := New[string, int]("test", 0, time.Hour)
+	c.Put("a", 1)
+	if _, ok := c.Get("a"); !ok {
+		t.Fatal("entry within its TTL is missing")
+	}
+
+	c = New[string, int]("test", 0, time.Nanosecond)
+	c.Put("a", 1)
+	time.Sleep(time.Millisecond)
+	if _, ok := c.Get("a"); ok {
+		t.Error("entry past its TTL is still returned")
+	}
+}
@@ -0,0 +1,77 @@
+package metrics
+
+import (
+	"encoding/json"
+	"expvar"
+	"sync"
+	"time"
+)
+
+// Process-wide metrics, published through expvar at /debug/vars
+var (
+	counters  = expvar.NewMap("cdnbuddy_counters")
+	latencies = expvar.NewMap("cdnbuddy_latency_ms")
+
+	latencyMu sync.Mutex // guards creation of latency entries
+)
+
+// latencyStat aggregates observed durations for one operation
+type latencyStat struct {
+	mu    sync.Mutex
+	count int64
+	total time.Duration
+	max   time.Duration
+}
+
+// String implements expvar.Var
+func (l *latencyStat) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	avg := 0.0
+	if l.count > 0 {
+		avg = float64(l.total.Milliseconds()) / float64(l.count)
+	}
+
+	data, _ := json.Marshal(map[string]interface{}{
+		"count":    l.count,
+		"total_ms": l.total.Milliseconds(),
+		"max_ms":   l.max.Milliseconds(),
+		"avg_ms":   avg,
+	})
+	return string(data)
+}
+
+// Inc increments a named counter
+func Inc(name string) {
+	counters.Add(name, 1)
+}
+
+// Add adds delta to a named counter
+func Add(name string, delta int64) {
+	counters.Add(name, delta)
+}
+
+// ObserveDuration records the latency of a named operation
+func ObserveDuration(name string, d time.Duration) {
+	latencyMu.Lock()
+	stat, ok := latencies.Get(name).(*latencyStat)
+	if !ok {
+		stat = &latencyStat{}
+		latencies.Set(name, stat)
+	}
+	latencyMu.Unlock()
+
+	stat.mu.Lock()
+	stat.count++
+	stat.total += d
+	if d > stat.max {
+		stat.max = d
+	}
+	stat.mu.Unlock()
+}
+
+// Since records the time elapsed since start for a named operation
+func Since(name string, start time.Time) {
+	ObserveDuration(name, time.Since(start))
+}
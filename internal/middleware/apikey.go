@@ -0,0 +1,174 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	chimw "github.com/go-chi/chi/v5/middleware"
+	"github.com/sirupsen/logrus"
+
+	"github.com/avvvet/cdnbuddy-api/internal/api/problem"
+	"github.com/avvvet/cdnbuddy-api/internal/services/apikeys"
+	"github.com/avvvet/cdnbuddy-api/internal/services/audit"
+)
+
+// APIKeyHeader carries the API key
+const APIKeyHeader = "X-API-Key"
+
+// API key enforcement modes
+const (
+	APIKeysOff      = "off"      // keys are ignored
+	APIKeysOptional = "optional" // keys are checked when sent; requests without one pass
+	APIKeysRequired = "required" // every /api/v1 and /api/v2 request needs a key
+)
+
+// ScopeRoute names the scope requests matching Path ("*" wildcards) and
+// Method (empty = any) need. Routes are matched in order and the first match
+// wins; other requests need read for GET and HEAD and write otherwise.
+// Required routes need a key even when keys are optional.
+type ScopeRoute struct {
+	Method   string        `json:"method,omitempty"`
+	Path     string        `json:"path"`
+	Scope    apikeys.Scope `json:"scope"` // empty = no key needed
+	Required bool          `json:"required,omitempty"`
+
+	pattern *regexp.Regexp
+}
+
+// DefaultScopeRoutes guard key management and account-wide operations, and
+// let purge-only keys purge. Those, and AI usage which is scoped to the key's
+// org, need a key even when keys are optional.
+var DefaultScopeRoutes = []ScopeRoute{
+	{Path: "/api/v1/health"},
+	{Path: "/api/v1/hooks/*"}, // provider callbacks carry their own signature
+	{Path: "/api/v1/api-keys*", Scope: apikeys.ScopeAdmin, Required: true},
+	{Path: "/api/v1/backup", Scope: apikeys.ScopeAdmin, Required: true},
+	{Path: "/api/v1/restore", Scope: apikeys.ScopeAdmin, Required: true},
+	{Path: "/api/v1/cors*", Scope: apikeys.ScopeAdmin, Required: true},
+	{Path: "/api/v1/usage", Scope: apikeys.ScopeRead, Required: true}, // scoped to the key's org
+	{Method: http.MethodPost, Path: "/api/v1/*/purge*", Scope: apikeys.ScopePurge},
+	{Method: http.MethodPost, Path: "/api/v2/services/*/purges", Scope: apikeys.ScopePurge},
+}
+
+// APIKeyAuth authenticates /api/v1 and /api/v2 requests by X-API-Key, checks
+// the key's scopes against the route and attaches the principal to the request
+// context. Changes made with a key are recorded in the audit log.
+type APIKeyAuth struct {
+	keys     *apikeys.Store
+	auditLog *audit.Log
+	routes   []ScopeRoute
+	mode     string
+}
+
+// NewAPIKeyAuth creates the middleware
+func NewAPIKeyAuth(keys *apikeys.Store, auditLog *audit.Log, routes []ScopeRoute, mode string) (*APIKeyAuth, error) {
+	switch mode {
+	case APIKeysOff, APIKeysOptional, APIKeysRequired:
+	default:
+		return nil, fmt.Errorf("invalid API key mode %q (expected off, optional or required)", mode)
+	}
+
+	compiled := make([]ScopeRoute, len(routes))
+	for i, route := range routes {
+		limit := RouteLimit{Path: route.Path}
+		if err := limit.compile(); err != nil {
+			return nil, err
+		}
+		route.pattern = limit.pattern
+		route.Method = strings.ToUpper(route.Method)
+		compiled[i] = route
+	}
+	return &APIKeyAuth{keys: keys, auditLog: auditLog, routes: compiled, mode: mode}, nil
+}
+
+// Scope returns the scope a request needs; empty means none
+func (a *APIKeyAuth) Scope(method, path string) apikeys.Scope {
+	return a.route(method, path).Scope
+}
+
+// route returns the first route matching a request, or the default by method
+func (a *APIKeyAuth) route(method, path string) ScopeRoute {
+	for _, route := range a.routes {
+		if (route.Method == "" || route.Method == method) && route.pattern.MatchString(path) {
+			return route
+		}
+	}
+	if method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions {
+		return ScopeRoute{Scope: apikeys.ScopeRead}
+	}
+	return ScopeRoute{Scope: apikeys.ScopeWrite}
+}
+
+// Middleware enforces API keys on /api/v1 and /api/v2 routes
+func (a *APIKeyAuth) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if a.mode == APIKeysOff || !(strings.HasPrefix(r.URL.Path, "/api/v1/") || strings.HasPrefix(r.URL.Path, "/api/v2/")) || r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		route := a.route(r.Method, r.URL.Path)
+		scope := route.Scope
+		raw := r.Header.Get(APIKeyHeader)
+		if scope == "" || (raw == "" && a.mode == APIKeysOptional && !route.Required) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if raw == "" {
+			problem.Error(w, http.StatusUnauthorized, problem.CodeAPIKeyRequired, "an API key is required in the "+APIKeyHeader+" header")
+			return
+		}
+
+		principal, err := a.keys.Authenticate(raw)
+		if err != nil {
+			problem.Error(w, http.StatusUnauthorized, problem.CodeInvalidAPIKey, err.Error())
+			return
+		}
+		if !principal.Allows(scope) {
+			logrus.WithFields(logrus.Fields{
+				"key_id": principal.KeyID,
+				"method": r.Method,
+				"path":   r.URL.Path,
+				"scope":  scope,
+			}).Warn("🔑 API key lacks the scope for a request")
+			problem.Error(w, http.StatusForbidden, problem.CodeInsufficientScope, fmt.Sprintf("this API key doesn't have the %s scope", scope))
+			return
+		}
+
+		r = r.WithContext(apikeys.WithPrincipal(r.Context(), principal))
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ww := chimw.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		a.record(principal, r, status)
+	})
+}
+
+// record audits a change made with an API key
+func (a *APIKeyAuth) record(p apikeys.Principal, r *http.Request, status int) {
+	entry := audit.Entry{
+		UserID:   p.Subject(),
+		APIKeyID: p.KeyID,
+		Action:   "api_request",
+		Parameters: map[string]string{
+			"method":   r.Method,
+			"path":     r.URL.Path,
+			"key_name": p.Name,
+			"status":   fmt.Sprint(status),
+		},
+		Success: status < http.StatusBadRequest,
+	}
+	if !entry.Success {
+		entry.Error = http.StatusText(status)
+	}
+	a.auditLog.Record(entry)
+}
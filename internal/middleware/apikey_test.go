@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/avvvet/cdnbuddy-api/internal/services/apikeys"
+	"github.com/avvvet/cdnbuddy-api/internal/services/audit"
+)
+
+func TestAPIKeyAuth(t *testing.T) {
+	keys := apikeys.NewStore()
+	issue := func(scopes ...apikeys.Scope) string {
+		_, raw, err := keys.Create("org-1", "test", scopes, nil)
+		if err != nil {
+			t.Fatalf("Create() unexpected error: %v", err)
+		}
+		return raw
+	}
+	readKey, writeKey, purgeKey, adminKey := issue(apikeys.ScopeRead), issue(apikeys.ScopeWrite), issue(apikeys.ScopePurge), issue(apikeys.ScopeAdmin)
+
+	tests := []struct {
+		name       string
+		mode       string
+		method     string
+		path       string
+		key        string
+		wantStatus int
+		wantOrg    string
+		wantAudit  bool
+	}{
+		{name: "read key reads", mode: APIKeysRequired, method: http.MethodGet, path: "/api/v1/services", key: readKey, wantStatus: http.StatusOK, wantOrg: "org-1"},
+		{name: "read key can't write", mode: APIKeysRequired, method: http.MethodPost, path: "/api/v1/services", key: readKey, wantStatus: http.StatusForbidden},
+		{name: "write key reads", mode: APIKeysRequired, method: http.MethodGet, path: "/api/v1/services", key: writeKey, wantStatus: http.StatusOK, wantOrg: "org-1"},
+		{name: "write key writes and is audited", mode: APIKeysRequired, method: http.MethodPut, path: "/api/v1/services/svc-1", key: writeKey, wantStatus: http.StatusOK, wantOrg: "org-1", wantAudit: true},
+		{name: "purge key purges", mode: APIKeysRequired, method: http.MethodPost, path: "/api/v1/services/svc-1/purge", key: purgeKey, wantStatus: http.StatusOK, wantOrg: "org-1", wantAudit: true},
+		{name: "purge key purges through v2", mode: APIKeysRequired, method: http.MethodPost, path: "/api/v2/services/svc-1/purges", key: purgeKey, wantStatus: http.StatusOK, wantOrg: "org-1", wantAudit: true},
+		{name: "v2 needs a key when required", mode: APIKeysRequired, method: http.MethodGet, path: "/api/v2/operations", wantStatus: http.StatusUnauthorized},
+		{name: "purge key can't read", mode: APIKeysRequired, method: http.MethodGet, path: "/api/v1/services", key: purgeKey, wantStatus: http.StatusForbidden},
+		{name: "write key can't manage keys", mode: APIKeysRequired, method: http.MethodGet, path: "/api/v1/api-keys", key: writeKey, wantStatus: http.StatusForbidden},
+		{name: "admin key manages keys", mode: APIKeysRequired, method: http.MethodPost, path: "/api/v1/api-keys", key: adminKey, wantStatus: http.StatusOK, wantOrg: "org-1", wantAudit: true},
+		{name: "missing key when required", mode: APIKeysRequired, method: http.MethodGet, path: "/api/v1/services", wantStatus: http.StatusUnauthorized},
+		{name: "unknown key", mode: APIKeysOptional, method: http.MethodGet, path: "/api/v1/services", key: "cdnb_000000000000000000000000", wantStatus: http.StatusUnauthorized},
+		{name: "missing key when optional", mode: APIKeysOptional, method: http.MethodGet, path: "/api/v1/services", wantStatus: http.StatusOK},
+		{name: "backups need a key even when optional", mode: APIKeysOptional, method: http.MethodGet, path: "/api/v1/backup", wantStatus: http.StatusUnauthorized},
+		{name: "keys can't be created anonymously when optional", mode: APIKeysOptional, method: http.MethodPost, path: "/api/v1/api-keys", wantStatus: http.StatusUnauthorized},
+		{name: "restore needs a key when optional", mode: APIKeysOptional, method: http.MethodPost, path: "/api/v1/restore", wantStatus: http.StatusUnauthorized},
+		{name: "cors needs a key when optional", mode: APIKeysOptional, method: http.MethodPut, path: "/api/v1/cors", wantStatus: http.StatusUnauthorized},
+		{name: "write key can't take backups", mode: APIKeysOptional, method: http.MethodGet, path: "/api/v1/backup", key: writeKey, wantStatus: http.StatusForbidden},
+		{name: "admin key takes backups", mode: APIKeysOptional, method: http.MethodGet, path: "/api/v1/backup", key: adminKey, wantStatus: http.StatusOK, wantOrg: "org-1"},
+		{name: "exempt route", mode: APIKeysRequired, method: http.MethodPost, path: "/api/v1/hooks/cachefly", wantStatus: http.StatusOK},
+		{name: "outside the API", mode: APIKeysRequired, method: http.MethodGet, path: "/share/abc", wantStatus: http.StatusOK},
+		{name: "off ignores keys", mode: APIKeysOff, method: http.MethodGet, path: "/api/v1/services", key: "wrong", wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auditLog := audit.NewLog(10)
+			auth, err := NewAPIKeyAuth(keys, auditLog, DefaultScopeRoutes, tt.mode)
+			if err != nil {
+				t.Fatalf("NewAPIKeyAuth() unexpected error: %v", err)
+			}
+
+			var gotOrg string
+			handler := auth.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if p, ok := apikeys.PrincipalFrom(r.Context()); ok {
+					gotOrg = p.OrgID
+				}
+			}))
+
+			r := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.key != "" {
+				r.Header.Set(APIKeyHeader, tt.key)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if gotOrg != tt.wantOrg {
+				t.Errorf("principal org = %q, want %q", gotOrg, tt.wantOrg)
+			}
+			entries := auditLog.Query(audit.Filter{})
+			if (len(entries) == 1) != tt.wantAudit {
+				t.Errorf("audit entries = %v, want audited %v", entries, tt.wantAudit)
+			}
+			if tt.wantAudit && entries[0].APIKeyID == "" {
+				t.Error("audit entry has no API key ID")
+			}
+		})
+	}
+}
@@ -0,0 +1,225 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/avvvet/cdnbuddy-api/internal/api/problem"
+)
+
+// CORSRoute restricts the methods origins registered by an org may use on
+// routes matching Path ("*" wildcards, as in RouteLimit). Routes are matched
+// in order and the first match wins; no methods blocks the route entirely.
+type CORSRoute struct {
+	Path    string   `json:"path"`
+	Methods []string `json:"methods"`
+
+	pattern *regexp.Regexp
+}
+
+// DefaultCORSRoutes keep embedded frontends away from account-wide operations
+var DefaultCORSRoutes = []CORSRoute{
+	{Path: "/api/v1/backup"},
+	{Path: "/api/v1/restore"},
+	{Path: "/api/v1/cors*"},
+	{Path: "/api/v1/audit*", Methods: []string{"GET"}},
+}
+
+// CORS decides which origins may call the API: the globally configured ones
+// (our own dashboards) with every method on every route, and origins an org
+// registered for its embedded or white-label frontend, limited per route
+type CORS struct {
+	global  map[string]bool
+	routes  []CORSRoute
+	orgOf   func(r *http.Request) string
+	origins map[string]map[string]bool // org ID -> registered origins
+	mu      sync.RWMutex
+}
+
+// NewCORS creates the origin policy. orgOf tells which org a request is made for.
+func NewCORS(globalOrigins []string, routes []CORSRoute, orgOf func(r *http.Request) string) (*CORS, error) {
+	c := &CORS{
+		global:  make(map[string]bool, len(globalOrigins)),
+		routes:  make([]CORSRoute, len(routes)),
+		orgOf:   orgOf,
+		origins: make(map[string]map[string]bool),
+	}
+	for _, origin := range globalOrigins {
+		normalized, err := NormalizeOrigin(origin)
+		if err != nil {
+			return nil, err
+		}
+		c.global[normalized] = true
+	}
+	for i, route := range routes {
+		limit := RouteLimit{Path: route.Path}
+		if err := limit.compile(); err != nil {
+			return nil, err
+		}
+		route.pattern = limit.pattern
+		methods := make([]string, len(route.Methods))
+		for j, method := range route.Methods {
+			methods[j] = strings.ToUpper(strings.TrimSpace(method))
+		}
+		route.Methods = methods
+		c.routes[i] = route
+	}
+	return c, nil
+}
+
+// ParseCORSRoutes parses "path=GET|POST,path2=" route restrictions; an empty
+// method list blocks the path
+func ParseCORSRoutes(s string) ([]CORSRoute, error) {
+	routes := make([]CORSRoute, 0)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		path, methods, ok := strings.Cut(entry, "=")
+		if !ok || strings.TrimSpace(path) == "" {
+			return nil, fmt.Errorf("invalid CORS route %q (expected path=METHOD|METHOD)", entry)
+		}
+		route := CORSRoute{Path: strings.TrimSpace(path), Methods: []string{}}
+		for _, method := range strings.Split(methods, "|") {
+			if method = strings.TrimSpace(method); method != "" {
+				route.Methods = append(route.Methods, method)
+			}
+		}
+		routes = append(routes, route)
+	}
+	return routes, nil
+}
+
+// NormalizeOrigin validates an origin and returns it as browsers send it:
+// lower-case scheme and host, no path and no trailing slash
+func NormalizeOrigin(origin string) (string, error) {
+	u, err := url.Parse(strings.TrimSuffix(strings.TrimSpace(origin), "/"))
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", fmt.Errorf("invalid origin %q (expected e.g. https://dashboard.example.com)", origin)
+	}
+	if u.Path != "" || u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+		return "", fmt.Errorf("invalid origin %q (scheme, host and port only)", origin)
+	}
+	return strings.ToLower(u.Scheme + "://" + u.Host), nil
+}
+
+// AddOrigin registers an origin for an org and returns it normalized
+func (c *CORS) AddOrigin(orgID, origin string) (string, error) {
+	normalized, err := NormalizeOrigin(origin)
+	if err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.origins[orgID] == nil {
+		c.origins[orgID] = make(map[string]bool)
+	}
+	c.origins[orgID][normalized] = true
+	return normalized, nil
+}
+
+// RemoveOrigin unregisters an origin of an org; it reports whether it was registered
+func (c *CORS) RemoveOrigin(orgID, origin string) bool {
+	normalized, err := NormalizeOrigin(origin)
+	if err != nil {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.origins[orgID][normalized] {
+		return false
+	}
+	delete(c.origins[orgID], normalized)
+	if len(c.origins[orgID]) == 0 {
+		delete(c.origins, orgID)
+	}
+	return true
+}
+
+// Origins returns the origins registered by an org, sorted
+func (c *CORS) Origins(orgID string) []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	origins := make([]string, 0, len(c.origins[orgID]))
+	for origin := range c.origins[orgID] {
+		origins = append(origins, origin)
+	}
+	sort.Strings(origins)
+	return origins
+}
+
+// Routes returns the per-route method restrictions of org origins
+func (c *CORS) Routes() []CORSRoute {
+	return c.routes
+}
+
+// AllowOrigin reports whether an origin may call the API for the request's
+// org; it plugs into cors.Options.AllowOriginFunc
+func (c *CORS) AllowOrigin(r *http.Request, origin string) bool {
+	normalized, err := NormalizeOrigin(origin)
+	if err != nil {
+		return false
+	}
+	return c.global[normalized] || c.registered(r, normalized)
+}
+
+func (c *CORS) registered(r *http.Request, origin string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.origins[c.orgOf(r)][origin]
+}
+
+// Middleware rejects requests from org origins using a method their route
+// doesn't allow. It runs before the CORS handler so preflights are checked too.
+func (c *CORS) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin, err := NormalizeOrigin(r.Header.Get("Origin"))
+		if err != nil || c.global[origin] || !c.registered(r, origin) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		method := r.Method
+		if requested := r.Header.Get("Access-Control-Request-Method"); r.Method == http.MethodOptions && requested != "" {
+			method = strings.ToUpper(requested)
+		}
+		if c.methodAllowed(method, r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		logrus.WithFields(logrus.Fields{
+			"origin": origin,
+			"method": method,
+			"path":   r.URL.Path,
+		}).Warn("🚫 Cross-origin request blocked by route policy")
+
+		problem.Error(w, http.StatusForbidden, problem.CodeOriginNotAllowed, fmt.Sprintf("%s %s is not allowed from %s", method, r.URL.Path, origin))
+	})
+}
+
+func (c *CORS) methodAllowed(method, path string) bool {
+	for _, route := range c.routes {
+		if !route.pattern.MatchString(path) {
+			continue
+		}
+		for _, allowed := range route.Methods {
+			if allowed == method {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
@@ -0,0 +1,206 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// writeGrace is added to a route timeout for the connection write deadline, so
+// handlers that hit their context deadline still get to write an error response
+const writeGrace = 5 * time.Second
+
+// RouteLimit configures timeout and request size for routes matching Path.
+// Path may contain "*" wildcards; Method is optional (empty matches any method).
+// A Timeout of 0 disables the deadline (SSE, long exports).
+type RouteLimit struct {
+	Method       string        `json:"method,omitempty"`
+	Path         string        `json:"path"`
+	Timeout      time.Duration `json:"-"`
+	TimeoutRaw   string        `json:"timeout"`
+	MaxBodyBytes int64         `json:"max_body_bytes,omitempty"`
+
+	pattern *regexp.Regexp
+}
+
+// RouteLimitsConfig is the per-route configuration; rules are matched in order
+// and the first match wins, Default applies when nothing matches
+type RouteLimitsConfig struct {
+	Default RouteLimit   `json:"default"`
+	Routes  []RouteLimit `json:"routes"`
+}
+
+// DefaultRouteLimits are used when no config file is given
+var DefaultRouteLimits = RouteLimitsConfig{
+	Default: RouteLimit{Timeout: 60 * time.Second, MaxBodyBytes: 1 << 20},
+	Routes: []RouteLimit{
+		{Path: "/api/v1/*/purge*", Timeout: 10 * time.Second},
+		{Path: "/api/v1/*/export*", Timeout: 5 * time.Minute},
+		{Path: "/api/v1/*/events*", Timeout: 0},
+		{Path: "/api/v1/*/stream*", Timeout: 0},
+		{Path: "/api/v1/diagnostics/*", Timeout: 2 * time.Minute},
+		{Path: "/api/v1/backup", Timeout: 5 * time.Minute},
+		{Path: "/api/v1/restore", Timeout: 10 * time.Minute, MaxBodyBytes: 32 << 20},
+		{Path: "/api/v1/chat/voice", Timeout: 2 * time.Minute, MaxBodyBytes: 10 << 20},
+	},
+}
+
+// RouteLimits applies per-route timeouts and body limits. The config can be
+// swapped at runtime (see Watch) without dropping in-flight requests.
+type RouteLimits struct {
+	config atomic.Pointer[RouteLimitsConfig]
+}
+
+// NewRouteLimits creates route limits from a config
+func NewRouteLimits(cfg RouteLimitsConfig) (*RouteLimits, error) {
+	rl := &RouteLimits{}
+	if err := rl.Set(cfg); err != nil {
+		return nil, err
+	}
+	return rl, nil
+}
+
+// Set validates and atomically installs a new config
+func (rl *RouteLimits) Set(cfg RouteLimitsConfig) error {
+	routes := make([]RouteLimit, len(cfg.Routes))
+	for i, route := range cfg.Routes {
+		if err := route.compile(); err != nil {
+			return err
+		}
+		routes[i] = route
+	}
+	cfg.Routes = routes
+
+	rl.config.Store(&cfg)
+	return nil
+}
+
+// Load reads a JSON config file and installs it
+func (rl *RouteLimits) Load(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read route limits: %w", err)
+	}
+
+	var cfg RouteLimitsConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("failed to parse route limits: %w", err)
+	}
+	// Omitted timeouts inherit the default; use "0s" to disable the deadline
+	if err := cfg.Default.parseTimeout(DefaultRouteLimits.Default.Timeout); err != nil {
+		return err
+	}
+	for i := range cfg.Routes {
+		if err := cfg.Routes[i].parseTimeout(cfg.Default.Timeout); err != nil {
+			return err
+		}
+	}
+
+	return rl.Set(cfg)
+}
+
+// Watch reloads the config file whenever its modification time changes
+func (rl *RouteLimits) Watch(ctx context.Context, path string, interval time.Duration) {
+	var lastMod time.Time
+	if info, err := os.Stat(path); err == nil {
+		lastMod = info.ModTime()
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			info, err := os.Stat(path)
+			if err != nil || !info.ModTime().After(lastMod) {
+				continue
+			}
+			lastMod = info.ModTime()
+
+			if err := rl.Load(path); err != nil {
+				logrus.WithError(err).Warn("⚠️ Route limits reload failed, keeping previous config")
+				continue
+			}
+			logrus.WithField("path", path).Info("🔄 Route limits reloaded")
+		}
+	}
+}
+
+// Match returns the limit that applies to a request
+func (rl *RouteLimits) Match(method, path string) RouteLimit {
+	cfg := rl.config.Load()
+	for _, route := range cfg.Routes {
+		if route.Method != "" && !strings.EqualFold(route.Method, method) {
+			continue
+		}
+		if route.pattern.MatchString(path) {
+			if route.MaxBodyBytes == 0 {
+				route.MaxBodyBytes = cfg.Default.MaxBodyBytes
+			}
+			return route
+		}
+	}
+	return cfg.Default
+}
+
+// Middleware applies the matching route's timeout and body limit to each request
+func (rl *RouteLimits) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limit := rl.Match(r.Method, r.URL.Path)
+
+		if limit.MaxBodyBytes > 0 && r.Body != nil {
+			r.Body = http.MaxBytesReader(w, r.Body, limit.MaxBodyBytes)
+		}
+
+		// The server has no global read/write timeouts; deadlines are set per request
+		rc := http.NewResponseController(w)
+		if limit.Timeout > 0 {
+			deadline := time.Now().Add(limit.Timeout)
+			_ = rc.SetReadDeadline(deadline)
+			_ = rc.SetWriteDeadline(deadline.Add(writeGrace))
+
+			ctx, cancel := context.WithDeadline(r.Context(), deadline)
+			defer cancel()
+			r = r.WithContext(ctx)
+		} else {
+			_ = rc.SetReadDeadline(time.Time{})
+			_ = rc.SetWriteDeadline(time.Time{})
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (l *RouteLimit) compile() error {
+	expr := "^" + strings.ReplaceAll(regexp.QuoteMeta(l.Path), `\*`, ".*") + "$"
+	pattern, err := regexp.Compile(expr)
+	if err != nil {
+		return fmt.Errorf("invalid route pattern %q: %w", l.Path, err)
+	}
+	l.pattern = pattern
+	return nil
+}
+
+func (l *RouteLimit) parseTimeout(fallback time.Duration) error {
+	if l.TimeoutRaw == "" {
+		l.Timeout = fallback
+		return nil
+	}
+	d, err := time.ParseDuration(l.TimeoutRaw)
+	if err != nil {
+		return fmt.Errorf("invalid timeout %q for %s: %w", l.TimeoutRaw, l.Path, err)
+	}
+	l.Timeout = d
+	return nil
+}
@@ -1,7 +1,10 @@
 package models
 
 import (
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 )
 
@@ -9,8 +12,59 @@ import (
 type IntentRequest struct {
 	SessionID           string                `json:"session_id"`
 	UserMessage         string                `json:"user_message"`
-	ConversationHistory []ConversationMessage `json:"conversation_history"`
+	ConversationHistory []ConversationMessage `json:"conversation_history"` // messages since the summary
+	Summary             string                `json:"summary,omitempty"`    // rolling summary of older messages
 	AvailableActions    []ActionSchema        `json:"available_actions"`
+	Context             *IntentContext        `json:"context,omitempty"`
+}
+
+// IntentContext is account state the intent service can use instead of asking
+// the user for parameters
+type IntentContext struct {
+	InferredParameters map[string]string   `json:"inferred_parameters,omitempty"` // unambiguous values, safe to use as-is
+	Candidates         map[string][]string `json:"candidates,omitempty"`          // known values to offer when asking
+}
+
+// Fill completes a NEEDS_INFO response whose missing parameters can all be
+// inferred, turning it READY. It returns the filled parameter names.
+func (c *IntentContext) Fill(resp *IntentResponse) []string {
+	if c == nil || resp == nil || resp.Status != "NEEDS_INFO" || resp.Action == nil {
+		return nil
+	}
+
+	var missing []string
+	for name, value := range resp.Parameters {
+		if value == nil || *value == "" {
+			if _, ok := c.InferredParameters[name]; !ok {
+				return nil
+			}
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	sort.Strings(missing)
+	for _, name := range missing {
+		value := c.InferredParameters[name]
+		resp.Parameters[name] = &value
+	}
+	resp.Status = "READY"
+	return missing
+}
+
+// SummaryRequest asks the intent service to fold messages into a session summary
+type SummaryRequest struct {
+	SessionID       string                `json:"session_id"`
+	PreviousSummary string                `json:"previous_summary,omitempty"`
+	Messages        []ConversationMessage `json:"messages"`
+}
+
+// SummaryResponse is the updated summary of a session
+type SummaryResponse struct {
+	Summary string       `json:"summary"`
+	Usage   *IntentUsage `json:"usage,omitempty"`
 }
 
 type ConversationMessage struct {
@@ -33,6 +87,14 @@ type IntentResponse struct {
 	UserMessage  string             `json:"user_message"`
 	ErrorCode    *string            `json:"error_code,omitempty"`
 	ErrorMessage *string            `json:"error_message,omitempty"`
+	Usage        *IntentUsage       `json:"usage,omitempty"`
+}
+
+// IntentUsage reports LLM token usage for one intent analysis (if the intent service provides it)
+type IntentUsage struct {
+	PromptTokens     int64 `json:"prompt_tokens"`
+	CompletionTokens int64 `json:"completion_tokens"`
+	TotalTokens      int64 `json:"total_tokens"`
 }
 
 // ExecutionPlan represents a pending execution plan for the user
@@ -44,9 +106,30 @@ type ExecutionPlan struct {
 	EstimatedDuration string             `json:"estimated_duration"`
 	Action            string             `json:"action"`
 	Parameters        map[string]*string `json:"parameters"`
-	IntentResponse    *IntentResponse    `json:"-"` // Store original intent (not sent to frontend)
+	IntentResponse    *IntentResponse    `json:"-"`                 // Store original intent (not sent to frontend)
+	Preview           interface{}        `json:"preview,omitempty"` // predicted effect, e.g. a cache rule simulation
+	Changes           interface{}        `json:"changes,omitempty"` // provider requests the plan would send (dry run)
 	CreatedAt         time.Time          `json:"created_at"`
 	ExpiresAt         time.Time          `json:"expires_at"`
+
+	// Demo sandbox the plan was made in, empty for the real account; the plan
+	// only ever runs there
+	SandboxID string `json:"sandbox_id,omitempty"`
+
+	// Org of the widget user the plan was made for, empty for the account's
+	// own users; the plan may only touch that org's services
+	WidgetOrgID string `json:"widget_org_id,omitempty"`
+}
+
+// ErrWrongSandbox is returned for a plan confirmed outside the sandbox it was made in
+var ErrWrongSandbox = errors.New("plan belongs to a different sandbox")
+
+// CheckSandbox reports whether a confirmation from sandboxID may run the plan
+func (p *ExecutionPlan) CheckSandbox(sandboxID string) error {
+	if sandboxID != p.SandboxID {
+		return ErrWrongSandbox
+	}
+	return nil
 }
 
 // BuildExecutionPlan creates an execution plan from IntentResponse
@@ -89,6 +172,12 @@ func BuildExecutionPlan(intent *IntentResponse) ExecutionPlan {
 			"Enable SSL certificate",
 			"Configure caching rules",
 		}
+		if profile := intent.Parameters["profile"]; profile != nil && *profile != "" {
+			plan.Steps[3] = fmt.Sprintf("Configure caching rules for the %s profile", *profile)
+		}
+		if shield := intent.Parameters["origin_shield"]; shield != nil && *shield != "" {
+			plan.Steps = append(plan.Steps, fmt.Sprintf("Origin shield: %s", *shield))
+		}
 
 	case "PURGE_CACHE":
 		domain := ""
@@ -102,15 +191,169 @@ func BuildExecutionPlan(intent *IntentResponse) ExecutionPlan {
 			"Propagate changes across CDN nodes",
 		}
 
+	case "SET_STALE_POLICY":
+		serviceID := ""
+		if id := intent.Parameters["service_id"]; id != nil {
+			serviceID = *id
+		}
+		plan.Title = fmt.Sprintf("Update stale content policy for %s", serviceID)
+		plan.Description = "Change when the CDN may serve expired content (stale-while-revalidate / stale-if-error)"
+		plan.Steps = []string{
+			"Read the current stale content policy",
+			"Apply the requested stale-while-revalidate / stale-if-error settings",
+			"Explain what changed and the freshness vs. availability trade-off",
+		}
+
+	case "UPDATE_CACHE_RULES":
+		serviceID := ""
+		if id := intent.Parameters["service_id"]; id != nil {
+			serviceID = *id
+		}
+		plan.Title = fmt.Sprintf("Update cache rules for %s", serviceID)
+		plan.Description = "Replace the cache rules; the preview shows predicted TTLs and hit ratio for sample URLs"
+		plan.Steps = []string{
+			"Validate the proposed cache rules",
+			"Replace the service's cache rules",
+			"Propagate changes across CDN nodes",
+		}
+
+	case "CONFIGURE_ORIGIN_SHIELD":
+		serviceID := ""
+		if id := intent.Parameters["service_id"]; id != nil {
+			serviceID = *id
+		}
+		// Trade-offs are part of the plan so users see them before confirming
+		plan.Title = fmt.Sprintf("Configure origin shield for %s", serviceID)
+		plan.Description = "Reduce origin load with an origin shield and request coalescing. " +
+			"Origin shield: cache misses from all edge locations go through one shield location, so your origin sees far fewer requests; " +
+			"a first request from a distant edge takes an extra hop and some providers bill shield traffic separately. " +
+			"Request coalescing: concurrent requests for the same uncached object share one origin fetch; " +
+			"waiting visitors share that fetch's latency, so keep it off for personalized responses."
+		plan.Steps = []string{
+			"Check that the provider supports the requested options",
+			"Apply origin shield / request coalescing settings",
+			"Report the new settings",
+		}
+
+	case "DELETE_SERVICE":
+		plan.Title = fmt.Sprintf("Delete service %s", serviceLabel(intent.Parameters))
+		plan.Description = "Permanently delete the CDN service. This cannot be undone."
+		plan.Steps = []string{
+			"Verify the confirmation phrase matches the service name",
+			"Delete the service at the provider",
+		}
+
+	case "PURGE_ALL":
+		plan.Title = fmt.Sprintf("Purge entire cache of %s", serviceLabel(intent.Parameters))
+		plan.Description = "Clear every cached object; the origin serves all traffic until the cache refills"
+		plan.Steps = []string{
+			"Verify the confirmation phrase matches the service name",
+			"Purge all cached content",
+			"Propagate changes across CDN nodes",
+		}
+
+	case "PROTECT_HOTLINKS":
+		plan.Title = fmt.Sprintf("Update hotlink protection for %s", serviceLabel(intent.Parameters))
+		plan.Description = "Only let allowed sites embed your content, by Referer header"
+		plan.Steps = []string{
+			"Resolve allowed referrers (your own domains by default)",
+			"Apply referrer rules to the service",
+		}
+
+	case "ADD_HEADER":
+		header := ""
+		if h := intent.Parameters["header"]; h != nil {
+			header = *h
+		}
+		plan.Title = fmt.Sprintf("Set %s header on %s", header, serviceLabel(intent.Parameters))
+		plan.Description = "Add a header to every response, replacing the origin's value"
+		plan.Steps = []string{
+			"Validate the header name and value",
+			"Update the service's response headers",
+			"Propagate changes across CDN nodes",
+		}
+
+	case "SECURE_SERVICE":
+		plan.Title = "Update firewall for service"
+		plan.Description = "Change IP and country blocklists, managed rule sets and bot protection"
+		plan.Steps = []string{
+			"Validate IPs, CIDR ranges and country codes",
+			"Check which firewall features the provider supports",
+			"Apply the firewall settings to the service",
+		}
+
+	case "ACCOUNT_STATUS":
+		plan.Title = "Summarize provider account"
+		plan.Description = "Report plan, quota usage, services and provider incidents"
+		plan.Steps = []string{
+			"Read account details from the provider",
+			"Count active and deactivated services",
+			"Report quota usage and open incidents",
+		}
+
+	case "UNDO":
+		plan.Title = "Undo last configuration change"
+		if id := intent.Parameters["service_id"]; id != nil && *id != "" {
+			plan.Title = fmt.Sprintf("Undo last configuration change to %s", *id)
+		}
+		plan.Description = "Restore the provider options the service had before its last service update or cache rule change"
+		plan.Steps = []string{
+			"Find the last configuration change",
+			"Write back the options the service had before it",
+			"Propagate changes across CDN nodes",
+		}
+
+	case "IMPORT_SERVICES":
+		plan.Title = "Import existing CDN services"
+		plan.Description = "Adopt services that already exist in your provider account so CDNBuddy can manage them"
+		plan.Steps = []string{
+			"Discover services in the provider account",
+			"Read each selected service's current configuration",
+			"Record the services as managed by CDNBuddy",
+		}
+
 	default:
 		plan.Title = "Execute action"
 		plan.Description = "Process your request"
 		plan.Steps = []string{"Execute requested action"}
 	}
 
+	if plan.DryRun() {
+		plan.Title = "Dry run: " + plan.Title
+		plan.Steps = append(plan.Steps, "Simulate only: nothing is sent to the provider")
+	}
+
 	return plan
 }
 
+// DryRun reports whether the plan only simulates its action
+func (p ExecutionPlan) DryRun() bool {
+	return IsDryRun(p.Parameters)
+}
+
+// IsDryRun reports whether intent parameters ask for a simulation only (dry_run=true)
+func IsDryRun(params map[string]*string) bool {
+	v := params["dry_run"]
+	if v == nil {
+		return false
+	}
+	switch strings.ToLower(strings.TrimSpace(*v)) {
+	case "true", "yes", "1", "on":
+		return true
+	}
+	return false
+}
+
+// serviceLabel names the service an intent targets, by domain or ID
+func serviceLabel(params map[string]*string) string {
+	for _, key := range []string{"domain", "service_id"} {
+		if v := params[key]; v != nil && *v != "" {
+			return *v
+		}
+	}
+	return "service"
+}
+
 // generatePlanID creates a unique plan ID
 func generatePlanID() string {
 	return fmt.Sprintf("plan_%d", time.Now().UnixNano())
@@ -0,0 +1,230 @@
+// Package apikeys issues and verifies the API keys integrations call the API
+// with. Only a SHA-256 hash of each key is kept; the key itself is shown once
+// when it is created.
+package apikeys
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Scope is what a key may do
+type Scope string
+
+const (
+	ScopeRead  Scope = "read"  // GET requests
+	ScopeWrite Scope = "write" // changes; implies read
+	ScopePurge Scope = "purge" // cache purges only, for CI pipelines
+	ScopeAdmin Scope = "admin" // everything, including key management
+)
+
+// Scopes lists every scope
+var Scopes = []Scope{ScopeRead, ScopeWrite, ScopePurge, ScopeAdmin}
+
+// keyPrefix marks our keys so secret scanners can find leaked ones
+const keyPrefix = "cdnb_"
+
+var (
+	// ErrInvalidKey is returned for keys that don't exist, were revoked or expired
+	ErrInvalidKey = errors.New("invalid or expired API key")
+	// ErrUnknownKey is returned when revoking a key that doesn't exist
+	ErrUnknownKey = errors.New("API key not found")
+)
+
+// Key is a stored API key; Hash is never serialized
+type Key struct {
+	ID         string     `json:"id"`
+	OrgID      string     `json:"org_id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"` // the first characters of the key, to recognize it
+	Scopes     []Scope    `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	Hash       string     `json:"-"`
+}
+
+// Allows reports whether the key grants scope
+func (k Key) Allows(scope Scope) bool {
+	return allows(k.Scopes, scope)
+}
+
+// Principal is the caller a request authenticated as
+type Principal struct {
+	KeyID  string  `json:"key_id"`
+	OrgID  string  `json:"org_id"`
+	Name   string  `json:"name"`
+	Scopes []Scope `json:"scopes"`
+}
+
+// Allows reports whether the principal's key grants scope
+func (p Principal) Allows(scope Scope) bool {
+	return allows(p.Scopes, scope)
+}
+
+func allows(scopes []Scope, scope Scope) bool {
+	return slices.Contains(scopes, ScopeAdmin) || slices.Contains(scopes, scope) ||
+		(scope == ScopeRead && slices.Contains(scopes, ScopeWrite))
+}
+
+// Subject names the principal in audit entries
+func (p Principal) Subject() string {
+	return "api_key:" + p.KeyID
+}
+
+type principalKey struct{}
+
+// WithPrincipal attaches the authenticated caller to ctx
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFrom returns the caller attached by WithPrincipal
+func PrincipalFrom(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+// ParseScopes validates scope names
+func ParseScopes(names []string) ([]Scope, error) {
+	if len(names) == 0 {
+		return nil, fmt.Errorf("at least one scope is required")
+	}
+	scopes := make([]Scope, 0, len(names))
+	for _, name := range names {
+		scope := Scope(strings.ToLower(strings.TrimSpace(name)))
+		if !slices.Contains(Scopes, scope) {
+			return nil, fmt.Errorf("unknown scope %q (expected read, write, purge or admin)", name)
+		}
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes, nil
+}
+
+// Store keeps API keys in memory, indexed by hash
+type Store struct {
+	keys   map[string]*Key // by ID
+	byHash map[string]*Key
+	mu     sync.RWMutex
+}
+
+// NewStore creates an empty key store
+func NewStore() *Store {
+	return &Store{
+		keys:   make(map[string]*Key),
+		byHash: make(map[string]*Key),
+	}
+}
+
+// Create issues a key and returns it with the plaintext, which isn't kept
+func (s *Store) Create(orgID, name string, scopes []Scope, expiresAt *time.Time) (Key, string, error) {
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return Key{}, "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+	raw := keyPrefix + hex.EncodeToString(secret)
+
+	key, err := s.Import(orgID, name, raw, scopes, expiresAt)
+	if err != nil {
+		return Key{}, "", err
+	}
+	return key, raw, nil
+}
+
+// Import stores a key generated elsewhere, such as the bootstrap admin key
+// from the environment
+func (s *Store) Import(orgID, name, raw string, scopes []Scope, expiresAt *time.Time) (Key, error) {
+	if len(raw) < 24 {
+		return Key{}, fmt.Errorf("API keys must be at least 24 characters")
+	}
+	if strings.TrimSpace(name) == "" {
+		return Key{}, fmt.Errorf("name is required")
+	}
+	if len(scopes) == 0 {
+		return Key{}, fmt.Errorf("at least one scope is required")
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return Key{}, fmt.Errorf("expires_at must be in the future")
+	}
+
+	key := &Key{
+		ID:        uuid.New().String(),
+		OrgID:     orgID,
+		Name:      strings.TrimSpace(name),
+		Prefix:    raw[:min(len(raw), len(keyPrefix)+6)],
+		Scopes:    scopes,
+		CreatedAt: time.Now(),
+		ExpiresAt: expiresAt,
+		Hash:      hash(raw),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.byHash[key.Hash]; exists {
+		return Key{}, fmt.Errorf("API key already exists")
+	}
+	s.keys[key.ID] = key
+	s.byHash[key.Hash] = key
+	return *key, nil
+}
+
+// Authenticate returns the principal of a raw key and records its use
+func (s *Store) Authenticate(raw string) (Principal, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.byHash[hash(raw)]
+	now := time.Now()
+	if !ok || (key.ExpiresAt != nil && now.After(*key.ExpiresAt)) {
+		return Principal{}, ErrInvalidKey
+	}
+	key.LastUsedAt = &now
+	return Principal{KeyID: key.ID, OrgID: key.OrgID, Name: key.Name, Scopes: key.Scopes}, nil
+}
+
+// Revoke deletes an org's key
+func (s *Store) Revoke(orgID, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.keys[id]
+	if !ok || key.OrgID != orgID {
+		return ErrUnknownKey
+	}
+	delete(s.keys, id)
+	delete(s.byHash, key.Hash)
+	return nil
+}
+
+// List returns an org's keys, oldest first
+func (s *Store) List(orgID string) []Key {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := make([]Key, 0)
+	for _, key := range s.keys {
+		if key.OrgID == orgID {
+			keys = append(keys, *key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.Before(keys[j].CreatedAt) })
+	return keys
+}
+
+func hash(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
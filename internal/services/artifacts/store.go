@@ -0,0 +1,75 @@
+// Package artifacts keeps generated files (reports, exports) for a limited
+// time so chat responses can link to them for download.
+package artifacts
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/avvvet/cdnbuddy-api/internal/lru"
+)
+
+// maxArtifactBytes caps the size of a single artifact
+const maxArtifactBytes = 10 << 20
+
+// Artifact is a downloadable file
+type Artifact struct {
+	ID          string    `json:"id"`
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"content_type"`
+	Data        []byte    `json:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// Store holds artifacts in memory until they expire
+type Store struct {
+	artifacts *lru.Cache[string, *Artifact]
+	ttl       time.Duration
+	baseURL   string
+}
+
+// NewStore creates a store keeping at most maxEntries artifacts for ttl each;
+// download links are baseURL + "/" + ID
+func NewStore(ttl time.Duration, maxEntries int, baseURL string) *Store {
+	return &Store{
+		artifacts: lru.New[string, *Artifact]("artifacts", maxEntries, ttl),
+		ttl:       ttl,
+		baseURL:   baseURL,
+	}
+}
+
+// Put stores a file and returns it with its ID
+func (s *Store) Put(filename, contentType string, data []byte) (*Artifact, error) {
+	if len(data) > maxArtifactBytes {
+		return nil, fmt.Errorf("artifact too large: %d bytes (max %d)", len(data), maxArtifactBytes)
+	}
+
+	now := time.Now()
+	a := &Artifact{
+		ID:          uuid.New().String(),
+		Filename:    filename,
+		ContentType: contentType,
+		Data:        data,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(s.ttl),
+	}
+	s.artifacts.PutUntil(a.ID, a, a.ExpiresAt)
+	return a, nil
+}
+
+// Get returns an artifact that hasn't expired
+func (s *Store) Get(id string) (*Artifact, error) {
+	a, ok := s.artifacts.Get(id)
+	if !ok {
+		return nil, fmt.Errorf("artifact not found or expired: %s", id)
+	}
+	return a, nil
+}
+
+// URL returns the download link of an artifact
+func (s *Store) URL(a *Artifact) string {
+	return s.baseURL + "/" + a.ID
+}
@@ -0,0 +1,228 @@
+package audit
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// defaultMaxEntries bounds the in-memory audit log
+const defaultMaxEntries = 50000
+
+// Entry is one executed change: who ran which action with which parameters.
+// Setting and Value name the configuration that changed when the action is a
+// setting change (e.g. "brotli" -> "enabled"); each such entry is one version
+// of that setting in its history.
+type Entry struct {
+	ID         string            `json:"id"`
+	Timestamp  time.Time         `json:"timestamp"`
+	UserID     string            `json:"user_id"`
+	APIKeyID   string            `json:"api_key_id,omitempty"` // set for changes made with an API key
+	SessionID  string            `json:"session_id,omitempty"`
+	PlanID     string            `json:"plan_id,omitempty"`
+	Action     string            `json:"action"`
+	ServiceID  string            `json:"service_id,omitempty"`
+	Domain     string            `json:"domain,omitempty"`
+	Setting    string            `json:"setting,omitempty"`
+	Value      string            `json:"value,omitempty"`
+	Parameters map[string]string `json:"parameters,omitempty"`
+	// Confirmation is the phrase typed to confirm a destructive action
+	Confirmation string `json:"confirmation,omitempty"`
+	Success      bool   `json:"success"`
+	Error        string `json:"error,omitempty"`
+}
+
+// Filter selects audit entries; empty fields match everything
+type Filter struct {
+	UserID    string
+	ServiceID string
+	Domain    string
+	Setting   string
+	Action    string
+	Since     time.Time
+	Limit     int
+}
+
+// Log is an append-only, bounded in-memory audit log
+type Log struct {
+	entries    []Entry // oldest first
+	maxEntries int
+	mu         sync.RWMutex
+}
+
+// NewLog creates a new audit log keeping at most maxEntries (0 = default)
+func NewLog(maxEntries int) *Log {
+	if maxEntries <= 0 {
+		maxEntries = defaultMaxEntries
+	}
+	return &Log{maxEntries: maxEntries}
+}
+
+// Record appends an entry, filling ID and timestamp if unset
+func (l *Log) Record(e Entry) Entry {
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+	if e.Timestamp.IsZero() {
+		e.Timestamp = time.Now()
+	}
+
+	l.mu.Lock()
+	l.entries = append(l.entries, e)
+	if over := len(l.entries) - l.maxEntries; over > 0 {
+		l.entries = append([]Entry(nil), l.entries[over:]...)
+	}
+	l.mu.Unlock()
+
+	logrus.WithFields(logrus.Fields{
+		"user_id": e.UserID,
+		"action":  e.Action,
+		"domain":  e.Domain,
+		"success": e.Success,
+	}).Debug("📝 Recorded audit entry")
+
+	return e
+}
+
+// Query returns matching entries, newest first
+func (l *Log) Query(f Filter) []Entry {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	result := make([]Entry, 0)
+	for i := len(l.entries) - 1; i >= 0; i-- {
+		e := l.entries[i]
+		if !f.matches(e) {
+			continue
+		}
+		result = append(result, e)
+		if f.Limit > 0 && len(result) >= f.Limit {
+			break
+		}
+	}
+	return result
+}
+
+// History returns the successful changes of a setting on a domain, oldest first
+func (l *Log) History(domainName, setting string) []Entry {
+	history := l.Query(Filter{Domain: domainName, Setting: setting})
+
+	changes := make([]Entry, 0, len(history))
+	for _, e := range history {
+		if e.Success {
+			changes = append(changes, e)
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Timestamp.Before(changes[j].Timestamp)
+	})
+	return changes
+}
+
+// WhoChanged returns the most recent successful change of a setting on a domain
+func (l *Log) WhoChanged(domainName, setting string) (*Entry, bool) {
+	history := l.History(domainName, setting)
+	if len(history) == 0 {
+		return nil, false
+	}
+	return &history[len(history)-1], true
+}
+
+// Attribution answers "who changed <setting> on <domain> and when?" in one sentence
+func (l *Log) Attribution(domainName, setting string) string {
+	e, ok := l.WhoChanged(domainName, setting)
+	if !ok {
+		return fmt.Sprintf("I couldn't find any recorded change of %s on %s.", settingLabel(setting), domainName)
+	}
+
+	change := "changed"
+	if e.Value != "" {
+		change = "set to " + e.Value
+	}
+
+	answer := fmt.Sprintf("%s on %s was %s by %s on %s (action %s).",
+		settingLabel(setting), domainName, change, e.UserID,
+		e.Timestamp.UTC().Format("2006-01-02 15:04 MST"), e.Action)
+
+	if versions := len(l.History(domainName, setting)); versions > 1 {
+		answer += fmt.Sprintf(" It has been changed %d times in total.", versions)
+	}
+	return answer
+}
+
+// EntryFromIntent builds an audit entry for an executed intent, picking the
+// domain, service and setting out of the intent parameters
+func EntryFromIntent(userID, sessionID, planID, action string, params map[string]*string) Entry {
+	e := Entry{
+		UserID:     userID,
+		SessionID:  sessionID,
+		PlanID:     planID,
+		Action:     action,
+		Parameters: make(map[string]string, len(params)),
+	}
+	for k, v := range params {
+		if v != nil {
+			e.Parameters[k] = *v
+		}
+	}
+
+	e.Domain = firstParam(e.Parameters, "domain", "domain_name", "hostname")
+	e.ServiceID = e.Parameters["service_id"]
+	e.Setting = firstParam(e.Parameters, "setting", "feature", "option")
+	e.Value = firstParam(e.Parameters, "value", "enabled", "ttl")
+	e.Confirmation = e.Parameters["confirmation"]
+	return e
+}
+
+func firstParam(params map[string]string, keys ...string) string {
+	for _, k := range keys {
+		if v := params[k]; v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func (f Filter) matches(e Entry) bool {
+	if f.UserID != "" && e.UserID != f.UserID {
+		return false
+	}
+	if f.ServiceID != "" && e.ServiceID != f.ServiceID {
+		return false
+	}
+	if f.Action != "" && !strings.EqualFold(e.Action, f.Action) {
+		return false
+	}
+	if f.Domain != "" && !strings.EqualFold(e.Domain, f.Domain) {
+		return false
+	}
+	if f.Setting != "" && !matchesSetting(e, f.Setting) {
+		return false
+	}
+	if !f.Since.IsZero() && e.Timestamp.Before(f.Since) {
+		return false
+	}
+	return true
+}
+
+// matchesSetting matches the recorded setting, or the action name for changes
+// that don't carry an explicit setting (e.g. "brotli" matches ENABLE_BROTLI)
+func matchesSetting(e Entry, setting string) bool {
+	setting = strings.ToLower(setting)
+	if e.Setting != "" {
+		return strings.ToLower(e.Setting) == setting
+	}
+	return strings.Contains(strings.ToLower(e.Action), setting)
+}
+
+func settingLabel(setting string) string {
+	if setting == "" {
+		return "This setting"
+	}
+	return strings.ToUpper(setting[:1]) + setting[1:]
+}
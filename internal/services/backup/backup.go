@@ -0,0 +1,292 @@
+// Package backup exports a complete account snapshot and replays it against
+// providers, for disaster recovery and moving accounts between providers.
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/avvvet/cdnbuddy-api/internal/domain"
+	"github.com/avvvet/cdnbuddy-api/internal/services/cdn"
+	"github.com/avvvet/cdnbuddy-api/internal/services/operations"
+)
+
+// FormatVersion is bumped when the export format changes incompatibly
+const FormatVersion = 1
+
+// Backup is a complete export of an account
+type Backup struct {
+	Version   int             `json:"version"`
+	CreatedAt time.Time       `json:"created_at"`
+	Services  []ServiceBackup `json:"services"`
+	Warnings  []string        `json:"warnings,omitempty"` // settings that couldn't be read
+}
+
+// ServiceBackup is one service with its domains and settings. Settings a
+// provider doesn't support are left out.
+type ServiceBackup struct {
+	Service     domain.CDNService      `json:"service"`
+	Origin      cdn.OriginConfig       `json:"origin"`
+	Domains     []string               `json:"domains"`
+	CacheKey    *cdn.CacheKeyConfig    `json:"cache_key,omitempty"`
+	StalePolicy *cdn.StalePolicy       `json:"stale_policy,omitempty"`
+	OriginLoad  *cdn.OriginLoadOptions `json:"origin_load,omitempty"`
+	LogDelivery *cdn.LogDelivery       `json:"log_delivery,omitempty"`
+}
+
+// RestoreOptions control a restore
+type RestoreOptions struct {
+	UserID       string
+	Provider     domain.CDNProvider // restore onto this provider instead of the original one
+	DryRun       bool               // only report what would be done
+	SkipExisting bool               // skip services whose name already exists
+}
+
+// RestoreStep is one replayed operation
+type RestoreStep struct {
+	Action      string `json:"action"`
+	Target      string `json:"target"`
+	OperationID string `json:"operation_id,omitempty"`
+	Status      string `json:"status"` // planned, succeeded, failed, skipped
+	Error       string `json:"error,omitempty"`
+}
+
+// ServiceRestore is the outcome of restoring one service
+type ServiceRestore struct {
+	Name         string        `json:"name"`
+	OldServiceID string        `json:"old_service_id"`
+	NewServiceID string        `json:"new_service_id,omitempty"`
+	Steps        []RestoreStep `json:"steps"`
+}
+
+// RestoreReport is the outcome of a restore
+type RestoreReport struct {
+	DryRun   bool             `json:"dry_run"`
+	Services []ServiceRestore `json:"services"`
+	Failed   int              `json:"failed"` // failed steps
+}
+
+// Manager exports and restores accounts; restores are recorded as operations
+type Manager struct {
+	operations *operations.Store
+}
+
+// NewManager creates a backup manager
+func NewManager(ops *operations.Store) *Manager {
+	return &Manager{operations: ops}
+}
+
+// Export snapshots every service of the account
+func (m *Manager) Export(ctx context.Context, svc *cdn.Service) (*Backup, error) {
+	services, err := svc.ListServices(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services: %w", err)
+	}
+
+	backup := &Backup{
+		Version:   FormatVersion,
+		CreatedAt: time.Now(),
+		Services:  make([]ServiceBackup, 0, len(services)),
+	}
+
+	for _, service := range services {
+		item := ServiceBackup{Service: service, Origin: originFromConfig(service.Config), Domains: make([]string, 0)}
+		if item.Origin.Host == "" {
+			backup.Warnings = append(backup.Warnings, fmt.Sprintf("%s: origin unknown, set services[].origin.host before restoring", service.ID))
+		}
+
+		domains, err := svc.ListServiceDomains(ctx, service)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list domains of %s: %w", service.ID, err)
+		}
+		for _, d := range domains {
+			item.Domains = append(item.Domains, d.Name)
+		}
+
+		scoped, err := svc.ForProvider(service.Provider)
+		if err != nil {
+			scoped = svc
+		}
+
+		warn := func(setting string, err error) {
+			if err != nil && !errors.Is(err, cdn.ErrNotSupported) {
+				backup.Warnings = append(backup.Warnings, fmt.Sprintf("%s %s: %v", service.ID, setting, err))
+			}
+		}
+
+		item.CacheKey, err = scoped.GetCacheKey(ctx, service.ID)
+		warn("cache key", err)
+		item.StalePolicy, err = scoped.GetStalePolicy(ctx, service.ID)
+		warn("stale policy", err)
+		item.OriginLoad, err = scoped.GetOriginLoad(ctx, service.ID)
+		warn("origin load", err)
+		item.LogDelivery, err = scoped.GetLogDelivery(ctx, service.ID)
+		warn("log delivery", err)
+
+		backup.Services = append(backup.Services, item)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"services": len(backup.Services),
+		"warnings": len(backup.Warnings),
+	}).Info("💾 Account exported")
+
+	return backup, nil
+}
+
+// Restore replays a backup: each service is created with its origin, then its
+// domains are added and its settings applied. A failed step doesn't stop the
+// remaining services.
+func (m *Manager) Restore(ctx context.Context, svc *cdn.Service, backup *Backup, opts RestoreOptions) (*RestoreReport, error) {
+	if backup.Version != FormatVersion {
+		return nil, fmt.Errorf("unsupported backup version %d (expected %d)", backup.Version, FormatVersion)
+	}
+
+	existing := make(map[string]bool)
+	if opts.SkipExisting {
+		services, err := svc.ListServices(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list services: %w", err)
+		}
+		for _, s := range services {
+			existing[s.Name] = true
+		}
+	}
+
+	report := &RestoreReport{DryRun: opts.DryRun, Services: make([]ServiceRestore, 0, len(backup.Services))}
+
+	for _, item := range backup.Services {
+		result := ServiceRestore{Name: item.Service.Name, OldServiceID: item.Service.ID, Steps: make([]RestoreStep, 0)}
+
+		if existing[item.Service.Name] {
+			result.Steps = append(result.Steps, RestoreStep{Action: "CREATE_SERVICE", Target: item.Service.Name, Status: "skipped", Error: "a service with this name already exists"})
+			report.Services = append(report.Services, result)
+			continue
+		}
+
+		provider := opts.Provider
+		if provider == "" {
+			provider = item.Service.Provider
+		}
+		target, err := svc.ForProvider(provider)
+		if err != nil {
+			result.Steps = append(result.Steps, RestoreStep{Action: "CREATE_SERVICE", Target: item.Service.Name, Status: "failed", Error: err.Error()})
+			report.Failed++
+			report.Services = append(report.Services, result)
+			continue
+		}
+
+		m.restoreService(ctx, target, item, opts, &result)
+		for _, step := range result.Steps {
+			if step.Status == "failed" {
+				report.Failed++
+			}
+		}
+		report.Services = append(report.Services, result)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"services": len(report.Services),
+		"failed":   report.Failed,
+		"dry_run":  opts.DryRun,
+	}).Info("♻️ Account restore finished")
+
+	return report, nil
+}
+
+func (m *Manager) restoreService(ctx context.Context, svc *cdn.Service, item ServiceBackup, opts RestoreOptions, result *ServiceRestore) {
+	serviceID := ""
+
+	ok := m.step(opts, result, "CREATE_SERVICE", item.Service.Name, "", func() error {
+		if item.Origin.Host == "" {
+			return fmt.Errorf("origin host missing in backup")
+		}
+		created, err := svc.CreateService(ctx, &cdn.ServiceConfig{
+			Name:   item.Service.Name,
+			Origin: item.Origin,
+			SSL:    cdn.SSLConfig{Enabled: true},
+		})
+		if err != nil {
+			return err
+		}
+		serviceID = created.ID
+		result.NewServiceID = created.ID
+		return nil
+	})
+	if !ok {
+		return
+	}
+
+	for _, name := range item.Domains {
+		m.step(opts, result, "ADD_DOMAIN", name, serviceID, func() error {
+			return svc.AddDomain(ctx, serviceID, name)
+		})
+	}
+
+	if item.CacheKey != nil {
+		m.step(opts, result, "UPDATE_CACHE_KEY", item.Service.Name, serviceID, func() error {
+			return svc.UpdateCacheKey(ctx, serviceID, *item.CacheKey)
+		})
+	}
+	if item.StalePolicy != nil {
+		m.step(opts, result, "SET_STALE_POLICY", item.Service.Name, serviceID, func() error {
+			return svc.UpdateStalePolicy(ctx, serviceID, *item.StalePolicy)
+		})
+	}
+	if item.OriginLoad != nil {
+		m.step(opts, result, "CONFIGURE_ORIGIN_SHIELD", item.Service.Name, serviceID, func() error {
+			return svc.UpdateOriginLoad(ctx, serviceID, *item.OriginLoad)
+		})
+	}
+	if item.LogDelivery != nil && item.LogDelivery.Enabled {
+		m.step(opts, result, "UPDATE_LOG_DELIVERY", item.Service.Name, serviceID, func() error {
+			return svc.UpdateLogDelivery(ctx, serviceID, *item.LogDelivery)
+		})
+	}
+}
+
+// step runs one restore action as a recorded operation and reports whether it succeeded
+func (m *Manager) step(opts RestoreOptions, result *ServiceRestore, action, target, serviceID string, fn func() error) bool {
+	step := RestoreStep{Action: action, Target: target}
+	if opts.DryRun {
+		step.Status = "planned"
+		result.Steps = append(result.Steps, step)
+		return true
+	}
+
+	op := m.operations.Start(operations.Operation{
+		UserID:    opts.UserID,
+		Action:    action,
+		Title:     fmt.Sprintf("Restore: %s %s", action, target),
+		ServiceID: serviceID,
+	})
+	step.OperationID = op.ID
+
+	err := fn()
+	m.operations.Finish(op.ID, "", err)
+
+	step.Status = "succeeded"
+	if err != nil {
+		step.Status = "failed"
+		step.Error = err.Error()
+	}
+	result.Steps = append(result.Steps, step)
+	return err == nil
+}
+
+// originFromConfig reads the origin stored in a service's config JSON
+func originFromConfig(config string) cdn.OriginConfig {
+	var data struct {
+		Origin cdn.OriginConfig `json:"origin"`
+	}
+	json.Unmarshal([]byte(config), &data)
+	if data.Origin.Protocol == "" {
+		data.Origin.Protocol = "https"
+	}
+	return data.Origin
+}
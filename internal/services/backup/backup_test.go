@@ -0,0 +1,125 @@
+package backup_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/avvvet/cdnbuddy-api/internal/domain"
+	"github.com/avvvet/cdnbuddy-api/internal/services/backup"
+	"github.com/avvvet/cdnbuddy-api/internal/services/cdn"
+	"github.com/avvvet/cdnbuddy-api/internal/services/operations"
+	"github.com/avvvet/cdnbuddy-api/internal/testutil"
+)
+
+func TestExportRestore(t *testing.T) {
+	tests := []struct {
+		name         string
+		opts         backup.RestoreOptions
+		existing     bool // the target already has a service of the same name
+		wantStatus   string
+		wantFailed   int
+		wantServices int // services on the target afterwards
+	}{
+		{
+			name:         "restores services with their domains",
+			wantStatus:   "succeeded",
+			wantServices: 1,
+		},
+		{
+			name:       "dry run changes nothing",
+			opts:       backup.RestoreOptions{DryRun: true},
+			wantStatus: "planned",
+		},
+		{
+			name:         "skips services that already exist",
+			opts:         backup.RestoreOptions{SkipExisting: true},
+			existing:     true,
+			wantStatus:   "skipped",
+			wantServices: 1,
+		},
+		{
+			name:       "unknown target provider fails the service",
+			opts:       backup.RestoreOptions{Provider: "nope"},
+			wantStatus: "failed",
+			wantFailed: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			source, sourceProvider := testutil.NewMockService(t)
+			testutil.SeedService(t, sourceProvider, "shop", "origin.shop.example.com", "shop.example.com", "static.shop.example.com")
+
+			targetProvider := cdn.NewMockProvider()
+			registry := cdn.NewProviderRegistry(domain.ProviderMock)
+			registry.Register(domain.ProviderMock, targetProvider)
+			target, err := cdn.NewServiceWithRegistry(registry, nil, nil)
+			if err != nil {
+				t.Fatalf("NewServiceWithRegistry() error = %v", err)
+			}
+			if tt.existing {
+				testutil.SeedService(t, targetProvider, "shop", "origin.shop.example.com")
+			}
+
+			manager := backup.NewManager(operations.NewStore(100))
+			exported, err := manager.Export(ctx, source)
+			if err != nil {
+				t.Fatalf("Export() error = %v", err)
+			}
+			if len(exported.Services) != 1 || len(exported.Services[0].Domains) != 2 {
+				t.Fatalf("exported %+v, want one service with two domains", exported.Services)
+			}
+
+			report, err := manager.Restore(ctx, target, exported, tt.opts)
+			if err != nil {
+				t.Fatalf("Restore() error = %v", err)
+			}
+			if report.Failed != tt.wantFailed {
+				t.Errorf("failed steps = %d, want %d", report.Failed, tt.wantFailed)
+			}
+			steps := report.Services[0].Steps
+			if steps[0].Action != "CREATE_SERVICE" || steps[0].Status != tt.wantStatus {
+				t.Errorf("first step = %+v, want CREATE_SERVICE %s", steps[0], tt.wantStatus)
+			}
+
+			services, err := targetProvider.ListServices(ctx)
+			if err != nil {
+				t.Fatalf("ListServices() error = %v", err)
+			}
+			if len(services) != tt.wantServices {
+				t.Fatalf("target has %d services, want %d", len(services), tt.wantServices)
+			}
+			if tt.wantStatus != "succeeded" {
+				return
+			}
+
+			restored := services[0]
+			if restored.ID != report.Services[0].NewServiceID {
+				t.Errorf("new service ID = %s, want %s", report.Services[0].NewServiceID, restored.ID)
+			}
+			domains, err := targetProvider.ListDomains(ctx, restored.ID)
+			if err != nil || len(domains) != 2 {
+				t.Errorf("restored domains = %v, %v, want two", domains, err)
+			}
+			state, err := targetProvider.GetServiceState(ctx, restored.ID)
+			if err != nil || state.Origin.Host != "origin.shop.example.com" {
+				t.Errorf("restored origin = %+v, %v", state, err)
+			}
+			for _, step := range steps {
+				if step.OperationID == "" {
+					t.Errorf("step %s wasn't recorded as an operation", step.Action)
+				}
+			}
+		})
+	}
+}
+
+func TestRestoreRejectsOtherVersions(t *testing.T) {
+	service, _ := testutil.NewMockService(t)
+	manager := backup.NewManager(operations.NewStore(100))
+
+	if _, err := manager.Restore(context.Background(), service, &backup.Backup{Version: backup.FormatVersion + 1}, backup.RestoreOptions{}); err == nil {
+		t.Error("Restore() accepted a backup of an unknown version")
+	}
+}
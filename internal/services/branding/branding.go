@@ -0,0 +1,151 @@
+// Package branding holds per-org white-label settings and applies them to
+// the text CDNBuddy generates for users: chat responses, notifications and
+// exported reports.
+package branding
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// DefaultProductName is used by orgs without their own branding
+const DefaultProductName = "CDNBuddy"
+
+const (
+	maxProductName = 64
+	maxSignature   = 280
+)
+
+// Settings are the branding of one org
+type Settings struct {
+	ProductName       string `json:"product_name"`
+	LogoURL           string `json:"logo_url,omitempty"`
+	ResponseSignature string `json:"response_signature,omitempty"` // appended to chat responses and notifications
+	CustomDomain      string `json:"custom_domain,omitempty"`      // host serving the org's API and status page
+}
+
+// Validate checks and normalizes settings; an empty product name selects the default
+func (s *Settings) Validate() error {
+	s.ProductName = strings.TrimSpace(s.ProductName)
+	if s.ProductName == "" {
+		s.ProductName = DefaultProductName
+	}
+	if len(s.ProductName) > maxProductName {
+		return fmt.Errorf("product_name must be at most %d characters", maxProductName)
+	}
+
+	if s.LogoURL != "" {
+		u, err := url.Parse(s.LogoURL)
+		if err != nil || u.Scheme != "https" || u.Host == "" {
+			return fmt.Errorf("logo_url must be an absolute https URL")
+		}
+	}
+
+	s.ResponseSignature = strings.TrimSpace(s.ResponseSignature)
+	if len(s.ResponseSignature) > maxSignature {
+		return fmt.Errorf("response_signature must be at most %d characters", maxSignature)
+	}
+
+	s.CustomDomain = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(s.CustomDomain)), ".")
+	if s.CustomDomain != "" {
+		if strings.ContainsAny(s.CustomDomain, "/:@ ") || !strings.Contains(s.CustomDomain, ".") || net.ParseIP(s.CustomDomain) != nil {
+			return fmt.Errorf("custom_domain must be a host name like api.example.com")
+		}
+	}
+	return nil
+}
+
+// Store keeps branding settings per org in memory
+type Store struct {
+	settings map[string]Settings // by org ID
+	domains  map[string]string   // custom domain -> org ID
+	mu       sync.RWMutex
+}
+
+// NewStore creates an empty branding store
+func NewStore() *Store {
+	return &Store{
+		settings: make(map[string]Settings),
+		domains:  make(map[string]string),
+	}
+}
+
+// Get returns an org's branding, or the default branding
+func (s *Store) Get(orgID string) Settings {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if settings, ok := s.settings[orgID]; ok {
+		return settings
+	}
+	return Settings{ProductName: DefaultProductName}
+}
+
+// Set validates and stores an org's branding. A custom domain can only belong to one org.
+func (s *Store) Set(orgID string, settings Settings) (Settings, error) {
+	if err := settings.Validate(); err != nil {
+		return Settings{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if owner, ok := s.domains[settings.CustomDomain]; ok && settings.CustomDomain != "" && owner != orgID {
+		return Settings{}, fmt.Errorf("custom_domain %s is already used by another org", settings.CustomDomain)
+	}
+	if previous := s.settings[orgID].CustomDomain; previous != "" {
+		delete(s.domains, previous)
+	}
+	if settings.CustomDomain != "" {
+		s.domains[settings.CustomDomain] = orgID
+	}
+	s.settings[orgID] = settings
+	return settings, nil
+}
+
+// OrgForHost returns the org whose custom domain a request host is
+func (s *Store) OrgForHost(host string) (string, bool) {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	orgID, ok := s.domains[strings.TrimSuffix(strings.ToLower(host), ".")]
+	return orgID, ok
+}
+
+// Rebrand replaces the default product name in generated text
+func (s *Store) Rebrand(orgID, text string) string {
+	name := s.Get(orgID).ProductName
+	if name == DefaultProductName {
+		return text
+	}
+	return strings.ReplaceAll(text, DefaultProductName, name)
+}
+
+// Apply rebrands a user-facing message and appends the org's signature
+func (s *Store) Apply(orgID, message string) string {
+	message = s.Rebrand(orgID, message)
+	if signature := s.Get(orgID).ResponseSignature; signature != "" && message != "" {
+		message += "\n\n" + signature
+	}
+	return message
+}
+
+// Middleware makes requests arriving on an org's custom domain act for that
+// org unless they name one with ?org_id=
+func (s *Store) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if orgID, ok := s.OrgForHost(r.Host); ok && r.URL.Query().Get("org_id") == "" {
+			query := r.URL.Query()
+			query.Set("org_id", orgID)
+			r.URL.RawQuery = query.Encode()
+		}
+		next.ServeHTTP(w, r)
+	})
+}
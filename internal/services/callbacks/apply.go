@@ -0,0 +1,65 @@
+package callbacks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/avvvet/cdnbuddy-api/internal/services/cdn"
+	"github.com/avvvet/cdnbuddy-api/internal/services/messaging"
+)
+
+// Apply records the domain status a notification reports and publishes the
+// matching events. svc must be scoped to the notification's provider.
+func Apply(ctx context.Context, svc *cdn.Service, publisher *messaging.Publisher, n *Notification) error {
+	service, err := svc.GetService(ctx, n.ServiceID)
+	if err != nil {
+		return fmt.Errorf("failed to find service %s: %w", n.ServiceID, err)
+	}
+
+	if n.Kind == KindPurgeCompleted {
+		if err := publisher.PublishCachePurgeCompleted(service.ID, n.Paths); err != nil {
+			return fmt.Errorf("failed to publish purge completion: %w", err)
+		}
+		return nil
+	}
+
+	if status, ok := n.DomainStatus(); ok {
+		domains, err := svc.ListServiceDomains(ctx, *service)
+		if err != nil {
+			return fmt.Errorf("failed to list domains of service %s: %w", service.ID, err)
+		}
+
+		found := false
+		for _, d := range domains {
+			if !strings.EqualFold(d.Name, n.Domain) {
+				continue
+			}
+			found = true
+
+			// Providers that don't keep the status with us already report the new one
+			err := svc.SetDomainStatus(ctx, service.ID, d.Name, status)
+			if err != nil && !errors.Is(err, cdn.ErrNotSupported) {
+				return fmt.Errorf("failed to update domain %s: %w", d.Name, err)
+			}
+
+			oldStatus := d.Status
+			d.Status = status
+			if err := publisher.PublishDomainStatusChanged(&d, oldStatus); err != nil {
+				return fmt.Errorf("failed to publish domain status change: %w", err)
+			}
+			break
+		}
+		if !found {
+			return fmt.Errorf("domain %s not found on service %s", n.Domain, service.ID)
+		}
+	}
+
+	if n.Kind == KindCertificateIssued || n.Kind == KindCertificateFailed {
+		if err := publisher.PublishCDNServiceUpdated(service); err != nil {
+			return fmt.Errorf("failed to publish service update: %w", err)
+		}
+	}
+	return nil
+}
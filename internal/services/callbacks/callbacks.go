@@ -0,0 +1,226 @@
+// Package callbacks receives notifications CDN providers send about work they
+// finish asynchronously (certificate issuance, domain validation, purges) and
+// translates them into our domain and cache events.
+package callbacks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/avvvet/cdnbuddy-api/internal/domain"
+	"github.com/avvvet/cdnbuddy-api/internal/services/cdn"
+)
+
+// Callbacks authenticate with an HMAC-SHA256 of the body keyed with the
+// provider's callback secret ("sha256=<hex>"), or, for providers that can only
+// send a fixed header, the secret itself
+const (
+	SignatureHeader = "X-Callback-Signature"
+	TokenHeader     = "X-Callback-Token"
+)
+
+var (
+	// ErrUnknownProvider is returned for providers without a callback secret
+	ErrUnknownProvider = errors.New("callbacks are not enabled for this provider")
+	// ErrUnauthorized is returned for callbacks without a valid signature or token
+	ErrUnauthorized = errors.New("callback signature or token is invalid")
+)
+
+// Kind is what a provider reports as done
+type Kind string
+
+const (
+	KindCertificateIssued      Kind = "certificate_issued"
+	KindCertificateFailed      Kind = "certificate_failed"
+	KindDomainValidated        Kind = "domain_validated"
+	KindDomainValidationFailed Kind = "domain_validation_failed"
+	KindPurgeCompleted         Kind = "purge_completed"
+)
+
+// Domain statuses set from callbacks
+const (
+	DomainActive            = "ACTIVE"
+	DomainValidationFailed  = "VALIDATION_FAILED"
+	DomainCertificateFailed = "CERTIFICATE_FAILED"
+)
+
+// eventNames maps each provider's callback event names to kinds; the mock
+// provider uses the kinds themselves
+var eventNames = map[domain.CDNProvider]map[string]Kind{
+	domain.ProviderCacheFly: {
+		"certificate.issued":           KindCertificateIssued,
+		"certificate.failed":           KindCertificateFailed,
+		"hostname.verified":            KindDomainValidated,
+		"hostname.verification_failed": KindDomainValidationFailed,
+		"purge.completed":              KindPurgeCompleted,
+	},
+	domain.ProviderKeyCDN: {
+		"ssl_issued":         KindCertificateIssued,
+		"ssl_failed":         KindCertificateFailed,
+		"zonealias_verified": KindDomainValidated,
+		"zonealias_failed":   KindDomainValidationFailed,
+		"purge_finished":     KindPurgeCompleted,
+	},
+	domain.ProviderCDN77: {
+		"SSL_ISSUED":     KindCertificateIssued,
+		"SSL_FAILED":     KindCertificateFailed,
+		"CNAME_VERIFIED": KindDomainValidated,
+		"CNAME_FAILED":   KindDomainValidationFailed,
+		"PURGE_FINISHED": KindPurgeCompleted,
+	},
+	domain.ProviderMock: {
+		string(KindCertificateIssued):      KindCertificateIssued,
+		string(KindCertificateFailed):      KindCertificateFailed,
+		string(KindDomainValidated):        KindDomainValidated,
+		string(KindDomainValidationFailed): KindDomainValidationFailed,
+		string(KindPurgeCompleted):         KindPurgeCompleted,
+	},
+}
+
+// Notification is a provider callback in our terms
+type Notification struct {
+	Provider   domain.CDNProvider `json:"provider"`
+	Event      string             `json:"event"` // the provider's own event name
+	Kind       Kind               `json:"kind,omitempty"`
+	ServiceID  string             `json:"service_id,omitempty"`
+	Domain     string             `json:"domain,omitempty"`
+	Paths      []string           `json:"paths,omitempty"`
+	Detail     string             `json:"detail,omitempty"`  // the provider's error message
+	Ignored    string             `json:"ignored,omitempty"` // why nothing is updated
+	ReceivedAt time.Time          `json:"received_at"`
+}
+
+// payload holds the fields providers name differently
+type payload struct {
+	Event      string   `json:"event"`
+	Type       string   `json:"type"`
+	ServiceID  string   `json:"service_id"`
+	ZoneID     string   `json:"zone_id"`
+	ResourceID string   `json:"resource_id"`
+	Domain     string   `json:"domain"`
+	Hostname   string   `json:"hostname"`
+	CNAME      string   `json:"cname"`
+	Paths      []string `json:"paths"`
+	URLs       []string `json:"urls"`
+	Error      string   `json:"error"`
+	Message    string   `json:"message"`
+}
+
+// Receiver authenticates and parses provider callbacks
+type Receiver struct {
+	secrets map[domain.CDNProvider]string
+}
+
+// NewReceiver creates a receiver; providers without a secret can't send callbacks
+func NewReceiver(secrets map[domain.CDNProvider]string) *Receiver {
+	return &Receiver{secrets: secrets}
+}
+
+// ParseSecrets parses "cachefly=secret,keycdn=secret"
+func ParseSecrets(spec string) (map[domain.CDNProvider]string, error) {
+	secrets := make(map[domain.CDNProvider]string)
+	for _, pair := range strings.Split(spec, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		name, secret, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(secret) == "" {
+			return nil, fmt.Errorf("invalid callback secret %q (expected provider=secret)", pair)
+		}
+		provider := cdn.ParseProvider(name)
+		if _, known := eventNames[provider]; !known {
+			return nil, fmt.Errorf("provider %s doesn't send callbacks", name)
+		}
+		secrets[provider] = strings.TrimSpace(secret)
+	}
+	return secrets, nil
+}
+
+// Receive authenticates a callback from a provider and parses it
+func (r *Receiver) Receive(provider domain.CDNProvider, header http.Header, body []byte) (*Notification, error) {
+	secret, ok := r.secrets[provider]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+	if !authenticated(secret, header, body) {
+		return nil, ErrUnauthorized
+	}
+
+	var p payload
+	if err := json.Unmarshal(body, &p); err != nil {
+		return nil, fmt.Errorf("invalid callback body: %w", err)
+	}
+
+	n := &Notification{
+		Provider:   provider,
+		Event:      first(p.Event, p.Type),
+		ServiceID:  first(p.ServiceID, p.ZoneID, p.ResourceID),
+		Domain:     strings.ToLower(first(p.Domain, p.Hostname, p.CNAME)),
+		Paths:      p.Paths,
+		Detail:     first(p.Error, p.Message),
+		ReceivedAt: time.Now(),
+	}
+	if n.Paths == nil {
+		n.Paths = p.URLs
+	}
+
+	kind, ok := eventNames[provider][n.Event]
+	if !ok {
+		n.Ignored = fmt.Sprintf("event %q isn't one we act on", n.Event)
+		return n, nil
+	}
+	n.Kind = kind
+
+	switch {
+	case n.ServiceID == "":
+		return nil, fmt.Errorf("callback has no service ID")
+	case n.Domain == "" && (kind == KindDomainValidated || kind == KindDomainValidationFailed):
+		return nil, fmt.Errorf("domain callback has no domain")
+	}
+	return n, nil
+}
+
+// DomainStatus returns the status a notification sets on its domain, if any
+func (n *Notification) DomainStatus() (string, bool) {
+	if n.Domain == "" {
+		return "", false
+	}
+	switch n.Kind {
+	case KindDomainValidated, KindCertificateIssued:
+		return DomainActive, true
+	case KindDomainValidationFailed:
+		return DomainValidationFailed, true
+	case KindCertificateFailed:
+		return DomainCertificateFailed, true
+	}
+	return "", false
+}
+
+// authenticated checks the signature header, or the token header when unsigned
+func authenticated(secret string, header http.Header, body []byte) bool {
+	if signature, ok := strings.CutPrefix(header.Get(SignatureHeader), "sha256="); ok {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		expected := hex.EncodeToString(mac.Sum(nil))
+		return hmac.Equal([]byte(strings.ToLower(signature)), []byte(expected))
+	}
+	token := header.Get(TokenHeader)
+	return token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(secret)) == 1
+}
+
+func first(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,154 @@
+package callbacks_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/avvvet/cdnbuddy-api/internal/domain"
+	"github.com/avvvet/cdnbuddy-api/internal/services/callbacks"
+	"github.com/avvvet/cdnbuddy-api/internal/services/messaging"
+	"github.com/avvvet/cdnbuddy-api/internal/testutil"
+)
+
+const secret = "callback-secret"
+
+func sign(body string) http.Header {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return http.Header{callbacks.SignatureHeader: {"sha256=" + hex.EncodeToString(mac.Sum(nil))}}
+}
+
+func TestReceive(t *testing.T) {
+	receiver := callbacks.NewReceiver(map[domain.CDNProvider]string{
+		domain.ProviderCacheFly: secret,
+		domain.ProviderKeyCDN:   secret,
+	})
+
+	tests := []struct {
+		name        string
+		provider    domain.CDNProvider
+		header      func(body string) http.Header
+		body        string
+		wantKind    callbacks.Kind
+		wantDomain  string
+		wantIgnored bool
+		wantErr     error
+		wantErrText string
+	}{
+		{
+			name:       "signed cachefly validation",
+			provider:   domain.ProviderCacheFly,
+			header:     sign,
+			body:       `{"event": "hostname.verified", "service_id": "svc-1", "hostname": "CDN.Example.com"}`,
+			wantKind:   callbacks.KindDomainValidated,
+			wantDomain: "cdn.example.com",
+		},
+		{
+			name:     "keycdn purge with token",
+			provider: domain.ProviderKeyCDN,
+			header:   func(string) http.Header { return http.Header{callbacks.TokenHeader: {secret}} },
+			body:     `{"type": "purge_finished", "zone_id": "svc-1", "urls": ["/a.css"]}`,
+			wantKind: callbacks.KindPurgeCompleted,
+		},
+		{
+			name:        "event we don't act on",
+			provider:    domain.ProviderCacheFly,
+			header:      sign,
+			body:        `{"event": "invoice.paid", "service_id": "svc-1"}`,
+			wantIgnored: true,
+		},
+		{
+			name:     "provider without a secret",
+			provider: domain.ProviderCDN77,
+			header:   sign,
+			body:     `{}`,
+			wantErr:  callbacks.ErrUnknownProvider,
+		},
+		{
+			name:     "bad signature",
+			provider: domain.ProviderCacheFly,
+			header:   func(string) http.Header { return sign("something else") },
+			body:     `{"event": "purge.completed", "service_id": "svc-1"}`,
+			wantErr:  callbacks.ErrUnauthorized,
+		},
+		{
+			name:     "no credentials",
+			provider: domain.ProviderCacheFly,
+			header:   func(string) http.Header { return http.Header{} },
+			body:     `{"event": "purge.completed", "service_id": "svc-1"}`,
+			wantErr:  callbacks.ErrUnauthorized,
+		},
+		{
+			name:        "validation without a domain",
+			provider:    domain.ProviderCacheFly,
+			header:      sign,
+			body:        `{"event": "hostname.verified", "service_id": "svc-1"}`,
+			wantErrText: "no domain",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := receiver.Receive(tt.provider, tt.header(tt.body), []byte(tt.body))
+			if tt.wantErr != nil || tt.wantErrText != "" {
+				if err == nil || (tt.wantErr != nil && !errors.Is(err, tt.wantErr)) || !strings.Contains(err.Error(), tt.wantErrText) {
+					t.Fatalf("Receive() error = %v, want %v %q", err, tt.wantErr, tt.wantErrText)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Receive() unexpected error: %v", err)
+			}
+			if (n.Ignored != "") != tt.wantIgnored {
+				t.Fatalf("Receive() ignored = %q, want ignored %v", n.Ignored, tt.wantIgnored)
+			}
+			if n.Kind != tt.wantKind || n.Domain != tt.wantDomain {
+				t.Errorf("Receive() = %s for %q, want %s for %q", n.Kind, n.Domain, tt.wantKind, tt.wantDomain)
+			}
+		})
+	}
+}
+
+func TestApplyUpdatesDomainStatus(t *testing.T) {
+	service, provider := testutil.NewMockService(t)
+	svc := testutil.SeedService(t, provider, "shop.example.com", "origin.shop.example.com", "cdn.shop.example.com")
+
+	bus := testutil.StartNATS(t)
+	subscriber := messaging.NewSubscriber(bus)
+	got := make(chan messaging.DomainEvent, 1)
+	if err := subscriber.RegisterDomainHandler(func(e messaging.DomainEvent) error {
+		got <- e
+		return nil
+	}); err != nil {
+		t.Fatalf("RegisterDomainHandler() unexpected error: %v", err)
+	}
+
+	n := &callbacks.Notification{Provider: domain.ProviderMock, Kind: callbacks.KindDomainValidationFailed, ServiceID: svc.ID, Domain: "cdn.shop.example.com"}
+	if err := callbacks.Apply(context.Background(), service, messaging.NewPublisher(bus), n); err != nil {
+		t.Fatalf("Apply() unexpected error: %v", err)
+	}
+
+	event := testutil.Await(t, got)
+	if event.Status != callbacks.DomainValidationFailed || event.OldStatus != "ACTIVE" {
+		t.Errorf("domain event status = %s (was %s), want %s (was ACTIVE)", event.Status, event.OldStatus, callbacks.DomainValidationFailed)
+	}
+
+	domains, err := service.ListServiceDomains(context.Background(), svc)
+	if err != nil {
+		t.Fatalf("ListServiceDomains() unexpected error: %v", err)
+	}
+	if len(domains) != 1 || domains[0].Status != callbacks.DomainValidationFailed {
+		t.Errorf("domains after callback = %+v, want one with status %s", domains, callbacks.DomainValidationFailed)
+	}
+
+	n.Domain = "other.example.com"
+	if err := callbacks.Apply(context.Background(), service, messaging.NewPublisher(bus), n); err == nil {
+		t.Error("Apply() for an unknown domain succeeded, want an error")
+	}
+}
@@ -0,0 +1,182 @@
+package cdn
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/avvvet/cdnbuddy-api/internal/domain"
+)
+
+// AccountInfo is what a provider reports about the account itself. Fields a
+// provider doesn't expose are left empty.
+type AccountInfo struct {
+	Name      string            `json:"name,omitempty"`
+	Status    string            `json:"status,omitempty"`
+	Plan      string            `json:"plan,omitempty"`
+	Quotas    []AccountQuota    `json:"quotas,omitempty"`
+	Incidents []AccountIncident `json:"incidents,omitempty"`
+}
+
+// AccountQuota is the usage of one plan limit
+type AccountQuota struct {
+	Name  string  `json:"name"`
+	Used  float64 `json:"used"`
+	Limit float64 `json:"limit,omitempty"` // 0 = unlimited or not reported
+	Unit  string  `json:"unit,omitempty"`
+}
+
+// AccountIncident is a provider-side incident affecting the account
+type AccountIncident struct {
+	Title     string    `json:"title"`
+	Status    string    `json:"status"`
+	StartedAt time.Time `json:"started_at"`
+	URL       string    `json:"url,omitempty"`
+}
+
+// AccountInspector is implemented by providers that can report account details
+type AccountInspector interface {
+	GetAccountInfo(ctx context.Context) (*AccountInfo, error)
+}
+
+// AccountSummary is the status of one connected provider account
+type AccountSummary struct {
+	Provider         domain.CDNProvider `json:"provider"`
+	ActiveServices   int                `json:"active_services"`
+	InactiveServices int                `json:"inactive_services"`
+	APIHealth        string             `json:"api_health"` // circuit breaker state of our calls to the provider
+	Account          *AccountInfo       `json:"account,omitempty"`
+	Warnings         []string           `json:"warnings,omitempty"`
+}
+
+// GetAccountSummaries summarizes every connected provider account. Account
+// details are best effort; only a failure to list services is fatal.
+func (s *Service) GetAccountSummaries(ctx context.Context) ([]AccountSummary, error) {
+	if s.registry == nil {
+		summary, err := s.summarizeAccount(ctx, "", s.provider)
+		if err != nil {
+			return nil, err
+		}
+		return []AccountSummary{*summary}, nil
+	}
+
+	summaries := make([]AccountSummary, 0)
+	for _, name := range s.registry.Names() {
+		provider, err := s.registry.Get(name)
+		if err != nil {
+			return nil, err
+		}
+		summary, err := s.summarizeAccount(ctx, name, provider)
+		if err != nil {
+			return nil, fmt.Errorf("failed to summarize %s account: %w", name, err)
+		}
+		summaries = append(summaries, *summary)
+	}
+	return summaries, nil
+}
+
+func (s *Service) summarizeAccount(ctx context.Context, name domain.CDNProvider, provider CDNProvider) (*AccountSummary, error) {
+	services, err := provider.ListServicesByStatus(ctx, StatusAll)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services: %w", err)
+	}
+
+	summary := &AccountSummary{Provider: name, APIHealth: BreakerClosed}
+	for _, svc := range services {
+		if summary.Provider == "" {
+			summary.Provider = svc.Provider
+		}
+		if StatusActive.Matches(svc.Status) {
+			summary.ActiveServices++
+		} else {
+			summary.InactiveServices++
+		}
+	}
+	if state, ok := s.BreakerStates()[string(summary.Provider)]; ok {
+		summary.APIHealth = state.State
+	}
+
+	if !supports[AccountInspector](provider) {
+		summary.Warnings = append(summary.Warnings, "plan, quotas and incidents are not exposed by this provider's API")
+		return summary, nil
+	}
+	info, err := provider.(AccountInspector).GetAccountInfo(ctx)
+	if err != nil {
+		summary.Warnings = append(summary.Warnings, fmt.Sprintf("account details: %v", err))
+		return summary, nil
+	}
+	summary.Account = info
+	return summary, nil
+}
+
+// handleAccountStatus answers "what's my CacheFly account status?" from chat
+func (s *Service) handleAccountStatus(ctx context.Context) (string, error) {
+	summaries, err := s.GetAccountSummaries(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for i, summary := range summaries {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "🏢 %s account\n", providerTitle(summary.Provider))
+		if info := summary.Account; info != nil {
+			if info.Name != "" {
+				fmt.Fprintf(&b, "   Account: %s\n", info.Name)
+			}
+			if info.Status != "" {
+				fmt.Fprintf(&b, "   Status: %s\n", info.Status)
+			}
+			if info.Plan != "" {
+				fmt.Fprintf(&b, "   Plan: %s\n", info.Plan)
+			}
+		}
+		fmt.Fprintf(&b, "   Services: %d active, %d deactivated\n", summary.ActiveServices, summary.InactiveServices)
+		fmt.Fprintf(&b, "   API connection: %s\n", summary.APIHealth)
+
+		if info := summary.Account; info != nil {
+			for _, q := range info.Quotas {
+				if q.Limit > 0 {
+					fmt.Fprintf(&b, "   📊 %s: %s of %s %s (%.0f%%)\n", q.Name, formatAmount(q.Used), formatAmount(q.Limit), q.Unit, 100*q.Used/q.Limit)
+				} else {
+					fmt.Fprintf(&b, "   📊 %s: %s %s\n", q.Name, formatAmount(q.Used), q.Unit)
+				}
+			}
+			if len(info.Incidents) == 0 {
+				b.WriteString("   ✅ No open provider incidents\n")
+			}
+			for _, incident := range info.Incidents {
+				fmt.Fprintf(&b, "   ⚠️ %s (%s since %s)\n", incident.Title, incident.Status, incident.StartedAt.UTC().Format("Jan 2 15:04 MST"))
+			}
+		}
+		for _, w := range summary.Warnings {
+			fmt.Fprintf(&b, "   ℹ️ %s\n", w)
+		}
+	}
+	return b.String(), nil
+}
+
+func providerTitle(name domain.CDNProvider) string {
+	switch name {
+	case domain.ProviderCacheFly:
+		return "CacheFly"
+	case domain.ProviderKeyCDN:
+		return "KeyCDN"
+	case domain.ProviderCDN77:
+		return "CDN77"
+	case "":
+		return "Provider"
+	}
+	return strings.ToUpper(string(name[:1])) + string(name[1:])
+}
+
+// formatAmount prints whole numbers without decimals
+func formatAmount(v float64) string {
+	if v == float64(int64(v)) {
+		return fmt.Sprintf("%d", int64(v))
+	}
+	return fmt.Sprintf("%.2f", v)
+}
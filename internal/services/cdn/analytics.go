@@ -0,0 +1,101 @@
+package cdn
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Analytics metrics
+const (
+	MetricCacheHitRatio = "cache_hit_ratio"
+	MetricBandwidth     = "bandwidth" // bytes served per bucket
+	MetricRequests      = "requests"
+)
+
+// AnalyticsMetrics are the metrics a time series can be requested for
+var AnalyticsMetrics = []string{MetricCacheHitRatio, MetricBandwidth, MetricRequests}
+
+const (
+	minAnalyticsInterval = 5 * time.Minute
+	maxAnalyticsBuckets  = 500
+	maxAnalyticsRange    = 90 * 24 * time.Hour
+)
+
+// AnalyticsBucket is the traffic of a service in one time bucket
+type AnalyticsBucket struct {
+	Start       time.Time `json:"start"`
+	Requests    int64     `json:"requests"`
+	CacheHits   int64     `json:"cache_hits"`
+	CacheMisses int64     `json:"cache_misses"`
+	Bytes       int64     `json:"bytes"`
+}
+
+// SeriesPoint is one value of a metric's time series
+type SeriesPoint struct {
+	Time  time.Time `json:"time"`
+	Value float64   `json:"value"`
+}
+
+// AnalyticsSource is implemented by providers that report traffic over time.
+// Buckets start at start, which is aligned to interval, and cover [start, end).
+type AnalyticsSource interface {
+	GetAnalytics(ctx context.Context, serviceID string, start, end time.Time, interval time.Duration) ([]AnalyticsBucket, error)
+}
+
+// AnalyticsInterval picks the bucket size for a range: the smallest of 5m,
+// 1h and 1d that keeps the series within the bucket limit
+func AnalyticsInterval(start, end time.Time) time.Duration {
+	for _, interval := range []time.Duration{minAnalyticsInterval, time.Hour} {
+		if end.Sub(start)/interval <= 288 {
+			return interval
+		}
+	}
+	return 24 * time.Hour
+}
+
+// GetAnalytics returns a service's traffic between start and end in buckets of
+// interval; start is aligned down to the interval
+func (s *Service) GetAnalytics(ctx context.Context, serviceID string, start, end time.Time, interval time.Duration) ([]AnalyticsBucket, error) {
+	source, ok := s.provider.(AnalyticsSource)
+	if !ok {
+		return nil, fmt.Errorf("analytics: %w", ErrNotSupported)
+	}
+
+	start, end = start.UTC().Truncate(interval), end.UTC()
+	switch {
+	case !end.After(start):
+		return nil, fmt.Errorf("end must be after start")
+	case end.Sub(start) > maxAnalyticsRange:
+		return nil, fmt.Errorf("the range can span at most %d days", int(maxAnalyticsRange/(24*time.Hour)))
+	case interval < minAnalyticsInterval:
+		return nil, fmt.Errorf("interval must be at least %s", minAnalyticsInterval)
+	case end.Sub(start)/interval > maxAnalyticsBuckets:
+		return nil, fmt.Errorf("the range and interval give more than %d buckets; use a larger interval", maxAnalyticsBuckets)
+	}
+
+	return source.GetAnalytics(ctx, serviceID, start, end, interval)
+}
+
+// AnalyticsSeries turns buckets into one series per metric. Buckets without
+// cache hits or misses have no hit ratio point.
+func AnalyticsSeries(buckets []AnalyticsBucket, metrics []string) map[string][]SeriesPoint {
+	series := make(map[string][]SeriesPoint, len(metrics))
+	for _, metric := range metrics {
+		points := make([]SeriesPoint, 0, len(buckets))
+		for _, b := range buckets {
+			switch metric {
+			case MetricRequests:
+				points = append(points, SeriesPoint{Time: b.Start, Value: float64(b.Requests)})
+			case MetricBandwidth:
+				points = append(points, SeriesPoint{Time: b.Start, Value: float64(b.Bytes)})
+			case MetricCacheHitRatio:
+				if total := b.CacheHits + b.CacheMisses; total > 0 {
+					points = append(points, SeriesPoint{Time: b.Start, Value: float64(b.CacheHits) / float64(total)})
+				}
+			}
+		}
+		series[metric] = points
+	}
+	return series
+}
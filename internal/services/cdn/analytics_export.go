@@ -0,0 +1,110 @@
+package cdn
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// AnalyticsExport is a service's traffic over a range, one row per bucket,
+// for reports fed into other tools
+type AnalyticsExport struct {
+	ServiceID string         `json:"service_id"`
+	Start     time.Time      `json:"start"`
+	End       time.Time      `json:"end"`
+	Interval  string         `json:"interval"`
+	Metrics   []string       `json:"metrics"`
+	Rows      []AnalyticsRow `json:"rows"`
+}
+
+// AnalyticsRow is one bucket of an export; metrics not exported are omitted
+type AnalyticsRow struct {
+	Start         time.Time `json:"start"`
+	Requests      *int64    `json:"requests,omitempty"`
+	CacheHits     *int64    `json:"cache_hits,omitempty"`
+	CacheMisses   *int64    `json:"cache_misses,omitempty"`
+	CacheHitRatio *float64  `json:"cache_hit_ratio,omitempty"` // absent for buckets without cacheable traffic
+	Bytes         *int64    `json:"bytes,omitempty"`
+}
+
+// NewAnalyticsExport builds an export of buckets with the given metrics
+func NewAnalyticsExport(serviceID string, start, end time.Time, interval time.Duration, metrics []string, buckets []AnalyticsBucket) *AnalyticsExport {
+	export := &AnalyticsExport{
+		ServiceID: serviceID,
+		Start:     start.UTC(),
+		End:       end.UTC(),
+		Interval:  interval.String(),
+		Metrics:   metrics,
+		Rows:      make([]AnalyticsRow, 0, len(buckets)),
+	}
+	for _, b := range buckets {
+		row := AnalyticsRow{Start: b.Start.UTC()}
+		for _, metric := range metrics {
+			switch metric {
+			case MetricRequests:
+				row.Requests = &b.Requests
+			case MetricBandwidth:
+				row.Bytes = &b.Bytes
+			case MetricCacheHitRatio:
+				row.CacheHits, row.CacheMisses = &b.CacheHits, &b.CacheMisses
+				if total := b.CacheHits + b.CacheMisses; total > 0 {
+					ratio := float64(b.CacheHits) / float64(total)
+					row.CacheHitRatio = &ratio
+				}
+			}
+		}
+		export.Rows = append(export.Rows, row)
+	}
+	return export
+}
+
+// WriteCSV writes the export as CSV with a header row: the bucket start in
+// RFC 3339, then the columns of each exported metric
+func (e *AnalyticsExport) WriteCSV(w io.Writer) error {
+	header := []string{"start"}
+	for _, metric := range e.Metrics {
+		switch metric {
+		case MetricRequests:
+			header = append(header, "requests")
+		case MetricBandwidth:
+			header = append(header, "bytes")
+		case MetricCacheHitRatio:
+			header = append(header, "cache_hits", "cache_misses", "cache_hit_ratio")
+		}
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Write(header)
+	for _, row := range e.Rows {
+		record := []string{row.Start.Format(time.RFC3339)}
+		for _, metric := range e.Metrics {
+			switch metric {
+			case MetricRequests:
+				record = append(record, formatCount(row.Requests))
+			case MetricBandwidth:
+				record = append(record, formatCount(row.Bytes))
+			case MetricCacheHitRatio:
+				ratio := ""
+				if row.CacheHitRatio != nil {
+					ratio = strconv.FormatFloat(*row.CacheHitRatio, 'f', 4, 64)
+				}
+				record = append(record, formatCount(row.CacheHits), formatCount(row.CacheMisses), ratio)
+			}
+		}
+		cw.Write(record)
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return nil
+}
+
+func formatCount(n *int64) string {
+	if n == nil {
+		return ""
+	}
+	return strconv.FormatInt(*n, 10)
+}
@@ -0,0 +1,113 @@
+package cdn_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/avvvet/cdnbuddy-api/internal/services/cdn"
+	"github.com/avvvet/cdnbuddy-api/internal/testutil"
+)
+
+func TestGetAnalytics(t *testing.T) {
+	service, provider := testutil.NewMockService(t)
+	svc := testutil.SeedService(t, provider, "shop.example.com", "origin.shop.example.com")
+	start := testutil.Epoch.Add(7 * time.Minute)
+
+	tests := []struct {
+		name        string
+		start, end  time.Time
+		interval    time.Duration
+		wantBuckets int
+		wantFirst   time.Time
+		wantErr     string
+	}{
+		{name: "hourly day", start: start, end: start.Add(24 * time.Hour), interval: time.Hour, wantBuckets: 25, wantFirst: testutil.Epoch},
+		{name: "five minute buckets", start: testutil.Epoch, end: testutil.Epoch.Add(time.Hour), interval: 5 * time.Minute, wantBuckets: 12, wantFirst: testutil.Epoch},
+		{name: "end before start", start: start, end: start.Add(-time.Hour), interval: time.Hour, wantErr: "end must be after start"},
+		{name: "interval too small", start: start, end: start.Add(time.Hour), interval: time.Minute, wantErr: "at least 5m"},
+		{name: "too many buckets", start: start, end: start.Add(30 * 24 * time.Hour), interval: 5 * time.Minute, wantErr: "more than 500 buckets"},
+		{name: "range too long", start: start, end: start.Add(100 * 24 * time.Hour), interval: 24 * time.Hour, wantErr: "at most 90 days"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buckets, err := service.GetAnalytics(context.Background(), svc.ID, tt.start, tt.end, tt.interval)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("GetAnalytics() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetAnalytics() unexpected error: %v", err)
+			}
+			if len(buckets) != tt.wantBuckets {
+				t.Fatalf("GetAnalytics() returned %d buckets, want %d", len(buckets), tt.wantBuckets)
+			}
+			if !buckets[0].Start.Equal(tt.wantFirst) {
+				t.Errorf("first bucket starts at %s, want %s", buckets[0].Start, tt.wantFirst)
+			}
+		})
+	}
+}
+
+func TestAnalyticsSeries(t *testing.T) {
+	buckets := []cdn.AnalyticsBucket{
+		{Start: testutil.Epoch, Requests: 100, CacheHits: 75, CacheMisses: 25, Bytes: 4096},
+		{Start: testutil.Epoch.Add(time.Hour)},
+	}
+
+	series := cdn.AnalyticsSeries(buckets, []string{cdn.MetricCacheHitRatio, cdn.MetricRequests})
+	if len(series) != 2 {
+		t.Fatalf("AnalyticsSeries() returned %d series, want 2", len(series))
+	}
+	if got := series[cdn.MetricCacheHitRatio]; len(got) != 1 || got[0].Value != 0.75 {
+		t.Errorf("hit ratio series = %v, want one point of 0.75", got)
+	}
+	if got := series[cdn.MetricRequests]; len(got) != 2 || got[0].Value != 100 || got[1].Value != 0 {
+		t.Errorf("requests series = %v, want 100 and 0", got)
+	}
+}
+
+func TestAnalyticsExportCSV(t *testing.T) {
+	buckets := []cdn.AnalyticsBucket{
+		{Start: testutil.Epoch, Requests: 100, CacheHits: 75, CacheMisses: 25, Bytes: 4096},
+		{Start: testutil.Epoch.Add(time.Hour)},
+	}
+
+	tests := []struct {
+		name    string
+		metrics []string
+		want    string
+	}{
+		{
+			name:    "all metrics",
+			metrics: cdn.AnalyticsMetrics,
+			want: "start,cache_hits,cache_misses,cache_hit_ratio,bytes,requests\n" +
+				testutil.Epoch.Format(time.RFC3339) + ",75,25,0.7500,4096,100\n" +
+				testutil.Epoch.Add(time.Hour).Format(time.RFC3339) + ",0,0,,0,0\n",
+		},
+		{
+			name:    "requests only",
+			metrics: []string{cdn.MetricRequests},
+			want: "start,requests\n" +
+				testutil.Epoch.Format(time.RFC3339) + ",100\n" +
+				testutil.Epoch.Add(time.Hour).Format(time.RFC3339) + ",0\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			export := cdn.NewAnalyticsExport("svc-1", testutil.Epoch, testutil.Epoch.Add(2*time.Hour), time.Hour, tt.metrics, buckets)
+			var b strings.Builder
+			if err := export.WriteCSV(&b); err != nil {
+				t.Fatalf("WriteCSV() error = %v", err)
+			}
+			if b.String() != tt.want {
+				t.Errorf("WriteCSV() =\n%s\nwant\n%s", b.String(), tt.want)
+			}
+		})
+	}
+}
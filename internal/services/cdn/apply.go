@@ -0,0 +1,377 @@
+package cdn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/avvvet/cdnbuddy-api/internal/domain"
+)
+
+// ServiceSpec is the full desired state of a service. Apply reconciles the
+// provider against it, so applying the same spec twice changes nothing.
+type ServiceSpec struct {
+	ServiceID string       `json:"service_id,omitempty"` // matched by name when empty
+	Name      string       `json:"name"`
+	Origin    OriginConfig `json:"origin"`
+	Domains   []string     `json:"domains"`
+	Rules     []CacheRule  `json:"rules"` // nil leaves the current rules alone
+	SSL       SSLConfig    `json:"ssl"`
+}
+
+// ServiceState is the current configuration of a service as its provider reports it
+type ServiceState struct {
+	Origin OriginConfig `json:"origin"`
+	Rules  []CacheRule  `json:"rules"`
+	SSL    *SSLConfig   `json:"ssl,omitempty"` // nil when the provider doesn't report it
+}
+
+// ServiceInspector is implemented by providers that can report a service's
+// origin, cache rules and SSL settings
+type ServiceInspector interface {
+	GetServiceState(ctx context.Context, serviceID string) (*ServiceState, error)
+}
+
+// SSLConfigurer is implemented by providers whose SSL settings can be changed after creation
+type SSLConfigurer interface {
+	UpdateSSL(ctx context.Context, serviceID string, ssl SSLConfig) error
+}
+
+// Actions reported for each resource of an applied spec
+const (
+	ApplyCreated    = "created"
+	ApplyUpdated    = "updated"
+	ApplyUnchanged  = "unchanged"
+	ApplyExtraneous = "extraneous" // on the provider but not in the spec; left in place
+	ApplySkipped    = "skipped"    // differs but the provider can't change it
+)
+
+// ApplyChange is what Apply did (or would do) to one resource
+type ApplyChange struct {
+	Resource string `json:"resource"` // service, origin, cache_rules, ssl or domain:<name>
+	Action   string `json:"action"`
+	Detail   string `json:"detail,omitempty"`
+}
+
+// ApplyResult describes how a spec was reconciled
+type ApplyResult struct {
+	ServiceID string        `json:"service_id,omitempty"`
+	Provider  string        `json:"provider"`
+	Created   bool          `json:"created"`
+	DryRun    bool          `json:"dry_run"`
+	Changes   []ApplyChange `json:"changes"`
+}
+
+// Changed reports whether anything was created or updated
+func (r *ApplyResult) Changed() bool {
+	for _, c := range r.Changes {
+		if c.Action == ApplyCreated || c.Action == ApplyUpdated {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply reconciles a service with spec: a missing service or domain is
+// created, a differing origin, rule set or SSL setting is updated and domains
+// not in the spec are reported but never removed
+func (s *Service) Apply(ctx context.Context, spec ServiceSpec) (*ApplyResult, error) {
+	return s.apply(ctx, spec, false)
+}
+
+// PlanApply returns the changes Apply would make without making them
+func (s *Service) PlanApply(ctx context.Context, spec ServiceSpec) (*ApplyResult, error) {
+	return s.apply(ctx, spec, true)
+}
+
+func (s *Service) apply(ctx context.Context, spec ServiceSpec, dryRun bool) (*ApplyResult, error) {
+	if err := spec.validate(); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.findSpecService(ctx, spec)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return s.applyCreate(ctx, spec, dryRun)
+	}
+
+	provider := s.providerOf(*existing)
+	result := &ApplyResult{
+		ServiceID: existing.ID,
+		Provider:  string(existing.Provider),
+		DryRun:    dryRun,
+		Changes:   []ApplyChange{{Resource: "service", Action: ApplyUnchanged, Detail: existing.Name}},
+	}
+	record := func(resource, action, detail string, write func() error) error {
+		result.Changes = append(result.Changes, ApplyChange{Resource: resource, Action: action, Detail: detail})
+		if dryRun || write == nil {
+			return nil
+		}
+		if err := s.guardChange(ctx, existing.ID, resource); err != nil {
+			return err
+		}
+		if err := write(); err != nil {
+			return fmt.Errorf("failed to apply %s: %w", resource, err)
+		}
+		return nil
+	}
+
+	// Without an inspector the current settings are unknown; writing the
+	// desired ones unconditionally is still safe to repeat
+	var state *ServiceState
+	if inspector, ok := provider.(ServiceInspector); ok && supports[ServiceInspector](provider) {
+		if state, err = inspector.GetServiceState(ctx, existing.ID); err != nil {
+			return nil, fmt.Errorf("failed to read service state: %w", err)
+		}
+	}
+
+	switch {
+	case state != nil && sameOrigin(state.Origin, spec.Origin):
+		err = record("origin", ApplyUnchanged, spec.Origin.Host, nil)
+	default:
+		err = record("origin", ApplyUpdated, describeChange(state != nil, originString(stateOrigin(state)), originString(spec.Origin)),
+			func() error { return provider.UpdateOriginSettings(ctx, existing.ID, spec.Origin) })
+	}
+	if err != nil {
+		return result, err
+	}
+
+	if spec.Rules != nil {
+		switch {
+		case state != nil && sameRules(state.Rules, spec.Rules):
+			err = record("cache_rules", ApplyUnchanged, fmt.Sprintf("%d rules", len(spec.Rules)), nil)
+		default:
+			detail := fmt.Sprintf("%d rules", len(spec.Rules))
+			if state != nil {
+				detail = fmt.Sprintf("%d rules -> %d rules", len(state.Rules), len(spec.Rules))
+			}
+			err = record("cache_rules", ApplyUpdated, detail,
+				func() error { return provider.UpdateCacheRules(ctx, existing.ID, spec.Rules) })
+		}
+		if err != nil {
+			return result, err
+		}
+	}
+
+	configurer, canSSL := provider.(SSLConfigurer)
+	canSSL = canSSL && supports[SSLConfigurer](provider)
+	switch {
+	case state != nil && state.SSL != nil && sameSSL(*state.SSL, spec.SSL):
+		err = record("ssl", ApplyUnchanged, sslString(spec.SSL), nil)
+	case canSSL:
+		err = record("ssl", ApplyUpdated, sslString(spec.SSL),
+			func() error { return configurer.UpdateSSL(ctx, existing.ID, spec.SSL) })
+	default:
+		err = record("ssl", ApplySkipped, "provider can't change SSL settings of an existing service", nil)
+	}
+	if err != nil {
+		return result, err
+	}
+
+	current, err := provider.ListDomains(ctx, existing.ID)
+	if err != nil {
+		return result, fmt.Errorf("failed to list domains: %w", err)
+	}
+	have := make(map[string]bool, len(current))
+	for _, d := range current {
+		have[normalizeDomain(d.Name)] = true
+	}
+	want := make(map[string]bool, len(spec.Domains))
+	for _, name := range spec.Domains {
+		name = normalizeDomain(name)
+		want[name] = true
+		if have[name] {
+			err = record("domain:"+name, ApplyUnchanged, "", nil)
+		} else {
+			err = record("domain:"+name, ApplyCreated, "",
+				func() error { return provider.AddDomain(ctx, existing.ID, name) })
+		}
+		if err != nil {
+			return result, err
+		}
+	}
+	for _, d := range current {
+		if name := normalizeDomain(d.Name); !want[name] {
+			record("domain:"+name, ApplyExtraneous, "not in spec; remove it explicitly if it is no longer needed", nil)
+		}
+	}
+
+	return result, nil
+}
+
+// applyCreate creates the service and its domains
+func (s *Service) applyCreate(ctx context.Context, spec ServiceSpec, dryRun bool) (*ApplyResult, error) {
+	result := &ApplyResult{
+		Provider: string(s.defaultProviderName()),
+		Created:  true,
+		DryRun:   dryRun,
+		Changes:  []ApplyChange{{Resource: "service", Action: ApplyCreated, Detail: spec.Name}},
+	}
+	for _, name := range spec.Domains {
+		result.Changes = append(result.Changes, ApplyChange{Resource: "domain:" + normalizeDomain(name), Action: ApplyCreated})
+	}
+	if dryRun {
+		return result, nil
+	}
+
+	service, err := s.CreateService(ctx, &ServiceConfig{
+		Name:   spec.Name,
+		Origin: spec.Origin,
+		Rules:  spec.Rules,
+		SSL:    spec.SSL,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create service: %w", err)
+	}
+	result.ServiceID = service.ID
+	if service.Provider != "" {
+		result.Provider = string(service.Provider)
+	}
+
+	for _, name := range spec.Domains {
+		if err := s.provider.AddDomain(ctx, service.ID, normalizeDomain(name)); err != nil {
+			return result, fmt.Errorf("failed to add domain %s: %w", name, err)
+		}
+	}
+	return result, nil
+}
+
+// findSpecService returns the active service a spec refers to, or nil if it doesn't exist yet
+func (s *Service) findSpecService(ctx context.Context, spec ServiceSpec) (*domain.CDNService, error) {
+	services, err := s.ListServices(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services: %w", err)
+	}
+
+	for _, svc := range services {
+		if spec.ServiceID != "" && svc.ID == spec.ServiceID {
+			return &svc, nil
+		}
+		if spec.ServiceID == "" && strings.EqualFold(svc.Name, spec.Name) {
+			return &svc, nil
+		}
+	}
+	if spec.ServiceID != "" {
+		return nil, fmt.Errorf("service %s not found", spec.ServiceID)
+	}
+	return nil, nil
+}
+
+// defaultProviderName returns the provider new services are created on
+func (s *Service) defaultProviderName() domain.CDNProvider {
+	if s.registry != nil {
+		return s.registry.Default()
+	}
+	return ""
+}
+
+func (spec ServiceSpec) validate() error {
+	if spec.Name == "" && spec.ServiceID == "" {
+		return errors.New("spec needs a name or service_id")
+	}
+	if spec.Origin.Host == "" {
+		return errors.New("spec needs an origin host")
+	}
+	return validateRules(spec.Rules)
+}
+
+func normalizeDomain(name string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(name)), ".")
+}
+
+func stateOrigin(state *ServiceState) OriginConfig {
+	if state == nil {
+		return OriginConfig{}
+	}
+	return state.Origin
+}
+
+// sameOrigin compares origins; an unset port or path in the spec matches any
+func sameOrigin(current, desired OriginConfig) bool {
+	return strings.EqualFold(current.Host, desired.Host) &&
+		strings.EqualFold(originProtocol(current), originProtocol(desired)) &&
+		(desired.Port == 0 || current.Port == desired.Port) &&
+		(desired.Path == "" || current.Path == desired.Path)
+}
+
+func originProtocol(origin OriginConfig) string {
+	if origin.Protocol == "" {
+		return "https"
+	}
+	return origin.Protocol
+}
+
+func originString(origin OriginConfig) string {
+	if origin.Host == "" {
+		return ""
+	}
+	return strings.ToLower(originProtocol(origin)) + "://" + origin.Host
+}
+
+// sameRules compares rule sets by path and TTL, ignoring order
+func sameRules(current, desired []CacheRule) bool {
+	if len(current) != len(desired) {
+		return false
+	}
+	key := func(rules []CacheRule) []string {
+		keys := make([]string, 0, len(rules))
+		for _, r := range rules {
+			keys = append(keys, fmt.Sprintf("%s=%d", r.Path, r.TTL))
+		}
+		sort.Strings(keys)
+		return keys
+	}
+	a, b := key(current), key(desired)
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// sameSSL compares SSL settings; a certificate in the spec is always (re)uploaded
+func sameSSL(current, desired SSLConfig) bool {
+	return current.Enabled == desired.Enabled && desired.Certificate == ""
+}
+
+func sslString(ssl SSLConfig) string {
+	switch {
+	case !ssl.Enabled:
+		return "disabled"
+	case ssl.Certificate != "":
+		return "enabled with custom certificate"
+	}
+	return "enabled"
+}
+
+// describeApply summarizes an applied spec for chat
+func describeApply(result *ApplyResult) string {
+	if !result.Changed() {
+		return fmt.Sprintf("✅ %s is already configured as requested; nothing to change.", result.Changes[0].Detail)
+	}
+
+	response := fmt.Sprintf("✅ Updated %s to match the requested setup:\n\n", result.Changes[0].Detail)
+	for _, c := range result.Changes {
+		if c.Action == ApplyUnchanged {
+			continue
+		}
+		line := fmt.Sprintf("   • %s: %s", c.Resource, c.Action)
+		if c.Detail != "" {
+			line += " (" + c.Detail + ")"
+		}
+		response += line + "\n"
+	}
+	return response
+}
+
+func describeChange(known bool, from, to string) string {
+	if !known {
+		return to + " (current setting unknown)"
+	}
+	return from + " -> " + to
+}
@@ -1,6 +1,11 @@
 package cdn
 
 import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
 	api "github.com/cachefly/cachefly-go-sdk/pkg/cachefly/api/v2_5"
 )
 
@@ -149,3 +154,76 @@ func GetOptimizationsSummary() []string {
 func GetOptimizationsCount() int {
 	return len(GetOptimizationsSummary())
 }
+
+// ValidateOptionOverrides checks the keys of ServiceConfig.Custom
+func ValidateOptionOverrides(overrides map[string]string) error {
+	for key := range overrides {
+		for _, part := range strings.Split(key, ".") {
+			if strings.TrimSpace(part) == "" {
+				return fmt.Errorf("invalid option override %q: empty path segment", key)
+			}
+		}
+	}
+	return nil
+}
+
+// ApplyOptionOverrides merges user overrides (ServiceConfig.Custom) over the
+// best-practice options. Keys are option names, with dots reaching into
+// nested options ("reverseProxy.cacheByQueryParam"); values are JSON, or a
+// plain string when they don't parse. Keys are applied in sorted order, so a
+// nested key always wins over its parent. A scalar set on an
+// {"enabled", "value"} option sets the value and enables it, except a
+// boolean, which toggles it ("maxcons": "250", "maxcons": "false").
+func ApplyOptionOverrides(options api.ServiceOptions, overrides map[string]string) error {
+	if err := ValidateOptionOverrides(overrides); err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(overrides))
+	for key := range overrides {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		path := strings.Split(key, ".")
+		parent := map[string]interface{}(options)
+		for _, part := range path[:len(path)-1] {
+			child, ok := parent[part].(map[string]interface{})
+			if !ok {
+				child = make(map[string]interface{})
+				parent[part] = child
+			}
+			parent = child
+		}
+		setOption(parent, path[len(path)-1], overrideValue(overrides[key]))
+	}
+	return nil
+}
+
+// overrideValue parses an override as JSON, falling back to the raw string
+func overrideValue(raw string) interface{} {
+	var v interface{}
+	if err := json.Unmarshal([]byte(raw), &v); err != nil {
+		return raw
+	}
+	return v
+}
+
+func setOption(parent map[string]interface{}, name string, value interface{}) {
+	toggle, isToggle := parent[name].(map[string]interface{})
+	if _, hasEnabled := toggle["enabled"]; !isToggle || !hasEnabled {
+		parent[name] = value
+		return
+	}
+
+	switch v := value.(type) {
+	case bool:
+		toggle["enabled"] = v
+	case map[string]interface{}:
+		parent[name] = v
+	default:
+		toggle["enabled"] = true
+		toggle["value"] = v
+	}
+}
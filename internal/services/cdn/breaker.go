@@ -0,0 +1,168 @@
+package cdn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/avvvet/cdnbuddy-api/internal/metrics"
+)
+
+// ErrProviderUnavailable is returned without calling the provider while its
+// circuit breaker is open
+var ErrProviderUnavailable = errors.New("provider temporarily unavailable")
+
+// UnavailableError is returned for calls refused by an open breaker
+type UnavailableError struct {
+	Provider string
+	RetryAt  time.Time
+}
+
+func (e *UnavailableError) Error() string {
+	retryIn := max(time.Until(e.RetryAt).Round(time.Second), time.Second)
+	return fmt.Sprintf("%s: %s, retry in %s", e.Provider, ErrProviderUnavailable, retryIn)
+}
+
+func (e *UnavailableError) Unwrap() error { return ErrProviderUnavailable }
+
+// Breaker states
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half_open" // one probe call is let through
+)
+
+// BreakerSettings control when a provider's breaker trips and recovers
+type BreakerSettings struct {
+	Threshold int           // consecutive failures that open the breaker (0 disables it)
+	Cooldown  time.Duration // how long it stays open before a probe call
+}
+
+// DefaultBreakerSettings are the breaker settings of DefaultCallPolicy
+var DefaultBreakerSettings = BreakerSettings{Threshold: 5, Cooldown: 30 * time.Second}
+
+// BreakerState is the state of one provider's breaker
+type BreakerState struct {
+	State    string     `json:"state"`
+	Failures int        `json:"consecutive_failures"`
+	OpenedAt *time.Time `json:"opened_at,omitempty"`
+	RetryAt  *time.Time `json:"retry_at,omitempty"`
+}
+
+type breaker struct {
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// BreakerStates returns the breaker state of every provider that has been called
+func (c *CallPolicy) BreakerStates() map[string]BreakerState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	states := make(map[string]BreakerState, len(c.breakers))
+	for provider, b := range c.breakers {
+		state := BreakerState{State: b.state(c.breaker, c.now()), Failures: b.failures}
+		if !b.openedAt.IsZero() {
+			openedAt, retryAt := b.openedAt, b.openedAt.Add(c.breaker.Cooldown)
+			state.OpenedAt, state.RetryAt = &openedAt, &retryAt
+		}
+		states[provider] = state
+	}
+	return states
+}
+
+// BreakerStates returns the breaker states of the service's providers
+func (s *Service) BreakerStates() map[string]BreakerState {
+	if s.calls == nil {
+		return map[string]BreakerState{}
+	}
+	return s.calls.BreakerStates()
+}
+
+func (b *breaker) state(settings BreakerSettings, now time.Time) string {
+	switch {
+	case b.openedAt.IsZero():
+		return BreakerClosed
+	case now.Before(b.openedAt.Add(settings.Cooldown)) || b.probing:
+		return BreakerOpen
+	}
+	return BreakerHalfOpen
+}
+
+// allowCall reports whether a call to provider may go out. Once the cooldown
+// has passed a single probe is allowed; its outcome closes or reopens the breaker.
+func (c *CallPolicy) allowCall(provider string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.breaker.Threshold <= 0 {
+		return nil
+	}
+	b, ok := c.breakers[provider]
+	if !ok {
+		b = &breaker{}
+		c.breakers[provider] = b
+	}
+
+	switch b.state(c.breaker, c.now()) {
+	case BreakerOpen:
+		metrics.Inc("provider_breaker_rejected_" + provider)
+		return &UnavailableError{Provider: provider, RetryAt: b.openedAt.Add(c.breaker.Cooldown)}
+	case BreakerHalfOpen:
+		b.probing = true
+	}
+	return nil
+}
+
+// recordCall updates a provider's breaker with the outcome of a call. Only
+// provider-side failures count; client errors such as 404 or validation
+// failures mean the provider is up.
+func (c *CallPolicy) recordCall(provider string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	b, ok := c.breakers[provider]
+	if !ok || c.breaker.Threshold <= 0 {
+		return
+	}
+	wasOpen := !b.openedAt.IsZero()
+	b.probing = false
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return // the caller gave up; says nothing about the provider
+	}
+
+	if err == nil || !providerFailure(err) {
+		if wasOpen {
+			logrus.WithField("provider", provider).Info("✅ Provider recovered, circuit breaker closed")
+		}
+		b.failures = 0
+		b.openedAt = time.Time{}
+		return
+	}
+
+	b.failures++
+	if wasOpen || b.failures >= c.breaker.Threshold {
+		if !wasOpen {
+			metrics.Inc("provider_breaker_opened_" + provider)
+			logrus.WithError(err).WithFields(logrus.Fields{
+				"provider": provider,
+				"failures": b.failures,
+			}).Error("🔌 Provider failing, circuit breaker opened")
+		}
+		b.openedAt = c.now()
+	}
+}
+
+// providerFailure reports whether an error means the provider itself is failing
+func providerFailure(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == 429 || apiErr.StatusCode >= 500
+	}
+	retry, _ := retryable(OpRead, err)
+	return retry
+}
@@ -3,24 +3,48 @@ package cdn
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"net/url"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/avvvet/cdnbuddy-api/internal/domain"
 	"github.com/cachefly/cachefly-go-sdk/pkg/cachefly"
 	api "github.com/cachefly/cachefly-go-sdk/pkg/cachefly/api/v2_5"
 	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
 )
 
+// cacheFlyName names CacheFly in rate budgets, retries and API errors
+const cacheFlyName = "cachefly"
+
+// cacheFlyBaseURL is the CacheFly REST API, used for endpoints the SDK doesn't cover
+const cacheFlyBaseURL = "https://api.cachefly.com/api/2.5"
+
 // CacheFlyProvider implements CDNProvider interface for CacheFly
 type CacheFlyProvider struct {
 	client   *cachefly.Client
+	api      *httpAdapter // reporting endpoints not wrapped by the SDK
+	calls    *CallPolicy
 	apiToken string
+	history  *configHistory // options before the last change, for rollback
+}
+
+// cacheFlyReport is a summary row of the CacheFly reporting API
+type cacheFlyReport struct {
+	Requests          int64   `json:"requests"`
+	CacheHits         int64   `json:"cacheHits"`
+	CacheMisses       int64   `json:"cacheMisses"`
+	AvgResponseTimeMs float64 `json:"avgResponseTime"`
 }
 
-// NewCacheFlyProvider creates a new CacheFly provider
-func NewCacheFlyProvider() (*CacheFlyProvider, error) {
+// NewCacheFlyProvider creates a new CacheFly provider whose calls follow calls
+// (DefaultCallPolicy if nil)
+func NewCacheFlyProvider(calls *CallPolicy) (*CacheFlyProvider, error) {
 	// Get API token from environment
 	token := os.Getenv("CACHEFLY_API_TOKEN")
 	if token == "" {
@@ -30,40 +54,47 @@ func NewCacheFlyProvider() (*CacheFlyProvider, error) {
 	// Initialize CacheFly client
 	client := cachefly.NewClient(
 		cachefly.WithToken(token),
+		cachefly.WithHTTPClient(newProviderHTTPClient()),
 	)
 
+	api := newHTTPAdapter(cacheFlyName, cacheFlyBaseURL, calls, func(req *http.Request) {
+		req.Header.Set("Authorization", "Bearer "+token)
+	})
+
 	return &CacheFlyProvider{
 		client:   client,
+		api:      api,
+		calls:    api.calls,
 		apiToken: token,
+		history:  newConfigHistory(domain.ProviderCacheFly),
 	}, nil
 }
 
 // CreateService creates a new CDN service with origin configuration
 func (p *CacheFlyProvider) CreateService(ctx context.Context, config *ServiceConfig) (*domain.CDNService, error) {
-	// Generate service name from config or auto-generate
-	serviceName := generateServiceName(config.Name)
-	uniqueName := fmt.Sprintf("%s-%s", serviceName, uuid.New().String()[:8])
-
-	// Step 1: Create CacheFly service
-	createReq := api.CreateServiceRequest{
-		Name:        serviceName,
-		UniqueName:  uniqueName,
-		Description: "CDN service created by CDNBuddy",
+	// Step 1: Build and validate the options (including origin via reverseProxy)
+	// first, so an invalid config doesn't leave a half-configured service behind
+	options, err := p.buildServiceOptions(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure service options: %w", err)
 	}
 
-	service, err := p.client.Services.Create(ctx, createReq)
+	// Step 2: Create CacheFly service
+	service, err := retryCall1(ctx, p.calls, cacheFlyName, OpWrite, p.client.Services.Create, cacheFlyCreateRequest(config))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create CacheFly service: %w", err)
 	}
 
-	// Step 2: Configure service options (including origin via reverseProxy)
-	if err := p.configureServiceOptions(ctx, service.ID, config); err != nil {
+	// Step 3: Apply the options
+	if _, err := retryCall2(ctx, p.calls, cacheFlyName, OpWrite, p.client.ServiceOptions.UpdateOptions, service.ID, options); err != nil {
 		// Cleanup: try to deactivate the service if options fail
-		_, err = p.client.Services.DeactivateServiceByID(ctx, service.ID)
+		if _, cleanupErr := retryCall1(ctx, p.calls, cacheFlyName, OpWrite, p.client.Services.DeactivateServiceByID, service.ID); cleanupErr != nil {
+			logrus.WithError(cleanupErr).WithField("service_id", service.ID).Warn("⚠️ Failed to deactivate half-configured CacheFly service")
+		}
 		return nil, fmt.Errorf("failed to configure service options: %w", err)
 	}
 
-	// Step 3: Build and return domain.CDNService
+	// Step 4: Build and return domain.CDNService
 	cdnService := &domain.CDNService{
 		ID:       service.ID,
 		Provider: domain.ProviderCacheFly,
@@ -75,29 +106,137 @@ func (p *CacheFlyProvider) CreateService(ctx context.Context, config *ServiceCon
 	return cdnService, nil
 }
 
+// CloneService creates a service with a copy of every option of another one
+func (p *CacheFlyProvider) CloneService(ctx context.Context, serviceID, name string) (*domain.CDNService, error) {
+	options, err := retryCall1(ctx, p.calls, cacheFlyName, OpRead, p.client.ServiceOptions.GetOptions, serviceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current options: %w", err)
+	}
+
+	config := &ServiceConfig{Name: name}
+	service, err := retryCall1(ctx, p.calls, cacheFlyName, OpWrite, p.client.Services.Create, cacheFlyCreateRequest(config))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CacheFly service: %w", err)
+	}
+
+	if _, err := retryCall2(ctx, p.calls, cacheFlyName, OpWrite, p.client.ServiceOptions.UpdateOptions, service.ID, options); err != nil {
+		// Cleanup: deactivate the half-configured clone
+		retryCall1(ctx, p.calls, cacheFlyName, OpWrite, p.client.Services.DeactivateServiceByID, service.ID)
+		return nil, fmt.Errorf("failed to copy service options: %w", err)
+	}
+
+	if proxy, ok := options["reverseProxy"].(map[string]interface{}); ok {
+		config.Origin.Host, _ = proxy["hostname"].(string)
+		scheme, _ := proxy["originScheme"].(string)
+		config.Origin.Protocol = strings.ToLower(scheme)
+	}
+
+	return &domain.CDNService{
+		ID:       service.ID,
+		Provider: domain.ProviderCacheFly,
+		Name:     service.Name,
+		Status:   service.Status,
+		Config:   p.buildConfigJSON(service, config),
+	}, nil
+}
+
+// cacheFlyCreateRequest names a new service after the config, with a random
+// suffix so the unique name doesn't collide
+func cacheFlyCreateRequest(config *ServiceConfig) api.CreateServiceRequest {
+	serviceName := generateServiceName(config.Name)
+	return api.CreateServiceRequest{
+		Name:        serviceName,
+		UniqueName:  fmt.Sprintf("%s-%s", serviceName, uuid.New().String()[:8]),
+		Description: "CDN service created by CDNBuddy",
+	}
+}
+
 // configureServiceOptions configures origin and performance settings with best practices
 func (p *CacheFlyProvider) configureServiceOptions(ctx context.Context, serviceID string, config *ServiceConfig) error {
+	options, err := p.buildServiceOptions(config)
+	if err != nil {
+		return err
+	}
+
+	// Update service options
+	_, err = retryCall2(ctx, p.calls, cacheFlyName, OpWrite, p.client.ServiceOptions.UpdateOptions, serviceID, options)
+	if err != nil {
+		return fmt.Errorf("failed to update service options: %w", err)
+	}
+
+	return nil
+}
+
+// buildServiceOptions returns the best-practice options with the config applied
+func (p *CacheFlyProvider) buildServiceOptions(config *ServiceConfig) (api.ServiceOptions, error) {
 	// Determine origin scheme
 	originScheme := "HTTPS"
 	if config.Origin.Protocol != "" {
 		originScheme = strings.ToUpper(config.Origin.Protocol)
 	}
 
-	// Get best practices configuration with origin details
-	options := GetBestPracticesOptions(config.Name, config.Origin.Host, originScheme)
+	// Get the workload profile's best practices with origin details
+	profile, err := ParseProfile(string(config.Profile))
+	if err != nil {
+		return nil, err
+	}
+	options := GetProfileOptions(profile, config.Name, config.Origin.Host, originScheme)
+
+	// Individual user overrides; the typed settings below still take precedence
+	if err := ApplyOptionOverrides(options, config.Custom); err != nil {
+		return nil, err
+	}
 
 	// Add custom cache rules if provided (override defaults)
 	if len(config.Rules) > 0 {
+		if err := validateRules(config.Rules); err != nil {
+			return nil, err
+		}
 		options["expiryHeaders"] = p.buildExpiryHeaders(config.Rules)
 	}
 
-	// Update service options
-	_, err := p.client.ServiceOptions.UpdateOptions(ctx, serviceID, options)
-	if err != nil {
-		return fmt.Errorf("failed to update service options: %w", err)
+	// Explicit stale policy replaces the best-practice servestale default
+	if config.Stale != nil {
+		if err := config.Stale.Validate(); err != nil {
+			return nil, err
+		}
+		applyStaleOptions(options, *config.Stale)
 	}
 
-	return nil
+	// Origin shield routes cache misses through one mid-tier location
+	if config.OriginShield != nil {
+		load := config.OriginShield.Options()
+		if err := p.OriginLoadSupport().Check(load); err != nil {
+			return nil, err
+		}
+		applyOriginLoadOptions(options, load)
+	}
+
+	// Minimum TLS version and HTTP/2 and HTTP/3 toggles
+	if config.TLS != nil {
+		if err := config.TLS.Validate(); err != nil {
+			return nil, err
+		}
+		applyTLSOptions(options, *config.TLS)
+	}
+
+	// Custom and security headers replace whatever the origin sends
+	if len(config.Headers) > 0 {
+		if err := ValidateResponseHeaders(config.Headers); err != nil {
+			return nil, err
+		}
+		applyHeaderOptions(options, config.Headers)
+	}
+
+	// Backup origins take over while the primary fails health checks
+	if config.Failover != nil {
+		if err := config.Failover.Validate(config.Origin); err != nil {
+			return nil, err
+		}
+		applyFailoverOptions(options, *config.Failover)
+	}
+
+	return options, nil
 }
 
 // buildExpiryHeaders converts cache rules to CacheFly expiry headers format
@@ -109,6 +248,9 @@ func (p *CacheFlyProvider) buildExpiryHeaders(rules []CacheRule) []interface{} {
 			"path":       rule.Path,
 			"expiryTime": rule.TTL,
 		}
+		if rule.Stale != nil {
+			header["cacheControlExtensions"] = rule.Stale.CacheControl()
+		}
 		headers = append(headers, header)
 	}
 
@@ -122,7 +264,7 @@ func (p *CacheFlyProvider) AddDomain(ctx context.Context, serviceID, domainName
 		Description: fmt.Sprintf("Domain added by CDNBuddy for %s", domainName),
 	}
 
-	_, err := p.client.ServiceDomains.Create(ctx, serviceID, req)
+	_, err := retryCall2(ctx, p.calls, cacheFlyName, OpWrite, p.client.ServiceDomains.Create, serviceID, req)
 	if err != nil {
 		return fmt.Errorf("failed to add domain %s: %w", domainName, err)
 	}
@@ -132,17 +274,22 @@ func (p *CacheFlyProvider) AddDomain(ctx context.Context, serviceID, domainName
 
 // UpdateService updates service configuration
 func (p *CacheFlyProvider) UpdateService(ctx context.Context, serviceID string, config *ServiceConfig) error {
+	previous, err := retryCall1(ctx, p.calls, cacheFlyName, OpRead, p.client.ServiceOptions.GetOptions, serviceID)
+	if err != nil {
+		return fmt.Errorf("failed to get current options: %w", err)
+	}
+
 	// Update service options with new configuration
 	if err := p.configureServiceOptions(ctx, serviceID, config); err != nil {
 		return fmt.Errorf("failed to update service: %w", err)
 	}
 
-	return nil
+	return p.history.record(serviceID, "update_service", previous)
 }
 
 // DeleteService deactivates a CDN service (CacheFly doesn't support deletion)
 func (p *CacheFlyProvider) DeleteService(ctx context.Context, serviceID string) error {
-	_, err := p.client.Services.DeactivateServiceByID(ctx, serviceID)
+	_, err := retryCall1(ctx, p.calls, cacheFlyName, OpWrite, p.client.Services.DeactivateServiceByID, serviceID)
 	if err != nil {
 		return fmt.Errorf("failed to deactivate service: %w", err)
 	}
@@ -150,68 +297,120 @@ func (p *CacheFlyProvider) DeleteService(ctx context.Context, serviceID string)
 	return nil
 }
 
-// ListServices lists all CDN services for the account
+// cacheFlyDeactivated is the status CacheFly gives deactivated services
+const cacheFlyDeactivated = "DEACTIVATED"
+
+// ListServices lists all active CDN services for the account
 func (p *CacheFlyProvider) ListServices(ctx context.Context) ([]domain.CDNService, error) {
-	opts := api.ListOptions{
-		Offset:          0,
-		Limit:           100, // Adjust as needed
-		Status:          "ACTIVE",
-		IncludeFeatures: false,
-		ResponseType:    "",
-	}
-
-	resp, err := p.client.Services.List(ctx, opts)
-	if err != nil {
-		return nil, fmt.Errorf("failed to list services: %w", err)
-	}
-
-	// Convert CacheFly services to domain.CDNService
-	services := make([]domain.CDNService, 0, len(resp.Services))
-	for _, svc := range resp.Services {
-		// Build config JSON for each service
-		configData := map[string]interface{}{
-			"cachefly_service_id": svc.ID,
-			"unique_name":         svc.UniqueName,
-			"test_url":            fmt.Sprintf("https://%s.cachefly.net", svc.UniqueName),
-			"auto_ssl":            svc.AutoSSL,
-			"status":              svc.Status,
-			"configuration_mode":  svc.ConfigurationMode,
-		}
-		configJSON, _ := json.Marshal(configData)
-
-		services = append(services, domain.CDNService{
-			ID:       svc.ID,
-			Provider: domain.ProviderCacheFly,
-			Name:     svc.Name,
-			Status:   svc.Status,
-			Config:   string(configJSON),
-			// UserID and timestamps would be filled from database
-		})
+	return collectServices(ctx, p)
+}
+
+// ListServicesByStatus lists services that pass the filter; CacheFly filters by
+// status server-side, so ALL takes one listing per status
+func (p *CacheFlyProvider) ListServicesByStatus(ctx context.Context, status StatusFilter) ([]domain.CDNService, error) {
+	statuses := map[StatusFilter][]string{
+		StatusActive:   {string(StatusActive)},
+		StatusInactive: {cacheFlyDeactivated},
+		StatusAll:      {string(StatusActive), cacheFlyDeactivated},
+	}[status]
+
+	all := make([]domain.CDNService, 0)
+	for _, s := range statuses {
+		services, err := collectServices(ctx, cacheFlyStatusIterator{p: p, status: s})
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, services...)
 	}
+	return all, nil
+}
 
-	return services, nil
+// cacheFlyStatusIterator streams the services with one CacheFly status
+type cacheFlyStatusIterator struct {
+	p      *CacheFlyProvider
+	status string
 }
 
-// RemoveDomain removes a domain from the service
-func (p *CacheFlyProvider) RemoveDomain(ctx context.Context, serviceID, domainName string) error {
-	// List domains to find the one to delete
-	opts := api.ListServiceDomainsOptions{
-		Offset: 0,
-		Limit:  100,
+func (it cacheFlyStatusIterator) ForEachService(ctx context.Context, fn func(svc domain.CDNService) error) error {
+	return it.p.forEachService(ctx, it.status, fn)
+}
+
+// ReactivateService activates a deactivated service again
+func (p *CacheFlyProvider) ReactivateService(ctx context.Context, serviceID string) error {
+	if err := p.api.do(ctx, http.MethodPut, "/services/"+url.PathEscape(serviceID)+"/activate", nil, nil); err != nil {
+		return fmt.Errorf("failed to reactivate service: %w", err)
 	}
+	return nil
+}
 
-	resp, err := p.client.ServiceDomains.List(ctx, serviceID, opts)
-	if err != nil {
-		return fmt.Errorf("failed to list domains: %w", err)
+// ForEachService streams all active CDN services for the account page by page
+func (p *CacheFlyProvider) ForEachService(ctx context.Context, fn func(svc domain.CDNService) error) error {
+	return p.forEachService(ctx, string(StatusActive), fn)
+}
+
+// forEachService streams the services with one CacheFly status page by page
+func (p *CacheFlyProvider) forEachService(ctx context.Context, status string, fn func(svc domain.CDNService) error) error {
+	for offset := 0; ; offset += listPageSize {
+		opts := api.ListOptions{
+			Offset:          offset,
+			Limit:           listPageSize,
+			Status:          status,
+			IncludeFeatures: false,
+			ResponseType:    "",
+		}
+
+		resp, err := retryCall1(ctx, p.calls, cacheFlyName, OpRead, p.client.Services.List, opts)
+		if err != nil {
+			return fmt.Errorf("failed to list services: %w", err)
+		}
+
+		// Convert CacheFly services to domain.CDNService
+		for _, svc := range resp.Services {
+			// Build config JSON for each service
+			configData := map[string]interface{}{
+				"cachefly_service_id": svc.ID,
+				"unique_name":         svc.UniqueName,
+				"cname_target":        fmt.Sprintf("%s.cachefly.net", svc.UniqueName),
+				"test_url":            fmt.Sprintf("https://%s.cachefly.net", svc.UniqueName),
+				"auto_ssl":            svc.AutoSSL,
+				"status":              svc.Status,
+				"configuration_mode":  svc.ConfigurationMode,
+			}
+			configJSON, _ := json.Marshal(configData)
+
+			err := fn(domain.CDNService{
+				ID:       svc.ID,
+				Provider: domain.ProviderCacheFly,
+				Name:     svc.Name,
+				Status:   svc.Status,
+				Config:   string(configJSON),
+				// UserID and timestamps would be filled from database
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		// A short page means we've reached the end
+		if len(resp.Services) < listPageSize {
+			return nil
+		}
 	}
+}
 
-	// Find domain ID by name
+// RemoveDomain removes a domain from the service
+func (p *CacheFlyProvider) RemoveDomain(ctx context.Context, serviceID, domainName string) error {
+	// Walk domains to find the one to delete
 	var domainID string
-	for _, d := range resp.Domains {
+	err := p.ForEachDomain(ctx, serviceID, func(d domain.Domain) error {
 		if d.Name == domainName {
 			domainID = d.ID
-			break
+			return errStopIteration
 		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errStopIteration) {
+		return err
 	}
 
 	if domainID == "" {
@@ -219,7 +418,11 @@ func (p *CacheFlyProvider) RemoveDomain(ctx context.Context, serviceID, domainNa
 	}
 
 	// Delete the domain by ID
-	err = p.client.ServiceDomains.DeleteByID(ctx, serviceID, domainID)
+	err = p.calls.withRetry(ctx, cacheFlyName, OpWrite, func() error {
+		status := &responseStatus{}
+		err := p.client.ServiceDomains.DeleteByID(withResponseStatus(ctx, status), serviceID, domainID)
+		return status.apiError(cacheFlyName, err)
+	})
 	if err != nil {
 		return fmt.Errorf("failed to remove domain: %w", err)
 	}
@@ -229,75 +432,228 @@ func (p *CacheFlyProvider) RemoveDomain(ctx context.Context, serviceID, domainNa
 
 // ListDomains lists all domains for a service
 func (p *CacheFlyProvider) ListDomains(ctx context.Context, serviceID string) ([]domain.Domain, error) {
-	opts := api.ListServiceDomainsOptions{
-		Offset: 0,
-		Limit:  100,
+	return collectDomains(ctx, p, serviceID)
+}
+
+// ForEachDomain streams all domains of a service page by page
+func (p *CacheFlyProvider) ForEachDomain(ctx context.Context, serviceID string, fn func(d domain.Domain) error) error {
+	for offset := 0; ; offset += listPageSize {
+		opts := api.ListServiceDomainsOptions{
+			Offset: offset,
+			Limit:  listPageSize,
+		}
+
+		resp, err := retryCall2(ctx, p.calls, cacheFlyName, OpRead, p.client.ServiceDomains.List, serviceID, opts)
+		if err != nil {
+			return fmt.Errorf("failed to list domains: %w", err)
+		}
+
+		// Convert CacheFly domains to our domain type
+		for _, d := range resp.Domains {
+			err := fn(domain.Domain{
+				ID:           d.ID,
+				CDNServiceID: serviceID,
+				Name:         d.Name,
+				Status:       d.ValidationStatus,
+				// Regions: not available in CacheFly API
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(resp.Domains) < listPageSize {
+			return nil
+		}
 	}
+}
 
-	resp, err := p.client.ServiceDomains.List(ctx, serviceID, opts)
-	if err != nil {
-		return nil, fmt.Errorf("failed to list domains: %w", err)
+// PurgeCache purges specific paths of a service
+func (p *CacheFlyProvider) PurgeCache(ctx context.Context, serviceID string, paths []string) error {
+	req := map[string]interface{}{
+		"paths": paths,
 	}
 
-	// Convert CacheFly domains to our domain type
-	domains := make([]domain.Domain, 0, len(resp.Domains))
-	for _, d := range resp.Domains {
-		domains = append(domains, domain.Domain{
-			ID:           d.ID,
-			CDNServiceID: serviceID,
-			Name:         d.Name,
-			Status:       d.ValidationStatus,
-			// Regions: not available in CacheFly API
-		})
+	if err := p.api.do(ctx, http.MethodPut, "/services/"+url.PathEscape(serviceID)+"/purge", req, nil); err != nil {
+		return fmt.Errorf("failed to purge cache: %w", err)
 	}
 
-	return domains, nil
+	return nil
 }
 
-// PurgeCache purges cache for specific paths
-func (p *CacheFlyProvider) PurgeCache(ctx context.Context, serviceID string, paths []string) error {
-	// CacheFly purge implementation would go here
-	// This depends on CacheFly SDK purge methods
-	return fmt.Errorf("purge cache not yet implemented")
+// PurgeAll purges the complete cache of a service
+func (p *CacheFlyProvider) PurgeAll(ctx context.Context, serviceID string) error {
+	req := map[string]interface{}{
+		"all": true,
+	}
+
+	if err := p.api.do(ctx, http.MethodPut, "/services/"+url.PathEscape(serviceID)+"/purge", req, nil); err != nil {
+		return fmt.Errorf("failed to purge all cache: %w", err)
+	}
+
+	return nil
 }
 
-// PurgeAll purges all cache for a service
-func (p *CacheFlyProvider) PurgeAll(ctx context.Context, serviceID string) error {
-	// CacheFly purge all implementation would go here
-	return fmt.Errorf("purge all cache not yet implemented")
+// GetAccountInfo reads the account profile; CacheFly's API exposes no plan
+// limits or incidents, so only the name and status are reported
+func (p *CacheFlyProvider) GetAccountInfo(ctx context.Context) (*AccountInfo, error) {
+	var account struct {
+		CompanyName string `json:"companyName"`
+		Status      string `json:"status"`
+	}
+	if err := p.api.do(ctx, http.MethodGet, "/accounts/me", nil, &account); err != nil {
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+	return &AccountInfo{Name: account.CompanyName, Status: account.Status}, nil
 }
 
-// GetMetrics retrieves metrics for a service
+// GetMetrics retrieves hit ratio, response time and request totals for the last 24 hours
 func (p *CacheFlyProvider) GetMetrics(ctx context.Context, serviceID string) (*domain.Metrics, error) {
-	// CacheFly metrics implementation would go here
-	// This depends on CacheFly SDK metrics methods
-	return nil, fmt.Errorf("get metrics not yet implemented")
+	end := time.Now()
+	query := url.Values{}
+	query.Set("from", end.Add(-24*time.Hour).UTC().Format(time.RFC3339))
+	query.Set("to", end.UTC().Format(time.RFC3339))
+	query.Set("groupBy", "service")
+	query.Set("services", serviceID)
+
+	var resp struct {
+		Data []cacheFlyReport `json:"data"`
+	}
+	if err := p.api.do(ctx, http.MethodGet, "/reports/summary?"+query.Encode(), nil, &resp); err != nil {
+		return nil, fmt.Errorf("failed to get metrics: %w", err)
+	}
+
+	metrics := &domain.Metrics{
+		CDNServiceID: serviceID,
+		Timestamp:    end,
+	}
+
+	// Weight response times by requests when more than one row comes back
+	var hits, misses int64
+	var weightedResponse float64
+	for _, row := range resp.Data {
+		metrics.TotalRequests += row.Requests
+		hits += row.CacheHits
+		misses += row.CacheMisses
+		weightedResponse += row.AvgResponseTimeMs * float64(row.Requests)
+	}
+
+	if total := hits + misses; total > 0 {
+		metrics.CacheHitRatio = float64(hits) / float64(total)
+	}
+	if metrics.TotalRequests > 0 {
+		metrics.AvgResponseTime = int(weightedResponse / float64(metrics.TotalRequests))
+	}
+
+	return metrics, nil
 }
 
 // UpdateCacheRules updates cache rules for a service
 func (p *CacheFlyProvider) UpdateCacheRules(ctx context.Context, serviceID string, rules []CacheRule) error {
-	// Get current options
-	currentOptions, err := p.client.ServiceOptions.GetOptions(ctx, serviceID)
-	if err != nil {
-		return fmt.Errorf("failed to get current options: %w", err)
+	if err := validateRules(rules); err != nil {
+		return err
 	}
 
-	// Update expiry headers
-	currentOptions["expiryHeaders"] = p.buildExpiryHeaders(rules)
+	options, previous, err := p.cacheRuleOptions(ctx, serviceID, rules)
+	if err != nil {
+		return err
+	}
 
 	// Save updated options
-	_, err = p.client.ServiceOptions.UpdateOptions(ctx, serviceID, currentOptions)
+	_, err = retryCall2(ctx, p.calls, cacheFlyName, OpWrite, p.client.ServiceOptions.UpdateOptions, serviceID, options)
 	if err != nil {
 		return fmt.Errorf("failed to update cache rules: %w", err)
 	}
 
-	return nil
+	return p.history.record(serviceID, "update_cache_rules", previous)
+}
+
+// cacheRuleOptions returns the current options with the expiry headers
+// replaced by rules, and the current options unchanged
+func (p *CacheFlyProvider) cacheRuleOptions(ctx context.Context, serviceID string, rules []CacheRule) (api.ServiceOptions, api.ServiceOptions, error) {
+	currentOptions, err := retryCall1(ctx, p.calls, cacheFlyName, OpRead, p.client.ServiceOptions.GetOptions, serviceID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get current options: %w", err)
+	}
+
+	options := make(api.ServiceOptions, len(currentOptions))
+	for key, value := range currentOptions {
+		options[key] = value
+	}
+	options["expiryHeaders"] = p.buildExpiryHeaders(rules)
+	return options, currentOptions, nil
+}
+
+// LastConfigChange returns the options a service had before its last update
+func (p *CacheFlyProvider) LastConfigChange(ctx context.Context, serviceID string) (*ConfigChange, error) {
+	return p.history.last(serviceID)
+}
+
+// RollbackConfig writes back the options a service had before its last update
+func (p *CacheFlyProvider) RollbackConfig(ctx context.Context, serviceID string) (*ConfigChange, error) {
+	change, err := p.history.last(serviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	var options api.ServiceOptions
+	if err := json.Unmarshal(change.Previous, &options); err != nil {
+		return nil, fmt.Errorf("failed to decode previous options: %w", err)
+	}
+	if _, err := retryCall2(ctx, p.calls, cacheFlyName, OpWrite, p.client.ServiceOptions.UpdateOptions, change.ServiceID, options); err != nil {
+		return nil, fmt.Errorf("failed to restore options: %w", err)
+	}
+
+	p.history.forget(change)
+	return change, nil
+}
+
+// DryRunCreateService returns the service and options requests CreateService
+// would send; the unique name suffix is regenerated on the real call
+func (p *CacheFlyProvider) DryRunCreateService(ctx context.Context, config *ServiceConfig) (*DryRun, error) {
+	options, err := p.buildServiceOptions(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return newDryRun(cacheFlyName, "create_service", "",
+		ProviderRequest{Method: http.MethodPost, Path: "/services", Body: cacheFlyCreateRequest(config)},
+		ProviderRequest{Method: http.MethodPut, Path: "/services/{new_service_id}/options", Body: options},
+	), nil
+}
+
+// DryRunUpdateService returns the options UpdateService would write
+func (p *CacheFlyProvider) DryRunUpdateService(ctx context.Context, serviceID string, config *ServiceConfig) (*DryRun, error) {
+	options, err := p.buildServiceOptions(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return newDryRun(cacheFlyName, "update_service", serviceID,
+		ProviderRequest{Method: http.MethodPut, Path: "/services/" + url.PathEscape(serviceID) + "/options", Body: options},
+	), nil
+}
+
+// DryRunCacheRules returns the merged options UpdateCacheRules would write
+func (p *CacheFlyProvider) DryRunCacheRules(ctx context.Context, serviceID string, rules []CacheRule) (*DryRun, error) {
+	if err := validateRules(rules); err != nil {
+		return nil, err
+	}
+
+	options, _, err := p.cacheRuleOptions(ctx, serviceID, rules)
+	if err != nil {
+		return nil, err
+	}
+
+	return newDryRun(cacheFlyName, "update_cache_rules", serviceID,
+		ProviderRequest{Method: http.MethodPut, Path: "/services/" + url.PathEscape(serviceID) + "/options", Body: options},
+	), nil
 }
 
 // UpdateOriginSettings updates origin configuration
 func (p *CacheFlyProvider) UpdateOriginSettings(ctx context.Context, serviceID string, origin OriginConfig) error {
 	// Get current options
-	currentOptions, err := p.client.ServiceOptions.GetOptions(ctx, serviceID)
+	currentOptions, err := retryCall1(ctx, p.calls, cacheFlyName, OpRead, p.client.ServiceOptions.GetOptions, serviceID)
 	if err != nil {
 		return fmt.Errorf("failed to get current options: %w", err)
 	}
@@ -320,7 +676,7 @@ func (p *CacheFlyProvider) UpdateOriginSettings(ctx context.Context, serviceID s
 	}
 
 	// Save updated options
-	_, err = p.client.ServiceOptions.UpdateOptions(ctx, serviceID, currentOptions)
+	_, err = retryCall2(ctx, p.calls, cacheFlyName, OpWrite, p.client.ServiceOptions.UpdateOptions, serviceID, currentOptions)
 	if err != nil {
 		return fmt.Errorf("failed to update origin settings: %w", err)
 	}
@@ -358,6 +714,7 @@ func (p *CacheFlyProvider) buildConfigJSON(service *api.Service, config *Service
 	configData := map[string]interface{}{
 		"cachefly_service_id": service.ID,
 		"unique_name":         service.UniqueName,
+		"cname_target":        fmt.Sprintf("%s.cachefly.net", service.UniqueName),
 		"test_url":            fmt.Sprintf("https://%s.cachefly.net", service.UniqueName),
 		"auto_ssl":            service.AutoSSL,
 		"configuration_mode":  service.ConfigurationMode,
@@ -366,7 +723,564 @@ func (p *CacheFlyProvider) buildConfigJSON(service *api.Service, config *Service
 			"protocol": config.Origin.Protocol,
 		},
 	}
+	if config.Failover != nil {
+		backups := make([]string, 0, len(config.Failover.Backups))
+		for _, b := range config.Failover.Backups {
+			backups = append(backups, b.Host)
+		}
+		configData["backup_origins"] = backups
+	}
 
 	jsonBytes, _ := json.Marshal(configData)
 	return string(jsonBytes)
 }
+
+// CacheKeySupport reports the cache-key features CacheFly options can express
+func (p *CacheFlyProvider) CacheKeySupport() CacheKeySupport {
+	return CacheKeySupport{
+		QueryParamModes: []string{QueryParamsAll, QueryParamsNone},
+		VaryHeaders:     true,
+	}
+}
+
+// GetCacheKey reads the cache-key settings from the service options
+func (p *CacheFlyProvider) GetCacheKey(ctx context.Context, serviceID string) (*CacheKeyConfig, error) {
+	options, err := retryCall1(ctx, p.calls, cacheFlyName, OpRead, p.client.ServiceOptions.GetOptions, serviceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current options: %w", err)
+	}
+
+	config := &CacheKeyConfig{QueryParams: QueryParamsNone}
+	if proxy, ok := options["reverseProxy"].(map[string]interface{}); ok {
+		if byQuery, _ := proxy["cacheByQueryParam"].(bool); byQuery {
+			config.QueryParams = QueryParamsAll
+		}
+	}
+	if byHeaders, ok := options["cacheByHeaders"].(map[string]interface{}); ok {
+		if enabled, _ := byHeaders["enabled"].(bool); enabled {
+			config.VaryHeaders = toStrings(byHeaders["value"])
+		}
+	}
+
+	return config, nil
+}
+
+// UpdateCacheKey maps the cache-key config onto reverseProxy.cacheByQueryParam and cacheByHeaders
+func (p *CacheFlyProvider) UpdateCacheKey(ctx context.Context, serviceID string, config CacheKeyConfig) error {
+	currentOptions, err := retryCall1(ctx, p.calls, cacheFlyName, OpRead, p.client.ServiceOptions.GetOptions, serviceID)
+	if err != nil {
+		return fmt.Errorf("failed to get current options: %w", err)
+	}
+
+	proxy, ok := currentOptions["reverseProxy"].(map[string]interface{})
+	if !ok {
+		proxy = map[string]interface{}{}
+	}
+	proxy["cacheByQueryParam"] = config.QueryParams == QueryParamsAll
+	currentOptions["reverseProxy"] = proxy
+
+	currentOptions["cacheByHeaders"] = map[string]interface{}{
+		"enabled": len(config.VaryHeaders) > 0,
+		"value":   config.VaryHeaders,
+	}
+
+	_, err = retryCall2(ctx, p.calls, cacheFlyName, OpWrite, p.client.ServiceOptions.UpdateOptions, serviceID, currentOptions)
+	if err != nil {
+		return fmt.Errorf("failed to update cache key: %w", err)
+	}
+
+	return nil
+}
+
+// GetHotlinkProtection reads the referrerBlocking option
+func (p *CacheFlyProvider) GetHotlinkProtection(ctx context.Context, serviceID string) (*HotlinkConfig, error) {
+	options, err := retryCall1(ctx, p.calls, cacheFlyName, OpRead, p.client.ServiceOptions.GetOptions, serviceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current options: %w", err)
+	}
+
+	config := &HotlinkConfig{AllowedReferrers: []string{}, AllowEmptyReferrer: true}
+	if blocking, ok := options["referrerBlocking"].(map[string]interface{}); ok {
+		config.Enabled, _ = blocking["enabled"].(bool)
+		if value, ok := blocking["value"].(map[string]interface{}); ok {
+			config.AllowedReferrers = toStrings(value["allowedReferrers"])
+			if allowEmpty, ok := value["allowEmptyReferrer"].(bool); ok {
+				config.AllowEmptyReferrer = allowEmpty
+			}
+		}
+	}
+
+	return config, nil
+}
+
+// UpdateHotlinkProtection writes the referrerBlocking option
+func (p *CacheFlyProvider) UpdateHotlinkProtection(ctx context.Context, serviceID string, config HotlinkConfig) error {
+	currentOptions, err := retryCall1(ctx, p.calls, cacheFlyName, OpRead, p.client.ServiceOptions.GetOptions, serviceID)
+	if err != nil {
+		return fmt.Errorf("failed to get current options: %w", err)
+	}
+
+	currentOptions["referrerBlocking"] = map[string]interface{}{
+		"enabled": config.Enabled,
+		"value": map[string]interface{}{
+			"allowedReferrers":   config.AllowedReferrers,
+			"allowEmptyReferrer": config.AllowEmptyReferrer,
+		},
+	}
+
+	_, err = retryCall2(ctx, p.calls, cacheFlyName, OpWrite, p.client.ServiceOptions.UpdateOptions, serviceID, currentOptions)
+	if err != nil {
+		return fmt.Errorf("failed to update hotlink protection: %w", err)
+	}
+
+	return nil
+}
+
+// GetResponseHeaders reads the responseHeaders option
+func (p *CacheFlyProvider) GetResponseHeaders(ctx context.Context, serviceID string) ([]ResponseHeader, error) {
+	options, err := retryCall1(ctx, p.calls, cacheFlyName, OpRead, p.client.ServiceOptions.GetOptions, serviceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current options: %w", err)
+	}
+
+	headers := make([]ResponseHeader, 0)
+	option, ok := options["responseHeaders"].(map[string]interface{})
+	if !ok {
+		return headers, nil
+	}
+	if enabled, _ := option["enabled"].(bool); !enabled {
+		return headers, nil
+	}
+	items, _ := option["value"].([]interface{})
+	for _, item := range items {
+		if h, ok := item.(map[string]interface{}); ok {
+			name, _ := h["name"].(string)
+			value, _ := h["value"].(string)
+			headers = append(headers, ResponseHeader{Name: name, Value: value})
+		}
+	}
+
+	return headers, nil
+}
+
+// UpdateResponseHeaders replaces the responseHeaders option
+func (p *CacheFlyProvider) UpdateResponseHeaders(ctx context.Context, serviceID string, headers []ResponseHeader) error {
+	currentOptions, err := retryCall1(ctx, p.calls, cacheFlyName, OpRead, p.client.ServiceOptions.GetOptions, serviceID)
+	if err != nil {
+		return fmt.Errorf("failed to get current options: %w", err)
+	}
+
+	applyHeaderOptions(currentOptions, headers)
+
+	_, err = retryCall2(ctx, p.calls, cacheFlyName, OpWrite, p.client.ServiceOptions.UpdateOptions, serviceID, currentOptions)
+	if err != nil {
+		return fmt.Errorf("failed to update response headers: %w", err)
+	}
+
+	return nil
+}
+
+func applyHeaderOptions(options api.ServiceOptions, headers []ResponseHeader) {
+	value := make([]interface{}, 0, len(headers))
+	for _, h := range headers {
+		value = append(value, map[string]interface{}{"name": h.Name, "value": h.Value})
+	}
+	options["responseHeaders"] = map[string]interface{}{
+		"enabled": len(headers) > 0,
+		"value":   value,
+	}
+}
+
+// TLSSupport reports the protocol settings CacheFly options can express
+func (p *CacheFlyProvider) TLSSupport() TLSSupport {
+	return TLSSupport{MinVersions: []string{"1.0", "1.1", "1.2", "1.3"}, HTTP2: true, HTTP3: true}
+}
+
+// GetTLSPolicy reads tlsMinVersion, http2 and http3 from the service options
+func (p *CacheFlyProvider) GetTLSPolicy(ctx context.Context, serviceID string) (*TLSPolicy, error) {
+	options, err := retryCall1(ctx, p.calls, cacheFlyName, OpRead, p.client.ServiceOptions.GetOptions, serviceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current options: %w", err)
+	}
+
+	policy := &TLSPolicy{}
+	if tls, ok := options["tlsMinVersion"].(map[string]interface{}); ok {
+		if enabled, _ := tls["enabled"].(bool); enabled {
+			policy.MinVersion, _ = tls["value"].(string)
+		}
+	}
+	for key, toggle := range map[string]**bool{"http2": &policy.HTTP2, "http3": &policy.HTTP3} {
+		if option, ok := options[key].(map[string]interface{}); ok {
+			enabled, _ := option["enabled"].(bool)
+			*toggle = &enabled
+		}
+	}
+
+	return policy, nil
+}
+
+// UpdateTLSPolicy writes tlsMinVersion, http2 and http3 options
+func (p *CacheFlyProvider) UpdateTLSPolicy(ctx context.Context, serviceID string, policy TLSPolicy) error {
+	currentOptions, err := retryCall1(ctx, p.calls, cacheFlyName, OpRead, p.client.ServiceOptions.GetOptions, serviceID)
+	if err != nil {
+		return fmt.Errorf("failed to get current options: %w", err)
+	}
+
+	applyTLSOptions(currentOptions, policy)
+
+	_, err = retryCall2(ctx, p.calls, cacheFlyName, OpWrite, p.client.ServiceOptions.UpdateOptions, serviceID, currentOptions)
+	if err != nil {
+		return fmt.Errorf("failed to update tls policy: %w", err)
+	}
+
+	return nil
+}
+
+func applyTLSOptions(options api.ServiceOptions, policy TLSPolicy) {
+	options["tlsMinVersion"] = map[string]interface{}{
+		"enabled": policy.MinVersion != "",
+		"value":   policy.MinVersion,
+	}
+	if policy.HTTP2 != nil {
+		options["http2"] = map[string]interface{}{"enabled": *policy.HTTP2}
+	}
+	if policy.HTTP3 != nil {
+		options["http3"] = map[string]interface{}{"enabled": *policy.HTTP3}
+	}
+}
+
+// toStrings converts a decoded JSON array into a string slice
+func toStrings(v interface{}) []string {
+	items, _ := v.([]interface{})
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// GetStalePolicy reads servestale and stale-while-revalidate from the service options
+func (p *CacheFlyProvider) GetStalePolicy(ctx context.Context, serviceID string) (*StalePolicy, error) {
+	options, err := retryCall1(ctx, p.calls, cacheFlyName, OpRead, p.client.ServiceOptions.GetOptions, serviceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current options: %w", err)
+	}
+
+	policy := &StalePolicy{}
+	policy.StaleIfError, _ = options["servestale"].(bool)
+	if policy.StaleIfError {
+		policy.SIEMaxStale = optionValue(options["servestale_maxage"])
+	}
+	if swr, ok := options["stalewhilerevalidate"].(map[string]interface{}); ok {
+		policy.StaleWhileRevalidate, _ = swr["enabled"].(bool)
+		if policy.StaleWhileRevalidate {
+			policy.SWRMaxStale = optionValue(swr)
+		}
+	}
+
+	return policy, nil
+}
+
+// UpdateStalePolicy writes servestale and stale-while-revalidate options
+func (p *CacheFlyProvider) UpdateStalePolicy(ctx context.Context, serviceID string, policy StalePolicy) error {
+	currentOptions, err := retryCall1(ctx, p.calls, cacheFlyName, OpRead, p.client.ServiceOptions.GetOptions, serviceID)
+	if err != nil {
+		return fmt.Errorf("failed to get current options: %w", err)
+	}
+
+	applyStaleOptions(currentOptions, policy)
+
+	_, err = retryCall2(ctx, p.calls, cacheFlyName, OpWrite, p.client.ServiceOptions.UpdateOptions, serviceID, currentOptions)
+	if err != nil {
+		return fmt.Errorf("failed to update stale policy: %w", err)
+	}
+
+	return nil
+}
+
+// GetServiceState reads the origin and cache rules from the service options.
+// SSL isn't reported: certificates are managed outside the service options.
+func (p *CacheFlyProvider) GetServiceState(ctx context.Context, serviceID string) (*ServiceState, error) {
+	options, err := retryCall1(ctx, p.calls, cacheFlyName, OpRead, p.client.ServiceOptions.GetOptions, serviceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current options: %w", err)
+	}
+
+	state := &ServiceState{Rules: []CacheRule{}}
+	if proxy, ok := options["reverseProxy"].(map[string]interface{}); ok {
+		state.Origin.Host, _ = proxy["hostname"].(string)
+		scheme, _ := proxy["originScheme"].(string)
+		state.Origin.Protocol = strings.ToLower(scheme)
+	}
+	headers, _ := options["expiryHeaders"].([]interface{})
+	for _, h := range headers {
+		header, ok := h.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		path, _ := header["path"].(string)
+		ttl, _ := header["expiryTime"].(float64)
+		state.Rules = append(state.Rules, CacheRule{Path: path, TTL: int(ttl)})
+	}
+
+	return state, nil
+}
+
+// GetSecuritySettings reads the TLS floor, HSTS and allowed HTTP methods from the service options
+func (p *CacheFlyProvider) GetSecuritySettings(ctx context.Context, serviceID string) (*SecuritySettings, error) {
+	options, err := retryCall1(ctx, p.calls, cacheFlyName, OpRead, p.client.ServiceOptions.GetOptions, serviceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current options: %w", err)
+	}
+
+	settings := &SecuritySettings{AllowedMethods: []string{}}
+	if tls, ok := options["tlsMinVersion"].(map[string]interface{}); ok {
+		if enabled, _ := tls["enabled"].(bool); enabled {
+			settings.MinTLSVersion, _ = tls["value"].(string)
+		}
+	}
+	if hsts, ok := options["hsts"].(map[string]interface{}); ok {
+		settings.HSTS, _ = hsts["enabled"].(bool)
+		settings.HSTSMaxAge = optionValue(hsts)
+	}
+
+	// Without an httpmethods option CacheFly passes every method through
+	methods, ok := options["httpmethods"].(map[string]interface{})
+	if enabled, _ := methods["enabled"].(bool); !ok || !enabled {
+		settings.AllowedMethods = []string{"GET", "HEAD", "POST", "PUT", "DELETE", "PATCH", "OPTIONS"}
+		return settings, nil
+	}
+	allowed, _ := methods["value"].(map[string]interface{})
+	for _, method := range []string{"GET", "HEAD", "POST", "PUT", "DELETE", "PATCH", "OPTIONS"} {
+		if on, _ := allowed[method].(bool); on {
+			settings.AllowedMethods = append(settings.AllowedMethods, method)
+		}
+	}
+
+	return settings, nil
+}
+
+// cacheFlyShieldRegions are the mid-tier locations CacheFly can shield from
+var cacheFlyShieldRegions = []string{"us-east", "us-west", "eu-central", "ap-southeast"}
+
+// OriginLoadSupport reports CacheFly's origin shield and request collapsing support
+func (p *CacheFlyProvider) OriginLoadSupport() OriginLoadSupport {
+	return OriginLoadSupport{
+		Shield:            true,
+		ShieldRegions:     cacheFlyShieldRegions,
+		RequestCoalescing: true,
+	}
+}
+
+// GetOriginLoad reads the originshield and collapse options
+func (p *CacheFlyProvider) GetOriginLoad(ctx context.Context, serviceID string) (*OriginLoadOptions, error) {
+	options, err := retryCall1(ctx, p.calls, cacheFlyName, OpRead, p.client.ServiceOptions.GetOptions, serviceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current options: %w", err)
+	}
+
+	result := &OriginLoadOptions{}
+	if shield, ok := options["originshield"].(map[string]interface{}); ok {
+		result.Shield, _ = shield["enabled"].(bool)
+		if result.Shield {
+			result.ShieldRegion, _ = shield["value"].(string)
+		}
+	}
+	result.RequestCoalescing, _ = options["collapse"].(bool)
+
+	return result, nil
+}
+
+// UpdateOriginLoad writes the originshield and collapse options
+func (p *CacheFlyProvider) UpdateOriginLoad(ctx context.Context, serviceID string, load OriginLoadOptions) error {
+	currentOptions, err := retryCall1(ctx, p.calls, cacheFlyName, OpRead, p.client.ServiceOptions.GetOptions, serviceID)
+	if err != nil {
+		return fmt.Errorf("failed to get current options: %w", err)
+	}
+
+	applyOriginLoadOptions(currentOptions, load)
+
+	_, err = retryCall2(ctx, p.calls, cacheFlyName, OpWrite, p.client.ServiceOptions.UpdateOptions, serviceID, currentOptions)
+	if err != nil {
+		return fmt.Errorf("failed to update origin shield settings: %w", err)
+	}
+
+	return nil
+}
+
+// cacheFlyLogTarget is the log delivery configuration of the CacheFly API
+type cacheFlyLogTarget struct {
+	Enabled bool   `json:"enabled"`
+	Format  string `json:"format"`
+	Type    string `json:"type"` // "cachefly" keeps logs for download, "s3" ships them to a bucket
+	Bucket  string `json:"bucket,omitempty"`
+	Prefix  string `json:"prefix,omitempty"`
+	Region  string `json:"region,omitempty"`
+}
+
+// cacheFlyLogFile is a delivered log file listed by the CacheFly API
+type cacheFlyLogFile struct {
+	Name      string    `json:"name"`
+	URL       string    `json:"downloadUrl"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// GetLogDelivery reads the service's access log delivery target
+func (p *CacheFlyProvider) GetLogDelivery(ctx context.Context, serviceID string) (*LogDelivery, error) {
+	var target cacheFlyLogTarget
+	if err := p.api.do(ctx, http.MethodGet, "/services/"+url.PathEscape(serviceID)+"/logs/target", nil, &target); err != nil {
+		return nil, fmt.Errorf("failed to get log delivery: %w", err)
+	}
+
+	delivery := &LogDelivery{
+		Enabled: target.Enabled,
+		Format:  target.Format,
+		Destination: LogDestination{
+			Type:   LogDestinationProvider,
+			Bucket: target.Bucket,
+			Prefix: target.Prefix,
+			Region: target.Region,
+		},
+	}
+	if target.Type == "s3" {
+		delivery.Destination.Type = LogDestinationS3
+	}
+	return delivery, nil
+}
+
+// UpdateLogDelivery sets the service's access log delivery target
+func (p *CacheFlyProvider) UpdateLogDelivery(ctx context.Context, serviceID string, delivery LogDelivery) error {
+	target := cacheFlyLogTarget{
+		Enabled: delivery.Enabled,
+		Format:  delivery.Format,
+		Type:    "cachefly",
+	}
+	if delivery.Destination.Type == LogDestinationS3 {
+		target.Type = "s3"
+		target.Bucket = delivery.Destination.Bucket
+		target.Prefix = delivery.Destination.Prefix
+		target.Region = delivery.Destination.Region
+	}
+
+	if err := p.api.do(ctx, http.MethodPut, "/services/"+url.PathEscape(serviceID)+"/logs/target", target, nil); err != nil {
+		return fmt.Errorf("failed to update log delivery: %w", err)
+	}
+	return nil
+}
+
+// ListLogFiles lists log files CacheFly kept for download since a point in time
+func (p *CacheFlyProvider) ListLogFiles(ctx context.Context, serviceID string, since time.Time) ([]LogFile, error) {
+	delivery, err := p.GetLogDelivery(ctx, serviceID)
+	if err != nil {
+		return nil, err
+	}
+	if !delivery.Enabled || delivery.Destination.Type != LogDestinationProvider {
+		return []LogFile{}, nil
+	}
+
+	query := url.Values{}
+	if !since.IsZero() {
+		query.Set("from", since.UTC().Format(time.RFC3339))
+	}
+
+	var resp struct {
+		Data []cacheFlyLogFile `json:"data"`
+	}
+	if err := p.api.do(ctx, http.MethodGet, "/services/"+url.PathEscape(serviceID)+"/logs?"+query.Encode(), nil, &resp); err != nil {
+		return nil, fmt.Errorf("failed to list log files: %w", err)
+	}
+
+	files := make([]LogFile, 0, len(resp.Data))
+	for _, f := range resp.Data {
+		files = append(files, LogFile{
+			ServiceID: serviceID,
+			Name:      f.Name,
+			URL:       f.URL,
+			Size:      f.Size,
+			Gzipped:   strings.HasSuffix(f.Name, ".gz"),
+			Format:    delivery.Format,
+			CreatedAt: f.CreatedAt,
+		})
+	}
+	return files, nil
+}
+
+// OpenLogFile downloads a delivered log file
+func (p *CacheFlyProvider) OpenLogFile(ctx context.Context, file LogFile) (io.ReadCloser, error) {
+	body, err := p.api.open(ctx, file.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download log file %s: %w", file.Name, err)
+	}
+	return body, nil
+}
+
+// applyOriginLoadOptions maps origin shield and request coalescing onto CacheFly options
+func applyOriginLoadOptions(options api.ServiceOptions, load OriginLoadOptions) {
+	options["originshield"] = map[string]interface{}{
+		"enabled": load.Shield,
+		"value":   load.ShieldRegion,
+	}
+	options["collapse"] = load.RequestCoalescing
+}
+
+// applyStaleOptions maps a stale policy onto CacheFly options
+func applyStaleOptions(options api.ServiceOptions, policy StalePolicy) {
+	options["servestale"] = policy.StaleIfError
+	options["servestale_maxage"] = map[string]interface{}{
+		"enabled": policy.StaleIfError && policy.SIEMaxStale > 0,
+		"value":   policy.SIEMaxStale,
+	}
+	options["stalewhilerevalidate"] = map[string]interface{}{
+		"enabled": policy.StaleWhileRevalidate,
+		"value":   maxStaleOrDefault(policy.SWRMaxStale),
+	}
+}
+
+// applyFailoverOptions maps backup origins and their health check onto the
+// CacheFly originFailover option; backups are tried in priority order
+func applyFailoverOptions(options api.ServiceOptions, failover OriginFailover) {
+	origins := make([]interface{}, 0, len(failover.Backups))
+	for i, backup := range failover.Backups {
+		scheme := "HTTPS"
+		if backup.Protocol != "" {
+			scheme = strings.ToUpper(backup.Protocol)
+		}
+		origin := map[string]interface{}{
+			"hostname":     backup.Host,
+			"originScheme": scheme,
+			"priority":     i + 1,
+		}
+		if backup.Port != 0 {
+			origin["port"] = backup.Port
+		}
+		origins = append(origins, origin)
+	}
+
+	check := failover.HealthCheck.WithDefaults()
+	options["originFailover"] = map[string]interface{}{
+		"enabled": true,
+		"origins": origins,
+		"healthCheck": map[string]interface{}{
+			"path":             check.Path,
+			"interval":         check.Interval,
+			"timeout":          check.Timeout,
+			"failureThreshold": check.FailureThreshold,
+		},
+	}
+}
+
+// optionValue returns the numeric value of an {"enabled", "value"} option, 0 if disabled
+func optionValue(v interface{}) int {
+	option, ok := v.(map[string]interface{})
+	if !ok {
+		return 0
+	}
+	if enabled, _ := option["enabled"].(bool); !enabled {
+		return 0
+	}
+	value, _ := option["value"].(float64)
+	return int(value)
+}
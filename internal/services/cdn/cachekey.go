@@ -0,0 +1,133 @@
+package cdn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNotSupported is returned when the provider can't do what was asked
+var ErrNotSupported = errors.New("not supported by provider")
+
+// Query string handling in the cache key
+const (
+	QueryParamsAll     = "all"     // every query param is part of the key (default)
+	QueryParamsNone    = "none"    // query string is ignored
+	QueryParamsInclude = "include" // only the listed params are part of the key
+	QueryParamsExclude = "exclude" // all params except the listed ones are part of the key
+)
+
+// CacheKeyConfig controls what makes two requests hit the same cache entry
+type CacheKeyConfig struct {
+	QueryParams    string   `json:"query_params"`               // all, none, include, exclude
+	QueryParamList []string `json:"query_param_list,omitempty"` // params for include/exclude
+	VaryHeaders    []string `json:"vary_headers,omitempty"`
+	VaryCookies    []string `json:"vary_cookies,omitempty"`
+	DeviceSplit    bool     `json:"device_split"` // separate entries for desktop/mobile/tablet
+}
+
+// CacheKeySupport describes which cache-key features a provider can map
+type CacheKeySupport struct {
+	QueryParamModes []string `json:"query_param_modes"`
+	VaryHeaders     bool     `json:"vary_headers"`
+	VaryCookies     bool     `json:"vary_cookies"`
+	DeviceSplit     bool     `json:"device_split"`
+}
+
+// CacheKeyConfigurer is implemented by providers that expose cache-key settings
+type CacheKeyConfigurer interface {
+	CacheKeySupport() CacheKeySupport
+	GetCacheKey(ctx context.Context, serviceID string) (*CacheKeyConfig, error)
+	UpdateCacheKey(ctx context.Context, serviceID string, config CacheKeyConfig) error
+}
+
+// Validate checks the config is well-formed, independent of the provider
+func (c *CacheKeyConfig) Validate() error {
+	if c.QueryParams == "" {
+		c.QueryParams = QueryParamsAll
+	}
+
+	switch c.QueryParams {
+	case QueryParamsAll, QueryParamsNone:
+		if len(c.QueryParamList) > 0 {
+			return fmt.Errorf("query_param_list is only valid with include or exclude")
+		}
+	case QueryParamsInclude, QueryParamsExclude:
+		if len(c.QueryParamList) == 0 {
+			return fmt.Errorf("query_param_list is required with %s", c.QueryParams)
+		}
+	default:
+		return fmt.Errorf("invalid query_params %q (expected all, none, include or exclude)", c.QueryParams)
+	}
+
+	for _, list := range [][]string{c.QueryParamList, c.VaryHeaders, c.VaryCookies} {
+		for _, name := range list {
+			if strings.TrimSpace(name) == "" || strings.ContainsAny(name, " \t,;=") {
+				return fmt.Errorf("invalid name %q in cache key config", name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Check returns an error wrapping ErrNotSupported for the first feature the
+// provider can't map
+func (s CacheKeySupport) Check(c CacheKeyConfig) error {
+	supported := false
+	for _, mode := range s.QueryParamModes {
+		if mode == c.QueryParams {
+			supported = true
+		}
+	}
+	if !supported {
+		return fmt.Errorf("query_params %q: %w", c.QueryParams, ErrNotSupported)
+	}
+	if len(c.VaryHeaders) > 0 && !s.VaryHeaders {
+		return fmt.Errorf("vary_headers: %w", ErrNotSupported)
+	}
+	if len(c.VaryCookies) > 0 && !s.VaryCookies {
+		return fmt.Errorf("vary_cookies: %w", ErrNotSupported)
+	}
+	if c.DeviceSplit && !s.DeviceSplit {
+		return fmt.Errorf("device_split: %w", ErrNotSupported)
+	}
+	return nil
+}
+
+// CacheKeySupport returns what the provider supports (nothing if it has no cache-key settings)
+func (s *Service) CacheKeySupport() CacheKeySupport {
+	configurer, ok := s.provider.(CacheKeyConfigurer)
+	if !ok {
+		return CacheKeySupport{QueryParamModes: []string{}}
+	}
+	return configurer.CacheKeySupport()
+}
+
+// GetCacheKey returns the cache-key config of a service
+func (s *Service) GetCacheKey(ctx context.Context, serviceID string) (*CacheKeyConfig, error) {
+	configurer, ok := s.provider.(CacheKeyConfigurer)
+	if !ok {
+		return nil, fmt.Errorf("cache key configuration: %w", ErrNotSupported)
+	}
+	return configurer.GetCacheKey(ctx, serviceID)
+}
+
+// UpdateCacheKey validates the config against the provider's support and applies it
+func (s *Service) UpdateCacheKey(ctx context.Context, serviceID string, config CacheKeyConfig) error {
+	configurer, ok := s.provider.(CacheKeyConfigurer)
+	if !ok {
+		return fmt.Errorf("cache key configuration: %w", ErrNotSupported)
+	}
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	if err := configurer.CacheKeySupport().Check(config); err != nil {
+		return err
+	}
+	if err := s.guardChange(ctx, serviceID, "cache_key"); err != nil {
+		return err
+	}
+	return configurer.UpdateCacheKey(ctx, serviceID, config)
+}
@@ -0,0 +1,46 @@
+package cdn
+
+import (
+	"sync"
+	"time"
+)
+
+// CallPolicy governs every call to provider APIs: rate budgets, retries, the
+// per-provider circuit breakers and the mutation journal. Providers created
+// for one deployment share a policy.
+type CallPolicy struct {
+	limiter  RateLimiter // nil = no rate budgets
+	retries  map[OperationClass]RetryPolicy
+	journal  *Journal // nil = mutations aren't journaled
+	breaker  BreakerSettings
+	breakers map[string]*breaker
+	mu       sync.Mutex // guards breakers
+	now      func() time.Time
+}
+
+// NewCallPolicy creates a call policy. Classes missing from retries use
+// DefaultRetryPolicies; limiter and journal may be nil.
+func NewCallPolicy(limiter RateLimiter, retries map[OperationClass]RetryPolicy, settings BreakerSettings, journal *Journal) *CallPolicy {
+	policies := make(map[OperationClass]RetryPolicy, len(DefaultRetryPolicies))
+	for class, policy := range DefaultRetryPolicies {
+		policies[class] = policy
+	}
+	for class, policy := range retries {
+		policies[class] = policy
+	}
+
+	return &CallPolicy{
+		limiter:  limiter,
+		retries:  policies,
+		journal:  journal,
+		breaker:  settings,
+		breakers: make(map[string]*breaker),
+		now:      time.Now,
+	}
+}
+
+// DefaultCallPolicy returns a policy with the default retries and breaker,
+// no rate budgets and no journal
+func DefaultCallPolicy() *CallPolicy {
+	return NewCallPolicy(nil, nil, DefaultBreakerSettings, nil)
+}
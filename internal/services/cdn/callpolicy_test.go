@@ -0,0 +1,307 @@
+package cdn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestCallPolicy(t *testing.T) {
+	unavailable := &APIError{Provider: "test", StatusCode: http.StatusServiceUnavailable}
+
+	tests := []struct {
+		name      string
+		retries   map[OperationClass]RetryPolicy
+		breaker   BreakerSettings
+		failures  int // failing calls made before the checked one
+		wantCalls int // attempts of the checked call
+		wantState string
+		wantErr   error
+	}{
+		{
+			name:      "retries a transient failure",
+			retries:   map[OperationClass]RetryPolicy{OpRead: {MaxAttempts: 3}},
+			breaker:   DefaultBreakerSettings,
+			wantCalls: 3,
+			wantState: BreakerClosed,
+			wantErr:   unavailable,
+		},
+		{
+			name:      "open breaker refuses calls",
+			retries:   map[OperationClass]RetryPolicy{OpRead: {MaxAttempts: 1}},
+			breaker:   BreakerSettings{Threshold: 2, Cooldown: time.Hour},
+			failures:  2,
+			wantCalls: 0,
+			wantState: BreakerOpen,
+			wantErr:   ErrProviderUnavailable,
+		},
+		{
+			name:      "breaker disabled",
+			retries:   map[OperationClass]RetryPolicy{OpRead: {MaxAttempts: 1}},
+			failures:  5,
+			wantCalls: 1,
+			wantErr:   unavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := NewCallPolicy(nil, tt.retries, tt.breaker, nil)
+			fail := func() error { return unavailable }
+			for i := 0; i < tt.failures; i++ {
+				calls.withRetry(context.Background(), "test", OpRead, fail)
+			}
+
+			attempts := 0
+			err := calls.withRetry(context.Background(), "test", OpRead, func() error {
+				attempts++
+				return fail()
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+			if attempts != tt.wantCalls {
+				t.Errorf("attempts = %d, want %d", attempts, tt.wantCalls)
+			}
+			if got := calls.BreakerStates()["test"].State; got != tt.wantState {
+				t.Errorf("breaker = %q, want %q", got, tt.wantState)
+			}
+			if states := DefaultCallPolicy().BreakerStates(); len(states) != 0 {
+				t.Errorf("a new policy sees breakers %v", states)
+			}
+		})
+	}
+}
+
+func TestBreakerTransitions(t *testing.T) {
+	unavailable := &APIError{Provider: "test", StatusCode: http.StatusServiceUnavailable}
+	notFound := &APIError{Provider: "test", StatusCode: http.StatusNotFound}
+
+	// each step advances the clock, then asks the breaker for a call and, if
+	// it is let through, records err as its outcome
+	type step struct {
+		advance     time.Duration
+		err         error
+		wantRefused bool
+		wantState   string
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "opens after threshold consecutive failures",
+			steps: []step{
+				{err: unavailable, wantState: BreakerClosed},
+				{err: unavailable, wantState: BreakerOpen},
+				{advance: time.Minute - time.Second, wantRefused: true, wantState: BreakerOpen},
+			},
+		},
+		{
+			name: "a success resets the failure count",
+			steps: []step{
+				{err: unavailable, wantState: BreakerClosed},
+				{wantState: BreakerClosed},
+				{err: unavailable, wantState: BreakerClosed},
+			},
+		},
+		{
+			name: "client errors mean the provider is up",
+			steps: []step{
+				{err: notFound, wantState: BreakerClosed},
+				{err: notFound, wantState: BreakerClosed},
+				{err: notFound, wantState: BreakerClosed},
+			},
+		},
+		{
+			name: "cancelled calls are ignored",
+			steps: []step{
+				{err: unavailable, wantState: BreakerClosed},
+				{err: context.Canceled, wantState: BreakerClosed},
+				{err: context.DeadlineExceeded, wantState: BreakerClosed},
+				{err: unavailable, wantState: BreakerOpen},
+			},
+		},
+		{
+			name: "successful probe closes the breaker",
+			steps: []step{
+				{err: unavailable},
+				{err: unavailable, wantState: BreakerOpen},
+				{advance: time.Minute, wantState: BreakerClosed},
+				{err: unavailable, wantState: BreakerClosed},
+			},
+		},
+		{
+			name: "failed probe reopens the breaker for another cooldown",
+			steps: []step{
+				{err: unavailable},
+				{err: unavailable, wantState: BreakerOpen},
+				{advance: time.Minute, err: unavailable, wantState: BreakerOpen},
+				{advance: time.Minute - time.Second, wantRefused: true, wantState: BreakerOpen},
+				{advance: time.Second, wantState: BreakerClosed},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Date(2025, time.January, 1, 12, 0, 0, 0, time.UTC)
+			calls := NewCallPolicy(nil, nil, BreakerSettings{Threshold: 2, Cooldown: time.Minute}, nil)
+			calls.now = func() time.Time { return now }
+
+			for i, s := range tt.steps {
+				now = now.Add(s.advance)
+				err := calls.allowCall("test")
+				if refused := err != nil; refused != s.wantRefused {
+					t.Fatalf("step %d: allowCall = %v, want refused %v", i, err, s.wantRefused)
+				}
+				if err == nil {
+					calls.recordCall("test", s.err)
+				} else if !errors.Is(err, ErrProviderUnavailable) {
+					t.Fatalf("step %d: allowCall = %v, want ErrProviderUnavailable", i, err)
+				}
+				if s.wantState == "" {
+					continue
+				}
+				if got := calls.BreakerStates()["test"].State; got != s.wantState {
+					t.Fatalf("step %d: breaker = %q, want %q", i, got, s.wantState)
+				}
+			}
+		})
+	}
+}
+
+func TestBreakerSingleProbe(t *testing.T) {
+	now := time.Date(2025, time.January, 1, 12, 0, 0, 0, time.UTC)
+	calls := NewCallPolicy(nil, nil, BreakerSettings{Threshold: 1, Cooldown: time.Minute}, nil)
+	calls.now = func() time.Time { return now }
+
+	if err := calls.allowCall("test"); err != nil {
+		t.Fatalf("allowCall = %v", err)
+	}
+	calls.recordCall("test", &APIError{Provider: "test", StatusCode: http.StatusBadGateway})
+
+	now = now.Add(time.Minute)
+	if got := calls.BreakerStates()["test"].State; got != BreakerHalfOpen {
+		t.Fatalf("breaker after cooldown = %q, want %q", got, BreakerHalfOpen)
+	}
+	if err := calls.allowCall("test"); err != nil {
+		t.Fatalf("probe refused: %v", err)
+	}
+	var unavailable *UnavailableError
+	if err := calls.allowCall("test"); !errors.As(err, &unavailable) {
+		t.Fatalf("second call while probing = %v, want UnavailableError", err)
+	}
+	if got := calls.BreakerStates()["test"].State; got != BreakerOpen {
+		t.Errorf("breaker while probing = %q, want %q", got, BreakerOpen)
+	}
+	if other := calls.allowCall("other"); other != nil {
+		t.Errorf("other provider refused: %v", other)
+	}
+}
+
+// timeoutError is a net.Error that timed out
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestRetryable(t *testing.T) {
+	dial := func(errno syscall.Errno) error {
+		return &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", errno)}
+	}
+
+	tests := []struct {
+		name           string
+		err            error
+		wantRead       bool
+		wantWrite      bool
+		wantRetryAfter time.Duration
+	}{
+		{
+			name:           "rate limited",
+			err:            &APIError{StatusCode: http.StatusTooManyRequests, RetryAfter: 3 * time.Second},
+			wantRead:       true,
+			wantWrite:      true,
+			wantRetryAfter: 3 * time.Second,
+		},
+		{name: "service unavailable", err: &APIError{StatusCode: http.StatusServiceUnavailable}, wantRead: true, wantWrite: true},
+		{name: "server error", err: fmt.Errorf("failed to update: %w", &APIError{StatusCode: http.StatusInternalServerError}), wantRead: true},
+		{name: "not found", err: &APIError{StatusCode: http.StatusNotFound}},
+		{name: "connection refused", err: dial(syscall.ECONNREFUSED), wantRead: true, wantWrite: true},
+		{name: "connection reset", err: dial(syscall.ECONNRESET), wantRead: true},
+		{name: "dropped response", err: fmt.Errorf("read body: %w", io.ErrUnexpectedEOF), wantRead: true},
+		{name: "network timeout", err: &net.OpError{Op: "read", Err: timeoutError{}}, wantRead: true},
+		{name: "caller cancelled", err: fmt.Errorf("request: %w", context.Canceled)},
+		{name: "status only in the message", err: errors.New("upstream said 503 service unavailable")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			read, retryAfter := retryable(OpRead, tt.err)
+			write, _ := retryable(OpWrite, tt.err)
+			if read != tt.wantRead || write != tt.wantWrite {
+				t.Errorf("retryable() read = %v, write = %v, want %v, %v", read, write, tt.wantRead, tt.wantWrite)
+			}
+			if retryAfter != tt.wantRetryAfter {
+				t.Errorf("retry after = %s, want %s", retryAfter, tt.wantRetryAfter)
+			}
+		})
+	}
+}
+
+func TestRetryCallClassifiesSDKErrors(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	// an SDK that reports failures without their status code
+	errSDK := errors.New("sdk: request failed")
+	client := newProviderHTTPClient()
+	get := func(ctx context.Context, id string) (string, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/services/"+id, nil)
+		if err != nil {
+			return "", err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return "", err
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return "", errSDK
+		}
+		return id, nil
+	}
+
+	calls := NewCallPolicy(nil, map[OperationClass]RetryPolicy{OpRead: {MaxAttempts: 3}}, DefaultBreakerSettings, nil)
+	_, err := retryCall1(context.Background(), calls, "test", OpRead, get, "svc-1")
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound || apiErr.Path != "/services/svc-1" {
+		t.Fatalf("err = %v, want a 404 APIError for /services/svc-1", err)
+	}
+	if !errors.Is(err, errSDK) {
+		t.Errorf("err = %v doesn't wrap the SDK error", err)
+	}
+	// the 503 was retried, the 404 wasn't
+	if requests != 2 {
+		t.Errorf("requests = %d, want 2", requests)
+	}
+}
@@ -0,0 +1,52 @@
+package cdn
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// CallStats counts one provider's calls since the process started
+type CallStats struct {
+	Calls     int64   `json:"calls"`
+	Failures  int64   `json:"failures"` // provider-side failures, as the breaker counts them
+	ErrorRate float64 `json:"error_rate"`
+}
+
+var (
+	callStats   = make(map[string]*CallStats)
+	callStatsMu sync.Mutex
+)
+
+// countCall tallies the outcome of a provider call
+func countCall(provider string, err error) {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return
+	}
+
+	callStatsMu.Lock()
+	defer callStatsMu.Unlock()
+
+	stats, ok := callStats[provider]
+	if !ok {
+		stats = &CallStats{}
+		callStats[provider] = stats
+	}
+	stats.Calls++
+	if err != nil && providerFailure(err) {
+		stats.Failures++
+	}
+	stats.ErrorRate = float64(stats.Failures) / float64(stats.Calls)
+}
+
+// ProviderCallStats returns the call counts of every provider that has been called
+func ProviderCallStats() map[string]CallStats {
+	callStatsMu.Lock()
+	defer callStatsMu.Unlock()
+
+	stats := make(map[string]CallStats, len(callStats))
+	for provider, s := range callStats {
+		stats[provider] = *s
+	}
+	return stats
+}
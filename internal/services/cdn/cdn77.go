@@ -0,0 +1,504 @@
+package cdn
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/avvvet/cdnbuddy-api/internal/domain"
+)
+
+const cdn77BaseURL = "https://api.cdn77.com/v3"
+
+// CDN77Provider implements CDNProvider interface for CDN77 CDN resources
+type CDN77Provider struct {
+	api *httpAdapter
+}
+
+type cdn77Resource struct {
+	ID       int      `json:"id"`
+	Label    string   `json:"label"`
+	URL      string   `json:"url"` // default hostname, e.g. 1234567890.rsc.cdn77.org
+	OriginID string   `json:"origin_id"`
+	CNAMEs   []string `json:"cnames"`
+	Disabled bool     `json:"disabled"`
+
+	QueryString   cdn77QueryString `json:"query_string"`
+	IPProtection  cdn77Protection  `json:"ip_protection"`
+	GeoProtection cdn77Protection  `json:"geo_protection"`
+}
+
+// cdn77Protection is an IP or country access list of a CDN resource
+type cdn77Protection struct {
+	Type      string   `json:"type"` // disabled, blacklist, whitelist
+	IPs       []string `json:"ips,omitempty"`
+	Countries []string `json:"countries,omitempty"`
+}
+
+// cdn77QueryString controls which query params CDN77 ignores in the cache key
+type cdn77QueryString struct {
+	IgnoreType string   `json:"ignore_type"` // none, all, list
+	Parameters []string `json:"parameters,omitempty"`
+}
+
+// NewCDN77Provider creates a new CDN77 provider whose calls follow calls
+// (DefaultCallPolicy if nil)
+func NewCDN77Provider(calls *CallPolicy) (*CDN77Provider, error) {
+	token := os.Getenv("CDN77_API_TOKEN")
+	if token == "" {
+		return nil, fmt.Errorf("CDN77_API_TOKEN environment variable is required")
+	}
+
+	api := newHTTPAdapter("cdn77", cdn77BaseURL, calls, func(req *http.Request) {
+		req.Header.Set("Authorization", "Bearer "+token)
+	})
+
+	return &CDN77Provider{api: api}, nil
+}
+
+// CreateService creates an URL origin and a CDN resource in front of it
+func (p *CDN77Provider) CreateService(ctx context.Context, config *ServiceConfig) (*domain.CDNService, error) {
+	serviceName := generateServiceName(config.Name)
+
+	// Step 1: Create origin
+	var origin struct {
+		ID string `json:"id"`
+	}
+	if err := p.api.do(ctx, http.MethodPost, "/origin/url", cdn77OriginRequest(serviceName, config.Origin), &origin); err != nil {
+		return nil, fmt.Errorf("failed to create CDN77 origin: %w", err)
+	}
+
+	// Step 2: Create CDN resource
+	var resource cdn77Resource
+	if err := p.api.do(ctx, http.MethodPost, "/cdn", cdn77ResourceRequest(serviceName, origin.ID, config.Rules), &resource); err != nil {
+		return nil, fmt.Errorf("failed to create CDN77 resource: %w", err)
+	}
+
+	service := p.toService(resource)
+	service.Config = buildProviderConfigJSON(domain.ProviderCDN77, service.ID, resource.URL, &config.Origin)
+	return &service, nil
+}
+
+// ListServices lists the active CDN resources
+func (p *CDN77Provider) ListServices(ctx context.Context) ([]domain.CDNService, error) {
+	return collectServicesByStatus(ctx, p, StatusActive)
+}
+
+// ListServicesByStatus lists the CDN resources that pass the status filter
+func (p *CDN77Provider) ListServicesByStatus(ctx context.Context, status StatusFilter) ([]domain.CDNService, error) {
+	return collectServicesByStatus(ctx, p, status)
+}
+
+// ForEachService streams all CDN resources; CDN77 returns every resource in one response
+func (p *CDN77Provider) ForEachService(ctx context.Context, fn func(svc domain.CDNService) error) error {
+	var resources []cdn77Resource
+	if err := p.api.do(ctx, http.MethodGet, "/cdn", nil, &resources); err != nil {
+		return fmt.Errorf("failed to list services: %w", err)
+	}
+
+	for _, r := range resources {
+		svc := p.toService(r)
+		svc.Config = buildProviderConfigJSON(domain.ProviderCDN77, svc.ID, r.URL, nil)
+		if err := fn(svc); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// UpdateService updates origin and default cache expiry of a CDN resource
+func (p *CDN77Provider) UpdateService(ctx context.Context, serviceID string, config *ServiceConfig) error {
+	if err := p.UpdateOriginSettings(ctx, serviceID, config.Origin); err != nil {
+		return fmt.Errorf("failed to update service: %w", err)
+	}
+
+	if err := p.UpdateCacheRules(ctx, serviceID, config.Rules); err != nil {
+		return fmt.Errorf("failed to update service: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteService deletes a CDN resource
+func (p *CDN77Provider) DeleteService(ctx context.Context, serviceID string) error {
+	if err := p.api.do(ctx, http.MethodDelete, "/cdn/"+url.PathEscape(serviceID), nil, nil); err != nil {
+		return fmt.Errorf("failed to delete service: %w", err)
+	}
+
+	return nil
+}
+
+// AddDomain adds a CNAME to the CDN resource
+func (p *CDN77Provider) AddDomain(ctx context.Context, serviceID, domainName string) error {
+	resource, err := p.getResource(ctx, serviceID)
+	if err != nil {
+		return err
+	}
+
+	for _, cname := range resource.CNAMEs {
+		if cname == domainName {
+			return nil
+		}
+	}
+
+	if err := p.setCNAMEs(ctx, serviceID, append(resource.CNAMEs, domainName)); err != nil {
+		return fmt.Errorf("failed to add domain %s: %w", domainName, err)
+	}
+
+	return nil
+}
+
+// RemoveDomain removes a CNAME from the CDN resource
+func (p *CDN77Provider) RemoveDomain(ctx context.Context, serviceID, domainName string) error {
+	resource, err := p.getResource(ctx, serviceID)
+	if err != nil {
+		return err
+	}
+
+	cnames := make([]string, 0, len(resource.CNAMEs))
+	for _, cname := range resource.CNAMEs {
+		if cname != domainName {
+			cnames = append(cnames, cname)
+		}
+	}
+	if len(cnames) == len(resource.CNAMEs) {
+		return fmt.Errorf("domain %s not found", domainName)
+	}
+
+	if err := p.setCNAMEs(ctx, serviceID, cnames); err != nil {
+		return fmt.Errorf("failed to remove domain: %w", err)
+	}
+
+	return nil
+}
+
+// ListDomains lists the CNAMEs of a CDN resource
+func (p *CDN77Provider) ListDomains(ctx context.Context, serviceID string) ([]domain.Domain, error) {
+	return collectDomains(ctx, p, serviceID)
+}
+
+// ForEachDomain streams the CNAMEs of a CDN resource
+func (p *CDN77Provider) ForEachDomain(ctx context.Context, serviceID string, fn func(d domain.Domain) error) error {
+	resource, err := p.getResource(ctx, serviceID)
+	if err != nil {
+		return err
+	}
+
+	for _, cname := range resource.CNAMEs {
+		err := fn(domain.Domain{
+			ID:           cname,
+			CDNServiceID: serviceID,
+			Name:         cname,
+			Status:       "active",
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PurgeCache purges specific paths from the CDN resource
+func (p *CDN77Provider) PurgeCache(ctx context.Context, serviceID string, paths []string) error {
+	req := map[string]interface{}{
+		"paths": paths,
+	}
+
+	if err := p.api.do(ctx, http.MethodPost, "/cdn/"+url.PathEscape(serviceID)+"/job/purge", req, nil); err != nil {
+		return fmt.Errorf("failed to purge cache: %w", err)
+	}
+
+	return nil
+}
+
+// PurgeAll purges the complete CDN resource cache
+func (p *CDN77Provider) PurgeAll(ctx context.Context, serviceID string) error {
+	if err := p.api.do(ctx, http.MethodPost, "/cdn/"+url.PathEscape(serviceID)+"/job/purge-all", nil, nil); err != nil {
+		return fmt.Errorf("failed to purge all cache: %w", err)
+	}
+
+	return nil
+}
+
+// GetMetrics retrieves hit ratio and request statistics for the last 24 hours
+func (p *CDN77Provider) GetMetrics(ctx context.Context, serviceID string) (*domain.Metrics, error) {
+	end := time.Now()
+	req := map[string]interface{}{
+		"cdn_ids": []string{serviceID},
+		"from":    end.Add(-24 * time.Hour).Unix(),
+		"to":      end.Unix(),
+	}
+
+	var hitRatio struct {
+		Data map[string]float64 `json:"data"`
+	}
+	if err := p.api.do(ctx, http.MethodPost, "/stats/hit-ratio", req, &hitRatio); err != nil {
+		return nil, fmt.Errorf("failed to get metrics: %w", err)
+	}
+
+	var requests struct {
+		Data map[string]float64 `json:"data"`
+	}
+	if err := p.api.do(ctx, http.MethodPost, "/stats/requests", req, &requests); err != nil {
+		return nil, fmt.Errorf("failed to get metrics: %w", err)
+	}
+
+	metrics := &domain.Metrics{
+		CDNServiceID: serviceID,
+		Timestamp:    end,
+	}
+
+	// Stats are returned as time series keyed by timestamp
+	var ratioSum float64
+	for _, v := range hitRatio.Data {
+		ratioSum += v
+	}
+	if len(hitRatio.Data) > 0 {
+		metrics.CacheHitRatio = ratioSum / float64(len(hitRatio.Data)) / 100
+	}
+	for _, v := range requests.Data {
+		metrics.TotalRequests += int64(v)
+	}
+
+	return metrics, nil
+}
+
+// UpdateCacheRules sets the default cache expiry; CDN77 resources have a single max-age
+func (p *CDN77Provider) UpdateCacheRules(ctx context.Context, serviceID string, rules []CacheRule) error {
+	if len(rules) == 0 {
+		return nil
+	}
+
+	if err := p.api.do(ctx, http.MethodPatch, "/cdn/"+url.PathEscape(serviceID), cdn77CacheRequest(rules), nil); err != nil {
+		return fmt.Errorf("failed to update cache rules: %w", err)
+	}
+
+	return nil
+}
+
+// DryRunCreateService returns the origin and resource CreateService would create
+func (p *CDN77Provider) DryRunCreateService(ctx context.Context, config *ServiceConfig) (*DryRun, error) {
+	serviceName := generateServiceName(config.Name)
+	return newDryRun("cdn77", "create_service", "",
+		ProviderRequest{Method: http.MethodPost, Path: "/origin/url", Body: cdn77OriginRequest(serviceName, config.Origin)},
+		ProviderRequest{Method: http.MethodPost, Path: "/cdn", Body: cdn77ResourceRequest(serviceName, "{new_origin_id}", config.Rules)},
+	), nil
+}
+
+// DryRunUpdateService returns the origin and cache updates UpdateService would
+// send; the resource is read to find its origin
+func (p *CDN77Provider) DryRunUpdateService(ctx context.Context, serviceID string, config *ServiceConfig) (*DryRun, error) {
+	resource, err := p.getResource(ctx, serviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	run := newDryRun("cdn77", "update_service", serviceID,
+		ProviderRequest{Method: http.MethodPatch, Path: "/origin/url/" + resource.OriginID, Body: cdn77OriginRequest(resource.Label, config.Origin)},
+	)
+	if len(config.Rules) > 0 {
+		run.Requests = append(run.Requests, ProviderRequest{Method: http.MethodPatch, Path: "/cdn/" + serviceID, Body: cdn77CacheRequest(config.Rules)})
+	}
+	return run, nil
+}
+
+// DryRunCacheRules returns the max-age update UpdateCacheRules would send
+func (p *CDN77Provider) DryRunCacheRules(ctx context.Context, serviceID string, rules []CacheRule) (*DryRun, error) {
+	run := newDryRun("cdn77", "update_cache_rules", serviceID)
+	if len(rules) > 0 {
+		run.Requests = append(run.Requests, ProviderRequest{Method: http.MethodPatch, Path: "/cdn/" + serviceID, Body: cdn77CacheRequest(rules)})
+	}
+	return run, nil
+}
+
+// cdn77ResourceRequest is the CDN resource payload for a new service
+func cdn77ResourceRequest(label, originID string, rules []CacheRule) map[string]interface{} {
+	req := map[string]interface{}{
+		"label":     label,
+		"origin_id": originID,
+	}
+	if len(rules) > 0 {
+		req["cache"] = map[string]interface{}{"max_age": ttlMinutes(rules[0].TTL)}
+	}
+	return req
+}
+
+// cdn77CacheRequest sets the single max-age CDN77 resources have
+func cdn77CacheRequest(rules []CacheRule) map[string]interface{} {
+	return map[string]interface{}{
+		"cache": map[string]interface{}{"max_age": ttlMinutes(rules[0].TTL)},
+	}
+}
+
+// UpdateOriginSettings updates the URL origin behind the CDN resource
+func (p *CDN77Provider) UpdateOriginSettings(ctx context.Context, serviceID string, origin OriginConfig) error {
+	resource, err := p.getResource(ctx, serviceID)
+	if err != nil {
+		return err
+	}
+
+	if err := p.api.do(ctx, http.MethodPatch, "/origin/url/"+url.PathEscape(resource.OriginID), cdn77OriginRequest(resource.Label, origin), nil); err != nil {
+		return fmt.Errorf("failed to update origin settings: %w", err)
+	}
+
+	return nil
+}
+
+// CacheKeySupport reports the cache-key features CDN77 resources can express
+func (p *CDN77Provider) CacheKeySupport() CacheKeySupport {
+	return CacheKeySupport{
+		QueryParamModes: []string{QueryParamsAll, QueryParamsNone, QueryParamsExclude},
+	}
+}
+
+// GetCacheKey reads the resource's query string settings
+func (p *CDN77Provider) GetCacheKey(ctx context.Context, serviceID string) (*CacheKeyConfig, error) {
+	resource, err := p.getResource(ctx, serviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &CacheKeyConfig{QueryParams: QueryParamsAll}
+	switch resource.QueryString.IgnoreType {
+	case "all":
+		config.QueryParams = QueryParamsNone
+	case "list":
+		config.QueryParams = QueryParamsExclude
+		config.QueryParamList = resource.QueryString.Parameters
+	}
+
+	return config, nil
+}
+
+// UpdateCacheKey maps the query param mode onto CDN77's query_string ignore type
+func (p *CDN77Provider) UpdateCacheKey(ctx context.Context, serviceID string, config CacheKeyConfig) error {
+	qs := cdn77QueryString{IgnoreType: "none"}
+	switch config.QueryParams {
+	case QueryParamsNone:
+		qs.IgnoreType = "all"
+	case QueryParamsExclude:
+		qs.IgnoreType = "list"
+		qs.Parameters = config.QueryParamList
+	}
+
+	req := map[string]interface{}{
+		"query_string": qs,
+	}
+	if err := p.api.do(ctx, http.MethodPatch, "/cdn/"+url.PathEscape(serviceID), req, nil); err != nil {
+		return fmt.Errorf("failed to update cache key: %w", err)
+	}
+
+	return nil
+}
+
+// FirewallSupport reports CDN77's IP and country access lists
+func (p *CDN77Provider) FirewallSupport() FirewallSupport {
+	return FirewallSupport{IPBlocking: true, CountryBlocking: true, ManagedRuleSets: []string{}}
+}
+
+// GetFirewall reads the resource's IP and country blocklists
+func (p *CDN77Provider) GetFirewall(ctx context.Context, serviceID string) (*FirewallConfig, error) {
+	resource, err := p.getResource(ctx, serviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &FirewallConfig{BlockedIPs: []string{}, BlockedCountries: []string{}, ManagedRuleSets: []string{}}
+	if resource.IPProtection.Type == "blacklist" {
+		config.BlockedIPs = resource.IPProtection.IPs
+	}
+	if resource.GeoProtection.Type == "blacklist" {
+		config.BlockedCountries = resource.GeoProtection.Countries
+	}
+	return config, nil
+}
+
+// UpdateFirewall replaces the resource's IP and country blocklists. Resources
+// using allowlists are left alone rather than silently opened up.
+func (p *CDN77Provider) UpdateFirewall(ctx context.Context, serviceID string, config FirewallConfig) error {
+	resource, err := p.getResource(ctx, serviceID)
+	if err != nil {
+		return err
+	}
+	if resource.IPProtection.Type == "whitelist" || resource.GeoProtection.Type == "whitelist" {
+		return fmt.Errorf("resource %s uses an allowlist; change it in the CDN77 console", serviceID)
+	}
+
+	req := map[string]interface{}{
+		"ip_protection":  cdn77Blocklist(config.BlockedIPs, false),
+		"geo_protection": cdn77Blocklist(config.BlockedCountries, true),
+	}
+	if err := p.api.do(ctx, http.MethodPatch, "/cdn/"+url.PathEscape(serviceID), req, nil); err != nil {
+		return fmt.Errorf("failed to update firewall: %w", err)
+	}
+
+	return nil
+}
+
+// cdn77Blocklist is the protection payload for a blocklist; empty disables it
+func cdn77Blocklist(items []string, countries bool) cdn77Protection {
+	if len(items) == 0 {
+		return cdn77Protection{Type: "disabled"}
+	}
+	if countries {
+		return cdn77Protection{Type: "blacklist", Countries: items}
+	}
+	return cdn77Protection{Type: "blacklist", IPs: items}
+}
+
+// Helper functions
+
+func (p *CDN77Provider) getResource(ctx context.Context, serviceID string) (*cdn77Resource, error) {
+	var resource cdn77Resource
+	if err := p.api.do(ctx, http.MethodGet, "/cdn/"+url.PathEscape(serviceID), nil, &resource); err != nil {
+		return nil, fmt.Errorf("failed to get CDN resource: %w", err)
+	}
+	return &resource, nil
+}
+
+func (p *CDN77Provider) setCNAMEs(ctx context.Context, serviceID string, cnames []string) error {
+	req := map[string]interface{}{
+		"cnames": cnames,
+	}
+	return p.api.do(ctx, http.MethodPatch, "/cdn/"+url.PathEscape(serviceID), req, nil)
+}
+
+func (p *CDN77Provider) toService(r cdn77Resource) domain.CDNService {
+	status := "ACTIVE"
+	if r.Disabled {
+		status = "INACTIVE"
+	}
+
+	return domain.CDNService{
+		ID:       fmt.Sprintf("%d", r.ID),
+		Provider: domain.ProviderCDN77,
+		Name:     r.Label,
+		Status:   status,
+	}
+}
+
+func cdn77OriginRequest(label string, origin OriginConfig) map[string]interface{} {
+	scheme := "https"
+	if origin.Protocol != "" {
+		scheme = strings.ToLower(origin.Protocol)
+	}
+
+	req := map[string]interface{}{
+		"label":  label,
+		"scheme": scheme,
+		"host":   origin.Host,
+	}
+	if origin.Port != 0 {
+		req["port"] = origin.Port
+	}
+	if origin.Path != "" {
+		req["base_dir"] = origin.Path
+	}
+	return req
+}
@@ -0,0 +1,123 @@
+package cdn
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// Certificate is a custom TLS certificate for a service's domains: the leaf
+// certificate followed by its intermediates, and the leaf's private key, in PEM
+type Certificate struct {
+	CertificatePEM string `json:"certificate"`
+	PrivateKeyPEM  string `json:"private_key"`
+}
+
+// CertificateInfo describes an uploaded certificate; the key is never returned
+type CertificateInfo struct {
+	Subject     string    `json:"subject"`
+	Issuer      string    `json:"issuer"`
+	DNSNames    []string  `json:"dns_names"`
+	NotBefore   time.Time `json:"not_before"`
+	NotAfter    time.Time `json:"not_after"`
+	Fingerprint string    `json:"fingerprint"` // SHA-256 of the leaf
+	ChainLength int       `json:"chain_length"`
+
+	// Domains of the service the certificate doesn't cover
+	UncoveredDomains []string `json:"uncovered_domains,omitempty"`
+}
+
+// CertificateUploader is implemented by providers that accept custom certificates
+type CertificateUploader interface {
+	UploadCertificate(ctx context.Context, serviceID string, cert Certificate) error
+}
+
+// ParseCertificate checks that a certificate chain is well formed, that each
+// certificate is signed by the next, that the key matches the leaf and that
+// the leaf is valid at now
+func ParseCertificate(cert Certificate, now time.Time) (*CertificateInfo, error) {
+	info, _, err := parseCertificate(cert, now)
+	return info, err
+}
+
+// parseCertificate is ParseCertificate, also returning the leaf
+func parseCertificate(cert Certificate, now time.Time) (*CertificateInfo, *x509.Certificate, error) {
+	pair, err := tls.X509KeyPair([]byte(cert.CertificatePEM), []byte(cert.PrivateKeyPEM))
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid certificate or key: %w", err)
+	}
+
+	chain := make([]*x509.Certificate, 0, len(pair.Certificate))
+	for i, der := range pair.Certificate {
+		c, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid certificate %d in chain: %w", i+1, err)
+		}
+		chain = append(chain, c)
+	}
+	for i := 0; i+1 < len(chain); i++ {
+		if err := chain[i].CheckSignatureFrom(chain[i+1]); err != nil {
+			return nil, nil, fmt.Errorf("certificate %d isn't signed by the next one in the chain: %w", i+1, err)
+		}
+	}
+
+	leaf := chain[0]
+	switch {
+	case now.Before(leaf.NotBefore):
+		return nil, nil, fmt.Errorf("certificate isn't valid until %s", leaf.NotBefore.UTC().Format(time.RFC3339))
+	case now.After(leaf.NotAfter):
+		return nil, nil, fmt.Errorf("certificate expired on %s", leaf.NotAfter.UTC().Format(time.RFC3339))
+	}
+	for i, c := range chain[1:] {
+		if now.After(c.NotAfter) {
+			return nil, nil, fmt.Errorf("intermediate certificate %d expired on %s", i+1, c.NotAfter.UTC().Format(time.RFC3339))
+		}
+	}
+
+	sum := sha256.Sum256(leaf.Raw)
+	return &CertificateInfo{
+		Subject:     leaf.Subject.String(),
+		Issuer:      leaf.Issuer.String(),
+		DNSNames:    leaf.DNSNames,
+		NotBefore:   leaf.NotBefore,
+		NotAfter:    leaf.NotAfter,
+		Fingerprint: hex.EncodeToString(sum[:]),
+		ChainLength: len(chain),
+	}, leaf, nil
+}
+
+// UploadCertificate validates a certificate and installs it on a service.
+// Domains of the service the certificate doesn't cover are reported, not
+// rejected, so a certificate can be uploaded ahead of a domain change.
+func (s *Service) UploadCertificate(ctx context.Context, serviceID string, cert Certificate) (*CertificateInfo, error) {
+	info, leaf, err := parseCertificate(cert, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	uploader, ok := s.provider.(CertificateUploader)
+	if !ok {
+		return nil, fmt.Errorf("certificate upload: %w", ErrNotSupported)
+	}
+
+	domains, err := s.provider.ListDomains(ctx, serviceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list domains: %w", err)
+	}
+	for _, d := range domains {
+		if leaf.VerifyHostname(d.Name) != nil {
+			info.UncoveredDomains = append(info.UncoveredDomains, d.Name)
+		}
+	}
+
+	if err := s.guardChange(ctx, serviceID, "certificate"); err != nil {
+		return nil, err
+	}
+	if err := uploader.UploadCertificate(ctx, serviceID, cert); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
@@ -0,0 +1,107 @@
+package cdn_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/avvvet/cdnbuddy-api/internal/services/cdn"
+)
+
+// testCert is a generated certificate with its key, in PEM
+type testCert struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM string
+	keyPEM  string
+}
+
+// issueCert creates a certificate for name valid over [notBefore, notAfter],
+// signed by parent or self-signed when parent is nil
+func issueCert(t *testing.T, name string, isCA bool, notBefore, notAfter time.Time, parent *testCert) *testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+	}
+	if !isCA {
+		template.DNSNames = []string{name}
+	}
+	signer, signerKey := template, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+	return &testCert{
+		cert:    cert,
+		key:     key,
+		certPEM: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		keyPEM:  string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})),
+	}
+}
+
+func TestParseCertificate(t *testing.T) {
+	now := time.Now()
+	nb, na := now.Add(-time.Hour), now.Add(90*24*time.Hour)
+
+	ca := issueCert(t, "Test CA", true, now.Add(-time.Hour), now.Add(365*24*time.Hour), nil)
+	otherCA := issueCert(t, "Other CA", true, now.Add(-time.Hour), now.Add(365*24*time.Hour), nil)
+	leaf := issueCert(t, "cdn.example.com", false, nb, na, ca)
+	expired := issueCert(t, "cdn.example.com", false, now.Add(-48*time.Hour), now.Add(-24*time.Hour), ca)
+	future := issueCert(t, "cdn.example.com", false, now.Add(24*time.Hour), now.Add(48*time.Hour), ca)
+	stray := issueCert(t, "cdn.example.com", false, nb, na, otherCA)
+
+	tests := []struct {
+		name      string
+		cert      cdn.Certificate
+		wantChain int
+		wantErr   string
+	}{
+		{name: "leaf and intermediate", cert: cdn.Certificate{CertificatePEM: leaf.certPEM + ca.certPEM, PrivateKeyPEM: leaf.keyPEM}, wantChain: 2},
+		{name: "leaf only", cert: cdn.Certificate{CertificatePEM: leaf.certPEM, PrivateKeyPEM: leaf.keyPEM}, wantChain: 1},
+		{name: "expired", cert: cdn.Certificate{CertificatePEM: expired.certPEM, PrivateKeyPEM: expired.keyPEM}, wantErr: "expired"},
+		{name: "not yet valid", cert: cdn.Certificate{CertificatePEM: future.certPEM, PrivateKeyPEM: future.keyPEM}, wantErr: "isn't valid until"},
+		{name: "key of another certificate", cert: cdn.Certificate{CertificatePEM: leaf.certPEM, PrivateKeyPEM: stray.keyPEM}, wantErr: "invalid certificate or key"},
+		{name: "broken chain", cert: cdn.Certificate{CertificatePEM: stray.certPEM + ca.certPEM, PrivateKeyPEM: stray.keyPEM}, wantErr: "isn't signed by the next one"},
+		{name: "not PEM", cert: cdn.Certificate{CertificatePEM: "certificate", PrivateKeyPEM: "key"}, wantErr: "invalid certificate or key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := cdn.ParseCertificate(tt.cert, now)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseCertificate() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseCertificate() error = %v", err)
+			}
+			if info.ChainLength != tt.wantChain || info.Fingerprint == "" || len(info.DNSNames) != 1 {
+				t.Errorf("info = %+v, want a chain of %d with a fingerprint and one name", info, tt.wantChain)
+			}
+		})
+	}
+}
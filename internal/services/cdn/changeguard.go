@@ -0,0 +1,178 @@
+package cdn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrChangesPaused is returned for automated changes to a service whose
+// changes were paused by the change guard
+var ErrChangesPaused = errors.New("automated changes to this service are paused")
+
+// Change sources; anything but SourceChat counts as automated
+const (
+	SourceAPI      = "api"      // REST API calls, the default
+	SourceSchedule = "schedule" // scheduled plans
+	SourceChat     = "chat"     // plans a user confirmed in chat
+)
+
+type changeSourceKey struct{}
+
+// WithChangeSource tags configuration changes made with ctx with their source
+func WithChangeSource(ctx context.Context, source string) context.Context {
+	return context.WithValue(ctx, changeSourceKey{}, source)
+}
+
+// ChangeSource returns the source set by WithChangeSource, SourceAPI if unset
+func ChangeSource(ctx context.Context) string {
+	if source, ok := ctx.Value(changeSourceKey{}).(string); ok && source != "" {
+		return source
+	}
+	return SourceAPI
+}
+
+// Pause is a service whose automated changes are blocked because one of its
+// options changed more often than the guard allows
+type Pause struct {
+	ServiceID string        `json:"service_id"`
+	Option    string        `json:"option"`  // the option that tripped the guard
+	Changes   int           `json:"changes"` // changes to it within Window
+	Window    time.Duration `json:"window"`
+	Source    string        `json:"source"`
+	PausedAt  time.Time     `json:"paused_at"`
+}
+
+// ChangeGuard stops automation loops, such as an integration toggling an
+// option back and forth, by pausing automated changes to a service once an
+// option changes more than maxChanges times within window. Changes confirmed
+// in chat are neither counted nor blocked, so the owner can still fix things.
+type ChangeGuard struct {
+	maxChanges int
+	window     time.Duration
+	onPause    func(Pause)
+	changes    map[string][]time.Time // service ID + option -> recent automated changes
+	paused     map[string]Pause       // service ID -> pause
+	mu         sync.Mutex
+	now        func() time.Time
+}
+
+// NewChangeGuard creates a guard; onPause is called once per pause to alert the owner
+func NewChangeGuard(maxChanges int, window time.Duration, onPause func(Pause)) *ChangeGuard {
+	if maxChanges <= 0 {
+		maxChanges = 10
+	}
+	if window <= 0 {
+		window = time.Hour
+	}
+	return &ChangeGuard{
+		maxChanges: maxChanges,
+		window:     window,
+		onPause:    onPause,
+		changes:    make(map[string][]time.Time),
+		paused:     make(map[string]Pause),
+		now:        time.Now,
+	}
+}
+
+// Allow records an automated change of an option and reports whether it may
+// go ahead. It returns ErrChangesPaused while the service is paused.
+func (g *ChangeGuard) Allow(serviceID, option, source string) error {
+	if source == SourceChat {
+		return nil
+	}
+
+	now := g.now()
+	g.mu.Lock()
+	if pause, ok := g.paused[serviceID]; ok {
+		g.mu.Unlock()
+		return fmt.Errorf("%w: %s changed %d times within %s; acknowledge the pause to resume",
+			ErrChangesPaused, pause.Option, pause.Changes, pause.Window)
+	}
+
+	key := serviceID + "/" + option
+	recent := g.changes[key][:0]
+	for _, t := range g.changes[key] {
+		if now.Sub(t) < g.window {
+			recent = append(recent, t)
+		}
+	}
+	recent = append(recent, now)
+	g.changes[key] = recent
+
+	if len(recent) <= g.maxChanges {
+		g.mu.Unlock()
+		return nil
+	}
+
+	pause := Pause{
+		ServiceID: serviceID,
+		Option:    option,
+		Changes:   len(recent),
+		Window:    g.window,
+		Source:    source,
+		PausedAt:  now,
+	}
+	g.paused[serviceID] = pause
+	g.mu.Unlock()
+
+	logrus.WithFields(logrus.Fields{
+		"service_id": serviceID,
+		"option":     option,
+		"changes":    pause.Changes,
+		"source":     source,
+	}).Warn("🔁 Option changing too often, automated changes paused")
+	if g.onPause != nil {
+		g.onPause(pause)
+	}
+
+	return fmt.Errorf("%w: %s changed %d times within %s; acknowledge the pause to resume",
+		ErrChangesPaused, option, pause.Changes, g.window)
+}
+
+// Pauses returns the paused services, most recent first
+func (g *ChangeGuard) Pauses() []Pause {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	pauses := make([]Pause, 0, len(g.paused))
+	for _, pause := range g.paused {
+		pauses = append(pauses, pause)
+	}
+	sort.Slice(pauses, func(i, j int) bool { return pauses[i].PausedAt.After(pauses[j].PausedAt) })
+	return pauses
+}
+
+// Acknowledge resumes automated changes to a service and forgets its recent
+// changes; it reports whether the service was paused
+func (g *ChangeGuard) Acknowledge(serviceID string) (*Pause, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	pause, ok := g.paused[serviceID]
+	if !ok {
+		return nil, false
+	}
+	delete(g.paused, serviceID)
+	for key := range g.changes {
+		if strings.HasPrefix(key, serviceID+"/") {
+			delete(g.changes, key)
+		}
+	}
+	return &pause, true
+}
+
+// guardChange checks a change of a service option against the change guard.
+// Simulated changes never reach the provider, so they aren't counted.
+func (s *Service) guardChange(ctx context.Context, serviceID, option string) error {
+	if s.simulation != nil || s.guard == nil {
+		return nil
+	}
+	return s.guard.Allow(serviceID, option, ChangeSource(ctx))
+}
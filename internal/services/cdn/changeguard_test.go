@@ -0,0 +1,107 @@
+package cdn
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestChangeGuard(t *testing.T) {
+	type change struct {
+		advance   time.Duration
+		serviceID string
+		option    string
+		source    string
+		wantErr   error
+	}
+	tests := []struct {
+		name       string
+		changes    []change
+		wantPauses int
+	}{
+		{
+			name: "pauses once an option changes too often",
+			changes: []change{
+				{serviceID: "svc-1", option: "cache_ttl", source: SourceAPI},
+				{serviceID: "svc-1", option: "cache_ttl", source: SourceAPI},
+				{serviceID: "svc-1", option: "cache_ttl", source: SourceSchedule, wantErr: ErrChangesPaused},
+				{serviceID: "svc-1", option: "gzip", source: SourceAPI, wantErr: ErrChangesPaused},
+				{serviceID: "svc-2", option: "cache_ttl", source: SourceAPI},
+			},
+			wantPauses: 1,
+		},
+		{
+			name: "changes outside the window are forgotten",
+			changes: []change{
+				{serviceID: "svc-1", option: "cache_ttl", source: SourceAPI},
+				{serviceID: "svc-1", option: "cache_ttl", source: SourceAPI},
+				{advance: time.Hour, serviceID: "svc-1", option: "cache_ttl", source: SourceAPI},
+				{serviceID: "svc-1", option: "cache_ttl", source: SourceAPI},
+			},
+		},
+		{
+			name: "options are counted separately",
+			changes: []change{
+				{serviceID: "svc-1", option: "cache_ttl", source: SourceAPI},
+				{serviceID: "svc-1", option: "cache_ttl", source: SourceAPI},
+				{serviceID: "svc-1", option: "gzip", source: SourceAPI},
+				{serviceID: "svc-1", option: "gzip", source: SourceAPI},
+			},
+		},
+		{
+			name: "chat changes are neither counted nor blocked",
+			changes: []change{
+				{serviceID: "svc-1", option: "cache_ttl", source: SourceChat},
+				{serviceID: "svc-1", option: "cache_ttl", source: SourceChat},
+				{serviceID: "svc-1", option: "cache_ttl", source: SourceAPI},
+				{serviceID: "svc-1", option: "cache_ttl", source: SourceAPI},
+				{serviceID: "svc-1", option: "cache_ttl", source: SourceAPI, wantErr: ErrChangesPaused},
+				{serviceID: "svc-1", option: "cache_ttl", source: SourceChat},
+			},
+			wantPauses: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Date(2025, time.January, 1, 12, 0, 0, 0, time.UTC)
+			var alerts []Pause
+			guard := NewChangeGuard(2, time.Hour, func(p Pause) { alerts = append(alerts, p) })
+			guard.now = func() time.Time { return now }
+
+			for i, c := range tt.changes {
+				now = now.Add(c.advance)
+				err := guard.Allow(c.serviceID, c.option, c.source)
+				if !errors.Is(err, c.wantErr) {
+					t.Fatalf("change %d: Allow = %v, want %v", i, err, c.wantErr)
+				}
+			}
+			if len(guard.Pauses()) != tt.wantPauses {
+				t.Errorf("pauses = %v, want %d", guard.Pauses(), tt.wantPauses)
+			}
+			if len(alerts) != tt.wantPauses {
+				t.Errorf("onPause called %d times, want %d", len(alerts), tt.wantPauses)
+			}
+		})
+	}
+}
+
+func TestChangeGuardAcknowledge(t *testing.T) {
+	guard := NewChangeGuard(1, time.Hour, nil)
+	guard.Allow("svc-1", "cache_ttl", SourceAPI)
+	if err := guard.Allow("svc-1", "cache_ttl", SourceAPI); !errors.Is(err, ErrChangesPaused) {
+		t.Fatalf("Allow = %v, want ErrChangesPaused", err)
+	}
+
+	pause, ok := guard.Acknowledge("svc-1")
+	if !ok || pause.Option != "cache_ttl" || pause.Changes != 2 {
+		t.Fatalf("Acknowledge = %+v, %v", pause, ok)
+	}
+	if _, ok := guard.Acknowledge("svc-1"); ok {
+		t.Error("second Acknowledge reported a pause")
+	}
+	// the count restarts, so one more change is allowed
+	if err := guard.Allow("svc-1", "cache_ttl", SourceAPI); err != nil {
+		t.Errorf("Allow after acknowledge = %v", err)
+	}
+}
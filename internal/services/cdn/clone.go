@@ -0,0 +1,160 @@
+package cdn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/avvvet/cdnbuddy-api/internal/domain"
+)
+
+// ServiceCloner is implemented by providers that can copy every option of a
+// service verbatim, including settings CDNBuddy has no neutral model for
+type ServiceCloner interface {
+	CloneService(ctx context.Context, serviceID, name string) (*domain.CDNService, error)
+}
+
+// CloneResult describes a cloned service and which settings made it across
+type CloneResult struct {
+	SourceID string             `json:"source_id"`
+	Service  *domain.CDNService `json:"service"`
+	Copied   []string           `json:"copied"`
+	Skipped  []string           `json:"skipped,omitempty"` // settings left at their defaults, with the reason
+	Domains  []string           `json:"domains"`
+}
+
+// CloneService copies the configuration of a service into a new service on
+// the same provider, e.g. to promote staging to production. Domains are not
+// copied since a domain can only serve one service; the new ones are added.
+// Settings that fail to copy after the service is created are reported as
+// skipped rather than undoing the clone.
+func (s *Service) CloneService(ctx context.Context, serviceID, name string, domains []string) (*CloneResult, error) {
+	if name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	source, err := s.findSpecService(ctx, ServiceSpec{ServiceID: serviceID})
+	if err != nil {
+		return nil, err
+	}
+	provider := s.providerOf(*source)
+
+	result := &CloneResult{SourceID: source.ID, Copied: make([]string, 0), Domains: make([]string, 0)}
+	if cloner, ok := provider.(ServiceCloner); ok && supports[ServiceCloner](provider) {
+		if result.Service, err = cloner.CloneService(ctx, source.ID, name); err != nil {
+			return nil, fmt.Errorf("failed to clone service: %w", err)
+		}
+		result.Copied = append(result.Copied, "options")
+	} else if err := s.cloneSettings(ctx, provider, *source, name, result); err != nil {
+		return nil, err
+	}
+
+	for _, d := range domains {
+		d = normalizeDomain(d)
+		if err := provider.AddDomain(ctx, result.Service.ID, d); err != nil {
+			return result, fmt.Errorf("failed to add domain %s: %w", d, err)
+		}
+		result.Domains = append(result.Domains, d)
+	}
+	return result, nil
+}
+
+// cloneSettings creates the clone from the source's neutral settings and then
+// copies every optional capability the provider has
+func (s *Service) cloneSettings(ctx context.Context, provider CDNProvider, source domain.CDNService, name string, result *CloneResult) error {
+	export, err := s.ExportService(ctx, source.ID)
+	if err != nil {
+		return err
+	}
+	config := &ServiceConfig{
+		Name:   name,
+		Origin: export.Spec.Origin,
+		Rules:  export.Spec.Rules,
+		SSL:    export.Spec.SSL,
+	}
+	result.Copied = append(result.Copied, "origin", "ssl")
+	if supports[ServiceInspector](provider) {
+		result.Copied = append(result.Copied, "cache_rules")
+	} else {
+		result.Skipped = append(result.Skipped, "cache_rules: not reported by the provider")
+	}
+
+	// Settings the create request carries are read up front, so a failure
+	// here leaves nothing half-created
+	if c, ok := provider.(StalePolicyConfigurer); ok && supports[StalePolicyConfigurer](provider) {
+		if config.Stale, err = c.GetStalePolicy(ctx, source.ID); err != nil {
+			return fmt.Errorf("failed to read stale policy: %w", err)
+		}
+		result.Copied = append(result.Copied, "stale")
+	}
+	if c, ok := provider.(HeaderConfigurer); ok && supports[HeaderConfigurer](provider) {
+		if config.Headers, err = c.GetResponseHeaders(ctx, source.ID); err != nil {
+			return fmt.Errorf("failed to read response headers: %w", err)
+		}
+		result.Copied = append(result.Copied, "headers")
+	}
+	if c, ok := provider.(TLSPolicyConfigurer); ok && supports[TLSPolicyConfigurer](provider) {
+		if config.TLS, err = c.GetTLSPolicy(ctx, source.ID); err != nil {
+			return fmt.Errorf("failed to read tls policy: %w", err)
+		}
+		result.Copied = append(result.Copied, "tls")
+	}
+
+	if err := s.checkServiceConfig(config); err != nil {
+		return err
+	}
+	if result.Service, err = provider.CreateService(ctx, config); err != nil {
+		return fmt.Errorf("failed to create service: %w", err)
+	}
+
+	copySetting := func(setting string, supported bool, copy func(from, to string) error) {
+		if !supported {
+			return
+		}
+		if err := copy(source.ID, result.Service.ID); err != nil {
+			if !errors.Is(err, ErrNotSupported) {
+				result.Skipped = append(result.Skipped, fmt.Sprintf("%s: %v", setting, err))
+			}
+			return
+		}
+		result.Copied = append(result.Copied, setting)
+	}
+
+	copySetting("cache_key", supports[CacheKeyConfigurer](provider), func(from, to string) error {
+		c := provider.(CacheKeyConfigurer)
+		config, err := c.GetCacheKey(ctx, from)
+		if err != nil {
+			return err
+		}
+		return c.UpdateCacheKey(ctx, to, *config)
+	})
+	copySetting("origin_load", supports[OriginLoadConfigurer](provider), func(from, to string) error {
+		c := provider.(OriginLoadConfigurer)
+		options, err := c.GetOriginLoad(ctx, from)
+		if err != nil {
+			return err
+		}
+		return c.UpdateOriginLoad(ctx, to, *options)
+	})
+	copySetting("firewall", supports[FirewallConfigurer](provider), func(from, to string) error {
+		c := provider.(FirewallConfigurer)
+		config, err := c.GetFirewall(ctx, from)
+		if err != nil {
+			return err
+		}
+		return c.UpdateFirewall(ctx, to, *config)
+	})
+	copySetting("hotlink", supports[HotlinkConfigurer](provider), func(from, to string) error {
+		c := provider.(HotlinkConfigurer)
+		config, err := c.GetHotlinkProtection(ctx, from)
+		if err != nil {
+			return err
+		}
+		return c.UpdateHotlinkProtection(ctx, to, *config)
+	})
+
+	// Log delivery points at per-service destinations; sharing them would mix logs
+	if supports[LogDeliveryConfigurer](provider) {
+		result.Skipped = append(result.Skipped, "log_delivery: destinations are per service, configure them on the clone")
+	}
+	return nil
+}
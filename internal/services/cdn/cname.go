@@ -0,0 +1,57 @@
+package cdn
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/avvvet/cdnbuddy-api/internal/domain"
+)
+
+// CNAMETargetFunc returns the hostname DNS instructions tell users to point a
+// service's domains at, given the provider's; e.g. an org's vanity hostname
+type CNAMETargetFunc func(ctx context.Context, serviceID, providerTarget string) string
+
+type cnameTargetKey struct{}
+
+// WithCNAMETarget makes setups run with ctx show targets from fn
+func WithCNAMETarget(ctx context.Context, fn CNAMETargetFunc) context.Context {
+	return context.WithValue(ctx, cnameTargetKey{}, fn)
+}
+
+// cnameTargetFor returns the target to show for a service, the provider's by default
+func cnameTargetFor(ctx context.Context, serviceID, providerTarget string) string {
+	if fn, ok := ctx.Value(cnameTargetKey{}).(CNAMETargetFunc); ok && fn != nil {
+		return fn(ctx, serviceID, providerTarget)
+	}
+	return providerTarget
+}
+
+// providerConfig is the part of a service's config naming its provider hostname
+type providerConfig struct {
+	CNAMETarget string `json:"cname_target"`
+	UniqueName  string `json:"unique_name"`
+	TestURL     string `json:"test_url"`
+}
+
+// ProviderCNAMETarget returns the provider hostname a service's domains point
+// at, read from its config; CacheFly's {uniqueName}.cachefly.net by default
+func ProviderCNAMETarget(svc domain.CDNService) string {
+	var config providerConfig
+	json.Unmarshal([]byte(svc.Config), &config)
+	if config.CNAMETarget != "" {
+		return config.CNAMETarget
+	}
+	return config.UniqueName + ".cachefly.net"
+}
+
+// ProviderTestURL returns the URL that reaches a service through the CDN
+// before any of its domains point at it
+func ProviderTestURL(svc domain.CDNService) string {
+	var config providerConfig
+	json.Unmarshal([]byte(svc.Config), &config)
+	if config.TestURL != "" {
+		return strings.TrimSuffix(config.TestURL, "/")
+	}
+	return "https://" + ProviderCNAMETarget(svc)
+}
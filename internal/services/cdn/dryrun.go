@@ -0,0 +1,92 @@
+package cdn
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/avvvet/cdnbuddy-api/internal/models"
+)
+
+// ProviderRequest is one write a change sends to the provider API
+type ProviderRequest struct {
+	Method string      `json:"method"`
+	Path   string      `json:"path"`
+	Body   interface{} `json:"body,omitempty"`
+}
+
+// DryRun lists the requests a change would send, computed without sending
+// them. Reads needed to compute a payload (e.g. current options that are
+// merged) still go to the provider.
+type DryRun struct {
+	Provider  string            `json:"provider"`
+	Operation string            `json:"operation"`
+	ServiceID string            `json:"service_id,omitempty"`
+	Requests  []ProviderRequest `json:"requests"`
+	Input     interface{}       `json:"input,omitempty"` // for simulated writes the provider can't describe as requests
+}
+
+func newDryRun(provider, operation, serviceID string, requests ...ProviderRequest) *DryRun {
+	return &DryRun{
+		Provider:  provider,
+		Operation: operation,
+		ServiceID: serviceID,
+		Requests:  requests,
+	}
+}
+
+// DryRunCreateService returns the requests CreateService would send
+func (s *Service) DryRunCreateService(ctx context.Context, config *ServiceConfig) (*DryRun, error) {
+	if err := s.checkServiceConfig(config); err != nil {
+		return nil, err
+	}
+	return s.provider.DryRunCreateService(ctx, config)
+}
+
+// DryRunUpdateService returns the requests UpdateService would send
+func (s *Service) DryRunUpdateService(ctx context.Context, serviceID string, config *ServiceConfig) (*DryRun, error) {
+	if err := s.checkServiceConfig(config); err != nil {
+		return nil, err
+	}
+	return s.provider.DryRunUpdateService(ctx, serviceID, config)
+}
+
+// DryRunCacheRules returns the requests UpdateCacheRules would send
+func (s *Service) DryRunCacheRules(ctx context.Context, serviceID string, rules []CacheRule) (*DryRun, error) {
+	if err := validateRules(rules); err != nil {
+		return nil, err
+	}
+	return s.provider.DryRunCacheRules(ctx, serviceID, rules)
+}
+
+// DryRunIntent returns the requests executing an intent would send, for the
+// execution plan. Actions without a dry run return nil and no error.
+func (s *Service) DryRunIntent(ctx context.Context, intent *models.IntentResponse) (*DryRun, error) {
+	if intent.Action == nil {
+		return nil, fmt.Errorf("no action specified")
+	}
+
+	if name := getParam(intent.Parameters, "provider"); name != "" && s.registry != nil {
+		scoped, err := s.ForProvider(ParseProvider(name))
+		if err != nil {
+			return nil, err
+		}
+		return scoped.DryRunIntent(ctx, intent)
+	}
+
+	switch *intent.Action {
+	case "SETUP_CDN":
+		config, err := setupConfigFromParams(intent.Parameters)
+		if err != nil {
+			return nil, err
+		}
+		return s.DryRunCreateService(ctx, config)
+	case "UPDATE_CACHE_RULES":
+		serviceID := getParam(intent.Parameters, "service_id")
+		rules, err := parseRulesParam(getParam(intent.Parameters, "rules"))
+		if serviceID == "" || err != nil {
+			return nil, fmt.Errorf("missing required parameters")
+		}
+		return s.DryRunCacheRules(ctx, serviceID, rules)
+	}
+	return nil, nil
+}
@@ -0,0 +1,146 @@
+package cdn
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/avvvet/cdnbuddy-api/internal/domain"
+)
+
+// ServiceExport is a provider-neutral copy of a service's configuration. Its
+// spec can be sent back to Apply, on this provider or another one.
+type ServiceExport struct {
+	ServiceID  string             `json:"service_id"`
+	Provider   domain.CDNProvider `json:"provider"`
+	ExportedAt time.Time          `json:"exported_at"`
+	Spec       ServiceSpec        `json:"spec"`
+	Warnings   []string           `json:"warnings,omitempty"` // settings that couldn't be read
+}
+
+// ExportService reads the current configuration of a service as a spec.
+// Certificates and private keys are never exported.
+func (s *Service) ExportService(ctx context.Context, serviceID string) (*ServiceExport, error) {
+	existing, err := s.findSpecService(ctx, ServiceSpec{ServiceID: serviceID})
+	if err != nil {
+		return nil, err
+	}
+
+	export := &ServiceExport{
+		ServiceID:  existing.ID,
+		Provider:   existing.Provider,
+		ExportedAt: time.Now(),
+		Spec:       ServiceSpec{Name: existing.Name, Domains: make([]string, 0)},
+	}
+
+	provider := s.providerOf(*existing)
+	if inspector, ok := provider.(ServiceInspector); ok && supports[ServiceInspector](provider) {
+		state, err := inspector.GetServiceState(ctx, existing.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read service state: %w", err)
+		}
+		export.Spec.Origin = state.Origin
+		export.Spec.Rules = state.Rules
+		if state.SSL != nil {
+			export.Spec.SSL = SSLConfig{Enabled: state.SSL.Enabled}
+		} else {
+			export.Spec.SSL = SSLConfig{Enabled: true}
+			export.Warnings = append(export.Warnings, "ssl: not reported by the provider; exported as enabled")
+		}
+	} else {
+		export.Spec.Origin = originFromServiceConfig(existing.Config)
+		export.Spec.SSL = SSLConfig{Enabled: true}
+		export.Warnings = append(export.Warnings,
+			"cache_rules: not reported by the provider; left out so applying the spec keeps the current rules",
+			"ssl: not reported by the provider; exported as enabled")
+	}
+	if export.Spec.Origin.Host == "" {
+		export.Warnings = append(export.Warnings, "origin: unknown, set spec.origin.host before applying")
+	}
+
+	domains, err := provider.ListDomains(ctx, existing.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list domains: %w", err)
+	}
+	for _, d := range domains {
+		export.Spec.Domains = append(export.Spec.Domains, d.Name)
+	}
+
+	return export, nil
+}
+
+// originFromServiceConfig reads the origin we stored in a service's config JSON
+func originFromServiceConfig(config string) OriginConfig {
+	var data struct {
+		Origin OriginConfig `json:"origin"`
+	}
+	json.Unmarshal([]byte(config), &data)
+	if data.Origin.Protocol == "" {
+		data.Origin.Protocol = "https"
+	}
+	return data.Origin
+}
+
+var hclIdentifier = regexp.MustCompile(`[^a-zA-Z0-9_]+`)
+
+// RenderTerraform renders an export as a Terraform locals block. Terraform
+// providers for CDNs don't share a schema, so the values are kept neutral
+// for mapping onto whichever provider's resources the user adopts.
+func RenderTerraform(export *ServiceExport) string {
+	spec := export.Spec
+	name := strings.Trim(hclIdentifier.ReplaceAllString(strings.ToLower(spec.Name), "_"), "_")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "service_" + name
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Exported by CDNBuddy from %s service %s on %s\n", export.Provider, export.ServiceID, export.ExportedAt.UTC().Format(time.RFC3339))
+	for _, w := range export.Warnings {
+		fmt.Fprintf(&b, "# Warning: %s\n", w)
+	}
+	b.WriteString("locals {\n")
+	fmt.Fprintf(&b, "  %s = {\n", name)
+	fmt.Fprintf(&b, "    name = %s\n", hclString(spec.Name))
+
+	b.WriteString("    origin = {\n")
+	fmt.Fprintf(&b, "      host     = %s\n", hclString(spec.Origin.Host))
+	fmt.Fprintf(&b, "      protocol = %s\n", hclString(originProtocol(spec.Origin)))
+	if spec.Origin.Port != 0 {
+		fmt.Fprintf(&b, "      port     = %d\n", spec.Origin.Port)
+	}
+	if spec.Origin.Path != "" {
+		fmt.Fprintf(&b, "      path     = %s\n", hclString(spec.Origin.Path))
+	}
+	b.WriteString("    }\n")
+
+	domains := make([]string, 0, len(spec.Domains))
+	for _, d := range spec.Domains {
+		domains = append(domains, hclString(d))
+	}
+	fmt.Fprintf(&b, "    domains = [%s]\n", strings.Join(domains, ", "))
+
+	if spec.Rules != nil {
+		b.WriteString("    cache_rules = [\n")
+		for _, r := range spec.Rules {
+			fmt.Fprintf(&b, "      { path = %s, ttl = %d, browser_ttl = %d, always_cache = %t },\n",
+				hclString(r.Path), r.TTL, r.BrowserTTL, r.AlwaysCache)
+		}
+		b.WriteString("    ]\n")
+	}
+
+	fmt.Fprintf(&b, "    ssl = { enabled = %t }\n", spec.SSL.Enabled)
+	b.WriteString("  }\n")
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// hclString quotes s for HCL, escaping template sequences
+func hclString(s string) string {
+	quoted := strconv.Quote(s)
+	quoted = strings.ReplaceAll(quoted, "${", "$${")
+	return strings.ReplaceAll(quoted, "%{", "%%{")
+}
@@ -0,0 +1,94 @@
+package cdn
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxBackupOrigins caps the failover chain behind the primary origin
+const maxBackupOrigins = 3
+
+// OriginFailover sends traffic to backup origins, in order, while the primary
+// origin fails its health checks
+type OriginFailover struct {
+	Backups     []OriginConfig `json:"backups"`
+	HealthCheck HealthCheck    `json:"health_check"`
+}
+
+// HealthCheck probes an origin; zero values use the defaults below
+type HealthCheck struct {
+	Path             string `json:"path,omitempty"`              // default "/"
+	Interval         int    `json:"interval,omitempty"`          // seconds between probes, default 30
+	Timeout          int    `json:"timeout,omitempty"`           // seconds, default 5
+	FailureThreshold int    `json:"failure_threshold,omitempty"` // failed probes before failover, default 3
+}
+
+// DefaultHealthCheck is used for fields left unset
+var DefaultHealthCheck = HealthCheck{Path: "/", Interval: 30, Timeout: 5, FailureThreshold: 3}
+
+// WithDefaults fills unset health check fields
+func (h HealthCheck) WithDefaults() HealthCheck {
+	if h.Path == "" {
+		h.Path = DefaultHealthCheck.Path
+	}
+	if h.Interval == 0 {
+		h.Interval = DefaultHealthCheck.Interval
+	}
+	if h.Timeout == 0 {
+		h.Timeout = DefaultHealthCheck.Timeout
+	}
+	if h.FailureThreshold == 0 {
+		h.FailureThreshold = DefaultHealthCheck.FailureThreshold
+	}
+	return h
+}
+
+// Validate checks the backups differ from the primary and the health check is sane
+func (f OriginFailover) Validate(primary OriginConfig) error {
+	if len(f.Backups) == 0 {
+		return fmt.Errorf("failover needs at least one backup origin")
+	}
+	if len(f.Backups) > maxBackupOrigins {
+		return fmt.Errorf("at most %d backup origins are allowed", maxBackupOrigins)
+	}
+
+	seen := map[string]bool{strings.ToLower(primary.Host): true}
+	for _, b := range f.Backups {
+		if b.Host == "" {
+			return fmt.Errorf("backup origin host is required")
+		}
+		if seen[strings.ToLower(b.Host)] {
+			return fmt.Errorf("backup origin %s duplicates another origin", b.Host)
+		}
+		seen[strings.ToLower(b.Host)] = true
+	}
+
+	h := f.HealthCheck.WithDefaults()
+	if !strings.HasPrefix(h.Path, "/") {
+		return fmt.Errorf("health check path must start with /")
+	}
+	if h.Interval < 5 || h.Interval > 300 {
+		return fmt.Errorf("health check interval must be between 5 and 300 seconds")
+	}
+	if h.Timeout < 1 || h.Timeout >= h.Interval {
+		return fmt.Errorf("health check timeout must be at least 1 second and shorter than the interval")
+	}
+	if h.FailureThreshold < 1 || h.FailureThreshold > 10 {
+		return fmt.Errorf("health check failure_threshold must be between 1 and 10")
+	}
+	return nil
+}
+
+// parseBackupOrigins parses a comma-separated list of backup origin hosts from chat
+func parseBackupOrigins(v string) *OriginFailover {
+	backups := make([]OriginConfig, 0)
+	for _, host := range strings.Split(v, ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			backups = append(backups, OriginConfig{Host: host, Protocol: "https"})
+		}
+	}
+	if len(backups) == 0 {
+		return nil
+	}
+	return &OriginFailover{Backups: backups}
+}
@@ -0,0 +1,206 @@
+package cdn
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+)
+
+// FirewallConfig is the WAF configuration of a service
+type FirewallConfig struct {
+	BlockedIPs       []string `json:"blocked_ips"`       // IPs or CIDR ranges
+	BlockedCountries []string `json:"blocked_countries"` // ISO 3166-1 alpha-2 codes
+	ManagedRuleSets  []string `json:"managed_rule_sets"` // provider rule sets to enforce, see FirewallSupport
+	BotProtection    bool     `json:"bot_protection"`
+}
+
+// FirewallSupport describes which firewall features a provider can map
+type FirewallSupport struct {
+	IPBlocking      bool     `json:"ip_blocking"`
+	CountryBlocking bool     `json:"country_blocking"`
+	ManagedRuleSets []string `json:"managed_rule_sets"`
+	BotProtection   bool     `json:"bot_protection"`
+}
+
+// FirewallConfigurer is implemented by providers that expose WAF settings
+type FirewallConfigurer interface {
+	FirewallSupport() FirewallSupport
+	GetFirewall(ctx context.Context, serviceID string) (*FirewallConfig, error)
+	UpdateFirewall(ctx context.Context, serviceID string, config FirewallConfig) error
+}
+
+// Validate checks and normalizes the config, independent of the provider:
+// countries are upper-cased and every list is sorted without duplicates
+func (c *FirewallConfig) Validate() error {
+	for _, ip := range c.BlockedIPs {
+		if net.ParseIP(ip) == nil {
+			if _, _, err := net.ParseCIDR(ip); err != nil {
+				return fmt.Errorf("invalid blocked IP %q (expected an IP or CIDR range)", ip)
+			}
+		}
+	}
+	for i, country := range c.BlockedCountries {
+		country = strings.ToUpper(strings.TrimSpace(country))
+		if len(country) != 2 || country[0] < 'A' || country[0] > 'Z' || country[1] < 'A' || country[1] > 'Z' {
+			return fmt.Errorf("invalid country %q (expected an ISO 3166-1 alpha-2 code)", c.BlockedCountries[i])
+		}
+		c.BlockedCountries[i] = country
+	}
+	for _, set := range c.ManagedRuleSets {
+		if strings.TrimSpace(set) == "" {
+			return fmt.Errorf("empty managed rule set name")
+		}
+	}
+
+	c.BlockedIPs = sortedUnique(c.BlockedIPs)
+	c.BlockedCountries = sortedUnique(c.BlockedCountries)
+	c.ManagedRuleSets = sortedUnique(c.ManagedRuleSets)
+	return nil
+}
+
+// Check returns an error wrapping ErrNotSupported for the first feature the
+// provider can't map
+func (s FirewallSupport) Check(c FirewallConfig) error {
+	if len(c.BlockedIPs) > 0 && !s.IPBlocking {
+		return fmt.Errorf("blocked_ips: %w", ErrNotSupported)
+	}
+	if len(c.BlockedCountries) > 0 && !s.CountryBlocking {
+		return fmt.Errorf("blocked_countries: %w", ErrNotSupported)
+	}
+	for _, set := range c.ManagedRuleSets {
+		if !containsString(s.ManagedRuleSets, set) {
+			return fmt.Errorf("managed rule set %q: %w", set, ErrNotSupported)
+		}
+	}
+	if c.BotProtection && !s.BotProtection {
+		return fmt.Errorf("bot_protection: %w", ErrNotSupported)
+	}
+	return nil
+}
+
+// FirewallSupport returns what the provider supports (nothing if it has no firewall settings)
+func (s *Service) FirewallSupport() FirewallSupport {
+	if configurer, ok := s.provider.(FirewallConfigurer); ok {
+		return configurer.FirewallSupport()
+	}
+	return FirewallSupport{}
+}
+
+// GetFirewall returns the firewall configuration of a service
+func (s *Service) GetFirewall(ctx context.Context, serviceID string) (*FirewallConfig, error) {
+	configurer, ok := s.provider.(FirewallConfigurer)
+	if !ok {
+		return nil, fmt.Errorf("firewall: %w", ErrNotSupported)
+	}
+	return configurer.GetFirewall(ctx, serviceID)
+}
+
+// UpdateFirewall validates the config against the provider's support and applies it
+func (s *Service) UpdateFirewall(ctx context.Context, serviceID string, config FirewallConfig) error {
+	configurer, ok := s.provider.(FirewallConfigurer)
+	if !ok {
+		return fmt.Errorf("firewall: %w", ErrNotSupported)
+	}
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	if err := configurer.FirewallSupport().Check(config); err != nil {
+		return err
+	}
+	if err := s.guardChange(ctx, serviceID, "firewall"); err != nil {
+		return err
+	}
+	return configurer.UpdateFirewall(ctx, serviceID, config)
+}
+
+// handleSecureService changes firewall settings from chat. Block and unblock
+// parameters are comma-separated and edit the current lists.
+func (s *Service) handleSecureService(ctx context.Context, params map[string]*string) (string, error) {
+	serviceID := getParam(params, "service_id")
+	if serviceID == "" {
+		return "", fmt.Errorf("missing required parameters")
+	}
+
+	config, err := s.GetFirewall(ctx, serviceID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get firewall settings: %w", err)
+	}
+
+	config.BlockedIPs = editList(config.BlockedIPs, getParam(params, "block_ips"), getParam(params, "unblock_ips"))
+	config.BlockedCountries = editList(config.BlockedCountries,
+		strings.ToUpper(getParam(params, "block_countries")), strings.ToUpper(getParam(params, "unblock_countries")))
+	config.ManagedRuleSets = editList(config.ManagedRuleSets, getParam(params, "enable_rule_sets"), getParam(params, "disable_rule_sets"))
+	if v := getParam(params, "bot_protection"); v != "" {
+		config.BotProtection = parseToggle(v)
+	}
+
+	if err := s.UpdateFirewall(ctx, serviceID, *config); err != nil {
+		return "", fmt.Errorf("failed to update firewall: %w", err)
+	}
+
+	return fmt.Sprintf(`🛡️ Firewall updated!
+
+   • Blocked IPs: %s
+   • Blocked countries: %s
+   • Managed rule sets: %s
+   • Bot protection: %s`,
+		listOrNone(config.BlockedIPs),
+		listOrNone(config.BlockedCountries),
+		listOrNone(config.ManagedRuleSets),
+		map[bool]string{true: "on", false: "off"}[config.BotProtection],
+	), nil
+}
+
+// editList adds and removes the comma-separated items of add and remove
+func editList(list []string, add, remove string) []string {
+	removed := make(map[string]bool)
+	for _, item := range strings.Split(remove, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			removed[item] = true
+		}
+	}
+
+	result := make([]string, 0, len(list))
+	for _, item := range list {
+		if !removed[item] {
+			result = append(result, item)
+		}
+	}
+	for _, item := range strings.Split(add, ",") {
+		if item = strings.TrimSpace(item); item != "" && !removed[item] {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+func sortedUnique(list []string) []string {
+	seen := make(map[string]bool, len(list))
+	result := make([]string, 0, len(list))
+	for _, item := range list {
+		if !seen[item] {
+			seen[item] = true
+			result = append(result, item)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func listOrNone(list []string) string {
+	if len(list) == 0 {
+		return "none"
+	}
+	return strings.Join(list, ", ")
+}
@@ -0,0 +1,244 @@
+package cdn
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FixtureMode controls how provider HTTP calls are recorded or replayed
+type FixtureMode string
+
+const (
+	FixtureOff    FixtureMode = ""
+	FixtureRecord FixtureMode = "record" // call the real API and save responses
+	FixtureReplay FixtureMode = "replay" // serve saved responses, never touch the network
+)
+
+// sensitiveKeys are stripped from recorded query strings and JSON bodies
+var sensitiveKeys = []string{"token", "api_key", "apikey", "password", "secret", "private_key", "customsslkey", "authorization"}
+
+// FixtureTransport is an http.RoundTripper that records real provider responses
+// into fixture files and replays them later, so provider tests run without
+// network access or credentials. Request headers are never written to disk.
+type FixtureTransport struct {
+	mode FixtureMode
+	dir  string
+	next http.RoundTripper
+}
+
+type fixture struct {
+	Request  fixtureRequest  `json:"request"`
+	Response fixtureResponse `json:"response"`
+}
+
+type fixtureRequest struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Body   string `json:"body,omitempty"`
+}
+
+type fixtureResponse struct {
+	StatusCode int               `json:"status_code"`
+	Header     map[string]string `json:"header,omitempty"`
+	Body       string            `json:"body"`
+}
+
+// NewFixtureTransport creates a new fixture transport; next is used in record mode
+func NewFixtureTransport(mode FixtureMode, dir string, next http.RoundTripper) *FixtureTransport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &FixtureTransport{mode: mode, dir: dir, next: next}
+}
+
+// fixtureTransportFromEnv returns a fixture transport configured by
+// CDN_FIXTURE_MODE and CDN_FIXTURE_DIR, or nil when fixtures are disabled
+func fixtureTransportFromEnv() http.RoundTripper {
+	mode := FixtureMode(os.Getenv("CDN_FIXTURE_MODE"))
+	if mode != FixtureRecord && mode != FixtureReplay {
+		return nil
+	}
+
+	dir := os.Getenv("CDN_FIXTURE_DIR")
+	if dir == "" {
+		dir = filepath.Join("testdata", "fixtures")
+	}
+	return NewFixtureTransport(mode, dir, nil)
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *FixtureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	recorded := fixtureRequest{
+		Method: req.Method,
+		URL:    redactURL(req.URL),
+		Body:   redactBody(body),
+	}
+	path := filepath.Join(t.dir, fixtureName(recorded))
+
+	if t.mode == FixtureReplay {
+		return t.replay(req, path)
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.mode == FixtureRecord {
+		if err := t.record(resp, recorded, path); err != nil {
+			resp.Body.Close()
+			return nil, err
+		}
+	}
+
+	return resp, nil
+}
+
+func (t *FixtureTransport) record(resp *http.Response, recorded fixtureRequest, path string) error {
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+
+	f := fixture{
+		Request: recorded,
+		Response: fixtureResponse{
+			StatusCode: resp.StatusCode,
+			Header: map[string]string{
+				"Content-Type": resp.Header.Get("Content-Type"),
+			},
+			Body: redactBody(data),
+		},
+	}
+
+	payload, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal fixture: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create fixture dir: %w", err)
+	}
+	if err := os.WriteFile(path, payload, 0o644); err != nil {
+		return fmt.Errorf("failed to write fixture: %w", err)
+	}
+
+	return nil
+}
+
+func (t *FixtureTransport) replay(req *http.Request, path string) (*http.Response, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("no fixture for %s %s (%s): %w", req.Method, req.URL.Path, path, err)
+	}
+
+	var f fixture
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal fixture %s: %w", path, err)
+	}
+
+	header := make(http.Header)
+	for k, v := range f.Response.Header {
+		header.Set(k, v)
+	}
+
+	return &http.Response{
+		StatusCode: f.Response.StatusCode,
+		Status:     fmt.Sprintf("%d %s", f.Response.StatusCode, http.StatusText(f.Response.StatusCode)),
+		Header:     header,
+		Body:       io.NopCloser(strings.NewReader(f.Response.Body)),
+		Request:    req,
+	}, nil
+}
+
+// fixtureName derives a stable, readable file name for a request
+func fixtureName(req fixtureRequest) string {
+	sum := sha256.Sum256([]byte(req.Method + " " + req.URL + "\n" + req.Body))
+
+	u, _ := url.Parse(req.URL)
+	slug := strings.Trim(strings.NewReplacer("/", "_", ".", "_").Replace(u.Path), "_")
+
+	return fmt.Sprintf("%s_%s_%s.json", strings.ToLower(req.Method), slug, hex.EncodeToString(sum[:])[:12])
+}
+
+func redactURL(u *url.URL) string {
+	clean := *u
+	clean.User = nil
+
+	query := clean.Query()
+	for key := range query {
+		if isSensitiveKey(key) {
+			query.Set(key, "REDACTED")
+		}
+	}
+	clean.RawQuery = query.Encode()
+
+	return clean.String()
+}
+
+// redactBody replaces sensitive values in JSON bodies; non-JSON bodies are kept as-is
+func redactBody(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	var data interface{}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return string(body)
+	}
+
+	redacted, err := json.Marshal(redactValue(data))
+	if err != nil {
+		return string(body)
+	}
+	return string(redacted)
+}
+
+func redactValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, inner := range val {
+			if isSensitiveKey(k) {
+				val[k] = "REDACTED"
+			} else {
+				val[k] = redactValue(inner)
+			}
+		}
+	case []interface{}:
+		for i, inner := range val {
+			val[i] = redactValue(inner)
+		}
+	}
+	return v
+}
+
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, s := range sensitiveKeys {
+		if key == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,162 @@
+package cdn
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/avvvet/cdnbuddy-api/internal/domain"
+)
+
+// roundTripFunc adapts a function to http.RoundTripper
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestFixtureTransport(t *testing.T) {
+	dir := t.TempDir()
+	calls := 0
+	next := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		calls++
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {"application/json"}, "Set-Cookie": {"session=abc"}},
+			Body:       io.NopCloser(strings.NewReader(`{"id":"1","token":"response-secret"}`)),
+			Request:    req,
+		}, nil
+	})
+	send := func(t *testing.T, transport http.RoundTripper) (*http.Response, error) {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodPost, "https://api.example.com/zones.json?apikey=query-secret", strings.NewReader(`{"name":"shop","password":"body-secret"}`))
+		req.Header.Set("Authorization", "Bearer header-secret")
+		return transport.RoundTrip(req)
+	}
+
+	resp, err := send(t, NewFixtureTransport(FixtureRecord, dir, next))
+	if err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	if body, _ := io.ReadAll(resp.Body); !strings.Contains(string(body), "response-secret") {
+		t.Errorf("recorded call returned %s, want the real response", body)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(files) != 1 || !strings.HasPrefix(filepath.Base(files[0]), "post_zones_json_") {
+		t.Fatalf("fixtures = %v, want one post_zones_json_* file", files)
+	}
+	saved, _ := os.ReadFile(files[0])
+	for _, secret := range []string{"query-secret", "body-secret", "response-secret", "header-secret", "session=abc"} {
+		if strings.Contains(string(saved), secret) {
+			t.Errorf("fixture contains %q:\n%s", secret, saved)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		dir      string
+		wantErr  bool
+		wantBody string
+	}{
+		{name: "recorded request", dir: dir, wantBody: `{"id":"1","token":"REDACTED"}`},
+		{name: "request without a fixture", dir: t.TempDir(), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := send(t, NewFixtureTransport(FixtureReplay, tt.dir, next))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("replay error = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != http.StatusOK || string(body) != tt.wantBody {
+				t.Errorf("replay = %d %s, want 200 %s", resp.StatusCode, body, tt.wantBody)
+			}
+		})
+	}
+	if calls != 1 {
+		t.Errorf("next transport called %d times, want only while recording", calls)
+	}
+}
+
+// TestProvidersReplayFixtures runs provider calls against the responses
+// committed in testdata/fixtures; re-record them with CDN_FIXTURE_MODE=record
+func TestProvidersReplayFixtures(t *testing.T) {
+	t.Setenv("CDN_FIXTURE_MODE", string(FixtureReplay))
+	t.Setenv("CDN_FIXTURE_DIR", "")
+	ctx := context.Background()
+
+	t.Run("keycdn", func(t *testing.T) {
+		p := &KeyCDNProvider{api: newHTTPAdapter("keycdn", keyCDNBaseURL, nil, nil)}
+		services, err := p.ListServicesByStatus(ctx, StatusAll)
+		if err != nil {
+			t.Fatalf("list services: %v", err)
+		}
+		if got := serviceNames(services); !reflect.DeepEqual(got, []string{"42 shop ACTIVE", "43 blog INACTIVE"}) {
+			t.Errorf("services = %v", got)
+		}
+		if !strings.Contains(services[0].Config, "shop-7f3a.kxcdn.com") {
+			t.Errorf("config = %s, want the zone hostname", services[0].Config)
+		}
+
+		domains, err := p.ListDomains(ctx, "42")
+		if err != nil {
+			t.Fatalf("list domains: %v", err)
+		}
+		if got := domainNames(domains); !reflect.DeepEqual(got, []string{"cdn.shop.example.com"}) {
+			t.Errorf("domains = %v", got)
+		}
+	})
+
+	t.Run("cdn77", func(t *testing.T) {
+		p := &CDN77Provider{api: newHTTPAdapter("cdn77", cdn77BaseURL, nil, nil)}
+		services, err := p.ListServicesByStatus(ctx, StatusAll)
+		if err != nil {
+			t.Fatalf("list services: %v", err)
+		}
+		if got := serviceNames(services); !reflect.DeepEqual(got, []string{"1234 shop ACTIVE", "1235 blog INACTIVE"}) {
+			t.Errorf("services = %v", got)
+		}
+
+		domains, err := p.ListDomains(ctx, "1234")
+		if err != nil {
+			t.Fatalf("list domains: %v", err)
+		}
+		if got := domainNames(domains); !reflect.DeepEqual(got, []string{"cdn.shop.example.com", "static.shop.example.com"}) {
+			t.Errorf("domains = %v", got)
+		}
+	})
+
+	t.Run("cachefly", func(t *testing.T) {
+		p := &CacheFlyProvider{api: newHTTPAdapter(cacheFlyName, cacheFlyBaseURL, nil, nil)}
+		account, err := p.GetAccountInfo(ctx)
+		if err != nil {
+			t.Fatalf("account info: %v", err)
+		}
+		if account.Name != "Example Shop" || account.Status != "ACTIVE" {
+			t.Errorf("account = %+v", account)
+		}
+	})
+}
+
+func serviceNames(services []domain.CDNService) []string {
+	result := make([]string, len(services))
+	for i, s := range services {
+		result[i] = s.ID + " " + s.Name + " " + s.Status
+	}
+	return result
+}
+
+func domainNames(domains []domain.Domain) []string {
+	result := make([]string, len(domains))
+	for i, d := range domains {
+		result[i] = d.Name
+	}
+	return result
+}
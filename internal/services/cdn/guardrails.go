@@ -0,0 +1,79 @@
+package cdn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/avvvet/cdnbuddy-api/internal/domain"
+)
+
+// ErrConfirmationRequired is returned when a destructive intent lacks the
+// confirmation phrase
+var ErrConfirmationRequired = errors.New("confirmation required")
+
+// ConfirmationParam is the intent parameter carrying the confirmation phrase
+const ConfirmationParam = "confirmation"
+
+// destructiveActions can't be undone; each needs the service name typed back
+var destructiveActions = map[string]bool{
+	"DELETE_SERVICE": true,
+	"PURGE_ALL":      true,
+}
+
+// IsDestructive reports whether an intent action requires a confirmation phrase
+func IsDestructive(action string) bool {
+	return destructiveActions[action]
+}
+
+// confirmedService looks up the service of a destructive intent and checks
+// that the confirmation phrase is its name. This is enforced here rather than
+// trusted from the intent service.
+func (s *Service) confirmedService(ctx context.Context, params map[string]*string) (*domain.CDNService, error) {
+	serviceID := getParam(params, "service_id")
+	name := getParam(params, "domain")
+	if serviceID == "" && name == "" {
+		return nil, fmt.Errorf("missing required parameters")
+	}
+
+	svc, err := s.findSpecService(ctx, ServiceSpec{ServiceID: serviceID, Name: name})
+	if err != nil {
+		return nil, err
+	}
+	if svc == nil {
+		return nil, fmt.Errorf("service %s not found", name)
+	}
+
+	phrase := strings.TrimSpace(getParam(params, ConfirmationParam))
+	if !strings.EqualFold(phrase, svc.Name) {
+		return nil, fmt.Errorf("%w: type the service name %q to confirm", ErrConfirmationRequired, svc.Name)
+	}
+	return svc, nil
+}
+
+func (s *Service) handleDeleteService(ctx context.Context, params map[string]*string) (string, error) {
+	svc, err := s.confirmedService(ctx, params)
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.providerOf(*svc).DeleteService(ctx, svc.ID); err != nil {
+		return "", fmt.Errorf("failed to delete service: %w", err)
+	}
+
+	return fmt.Sprintf("🗑️ Service %s deleted. DNS records pointing at it should be removed.", svc.Name), nil
+}
+
+func (s *Service) handlePurgeAll(ctx context.Context, params map[string]*string) (string, error) {
+	svc, err := s.confirmedService(ctx, params)
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.providerOf(*svc).PurgeAll(ctx, svc.ID); err != nil {
+		return "", fmt.Errorf("failed to purge cache: %w", err)
+	}
+
+	return fmt.Sprintf("🧹 Entire cache of %s purged. Expect higher origin load while it refills.", svc.Name), nil
+}
@@ -0,0 +1,159 @@
+package cdn
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ResponseHeader is a header the CDN adds to every response of a service,
+// replacing any value the origin sent
+type ResponseHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// HeaderConfigurer is implemented by providers that can inject response headers
+type HeaderConfigurer interface {
+	GetResponseHeaders(ctx context.Context, serviceID string) ([]ResponseHeader, error)
+	UpdateResponseHeaders(ctx context.Context, serviceID string, headers []ResponseHeader) error
+}
+
+// headerPresets are the values used when a well-known header is added without one
+var headerPresets = map[string]string{
+	"Strict-Transport-Security": "max-age=31536000; includeSubDomains",
+	"X-Frame-Options":           "SAMEORIGIN",
+	"X-Content-Type-Options":    "nosniff",
+	"Referrer-Policy":           "strict-origin-when-cross-origin",
+}
+
+// headerAliases let chat users name headers the short way
+var headerAliases = map[string]string{
+	"hsts":          "Strict-Transport-Security",
+	"frame-options": "X-Frame-Options",
+	"nosniff":       "X-Content-Type-Options",
+}
+
+// protectedHeaders are managed by the CDN itself and can't be overridden
+var protectedHeaders = map[string]bool{
+	"Connection":        true,
+	"Content-Length":    true,
+	"Content-Encoding":  true,
+	"Date":              true,
+	"Host":              true,
+	"Transfer-Encoding": true,
+	"Age":               true,
+}
+
+// ValidateResponseHeaders canonicalizes header names, fills preset values
+// and rejects protected headers, malformed names or values and duplicates
+func ValidateResponseHeaders(headers []ResponseHeader) error {
+	seen := make(map[string]bool, len(headers))
+	for i := range headers {
+		h := &headers[i]
+		name, err := headerName(h.Name)
+		if err != nil {
+			return err
+		}
+		h.Name = name
+
+		if seen[h.Name] {
+			return fmt.Errorf("duplicate header %s", h.Name)
+		}
+		seen[h.Name] = true
+
+		h.Value = strings.TrimSpace(h.Value)
+		if h.Value == "" {
+			h.Value = headerPresets[h.Name]
+		}
+		if h.Value == "" {
+			return fmt.Errorf("header %s needs a value", h.Name)
+		}
+		if strings.ContainsAny(h.Value, "\r\n") {
+			return fmt.Errorf("invalid value for header %s", h.Name)
+		}
+	}
+	return nil
+}
+
+// headerName resolves aliases and canonicalizes a header name
+func headerName(name string) (string, error) {
+	trimmed := strings.TrimSpace(name)
+	if alias, ok := headerAliases[strings.ToLower(trimmed)]; ok {
+		trimmed = alias
+	}
+	if trimmed == "" || strings.ContainsAny(trimmed, " \t\r\n:()<>@,;\\\"/[]?={}") {
+		return "", fmt.Errorf("invalid header name %q", name)
+	}
+	trimmed = http.CanonicalHeaderKey(trimmed)
+	if protectedHeaders[trimmed] {
+		return "", fmt.Errorf("header %s is managed by the CDN and can't be set", trimmed)
+	}
+	return trimmed, nil
+}
+
+// GetResponseHeaders returns the headers injected into a service's responses
+func (s *Service) GetResponseHeaders(ctx context.Context, serviceID string) ([]ResponseHeader, error) {
+	configurer, ok := s.provider.(HeaderConfigurer)
+	if !ok {
+		return nil, fmt.Errorf("response headers: %w", ErrNotSupported)
+	}
+	return configurer.GetResponseHeaders(ctx, serviceID)
+}
+
+// UpdateResponseHeaders validates and replaces the headers injected into a service's responses
+func (s *Service) UpdateResponseHeaders(ctx context.Context, serviceID string, headers []ResponseHeader) error {
+	configurer, ok := s.provider.(HeaderConfigurer)
+	if !ok {
+		return fmt.Errorf("response headers: %w", ErrNotSupported)
+	}
+	if err := ValidateResponseHeaders(headers); err != nil {
+		return err
+	}
+	if err := s.guardChange(ctx, serviceID, "headers"); err != nil {
+		return err
+	}
+	return configurer.UpdateResponseHeaders(ctx, serviceID, headers)
+}
+
+// handleAddHeader adds or replaces one response header from chat; a
+// value of "remove" drops it
+func (s *Service) handleAddHeader(ctx context.Context, params map[string]*string) (string, error) {
+	serviceID := getParam(params, "service_id")
+	if serviceID == "" || getParam(params, "header") == "" {
+		return "", fmt.Errorf("missing required parameters")
+	}
+	name, err := headerName(getParam(params, "header"))
+	if err != nil {
+		return "", err
+	}
+	value := getParam(params, "value")
+	removed := strings.EqualFold(value, "remove")
+
+	headers, err := s.GetResponseHeaders(ctx, serviceID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get response headers: %w", err)
+	}
+
+	updated := make([]ResponseHeader, 0, len(headers)+1)
+	for _, h := range headers {
+		if h.Name != name {
+			updated = append(updated, h)
+		}
+	}
+	if !removed {
+		updated = append(updated, ResponseHeader{Name: name, Value: value})
+	}
+
+	if err := s.UpdateResponseHeaders(ctx, serviceID, updated); err != nil {
+		return "", fmt.Errorf("failed to update response headers: %w", err)
+	}
+
+	if removed {
+		return fmt.Sprintf("✅ Header %s removed from responses.", name), nil
+	}
+	// Validation filled in the preset value, if none was given
+	added := updated[len(updated)-1]
+	return fmt.Sprintf("✅ Responses now include:\n\n   %s: %s", added.Name, added.Value), nil
+}
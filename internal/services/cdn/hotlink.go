@@ -0,0 +1,109 @@
+package cdn
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// HotlinkConfig restricts which sites may embed a service's content, by the
+// Referer header of requests
+type HotlinkConfig struct {
+	Enabled            bool     `json:"enabled"`
+	AllowedReferrers   []string `json:"allowed_referrers"`    // hostnames, "*.example.com" for subdomains
+	AllowEmptyReferrer bool     `json:"allow_empty_referrer"` // direct visits and privacy-stripped requests
+}
+
+// HotlinkConfigurer is implemented by providers that expose referrer rules
+type HotlinkConfigurer interface {
+	GetHotlinkProtection(ctx context.Context, serviceID string) (*HotlinkConfig, error)
+	UpdateHotlinkProtection(ctx context.Context, serviceID string, config HotlinkConfig) error
+}
+
+// Validate checks and normalizes the config: referrers are lower-cased and
+// sorted without duplicates
+func (c *HotlinkConfig) Validate() error {
+	for i, referrer := range c.AllowedReferrers {
+		referrer = strings.ToLower(strings.TrimSpace(referrer))
+		host := strings.TrimPrefix(referrer, "*.")
+		if host == "" || strings.ContainsAny(host, "*/: ") || !strings.Contains(host, ".") {
+			return fmt.Errorf("invalid referrer %q (expected a hostname like example.com or *.example.com)", c.AllowedReferrers[i])
+		}
+		c.AllowedReferrers[i] = referrer
+	}
+	c.AllowedReferrers = sortedUnique(c.AllowedReferrers)
+
+	if c.Enabled && len(c.AllowedReferrers) == 0 {
+		return fmt.Errorf("allowed_referrers is required when hotlink protection is enabled")
+	}
+	return nil
+}
+
+// GetHotlinkProtection returns the referrer rules of a service
+func (s *Service) GetHotlinkProtection(ctx context.Context, serviceID string) (*HotlinkConfig, error) {
+	configurer, ok := s.provider.(HotlinkConfigurer)
+	if !ok {
+		return nil, fmt.Errorf("hotlink protection: %w", ErrNotSupported)
+	}
+	return configurer.GetHotlinkProtection(ctx, serviceID)
+}
+
+// UpdateHotlinkProtection validates and applies the referrer rules of a service
+func (s *Service) UpdateHotlinkProtection(ctx context.Context, serviceID string, config HotlinkConfig) error {
+	configurer, ok := s.provider.(HotlinkConfigurer)
+	if !ok {
+		return fmt.Errorf("hotlink protection: %w", ErrNotSupported)
+	}
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	if err := s.guardChange(ctx, serviceID, "hotlink"); err != nil {
+		return err
+	}
+	return configurer.UpdateHotlinkProtection(ctx, serviceID, config)
+}
+
+// handleProtectHotlinks turns hotlink protection on or off from chat. Without
+// allowed_referrers, the service's own domains (and their subdomains) are allowed.
+func (s *Service) handleProtectHotlinks(ctx context.Context, params map[string]*string) (string, error) {
+	serviceID := getParam(params, "service_id")
+	if serviceID == "" {
+		return "", fmt.Errorf("missing required parameters")
+	}
+
+	config := HotlinkConfig{Enabled: true, AllowEmptyReferrer: true}
+	if v := getParam(params, "enabled"); v != "" {
+		config.Enabled = parseToggle(v)
+	}
+	if v := getParam(params, "allow_empty_referrer"); v != "" {
+		config.AllowEmptyReferrer = parseToggle(v)
+	}
+	config.AllowedReferrers = editList(nil, getParam(params, "allowed_referrers"), "")
+
+	if config.Enabled && len(config.AllowedReferrers) == 0 {
+		domains, err := s.provider.ListDomains(ctx, serviceID)
+		if err != nil {
+			return "", fmt.Errorf("failed to list domains: %w", err)
+		}
+		for _, d := range domains {
+			config.AllowedReferrers = append(config.AllowedReferrers, d.Name, "*."+d.Name)
+		}
+	}
+
+	if err := s.UpdateHotlinkProtection(ctx, serviceID, config); err != nil {
+		return "", fmt.Errorf("failed to update hotlink protection: %w", err)
+	}
+
+	if !config.Enabled {
+		return "🔓 Hotlink protection disabled. Any site can embed your content again.", nil
+	}
+	emptyReferrer := "allowed"
+	if !config.AllowEmptyReferrer {
+		emptyReferrer = "blocked"
+	}
+	return fmt.Sprintf(`🔒 Hotlink protection enabled!
+
+   • Allowed referrers: %s
+   • Requests without a referrer: %s`,
+		listOrNone(config.AllowedReferrers), emptyReferrer), nil
+}
@@ -0,0 +1,271 @@
+package cdn
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/avvvet/cdnbuddy-api/internal/domain"
+)
+
+// APIError is returned when a provider responds with a non-2xx status, by
+// httpAdapter and by SDK calls made through retryCall1 and retryCall2
+type APIError struct {
+	Provider   string
+	Method     string
+	Path       string
+	StatusCode int
+	Body       string
+	RetryAfter time.Duration // back-off the provider asked for, if any
+	Err        error         // the SDK's own error, for SDK calls
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s API %s %s returned %d: %s", e.Provider, e.Method, e.Path, e.StatusCode, e.Body)
+}
+
+func (e *APIError) Unwrap() error { return e.Err }
+
+// httpAdapter holds the shared plumbing for providers that expose a plain JSON REST API
+// (KeyCDN, CDN77). Provider implementations only describe endpoints and payloads.
+type httpAdapter struct {
+	provider  string
+	baseURL   string
+	client    *http.Client
+	calls     *CallPolicy
+	authorize func(req *http.Request)
+}
+
+// newHTTPAdapter creates a new adapter for the given provider API; a nil
+// calls uses DefaultCallPolicy
+func newHTTPAdapter(provider, baseURL string, calls *CallPolicy, authorize func(req *http.Request)) *httpAdapter {
+	if calls == nil {
+		calls = DefaultCallPolicy()
+	}
+	return &httpAdapter{
+		provider:  provider,
+		baseURL:   strings.TrimRight(baseURL, "/"),
+		client:    newProviderHTTPClient(),
+		calls:     calls,
+		authorize: authorize,
+	}
+}
+
+// newProviderHTTPClient returns the HTTP client provider APIs and SDKs are
+// called with; it records or replays responses when fixtures are enabled
+func newProviderHTTPClient() *http.Client {
+	var next http.RoundTripper = http.DefaultTransport
+	if transport := fixtureTransportFromEnv(); transport != nil {
+		next = transport
+	}
+	return &http.Client{Timeout: 30 * time.Second, Transport: statusTransport{next: next}}
+}
+
+// responseStatus is the last response an SDK call got. SDK errors carry no
+// status code, so the transport records it for the call to classify them.
+type responseStatus struct {
+	method     string
+	path       string
+	statusCode int
+	retryAfter time.Duration
+}
+
+type responseStatusKey struct{}
+
+// withResponseStatus makes requests sent with ctx record their response in status
+func withResponseStatus(ctx context.Context, status *responseStatus) context.Context {
+	return context.WithValue(ctx, responseStatusKey{}, status)
+}
+
+// apiError wraps err in an APIError when the provider answered with an error status
+func (s *responseStatus) apiError(provider string, err error) error {
+	if err == nil || s.statusCode < 300 {
+		return err
+	}
+	return &APIError{
+		Provider:   provider,
+		Method:     s.method,
+		Path:       s.path,
+		StatusCode: s.statusCode,
+		Body:       err.Error(),
+		RetryAfter: s.retryAfter,
+		Err:        err,
+	}
+}
+
+// statusTransport records responses in the responseStatus of their request's context
+type statusTransport struct {
+	next http.RoundTripper
+}
+
+func (t statusTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if status, ok := req.Context().Value(responseStatusKey{}).(*responseStatus); ok && err == nil {
+		*status = responseStatus{
+			method:     req.Method,
+			path:       req.URL.Path,
+			statusCode: resp.StatusCode,
+			retryAfter: parseRetryAfter(resp.Header),
+		}
+	}
+	return resp, err
+}
+
+// do sends a JSON request and decodes the JSON response into out (if out is not nil).
+// Transient failures are retried per the policy of the request's operation class.
+func (a *httpAdapter) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+	}
+
+	class := OpWrite
+	switch {
+	case !isMutation(method, path):
+		class = OpRead
+	case strings.Contains(path, "purge"):
+		class = OpPurge
+	}
+
+	var data []byte
+	err := a.calls.withRetry(ctx, a.provider, class, func() error {
+		var err error
+		data, err = a.send(ctx, method, path, payload)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return nil
+}
+
+// send makes one attempt of a JSON request and returns the response body;
+// mutations are journaled
+func (a *httpAdapter) send(ctx context.Context, method, path string, payload []byte) (data []byte, err error) {
+	status := 0
+	if isMutation(method, path) {
+		start := time.Now()
+		defer func() { a.calls.journalMutation(ctx, a.provider, method, path, payload, status, start, err) }()
+	}
+
+	var reader io.Reader
+	if payload != nil {
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, a.baseURL+path, reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if a.authorize != nil {
+		a.authorize(req)
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s API request failed: %w", a.provider, err)
+	}
+	defer resp.Body.Close()
+	status = resp.StatusCode
+
+	data, err = io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, &APIError{
+			Provider:   a.provider,
+			Method:     method,
+			Path:       path,
+			StatusCode: resp.StatusCode,
+			Body:       strings.TrimSpace(string(data)),
+			RetryAfter: parseRetryAfter(resp.Header),
+		}
+	}
+
+	return data, nil
+}
+
+// open streams a raw (non-JSON) response body, e.g. a log file download. Absolute
+// URLs are presigned download links and are fetched without API credentials.
+func (a *httpAdapter) open(ctx context.Context, path string) (io.ReadCloser, error) {
+	target := a.baseURL + path
+	absolute := strings.HasPrefix(path, "https://") || strings.HasPrefix(path, "http://")
+	if absolute {
+		target = path
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	if !absolute && a.authorize != nil {
+		a.authorize(req)
+	}
+
+	if !absolute {
+		if err := a.calls.waitBudget(ctx, a.provider); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s API request failed: %w", a.provider, err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, &APIError{
+			Provider:   a.provider,
+			Method:     http.MethodGet,
+			Path:       path,
+			StatusCode: resp.StatusCode,
+			Body:       strings.TrimSpace(string(data)),
+		}
+	}
+
+	return resp.Body, nil
+}
+
+// buildProviderConfigJSON builds the config JSON stored for services of non-CacheFly providers
+func buildProviderConfigJSON(provider domain.CDNProvider, serviceID, cname string, origin *OriginConfig) string {
+	configData := map[string]interface{}{
+		"provider":            provider,
+		"provider_service_id": serviceID,
+		"unique_name":         cname,
+		"cname_target":        cname,
+		"test_url":            fmt.Sprintf("https://%s", cname),
+	}
+	if origin != nil {
+		configData["origin"] = map[string]interface{}{
+			"host":     origin.Host,
+			"protocol": origin.Protocol,
+		}
+	}
+
+	jsonBytes, _ := json.Marshal(configData)
+	return string(jsonBytes)
+}
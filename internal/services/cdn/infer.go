@@ -0,0 +1,53 @@
+package cdn
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/avvvet/cdnbuddy-api/internal/models"
+)
+
+// InferParameters derives intent parameters from the account's current state:
+// with a single active service its ID and domain are unambiguous, and new
+// setups default to the origin protocol of the most recent one. Every service
+// is offered as a candidate.
+func (s *Service) InferParameters(ctx context.Context) (*models.IntentContext, error) {
+	services, err := s.ListServices(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services: %w", err)
+	}
+
+	intentContext := &models.IntentContext{
+		InferredParameters: make(map[string]string),
+		Candidates:         make(map[string][]string),
+	}
+	if len(services) == 0 {
+		return intentContext, nil
+	}
+
+	newest := services[0]
+	for _, svc := range services {
+		intentContext.Candidates["service_id"] = append(intentContext.Candidates["service_id"], svc.ID)
+		intentContext.Candidates["domain"] = append(intentContext.Candidates["domain"], svc.Name)
+		if svc.CreatedAt.After(newest.CreatedAt) {
+			newest = svc
+		}
+	}
+	if len(services) == 1 {
+		intentContext.InferredParameters["service_id"] = services[0].ID
+		intentContext.InferredParameters["domain"] = services[0].Name
+	}
+
+	provider := s.providerOf(newest)
+	if supports[ServiceInspector](provider) {
+		state, err := provider.(ServiceInspector).GetServiceState(ctx, newest.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get service state: %w", err)
+		}
+		if state.Origin.Protocol != "" {
+			intentContext.InferredParameters["origin_protocol"] = state.Origin.Protocol
+		}
+	}
+
+	return intentContext, nil
+}
@@ -0,0 +1,199 @@
+package cdn
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/avvvet/cdnbuddy-api/internal/models"
+)
+
+// Parameter problems
+const (
+	ParamMissing = "missing"
+	ParamInvalid = "invalid"
+)
+
+// ParamProblem is one missing or invalid parameter of an intent
+type ParamProblem struct {
+	Parameter string   `json:"parameter"`
+	Problem   string   `json:"problem"` // missing or invalid
+	Value     string   `json:"value,omitempty"`
+	Expected  string   `json:"expected"`
+	OneOf     []string `json:"one_of,omitempty"` // any of these parameters satisfies the requirement
+	Reason    string   `json:"reason,omitempty"`
+}
+
+// ValidationError lists what is wrong with the parameters of a READY intent,
+// so the intent service can ask the user for exactly those
+type ValidationError struct {
+	Action   string         `json:"action"`
+	Problems []ParamProblem `json:"problems"`
+}
+
+func (e *ValidationError) Error() string {
+	names := make([]string, 0, len(e.Problems))
+	for _, p := range e.Problems {
+		names = append(names, p.Problem+" "+p.Parameter)
+	}
+	return fmt.Sprintf("invalid parameters for %s: %s", e.Action, strings.Join(names, ", "))
+}
+
+// UserMessage asks the user for the missing or invalid parameters
+func (e *ValidationError) UserMessage() string {
+	var b strings.Builder
+	b.WriteString("I need a bit more information before I can prepare this change:\n")
+	for _, p := range e.Problems {
+		name := p.Parameter
+		if len(p.OneOf) > 0 {
+			name = strings.Join(p.OneOf, " or ")
+		}
+		if p.Problem == ParamMissing {
+			fmt.Fprintf(&b, "   • %s is missing (%s)\n", name, p.Expected)
+		} else {
+			fmt.Fprintf(&b, "   • %s %q isn't valid, expected %s\n", name, p.Value, p.Expected)
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// paramSpec describes one parameter of an action. Required specs with
+// alternatives are satisfied by any of them.
+type paramSpec struct {
+	name         string
+	alternatives []string
+	required     bool
+	expected     string
+	check        func(v string) error
+}
+
+var (
+	serviceIDParam = paramSpec{name: "service_id", required: true, expected: "the ID of an existing CDN service"}
+	serviceOrName  = paramSpec{name: "service_id", alternatives: []string{"domain"}, required: true, expected: "the ID or domain name of an existing CDN service"}
+)
+
+// intentParams are the parameters actions need before a plan can be built
+var intentParams = map[string][]paramSpec{
+	"SETUP_CDN": {
+		{name: "domain", required: true, expected: "a domain name like cdn.example.com", check: checkHostname},
+		{name: "origin_hostname", required: true, expected: "the origin host name like origin.example.com", check: checkHostname},
+		{name: "origin_protocol", expected: "http or https", check: oneOf("http", "https")},
+		{name: "profile", expected: "static-site, spa, api, video or wordpress", check: func(v string) error {
+			_, err := ParseProfile(v)
+			return err
+		}},
+	},
+	"ADD_DOMAIN": {
+		serviceIDParam,
+		{name: "domain", required: true, expected: "a domain name like cdn.example.com", check: checkHostname},
+	},
+	"FIND_SERVICE": {
+		{name: "query", alternatives: []string{"domain"}, required: true, expected: "a service name or domain to look for"},
+	},
+	"SET_STALE_POLICY": {
+		serviceIDParam,
+		{name: "max_stale", expected: "a number of seconds", check: checkSeconds},
+	},
+	"CONFIGURE_ORIGIN_SHIELD": {serviceIDParam},
+	"UPDATE_CACHE_RULES": {
+		serviceIDParam,
+		{name: "rules", required: true, expected: `a JSON array of rules like [{"path": "/static/*", "ttl": 86400}]`, check: func(v string) error {
+			_, err := parseRulesParam(v)
+			return err
+		}},
+	},
+	"SECURE_SERVICE":   {serviceIDParam},
+	"PROTECT_HOTLINKS": {serviceIDParam},
+	"ADD_HEADER": {
+		serviceIDParam,
+		{name: "header", required: true, expected: "a response header name like Cache-Control", check: func(v string) error {
+			_, err := headerName(v)
+			return err
+		}},
+	},
+	"DELETE_SERVICE": {serviceOrName},
+	"PURGE_ALL":      {serviceOrName},
+}
+
+// ValidateIntent checks the parameters of an intent against what its action
+// needs. It returns nil for valid intents and for actions without a spec.
+func ValidateIntent(intent *models.IntentResponse) *ValidationError {
+	if intent == nil || intent.Action == nil {
+		return nil
+	}
+	specs, ok := intentParams[*intent.Action]
+	if !ok {
+		return nil
+	}
+
+	result := &ValidationError{Action: *intent.Action}
+	for _, spec := range specs {
+		names := append([]string{spec.name}, spec.alternatives...)
+		present := ""
+		for _, name := range names {
+			if v := strings.TrimSpace(getParam(intent.Parameters, name)); v != "" {
+				present = name
+				break
+			}
+		}
+
+		switch {
+		case present == "" && spec.required:
+			problem := ParamProblem{Parameter: spec.name, Problem: ParamMissing, Expected: spec.expected}
+			if len(spec.alternatives) > 0 {
+				problem.OneOf = names
+			}
+			result.Problems = append(result.Problems, problem)
+		case present != "" && spec.check != nil:
+			value := strings.TrimSpace(getParam(intent.Parameters, present))
+			if err := spec.check(value); err != nil {
+				result.Problems = append(result.Problems, ParamProblem{
+					Parameter: present,
+					Problem:   ParamInvalid,
+					Value:     value,
+					Expected:  spec.expected,
+					Reason:    err.Error(),
+				})
+			}
+		}
+	}
+
+	if len(result.Problems) == 0 {
+		return nil
+	}
+	return result
+}
+
+func checkHostname(v string) error {
+	host := strings.TrimSuffix(strings.ToLower(v), ".")
+	if strings.Contains(host, "://") {
+		return fmt.Errorf("expected a host name without scheme")
+	}
+	if net.ParseIP(host) != nil {
+		return nil
+	}
+	if !strings.Contains(host, ".") || strings.ContainsAny(host, "/:@ *?#") {
+		return fmt.Errorf("%q is not a host name", v)
+	}
+	return nil
+}
+
+func checkSeconds(v string) error {
+	seconds, err := strconv.Atoi(v)
+	if err != nil || seconds < 0 {
+		return fmt.Errorf("%q is not a number of seconds", v)
+	}
+	return nil
+}
+
+func oneOf(values ...string) func(string) error {
+	return func(v string) error {
+		for _, allowed := range values {
+			if v == allowed {
+				return nil
+			}
+		}
+		return fmt.Errorf("expected one of %s", strings.Join(values, ", "))
+	}
+}
@@ -0,0 +1,168 @@
+package cdn
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxJournalPayload caps the stored payload of one journal entry
+const maxJournalPayload = 8 * 1024
+
+// JournalEntry is one attempt of a provider API mutation, as sent
+type JournalEntry struct {
+	ID            int64     `json:"id"`
+	Time          time.Time `json:"time"`
+	Provider      string    `json:"provider"`
+	Method        string    `json:"method"`   // HTTP method, or "SDK" for SDK calls
+	Endpoint      string    `json:"endpoint"` // request path, or the SDK operation
+	Payload       string    `json:"payload,omitempty"`
+	StatusCode    int       `json:"status_code,omitempty"` // 0 when no response was received
+	LatencyMs     int64     `json:"latency_ms"`
+	CorrelationID string    `json:"correlation_id,omitempty"`
+	Error         string    `json:"error,omitempty"`
+}
+
+// JournalFilter selects journal entries; zero fields match everything
+type JournalFilter struct {
+	Provider      string
+	CorrelationID string
+	ServiceID     string // matched against the endpoint and payload
+	Since         time.Time
+	Limit         int
+}
+
+// Journal keeps recent provider mutations for support. Secrets are redacted
+// from payloads before they are stored.
+type Journal struct {
+	entries    []JournalEntry // oldest first
+	maxEntries int
+	retention  time.Duration
+	nextID     int64
+	mu         sync.Mutex
+}
+
+// NewJournal creates a journal keeping at most maxEntries entries for retention
+func NewJournal(maxEntries int, retention time.Duration) *Journal {
+	return &Journal{maxEntries: maxEntries, retention: retention}
+}
+
+// Record stores an entry, dropping the oldest ones beyond the limits
+func (j *Journal) Record(e JournalEntry) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.nextID++
+	e.ID = j.nextID
+	j.entries = append(j.entries, e)
+	j.pruneLocked(time.Now())
+}
+
+func (j *Journal) pruneLocked(now time.Time) {
+	drop := 0
+	if j.maxEntries > 0 && len(j.entries) > j.maxEntries {
+		drop = len(j.entries) - j.maxEntries
+	}
+	if j.retention > 0 {
+		cutoff := now.Add(-j.retention)
+		for drop < len(j.entries) && j.entries[drop].Time.Before(cutoff) {
+			drop++
+		}
+	}
+	if drop > 0 {
+		j.entries = append([]JournalEntry(nil), j.entries[drop:]...)
+	}
+}
+
+// Query returns matching entries, newest first
+func (j *Journal) Query(f JournalFilter) []JournalEntry {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.pruneLocked(time.Now())
+
+	results := make([]JournalEntry, 0)
+	for i := len(j.entries) - 1; i >= 0; i-- {
+		e := j.entries[i]
+		if f.Provider != "" && e.Provider != f.Provider {
+			continue
+		}
+		if f.CorrelationID != "" && e.CorrelationID != f.CorrelationID {
+			continue
+		}
+		if f.ServiceID != "" && !strings.Contains(e.Endpoint, f.ServiceID) && !strings.Contains(e.Payload, f.ServiceID) {
+			continue
+		}
+		if !f.Since.IsZero() && e.Time.Before(f.Since) {
+			continue
+		}
+		results = append(results, e)
+		if f.Limit > 0 && len(results) >= f.Limit {
+			break
+		}
+	}
+	return results
+}
+
+type correlationKey struct{}
+
+// WithCorrelationID tags provider calls made with ctx, e.g. with a request or plan ID
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationKey{}, id)
+}
+
+// CorrelationID returns the ID set by WithCorrelationID
+func CorrelationID(ctx context.Context) string {
+	id, _ := ctx.Value(correlationKey{}).(string)
+	return id
+}
+
+// isMutation reports whether an HTTP provider call changes anything
+func isMutation(method, path string) bool {
+	return method != http.MethodGet || strings.Contains(path, "purge") // KeyCDN purges with a GET
+}
+
+// journalMutation records one mutation attempt if a journal is set
+func (c *CallPolicy) journalMutation(ctx context.Context, provider, method, endpoint string, payload []byte, status int, start time.Time, err error) {
+	if c.journal == nil {
+		return
+	}
+
+	e := JournalEntry{
+		Time:          start,
+		Provider:      provider,
+		Method:        method,
+		Endpoint:      endpoint,
+		Payload:       redactBody(payload),
+		StatusCode:    status,
+		LatencyMs:     time.Since(start).Milliseconds(),
+		CorrelationID: CorrelationID(ctx),
+	}
+	if len(e.Payload) > maxJournalPayload {
+		e.Payload = e.Payload[:maxJournalPayload] + "…(truncated)"
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	c.journal.Record(e)
+}
+
+// journalSDKCall records one SDK mutation attempt with its arguments as payload
+func (c *CallPolicy) journalSDKCall(ctx context.Context, provider string, fn interface{}, start time.Time, status int, err error, args ...interface{}) {
+	payload, _ := json.Marshal(args)
+	c.journalMutation(ctx, provider, "SDK", sdkOperation(fn), payload, status, start, err)
+}
+
+// sdkOperation names an SDK method value, e.g. "ServiceOptionsService.UpdateOptions"
+func sdkOperation(fn interface{}) string {
+	name := runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Name()
+	name = strings.TrimSuffix(name[strings.LastIndex(name, "/")+1:], "-fm")
+	if _, rest, ok := strings.Cut(name, "."); ok {
+		name = rest
+	}
+	return strings.NewReplacer("(*", "", ")", "").Replace(name)
+}
@@ -0,0 +1,519 @@
+package cdn
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/avvvet/cdnbuddy-api/internal/domain"
+)
+
+const keyCDNBaseURL = "https://api.keycdn.com"
+
+// KeyCDNProvider implements CDNProvider interface for KeyCDN pull zones
+type KeyCDNProvider struct {
+	api *httpAdapter
+
+	// userID is the account's ID, which zone hostnames end in; fetched once
+	userID string
+	mu     sync.Mutex
+}
+
+// keyCDNResponse is the envelope KeyCDN wraps every response in
+type keyCDNResponse[T any] struct {
+	Status      string `json:"status"`
+	Description string `json:"description"`
+	Data        T      `json:"data"`
+}
+
+type keyCDNZone struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Status    string `json:"status"`
+	Type      string `json:"type"`
+	OriginURL string `json:"originurl"`
+	Expire    string `json:"expire"`
+
+	// Cache key settings ("enabled"/"disabled")
+	CacheIgnoreQueryString string `json:"cacheignorequerystring"`
+	CacheKeyDevice         string `json:"cachekeydevice"`
+
+	BlockBadBots string `json:"blockbadbots"` // "enabled"/"disabled"
+}
+
+type keyCDNZoneAlias struct {
+	ID     string `json:"id"`
+	ZoneID string `json:"zone_id"`
+	Name   string `json:"name"`
+}
+
+// NewKeyCDNProvider creates a new KeyCDN provider whose calls follow calls
+// (DefaultCallPolicy if nil)
+func NewKeyCDNProvider(calls *CallPolicy) (*KeyCDNProvider, error) {
+	apiKey := os.Getenv("KEYCDN_API_KEY")
+	if apiKey == "" {
+		return nil, fmt.Errorf("KEYCDN_API_KEY environment variable is required")
+	}
+
+	// KeyCDN uses HTTP basic auth with the API key as username and an empty password
+	api := newHTTPAdapter("keycdn", keyCDNBaseURL, calls, func(req *http.Request) {
+		req.SetBasicAuth(apiKey, "")
+	})
+
+	return &KeyCDNProvider{api: api}, nil
+}
+
+// CreateService creates a new pull zone pointing at the configured origin
+func (p *KeyCDNProvider) CreateService(ctx context.Context, config *ServiceConfig) (*domain.CDNService, error) {
+	req := keyCDNCreateRequest(config)
+
+	var resp keyCDNResponse[struct {
+		Zone keyCDNZone `json:"zone"`
+	}]
+	if err := p.api.do(ctx, http.MethodPost, "/zones.json", req, &resp); err != nil {
+		return nil, fmt.Errorf("failed to create KeyCDN zone: %w", err)
+	}
+
+	service := p.toService(resp.Data.Zone)
+	service.Config = buildProviderConfigJSON(domain.ProviderKeyCDN, resp.Data.Zone.ID, p.hostname(ctx, resp.Data.Zone), &config.Origin)
+	return &service, nil
+}
+
+// ListServices lists the active pull zones
+func (p *KeyCDNProvider) ListServices(ctx context.Context) ([]domain.CDNService, error) {
+	return collectServicesByStatus(ctx, p, StatusActive)
+}
+
+// ListServicesByStatus lists the pull zones that pass the status filter
+func (p *KeyCDNProvider) ListServicesByStatus(ctx context.Context, status StatusFilter) ([]domain.CDNService, error) {
+	return collectServicesByStatus(ctx, p, status)
+}
+
+// ForEachService streams all pull zones; KeyCDN returns every zone in one response
+func (p *KeyCDNProvider) ForEachService(ctx context.Context, fn func(svc domain.CDNService) error) error {
+	var resp keyCDNResponse[struct {
+		Zones []keyCDNZone `json:"zones"`
+	}]
+	if err := p.api.do(ctx, http.MethodGet, "/zones.json", nil, &resp); err != nil {
+		return fmt.Errorf("failed to list zones: %w", err)
+	}
+
+	for _, zone := range resp.Data.Zones {
+		svc := p.toService(zone)
+		svc.Config = buildProviderConfigJSON(domain.ProviderKeyCDN, zone.ID, p.hostname(ctx, zone), nil)
+		if err := fn(svc); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// UpdateService updates origin and default expiry of a zone
+func (p *KeyCDNProvider) UpdateService(ctx context.Context, serviceID string, config *ServiceConfig) error {
+	req := keyCDNUpdateRequest(config)
+	if err := p.api.do(ctx, http.MethodPut, "/zones/"+url.PathEscape(serviceID)+".json", req, nil); err != nil {
+		return fmt.Errorf("failed to update service: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteService deletes a pull zone
+func (p *KeyCDNProvider) DeleteService(ctx context.Context, serviceID string) error {
+	if err := p.api.do(ctx, http.MethodDelete, "/zones/"+url.PathEscape(serviceID)+".json", nil, nil); err != nil {
+		return fmt.Errorf("failed to delete service: %w", err)
+	}
+
+	return nil
+}
+
+// AddDomain adds a zone alias (custom domain) to the zone
+func (p *KeyCDNProvider) AddDomain(ctx context.Context, serviceID, domainName string) error {
+	req := map[string]interface{}{
+		"zone_id": serviceID,
+		"name":    domainName,
+	}
+
+	if err := p.api.do(ctx, http.MethodPost, "/zonealiases.json", req, nil); err != nil {
+		return fmt.Errorf("failed to add domain %s: %w", domainName, err)
+	}
+
+	return nil
+}
+
+// RemoveDomain removes a zone alias from the zone
+func (p *KeyCDNProvider) RemoveDomain(ctx context.Context, serviceID, domainName string) error {
+	aliases, err := p.listAliases(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, alias := range aliases {
+		if alias.ZoneID == serviceID && alias.Name == domainName {
+			if err := p.api.do(ctx, http.MethodDelete, "/zonealiases/"+url.PathEscape(alias.ID)+".json", nil, nil); err != nil {
+				return fmt.Errorf("failed to remove domain: %w", err)
+			}
+			return nil
+		}
+	}
+
+	return fmt.Errorf("domain %s not found", domainName)
+}
+
+// ListDomains lists the zone aliases of a zone
+func (p *KeyCDNProvider) ListDomains(ctx context.Context, serviceID string) ([]domain.Domain, error) {
+	return collectDomains(ctx, p, serviceID)
+}
+
+// ForEachDomain streams the zone aliases of a zone
+func (p *KeyCDNProvider) ForEachDomain(ctx context.Context, serviceID string, fn func(d domain.Domain) error) error {
+	aliases, err := p.listAliases(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, alias := range aliases {
+		if alias.ZoneID != serviceID {
+			continue
+		}
+		err := fn(domain.Domain{
+			ID:           alias.ID,
+			CDNServiceID: serviceID,
+			Name:         alias.Name,
+			Status:       "active", // KeyCDN aliases are active once created
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PurgeCache purges specific URLs from the zone
+func (p *KeyCDNProvider) PurgeCache(ctx context.Context, serviceID string, paths []string) error {
+	req := map[string]interface{}{
+		"urls": paths,
+	}
+
+	if err := p.api.do(ctx, http.MethodDelete, "/zones/purgeurl/"+url.PathEscape(serviceID)+".json", req, nil); err != nil {
+		return fmt.Errorf("failed to purge cache: %w", err)
+	}
+
+	return nil
+}
+
+// PurgeAll purges the complete zone cache
+func (p *KeyCDNProvider) PurgeAll(ctx context.Context, serviceID string) error {
+	if err := p.api.do(ctx, http.MethodGet, "/zones/purge/"+url.PathEscape(serviceID)+".json", nil, nil); err != nil {
+		return fmt.Errorf("failed to purge all cache: %w", err)
+	}
+
+	return nil
+}
+
+// GetMetrics retrieves cache hit statistics for the last 24 hours
+func (p *KeyCDNProvider) GetMetrics(ctx context.Context, serviceID string) (*domain.Metrics, error) {
+	end := time.Now()
+	start := end.Add(-24 * time.Hour)
+	query := url.Values{
+		"zone_id": {serviceID},
+		"start":   {strconv.FormatInt(start.Unix(), 10)},
+		"end":     {strconv.FormatInt(end.Unix(), 10)},
+	}
+	path := "/reports/statestats.json?" + query.Encode()
+
+	var resp keyCDNResponse[struct {
+		Stats []struct {
+			Totalcachehit  int64 `json:"totalcachehit"`
+			Totalcachemiss int64 `json:"totalcachemiss"`
+		} `json:"stats"`
+	}]
+	if err := p.api.do(ctx, http.MethodGet, path, nil, &resp); err != nil {
+		return nil, fmt.Errorf("failed to get metrics: %w", err)
+	}
+
+	var hits, misses int64
+	for _, s := range resp.Data.Stats {
+		hits += s.Totalcachehit
+		misses += s.Totalcachemiss
+	}
+
+	metrics := &domain.Metrics{
+		CDNServiceID:  serviceID,
+		TotalRequests: hits + misses,
+		Timestamp:     end,
+	}
+	if total := hits + misses; total > 0 {
+		metrics.CacheHitRatio = float64(hits) / float64(total)
+	}
+
+	return metrics, nil
+}
+
+// UpdateCacheRules sets the zone expiry; KeyCDN zones have a single default expiry
+func (p *KeyCDNProvider) UpdateCacheRules(ctx context.Context, serviceID string, rules []CacheRule) error {
+	if len(rules) == 0 {
+		return nil
+	}
+
+	req := map[string]interface{}{
+		"expire": ttlMinutes(rules[0].TTL),
+	}
+	if err := p.api.do(ctx, http.MethodPut, "/zones/"+url.PathEscape(serviceID)+".json", req, nil); err != nil {
+		return fmt.Errorf("failed to update cache rules: %w", err)
+	}
+
+	return nil
+}
+
+// DryRunCreateService returns the zone CreateService would create
+func (p *KeyCDNProvider) DryRunCreateService(ctx context.Context, config *ServiceConfig) (*DryRun, error) {
+	return newDryRun("keycdn", "create_service", "",
+		ProviderRequest{Method: http.MethodPost, Path: "/zones.json", Body: keyCDNCreateRequest(config)},
+	), nil
+}
+
+// DryRunUpdateService returns the zone update UpdateService would send
+func (p *KeyCDNProvider) DryRunUpdateService(ctx context.Context, serviceID string, config *ServiceConfig) (*DryRun, error) {
+	return newDryRun("keycdn", "update_service", serviceID,
+		ProviderRequest{Method: http.MethodPut, Path: "/zones/" + serviceID + ".json", Body: keyCDNUpdateRequest(config)},
+	), nil
+}
+
+// DryRunCacheRules returns the expiry update UpdateCacheRules would send
+func (p *KeyCDNProvider) DryRunCacheRules(ctx context.Context, serviceID string, rules []CacheRule) (*DryRun, error) {
+	run := newDryRun("keycdn", "update_cache_rules", serviceID)
+	if len(rules) > 0 {
+		run.Requests = append(run.Requests, ProviderRequest{
+			Method: http.MethodPut,
+			Path:   "/zones/" + serviceID + ".json",
+			Body:   map[string]interface{}{"expire": ttlMinutes(rules[0].TTL)},
+		})
+	}
+	return run, nil
+}
+
+// keyCDNCreateRequest is the pull zone payload for a service config
+func keyCDNCreateRequest(config *ServiceConfig) map[string]interface{} {
+	req := map[string]interface{}{
+		"name":      generateServiceName(config.Name),
+		"type":      "pull",
+		"originurl": originURL(config.Origin),
+	}
+	if len(config.Rules) > 0 {
+		req["expire"] = ttlMinutes(config.Rules[0].TTL)
+	}
+	return req
+}
+
+// keyCDNUpdateRequest is the zone update payload for a service config
+func keyCDNUpdateRequest(config *ServiceConfig) map[string]interface{} {
+	req := map[string]interface{}{
+		"originurl": originURL(config.Origin),
+	}
+	if len(config.Rules) > 0 {
+		req["expire"] = ttlMinutes(config.Rules[0].TTL)
+	}
+	return req
+}
+
+// UploadCertificate switches a zone to a custom certificate
+func (p *KeyCDNProvider) UploadCertificate(ctx context.Context, serviceID string, cert Certificate) error {
+	req := map[string]interface{}{
+		"sslcert":       "custom",
+		"customsslcert": cert.CertificatePEM,
+		"customsslkey":  cert.PrivateKeyPEM,
+	}
+	if err := p.api.do(ctx, http.MethodPut, "/zones/"+url.PathEscape(serviceID)+".json", req, nil); err != nil {
+		return fmt.Errorf("failed to upload certificate: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateOriginSettings updates the origin URL of a zone
+func (p *KeyCDNProvider) UpdateOriginSettings(ctx context.Context, serviceID string, origin OriginConfig) error {
+	req := map[string]interface{}{
+		"originurl": originURL(origin),
+	}
+	if err := p.api.do(ctx, http.MethodPut, "/zones/"+url.PathEscape(serviceID)+".json", req, nil); err != nil {
+		return fmt.Errorf("failed to update origin settings: %w", err)
+	}
+
+	return nil
+}
+
+// CacheKeySupport reports the cache-key features KeyCDN zones can express
+func (p *KeyCDNProvider) CacheKeySupport() CacheKeySupport {
+	return CacheKeySupport{
+		QueryParamModes: []string{QueryParamsAll, QueryParamsNone},
+		DeviceSplit:     true,
+	}
+}
+
+// GetCacheKey reads the zone's query string and device cache-key settings
+func (p *KeyCDNProvider) GetCacheKey(ctx context.Context, serviceID string) (*CacheKeyConfig, error) {
+	var resp keyCDNResponse[struct {
+		Zone keyCDNZone `json:"zone"`
+	}]
+	if err := p.api.do(ctx, http.MethodGet, "/zones/"+url.PathEscape(serviceID)+".json", nil, &resp); err != nil {
+		return nil, fmt.Errorf("failed to get zone: %w", err)
+	}
+
+	config := &CacheKeyConfig{
+		QueryParams: QueryParamsAll,
+		DeviceSplit: resp.Data.Zone.CacheKeyDevice == "enabled",
+	}
+	if resp.Data.Zone.CacheIgnoreQueryString == "enabled" {
+		config.QueryParams = QueryParamsNone
+	}
+
+	return config, nil
+}
+
+// UpdateCacheKey sets the zone's query string and device cache-key settings
+func (p *KeyCDNProvider) UpdateCacheKey(ctx context.Context, serviceID string, config CacheKeyConfig) error {
+	req := map[string]interface{}{
+		"cacheignorequerystring": enabledFlag(config.QueryParams == QueryParamsNone),
+		"cachekeydevice":         enabledFlag(config.DeviceSplit),
+	}
+	if err := p.api.do(ctx, http.MethodPut, "/zones/"+url.PathEscape(serviceID)+".json", req, nil); err != nil {
+		return fmt.Errorf("failed to update cache key: %w", err)
+	}
+
+	return nil
+}
+
+// FirewallSupport reports KeyCDN's bad-bot blocking; IP and country blocking
+// are account-level features outside the zone API
+func (p *KeyCDNProvider) FirewallSupport() FirewallSupport {
+	return FirewallSupport{BotProtection: true, ManagedRuleSets: []string{}}
+}
+
+// GetFirewall reads the zone's bad-bot blocking setting
+func (p *KeyCDNProvider) GetFirewall(ctx context.Context, serviceID string) (*FirewallConfig, error) {
+	var resp keyCDNResponse[struct {
+		Zone keyCDNZone `json:"zone"`
+	}]
+	if err := p.api.do(ctx, http.MethodGet, "/zones/"+url.PathEscape(serviceID)+".json", nil, &resp); err != nil {
+		return nil, fmt.Errorf("failed to get zone: %w", err)
+	}
+
+	return &FirewallConfig{
+		BlockedIPs:       []string{},
+		BlockedCountries: []string{},
+		ManagedRuleSets:  []string{},
+		BotProtection:    resp.Data.Zone.BlockBadBots == "enabled",
+	}, nil
+}
+
+// UpdateFirewall sets the zone's bad-bot blocking
+func (p *KeyCDNProvider) UpdateFirewall(ctx context.Context, serviceID string, config FirewallConfig) error {
+	req := map[string]interface{}{
+		"blockbadbots": enabledFlag(config.BotProtection),
+	}
+	if err := p.api.do(ctx, http.MethodPut, "/zones/"+url.PathEscape(serviceID)+".json", req, nil); err != nil {
+		return fmt.Errorf("failed to update firewall: %w", err)
+	}
+
+	return nil
+}
+
+// Helper functions
+
+func (p *KeyCDNProvider) listAliases(ctx context.Context) ([]keyCDNZoneAlias, error) {
+	var resp keyCDNResponse[struct {
+		Zonealiases []keyCDNZoneAlias `json:"zonealiases"`
+	}]
+	if err := p.api.do(ctx, http.MethodGet, "/zonealiases.json", nil, &resp); err != nil {
+		return nil, fmt.Errorf("failed to list domains: %w", err)
+	}
+	return resp.Data.Zonealiases, nil
+}
+
+func (p *KeyCDNProvider) toService(zone keyCDNZone) domain.CDNService {
+	return domain.CDNService{
+		ID:       zone.ID,
+		Provider: domain.ProviderKeyCDN,
+		Name:     zone.Name,
+		Status:   strings.ToUpper(zone.Status),
+	}
+}
+
+// hostname returns the default zone hostname, {zone name}-{user ID}.kxcdn.com.
+// It is empty when the user ID can't be fetched, rather than a wrong CNAME.
+func (p *KeyCDNProvider) hostname(ctx context.Context, zone keyCDNZone) string {
+	userID, err := p.accountUserID(ctx)
+	if err != nil {
+		logrus.WithError(err).WithField("zone_id", zone.ID).Warn("⚠️ Failed to get KeyCDN user ID, zone hostname unknown")
+		return ""
+	}
+	return fmt.Sprintf("%s-%s.kxcdn.com", zone.Name, userID)
+}
+
+// accountUserID returns the ID of the account the API key belongs to
+func (p *KeyCDNProvider) accountUserID(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.userID != "" {
+		return p.userID, nil
+	}
+
+	var resp keyCDNResponse[struct {
+		User struct {
+			ID string `json:"id"`
+		} `json:"user"`
+	}]
+	if err := p.api.do(ctx, http.MethodGet, "/user.json", nil, &resp); err != nil {
+		return "", fmt.Errorf("failed to get user: %w", err)
+	}
+	if resp.Data.User.ID == "" {
+		return "", fmt.Errorf("failed to get user: response has no user ID")
+	}
+
+	p.userID = resp.Data.User.ID
+	return p.userID, nil
+}
+
+// originURL renders an OriginConfig as a URL
+func originURL(origin OriginConfig) string {
+	scheme := "https"
+	if origin.Protocol != "" {
+		scheme = strings.ToLower(origin.Protocol)
+	}
+
+	host := origin.Host
+	if origin.Port != 0 {
+		host = fmt.Sprintf("%s:%d", host, origin.Port)
+	}
+
+	return fmt.Sprintf("%s://%s%s", scheme, host, origin.Path)
+}
+
+// ttlMinutes converts a TTL in seconds into whole minutes (at least one)
+func ttlMinutes(seconds int) int {
+	minutes := seconds / 60
+	if minutes < 1 {
+		return 1
+	}
+	return minutes
+}
+
+// enabledFlag renders a bool the way KeyCDN zone settings expect
+func enabledFlag(on bool) string {
+	if on {
+		return "enabled"
+	}
+	return "disabled"
+}
@@ -0,0 +1,120 @@
+package cdn
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Log formats a provider can deliver access logs in
+const (
+	LogFormatJSON     = "json"     // one JSON object per line
+	LogFormatCombined = "combined" // Apache combined log format
+)
+
+// Log destination types
+const (
+	LogDestinationProvider = "provider" // kept by the provider for download
+	LogDestinationS3       = "s3"
+)
+
+// LogDelivery configures where and how a provider ships access logs
+type LogDelivery struct {
+	Enabled     bool           `json:"enabled"`
+	Format      string         `json:"format"`
+	Destination LogDestination `json:"destination"`
+}
+
+// LogDestination is where delivered log files end up
+type LogDestination struct {
+	Type   string `json:"type"`
+	Bucket string `json:"bucket,omitempty"`
+	Prefix string `json:"prefix,omitempty"`
+	Region string `json:"region,omitempty"`
+}
+
+// LogFile is one delivered access log file
+type LogFile struct {
+	ServiceID string    `json:"service_id"`
+	Name      string    `json:"name"`
+	URL       string    `json:"url"`
+	Size      int64     `json:"size"`
+	Gzipped   bool      `json:"gzipped"`
+	Format    string    `json:"format"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// LogDeliveryConfigurer is implemented by providers that can ship access logs
+type LogDeliveryConfigurer interface {
+	GetLogDelivery(ctx context.Context, serviceID string) (*LogDelivery, error)
+	UpdateLogDelivery(ctx context.Context, serviceID string, delivery LogDelivery) error
+}
+
+// LogSource is implemented by providers whose delivered logs we can download
+type LogSource interface {
+	ListLogFiles(ctx context.Context, serviceID string, since time.Time) ([]LogFile, error)
+	OpenLogFile(ctx context.Context, file LogFile) (io.ReadCloser, error)
+}
+
+// Validate checks the format and destination
+func (d LogDelivery) Validate() error {
+	if !d.Enabled {
+		return nil
+	}
+	if d.Format != LogFormatJSON && d.Format != LogFormatCombined {
+		return fmt.Errorf("invalid format %q (expected %s or %s)", d.Format, LogFormatJSON, LogFormatCombined)
+	}
+	switch d.Destination.Type {
+	case LogDestinationProvider:
+	case LogDestinationS3:
+		if d.Destination.Bucket == "" {
+			return fmt.Errorf("bucket is required for s3 destinations")
+		}
+	default:
+		return fmt.Errorf("invalid destination type %q (expected %s or %s)", d.Destination.Type, LogDestinationProvider, LogDestinationS3)
+	}
+	return nil
+}
+
+// GetLogDelivery returns the access log delivery settings of a service
+func (s *Service) GetLogDelivery(ctx context.Context, serviceID string) (*LogDelivery, error) {
+	configurer, ok := s.provider.(LogDeliveryConfigurer)
+	if !ok {
+		return nil, fmt.Errorf("log delivery: %w", ErrNotSupported)
+	}
+	return configurer.GetLogDelivery(ctx, serviceID)
+}
+
+// UpdateLogDelivery validates and applies access log delivery settings
+func (s *Service) UpdateLogDelivery(ctx context.Context, serviceID string, delivery LogDelivery) error {
+	configurer, ok := s.provider.(LogDeliveryConfigurer)
+	if !ok {
+		return fmt.Errorf("log delivery: %w", ErrNotSupported)
+	}
+	if err := delivery.Validate(); err != nil {
+		return err
+	}
+	if err := s.guardChange(ctx, serviceID, "log_delivery"); err != nil {
+		return err
+	}
+	return configurer.UpdateLogDelivery(ctx, serviceID, delivery)
+}
+
+// ListLogFiles returns log files delivered for a service since a point in time
+func (s *Service) ListLogFiles(ctx context.Context, serviceID string, since time.Time) ([]LogFile, error) {
+	source, ok := s.provider.(LogSource)
+	if !ok {
+		return nil, fmt.Errorf("log download: %w", ErrNotSupported)
+	}
+	return source.ListLogFiles(ctx, serviceID, since)
+}
+
+// OpenLogFile streams a delivered log file; the caller closes it
+func (s *Service) OpenLogFile(ctx context.Context, file LogFile) (io.ReadCloser, error) {
+	source, ok := s.provider.(LogSource)
+	if !ok {
+		return nil, fmt.Errorf("log download: %w", ErrNotSupported)
+	}
+	return source.OpenLogFile(ctx, file)
+}
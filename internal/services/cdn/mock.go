@@ -0,0 +1,728 @@
+package cdn
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/avvvet/cdnbuddy-api/internal/domain"
+	"github.com/google/uuid"
+)
+
+// MockProvider is an in-memory CDNProvider used for sandbox tenants and tests.
+// It never talks to a real CDN and returns plausible, stable metrics per service.
+type MockProvider struct {
+	services map[string]*mockService
+	order    []string // creation order, so listings are stable
+	history  *configHistory
+	mu       sync.RWMutex
+}
+
+type mockService struct {
+	service  domain.CDNService
+	origin   OriginConfig
+	rules    []CacheRule
+	ssl      SSLConfig
+	domains  []domain.Domain
+	purges   int
+	cacheKey CacheKeyConfig
+	stale    StalePolicy
+	load     OriginLoadOptions
+	logs     LogDelivery
+	security SecuritySettings
+	firewall FirewallConfig
+	hotlink  HotlinkConfig
+	headers  []ResponseHeader
+	tls      TLSPolicy
+}
+
+// NewMockProvider creates an empty mock provider
+func NewMockProvider() *MockProvider {
+	return &MockProvider{
+		services: make(map[string]*mockService),
+		history:  newConfigHistory(domain.ProviderMock),
+	}
+}
+
+// SeedDemoData creates a few realistic services so a sandbox has something to show
+func (p *MockProvider) SeedDemoData(ctx context.Context) error {
+	demo := []struct {
+		name    string
+		origin  string
+		domains []string
+	}{
+		{name: "shop.example.com", origin: "origin.shop.example.com", domains: []string{"shop.example.com", "static.shop.example.com"}},
+		{name: "blog.example.com", origin: "blog-origin.example.com", domains: []string{"blog.example.com"}},
+		{name: "video.example.com", origin: "media.example.com", domains: []string{"video.example.com"}},
+	}
+
+	for _, d := range demo {
+		svc, err := p.CreateService(ctx, &ServiceConfig{
+			Name:   d.name,
+			Origin: OriginConfig{Host: d.origin, Protocol: "https"},
+			SSL:    SSLConfig{Enabled: true},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to seed service %s: %w", d.name, err)
+		}
+
+		for _, name := range d.domains {
+			if err := p.AddDomain(ctx, svc.ID, name); err != nil {
+				return fmt.Errorf("failed to seed domain %s: %w", name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// CreateService creates an in-memory service
+func (p *MockProvider) CreateService(ctx context.Context, config *ServiceConfig) (*domain.CDNService, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	id := uuid.New().String()
+	uniqueName := fmt.Sprintf("%s-%s", generateServiceName(config.Name), id[:8])
+	now := time.Now()
+
+	svc := &mockService{
+		service: domain.CDNService{
+			ID:        id,
+			Provider:  domain.ProviderMock,
+			Name:      config.Name,
+			Status:    "ACTIVE",
+			Config:    buildProviderConfigJSON(domain.ProviderMock, id, uniqueName+".sandbox.cdnbuddy.dev", &config.Origin),
+			CreatedAt: now,
+			UpdatedAt: now,
+		},
+		origin:  config.Origin,
+		rules:   config.Rules,
+		ssl:     config.SSL,
+		stale:   DefaultStalePolicy,
+		headers: config.Headers,
+		security: SecuritySettings{
+			MinTLSVersion:  "1.2",
+			AllowedMethods: []string{"GET", "HEAD", "POST", "OPTIONS"},
+		},
+	}
+	if config.Stale != nil {
+		svc.stale = *config.Stale
+	}
+	if config.OriginShield != nil {
+		svc.load = config.OriginShield.Options()
+	}
+	svc.tls = TLSPolicy{MinVersion: "1.2", HTTP2: boolPtr(true), HTTP3: boolPtr(false)}
+	if config.TLS != nil {
+		applyMockTLS(svc, *config.TLS)
+	}
+
+	p.services[id] = svc
+	p.order = append(p.order, id)
+
+	service := svc.service
+	return &service, nil
+}
+
+// ListServices lists the active in-memory services
+func (p *MockProvider) ListServices(ctx context.Context) ([]domain.CDNService, error) {
+	return collectServicesByStatus(ctx, p, StatusActive)
+}
+
+// ListServicesByStatus lists the in-memory services that pass the status filter
+func (p *MockProvider) ListServicesByStatus(ctx context.Context, status StatusFilter) ([]domain.CDNService, error) {
+	return collectServicesByStatus(ctx, p, status)
+}
+
+// ForEachService streams all in-memory services in creation order
+func (p *MockProvider) ForEachService(ctx context.Context, fn func(svc domain.CDNService) error) error {
+	p.mu.RLock()
+	services := make([]domain.CDNService, 0, len(p.order))
+	for _, id := range p.order {
+		if svc, ok := p.services[id]; ok {
+			services = append(services, svc.service)
+		}
+	}
+	p.mu.RUnlock()
+
+	for _, svc := range services {
+		if err := fn(svc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UpdateService replaces origin and rules of a service
+func (p *MockProvider) UpdateService(ctx context.Context, serviceID string, config *ServiceConfig) error {
+	return p.updateConfig(serviceID, "update_service", func(svc *mockService) {
+		svc.origin = config.Origin
+		svc.rules = config.Rules
+	})
+}
+
+// DeleteService deactivates a service, mirroring CacheFly semantics
+func (p *MockProvider) DeleteService(ctx context.Context, serviceID string) error {
+	return p.update(serviceID, func(svc *mockService) {
+		svc.service.Status = "INACTIVE"
+	})
+}
+
+// ReactivateService marks a deleted service active again
+func (p *MockProvider) ReactivateService(ctx context.Context, serviceID string) error {
+	return p.update(serviceID, func(svc *mockService) {
+		svc.service.Status = "ACTIVE"
+	})
+}
+
+// AddDomain adds a domain to a service
+func (p *MockProvider) AddDomain(ctx context.Context, serviceID, domainName string) error {
+	return p.update(serviceID, func(svc *mockService) {
+		now := time.Now()
+		svc.domains = append(svc.domains, domain.Domain{
+			ID:           uuid.New().String(),
+			CDNServiceID: serviceID,
+			Name:         domainName,
+			Status:       "ACTIVE",
+			CreatedAt:    now,
+			UpdatedAt:    now,
+		})
+	})
+}
+
+// RemoveDomain removes a domain from a service
+func (p *MockProvider) RemoveDomain(ctx context.Context, serviceID, domainName string) error {
+	found := false
+	err := p.update(serviceID, func(svc *mockService) {
+		for i, d := range svc.domains {
+			if d.Name == domainName {
+				svc.domains = append(svc.domains[:i], svc.domains[i+1:]...)
+				found = true
+				return
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("domain %s not found", domainName)
+	}
+	return nil
+}
+
+// SetDomainStatus sets the status of one of a service's domains
+func (p *MockProvider) SetDomainStatus(ctx context.Context, serviceID, domainName, status string) error {
+	found := false
+	err := p.update(serviceID, func(svc *mockService) {
+		for i, d := range svc.domains {
+			if d.Name == domainName {
+				svc.domains[i].Status, svc.domains[i].UpdatedAt = status, time.Now()
+				found = true
+				return
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("domain %s not found", domainName)
+	}
+	return nil
+}
+
+// ListDomains lists the domains of a service
+func (p *MockProvider) ListDomains(ctx context.Context, serviceID string) ([]domain.Domain, error) {
+	return collectDomains(ctx, p, serviceID)
+}
+
+// ForEachDomain streams the domains of a service
+func (p *MockProvider) ForEachDomain(ctx context.Context, serviceID string, fn func(d domain.Domain) error) error {
+	p.mu.RLock()
+	svc, ok := p.services[serviceID]
+	if !ok {
+		p.mu.RUnlock()
+		return fmt.Errorf("service %s not found", serviceID)
+	}
+	domains := append([]domain.Domain(nil), svc.domains...)
+	p.mu.RUnlock()
+
+	for _, d := range domains {
+		if err := fn(d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PurgeCache records a purge
+func (p *MockProvider) PurgeCache(ctx context.Context, serviceID string, paths []string) error {
+	return p.update(serviceID, func(svc *mockService) { svc.purges++ })
+}
+
+// PurgeAll records a purge
+func (p *MockProvider) PurgeAll(ctx context.Context, serviceID string) error {
+	return p.update(serviceID, func(svc *mockService) { svc.purges++ })
+}
+
+// GetMetrics returns seeded metrics; values are stable per service with a little
+// jitter over time so dashboards look alive
+func (p *MockProvider) GetMetrics(ctx context.Context, serviceID string) (*domain.Metrics, error) {
+	p.mu.RLock()
+	svc, ok := p.services[serviceID]
+	p.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("service %s not found", serviceID)
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(serviceID))
+	base := rand.New(rand.NewSource(int64(h.Sum64())))
+	jitter := rand.New(rand.NewSource(time.Now().Unix() / 60))
+
+	hitRatio := 0.80 + base.Float64()*0.15 - float64(svc.purges)*0.02
+	if hitRatio < 0.5 {
+		hitRatio = 0.5
+	}
+
+	return &domain.Metrics{
+		ID:              uuid.New().String(),
+		CDNServiceID:    serviceID,
+		CacheHitRatio:   hitRatio + (jitter.Float64()-0.5)*0.02,
+		AvgResponseTime: 20 + base.Intn(60) + jitter.Intn(10),
+		TotalRequests:   100000 + base.Int63n(5000000),
+		Timestamp:       time.Now(),
+	}, nil
+}
+
+// GetAnalytics returns plausible traffic per bucket; each service has a stable
+// base rate, and a bucket's values only depend on the service and its start
+func (p *MockProvider) GetAnalytics(ctx context.Context, serviceID string, start, end time.Time, interval time.Duration) ([]AnalyticsBucket, error) {
+	p.mu.RLock()
+	svc, ok := p.services[serviceID]
+	p.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("service %s not found", serviceID)
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(serviceID))
+	seed := int64(h.Sum64())
+	base := rand.New(rand.NewSource(seed))
+	perMinute := 50 + base.Int63n(3000)
+	objectSize := 20*1024 + base.Int63n(200*1024)
+	hitRatio := 0.80 + base.Float64()*0.15 - float64(svc.purges)*0.02
+	if hitRatio < 0.5 {
+		hitRatio = 0.5
+	}
+
+	buckets := make([]AnalyticsBucket, 0, end.Sub(start)/interval+1)
+	for t := start; t.Before(end); t = t.Add(interval) {
+		jitter := rand.New(rand.NewSource(seed ^ t.Unix()))
+		requests := int64(float64(perMinute) * interval.Minutes() * (0.7 + jitter.Float64()*0.6))
+		hits := int64(float64(requests) * (hitRatio + (jitter.Float64()-0.5)*0.04))
+		buckets = append(buckets, AnalyticsBucket{
+			Start:       t,
+			Requests:    requests,
+			CacheHits:   hits,
+			CacheMisses: requests - hits,
+			Bytes:       requests * objectSize,
+		})
+	}
+	return buckets, nil
+}
+
+// UpdateCacheRules replaces the cache rules of a service
+func (p *MockProvider) UpdateCacheRules(ctx context.Context, serviceID string, rules []CacheRule) error {
+	return p.updateConfig(serviceID, "update_cache_rules", func(svc *mockService) { svc.rules = rules })
+}
+
+// mockConfig is the part of a mock service configuration changes replace
+type mockConfig struct {
+	Origin OriginConfig `json:"origin"`
+	Rules  []CacheRule  `json:"rules"`
+}
+
+// updateConfig applies a configuration change, keeping the previous one for rollback
+func (p *MockProvider) updateConfig(serviceID, operation string, fn func(svc *mockService)) error {
+	var previous mockConfig
+	err := p.update(serviceID, func(svc *mockService) {
+		previous = mockConfig{Origin: svc.origin, Rules: svc.rules}
+		fn(svc)
+	})
+	if err != nil {
+		return err
+	}
+	return p.history.record(serviceID, operation, previous)
+}
+
+// LastConfigChange returns the configuration a service had before its last update
+func (p *MockProvider) LastConfigChange(ctx context.Context, serviceID string) (*ConfigChange, error) {
+	return p.history.last(serviceID)
+}
+
+// RollbackConfig restores the configuration a service had before its last update
+func (p *MockProvider) RollbackConfig(ctx context.Context, serviceID string) (*ConfigChange, error) {
+	change, err := p.history.last(serviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	var previous mockConfig
+	if err := json.Unmarshal(change.Previous, &previous); err != nil {
+		return nil, fmt.Errorf("failed to decode previous configuration: %w", err)
+	}
+	if err := p.update(change.ServiceID, func(svc *mockService) {
+		svc.origin = previous.Origin
+		svc.rules = previous.Rules
+	}); err != nil {
+		return nil, err
+	}
+
+	p.history.forget(change)
+	return change, nil
+}
+
+// UpdateOriginSettings replaces the origin of a service
+func (p *MockProvider) UpdateOriginSettings(ctx context.Context, serviceID string, origin OriginConfig) error {
+	return p.update(serviceID, func(svc *mockService) { svc.origin = origin })
+}
+
+// CacheKeySupport reports that the mock supports every cache-key feature
+func (p *MockProvider) CacheKeySupport() CacheKeySupport {
+	return CacheKeySupport{
+		QueryParamModes: []string{QueryParamsAll, QueryParamsNone, QueryParamsInclude, QueryParamsExclude},
+		VaryHeaders:     true,
+		VaryCookies:     true,
+		DeviceSplit:     true,
+	}
+}
+
+// GetCacheKey returns the stored cache-key config of a service
+func (p *MockProvider) GetCacheKey(ctx context.Context, serviceID string) (*CacheKeyConfig, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	svc, ok := p.services[serviceID]
+	if !ok {
+		return nil, fmt.Errorf("service %s not found", serviceID)
+	}
+
+	config := svc.cacheKey
+	if config.QueryParams == "" {
+		config.QueryParams = QueryParamsAll
+	}
+	return &config, nil
+}
+
+// UpdateCacheKey stores the cache-key config of a service
+func (p *MockProvider) UpdateCacheKey(ctx context.Context, serviceID string, config CacheKeyConfig) error {
+	return p.update(serviceID, func(svc *mockService) { svc.cacheKey = config })
+}
+
+// FirewallSupport reports every firewall feature, with a few sample rule sets
+func (p *MockProvider) FirewallSupport() FirewallSupport {
+	return FirewallSupport{
+		IPBlocking:      true,
+		CountryBlocking: true,
+		ManagedRuleSets: []string{"owasp-core", "php", "wordpress"},
+		BotProtection:   true,
+	}
+}
+
+// GetFirewall returns the stored firewall config of a service
+func (p *MockProvider) GetFirewall(ctx context.Context, serviceID string) (*FirewallConfig, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	svc, ok := p.services[serviceID]
+	if !ok {
+		return nil, fmt.Errorf("service %s not found", serviceID)
+	}
+
+	config := FirewallConfig{
+		BlockedIPs:       append([]string{}, svc.firewall.BlockedIPs...),
+		BlockedCountries: append([]string{}, svc.firewall.BlockedCountries...),
+		ManagedRuleSets:  append([]string{}, svc.firewall.ManagedRuleSets...),
+		BotProtection:    svc.firewall.BotProtection,
+	}
+	return &config, nil
+}
+
+// UpdateFirewall stores the firewall config of a service
+func (p *MockProvider) UpdateFirewall(ctx context.Context, serviceID string, config FirewallConfig) error {
+	return p.update(serviceID, func(svc *mockService) { svc.firewall = config })
+}
+
+// GetHotlinkProtection returns the stored referrer rules of a service
+func (p *MockProvider) GetHotlinkProtection(ctx context.Context, serviceID string) (*HotlinkConfig, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	svc, ok := p.services[serviceID]
+	if !ok {
+		return nil, fmt.Errorf("service %s not found", serviceID)
+	}
+
+	config := svc.hotlink
+	config.AllowedReferrers = append([]string{}, svc.hotlink.AllowedReferrers...)
+	return &config, nil
+}
+
+// UpdateHotlinkProtection stores the referrer rules of a service
+func (p *MockProvider) UpdateHotlinkProtection(ctx context.Context, serviceID string, config HotlinkConfig) error {
+	return p.update(serviceID, func(svc *mockService) { svc.hotlink = config })
+}
+
+// GetResponseHeaders returns the stored response headers of a service
+func (p *MockProvider) GetResponseHeaders(ctx context.Context, serviceID string) ([]ResponseHeader, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	svc, ok := p.services[serviceID]
+	if !ok {
+		return nil, fmt.Errorf("service %s not found", serviceID)
+	}
+	return append([]ResponseHeader{}, svc.headers...), nil
+}
+
+// UpdateResponseHeaders stores the response headers of a service
+func (p *MockProvider) UpdateResponseHeaders(ctx context.Context, serviceID string, headers []ResponseHeader) error {
+	return p.update(serviceID, func(svc *mockService) { svc.headers = headers })
+}
+
+// UploadCertificate installs a custom certificate and turns on SSL
+func (p *MockProvider) UploadCertificate(ctx context.Context, serviceID string, cert Certificate) error {
+	return p.update(serviceID, func(svc *mockService) {
+		svc.ssl = SSLConfig{Enabled: true, Certificate: cert.CertificatePEM, PrivateKey: cert.PrivateKeyPEM}
+	})
+}
+
+// TLSSupport reports every protocol setting
+func (p *MockProvider) TLSSupport() TLSSupport {
+	return TLSSupport{MinVersions: []string{"1.0", "1.1", "1.2", "1.3"}, HTTP2: true, HTTP3: true}
+}
+
+// GetTLSPolicy returns the stored protocol settings of a service
+func (p *MockProvider) GetTLSPolicy(ctx context.Context, serviceID string) (*TLSPolicy, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	svc, ok := p.services[serviceID]
+	if !ok {
+		return nil, fmt.Errorf("service %s not found", serviceID)
+	}
+	policy := TLSPolicy{MinVersion: svc.tls.MinVersion, HTTP2: boolPtr(*svc.tls.HTTP2), HTTP3: boolPtr(*svc.tls.HTTP3)}
+	return &policy, nil
+}
+
+// UpdateTLSPolicy stores the protocol settings of a service
+func (p *MockProvider) UpdateTLSPolicy(ctx context.Context, serviceID string, policy TLSPolicy) error {
+	return p.update(serviceID, func(svc *mockService) { applyMockTLS(svc, policy) })
+}
+
+// applyMockTLS merges a policy into the stored one, keeping the security
+// settings' minimum version in sync
+func applyMockTLS(svc *mockService, policy TLSPolicy) {
+	svc.tls.MinVersion = policy.MinVersion
+	if policy.HTTP2 != nil {
+		svc.tls.HTTP2 = boolPtr(*policy.HTTP2)
+	}
+	if policy.HTTP3 != nil {
+		svc.tls.HTTP3 = boolPtr(*policy.HTTP3)
+	}
+	svc.security.MinTLSVersion = policy.MinVersion
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+// GetStalePolicy returns the stored stale policy of a service
+func (p *MockProvider) GetStalePolicy(ctx context.Context, serviceID string) (*StalePolicy, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	svc, ok := p.services[serviceID]
+	if !ok {
+		return nil, fmt.Errorf("service %s not found", serviceID)
+	}
+
+	policy := svc.stale
+	return &policy, nil
+}
+
+// UpdateStalePolicy stores the stale policy of a service
+func (p *MockProvider) UpdateStalePolicy(ctx context.Context, serviceID string, policy StalePolicy) error {
+	return p.update(serviceID, func(svc *mockService) { svc.stale = policy })
+}
+
+// OriginLoadSupport reports that the mock supports origin shield and request coalescing
+func (p *MockProvider) OriginLoadSupport() OriginLoadSupport {
+	return OriginLoadSupport{Shield: true, RequestCoalescing: true}
+}
+
+// GetOriginLoad returns the stored origin-load options of a service
+func (p *MockProvider) GetOriginLoad(ctx context.Context, serviceID string) (*OriginLoadOptions, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	svc, ok := p.services[serviceID]
+	if !ok {
+		return nil, fmt.Errorf("service %s not found", serviceID)
+	}
+
+	load := svc.load
+	return &load, nil
+}
+
+// UpdateOriginLoad stores the origin-load options of a service
+func (p *MockProvider) UpdateOriginLoad(ctx context.Context, serviceID string, options OriginLoadOptions) error {
+	return p.update(serviceID, func(svc *mockService) { svc.load = options })
+}
+
+// GetLogDelivery returns the stored log delivery settings of a service
+func (p *MockProvider) GetLogDelivery(ctx context.Context, serviceID string) (*LogDelivery, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	svc, ok := p.services[serviceID]
+	if !ok {
+		return nil, fmt.Errorf("service %s not found", serviceID)
+	}
+
+	logs := svc.logs
+	return &logs, nil
+}
+
+// UpdateLogDelivery stores the log delivery settings of a service
+func (p *MockProvider) UpdateLogDelivery(ctx context.Context, serviceID string, delivery LogDelivery) error {
+	return p.update(serviceID, func(svc *mockService) { svc.logs = delivery })
+}
+
+// GetSecuritySettings returns the security settings of a service. Sandbox
+// services start without HSTS so compliance scans have something to report.
+func (p *MockProvider) GetSecuritySettings(ctx context.Context, serviceID string) (*SecuritySettings, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	svc, ok := p.services[serviceID]
+	if !ok {
+		return nil, fmt.Errorf("service %s not found", serviceID)
+	}
+
+	settings := svc.security
+	settings.AllowedMethods = append([]string{}, svc.security.AllowedMethods...)
+	return &settings, nil
+}
+
+// mockServiceLimit and mockPurgeLimit are the plan limits the mock account reports
+const (
+	mockServiceLimit = 10
+	mockPurgeLimit   = 1000
+)
+
+// GetAccountInfo reports a sandbox plan with usage derived from the services
+func (p *MockProvider) GetAccountInfo(ctx context.Context) (*AccountInfo, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	active, purges := 0, 0
+	for _, svc := range p.services {
+		if svc.service.Status == "ACTIVE" {
+			active++
+		}
+		purges += svc.purges
+	}
+
+	return &AccountInfo{
+		Name:   "Sandbox",
+		Status: "ACTIVE",
+		Plan:   "Developer",
+		Quotas: []AccountQuota{
+			{Name: "Active services", Used: float64(active), Limit: mockServiceLimit},
+			{Name: "Purges", Used: float64(purges), Limit: mockPurgeLimit, Unit: "requests"},
+		},
+		Incidents: []AccountIncident{},
+	}, nil
+}
+
+// GetServiceState returns the origin, rules and SSL settings of a service
+func (p *MockProvider) GetServiceState(ctx context.Context, serviceID string) (*ServiceState, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	svc, ok := p.services[serviceID]
+	if !ok {
+		return nil, fmt.Errorf("service %s not found", serviceID)
+	}
+
+	ssl := svc.ssl
+	return &ServiceState{
+		Origin: svc.origin,
+		Rules:  append([]CacheRule{}, svc.rules...),
+		SSL:    &ssl,
+	}, nil
+}
+
+// UpdateSSL replaces the SSL settings of a service
+func (p *MockProvider) UpdateSSL(ctx context.Context, serviceID string, ssl SSLConfig) error {
+	return p.update(serviceID, func(svc *mockService) { svc.ssl = ssl })
+}
+
+// DryRunCreateService returns the config CreateService would store
+func (p *MockProvider) DryRunCreateService(ctx context.Context, config *ServiceConfig) (*DryRun, error) {
+	return newDryRun(string(domain.ProviderMock), "create_service", "",
+		ProviderRequest{Method: "POST", Path: "/services", Body: config},
+	), nil
+}
+
+// DryRunUpdateService returns the config UpdateService would store
+func (p *MockProvider) DryRunUpdateService(ctx context.Context, serviceID string, config *ServiceConfig) (*DryRun, error) {
+	if err := p.exists(serviceID); err != nil {
+		return nil, err
+	}
+	return newDryRun(string(domain.ProviderMock), "update_service", serviceID,
+		ProviderRequest{Method: "PUT", Path: "/services/" + serviceID, Body: config},
+	), nil
+}
+
+// DryRunCacheRules returns the rules UpdateCacheRules would store
+func (p *MockProvider) DryRunCacheRules(ctx context.Context, serviceID string, rules []CacheRule) (*DryRun, error) {
+	if err := p.exists(serviceID); err != nil {
+		return nil, err
+	}
+	return newDryRun(string(domain.ProviderMock), "update_cache_rules", serviceID,
+		ProviderRequest{Method: "PUT", Path: "/services/" + serviceID + "/rules", Body: rules},
+	), nil
+}
+
+// exists returns an error for unknown services
+func (p *MockProvider) exists(serviceID string) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if _, ok := p.services[serviceID]; !ok {
+		return fmt.Errorf("service %s not found", serviceID)
+	}
+	return nil
+}
+
+// update applies fn to a service under the write lock
+func (p *MockProvider) update(serviceID string, fn func(svc *mockService)) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	svc, ok := p.services[serviceID]
+	if !ok {
+		return fmt.Errorf("service %s not found", serviceID)
+	}
+
+	fn(svc)
+	svc.service.UpdatedAt = time.Now()
+	return nil
+}
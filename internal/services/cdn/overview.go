@@ -0,0 +1,116 @@
+package cdn
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/avvvet/cdnbuddy-api/internal/domain"
+	"github.com/avvvet/cdnbuddy-api/internal/metrics"
+)
+
+// overviewConcurrency bounds concurrent provider calls made for one account overview
+const overviewConcurrency = 8
+
+// ServiceOverview bundles a service with its domains and metrics.
+// Errors lists the per-item lookups that failed; the rest of the data is still valid.
+type ServiceOverview struct {
+	Service domain.CDNService `json:"service"`
+	Domains []domain.Domain   `json:"domains"`
+	Metrics *domain.Metrics   `json:"metrics,omitempty"`
+	Errors  []string          `json:"errors,omitempty"`
+}
+
+// AccountOverview is the account-wide view used by dashboards and status requests
+type AccountOverview struct {
+	Services   []ServiceOverview `json:"services"`
+	DurationMs int64             `json:"duration_ms"`
+	Partial    bool              `json:"partial"` // true if any per-item lookup failed
+}
+
+// GetAccountOverview lists all services and fetches their domains and metrics in
+// parallel. Failures of individual lookups are reported per service instead of
+// failing the whole overview; only a failure to list services is fatal.
+func (s *Service) GetAccountOverview(ctx context.Context) (*AccountOverview, error) {
+	start := time.Now()
+	defer metrics.Since("cdn.account_overview", start)
+
+	services, err := s.ListServices(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services: %w", err)
+	}
+
+	overview := &AccountOverview{
+		Services: make([]ServiceOverview, len(services)),
+	}
+
+	var mu sync.Mutex
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(overviewConcurrency)
+
+	for i, svc := range services {
+		overview.Services[i] = ServiceOverview{Service: svc}
+		item := &overview.Services[i]
+		provider := s.providerOf(svc)
+
+		g.Go(func() error {
+			callStart := time.Now()
+			domains, err := provider.ListDomains(gctx, svc.ID)
+			metrics.Since("cdn.provider.list_domains", callStart)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				item.Errors = append(item.Errors, fmt.Sprintf("domains: %v", err))
+				overview.Partial = true
+				return nil
+			}
+			item.Domains = domains
+			return nil
+		})
+
+		g.Go(func() error {
+			callStart := time.Now()
+			m, err := provider.GetMetrics(gctx, svc.ID)
+			metrics.Since("cdn.provider.get_metrics", callStart)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				item.Errors = append(item.Errors, fmt.Sprintf("metrics: %v", err))
+				overview.Partial = true
+				return nil
+			}
+			item.Metrics = m
+			return nil
+		})
+	}
+
+	// Tasks never return errors, so Wait only blocks until all finish
+	_ = g.Wait()
+
+	overview.DurationMs = time.Since(start).Milliseconds()
+	return overview, nil
+}
+
+// GetServiceOverview returns one service with its domains and metrics; as in
+// the account overview, failed lookups are reported rather than fatal
+func (s *Service) GetServiceOverview(ctx context.Context, serviceID string) (*ServiceOverview, error) {
+	svc, err := s.GetService(ctx, serviceID)
+	if err != nil {
+		return nil, err
+	}
+	provider := s.providerOf(*svc)
+
+	overview := &ServiceOverview{Service: *svc}
+	if overview.Domains, err = provider.ListDomains(ctx, svc.ID); err != nil {
+		overview.Errors = append(overview.Errors, fmt.Sprintf("domains: %v", err))
+	}
+	if overview.Metrics, err = provider.GetMetrics(ctx, svc.ID); err != nil {
+		overview.Errors = append(overview.Errors, fmt.Sprintf("metrics: %v", err))
+	}
+	return overview, nil
+}
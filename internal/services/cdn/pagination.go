@@ -0,0 +1,76 @@
+package cdn
+
+import (
+	"context"
+	"errors"
+
+	"github.com/avvvet/cdnbuddy-api/internal/domain"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// listPageSize is the page size requested from paginated provider APIs
+	listPageSize = 100
+
+	// maxListItems caps how many items a single List call collects, so a very
+	// large account can't exhaust memory; use ForEachService to stream instead
+	maxListItems = 10000
+)
+
+var (
+	// errListCapReached stops iteration once maxListItems is hit
+	errListCapReached = errors.New("list item cap reached")
+
+	// errStopIteration lets a callback end iteration early without reporting an error
+	errStopIteration = errors.New("stop iteration")
+)
+
+// ServiceIterator is implemented by providers that can stream services page by page.
+// Iteration stops at the first error returned by fn, which is passed back to the caller.
+type ServiceIterator interface {
+	ForEachService(ctx context.Context, fn func(svc domain.CDNService) error) error
+}
+
+// DomainIterator is implemented by providers that can stream domains page by page
+type DomainIterator interface {
+	ForEachDomain(ctx context.Context, serviceID string, fn func(d domain.Domain) error) error
+}
+
+// collectServices drains an iterator into a slice, honoring maxListItems
+func collectServices(ctx context.Context, it ServiceIterator) ([]domain.CDNService, error) {
+	services := make([]domain.CDNService, 0)
+	err := it.ForEachService(ctx, func(svc domain.CDNService) error {
+		if len(services) >= maxListItems {
+			return errListCapReached
+		}
+		services = append(services, svc)
+		return nil
+	})
+
+	if errors.Is(err, errListCapReached) {
+		logrus.WithField("cap", maxListItems).Warn("⚠️ Service list truncated at safety cap")
+		return services, nil
+	}
+	return services, err
+}
+
+// collectDomains drains a domain iterator into a slice, honoring maxListItems
+func collectDomains(ctx context.Context, it DomainIterator, serviceID string) ([]domain.Domain, error) {
+	domains := make([]domain.Domain, 0)
+	err := it.ForEachDomain(ctx, serviceID, func(d domain.Domain) error {
+		if len(domains) >= maxListItems {
+			return errListCapReached
+		}
+		domains = append(domains, d)
+		return nil
+	})
+
+	if errors.Is(err, errListCapReached) {
+		logrus.WithFields(logrus.Fields{
+			"service_id": serviceID,
+			"cap":        maxListItems,
+		}).Warn("⚠️ Domain list truncated at safety cap")
+		return domains, nil
+	}
+	return domains, err
+}
@@ -0,0 +1,46 @@
+package cdn
+
+import (
+	"context"
+	"time"
+
+	"github.com/avvvet/cdnbuddy-api/internal/domain"
+)
+
+// ProviderPing is the outcome of one reachability check of a provider's API
+type ProviderPing struct {
+	Provider domain.CDNProvider
+	Latency  time.Duration
+	Err      error
+}
+
+// PingProviders makes one cheap authenticated call to each managed provider's
+// API: the account profile where the provider exposes it, otherwise the
+// service list
+func (s *Service) PingProviders(ctx context.Context) []ProviderPing {
+	if s.registry == nil {
+		return []ProviderPing{pingProvider(ctx, s.defaultProviderName(), s.provider)}
+	}
+
+	pings := make([]ProviderPing, 0)
+	for _, name := range s.registry.Names() {
+		provider, err := s.registry.Get(name)
+		if err != nil {
+			pings = append(pings, ProviderPing{Provider: name, Err: err})
+			continue
+		}
+		pings = append(pings, pingProvider(ctx, name, provider))
+	}
+	return pings
+}
+
+func pingProvider(ctx context.Context, name domain.CDNProvider, provider CDNProvider) ProviderPing {
+	start := time.Now()
+	var err error
+	if inspector, ok := provider.(AccountInspector); ok {
+		_, err = inspector.GetAccountInfo(ctx)
+	} else {
+		_, err = provider.ListServices(ctx)
+	}
+	return ProviderPing{Provider: name, Latency: time.Since(start), Err: err}
+}
@@ -0,0 +1,188 @@
+package cdn
+
+import (
+	"fmt"
+	"strings"
+
+	api "github.com/cachefly/cachefly-go-sdk/pkg/cachefly/api/v2_5"
+)
+
+// Profile is a named set of best-practice defaults for one kind of workload
+type Profile string
+
+const (
+	ProfileStaticSite Profile = "static-site"
+	ProfileSPA        Profile = "spa"
+	ProfileAPI        Profile = "api"
+	ProfileVideo      Profile = "video"
+	ProfileWordPress  Profile = "wordpress"
+)
+
+// DefaultProfile applies when a service config names no profile
+const DefaultProfile = ProfileStaticSite
+
+type profileDefaults struct {
+	description string
+	summary     []string // profile-specific optimizations, listed first
+	drops       []string // prefixes of static-site optimizations the profile undoes
+	apply       func(options api.ServiceOptions)
+}
+
+var profiles = map[Profile]profileDefaults{
+	ProfileStaticSite: {
+		description: "Static sites and assets: aggressive caching and compression",
+		apply:       func(api.ServiceOptions) {},
+	},
+	ProfileSPA: {
+		description: "Single-page apps: long-lived hashed assets, index.html always revalidated",
+		summary: []string{
+			"index.html revalidated every minute so deploys show up at once",
+			"Hashed assets cached for a year",
+		},
+		drops: []string{"Aggressive caching", "Smart query string"},
+		apply: func(options api.ServiceOptions) {
+			setReverseProxy(options, "cacheByQueryParam", false)
+			options["expiryHeaders"] = []interface{}{
+				map[string]interface{}{"path": "/index.html", "expiryTime": 60},
+				map[string]interface{}{"path": "/assets/", "expiryTime": 31536000},
+				map[string]interface{}{"path": "/static/", "expiryTime": 31536000},
+			}
+		},
+	},
+	ProfileAPI: {
+		description: "APIs: origin cache headers respected, every HTTP method passed through",
+		summary: []string{
+			"Origin Cache-Control respected (no forced TTL)",
+			"All HTTP methods passed through to the API",
+			"Errors cached for 10 seconds only",
+			"Longer 60s TTFB timeout for slow endpoints",
+		},
+		drops: []string{"Aggressive caching", "Serve stale", "Geographic", "Auto-redirect", "Optimized timeouts", "Secure HTTP methods", "Error caching"},
+		apply: func(options api.ServiceOptions) {
+			setReverseProxy(options, "ttl", 0)
+			options["servestale"] = false
+			options["cachebygeocountry"] = false
+			options["cachebyregion"] = false
+			options["linkpreheat"] = false
+			options["livestreaming"] = false
+			options["autoRedirect"] = false
+			options["error_ttl"] = map[string]interface{}{"enabled": true, "value": 10}
+			options["ttfb_timeout"] = map[string]interface{}{"enabled": true, "value": 60}
+			options["httpmethods"] = map[string]interface{}{
+				"enabled": true,
+				"value": map[string]interface{}{
+					"GET": true, "POST": true, "HEAD": true, "OPTIONS": true,
+					"PUT": true, "DELETE": true, "PATCH": true,
+				},
+			}
+		},
+	},
+	ProfileVideo: {
+		description: "Video and streaming: large objects, live streaming, no recompression",
+		summary: []string{
+			"Live streaming enabled for HLS/DASH",
+			"Video segments never recompressed",
+			"500 concurrent origin connections for large objects",
+		},
+		drops: []string{"Brotli", "Smart query string", "Geographic", "Connection pooling", "Auto-redirect", "Optimized timeouts", "Smart file encoding"},
+		apply: func(options api.ServiceOptions) {
+			setReverseProxy(options, "cacheByQueryParam", false)
+			options["livestreaming"] = true
+			options["brotli_support"] = false
+			options["cachebygeocountry"] = false
+			options["cachebyregion"] = false
+			options["linkpreheat"] = false
+			options["ttfb_timeout"] = map[string]interface{}{"enabled": true, "value": 60}
+			options["maxcons"] = map[string]interface{}{"enabled": true, "value": 500}
+			options["skip_encoding_ext"] = map[string]interface{}{
+				"enabled": true,
+				"value":   []string{".mp4", ".m4s", ".ts", ".webm", ".mov", ".zip", ".gz"},
+			}
+			options["expiryHeaders"] = []interface{}{
+				map[string]interface{}{"path": ".m3u8", "expiryTime": 2},
+				map[string]interface{}{"path": ".mpd", "expiryTime": 2},
+			}
+		},
+	},
+	ProfileWordPress: {
+		description: "WordPress: cached pages, admin and login never cached",
+		summary: []string{
+			"wp-admin and wp-login.php bypass the cache",
+			"Pages cached for a day, uploads for a month",
+		},
+		drops: []string{"Aggressive caching"},
+		apply: func(options api.ServiceOptions) {
+			setReverseProxy(options, "ttl", 86400)
+			options["livestreaming"] = false
+			options["expiryHeaders"] = []interface{}{
+				map[string]interface{}{"path": "/wp-admin/", "expiryTime": 0},
+				map[string]interface{}{"path": "/wp-login.php", "expiryTime": 0},
+				map[string]interface{}{"path": "/wp-content/uploads/", "expiryTime": 2678400},
+			}
+		},
+	},
+}
+
+// ParseProfile validates a profile name; empty selects the default profile
+func ParseProfile(name string) (Profile, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "":
+		return DefaultProfile, nil
+	case "static", "static-site", "static_site":
+		return ProfileStaticSite, nil
+	case "spa", "single-page-app":
+		return ProfileSPA, nil
+	case "api":
+		return ProfileAPI, nil
+	case "video", "streaming", "video-streaming":
+		return ProfileVideo, nil
+	case "wordpress", "wp":
+		return ProfileWordPress, nil
+	}
+	return "", fmt.Errorf("unknown profile %q (expected static-site, spa, api, video or wordpress)", name)
+}
+
+// ProfileDescriptions describes every profile, for listings and the AI prompt
+func ProfileDescriptions() map[Profile]string {
+	descriptions := make(map[Profile]string, len(profiles))
+	for name, p := range profiles {
+		descriptions[name] = p.description
+	}
+	return descriptions
+}
+
+// GetProfileOptions returns the best-practice options tuned for a profile
+func GetProfileOptions(profile Profile, domain, originHostname, originScheme string) api.ServiceOptions {
+	options := GetBestPracticesOptions(domain, originHostname, originScheme)
+	if p, ok := profiles[profile]; ok {
+		p.apply(options)
+	}
+	return options
+}
+
+// ProfileOptimizations returns the human-readable optimizations of a profile
+func ProfileOptimizations(profile Profile) []string {
+	p := profiles[profile]
+	optimizations := append([]string{}, p.summary...)
+	for _, o := range GetOptimizationsSummary() {
+		if !hasAnyPrefix(o, p.drops) {
+			optimizations = append(optimizations, o)
+		}
+	}
+	return optimizations
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func setReverseProxy(options api.ServiceOptions, key string, value interface{}) {
+	if proxy, ok := options["reverseProxy"].(map[string]interface{}); ok {
+		proxy[key] = value
+	}
+}
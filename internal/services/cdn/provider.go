@@ -10,7 +10,8 @@ import (
 type CDNProvider interface {
 	// Basic operations
 	CreateService(ctx context.Context, config *ServiceConfig) (*domain.CDNService, error)
-	ListServices(ctx context.Context) ([]domain.CDNService, error)
+	ListServices(ctx context.Context) ([]domain.CDNService, error) // active services
+	ListServicesByStatus(ctx context.Context, status StatusFilter) ([]domain.CDNService, error)
 	UpdateService(ctx context.Context, serviceID string, config *ServiceConfig) error
 	DeleteService(ctx context.Context, serviceID string) error
 
@@ -29,14 +30,25 @@ type CDNProvider interface {
 	// Configuration
 	UpdateCacheRules(ctx context.Context, serviceID string, rules []CacheRule) error
 	UpdateOriginSettings(ctx context.Context, serviceID string, origin OriginConfig) error
+
+	// Dry runs: the requests a write would send, computed without sending them
+	DryRunCreateService(ctx context.Context, config *ServiceConfig) (*DryRun, error)
+	DryRunUpdateService(ctx context.Context, serviceID string, config *ServiceConfig) (*DryRun, error)
+	DryRunCacheRules(ctx context.Context, serviceID string, rules []CacheRule) (*DryRun, error)
 }
 
 type ServiceConfig struct {
-	Name   string            `json:"name"`
-	Origin OriginConfig      `json:"origin"`
-	Rules  []CacheRule       `json:"rules"`
-	SSL    SSLConfig         `json:"ssl"`
-	Custom map[string]string `json:"custom"`
+	Name         string            `json:"name"`
+	Origin       OriginConfig      `json:"origin"`
+	Rules        []CacheRule       `json:"rules"`
+	SSL          SSLConfig         `json:"ssl"`
+	Stale        *StalePolicy      `json:"stale,omitempty"`    // nil = best-practice default
+	Failover     *OriginFailover   `json:"failover,omitempty"` // backup origins behind Origin
+	OriginShield *OriginShield     `json:"origin_shield,omitempty"`
+	Profile      Profile           `json:"profile,omitempty"` // empty = DefaultProfile
+	Headers      []ResponseHeader  `json:"headers,omitempty"` // added to every response
+	TLS          *TLSPolicy        `json:"tls,omitempty"`     // nil = provider defaults
+	Custom       map[string]string `json:"custom"`            // best-practice option overrides, see ApplyOptionOverrides
 }
 
 type OriginConfig struct {
@@ -51,6 +63,8 @@ type CacheRule struct {
 	TTL         int    `json:"ttl"`         // seconds
 	BrowserTTL  int    `json:"browser_ttl"` // seconds
 	AlwaysCache bool   `json:"always_cache"`
+
+	Stale *StalePolicy `json:"stale,omitempty"` // overrides the service policy for this path
 }
 
 type SSLConfig struct {
@@ -0,0 +1,244 @@
+package cdn
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// recordedRequest is a request received by a fake provider API
+type recordedRequest struct {
+	Method string
+	Path   string
+	Body   string
+}
+
+// fakeProviderAPI answers requests with the reply for their path (without
+// the query), or {} for other paths, and records what it got
+func fakeProviderAPI(t *testing.T, replies map[string]string) (string, *[]recordedRequest) {
+	t.Helper()
+	var got []recordedRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got = append(got, recordedRequest{Method: r.Method, Path: r.URL.EscapedPath(), Body: string(body)})
+		reply, ok := replies[r.URL.EscapedPath()]
+		if !ok {
+			reply = `{}`
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, reply)
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL, &got
+}
+
+// paths lists the method and path of recorded requests
+func paths(requests []recordedRequest) []string {
+	result := make([]string, len(requests))
+	for i, req := range requests {
+		result[i] = req.Method + " " + req.Path
+	}
+	return result
+}
+
+// sameJSON reports whether a and b encode the same value
+func sameJSON(t *testing.T, a, b string) bool {
+	t.Helper()
+	var va, vb interface{}
+	if err := json.Unmarshal([]byte(a), &va); err != nil {
+		t.Fatalf("invalid JSON %s: %v", a, err)
+	}
+	if err := json.Unmarshal([]byte(b), &vb); err != nil {
+		t.Fatalf("invalid JSON %s: %v", b, err)
+	}
+	ea, _ := json.Marshal(va)
+	eb, _ := json.Marshal(vb)
+	return string(ea) == string(eb)
+}
+
+func TestCacheFlyPurge(t *testing.T) {
+	tests := []struct {
+		name     string
+		purge    func(p *CacheFlyProvider) error
+		wantPath string
+		wantBody string
+	}{
+		{
+			name: "paths",
+			purge: func(p *CacheFlyProvider) error {
+				return p.PurgeCache(context.Background(), "svc-1", []string{"/a.css", "/b.js"})
+			},
+			wantPath: "/services/svc-1/purge",
+			wantBody: `{"paths":["/a.css","/b.js"]}`,
+		},
+		{
+			name:     "everything",
+			purge:    func(p *CacheFlyProvider) error { return p.PurgeAll(context.Background(), "svc-1") },
+			wantPath: "/services/svc-1/purge",
+			wantBody: `{"all":true}`,
+		},
+		{
+			name:     "escapes the service ID",
+			purge:    func(p *CacheFlyProvider) error { return p.PurgeAll(context.Background(), "svc/../1") },
+			wantPath: "/services/svc%2F..%2F1/purge",
+			wantBody: `{"all":true}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			baseURL, got := fakeProviderAPI(t, nil)
+			p := &CacheFlyProvider{api: newHTTPAdapter(cacheFlyName, baseURL, nil, nil)}
+
+			if err := tt.purge(p); err != nil {
+				t.Fatalf("purge: %v", err)
+			}
+			if len(*got) != 1 {
+				t.Fatalf("sent %d requests, want 1", len(*got))
+			}
+			req := (*got)[0]
+			if req.Method != http.MethodPut || req.Path != tt.wantPath {
+				t.Errorf("sent %s %s, want PUT %s", req.Method, req.Path, tt.wantPath)
+			}
+			if !sameJSON(t, req.Body, tt.wantBody) {
+				t.Errorf("body = %s, want %s", req.Body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestKeyCDNAdapter(t *testing.T) {
+	replies := map[string]string{
+		"/zones.json": `{"status":"success","data":{"zone":{"id":"42","name":"shop","status":"active"},
+			"zones":[{"id":"42","name":"shop","status":"active"},{"id":"43","name":"blog","status":"active"}]}}`,
+		"/user.json": `{"status":"success","data":{"user":{"id":"7f3a"}}}`,
+	}
+
+	tests := []struct {
+		name      string
+		call      func(p *KeyCDNProvider) (string, error) // returns what to compare with want
+		want      string
+		wantPaths []string
+	}{
+		{
+			name: "zone hostname ends in the user ID",
+			call: func(p *KeyCDNProvider) (string, error) {
+				svc, err := p.CreateService(context.Background(), &ServiceConfig{Name: "shop", Origin: OriginConfig{Host: "origin.example.com"}})
+				if err != nil {
+					return "", err
+				}
+				var config struct {
+					CNAMETarget string `json:"cname_target"`
+				}
+				return config.CNAMETarget, json.Unmarshal([]byte(svc.Config), &config)
+			},
+			want:      "shop-7f3a.kxcdn.com",
+			wantPaths: []string{"POST /zones.json", "GET /user.json"},
+		},
+		{
+			name: "user ID is fetched once",
+			call: func(p *KeyCDNProvider) (string, error) {
+				services, err := p.ListServicesByStatus(context.Background(), StatusAll)
+				if err != nil {
+					return "", err
+				}
+				return services[1].Config, nil
+			},
+			want:      `{"cname_target":"blog-7f3a.kxcdn.com","provider":"keycdn","provider_service_id":"43","test_url":"https://blog-7f3a.kxcdn.com","unique_name":"blog-7f3a.kxcdn.com"}`,
+			wantPaths: []string{"GET /zones.json", "GET /user.json"},
+		},
+		{
+			name:      "escapes zone IDs",
+			call:      func(p *KeyCDNProvider) (string, error) { return "", p.DeleteService(context.Background(), "42/../1") },
+			wantPaths: []string{"DELETE /zones/42%2F..%2F1.json"},
+		},
+		{
+			name:      "purges a zone",
+			call:      func(p *KeyCDNProvider) (string, error) { return "", p.PurgeAll(context.Background(), "42") },
+			wantPaths: []string{"GET /zones/purge/42.json"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			baseURL, got := fakeProviderAPI(t, replies)
+			p := &KeyCDNProvider{api: newHTTPAdapter("keycdn", baseURL, nil, nil)}
+
+			result, err := tt.call(p)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tt.want {
+				t.Errorf("got %s, want %s", result, tt.want)
+			}
+			if sent := paths(*got); strings.Join(sent, ", ") != strings.Join(tt.wantPaths, ", ") {
+				t.Errorf("sent %v, want %v", sent, tt.wantPaths)
+			}
+		})
+	}
+}
+
+func TestCDN77Adapter(t *testing.T) {
+	replies := map[string]string{
+		"/origin/url": `{"id":"origin-1"}`,
+		"/cdn":        `{"id":1234,"label":"shop","url":"1234.rsc.cdn77.org","origin_id":"origin-1"}`,
+	}
+
+	tests := []struct {
+		name      string
+		call      func(p *CDN77Provider) (string, error)
+		want      string
+		wantPaths []string
+	}{
+		{
+			name: "creates an origin and a resource",
+			call: func(p *CDN77Provider) (string, error) {
+				svc, err := p.CreateService(context.Background(), &ServiceConfig{Name: "shop", Origin: OriginConfig{Host: "origin.example.com"}})
+				if err != nil {
+					return "", err
+				}
+				var config struct {
+					CNAMETarget string `json:"cname_target"`
+				}
+				err = json.Unmarshal([]byte(svc.Config), &config)
+				return svc.ID + " " + config.CNAMETarget, err
+			},
+			want:      "1234 1234.rsc.cdn77.org",
+			wantPaths: []string{"POST /origin/url", "POST /cdn"},
+		},
+		{
+			name: "escapes resource IDs",
+			call: func(p *CDN77Provider) (string, error) {
+				return "", p.PurgeCache(context.Background(), "12/34", []string{"/a.css"})
+			},
+			wantPaths: []string{"POST /cdn/12%2F34/job/purge"},
+		},
+		{
+			name:      "deletes a resource",
+			call:      func(p *CDN77Provider) (string, error) { return "", p.DeleteService(context.Background(), "1234") },
+			wantPaths: []string{"DELETE /cdn/1234"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			baseURL, got := fakeProviderAPI(t, replies)
+			p := &CDN77Provider{api: newHTTPAdapter("cdn77", baseURL, nil, nil)}
+
+			result, err := tt.call(p)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tt.want {
+				t.Errorf("got %s, want %s", result, tt.want)
+			}
+			if sent := paths(*got); strings.Join(sent, ", ") != strings.Join(tt.wantPaths, ", ") {
+				t.Errorf("sent %v, want %v", sent, tt.wantPaths)
+			}
+		})
+	}
+}
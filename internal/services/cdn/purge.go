@@ -0,0 +1,64 @@
+package cdn
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// maxPurgePaths bounds the paths of one purge request
+const maxPurgePaths = 500
+
+// PurgeCache purges paths of a service on the provider that owns it. Paths
+// are URL paths starting with "/"; duplicates are dropped.
+func (s *Service) PurgeCache(ctx context.Context, serviceID string, paths []string) ([]string, error) {
+	paths, err := normalizePurgePaths(paths)
+	if err != nil {
+		return nil, err
+	}
+	svc, err := s.GetService(ctx, serviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.providerOf(*svc).PurgeCache(ctx, svc.ID, paths); err != nil {
+		return nil, fmt.Errorf("failed to purge cache: %w", err)
+	}
+	return paths, nil
+}
+
+// PurgeAll purges the entire cache of a service on the provider that owns it
+func (s *Service) PurgeAll(ctx context.Context, serviceID string) error {
+	svc, err := s.GetService(ctx, serviceID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.providerOf(*svc).PurgeAll(ctx, svc.ID); err != nil {
+		return fmt.Errorf("failed to purge cache: %w", err)
+	}
+	return nil
+}
+
+func normalizePurgePaths(paths []string) ([]string, error) {
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("at least one path is required")
+	}
+	if len(paths) > maxPurgePaths {
+		return nil, fmt.Errorf("at most %d paths can be purged at once; purge everything instead", maxPurgePaths)
+	}
+
+	seen := make(map[string]bool, len(paths))
+	result := make([]string, 0, len(paths))
+	for _, path := range paths {
+		path = strings.TrimSpace(path)
+		if !strings.HasPrefix(path, "/") {
+			return nil, fmt.Errorf("invalid path %q: paths must start with /", path)
+		}
+		if !seen[path] {
+			seen[path] = true
+			result = append(result, path)
+		}
+	}
+	return result, nil
+}
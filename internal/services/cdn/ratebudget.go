@@ -0,0 +1,17 @@
+package cdn
+
+import "context"
+
+// RateLimiter keeps calls to a provider API within its rate budget
+// (see the ratelimit package)
+type RateLimiter interface {
+	Wait(ctx context.Context, provider string) error
+}
+
+// waitBudget blocks until a call to provider fits its rate budget
+func (c *CallPolicy) waitBudget(ctx context.Context, provider string) error {
+	if c.limiter == nil {
+		return nil
+	}
+	return c.limiter.Wait(ctx, provider)
+}
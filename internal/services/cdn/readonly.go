@@ -0,0 +1,379 @@
+package cdn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/avvvet/cdnbuddy-api/internal/domain"
+)
+
+// ErrReadOnly is returned for mutations on a provider enabled in read-only mode
+var ErrReadOnly = errors.New("provider is in read-only mode")
+
+// ProviderMode controls what a tenant may do with a provider. New integrations
+// are dark-launched read-only (list, import, metrics) until writes are unlocked.
+type ProviderMode string
+
+const (
+	ProviderEnabled  ProviderMode = "enabled"
+	ProviderReadOnly ProviderMode = "read_only"
+	ProviderDisabled ProviderMode = "disabled"
+)
+
+// ParseProviderMode validates a provider mode name
+func ParseProviderMode(mode string) (ProviderMode, error) {
+	switch m := ProviderMode(mode); m {
+	case ProviderEnabled, ProviderReadOnly, ProviderDisabled:
+		return m, nil
+	}
+	return "", fmt.Errorf("invalid provider mode %q (expected %s, %s or %s)", mode, ProviderEnabled, ProviderReadOnly, ProviderDisabled)
+}
+
+// Scoped returns a service whose providers follow the given modes: disabled
+// providers are removed and read-only ones reject every mutation
+func (s *Service) Scoped(modeOf func(name domain.CDNProvider) ProviderMode) (*Service, error) {
+	if s.registry == nil {
+		return s, nil
+	}
+
+	kept := make(map[domain.CDNProvider]CDNProvider)
+	names := make([]domain.CDNProvider, 0)
+	for _, name := range s.registry.Names() {
+		provider, err := s.registry.Get(name)
+		if err != nil {
+			return nil, err
+		}
+
+		switch modeOf(name) {
+		case ProviderDisabled:
+			continue
+		case ProviderReadOnly:
+			provider = NewReadOnlyProvider(provider)
+		}
+		kept[name] = provider
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("%w: no providers are enabled", ErrUnknownProvider)
+	}
+
+	// Fall back to the first remaining provider when the default is disabled
+	defaultName := s.registry.Default()
+	if _, ok := kept[defaultName]; !ok {
+		defaultName = names[0]
+	}
+
+	registry := NewProviderRegistry(defaultName)
+	for name, provider := range kept {
+		registry.Register(name, provider)
+	}
+	return NewServiceWithRegistry(registry, s.calls, s.guard)
+}
+
+// readOnlyProvider passes reads through to a provider and rejects writes
+type readOnlyProvider struct {
+	inner CDNProvider
+}
+
+// supports reports whether a provider has capability T. Wrappers implement
+// every capability, so they are seen through to the provider they wrap.
+func supports[T any](provider CDNProvider) bool {
+	for {
+		w, ok := provider.(interface{ unwrap() CDNProvider })
+		if !ok {
+			break
+		}
+		provider = w.unwrap()
+	}
+	_, ok := provider.(T)
+	return ok
+}
+
+func (p *readOnlyProvider) unwrap() CDNProvider { return p.inner }
+
+// NewReadOnlyProvider wraps a provider so only list, metrics and settings reads reach it
+func NewReadOnlyProvider(provider CDNProvider) CDNProvider {
+	return &readOnlyProvider{inner: provider}
+}
+
+func (p *readOnlyProvider) CreateService(ctx context.Context, config *ServiceConfig) (*domain.CDNService, error) {
+	return nil, fmt.Errorf("create service: %w", ErrReadOnly)
+}
+
+func (p *readOnlyProvider) CloneService(ctx context.Context, serviceID, name string) (*domain.CDNService, error) {
+	return nil, fmt.Errorf("clone service: %w", ErrReadOnly)
+}
+
+func (p *readOnlyProvider) ListServices(ctx context.Context) ([]domain.CDNService, error) {
+	return p.inner.ListServices(ctx)
+}
+
+func (p *readOnlyProvider) ListServicesByStatus(ctx context.Context, status StatusFilter) ([]domain.CDNService, error) {
+	return p.inner.ListServicesByStatus(ctx, status)
+}
+
+func (p *readOnlyProvider) ReactivateService(ctx context.Context, serviceID string) error {
+	return fmt.Errorf("reactivate service: %w", ErrReadOnly)
+}
+
+func (p *readOnlyProvider) UpdateService(ctx context.Context, serviceID string, config *ServiceConfig) error {
+	return fmt.Errorf("update service: %w", ErrReadOnly)
+}
+
+func (p *readOnlyProvider) DeleteService(ctx context.Context, serviceID string) error {
+	return fmt.Errorf("delete service: %w", ErrReadOnly)
+}
+
+func (p *readOnlyProvider) AddDomain(ctx context.Context, serviceID, domainName string) error {
+	return fmt.Errorf("add domain: %w", ErrReadOnly)
+}
+
+func (p *readOnlyProvider) RemoveDomain(ctx context.Context, serviceID, domainName string) error {
+	return fmt.Errorf("remove domain: %w", ErrReadOnly)
+}
+
+func (p *readOnlyProvider) ListDomains(ctx context.Context, serviceID string) ([]domain.Domain, error) {
+	return p.inner.ListDomains(ctx, serviceID)
+}
+
+func (p *readOnlyProvider) PurgeCache(ctx context.Context, serviceID string, paths []string) error {
+	return fmt.Errorf("purge cache: %w", ErrReadOnly)
+}
+
+func (p *readOnlyProvider) PurgeAll(ctx context.Context, serviceID string) error {
+	return fmt.Errorf("purge cache: %w", ErrReadOnly)
+}
+
+func (p *readOnlyProvider) GetMetrics(ctx context.Context, serviceID string) (*domain.Metrics, error) {
+	return p.inner.GetMetrics(ctx, serviceID)
+}
+
+func (p *readOnlyProvider) UpdateCacheRules(ctx context.Context, serviceID string, rules []CacheRule) error {
+	return fmt.Errorf("update cache rules: %w", ErrReadOnly)
+}
+
+func (p *readOnlyProvider) UpdateOriginSettings(ctx context.Context, serviceID string, origin OriginConfig) error {
+	return fmt.Errorf("update origin: %w", ErrReadOnly)
+}
+
+// Optional capabilities: reads pass through when the wrapped provider has
+// them, writes are rejected either way
+
+// Dry runs send nothing, so they pass through: users can still see what a
+// change would do once writes are unlocked
+func (p *readOnlyProvider) DryRunCreateService(ctx context.Context, config *ServiceConfig) (*DryRun, error) {
+	return p.inner.DryRunCreateService(ctx, config)
+}
+
+func (p *readOnlyProvider) DryRunUpdateService(ctx context.Context, serviceID string, config *ServiceConfig) (*DryRun, error) {
+	return p.inner.DryRunUpdateService(ctx, serviceID, config)
+}
+
+func (p *readOnlyProvider) DryRunCacheRules(ctx context.Context, serviceID string, rules []CacheRule) (*DryRun, error) {
+	return p.inner.DryRunCacheRules(ctx, serviceID, rules)
+}
+
+func (p *readOnlyProvider) ForEachService(ctx context.Context, fn func(svc domain.CDNService) error) error {
+	if it, ok := p.inner.(ServiceIterator); ok {
+		return it.ForEachService(ctx, fn)
+	}
+	services, err := p.inner.ListServices(ctx)
+	if err != nil {
+		return err
+	}
+	for _, svc := range services {
+		if err := fn(svc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *readOnlyProvider) ForEachDomain(ctx context.Context, serviceID string, fn func(d domain.Domain) error) error {
+	if it, ok := p.inner.(DomainIterator); ok {
+		return it.ForEachDomain(ctx, serviceID, fn)
+	}
+	domains, err := p.inner.ListDomains(ctx, serviceID)
+	if err != nil {
+		return err
+	}
+	for _, d := range domains {
+		if err := fn(d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *readOnlyProvider) CacheKeySupport() CacheKeySupport {
+	if c, ok := p.inner.(CacheKeyConfigurer); ok {
+		return c.CacheKeySupport()
+	}
+	return CacheKeySupport{}
+}
+
+func (p *readOnlyProvider) GetCacheKey(ctx context.Context, serviceID string) (*CacheKeyConfig, error) {
+	if c, ok := p.inner.(CacheKeyConfigurer); ok {
+		return c.GetCacheKey(ctx, serviceID)
+	}
+	return nil, fmt.Errorf("cache key: %w", ErrNotSupported)
+}
+
+func (p *readOnlyProvider) UpdateCacheKey(ctx context.Context, serviceID string, config CacheKeyConfig) error {
+	return fmt.Errorf("update cache key: %w", ErrReadOnly)
+}
+
+func (p *readOnlyProvider) GetStalePolicy(ctx context.Context, serviceID string) (*StalePolicy, error) {
+	if c, ok := p.inner.(StalePolicyConfigurer); ok {
+		return c.GetStalePolicy(ctx, serviceID)
+	}
+	return nil, fmt.Errorf("stale policy: %w", ErrNotSupported)
+}
+
+func (p *readOnlyProvider) UpdateStalePolicy(ctx context.Context, serviceID string, policy StalePolicy) error {
+	return fmt.Errorf("update stale policy: %w", ErrReadOnly)
+}
+
+func (p *readOnlyProvider) OriginLoadSupport() OriginLoadSupport {
+	if c, ok := p.inner.(OriginLoadConfigurer); ok {
+		return c.OriginLoadSupport()
+	}
+	return OriginLoadSupport{}
+}
+
+func (p *readOnlyProvider) GetOriginLoad(ctx context.Context, serviceID string) (*OriginLoadOptions, error) {
+	if c, ok := p.inner.(OriginLoadConfigurer); ok {
+		return c.GetOriginLoad(ctx, serviceID)
+	}
+	return nil, fmt.Errorf("origin shield: %w", ErrNotSupported)
+}
+
+func (p *readOnlyProvider) UpdateOriginLoad(ctx context.Context, serviceID string, options OriginLoadOptions) error {
+	return fmt.Errorf("update origin shield: %w", ErrReadOnly)
+}
+
+func (p *readOnlyProvider) GetLogDelivery(ctx context.Context, serviceID string) (*LogDelivery, error) {
+	if c, ok := p.inner.(LogDeliveryConfigurer); ok {
+		return c.GetLogDelivery(ctx, serviceID)
+	}
+	return nil, fmt.Errorf("log delivery: %w", ErrNotSupported)
+}
+
+func (p *readOnlyProvider) UpdateLogDelivery(ctx context.Context, serviceID string, delivery LogDelivery) error {
+	return fmt.Errorf("update log delivery: %w", ErrReadOnly)
+}
+
+func (p *readOnlyProvider) ListLogFiles(ctx context.Context, serviceID string, since time.Time) ([]LogFile, error) {
+	if s, ok := p.inner.(LogSource); ok {
+		return s.ListLogFiles(ctx, serviceID, since)
+	}
+	return nil, fmt.Errorf("log download: %w", ErrNotSupported)
+}
+
+func (p *readOnlyProvider) OpenLogFile(ctx context.Context, file LogFile) (io.ReadCloser, error) {
+	if s, ok := p.inner.(LogSource); ok {
+		return s.OpenLogFile(ctx, file)
+	}
+	return nil, fmt.Errorf("log download: %w", ErrNotSupported)
+}
+
+func (p *readOnlyProvider) GetServiceState(ctx context.Context, serviceID string) (*ServiceState, error) {
+	if i, ok := p.inner.(ServiceInspector); ok {
+		return i.GetServiceState(ctx, serviceID)
+	}
+	return nil, fmt.Errorf("service state: %w", ErrNotSupported)
+}
+
+func (p *readOnlyProvider) UpdateSSL(ctx context.Context, serviceID string, ssl SSLConfig) error {
+	return fmt.Errorf("update ssl: %w", ErrReadOnly)
+}
+
+func (p *readOnlyProvider) FirewallSupport() FirewallSupport {
+	if c, ok := p.inner.(FirewallConfigurer); ok {
+		return c.FirewallSupport()
+	}
+	return FirewallSupport{}
+}
+
+func (p *readOnlyProvider) GetFirewall(ctx context.Context, serviceID string) (*FirewallConfig, error) {
+	if c, ok := p.inner.(FirewallConfigurer); ok {
+		return c.GetFirewall(ctx, serviceID)
+	}
+	return nil, fmt.Errorf("firewall: %w", ErrNotSupported)
+}
+
+func (p *readOnlyProvider) UpdateFirewall(ctx context.Context, serviceID string, config FirewallConfig) error {
+	return fmt.Errorf("update firewall: %w", ErrReadOnly)
+}
+
+func (p *readOnlyProvider) GetHotlinkProtection(ctx context.Context, serviceID string) (*HotlinkConfig, error) {
+	if c, ok := p.inner.(HotlinkConfigurer); ok {
+		return c.GetHotlinkProtection(ctx, serviceID)
+	}
+	return nil, fmt.Errorf("hotlink protection: %w", ErrNotSupported)
+}
+
+func (p *readOnlyProvider) UpdateHotlinkProtection(ctx context.Context, serviceID string, config HotlinkConfig) error {
+	return fmt.Errorf("update hotlink protection: %w", ErrReadOnly)
+}
+
+func (p *readOnlyProvider) GetResponseHeaders(ctx context.Context, serviceID string) ([]ResponseHeader, error) {
+	if c, ok := p.inner.(HeaderConfigurer); ok {
+		return c.GetResponseHeaders(ctx, serviceID)
+	}
+	return nil, fmt.Errorf("response headers: %w", ErrNotSupported)
+}
+
+func (p *readOnlyProvider) UpdateResponseHeaders(ctx context.Context, serviceID string, headers []ResponseHeader) error {
+	return fmt.Errorf("update response headers: %w", ErrReadOnly)
+}
+
+func (p *readOnlyProvider) TLSSupport() TLSSupport {
+	if c, ok := p.inner.(TLSPolicyConfigurer); ok {
+		return c.TLSSupport()
+	}
+	return TLSSupport{}
+}
+
+func (p *readOnlyProvider) GetTLSPolicy(ctx context.Context, serviceID string) (*TLSPolicy, error) {
+	if c, ok := p.inner.(TLSPolicyConfigurer); ok {
+		return c.GetTLSPolicy(ctx, serviceID)
+	}
+	return nil, fmt.Errorf("tls policy: %w", ErrNotSupported)
+}
+
+func (p *readOnlyProvider) UpdateTLSPolicy(ctx context.Context, serviceID string, policy TLSPolicy) error {
+	return fmt.Errorf("update tls policy: %w", ErrReadOnly)
+}
+
+func (p *readOnlyProvider) UploadCertificate(ctx context.Context, serviceID string, cert Certificate) error {
+	return fmt.Errorf("upload certificate: %w", ErrReadOnly)
+}
+
+func (p *readOnlyProvider) GetAccountInfo(ctx context.Context) (*AccountInfo, error) {
+	if i, ok := p.inner.(AccountInspector); ok {
+		return i.GetAccountInfo(ctx)
+	}
+	return nil, fmt.Errorf("account info: %w", ErrNotSupported)
+}
+
+func (p *readOnlyProvider) GetSecuritySettings(ctx context.Context, serviceID string) (*SecuritySettings, error) {
+	if i, ok := p.inner.(SecurityInspector); ok {
+		return i.GetSecuritySettings(ctx, serviceID)
+	}
+	return nil, fmt.Errorf("security settings: %w", ErrNotSupported)
+}
+
+func (p *readOnlyProvider) LastConfigChange(ctx context.Context, serviceID string) (*ConfigChange, error) {
+	if r, ok := p.inner.(ConfigRollbacker); ok {
+		return r.LastConfigChange(ctx, serviceID)
+	}
+	return nil, fmt.Errorf("configuration rollback: %w", ErrNotSupported)
+}
+
+func (p *readOnlyProvider) RollbackConfig(ctx context.Context, serviceID string) (*ConfigChange, error) {
+	return nil, fmt.Errorf("rollback configuration: %w", ErrReadOnly)
+}
@@ -0,0 +1,112 @@
+package cdn
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/avvvet/cdnbuddy-api/internal/domain"
+)
+
+// ErrUnknownProvider is returned when a request names a provider that isn't configured
+var ErrUnknownProvider = errors.New("unknown CDN provider")
+
+// ProviderRegistry holds the configured CDN providers of a deployment, so one
+// deployment can manage services on several providers at once
+type ProviderRegistry struct {
+	providers   map[domain.CDNProvider]CDNProvider
+	defaultName domain.CDNProvider
+	mu          sync.RWMutex
+}
+
+// NewProviderRegistry creates an empty registry; defaultName is used when a
+// request doesn't name a provider
+func NewProviderRegistry(defaultName domain.CDNProvider) *ProviderRegistry {
+	return &ProviderRegistry{
+		providers:   make(map[domain.CDNProvider]CDNProvider),
+		defaultName: defaultName,
+	}
+}
+
+// Register adds or replaces a provider
+func (r *ProviderRegistry) Register(name domain.CDNProvider, provider CDNProvider) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.providers[name] = provider
+}
+
+// Get returns the named provider, or the default provider for an empty name
+func (r *ProviderRegistry) Get(name domain.CDNProvider) (CDNProvider, error) {
+	if name == "" {
+		name = r.defaultName
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	provider, ok := r.providers[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownProvider, name)
+	}
+	return provider, nil
+}
+
+// Default returns the name of the default provider
+func (r *ProviderRegistry) Default() domain.CDNProvider {
+	return r.defaultName
+}
+
+// Names returns the registered provider names, sorted
+func (r *ProviderRegistry) Names() []domain.CDNProvider {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]domain.CDNProvider, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
+
+// ParseProvider normalizes a provider name from a request or intent parameter
+func ParseProvider(name string) domain.CDNProvider {
+	return domain.CDNProvider(strings.ToLower(strings.TrimSpace(name)))
+}
+
+// NewProvider creates a provider by name from its environment credentials;
+// its API calls follow calls
+func NewProvider(name domain.CDNProvider, calls *CallPolicy) (CDNProvider, error) {
+	var (
+		provider CDNProvider
+		err      error
+	)
+
+	// Assign through concrete results so a failed constructor yields a nil interface
+	switch name {
+	case domain.ProviderCacheFly:
+		var p *CacheFlyProvider
+		if p, err = NewCacheFlyProvider(calls); err == nil {
+			provider = p
+		}
+	case domain.ProviderKeyCDN:
+		var p *KeyCDNProvider
+		if p, err = NewKeyCDNProvider(calls); err == nil {
+			provider = p
+		}
+	case domain.ProviderCDN77:
+		var p *CDN77Provider
+		if p, err = NewCDN77Provider(calls); err == nil {
+			provider = p
+		}
+	case domain.ProviderMock:
+		provider = NewMockProvider()
+	default:
+		err = fmt.Errorf("%w: no adapter for %s", ErrUnknownProvider, name)
+	}
+
+	return provider, err
+}
@@ -0,0 +1,250 @@
+package cdn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/avvvet/cdnbuddy-api/internal/metrics"
+)
+
+// OperationClass groups provider calls that share a retry policy
+type OperationClass string
+
+const (
+	OpRead  OperationClass = "read"
+	OpWrite OperationClass = "write"
+	OpPurge OperationClass = "purge"
+)
+
+// maxRetryAfter caps how long a provider's Retry-After header can hold a call
+const maxRetryAfter = time.Minute
+
+// RetryPolicy controls how often and how fast a failed call is retried
+type RetryPolicy struct {
+	MaxAttempts int           `json:"max_attempts"` // including the first call
+	BaseDelay   time.Duration `json:"base_delay"`
+	MaxDelay    time.Duration `json:"max_delay"`
+}
+
+// DefaultRetryPolicies apply to classes without a configured policy. Writes
+// are retried less, and only when the provider clearly didn't apply them.
+var DefaultRetryPolicies = map[OperationClass]RetryPolicy{
+	OpRead:  {MaxAttempts: 4, BaseDelay: 200 * time.Millisecond, MaxDelay: 5 * time.Second},
+	OpWrite: {MaxAttempts: 3, BaseDelay: 500 * time.Millisecond, MaxDelay: 10 * time.Second},
+	OpPurge: {MaxAttempts: 3, BaseDelay: 500 * time.Millisecond, MaxDelay: 10 * time.Second},
+}
+
+// ParseRetryPolicies parses "read=4:200ms:5s,write=2" (attempts, optional base
+// and max delay); omitted delays keep the class default
+func ParseRetryPolicies(spec string) (map[OperationClass]RetryPolicy, error) {
+	policies := make(map[OperationClass]RetryPolicy)
+	for _, pair := range strings.Split(spec, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid retry policy %q (expected class=attempts[:base[:max]])", pair)
+		}
+
+		class := OperationClass(strings.ToLower(strings.TrimSpace(name)))
+		policy, known := DefaultRetryPolicies[class]
+		if !known {
+			return nil, fmt.Errorf("unknown operation class %q (expected read, write or purge)", name)
+		}
+
+		parts := strings.Split(value, ":")
+		attempts, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+		if err != nil || attempts < 1 {
+			return nil, fmt.Errorf("invalid attempts in %q", pair)
+		}
+		policy.MaxAttempts = attempts
+		if len(parts) > 1 {
+			if policy.BaseDelay, err = time.ParseDuration(parts[1]); err != nil {
+				return nil, fmt.Errorf("invalid base delay in %q", pair)
+			}
+		}
+		if len(parts) > 2 {
+			if policy.MaxDelay, err = time.ParseDuration(parts[2]); err != nil {
+				return nil, fmt.Errorf("invalid max delay in %q", pair)
+			}
+		}
+
+		policies[class] = policy
+	}
+	return policies, nil
+}
+
+// withRetry runs fn within the provider's rate budget and circuit breaker,
+// retrying transient failures with exponential backoff and jitter
+func (c *CallPolicy) withRetry(ctx context.Context, provider string, class OperationClass, fn func() error) (err error) {
+	if err := c.allowCall(provider); err != nil {
+		return err
+	}
+	defer func() {
+		c.recordCall(provider, err)
+		countCall(provider, err)
+	}()
+
+	policy := c.retries[class]
+
+	for attempt := 1; ; attempt++ {
+		if err := c.waitBudget(ctx, provider); err != nil {
+			return err
+		}
+
+		err := fn()
+		if err == nil {
+			return nil
+		}
+
+		retry, retryAfter := retryable(class, err)
+		if !retry || attempt >= policy.MaxAttempts || ctx.Err() != nil {
+			if retry {
+				metrics.Inc("provider_retries_exhausted_" + provider)
+			}
+			return err
+		}
+
+		delay := backoff(policy, attempt)
+		if retryAfter > delay {
+			delay = min(retryAfter, maxRetryAfter)
+		}
+		metrics.Inc("provider_retries_" + provider)
+		logrus.WithError(err).WithFields(logrus.Fields{
+			"provider": provider,
+			"class":    class,
+			"attempt":  attempt,
+			"delay":    delay,
+		}).Warn("🔁 Retrying provider call")
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// retryCall1 runs a one-argument SDK call through withRetry; mutations are journaled
+func retryCall1[A, R any](ctx context.Context, c *CallPolicy, provider string, class OperationClass, fn func(context.Context, A) (R, error), a A) (R, error) {
+	var result R
+	err := c.withRetry(ctx, provider, class, func() error {
+		start := time.Now()
+		status := &responseStatus{}
+		var err error
+		result, err = fn(withResponseStatus(ctx, status), a)
+		err = status.apiError(provider, err)
+		if class != OpRead {
+			c.journalSDKCall(ctx, provider, fn, start, status.statusCode, err, a)
+		}
+		return err
+	})
+	return result, err
+}
+
+// retryCall2 runs a two-argument SDK call through withRetry; mutations are journaled
+func retryCall2[A, B, R any](ctx context.Context, c *CallPolicy, provider string, class OperationClass, fn func(context.Context, A, B) (R, error), a A, b B) (R, error) {
+	var result R
+	err := c.withRetry(ctx, provider, class, func() error {
+		start := time.Now()
+		status := &responseStatus{}
+		var err error
+		result, err = fn(withResponseStatus(ctx, status), a, b)
+		err = status.apiError(provider, err)
+		if class != OpRead {
+			c.journalSDKCall(ctx, provider, fn, start, status.statusCode, err, a, b)
+		}
+		return err
+	})
+	return result, err
+}
+
+// backoff returns the delay before the next attempt: exponential with jitter
+// in [d/2, d) so replicas retrying together spread out
+func backoff(policy RetryPolicy, attempt int) time.Duration {
+	d := policy.BaseDelay << (attempt - 1)
+	if d <= 0 || d > policy.MaxDelay {
+		d = policy.MaxDelay
+	}
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// retryable reports whether a failed call may be retried and how long the
+// provider asked us to wait. Reads are retried on any transient failure;
+// writes and purges only when the provider rejected the request before
+// applying it (429, 503) or the connection couldn't be made. Errors are
+// classified by status code or network error type, never by their message.
+func retryable(class OperationClass, err error) (bool, time.Duration) {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false, 0
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+			return true, apiErr.RetryAfter
+		case http.StatusBadGateway, http.StatusGatewayTimeout, http.StatusInternalServerError:
+			return class == OpRead, apiErr.RetryAfter
+		}
+		return false, 0
+	}
+
+	// No response: a refused connection never reached the provider, while a
+	// timeout or dropped connection may have been applied, so only reads retry
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return true, 0
+	}
+	var netErr net.Error
+	dropped := errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+	if dropped || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return class == OpRead, 0
+	}
+	return false, 0
+}
+
+// IsTransient reports whether an error is a transient provider failure that
+// may succeed when an idempotent action is run again
+func IsTransient(err error) bool {
+	retry, _ := retryable(OpRead, err)
+	return retry
+}
+
+// parseRetryAfter reads how long a provider asked clients to back off, from
+// Retry-After (seconds or HTTP date) or X-RateLimit-Reset (epoch or seconds)
+func parseRetryAfter(h http.Header) time.Duration {
+	if v := h.Get("Retry-After"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil {
+			return time.Duration(secs) * time.Second
+		}
+		if t, err := http.ParseTime(v); err == nil {
+			return time.Until(t)
+		}
+	}
+	if v := h.Get("X-RateLimit-Reset"); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			if n > 1_000_000_000 {
+				return time.Until(time.Unix(n, 0))
+			}
+			return time.Duration(n) * time.Second
+		}
+	}
+	return 0
+}
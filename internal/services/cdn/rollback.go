@@ -0,0 +1,192 @@
+package cdn
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/avvvet/cdnbuddy-api/internal/domain"
+	"github.com/avvvet/cdnbuddy-api/internal/lru"
+)
+
+// ErrNothingToRollBack is returned when no configuration change is kept for a service
+var ErrNothingToRollBack = errors.New("no configuration change to roll back")
+
+// maxConfigChanges bounds the services a provider keeps a previous configuration for
+const maxConfigChanges = 10000
+
+// ConfigChange is the configuration a service had before its last
+// UpdateService or UpdateCacheRules call
+type ConfigChange struct {
+	ServiceID string             `json:"service_id"`
+	Provider  domain.CDNProvider `json:"provider"`
+	Operation string             `json:"operation"` // update_service or update_cache_rules
+	Previous  json.RawMessage    `json:"previous"`  // provider options as they were
+	ChangedAt time.Time          `json:"changed_at"`
+}
+
+// ConfigRollbacker is implemented by providers that keep the options a
+// service had before its last configuration change and can restore them.
+// An empty service ID means the most recently changed service.
+type ConfigRollbacker interface {
+	LastConfigChange(ctx context.Context, serviceID string) (*ConfigChange, error)
+	RollbackConfig(ctx context.Context, serviceID string) (*ConfigChange, error)
+}
+
+// configHistory keeps the previous configuration of each service of a provider
+type configHistory struct {
+	provider domain.CDNProvider
+	changes  *lru.Cache[string, ConfigChange]
+}
+
+func newConfigHistory(provider domain.CDNProvider) *configHistory {
+	return &configHistory{
+		provider: provider,
+		changes:  lru.New[string, ConfigChange]("config_changes_"+string(provider), maxConfigChanges, 0),
+	}
+}
+
+// record keeps previous as the configuration to roll serviceID back to
+func (h *configHistory) record(serviceID, operation string, previous interface{}) error {
+	data, err := json.Marshal(previous)
+	if err != nil {
+		return fmt.Errorf("failed to snapshot configuration: %w", err)
+	}
+	h.changes.Put(serviceID, ConfigChange{
+		ServiceID: serviceID,
+		Provider:  h.provider,
+		Operation: operation,
+		Previous:  data,
+		ChangedAt: time.Now(),
+	})
+	return nil
+}
+
+// last returns the kept change of a service, or of the most recently changed one
+func (h *configHistory) last(serviceID string) (*ConfigChange, error) {
+	if serviceID != "" {
+		change, ok := h.changes.Get(serviceID)
+		if !ok {
+			return nil, fmt.Errorf("%w for service %s", ErrNothingToRollBack, serviceID)
+		}
+		return &change, nil
+	}
+
+	var latest *ConfigChange
+	h.changes.Range(func(_ string, change ConfigChange) bool {
+		if latest == nil || change.ChangedAt.After(latest.ChangedAt) {
+			c := change
+			latest = &c
+		}
+		return true
+	})
+	if latest == nil {
+		return nil, ErrNothingToRollBack
+	}
+	return latest, nil
+}
+
+// forget drops a change once it has been rolled back
+func (h *configHistory) forget(change *ConfigChange) {
+	if current, ok := h.changes.Get(change.ServiceID); ok && current.ChangedAt.Equal(change.ChangedAt) {
+		h.changes.Delete(change.ServiceID)
+	}
+}
+
+// LastConfigChange returns the change a rollback of serviceID would undo; an
+// empty service ID returns the most recent change across providers
+func (s *Service) LastConfigChange(ctx context.Context, serviceID string) (*ConfigChange, error) {
+	_, change, err := s.rollbackTarget(ctx, serviceID)
+	return change, err
+}
+
+// RollbackConfig restores the configuration a service had before its last
+// UpdateService or UpdateCacheRules call
+func (s *Service) RollbackConfig(ctx context.Context, serviceID string) (*ConfigChange, error) {
+	rollbacker, change, err := s.rollbackTarget(ctx, serviceID)
+	if err != nil {
+		return nil, err
+	}
+	return rollbacker.RollbackConfig(ctx, change.ServiceID)
+}
+
+// rollbackTarget finds the provider keeping the change to roll back
+func (s *Service) rollbackTarget(ctx context.Context, serviceID string) (ConfigRollbacker, *ConfigChange, error) {
+	if serviceID != "" {
+		svc, err := s.findSpecService(ctx, ServiceSpec{ServiceID: serviceID})
+		if err != nil {
+			return nil, nil, err
+		}
+		provider := s.providerOf(*svc)
+		rollbacker, ok := provider.(ConfigRollbacker)
+		if !ok || !supports[ConfigRollbacker](provider) {
+			return nil, nil, fmt.Errorf("configuration rollback: %w", ErrNotSupported)
+		}
+		change, err := rollbacker.LastConfigChange(ctx, svc.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+		return rollbacker, change, nil
+	}
+
+	var (
+		target *ConfigChange
+		owner  ConfigRollbacker
+	)
+	for _, provider := range s.managedProviders() {
+		rollbacker, ok := provider.(ConfigRollbacker)
+		if !ok || !supports[ConfigRollbacker](provider) {
+			continue
+		}
+		change, err := rollbacker.LastConfigChange(ctx, "")
+		if errors.Is(err, ErrNothingToRollBack) {
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if target == nil || change.ChangedAt.After(target.ChangedAt) {
+			target, owner = change, rollbacker
+		}
+	}
+	if target == nil {
+		return nil, nil, ErrNothingToRollBack
+	}
+	return owner, target, nil
+}
+
+// managedProviders returns every provider of the service, the default first
+func (s *Service) managedProviders() []CDNProvider {
+	providers := []CDNProvider{s.provider}
+	if s.registry == nil {
+		return providers
+	}
+	for _, name := range s.registry.Names() {
+		if provider, err := s.registry.Get(name); err == nil && provider != s.provider {
+			providers = append(providers, provider)
+		}
+	}
+	return providers
+}
+
+// handleRollbackConfig undoes the last configuration change (UNDO intent)
+func (s *Service) handleRollbackConfig(ctx context.Context, params map[string]*string) (string, error) {
+	change, err := s.RollbackConfig(ctx, getParam(params, "service_id"))
+	if err != nil {
+		if errors.Is(err, ErrNothingToRollBack) {
+			return "ℹ️ There is no configuration change to undo.", nil
+		}
+		return "", fmt.Errorf("failed to roll back configuration: %w", err)
+	}
+	return fmt.Sprintf("↩️ Rolled back the %s on service %s made at %s. The previous settings are restored.",
+		describeConfigOperation(change.Operation), change.ServiceID, change.ChangedAt.UTC().Format("15:04:05 MST")), nil
+}
+
+func describeConfigOperation(operation string) string {
+	if operation == "update_cache_rules" {
+		return "cache rule change"
+	}
+	return "service update"
+}
@@ -0,0 +1,108 @@
+package cdn_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/avvvet/cdnbuddy-api/internal/services/cdn"
+	"github.com/avvvet/cdnbuddy-api/internal/testutil"
+)
+
+func TestRollbackConfig(t *testing.T) {
+	tests := []struct {
+		name       string
+		change     func(ctx context.Context, p *cdn.MockProvider, serviceID string) error
+		serviceID  bool // roll back by service ID rather than the latest change
+		wantOp     string
+		wantOrigin string
+		wantRules  int
+		wantErr    error
+	}{
+		{
+			name:    "nothing changed",
+			wantErr: cdn.ErrNothingToRollBack,
+		},
+		{
+			name: "restores cache rules",
+			change: func(ctx context.Context, p *cdn.MockProvider, serviceID string) error {
+				return p.UpdateCacheRules(ctx, serviceID, []cdn.CacheRule{{Path: "/static/*", TTL: 3600}})
+			},
+			serviceID:  true,
+			wantOp:     "update_cache_rules",
+			wantOrigin: "origin.shop.example.com",
+		},
+		{
+			name: "restores the origin of the latest change",
+			change: func(ctx context.Context, p *cdn.MockProvider, serviceID string) error {
+				return p.UpdateService(ctx, serviceID, &cdn.ServiceConfig{
+					Origin: cdn.OriginConfig{Host: "new-origin.example.com", Protocol: "https"},
+					Rules:  []cdn.CacheRule{{Path: "/*", TTL: 60}},
+				})
+			},
+			wantOp:     "update_service",
+			wantOrigin: "origin.shop.example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			service, provider := testutil.NewMockService(t)
+			svc := testutil.SeedService(t, provider, "shop.example.com", "origin.shop.example.com", "shop.example.com")
+			if tt.change != nil {
+				if err := tt.change(ctx, provider, svc.ID); err != nil {
+					t.Fatalf("change failed: %v", err)
+				}
+			}
+
+			target := ""
+			if tt.serviceID {
+				target = svc.ID
+			}
+			change, err := service.RollbackConfig(ctx, target)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("RollbackConfig() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if change.ServiceID != svc.ID || change.Operation != tt.wantOp {
+				t.Errorf("rolled back %s %s, want %s %s", change.ServiceID, change.Operation, svc.ID, tt.wantOp)
+			}
+
+			state, err := provider.GetServiceState(ctx, svc.ID)
+			if err != nil {
+				t.Fatalf("GetServiceState() error = %v", err)
+			}
+			if state.Origin.Host != tt.wantOrigin || len(state.Rules) != tt.wantRules {
+				t.Errorf("state after rollback = %+v, want origin %s with %d rules", state, tt.wantOrigin, tt.wantRules)
+			}
+
+			// a change is undone once
+			if _, err := service.RollbackConfig(ctx, target); !errors.Is(err, cdn.ErrNothingToRollBack) {
+				t.Errorf("second RollbackConfig() error = %v, want ErrNothingToRollBack", err)
+			}
+		})
+	}
+}
+
+func TestUndoIntent(t *testing.T) {
+	service, provider := testutil.NewMockService(t)
+	svc := testutil.SeedService(t, provider, "shop.example.com", "origin.shop.example.com", "shop.example.com")
+
+	undo := testutil.NewIntentBuilder("UNDO").Build()
+	got, err := service.ExecuteIntent(context.Background(), undo)
+	if err != nil || !strings.Contains(got, "no configuration change to undo") {
+		t.Fatalf("UNDO without changes = %q, %v", got, err)
+	}
+
+	if err := provider.UpdateCacheRules(context.Background(), svc.ID, []cdn.CacheRule{{Path: "/*", TTL: 60}}); err != nil {
+		t.Fatalf("UpdateCacheRules() error = %v", err)
+	}
+	got, err = service.ExecuteIntent(context.Background(), undo)
+	if err != nil || !strings.Contains(got, "Rolled back the cache rule change on service "+svc.ID) {
+		t.Errorf("UNDO = %q, %v", got, err)
+	}
+}
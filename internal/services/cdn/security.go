@@ -0,0 +1,61 @@
+package cdn
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SecuritySettings are the security-relevant parts of a service configuration
+type SecuritySettings struct {
+	MinTLSVersion  string   `json:"min_tls_version,omitempty"` // e.g. "1.2"; empty when the provider default applies
+	HSTS           bool     `json:"hsts"`
+	HSTSMaxAge     int      `json:"hsts_max_age,omitempty"` // seconds
+	AllowedMethods []string `json:"allowed_methods"`
+}
+
+// SecurityInspector is implemented by providers that can report a service's security settings
+type SecurityInspector interface {
+	GetSecuritySettings(ctx context.Context, serviceID string) (*SecuritySettings, error)
+}
+
+// GetSecuritySettings returns the security settings of a service
+func (s *Service) GetSecuritySettings(ctx context.Context, serviceID string) (*SecuritySettings, error) {
+	inspector, ok := s.provider.(SecurityInspector)
+	if !ok {
+		return nil, fmt.Errorf("security settings: %w", ErrNotSupported)
+	}
+	return inspector.GetSecuritySettings(ctx, serviceID)
+}
+
+// CompareTLSVersions compares "1.2", "TLSv1.2" or "TLS1.2" style versions,
+// returning -1, 0 or 1; ok is false when either version can't be parsed
+func CompareTLSVersions(a, b string) (result int, ok bool) {
+	va, okA := tlsMinor(a)
+	vb, okB := tlsMinor(b)
+	if !okA || !okB {
+		return 0, false
+	}
+	switch {
+	case va < vb:
+		return -1, true
+	case va > vb:
+		return 1, true
+	}
+	return 0, true
+}
+
+// tlsMinor returns the minor version of a TLS 1.x version string
+func tlsMinor(v string) (int, bool) {
+	v = strings.TrimLeft(strings.ToUpper(strings.TrimSpace(v)), "TLSV_ ")
+	major, minor, found := strings.Cut(strings.ReplaceAll(v, "_", "."), ".")
+	if !found || major != "1" {
+		return 0, false
+	}
+	n, err := strconv.Atoi(minor)
+	if err != nil || n < 0 || n > 3 {
+		return 0, false
+	}
+	return n, true
+}
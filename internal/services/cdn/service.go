@@ -2,15 +2,24 @@ package cdn
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/avvvet/cdnbuddy-api/internal/domain"
 	"github.com/avvvet/cdnbuddy-api/internal/models"
+	"github.com/avvvet/cdnbuddy-api/internal/services/search"
 )
 
 type Service struct {
-	provider CDNProvider
+	provider   CDNProvider       // default provider
+	registry   *ProviderRegistry // nil for single-provider services
+	simulation *Simulation       // set on services returned by Simulate
+	calls      *CallPolicy       // the registry's providers' call policy, if any
+	guard      *ChangeGuard      // nil = configuration changes aren't rate-checked
 }
 
 func NewService(provider CDNProvider) *Service {
@@ -19,9 +28,93 @@ func NewService(provider CDNProvider) *Service {
 	}
 }
 
-// ListServices returns all CDN services (exposed for API handlers)
+// NewServiceWithRegistry creates a service that manages every provider in the
+// registry, using the registry's default when a request names none. calls is
+// the policy the providers were created with; guard checks configuration
+// changes. Both may be nil.
+func NewServiceWithRegistry(registry *ProviderRegistry, calls *CallPolicy, guard *ChangeGuard) (*Service, error) {
+	provider, err := registry.Get("")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get default provider: %w", err)
+	}
+
+	return &Service{
+		provider: provider,
+		registry: registry,
+		calls:    calls,
+		guard:    guard,
+	}, nil
+}
+
+// ForProvider returns a service scoped to one provider; an empty name returns s
+func (s *Service) ForProvider(name domain.CDNProvider) (*Service, error) {
+	if name == "" {
+		return s, nil
+	}
+	if s.registry == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownProvider, name)
+	}
+
+	provider, err := s.registry.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	return &Service{provider: provider, simulation: s.simulation, calls: s.calls, guard: s.guard}, nil
+}
+
+// ProviderOf names the provider intent parameters target: the one they name,
+// otherwise the default (empty for single-provider services)
+func (s *Service) ProviderOf(params map[string]*string) domain.CDNProvider {
+	if name := getParam(params, "provider"); name != "" {
+		return ParseProvider(name)
+	}
+	return s.defaultProviderName()
+}
+
+// Providers returns the names of the providers this service manages
+func (s *Service) Providers() []domain.CDNProvider {
+	if s.registry == nil {
+		return []domain.CDNProvider{}
+	}
+	return s.registry.Names()
+}
+
+// ListServices returns all active CDN services across managed providers (exposed for API handlers)
 func (s *Service) ListServices(ctx context.Context) ([]domain.CDNService, error) {
-	return s.provider.ListServices(ctx)
+	return s.ListServicesByStatus(ctx, StatusActive)
+}
+
+// providerOf returns the provider that owns a service
+func (s *Service) providerOf(svc domain.CDNService) CDNProvider {
+	if s.registry != nil && svc.Provider != "" {
+		if provider, err := s.registry.Get(svc.Provider); err == nil {
+			return provider
+		}
+	}
+	return s.provider
+}
+
+// CreateService creates a service on the default provider (or the one this service is scoped to)
+func (s *Service) CreateService(ctx context.Context, config *ServiceConfig) (*domain.CDNService, error) {
+	if err := s.checkServiceConfig(config); err != nil {
+		return nil, err
+	}
+	return s.provider.CreateService(ctx, config)
+}
+
+// AddDomain adds a domain to a service
+func (s *Service) AddDomain(ctx context.Context, serviceID, domainName string) error {
+	return s.provider.AddDomain(ctx, serviceID, domainName)
+}
+
+// GetService returns an active service by ID from any managed provider
+func (s *Service) GetService(ctx context.Context, serviceID string) (*domain.CDNService, error) {
+	return s.findSpecService(ctx, ServiceSpec{ServiceID: serviceID})
+}
+
+// ListServiceDomains returns the domains of a service from the provider that owns it
+func (s *Service) ListServiceDomains(ctx context.Context, svc domain.CDNService) ([]domain.Domain, error) {
+	return s.providerOf(svc).ListDomains(ctx, svc.ID)
 }
 
 // ExecuteIntent handles intent responses and executes CDN operations
@@ -30,6 +123,25 @@ func (s *Service) ExecuteIntent(ctx context.Context, intent *models.IntentRespon
 		return "", fmt.Errorf("no action specified")
 	}
 
+	// Route to the provider named in the intent, if any
+	if name := getParam(intent.Parameters, "provider"); name != "" && s.registry != nil {
+		scoped, err := s.ForProvider(ParseProvider(name))
+		if err != nil {
+			return "", err
+		}
+		return scoped.ExecuteIntent(ctx, intent)
+	}
+
+	// dry_run=true goes through validation and capability checks but
+	// records provider writes instead of sending them
+	if s.simulation == nil && models.IsDryRun(intent.Parameters) {
+		steps, result, err := s.SimulateIntent(ctx, intent)
+		if err != nil {
+			return "", err
+		}
+		return describeSimulation(steps, result), nil
+	}
+
 	switch *intent.Action {
 	case "SETUP_CDN":
 		return s.handleSetupCDN(ctx, intent.Parameters)
@@ -37,20 +149,41 @@ func (s *Service) ExecuteIntent(ctx context.Context, intent *models.IntentRespon
 		return s.handleAddDomain(ctx, intent.Parameters)
 	case "LIST_SERVICES":
 		return s.handleListServices(ctx)
+	case "SET_STALE_POLICY":
+		return s.handleSetStalePolicy(ctx, intent.Parameters)
+	case "CONFIGURE_ORIGIN_SHIELD":
+		return s.handleConfigureOriginShield(ctx, intent.Parameters)
+	case "UPDATE_CACHE_RULES":
+		return s.handleUpdateCacheRules(ctx, intent.Parameters)
+	case "FIND_SERVICE":
+		return s.handleFindService(ctx, intent.Parameters)
+	case "SECURE_SERVICE":
+		return s.handleSecureService(ctx, intent.Parameters)
+	case "DELETE_SERVICE":
+		return s.handleDeleteService(ctx, intent.Parameters)
+	case "PURGE_ALL":
+		return s.handlePurgeAll(ctx, intent.Parameters)
+	case "PROTECT_HOTLINKS":
+		return s.handleProtectHotlinks(ctx, intent.Parameters)
+	case "ADD_HEADER":
+		return s.handleAddHeader(ctx, intent.Parameters)
+	case "ACCOUNT_STATUS":
+		return s.handleAccountStatus(ctx)
+	case "UNDO":
+		return s.handleRollbackConfig(ctx, intent.Parameters)
 	default:
 		return "", fmt.Errorf("unknown action: %s", *intent.Action)
 	}
 }
 
-func (s *Service) handleSetupCDN(ctx context.Context, params map[string]*string) (string, error) {
-	// Extract parameters
+// setupConfigFromParams builds the service config of a SETUP_CDN intent
+func setupConfigFromParams(params map[string]*string) (*ServiceConfig, error) {
 	domain := getParam(params, "domain")
 	origin := getParam(params, "origin_hostname")
 	if domain == "" || origin == "" {
-		return "", fmt.Errorf("missing required parameters")
+		return nil, fmt.Errorf("missing required parameters")
 	}
 
-	// Step 1: Create service (this now automatically applies best practices)
 	config := &ServiceConfig{
 		Name: domain,
 		Origin: OriginConfig{
@@ -60,9 +193,47 @@ func (s *Service) handleSetupCDN(ctx context.Context, params map[string]*string)
 		SSL: SSLConfig{
 			Enabled: true,
 		},
+		Failover: parseBackupOrigins(getParam(params, "backup_origins")),
+	}
+	if v := getParam(params, "origin_protocol"); v != "" {
+		if v != "http" && v != "https" {
+			return nil, fmt.Errorf("invalid origin protocol %q (expected http or https)", v)
+		}
+		config.Origin.Protocol = v
+	}
+	if v := getParam(params, "origin_shield"); v != "" {
+		config.OriginShield = &OriginShield{Enabled: parseToggle(v), Region: getParam(params, "shield_region")}
+	}
+	profile, err := ParseProfile(getParam(params, "profile"))
+	if err != nil {
+		return nil, err
 	}
+	config.Profile = profile
+	return config, nil
+}
 
-	service, err := s.provider.CreateService(ctx, config)
+func (s *Service) handleSetupCDN(ctx context.Context, params map[string]*string) (string, error) {
+	config, err := setupConfigFromParams(params)
+	if err != nil {
+		return "", err
+	}
+	domain, origin := config.Name, config.Origin.Host
+
+	// Re-running a setup reconciles the existing service instead of creating a duplicate
+	spec := ServiceSpec{Name: domain, Origin: config.Origin, Domains: []string{domain}, SSL: config.SSL}
+	if existing, err := s.findSpecService(ctx, spec); err != nil {
+		return "", err
+	} else if existing != nil {
+		spec.ServiceID = existing.ID
+		result, err := s.Apply(ctx, spec)
+		if err != nil {
+			return "", err
+		}
+		return describeApply(result), nil
+	}
+
+	// Step 1: Create service (this now automatically applies best practices)
+	service, err := s.CreateService(ctx, config)
 	if err != nil {
 		return "", fmt.Errorf("failed to create service: %w", err)
 	}
@@ -76,20 +247,21 @@ func (s *Service) handleSetupCDN(ctx context.Context, params map[string]*string)
 	// Extract test URL from config
 	var configData map[string]interface{}
 	json.Unmarshal([]byte(service.Config), &configData)
-	testURL := configData["test_url"].(string)
-	uniqueName := configData["unique_name"].(string)
+	testURL, _ := configData["test_url"].(string)
+	cnameTarget := cnameTargetFor(ctx, service.ID, ProviderCNAMETarget(*service))
 
 	// ============================================
 	// Build enhanced response with optimizations
 	// ============================================
-	optimizations := GetOptimizationsSummary()
-	optimizationCount := GetOptimizationsCount()
+	optimizations := ProfileOptimizations(config.Profile)
+	optimizationCount := len(optimizations)
 
 	response := fmt.Sprintf(`✅ CDN configured successfully with %d optimizations!
 
 🧪 Test URL: %s
 🌐 Domain: %s (Status: Waiting for DNS)
 📡 Origin: %s
+🧩 Profile: %s
 
 🚀 Applied Optimizations:
    • %s
@@ -100,7 +272,7 @@ func (s *Service) handleSetupCDN(ctx context.Context, params map[string]*string)
    • ...and %d more optimizations
 
 📌 To activate your domain:
-   1. Update DNS: Type: CNAME, Name: %s, Value: %s.cachefly.net, TTL: 300
+   1. Update DNS: Type: CNAME, Name: %s, Value: %s, TTL: 300
    2. Wait 5-10 minutes for DNS propagation
 
 Your CDN is ready to test now!`,
@@ -108,6 +280,7 @@ Your CDN is ready to test now!`,
 		testURL,
 		domain,
 		origin,
+		config.Profile,
 		optimizations[0],
 		optimizations[1],
 		optimizations[2],
@@ -115,7 +288,7 @@ Your CDN is ready to test now!`,
 		optimizations[4],
 		optimizationCount-5,
 		domain,
-		uniqueName,
+		cnameTarget,
 	)
 
 	return response, nil
@@ -138,7 +311,7 @@ func (s *Service) handleAddDomain(ctx context.Context, params map[string]*string
 }
 
 func (s *Service) handleListServices(ctx context.Context) (string, error) {
-	services, err := s.provider.ListServices(ctx)
+	services, err := s.ListServices(ctx)
 	if err != nil {
 		return "", fmt.Errorf("failed to list services: %w", err)
 	}
@@ -149,15 +322,71 @@ func (s *Service) handleListServices(ctx context.Context) (string, error) {
 
 	response := fmt.Sprintf("You have %d CDN service(s):\n\n", len(services))
 	for i, svc := range services {
+		if s.registry != nil {
+			response += fmt.Sprintf("%d. %s [%s] (Status: %s)\n", i+1, svc.Name, svc.Provider, svc.Status)
+			continue
+		}
 		response += fmt.Sprintf("%d. %s (Status: %s)\n", i+1, svc.Name, svc.Status)
 	}
 
 	return response, nil
 }
 
+// handleFindService answers "which service serves blog.example.com" from chat
+func (s *Service) handleFindService(ctx context.Context, params map[string]*string) (string, error) {
+	query := getParam(params, "query")
+	if query == "" {
+		query = getParam(params, "domain")
+	}
+	if query == "" {
+		return "", fmt.Errorf("missing required parameters")
+	}
+
+	resp, err := search.NewSearcher(nil, nil).Search(ctx, s, query, search.Options{
+		Types: []search.Type{search.TypeService, search.TypeDomain},
+		Limit: 5,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to search services: %w", err)
+	}
+
+	if len(resp.Results) == 0 {
+		return fmt.Sprintf("I couldn't find a service or domain matching %q.", query), nil
+	}
+
+	best := resp.Results[0]
+	if best.Score == 100 && best.Type == search.TypeDomain {
+		return fmt.Sprintf("🔎 %s is %s (service ID: %s).", best.Title, best.Subtitle, best.ServiceID), nil
+	}
+
+	response := fmt.Sprintf("🔎 Matches for %q:\n\n", query)
+	for i, r := range resp.Results {
+		response += fmt.Sprintf("%d. %s %s — %s (service ID: %s)\n", i+1, r.Type, r.Title, r.Subtitle, r.ServiceID)
+	}
+	return response, nil
+}
+
 func getParam(params map[string]*string, key string) string {
 	if val, ok := params[key]; ok && val != nil {
 		return *val
 	}
 	return ""
 }
+
+// StateHash returns a fingerprint of the account's services, used to invalidate
+// cached answers whenever services are added, removed or change status
+func (s *Service) StateHash(ctx context.Context) (string, error) {
+	services, err := s.ListServices(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to list services: %w", err)
+	}
+
+	parts := make([]string, 0, len(services))
+	for _, svc := range services {
+		parts = append(parts, string(svc.Provider)+":"+svc.ID+":"+svc.Name+":"+svc.Status)
+	}
+	sort.Strings(parts)
+
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\n")))
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,230 @@
+package cdn_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/avvvet/cdnbuddy-api/internal/services/cdn"
+	"github.com/avvvet/cdnbuddy-api/internal/testutil"
+)
+
+func TestExecuteIntent(t *testing.T) {
+	tests := []struct {
+		name    string
+		seed    bool
+		intent  *testutil.IntentBuilder
+		want    string
+		wantErr string
+	}{
+		{
+			name:   "list without services",
+			intent: testutil.NewIntentBuilder("LIST_SERVICES"),
+			want:   "You don't have any CDN services yet.",
+		},
+		{
+			name:   "list seeded services",
+			seed:   true,
+			intent: testutil.NewIntentBuilder("LIST_SERVICES"),
+			want:   "1. shop.example.com (Status: ACTIVE)",
+		},
+		{
+			name: "setup creates a service",
+			intent: testutil.NewIntentBuilder("SETUP_CDN").
+				WithParam("domain", "cdn.example.com").
+				WithParam("origin_hostname", "origin.example.com"),
+			want: "CDN configured successfully",
+		},
+		{
+			name: "setup rejects unknown origin protocol",
+			intent: testutil.NewIntentBuilder("SETUP_CDN").
+				WithParam("domain", "cdn.example.com").
+				WithParam("origin_hostname", "origin.example.com").
+				WithParam("origin_protocol", "ftp"),
+			wantErr: "invalid origin protocol",
+		},
+		{
+			name:    "add domain without service",
+			intent:  testutil.NewIntentBuilder("ADD_DOMAIN").WithParam("domain", "www.example.com"),
+			wantErr: "missing required parameters",
+		},
+		{
+			name:   "find service by domain",
+			seed:   true,
+			intent: testutil.NewIntentBuilder("FIND_SERVICE").WithParam("query", "static.shop.example.com"),
+			want:   "static.shop.example.com",
+		},
+		{
+			name:    "unknown action",
+			intent:  testutil.NewIntentBuilder("TELEPORT"),
+			wantErr: "unknown action: TELEPORT",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, provider := testutil.NewMockService(t)
+			if tt.seed {
+				testutil.SeedService(t, provider, "shop.example.com", "origin.shop.example.com", "shop.example.com", "static.shop.example.com")
+			}
+
+			got, err := service.ExecuteIntent(context.Background(), tt.intent.Build())
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ExecuteIntent() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ExecuteIntent() unexpected error: %v", err)
+			}
+			if !strings.Contains(got, tt.want) {
+				t.Errorf("ExecuteIntent() = %q, want it to contain %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetupCDNIsIdempotent(t *testing.T) {
+	service, _ := testutil.NewMockService(t)
+	intent := testutil.NewIntentBuilder("SETUP_CDN").
+		WithParam("domain", "cdn.example.com").
+		WithParam("origin_hostname", "origin.example.com")
+
+	for i := 0; i < 2; i++ {
+		if _, err := service.ExecuteIntent(context.Background(), intent.Build()); err != nil {
+			t.Fatalf("run %d: ExecuteIntent() unexpected error: %v", i+1, err)
+		}
+	}
+
+	services, err := service.ListServices(context.Background())
+	if err != nil {
+		t.Fatalf("ListServices() unexpected error: %v", err)
+	}
+	if len(services) != 1 {
+		t.Errorf("ListServices() returned %d services after two setups, want 1", len(services))
+	}
+}
+
+func TestGetService(t *testing.T) {
+	service, provider := testutil.NewMockService(t)
+	active := testutil.SeedService(t, provider, "shop.example.com", "origin.shop.example.com")
+	deleted := testutil.SeedService(t, provider, "old.example.com", "origin.old.example.com")
+	if err := provider.DeleteService(context.Background(), deleted.ID); err != nil {
+		t.Fatalf("DeleteService() unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		serviceID string
+		wantName  string
+		wantErr   string
+	}{
+		{name: "active service", serviceID: active.ID, wantName: "shop.example.com"},
+		{name: "deleted service", serviceID: deleted.ID, wantErr: "not found"},
+		{name: "unknown service", serviceID: "missing", wantErr: "not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := service.GetService(context.Background(), tt.serviceID)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("GetService() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetService() unexpected error: %v", err)
+			}
+			if got.Name != tt.wantName {
+				t.Errorf("GetService() name = %q, want %q", got.Name, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestPurgeCache(t *testing.T) {
+	service, provider := testutil.NewMockService(t)
+	svc := testutil.SeedService(t, provider, "shop.example.com", "origin.shop.example.com")
+
+	tests := []struct {
+		name    string
+		paths   []string
+		want    []string
+		wantErr string
+	}{
+		{name: "deduplicates paths", paths: []string{"/a", " /b ", "/a"}, want: []string{"/a", "/b"}},
+		{name: "requires a path", paths: nil, wantErr: "at least one path"},
+		{name: "rejects relative paths", paths: []string{"a.css"}, wantErr: "must start with /"},
+		{name: "bounds the request", paths: make([]string, 501), wantErr: "at most 500 paths"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := service.PurgeCache(context.Background(), svc.ID, tt.paths)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("PurgeCache() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("PurgeCache() unexpected error: %v", err)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("PurgeCache() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateIntent(t *testing.T) {
+	tests := []struct {
+		name   string
+		intent *testutil.IntentBuilder
+		want   []string // "problem parameter"
+	}{
+		{
+			name: "valid setup",
+			intent: testutil.NewIntentBuilder("SETUP_CDN").
+				WithParam("domain", "cdn.example.com").
+				WithParam("origin_hostname", "origin.example.com"),
+		},
+		{
+			name: "setup with URL and missing origin",
+			intent: testutil.NewIntentBuilder("SETUP_CDN").
+				WithParam("domain", "https://cdn.example.com").
+				WithMissing("origin_hostname"),
+			want: []string{"invalid domain", "missing origin_hostname"},
+		},
+		{
+			name:   "alternative parameter satisfies requirement",
+			intent: testutil.NewIntentBuilder("PURGE_ALL").WithParam("domain", "shop.example.com"),
+		},
+		{
+			name:   "negative stale seconds",
+			intent: testutil.NewIntentBuilder("SET_STALE_POLICY").WithParam("service_id", "svc-1").WithParam("max_stale", "-5"),
+			want:   []string{"invalid max_stale"},
+		},
+		{
+			name:   "action without spec",
+			intent: testutil.NewIntentBuilder("LIST_SERVICES"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := cdn.ValidateIntent(tt.intent.Build())
+			var got []string
+			if err != nil {
+				for _, p := range err.Problems {
+					got = append(got, p.Problem+" "+p.Parameter)
+				}
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("ValidateIntent() problems = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
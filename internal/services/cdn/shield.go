@@ -0,0 +1,178 @@
+package cdn
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// OriginLoadOptions reduce traffic to the origin: an origin shield routes cache
+// misses through one mid-tier location, request coalescing merges concurrent
+// misses for the same object into a single origin fetch
+type OriginLoadOptions struct {
+	Shield            bool   `json:"shield"`
+	ShieldRegion      string `json:"shield_region,omitempty"`
+	RequestCoalescing bool   `json:"request_coalescing"`
+}
+
+// OriginShield enables the origin shield when a service is created
+type OriginShield struct {
+	Enabled bool   `json:"enabled"`
+	Region  string `json:"region,omitempty"` // empty = provider default
+}
+
+// Options returns the shield as origin-load options (request coalescing off)
+func (o OriginShield) Options() OriginLoadOptions {
+	if !o.Enabled {
+		return OriginLoadOptions{}
+	}
+	return OriginLoadOptions{Shield: true, ShieldRegion: o.Region}
+}
+
+// OriginLoadSupport describes which origin-load options a provider can set
+type OriginLoadSupport struct {
+	Shield            bool     `json:"shield"`
+	ShieldRegions     []string `json:"shield_regions,omitempty"`
+	RequestCoalescing bool     `json:"request_coalescing"`
+}
+
+// OriginLoadConfigurer is implemented by providers with origin shield or request coalescing
+type OriginLoadConfigurer interface {
+	OriginLoadSupport() OriginLoadSupport
+	GetOriginLoad(ctx context.Context, serviceID string) (*OriginLoadOptions, error)
+	UpdateOriginLoad(ctx context.Context, serviceID string, options OriginLoadOptions) error
+}
+
+// Check returns an error wrapping ErrNotSupported for options the provider can't set
+func (s OriginLoadSupport) Check(o OriginLoadOptions) error {
+	if o.Shield && !s.Shield {
+		return fmt.Errorf("origin shield: %w", ErrNotSupported)
+	}
+	if o.Shield && o.ShieldRegion != "" && len(s.ShieldRegions) > 0 {
+		found := false
+		for _, region := range s.ShieldRegions {
+			if region == o.ShieldRegion {
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("invalid shield_region %q (expected one of %s)", o.ShieldRegion, strings.Join(s.ShieldRegions, ", "))
+		}
+	}
+	if !o.Shield && o.ShieldRegion != "" {
+		return fmt.Errorf("shield_region is set but the shield is disabled")
+	}
+	if o.RequestCoalescing && !s.RequestCoalescing {
+		return fmt.Errorf("request coalescing: %w", ErrNotSupported)
+	}
+	return nil
+}
+
+// Explain summarizes the applied options for chat responses
+func (o OriginLoadOptions) Explain() string {
+	shield := "OFF"
+	if o.Shield {
+		shield = "ON"
+		if o.ShieldRegion != "" {
+			shield += " (" + o.ShieldRegion + ")"
+		}
+	}
+	coalescing := "OFF"
+	if o.RequestCoalescing {
+		coalescing = "ON"
+	}
+	return fmt.Sprintf("• Origin shield: %s\n• Request coalescing: %s", shield, coalescing)
+}
+
+// OriginLoadSupport returns what the provider supports (nothing if unsupported)
+func (s *Service) OriginLoadSupport() OriginLoadSupport {
+	configurer, ok := s.provider.(OriginLoadConfigurer)
+	if !ok {
+		return OriginLoadSupport{}
+	}
+	return configurer.OriginLoadSupport()
+}
+
+// GetOriginLoad returns the origin shield and request coalescing options of a service
+func (s *Service) GetOriginLoad(ctx context.Context, serviceID string) (*OriginLoadOptions, error) {
+	configurer, ok := s.provider.(OriginLoadConfigurer)
+	if !ok {
+		return nil, fmt.Errorf("origin shield: %w", ErrNotSupported)
+	}
+	return configurer.GetOriginLoad(ctx, serviceID)
+}
+
+// UpdateOriginLoad checks provider support and applies origin-load options
+func (s *Service) UpdateOriginLoad(ctx context.Context, serviceID string, options OriginLoadOptions) error {
+	configurer, ok := s.provider.(OriginLoadConfigurer)
+	if !ok {
+		return fmt.Errorf("origin shield: %w", ErrNotSupported)
+	}
+	if err := configurer.OriginLoadSupport().Check(options); err != nil {
+		return err
+	}
+	if err := s.guardChange(ctx, serviceID, "origin_load"); err != nil {
+		return err
+	}
+	return configurer.UpdateOriginLoad(ctx, serviceID, options)
+}
+
+// checkServiceConfig rejects service options the provider can't apply at creation
+func (s *Service) checkServiceConfig(config *ServiceConfig) error {
+	if _, err := ParseProfile(string(config.Profile)); err != nil {
+		return err
+	}
+	if err := ValidateOptionOverrides(config.Custom); err != nil {
+		return err
+	}
+	if len(config.Headers) > 0 {
+		if !supports[HeaderConfigurer](s.provider) {
+			return fmt.Errorf("response headers: %w", ErrNotSupported)
+		}
+		if err := ValidateResponseHeaders(config.Headers); err != nil {
+			return err
+		}
+	}
+	if config.TLS != nil {
+		if _, err := s.checkTLSPolicy(config.TLS); err != nil {
+			return err
+		}
+	}
+	if config.OriginShield == nil || !config.OriginShield.Enabled {
+		return nil
+	}
+	return s.OriginLoadSupport().Check(config.OriginShield.Options())
+}
+
+// handleConfigureOriginShield applies origin-load options from chat; the
+// trade-offs were shown in the execution plan before the user confirmed
+func (s *Service) handleConfigureOriginShield(ctx context.Context, params map[string]*string) (string, error) {
+	serviceID := getParam(params, "service_id")
+	if serviceID == "" {
+		return "", fmt.Errorf("missing required parameters")
+	}
+
+	options, err := s.GetOriginLoad(ctx, serviceID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get origin shield settings: %w", err)
+	}
+
+	if v := getParam(params, "shield"); v != "" {
+		options.Shield = parseToggle(v)
+	}
+	if v := getParam(params, "shield_region"); v != "" {
+		options.ShieldRegion = v
+	}
+	if !options.Shield {
+		options.ShieldRegion = ""
+	}
+	if v := getParam(params, "request_coalescing"); v != "" {
+		options.RequestCoalescing = parseToggle(v)
+	}
+
+	if err := s.UpdateOriginLoad(ctx, serviceID, *options); err != nil {
+		return "", fmt.Errorf("failed to update origin shield settings: %w", err)
+	}
+
+	return "✅ Origin load settings updated!\n\n" + options.Explain(), nil
+}
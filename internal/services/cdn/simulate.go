@@ -0,0 +1,395 @@
+package cdn
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/avvvet/cdnbuddy-api/internal/domain"
+	"github.com/avvvet/cdnbuddy-api/internal/models"
+)
+
+// SimulatedServiceID stands in for the ID of a service a simulation would create
+const SimulatedServiceID = "dry-run"
+
+// Simulation collects the provider writes a simulated operation would make
+type Simulation struct {
+	steps []DryRun
+	mu    sync.Mutex
+}
+
+func (s *Simulation) record(run DryRun) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.steps = append(s.steps, run)
+}
+
+// Steps returns the recorded writes in the order they would be sent
+func (s *Simulation) Steps() []DryRun {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append(make([]DryRun, 0, len(s.steps)), s.steps...)
+}
+
+// Simulate returns a copy of the service that runs validation, capability
+// checks and reads as usual but records provider writes instead of sending
+// them. Simulating a simulated service returns it unchanged.
+func (s *Service) Simulate() (*Service, *Simulation) {
+	if s.simulation != nil {
+		return s, s.simulation
+	}
+
+	sim := &Simulation{steps: make([]DryRun, 0)}
+	if s.registry == nil {
+		return &Service{provider: newSimulatingProvider("", s.provider, sim), simulation: sim, calls: s.calls, guard: s.guard}, sim
+	}
+
+	registry := NewProviderRegistry(s.registry.Default())
+	for _, name := range s.registry.Names() {
+		if provider, err := s.registry.Get(name); err == nil {
+			registry.Register(name, newSimulatingProvider(name, provider, sim))
+		}
+	}
+	provider, _ := registry.Get("")
+	return &Service{provider: provider, registry: registry, simulation: sim, calls: s.calls, guard: s.guard}, sim
+}
+
+// SimulateIntent runs an intent against a simulation of the service and
+// returns the writes it would make along with the response it would give
+func (s *Service) SimulateIntent(ctx context.Context, intent *models.IntentResponse) ([]DryRun, string, error) {
+	simulated, sim := s.Simulate()
+	result, err := simulated.ExecuteIntent(ctx, intent)
+	if err != nil {
+		return nil, "", err
+	}
+	return sim.Steps(), result, nil
+}
+
+// describeSimulation renders a simulated intent for chat
+func describeSimulation(steps []DryRun, result string) string {
+	var b strings.Builder
+	b.WriteString("🧪 Dry run: nothing was changed.\n\n")
+	if len(steps) == 0 {
+		b.WriteString("No provider changes would be made.\n")
+	} else {
+		b.WriteString("Provider changes that would be made:\n")
+		for _, step := range steps {
+			fmt.Fprintf(&b, "   • %s", step.Operation)
+			if step.ServiceID != "" {
+				fmt.Fprintf(&b, " on %s", step.ServiceID)
+			}
+			if step.Provider != "" {
+				fmt.Fprintf(&b, " [%s]", step.Provider)
+			}
+			b.WriteString("\n")
+			for _, req := range step.Requests {
+				fmt.Fprintf(&b, "      %s %s\n", req.Method, req.Path)
+			}
+		}
+	}
+	fmt.Fprintf(&b, "\nExpected result:\n%s", result)
+	return b.String()
+}
+
+// simulatingProvider passes reads through to a provider and records writes.
+// Writes the provider can describe exactly (create, update, cache rules) are
+// recorded as its dry-run requests; others record the operation and input.
+type simulatingProvider struct {
+	name  domain.CDNProvider
+	inner CDNProvider
+	sim   *Simulation
+}
+
+func newSimulatingProvider(name domain.CDNProvider, provider CDNProvider, sim *Simulation) CDNProvider {
+	return &simulatingProvider{name: name, inner: provider, sim: sim}
+}
+
+func (p *simulatingProvider) unwrap() CDNProvider { return p.inner }
+
+// write records a write the provider can't describe as requests
+func (p *simulatingProvider) write(operation, serviceID string, input interface{}) error {
+	run := newDryRun(string(p.name), operation, serviceID)
+	run.Input = input
+	p.sim.record(*run)
+	return nil
+}
+
+func (p *simulatingProvider) recordDryRun(run *DryRun, err error) error {
+	if err != nil {
+		return err
+	}
+	p.sim.record(*run)
+	return nil
+}
+
+func (p *simulatingProvider) CreateService(ctx context.Context, config *ServiceConfig) (*domain.CDNService, error) {
+	if err := p.recordDryRun(p.inner.DryRunCreateService(ctx, config)); err != nil {
+		return nil, err
+	}
+	return &domain.CDNService{
+		ID:        SimulatedServiceID,
+		Provider:  p.name,
+		Name:      config.Name,
+		Status:    "DRY_RUN",
+		Config:    "{}",
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}, nil
+}
+
+func (p *simulatingProvider) CloneService(ctx context.Context, serviceID, name string) (*domain.CDNService, error) {
+	if _, ok := p.inner.(ServiceCloner); !ok {
+		return nil, fmt.Errorf("clone service: %w", ErrNotSupported)
+	}
+	if err := p.write("clone_service", serviceID, map[string]string{"name": name}); err != nil {
+		return nil, err
+	}
+	return &domain.CDNService{
+		ID:        SimulatedServiceID,
+		Provider:  p.name,
+		Name:      name,
+		Status:    "DRY_RUN",
+		Config:    "{}",
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}, nil
+}
+
+func (p *simulatingProvider) ListServices(ctx context.Context) ([]domain.CDNService, error) {
+	return p.inner.ListServices(ctx)
+}
+
+func (p *simulatingProvider) ListServicesByStatus(ctx context.Context, status StatusFilter) ([]domain.CDNService, error) {
+	return p.inner.ListServicesByStatus(ctx, status)
+}
+
+func (p *simulatingProvider) ReactivateService(ctx context.Context, serviceID string) error {
+	if _, ok := p.inner.(ServiceActivator); !ok {
+		return fmt.Errorf("reactivate service: %w", ErrNotSupported)
+	}
+	return p.write("reactivate_service", serviceID, nil)
+}
+
+func (p *simulatingProvider) UpdateService(ctx context.Context, serviceID string, config *ServiceConfig) error {
+	return p.recordDryRun(p.inner.DryRunUpdateService(ctx, serviceID, config))
+}
+
+func (p *simulatingProvider) DeleteService(ctx context.Context, serviceID string) error {
+	return p.write("delete_service", serviceID, nil)
+}
+
+func (p *simulatingProvider) AddDomain(ctx context.Context, serviceID, domainName string) error {
+	return p.write("add_domain", serviceID, map[string]string{"domain": domainName})
+}
+
+func (p *simulatingProvider) RemoveDomain(ctx context.Context, serviceID, domainName string) error {
+	return p.write("remove_domain", serviceID, map[string]string{"domain": domainName})
+}
+
+func (p *simulatingProvider) ListDomains(ctx context.Context, serviceID string) ([]domain.Domain, error) {
+	if serviceID == SimulatedServiceID {
+		return []domain.Domain{}, nil
+	}
+	return p.inner.ListDomains(ctx, serviceID)
+}
+
+func (p *simulatingProvider) PurgeCache(ctx context.Context, serviceID string, paths []string) error {
+	return p.write("purge_cache", serviceID, map[string]interface{}{"paths": paths})
+}
+
+func (p *simulatingProvider) PurgeAll(ctx context.Context, serviceID string) error {
+	return p.write("purge_all", serviceID, nil)
+}
+
+func (p *simulatingProvider) GetMetrics(ctx context.Context, serviceID string) (*domain.Metrics, error) {
+	return p.inner.GetMetrics(ctx, serviceID)
+}
+
+func (p *simulatingProvider) UpdateCacheRules(ctx context.Context, serviceID string, rules []CacheRule) error {
+	return p.recordDryRun(p.inner.DryRunCacheRules(ctx, serviceID, rules))
+}
+
+func (p *simulatingProvider) UpdateOriginSettings(ctx context.Context, serviceID string, origin OriginConfig) error {
+	return p.write("update_origin", serviceID, origin)
+}
+
+func (p *simulatingProvider) DryRunCreateService(ctx context.Context, config *ServiceConfig) (*DryRun, error) {
+	return p.inner.DryRunCreateService(ctx, config)
+}
+
+func (p *simulatingProvider) DryRunUpdateService(ctx context.Context, serviceID string, config *ServiceConfig) (*DryRun, error) {
+	return p.inner.DryRunUpdateService(ctx, serviceID, config)
+}
+
+func (p *simulatingProvider) DryRunCacheRules(ctx context.Context, serviceID string, rules []CacheRule) (*DryRun, error) {
+	return p.inner.DryRunCacheRules(ctx, serviceID, rules)
+}
+
+// Optional capabilities: reads pass through when the wrapped provider has
+// them; writes are recorded only if it has them, so capability errors match
+// a real run
+
+func (p *simulatingProvider) ForEachService(ctx context.Context, fn func(svc domain.CDNService) error) error {
+	return (&readOnlyProvider{inner: p.inner}).ForEachService(ctx, fn)
+}
+
+func (p *simulatingProvider) ForEachDomain(ctx context.Context, serviceID string, fn func(d domain.Domain) error) error {
+	return (&readOnlyProvider{inner: p.inner}).ForEachDomain(ctx, serviceID, fn)
+}
+
+func (p *simulatingProvider) CacheKeySupport() CacheKeySupport {
+	return (&readOnlyProvider{inner: p.inner}).CacheKeySupport()
+}
+
+func (p *simulatingProvider) GetCacheKey(ctx context.Context, serviceID string) (*CacheKeyConfig, error) {
+	return (&readOnlyProvider{inner: p.inner}).GetCacheKey(ctx, serviceID)
+}
+
+func (p *simulatingProvider) UpdateCacheKey(ctx context.Context, serviceID string, config CacheKeyConfig) error {
+	if _, ok := p.inner.(CacheKeyConfigurer); !ok {
+		return fmt.Errorf("cache key configuration: %w", ErrNotSupported)
+	}
+	return p.write("update_cache_key", serviceID, config)
+}
+
+func (p *simulatingProvider) GetStalePolicy(ctx context.Context, serviceID string) (*StalePolicy, error) {
+	return (&readOnlyProvider{inner: p.inner}).GetStalePolicy(ctx, serviceID)
+}
+
+func (p *simulatingProvider) UpdateStalePolicy(ctx context.Context, serviceID string, policy StalePolicy) error {
+	if _, ok := p.inner.(StalePolicyConfigurer); !ok {
+		return fmt.Errorf("stale policy: %w", ErrNotSupported)
+	}
+	return p.write("update_stale_policy", serviceID, policy)
+}
+
+func (p *simulatingProvider) OriginLoadSupport() OriginLoadSupport {
+	return (&readOnlyProvider{inner: p.inner}).OriginLoadSupport()
+}
+
+func (p *simulatingProvider) GetOriginLoad(ctx context.Context, serviceID string) (*OriginLoadOptions, error) {
+	return (&readOnlyProvider{inner: p.inner}).GetOriginLoad(ctx, serviceID)
+}
+
+func (p *simulatingProvider) UpdateOriginLoad(ctx context.Context, serviceID string, options OriginLoadOptions) error {
+	if _, ok := p.inner.(OriginLoadConfigurer); !ok {
+		return fmt.Errorf("origin shield: %w", ErrNotSupported)
+	}
+	return p.write("update_origin_load", serviceID, options)
+}
+
+func (p *simulatingProvider) GetLogDelivery(ctx context.Context, serviceID string) (*LogDelivery, error) {
+	return (&readOnlyProvider{inner: p.inner}).GetLogDelivery(ctx, serviceID)
+}
+
+func (p *simulatingProvider) UpdateLogDelivery(ctx context.Context, serviceID string, delivery LogDelivery) error {
+	if _, ok := p.inner.(LogDeliveryConfigurer); !ok {
+		return fmt.Errorf("log delivery: %w", ErrNotSupported)
+	}
+	return p.write("update_log_delivery", serviceID, delivery)
+}
+
+func (p *simulatingProvider) ListLogFiles(ctx context.Context, serviceID string, since time.Time) ([]LogFile, error) {
+	return (&readOnlyProvider{inner: p.inner}).ListLogFiles(ctx, serviceID, since)
+}
+
+func (p *simulatingProvider) OpenLogFile(ctx context.Context, file LogFile) (io.ReadCloser, error) {
+	return (&readOnlyProvider{inner: p.inner}).OpenLogFile(ctx, file)
+}
+
+func (p *simulatingProvider) GetServiceState(ctx context.Context, serviceID string) (*ServiceState, error) {
+	return (&readOnlyProvider{inner: p.inner}).GetServiceState(ctx, serviceID)
+}
+
+func (p *simulatingProvider) UpdateSSL(ctx context.Context, serviceID string, ssl SSLConfig) error {
+	if _, ok := p.inner.(SSLConfigurer); !ok {
+		return fmt.Errorf("ssl: %w", ErrNotSupported)
+	}
+	return p.write("update_ssl", serviceID, ssl)
+}
+
+func (p *simulatingProvider) GetSecuritySettings(ctx context.Context, serviceID string) (*SecuritySettings, error) {
+	return (&readOnlyProvider{inner: p.inner}).GetSecuritySettings(ctx, serviceID)
+}
+
+func (p *simulatingProvider) FirewallSupport() FirewallSupport {
+	return (&readOnlyProvider{inner: p.inner}).FirewallSupport()
+}
+
+func (p *simulatingProvider) GetFirewall(ctx context.Context, serviceID string) (*FirewallConfig, error) {
+	return (&readOnlyProvider{inner: p.inner}).GetFirewall(ctx, serviceID)
+}
+
+func (p *simulatingProvider) UpdateFirewall(ctx context.Context, serviceID string, config FirewallConfig) error {
+	if _, ok := p.inner.(FirewallConfigurer); !ok {
+		return fmt.Errorf("firewall: %w", ErrNotSupported)
+	}
+	return p.write("update_firewall", serviceID, config)
+}
+
+func (p *simulatingProvider) GetHotlinkProtection(ctx context.Context, serviceID string) (*HotlinkConfig, error) {
+	return (&readOnlyProvider{inner: p.inner}).GetHotlinkProtection(ctx, serviceID)
+}
+
+func (p *simulatingProvider) UpdateHotlinkProtection(ctx context.Context, serviceID string, config HotlinkConfig) error {
+	if _, ok := p.inner.(HotlinkConfigurer); !ok {
+		return fmt.Errorf("hotlink protection: %w", ErrNotSupported)
+	}
+	return p.write("update_hotlink_protection", serviceID, config)
+}
+
+func (p *simulatingProvider) GetResponseHeaders(ctx context.Context, serviceID string) ([]ResponseHeader, error) {
+	return (&readOnlyProvider{inner: p.inner}).GetResponseHeaders(ctx, serviceID)
+}
+
+func (p *simulatingProvider) UpdateResponseHeaders(ctx context.Context, serviceID string, headers []ResponseHeader) error {
+	if _, ok := p.inner.(HeaderConfigurer); !ok {
+		return fmt.Errorf("response headers: %w", ErrNotSupported)
+	}
+	return p.write("update_response_headers", serviceID, headers)
+}
+
+func (p *simulatingProvider) TLSSupport() TLSSupport {
+	return (&readOnlyProvider{inner: p.inner}).TLSSupport()
+}
+
+func (p *simulatingProvider) GetTLSPolicy(ctx context.Context, serviceID string) (*TLSPolicy, error) {
+	return (&readOnlyProvider{inner: p.inner}).GetTLSPolicy(ctx, serviceID)
+}
+
+func (p *simulatingProvider) UpdateTLSPolicy(ctx context.Context, serviceID string, policy TLSPolicy) error {
+	if _, ok := p.inner.(TLSPolicyConfigurer); !ok {
+		return fmt.Errorf("tls policy: %w", ErrNotSupported)
+	}
+	return p.write("update_tls_policy", serviceID, policy)
+}
+
+// UploadCertificate records the certificate without its private key
+func (p *simulatingProvider) UploadCertificate(ctx context.Context, serviceID string, cert Certificate) error {
+	if _, ok := p.inner.(CertificateUploader); !ok {
+		return fmt.Errorf("certificate upload: %w", ErrNotSupported)
+	}
+	return p.write("upload_certificate", serviceID, Certificate{CertificatePEM: cert.CertificatePEM})
+}
+
+func (p *simulatingProvider) GetAccountInfo(ctx context.Context) (*AccountInfo, error) {
+	return (&readOnlyProvider{inner: p.inner}).GetAccountInfo(ctx)
+}
+
+func (p *simulatingProvider) LastConfigChange(ctx context.Context, serviceID string) (*ConfigChange, error) {
+	return (&readOnlyProvider{inner: p.inner}).LastConfigChange(ctx, serviceID)
+}
+
+func (p *simulatingProvider) RollbackConfig(ctx context.Context, serviceID string) (*ConfigChange, error) {
+	change, err := p.LastConfigChange(ctx, serviceID)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.write("rollback_config", change.ServiceID, change.Previous); err != nil {
+		return nil, err
+	}
+	return change, nil
+}
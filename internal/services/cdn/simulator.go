@@ -0,0 +1,228 @@
+package cdn
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+)
+
+const (
+	// DefaultEdgeTTL applies to paths no rule matches (the best-practice reverseProxy ttl)
+	DefaultEdgeTTL = 2678400
+
+	// simulationWindow is the period sample request counts refer to (one day)
+	simulationWindow = 24 * 3600
+
+	maxSimulationURLs = 5000
+)
+
+// SampleURL is a URL and how often it was requested per day (from logs);
+// sitemap entries without counts are weighted equally
+type SampleURL struct {
+	URL      string `json:"url"`
+	Requests int64  `json:"requests,omitempty"`
+}
+
+// URLPrediction is the simulated caching of one sample URL
+type URLPrediction struct {
+	URL              string  `json:"url"`
+	CurrentRule      string  `json:"current_rule,omitempty"`
+	CurrentTTL       int     `json:"current_ttl"`
+	ProposedRule     string  `json:"proposed_rule,omitempty"`
+	ProposedTTL      int     `json:"proposed_ttl"`
+	CurrentHitRatio  float64 `json:"current_hit_ratio"`
+	ProposedHitRatio float64 `json:"proposed_hit_ratio"`
+}
+
+// SimulationResult predicts how a proposed rule set changes effective TTLs and
+// hit ratio. Hit ratios assume requests spread evenly over the day, so one miss
+// per TTL window; they are estimates for comparison, not guarantees.
+type SimulationResult struct {
+	URLs             []URLPrediction `json:"urls"`
+	CurrentHitRatio  float64         `json:"current_hit_ratio"`
+	ProposedHitRatio float64         `json:"proposed_hit_ratio"`
+	HitRatioDelta    float64         `json:"hit_ratio_delta"`
+	ChangedURLs      int             `json:"changed_urls"`
+	UnmatchedRules   []string        `json:"unmatched_rules,omitempty"` // proposed rules no sample URL hits
+}
+
+// SimulateRules predicts effective TTLs and hit ratios of sample URLs under the
+// current and proposed rules
+func SimulateRules(current, proposed []CacheRule, samples []SampleURL) (*SimulationResult, error) {
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("at least one sample URL is required")
+	}
+	if len(samples) > maxSimulationURLs {
+		return nil, fmt.Errorf("at most %d sample URLs are allowed", maxSimulationURLs)
+	}
+	if err := validateRules(proposed); err != nil {
+		return nil, err
+	}
+
+	result := &SimulationResult{URLs: make([]URLPrediction, 0, len(samples))}
+	usedRules := make(map[string]bool)
+
+	var totalWeight, currentHits, proposedHits float64
+	for _, sample := range samples {
+		p, err := urlPath(sample.URL)
+		if err != nil {
+			return nil, err
+		}
+
+		weight := float64(sample.Requests)
+		if weight <= 0 {
+			weight = 1
+		}
+
+		curRule, curTTL := effectiveTTL(current, p)
+		newRule, newTTL := effectiveTTL(proposed, p)
+		if newRule != "" {
+			usedRules[newRule] = true
+		}
+
+		prediction := URLPrediction{
+			URL:              sample.URL,
+			CurrentRule:      curRule,
+			CurrentTTL:       curTTL,
+			ProposedRule:     newRule,
+			ProposedTTL:      newTTL,
+			CurrentHitRatio:  predictHitRatio(weight, curTTL),
+			ProposedHitRatio: predictHitRatio(weight, newTTL),
+		}
+		if curTTL != newTTL {
+			result.ChangedURLs++
+		}
+		result.URLs = append(result.URLs, prediction)
+
+		totalWeight += weight
+		currentHits += prediction.CurrentHitRatio * weight
+		proposedHits += prediction.ProposedHitRatio * weight
+	}
+
+	result.CurrentHitRatio = round3(currentHits / totalWeight)
+	result.ProposedHitRatio = round3(proposedHits / totalWeight)
+	result.HitRatioDelta = round3(result.ProposedHitRatio - result.CurrentHitRatio)
+
+	for _, rule := range proposed {
+		if !usedRules[rule.Path] {
+			result.UnmatchedRules = append(result.UnmatchedRules, rule.Path)
+		}
+	}
+
+	return result, nil
+}
+
+// PreviewCacheRulesIntent simulates the rules of an UPDATE_CACHE_RULES intent
+// against its sample URLs, for the execution plan preview
+func PreviewCacheRulesIntent(params map[string]*string) (*SimulationResult, error) {
+	rules, err := parseRulesParam(getParam(params, "rules"))
+	if err != nil {
+		return nil, err
+	}
+
+	samples := make([]SampleURL, 0)
+	for _, u := range strings.FieldsFunc(getParam(params, "sample_urls"), func(r rune) bool {
+		return r == ',' || r == '\n' || r == ' '
+	}) {
+		samples = append(samples, SampleURL{URL: u})
+	}
+
+	return SimulateRules(nil, rules, samples)
+}
+
+// handleUpdateCacheRules replaces the cache rules of a service from an intent
+func (s *Service) handleUpdateCacheRules(ctx context.Context, params map[string]*string) (string, error) {
+	serviceID := getParam(params, "service_id")
+	rules, err := parseRulesParam(getParam(params, "rules"))
+	if serviceID == "" || err != nil {
+		return "", fmt.Errorf("missing required parameters")
+	}
+
+	if err := s.UpdateCacheRules(ctx, serviceID, rules); err != nil {
+		return "", fmt.Errorf("failed to update cache rules: %w", err)
+	}
+
+	return fmt.Sprintf("✅ Updated %d cache rule(s)!", len(rules)), nil
+}
+
+// effectiveTTL returns the most specific matching rule and its TTL
+func effectiveTTL(rules []CacheRule, p string) (string, int) {
+	matches := make([]CacheRule, 0)
+	for _, rule := range rules {
+		if ruleMatches(rule.Path, p) {
+			matches = append(matches, rule)
+		}
+	}
+	if len(matches) == 0 {
+		return "", DefaultEdgeTTL
+	}
+
+	// Longer patterns are more specific; ties keep rule order
+	sort.SliceStable(matches, func(i, j int) bool {
+		return len(strings.ReplaceAll(matches[i].Path, "*", "")) > len(strings.ReplaceAll(matches[j].Path, "*", ""))
+	})
+	return matches[0].Path, matches[0].TTL
+}
+
+// ruleMatches supports prefixes ("/static/"), globs ("/img/*.png") and
+// extension patterns ("*.css", matched against the file name)
+func ruleMatches(pattern, p string) bool {
+	if pattern == "" || pattern == "/" || pattern == "*" {
+		return true
+	}
+	if !strings.Contains(pattern, "*") {
+		return strings.HasPrefix(p, pattern)
+	}
+	if strings.HasPrefix(pattern, "*.") {
+		return strings.HasSuffix(p, pattern[1:])
+	}
+	if ok, _ := path.Match(pattern, p); ok {
+		return true
+	}
+	// Trailing "/*" covers nested paths too
+	if strings.HasSuffix(pattern, "/*") {
+		return strings.HasPrefix(p, strings.TrimSuffix(pattern, "*"))
+	}
+	return false
+}
+
+// predictHitRatio estimates hit ratio for requestsPerDay at a TTL: each TTL
+// window starts with one miss, the remaining requests in it are hits
+func predictHitRatio(requestsPerDay float64, ttl int) float64 {
+	if ttl <= 0 || requestsPerDay <= 0 {
+		return 0
+	}
+	perWindow := requestsPerDay * float64(ttl) / simulationWindow
+	return perWindow / (1 + perWindow)
+}
+
+func urlPath(raw string) (string, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid sample URL %q", raw)
+	}
+	if u.Path == "" {
+		return "/", nil
+	}
+	return u.Path, nil
+}
+
+func parseRulesParam(raw string) ([]CacheRule, error) {
+	if raw == "" {
+		return nil, fmt.Errorf("no rules given")
+	}
+	var rules []CacheRule
+	if err := json.Unmarshal([]byte(raw), &rules); err != nil {
+		return nil, fmt.Errorf("invalid rules: %w", err)
+	}
+	return rules, nil
+}
+
+func round3(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
@@ -0,0 +1,201 @@
+package cdn
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// maxStaleLimit caps how long stale content may be served (7 days)
+const maxStaleLimit = 7 * 24 * 3600
+
+// StalePolicy controls serving expired content: stale-while-revalidate answers
+// from cache while refreshing in the background, stale-if-error answers from
+// cache when the origin fails. Max staleness is in seconds (0 = provider default).
+type StalePolicy struct {
+	StaleWhileRevalidate bool `json:"stale_while_revalidate"`
+	SWRMaxStale          int  `json:"swr_max_stale,omitempty"`
+	StaleIfError         bool `json:"stale_if_error"`
+	SIEMaxStale          int  `json:"sie_max_stale,omitempty"`
+}
+
+// DefaultStalePolicy matches the best-practice options: serve stale on origin errors
+var DefaultStalePolicy = StalePolicy{StaleIfError: true}
+
+// StalePolicyConfigurer is implemented by providers that expose service-wide stale serving
+type StalePolicyConfigurer interface {
+	GetStalePolicy(ctx context.Context, serviceID string) (*StalePolicy, error)
+	UpdateStalePolicy(ctx context.Context, serviceID string, policy StalePolicy) error
+}
+
+// Validate checks max staleness values are in range and only set when enabled
+func (p StalePolicy) Validate() error {
+	for _, v := range []struct {
+		name    string
+		enabled bool
+		value   int
+	}{
+		{"swr_max_stale", p.StaleWhileRevalidate, p.SWRMaxStale},
+		{"sie_max_stale", p.StaleIfError, p.SIEMaxStale},
+	} {
+		if v.value < 0 || v.value > maxStaleLimit {
+			return fmt.Errorf("%s must be between 0 and %d seconds", v.name, maxStaleLimit)
+		}
+		if v.value > 0 && !v.enabled {
+			return fmt.Errorf("%s is set but the policy is disabled", v.name)
+		}
+	}
+	return nil
+}
+
+// CacheControl renders the policy as Cache-Control extensions for per-rule headers
+func (p StalePolicy) CacheControl() string {
+	parts := make([]string, 0, 2)
+	if p.StaleWhileRevalidate {
+		parts = append(parts, "stale-while-revalidate="+strconv.Itoa(maxStaleOrDefault(p.SWRMaxStale)))
+	}
+	if p.StaleIfError {
+		parts = append(parts, "stale-if-error="+strconv.Itoa(maxStaleOrDefault(p.SIEMaxStale)))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Explain describes what the policy does and its trade-offs, for chat responses
+func (p StalePolicy) Explain() string {
+	var b strings.Builder
+
+	if p.StaleWhileRevalidate {
+		fmt.Fprintf(&b, "• Stale-while-revalidate is ON (up to %s): visitors get the cached copy instantly after it expires while the CDN refreshes it in the background. Faster responses, but some visitors may briefly see outdated content.\n", humanSeconds(p.SWRMaxStale))
+	} else {
+		b.WriteString("• Stale-while-revalidate is OFF: expired content is always refreshed from the origin before it is served. Always fresh, but the first visitor after expiry waits for the origin.\n")
+	}
+
+	if p.StaleIfError {
+		fmt.Fprintf(&b, "• Stale-if-error is ON (up to %s): if your origin is down or erroring, the CDN keeps serving the last good copy instead of an error page.", humanSeconds(p.SIEMaxStale))
+	} else {
+		b.WriteString("• Stale-if-error is OFF: if your origin fails, visitors see the error. Only do this if serving outdated content is worse than an outage.")
+	}
+
+	return b.String()
+}
+
+// GetStalePolicy returns the service-wide stale policy of a service
+func (s *Service) GetStalePolicy(ctx context.Context, serviceID string) (*StalePolicy, error) {
+	configurer, ok := s.provider.(StalePolicyConfigurer)
+	if !ok {
+		return nil, fmt.Errorf("stale policy: %w", ErrNotSupported)
+	}
+	return configurer.GetStalePolicy(ctx, serviceID)
+}
+
+// UpdateStalePolicy validates and applies a service-wide stale policy
+func (s *Service) UpdateStalePolicy(ctx context.Context, serviceID string, policy StalePolicy) error {
+	configurer, ok := s.provider.(StalePolicyConfigurer)
+	if !ok {
+		return fmt.Errorf("stale policy: %w", ErrNotSupported)
+	}
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	if err := s.guardChange(ctx, serviceID, "stale_policy"); err != nil {
+		return err
+	}
+	return configurer.UpdateStalePolicy(ctx, serviceID, policy)
+}
+
+// UpdateCacheRules validates per-rule stale policies and replaces the cache rules of a service
+func (s *Service) UpdateCacheRules(ctx context.Context, serviceID string, rules []CacheRule) error {
+	if err := validateRules(rules); err != nil {
+		return err
+	}
+	if err := s.guardChange(ctx, serviceID, "cache_rules"); err != nil {
+		return err
+	}
+	return s.provider.UpdateCacheRules(ctx, serviceID, rules)
+}
+
+// validateRules checks the per-rule stale policies
+func validateRules(rules []CacheRule) error {
+	for _, rule := range rules {
+		if rule.Stale == nil {
+			continue
+		}
+		if err := rule.Stale.Validate(); err != nil {
+			return fmt.Errorf("rule %s: %w", rule.Path, err)
+		}
+	}
+	return nil
+}
+
+// handleSetStalePolicy toggles stale serving from chat and explains the result
+func (s *Service) handleSetStalePolicy(ctx context.Context, params map[string]*string) (string, error) {
+	serviceID := getParam(params, "service_id")
+	if serviceID == "" {
+		return "", fmt.Errorf("missing required parameters")
+	}
+
+	policy, err := s.GetStalePolicy(ctx, serviceID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get stale policy: %w", err)
+	}
+
+	if v := getParam(params, "stale_while_revalidate"); v != "" {
+		policy.StaleWhileRevalidate = parseToggle(v)
+		if !policy.StaleWhileRevalidate {
+			policy.SWRMaxStale = 0
+		}
+	}
+	if v := getParam(params, "stale_if_error"); v != "" {
+		policy.StaleIfError = parseToggle(v)
+		if !policy.StaleIfError {
+			policy.SIEMaxStale = 0
+		}
+	}
+	if v := getParam(params, "max_stale"); v != "" {
+		seconds, err := strconv.Atoi(v)
+		if err != nil {
+			return "", fmt.Errorf("invalid max_stale: %s", v)
+		}
+		if policy.StaleWhileRevalidate {
+			policy.SWRMaxStale = seconds
+		}
+		if policy.StaleIfError {
+			policy.SIEMaxStale = seconds
+		}
+	}
+
+	if err := s.UpdateStalePolicy(ctx, serviceID, *policy); err != nil {
+		return "", fmt.Errorf("failed to update stale policy: %w", err)
+	}
+
+	return "✅ Stale content policy updated!\n\n" + policy.Explain(), nil
+}
+
+func parseToggle(v string) bool {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "true", "on", "enable", "enabled", "yes", "1":
+		return true
+	}
+	return false
+}
+
+func maxStaleOrDefault(seconds int) int {
+	if seconds > 0 {
+		return seconds
+	}
+	return 86400
+}
+
+func humanSeconds(seconds int) string {
+	seconds = maxStaleOrDefault(seconds)
+	switch {
+	case seconds%86400 == 0:
+		return fmt.Sprintf("%d day(s)", seconds/86400)
+	case seconds%3600 == 0:
+		return fmt.Sprintf("%d hour(s)", seconds/3600)
+	case seconds%60 == 0:
+		return fmt.Sprintf("%d minute(s)", seconds/60)
+	}
+	return fmt.Sprintf("%d seconds", seconds)
+}
@@ -0,0 +1,111 @@
+package cdn
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/avvvet/cdnbuddy-api/internal/domain"
+)
+
+// StatusFilter selects services by status when listing
+type StatusFilter string
+
+const (
+	StatusActive   StatusFilter = "ACTIVE"
+	StatusInactive StatusFilter = "INACTIVE" // deactivated services, which can be reactivated
+	StatusAll      StatusFilter = "ALL"
+)
+
+// ParseStatusFilter parses a status filter; empty means ACTIVE
+func ParseStatusFilter(v string) (StatusFilter, error) {
+	switch f := StatusFilter(strings.ToUpper(strings.TrimSpace(v))); f {
+	case "":
+		return StatusActive, nil
+	case StatusActive, StatusInactive, StatusAll:
+		return f, nil
+	}
+	return "", fmt.Errorf("invalid status %q (expected ACTIVE, INACTIVE or ALL)", v)
+}
+
+// Matches reports whether a service status passes the filter. Anything other
+// than ACTIVE (DEACTIVATED, DISABLED, ...) counts as inactive.
+func (f StatusFilter) Matches(status string) bool {
+	active := strings.EqualFold(status, string(StatusActive))
+	switch f {
+	case StatusAll:
+		return true
+	case StatusInactive:
+		return !active
+	}
+	return active
+}
+
+// ServiceActivator is implemented by providers that can bring a deactivated
+// service back
+type ServiceActivator interface {
+	ReactivateService(ctx context.Context, serviceID string) error
+}
+
+// DomainStatusRecorder is implemented by providers whose domain status is kept
+// on our side and updated from provider callbacks; other providers report the
+// status live
+type DomainStatusRecorder interface {
+	SetDomainStatus(ctx context.Context, serviceID, domainName, status string) error
+}
+
+// collectServicesByStatus drains an iterator, keeping services that pass the filter
+func collectServicesByStatus(ctx context.Context, it ServiceIterator, status StatusFilter) ([]domain.CDNService, error) {
+	services, err := collectServices(ctx, it)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := make([]domain.CDNService, 0, len(services))
+	for _, svc := range services {
+		if status.Matches(svc.Status) {
+			filtered = append(filtered, svc)
+		}
+	}
+	return filtered, nil
+}
+
+// ListServicesByStatus returns services across managed providers that pass the filter
+func (s *Service) ListServicesByStatus(ctx context.Context, status StatusFilter) ([]domain.CDNService, error) {
+	if s.registry == nil {
+		return s.provider.ListServicesByStatus(ctx, status)
+	}
+
+	all := make([]domain.CDNService, 0)
+	for _, name := range s.registry.Names() {
+		provider, err := s.registry.Get(name)
+		if err != nil {
+			return nil, err
+		}
+
+		services, err := provider.ListServicesByStatus(ctx, status)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s services: %w", name, err)
+		}
+		all = append(all, services...)
+	}
+	return all, nil
+}
+
+// ReactivateService brings a deactivated service back
+func (s *Service) ReactivateService(ctx context.Context, serviceID string) error {
+	activator, ok := s.provider.(ServiceActivator)
+	if !ok {
+		return fmt.Errorf("reactivate service: %w", ErrNotSupported)
+	}
+	return activator.ReactivateService(ctx, serviceID)
+}
+
+// SetDomainStatus records a domain's status reported by a provider callback
+func (s *Service) SetDomainStatus(ctx context.Context, serviceID, domainName, status string) error {
+	recorder, ok := s.provider.(DomainStatusRecorder)
+	if !ok {
+		return fmt.Errorf("set domain status: %w", ErrNotSupported)
+	}
+	return recorder.SetDomainStatus(ctx, serviceID, domainName, status)
+}
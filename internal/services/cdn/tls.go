@@ -0,0 +1,97 @@
+package cdn
+
+import (
+	"context"
+	"fmt"
+)
+
+// TLSPolicy controls which protocols clients may use to reach a service.
+// Nil toggles leave the provider's current setting alone.
+type TLSPolicy struct {
+	MinVersion string `json:"min_version,omitempty"` // "1.0" to "1.3"; empty = provider default
+	HTTP2      *bool  `json:"http2,omitempty"`
+	HTTP3      *bool  `json:"http3,omitempty"` // HTTP/3 over QUIC
+}
+
+// TLSSupport describes which protocol settings a provider can map
+type TLSSupport struct {
+	MinVersions []string `json:"min_versions"`
+	HTTP2       bool     `json:"http2"`
+	HTTP3       bool     `json:"http3"`
+}
+
+// TLSPolicyConfigurer is implemented by providers that expose protocol settings
+type TLSPolicyConfigurer interface {
+	TLSSupport() TLSSupport
+	GetTLSPolicy(ctx context.Context, serviceID string) (*TLSPolicy, error)
+	UpdateTLSPolicy(ctx context.Context, serviceID string, policy TLSPolicy) error
+}
+
+// Validate normalizes the minimum version to "1.x"
+func (p *TLSPolicy) Validate() error {
+	if p.MinVersion == "" {
+		return nil
+	}
+	minor, ok := tlsMinor(p.MinVersion)
+	if !ok {
+		return fmt.Errorf("invalid min_version %q (expected 1.0, 1.1, 1.2 or 1.3)", p.MinVersion)
+	}
+	p.MinVersion = fmt.Sprintf("1.%d", minor)
+	return nil
+}
+
+// Check returns an error wrapping ErrNotSupported for the first setting the
+// provider can't map
+func (s TLSSupport) Check(p TLSPolicy) error {
+	if p.MinVersion != "" && !containsString(s.MinVersions, p.MinVersion) {
+		return fmt.Errorf("min_version %s: %w", p.MinVersion, ErrNotSupported)
+	}
+	if p.HTTP2 != nil && !s.HTTP2 {
+		return fmt.Errorf("http2: %w", ErrNotSupported)
+	}
+	if p.HTTP3 != nil && !s.HTTP3 {
+		return fmt.Errorf("http3: %w", ErrNotSupported)
+	}
+	return nil
+}
+
+// checkTLSPolicy validates a policy against the provider's support
+func (s *Service) checkTLSPolicy(policy *TLSPolicy) (TLSPolicyConfigurer, error) {
+	configurer, ok := s.provider.(TLSPolicyConfigurer)
+	if !ok {
+		return nil, fmt.Errorf("tls policy: %w", ErrNotSupported)
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	return configurer, configurer.TLSSupport().Check(*policy)
+}
+
+// TLSSupport returns what the provider supports (nothing if it has no protocol settings)
+func (s *Service) TLSSupport() TLSSupport {
+	if configurer, ok := s.provider.(TLSPolicyConfigurer); ok {
+		return configurer.TLSSupport()
+	}
+	return TLSSupport{}
+}
+
+// GetTLSPolicy returns the protocol settings of a service
+func (s *Service) GetTLSPolicy(ctx context.Context, serviceID string) (*TLSPolicy, error) {
+	configurer, ok := s.provider.(TLSPolicyConfigurer)
+	if !ok {
+		return nil, fmt.Errorf("tls policy: %w", ErrNotSupported)
+	}
+	return configurer.GetTLSPolicy(ctx, serviceID)
+}
+
+// UpdateTLSPolicy validates the policy against the provider's support and applies it
+func (s *Service) UpdateTLSPolicy(ctx context.Context, serviceID string, policy TLSPolicy) error {
+	configurer, err := s.checkTLSPolicy(&policy)
+	if err != nil {
+		return err
+	}
+	if err := s.guardChange(ctx, serviceID, "tls"); err != nil {
+		return err
+	}
+	return configurer.UpdateTLSPolicy(ctx, serviceID, policy)
+}
@@ -0,0 +1,264 @@
+// Package cms receives content-change webhooks from CMS platforms (WordPress,
+// Ghost, Contentful) and maps them to targeted cache purges of the service
+// serving the content.
+package cms
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/avvvet/cdnbuddy-api/internal/domain"
+)
+
+// Platform is a CMS that sends webhooks
+type Platform string
+
+const (
+	PlatformWordPress  Platform = "wordpress"
+	PlatformGhost      Platform = "ghost"
+	PlatformContentful Platform = "contentful"
+)
+
+// ErrUnknownHook is returned for deliveries to a hook that doesn't exist
+var ErrUnknownHook = errors.New("unknown webhook")
+
+const maxTemplates = 50
+
+// Hook links a CMS webhook to a service and says what to purge on a change.
+// Path templates may use {path} (the content's URL path), {slug}, {id} and {type}.
+type Hook struct {
+	ID        string              `json:"id"`
+	OrgID     string              `json:"org_id"`
+	ServiceID string              `json:"service_id"`
+	Provider  domain.CDNProvider  `json:"provider,omitempty"`
+	Platform  Platform            `json:"platform"`
+	Secret    string              `json:"secret,omitempty"`    // only returned when the hook is created
+	Paths     map[string][]string `json:"paths,omitempty"`     // content type -> path templates
+	Default   []string            `json:"default,omitempty"`   // templates for unmapped types; {path} if empty
+	Always    []string            `json:"always,omitempty"`    // purged on every change, e.g. "/" and feeds
+	PurgeAll  bool                `json:"purge_all,omitempty"` // purge everything instead of paths
+	URL       string              `json:"url"`
+	CreatedAt time.Time           `json:"created_at"`
+	UpdatedAt time.Time           `json:"updated_at"`
+}
+
+// Validate checks a hook's platform and templates
+func (h *Hook) Validate() error {
+	switch h.Platform {
+	case PlatformWordPress, PlatformGhost, PlatformContentful:
+	default:
+		return fmt.Errorf("platform must be wordpress, ghost or contentful")
+	}
+	if h.ServiceID == "" {
+		return fmt.Errorf("service_id is required")
+	}
+
+	count := len(h.Default) + len(h.Always)
+	for contentType, templates := range h.Paths {
+		if contentType == "" {
+			return fmt.Errorf("content types in paths can't be empty")
+		}
+		count += len(templates)
+		if err := validateTemplates(templates); err != nil {
+			return err
+		}
+	}
+	if count > maxTemplates {
+		return fmt.Errorf("at most %d path templates are allowed", maxTemplates)
+	}
+	if err := validateTemplates(h.Default); err != nil {
+		return err
+	}
+	if err := validateTemplates(h.Always); err != nil {
+		return err
+	}
+
+	// Contentful entries have no URL, so {path} can't be derived
+	if h.Platform == PlatformContentful && !h.PurgeAll && len(h.Paths) == 0 && len(h.Default) == 0 && len(h.Always) == 0 {
+		return fmt.Errorf("contentful hooks need path templates or purge_all")
+	}
+	return nil
+}
+
+func validateTemplates(templates []string) error {
+	for _, t := range templates {
+		if !strings.HasPrefix(t, "/") && !strings.HasPrefix(t, "{path}") {
+			return fmt.Errorf("invalid path template %q: templates must start with / or {path}", t)
+		}
+	}
+	return nil
+}
+
+// Store keeps webhook mappings in memory
+type Store struct {
+	hooks map[string]Hook // by ID
+	mu    sync.RWMutex
+}
+
+// NewStore creates an empty hook store
+func NewStore() *Store {
+	return &Store{hooks: make(map[string]Hook)}
+}
+
+// Create validates and stores a new hook. Without a secret one is generated;
+// it is returned only here and must be configured in the CMS.
+func (s *Store) Create(h Hook) (Hook, error) {
+	if err := h.Validate(); err != nil {
+		return Hook{}, err
+	}
+	if h.Secret == "" {
+		secret := make([]byte, 24)
+		if _, err := rand.Read(secret); err != nil {
+			return Hook{}, fmt.Errorf("failed to generate webhook secret: %w", err)
+		}
+		h.Secret = hex.EncodeToString(secret)
+	}
+
+	h.ID = uuid.New().String()
+	h.URL = "/hooks/cms/" + h.ID
+	h.CreatedAt = time.Now()
+	h.UpdatedAt = h.CreatedAt
+
+	s.mu.Lock()
+	s.hooks[h.ID] = h
+	s.mu.Unlock()
+	return h, nil
+}
+
+// Update replaces the mapping of a hook; its ID, org, service and secret are kept
+// unless a new secret is given
+func (s *Store) Update(id string, h Hook) (Hook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, ok := s.hooks[id]
+	if !ok {
+		return Hook{}, ErrUnknownHook
+	}
+	h.ID, h.OrgID, h.ServiceID, h.Provider = current.ID, current.OrgID, current.ServiceID, current.Provider
+	h.URL, h.CreatedAt, h.UpdatedAt = current.URL, current.CreatedAt, time.Now()
+	if h.Secret == "" {
+		h.Secret = current.Secret
+	}
+	if err := h.Validate(); err != nil {
+		return Hook{}, err
+	}
+
+	s.hooks[id] = h
+	h.Secret = ""
+	return h, nil
+}
+
+// Delete removes a hook; it reports whether the hook existed
+func (s *Store) Delete(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.hooks[id]
+	delete(s.hooks, id)
+	return ok
+}
+
+// Get returns a hook including its secret
+func (s *Store) Get(id string) (Hook, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	h, ok := s.hooks[id]
+	return h, ok
+}
+
+// List returns an org's hooks, optionally of one service, oldest first and
+// without secrets
+func (s *Store) List(orgID, serviceID string) []Hook {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	hooks := make([]Hook, 0)
+	for _, h := range s.hooks {
+		if h.OrgID != orgID || (serviceID != "" && h.ServiceID != serviceID) {
+			continue
+		}
+		h.Secret = ""
+		hooks = append(hooks, h)
+	}
+	sort.Slice(hooks, func(i, j int) bool { return hooks[i].CreatedAt.Before(hooks[j].CreatedAt) })
+	return hooks
+}
+
+// purgePaths expands the hook's templates for changed content
+func (h Hook) purgePaths(contents []Content) []string {
+	seen := make(map[string]bool)
+	paths := make([]string, 0)
+	add := func(path string) {
+		if path != "" && strings.HasPrefix(path, "/") && !seen[path] {
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+
+	for _, c := range contents {
+		templates, ok := h.Paths[c.Type]
+		if !ok {
+			templates = h.Default
+		}
+		if len(templates) == 0 {
+			templates = []string{"{path}"}
+		}
+		for _, t := range templates {
+			if expanded, ok := c.expand(t); ok {
+				add(expanded)
+			}
+		}
+	}
+	for _, t := range h.Always {
+		add(t)
+	}
+	return paths
+}
+
+// Content is a piece of content a webhook reported as changed
+type Content struct {
+	Type string `json:"type"`
+	ID   string `json:"id,omitempty"`
+	Slug string `json:"slug,omitempty"`
+	Path string `json:"path,omitempty"`
+}
+
+// expand fills a template; it fails when the template needs a value the content lacks
+func (c Content) expand(template string) (string, bool) {
+	values := map[string]string{"{path}": c.Path, "{slug}": c.Slug, "{id}": c.ID, "{type}": c.Type}
+	for placeholder, value := range values {
+		if !strings.Contains(template, placeholder) {
+			continue
+		}
+		if value == "" {
+			return "", false
+		}
+		if placeholder != "{path}" {
+			value = url.PathEscape(value)
+		}
+		template = strings.ReplaceAll(template, placeholder, value)
+	}
+	return template, true
+}
+
+// pathOf returns the path of a content URL
+func pathOf(link string) string {
+	if link == "" {
+		return ""
+	}
+	u, err := url.Parse(link)
+	if err != nil || u.Path == "" {
+		return ""
+	}
+	return u.EscapedPath()
+}
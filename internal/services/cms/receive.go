@@ -0,0 +1,210 @@
+package cms
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// SecretHeader carries the hook secret for platforms that don't sign deliveries
+const SecretHeader = "X-Webhook-Secret"
+
+// ErrUnauthorized is returned for deliveries without a valid secret or signature
+var ErrUnauthorized = errors.New("webhook signature or secret is invalid")
+
+// Delivery is what a webhook delivery asks to purge
+type Delivery struct {
+	Hook     Hook      `json:"-"`
+	Contents []Content `json:"contents"`
+	Paths    []string  `json:"paths,omitempty"`
+	PurgeAll bool      `json:"purge_all,omitempty"`
+	Ignored  string    `json:"ignored,omitempty"` // why nothing is purged
+}
+
+// Receive authenticates a delivery to a hook and maps the changed content to
+// the paths to purge
+func (s *Store) Receive(hookID string, header http.Header, body []byte) (*Delivery, error) {
+	hook, ok := s.Get(hookID)
+	if !ok {
+		return nil, ErrUnknownHook
+	}
+	if !authenticated(hook, header, body) {
+		return nil, ErrUnauthorized
+	}
+
+	delivery := &Delivery{Hook: hook}
+	var err error
+	switch hook.Platform {
+	case PlatformWordPress:
+		delivery.Contents, err = parseWordPress(body)
+	case PlatformGhost:
+		delivery.Contents, err = parseGhost(body)
+	case PlatformContentful:
+		topic := header.Get("X-Contentful-Topic")
+		if !strings.HasPrefix(topic, "ContentManagement.Entry.") || strings.HasSuffix(topic, ".create") || strings.HasSuffix(topic, ".save") || strings.HasSuffix(topic, ".auto_save") {
+			delivery.Ignored = fmt.Sprintf("topic %q doesn't change published content", topic)
+			return delivery, nil
+		}
+		delivery.Contents, err = parseContentful(body)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if hook.PurgeAll {
+		delivery.PurgeAll = true
+		return delivery, nil
+	}
+	delivery.Paths = hook.purgePaths(delivery.Contents)
+	if len(delivery.Paths) == 0 {
+		delivery.Ignored = "no path templates matched the changed content"
+	}
+	return delivery, nil
+}
+
+// authenticated checks Ghost's signature, or the shared secret header of the
+// other platforms
+func authenticated(hook Hook, header http.Header, body []byte) bool {
+	if hook.Platform != PlatformGhost {
+		return subtle.ConstantTimeCompare([]byte(header.Get(SecretHeader)), []byte(hook.Secret)) == 1
+	}
+
+	// X-Ghost-Signature: sha256=<hex>, t=<timestamp>; the HMAC covers body + timestamp
+	var signature, timestamp string
+	for _, part := range strings.Split(header.Get("X-Ghost-Signature"), ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "sha256":
+			signature = value
+		case "t":
+			timestamp = value
+		}
+	}
+	mac := hmac.New(sha256.New, []byte(hook.Secret))
+	mac.Write(body)
+	mac.Write([]byte(timestamp))
+	return signature != "" && hmac.Equal([]byte(signature), []byte(hex.EncodeToString(mac.Sum(nil))))
+}
+
+// parseWordPress reads a post from WordPress webhook plugins, which send the
+// post either at the top level or under "post"
+func parseWordPress(body []byte) ([]Content, error) {
+	type wpPost struct {
+		ID        json.Number `json:"ID"`
+		PostID    json.Number `json:"post_id"`
+		Type      string      `json:"post_type"`
+		Slug      string      `json:"post_name"`
+		Permalink string      `json:"post_permalink"`
+		Link      string      `json:"permalink"`
+	}
+	var payload struct {
+		wpPost
+		Post *wpPost `json:"post"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("invalid wordpress payload: %w", err)
+	}
+
+	post := payload.wpPost
+	if payload.Post != nil {
+		post = *payload.Post
+	}
+	id := post.ID.String()
+	if id == "" {
+		id = post.PostID.String()
+	}
+	link := post.Permalink
+	if link == "" {
+		link = post.Link
+	}
+	if post.Type == "" {
+		post.Type = "post"
+	}
+	return []Content{{Type: post.Type, ID: id, Slug: post.Slug, Path: pathOf(link)}}, nil
+}
+
+// parseGhost reads a post or page event. A changed slug purges both the old
+// and the new URL; deletions only carry the previous version.
+func parseGhost(body []byte) ([]Content, error) {
+	type ghostVersion struct {
+		ID   string `json:"id"`
+		Slug string `json:"slug"`
+		URL  string `json:"url"`
+	}
+	type ghostResource struct {
+		Current  ghostVersion `json:"current"`
+		Previous ghostVersion `json:"previous"`
+	}
+	var payload struct {
+		Post *ghostResource `json:"post"`
+		Page *ghostResource `json:"page"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("invalid ghost payload: %w", err)
+	}
+
+	contentType, resource := "post", payload.Post
+	if resource == nil {
+		contentType, resource = "page", payload.Page
+	}
+	if resource == nil {
+		return nil, fmt.Errorf("ghost payload has no post or page")
+	}
+
+	contents := make([]Content, 0, 2)
+	for _, v := range []ghostVersion{resource.Current, resource.Previous} {
+		if v.ID == "" && v.Slug == "" && v.URL == "" {
+			continue
+		}
+		c := Content{Type: contentType, ID: v.ID, Slug: v.Slug, Path: pathOf(v.URL)}
+		if len(contents) == 0 || contents[0].Slug != c.Slug || contents[0].Path != c.Path {
+			contents = append(contents, c)
+		}
+	}
+	return contents, nil
+}
+
+// parseContentful reads an entry; each localized slug is a separate content
+func parseContentful(body []byte) ([]Content, error) {
+	var entry struct {
+		Sys struct {
+			ID          string `json:"id"`
+			ContentType struct {
+				Sys struct {
+					ID string `json:"id"`
+				} `json:"sys"`
+			} `json:"contentType"`
+		} `json:"sys"`
+		Fields struct {
+			Slug map[string]string `json:"slug"`
+		} `json:"fields"`
+	}
+	if err := json.Unmarshal(body, &entry); err != nil {
+		return nil, fmt.Errorf("invalid contentful payload: %w", err)
+	}
+
+	base := Content{Type: entry.Sys.ContentType.Sys.ID, ID: entry.Sys.ID}
+	if len(entry.Fields.Slug) == 0 {
+		return []Content{base}, nil
+	}
+	locales := make([]string, 0, len(entry.Fields.Slug))
+	for locale := range entry.Fields.Slug {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+
+	contents := make([]Content, 0, len(locales))
+	for _, locale := range locales {
+		c := base
+		c.Slug = entry.Fields.Slug[locale]
+		contents = append(contents, c)
+	}
+	return contents, nil
+}
@@ -0,0 +1,204 @@
+package compliance
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/avvvet/cdnbuddy-api/internal/services/cdn"
+	"github.com/avvvet/cdnbuddy-api/internal/services/messaging"
+	"github.com/avvvet/cdnbuddy-api/internal/testutil"
+)
+
+func TestPolicyCheck(t *testing.T) {
+	compliant := cdn.SecuritySettings{
+		MinTLSVersion:  "1.2",
+		HSTS:           true,
+		HSTSMaxAge:     31536000,
+		AllowedMethods: []string{http.MethodGet, http.MethodHead},
+	}
+
+	tests := []struct {
+		name      string
+		policy    Policy
+		change    func(s *cdn.SecuritySettings)
+		wantRules []string
+	}{
+		{name: "compliant", policy: DefaultPolicy, change: func(*cdn.SecuritySettings) {}},
+		{
+			name:      "TLS below the floor",
+			policy:    DefaultPolicy,
+			change:    func(s *cdn.SecuritySettings) { s.MinTLSVersion = "1.0" },
+			wantRules: []string{RuleMinTLS},
+		},
+		{
+			name:      "no TLS floor set",
+			policy:    DefaultPolicy,
+			change:    func(s *cdn.SecuritySettings) { s.MinTLSVersion = "" },
+			wantRules: []string{RuleMinTLS},
+		},
+		{
+			name:      "HSTS off",
+			policy:    DefaultPolicy,
+			change:    func(s *cdn.SecuritySettings) { s.HSTS = false },
+			wantRules: []string{RuleHSTS},
+		},
+		{
+			name:      "HSTS max-age too short",
+			policy:    DefaultPolicy,
+			change:    func(s *cdn.SecuritySettings) { s.HSTSMaxAge = 3600 },
+			wantRules: []string{RuleHSTSMaxAge},
+		},
+		{
+			name:   "forbidden methods match regardless of case",
+			policy: DefaultPolicy,
+			change: func(s *cdn.SecuritySettings) {
+				s.AllowedMethods = append(s.AllowedMethods, "put", http.MethodDelete)
+			},
+			wantRules: []string{RuleForbiddenMethod + ":PUT", RuleForbiddenMethod + ":DELETE"},
+		},
+		{
+			name:   "empty policy checks nothing",
+			policy: Policy{},
+			change: func(s *cdn.SecuritySettings) {
+				*s = cdn.SecuritySettings{AllowedMethods: []string{http.MethodDelete}}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := compliant
+			settings.AllowedMethods = append([]string{}, compliant.AllowedMethods...)
+			tt.change(&settings)
+
+			rules := make([]string, 0)
+			for _, v := range tt.policy.check(settings) {
+				rules = append(rules, v.Rule)
+			}
+			if want := append([]string{}, tt.wantRules...); !reflect.DeepEqual(rules, want) {
+				t.Errorf("violated rules = %v, want %v", rules, want)
+			}
+		})
+	}
+}
+
+func TestPolicyValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  Policy
+		wantErr bool
+	}{
+		{name: "default", policy: DefaultPolicy},
+		{name: "unknown TLS version", policy: Policy{MinTLSVersion: "2.0"}, wantErr: true},
+		{name: "negative max-age", policy: Policy{MinHSTSMaxAge: -1}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.policy.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNextRun(t *testing.T) {
+	tests := []struct {
+		name string
+		now  time.Time
+		hour int
+		want time.Time
+	}{
+		{name: "later today", now: testutil.Epoch, hour: 14, want: testutil.Epoch.Add(2 * time.Hour)},
+		{name: "already passed", now: testutil.Epoch, hour: 3, want: testutil.Epoch.Add(15 * time.Hour)},
+		{name: "exactly now runs tomorrow", now: testutil.Epoch, hour: 12, want: testutil.Epoch.Add(24 * time.Hour)},
+		{
+			name: "local times are judged in UTC",
+			now:  time.Date(2025, time.January, 1, 21, 0, 0, 0, time.FixedZone("UTC-5", -5*3600)), // 02:00 UTC
+			hour: 3,
+			want: time.Date(2025, time.January, 2, 3, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextRun(tt.now, tt.hour); !got.Equal(tt.want) {
+				t.Errorf("nextRun(%s, %d) = %s, want %s", tt.now, tt.hour, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScanOrgNotifiesDrift(t *testing.T) {
+	ctx := context.Background()
+	service, provider := testutil.NewMockService(t)
+	first := testutil.SeedService(t, provider, "shop", "origin.shop.example.com")
+	testutil.SeedService(t, provider, "blog", "origin.blog.example.com")
+
+	bus := testutil.StartNATS(t)
+	notifications := make(chan messaging.NotificationEvent, 10)
+	if _, err := bus.Subscribe(messaging.SubjectNotification, func(msg *messaging.Message) {
+		var event messaging.NotificationEvent
+		if err := json.Unmarshal(msg.Data, &event); err == nil {
+			notifications <- event
+		}
+	}); err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	publisher := messaging.NewPublisher(bus)
+
+	// mock services start without HSTS
+	scanner := NewScanner(publisher, DefaultPolicy)
+	scanner.RegisterOrg("org-1", service, "user-1")
+	if _, err := scanner.Report("org-1"); err == nil {
+		t.Error("Report() before the first scan returned a report")
+	}
+
+	report, err := scanner.ScanOrg(ctx, "org-1")
+	if err != nil {
+		t.Fatalf("ScanOrg() error = %v", err)
+	}
+	if report.ServicesScanned != 2 || report.CompliantServices != 0 || len(report.Violations) != 2 {
+		t.Fatalf("report = %+v, want two services violating HSTS", report)
+	}
+	for i := 0; i < 2; i++ {
+		if n := testutil.Await(t, notifications); n.Type != messaging.EventComplianceDrift || n.UserID != "user-1" {
+			t.Errorf("notification = %+v, want compliance drift for user-1", n)
+		}
+	}
+	firstSeen := report.Violations[0].FirstSeen
+
+	// known violations keep their first sighting and aren't notified again
+	report, err = scanner.ScanOrg(ctx, "org-1")
+	if err != nil {
+		t.Fatalf("second ScanOrg() error = %v", err)
+	}
+	if !report.Violations[0].FirstSeen.Equal(firstSeen) {
+		t.Errorf("first seen = %s, want %s", report.Violations[0].FirstSeen, firstSeen)
+	}
+	if err := publisher.PublishNotification(messaging.NotificationEvent{Type: "marker"}); err != nil {
+		t.Fatalf("PublishNotification() error = %v", err)
+	}
+	if n := testutil.Await(t, notifications); n.Type != "marker" {
+		t.Errorf("second scan notified again: %+v", n)
+	}
+
+	stored, err := scanner.Report("org-1")
+	if err != nil || !stored.ScannedAt.Equal(report.ScannedAt) {
+		t.Errorf("Report() = %+v, %v, want the latest scan", stored, err)
+	}
+	if report.Violations[0].ServiceID != first.ID && report.Violations[1].ServiceID != first.ID {
+		t.Errorf("violations %+v don't name service %s", report.Violations, first.ID)
+	}
+}
+
+func TestScanOrgUnknown(t *testing.T) {
+	scanner := NewScanner(nil, DefaultPolicy)
+	if _, err := scanner.ScanOrg(context.Background(), "nope"); err == nil {
+		t.Error("ScanOrg() of an unregistered org succeeded")
+	}
+}
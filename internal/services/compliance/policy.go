@@ -0,0 +1,106 @@
+// Package compliance scans every service against a security policy (TLS
+// floor, HSTS, allowed HTTP methods), keeps the resulting violations and
+// notifies owners when a service drifts out of compliance.
+package compliance
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/avvvet/cdnbuddy-api/internal/services/cdn"
+)
+
+// Policy is the configuration every service must meet
+type Policy struct {
+	MinTLSVersion    string   `json:"min_tls_version,omitempty"` // e.g. "1.2"; empty skips the check
+	RequireHSTS      bool     `json:"require_hsts"`
+	MinHSTSMaxAge    int      `json:"min_hsts_max_age,omitempty"` // seconds
+	ForbiddenMethods []string `json:"forbidden_methods,omitempty"`
+}
+
+// DefaultPolicy applies when no policy file is configured
+var DefaultPolicy = Policy{
+	MinTLSVersion:    "1.2",
+	RequireHSTS:      true,
+	MinHSTSMaxAge:    15552000, // 180 days
+	ForbiddenMethods: []string{http.MethodPut, http.MethodDelete},
+}
+
+// LoadPolicy reads a JSON policy file
+func LoadPolicy(path string) (Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Policy{}, fmt.Errorf("failed to read policy file: %w", err)
+	}
+
+	var policy Policy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return Policy{}, fmt.Errorf("failed to parse policy file: %w", err)
+	}
+	if err := policy.Validate(); err != nil {
+		return Policy{}, fmt.Errorf("invalid policy file: %w", err)
+	}
+	return policy, nil
+}
+
+// Validate checks the policy is usable and normalizes method names
+func (p *Policy) Validate() error {
+	if p.MinTLSVersion != "" {
+		if _, ok := cdn.CompareTLSVersions(p.MinTLSVersion, "1.0"); !ok {
+			return fmt.Errorf("unknown min_tls_version %q (expected 1.0 to 1.3)", p.MinTLSVersion)
+		}
+	}
+	if p.MinHSTSMaxAge < 0 {
+		return fmt.Errorf("min_hsts_max_age must not be negative")
+	}
+	for i, m := range p.ForbiddenMethods {
+		p.ForbiddenMethods[i] = strings.ToUpper(strings.TrimSpace(m))
+	}
+	return nil
+}
+
+// Rules checked by the scanner
+const (
+	RuleMinTLS          = "min_tls_version"
+	RuleHSTS            = "hsts_required"
+	RuleHSTSMaxAge      = "hsts_max_age"
+	RuleForbiddenMethod = "forbidden_method"
+)
+
+// check returns the policy violations of one service's settings
+func (p Policy) check(settings cdn.SecuritySettings) []Violation {
+	violations := make([]Violation, 0)
+
+	if p.MinTLSVersion != "" {
+		cmp, ok := cdn.CompareTLSVersions(settings.MinTLSVersion, p.MinTLSVersion)
+		switch {
+		case settings.MinTLSVersion == "":
+			violations = append(violations, Violation{Rule: RuleMinTLS,
+				Message: fmt.Sprintf("No minimum TLS version is set; TLS %s or newer is required", p.MinTLSVersion)})
+		case !ok || cmp < 0:
+			violations = append(violations, Violation{Rule: RuleMinTLS,
+				Message: fmt.Sprintf("Minimum TLS version is %s; TLS %s or newer is required", settings.MinTLSVersion, p.MinTLSVersion)})
+		}
+	}
+
+	if p.RequireHSTS && !settings.HSTS {
+		violations = append(violations, Violation{Rule: RuleHSTS, Message: "HSTS is not enabled"})
+	} else if settings.HSTS && settings.HSTSMaxAge < p.MinHSTSMaxAge {
+		violations = append(violations, Violation{Rule: RuleHSTSMaxAge,
+			Message: fmt.Sprintf("HSTS max-age is %ds; at least %ds is required", settings.HSTSMaxAge, p.MinHSTSMaxAge)})
+	}
+
+	for _, method := range settings.AllowedMethods {
+		for _, forbidden := range p.ForbiddenMethods {
+			if strings.EqualFold(method, forbidden) {
+				violations = append(violations, Violation{Rule: RuleForbiddenMethod + ":" + forbidden,
+					Message: fmt.Sprintf("HTTP method %s is open to the public", forbidden)})
+			}
+		}
+	}
+
+	return violations
+}
@@ -0,0 +1,241 @@
+package compliance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/avvvet/cdnbuddy-api/internal/services/cdn"
+	"github.com/avvvet/cdnbuddy-api/internal/services/messaging"
+)
+
+// Violation is one policy rule a service breaks
+type Violation struct {
+	ServiceID   string    `json:"service_id"`
+	ServiceName string    `json:"service_name"`
+	Rule        string    `json:"rule"`
+	Message     string    `json:"message"`
+	FirstSeen   time.Time `json:"first_seen"`
+}
+
+// Report is the result of the latest scan of an org
+type Report struct {
+	OrgID               string      `json:"org_id"`
+	Policy              Policy      `json:"policy"`
+	ScannedAt           time.Time   `json:"scanned_at"`
+	ServicesScanned     int         `json:"services_scanned"`
+	CompliantServices   int         `json:"compliant_services"`
+	UnsupportedServices []string    `json:"unsupported_services,omitempty"` // provider can't report settings
+	Errors              []string    `json:"errors,omitempty"`
+	Violations          []Violation `json:"violations"`
+}
+
+type org struct {
+	service      *cdn.Service
+	notifyUserID string
+	report       *Report
+}
+
+// Scanner checks every service of registered orgs against the policy
+type Scanner struct {
+	policy    Policy
+	orgs      map[string]*org
+	publisher *messaging.Publisher
+	mu        sync.RWMutex
+}
+
+// NewScanner creates a compliance scanner for the given policy
+func NewScanner(publisher *messaging.Publisher, policy Policy) *Scanner {
+	return &Scanner{
+		policy:    policy,
+		orgs:      make(map[string]*org),
+		publisher: publisher,
+	}
+}
+
+// RegisterOrg adds an org whose services are scanned; drifts are sent to notifyUserID
+func (s *Scanner) RegisterOrg(orgID string, service *cdn.Service, notifyUserID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.orgs[orgID] = &org{service: service, notifyUserID: notifyUserID}
+}
+
+// Report returns the latest scan report of an org
+func (s *Scanner) Report(orgID string) (*Report, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	o, ok := s.orgs[orgID]
+	if !ok {
+		return nil, fmt.Errorf("org not found: %s", orgID)
+	}
+	if o.report == nil {
+		return nil, fmt.Errorf("org %s has not been scanned yet", orgID)
+	}
+	report := *o.report
+	return &report, nil
+}
+
+// Start scans all orgs every night at hour (UTC) until ctx is cancelled
+func (s *Scanner) Start(ctx context.Context, hour int) {
+	for {
+		timer := time.NewTimer(time.Until(nextRun(time.Now(), hour)))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		s.mu.RLock()
+		orgIDs := make([]string, 0, len(s.orgs))
+		for id := range s.orgs {
+			orgIDs = append(orgIDs, id)
+		}
+		s.mu.RUnlock()
+
+		for _, orgID := range orgIDs {
+			if _, err := s.ScanOrg(ctx, orgID); err != nil {
+				logrus.WithError(err).WithField("org_id", orgID).Warn("⚠️ Compliance scan failed")
+			}
+		}
+	}
+}
+
+// nextRun returns the next time of day at hour UTC after now
+func nextRun(now time.Time, hour int) time.Time {
+	now = now.UTC()
+	next := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, time.UTC)
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// ScanOrg checks every service of an org, stores the report and notifies the
+// org about violations that weren't there on the previous scan
+func (s *Scanner) ScanOrg(ctx context.Context, orgID string) (*Report, error) {
+	s.mu.RLock()
+	o, ok := s.orgs[orgID]
+	var previous *Report
+	if ok {
+		previous = o.report
+	}
+	s.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("org not found: %s", orgID)
+	}
+
+	services, err := o.service.ListServices(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load services: %w", err)
+	}
+
+	firstSeen := make(map[string]time.Time)
+	if previous != nil {
+		for _, v := range previous.Violations {
+			firstSeen[v.ServiceID+"/"+v.Rule] = v.FirstSeen
+		}
+	}
+
+	now := time.Now()
+	report := &Report{
+		OrgID:      orgID,
+		Policy:     s.policy,
+		ScannedAt:  now,
+		Violations: make([]Violation, 0),
+	}
+	drifted := make([]Violation, 0)
+
+	for _, svc := range services {
+		settings, err := o.service.GetSecuritySettings(ctx, svc.ID)
+		if errors.Is(err, cdn.ErrNotSupported) {
+			report.UnsupportedServices = append(report.UnsupportedServices, svc.ID)
+			continue
+		}
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", svc.ID, err))
+			continue
+		}
+
+		report.ServicesScanned++
+		violations := s.policy.check(*settings)
+		if len(violations) == 0 {
+			report.CompliantServices++
+			continue
+		}
+
+		for _, v := range violations {
+			v.ServiceID = svc.ID
+			v.ServiceName = svc.Name
+			key := svc.ID + "/" + v.Rule
+			if seen, ok := firstSeen[key]; ok {
+				v.FirstSeen = seen
+			} else {
+				v.FirstSeen = now
+				drifted = append(drifted, v)
+			}
+			report.Violations = append(report.Violations, v)
+		}
+	}
+
+	s.mu.Lock()
+	o.report = report
+	s.mu.Unlock()
+
+	logrus.WithFields(logrus.Fields{
+		"org_id":     orgID,
+		"scanned":    report.ServicesScanned,
+		"violations": len(report.Violations),
+		"new":        len(drifted),
+	}).Info("🛡️ Compliance scan completed")
+
+	s.notify(orgID, o.notifyUserID, drifted)
+	return report, nil
+}
+
+// notify sends one notification per service that drifted out of compliance
+func (s *Scanner) notify(orgID, userID string, drifted []Violation) {
+	if s.publisher == nil {
+		return
+	}
+
+	byService := make(map[string][]Violation)
+	order := make([]string, 0)
+	for _, v := range drifted {
+		if _, ok := byService[v.ServiceID]; !ok {
+			order = append(order, v.ServiceID)
+		}
+		byService[v.ServiceID] = append(byService[v.ServiceID], v)
+	}
+
+	for _, serviceID := range order {
+		violations := byService[serviceID]
+		message := violations[0].Message
+		if len(violations) > 1 {
+			message = fmt.Sprintf("%s (and %d more)", message, len(violations)-1)
+		}
+
+		err := s.publisher.PublishNotification(messaging.NotificationEvent{
+			Type:      messaging.EventComplianceDrift,
+			OrgID:     orgID,
+			UserID:    userID,
+			ServiceID: serviceID,
+			Title:     "Compliance drift on " + violations[0].ServiceName,
+			Message:   message,
+			Level:     "warning",
+			Data: map[string]interface{}{
+				"violations": violations,
+			},
+		})
+		if err != nil {
+			logrus.WithError(err).WithField("service_id", serviceID).Error("❌ Failed to send compliance notification")
+		}
+	}
+}
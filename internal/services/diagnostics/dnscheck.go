@@ -0,0 +1,100 @@
+package diagnostics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DNS propagation states of a domain
+const (
+	DNSPropagated    = "propagated"    // the domain resolves to the CDN
+	DNSPending       = "pending"       // no records yet, or not visible to our resolver
+	DNSMisconfigured = "misconfigured" // the domain resolves somewhere else
+)
+
+// dnsCheckTimeout bounds the lookups of one DNS check
+const dnsCheckTimeout = 5 * time.Second
+
+// dnsResolver is the part of *net.Resolver DNS checks use
+type dnsResolver interface {
+	LookupCNAME(ctx context.Context, host string) (string, error)
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// DNSCheck is the result of checking a domain's DNS against its CNAME target
+type DNSCheck struct {
+	Domain    string    `json:"domain"`
+	Expected  string    `json:"expected_target"`
+	CNAME     string    `json:"cname,omitempty"` // canonical name the domain resolves to
+	Addresses []string  `json:"addresses,omitempty"`
+	Status    string    `json:"status"`
+	Message   string    `json:"message"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// CheckDNS resolves a domain and reports whether it points at expected.
+// Resolution follows the whole CNAME chain, so a domain that reaches the
+// target through a vanity hostname or ends at the same edge hostname counts,
+// as does an apex flattened to the target's addresses.
+func (t *Tester) CheckDNS(ctx context.Context, domainName, expected string) DNSCheck {
+	ctx, cancel := context.WithTimeout(ctx, dnsCheckTimeout)
+	defer cancel()
+	resolver := t.resolver
+
+	domainName, expected = canonicalHost(domainName), canonicalHost(expected)
+	check := DNSCheck{Domain: domainName, Expected: expected, CheckedAt: time.Now()}
+
+	cname, err := resolver.LookupCNAME(ctx, domainName)
+	if err != nil {
+		check.Status = DNSPending
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			check.Message = fmt.Sprintf("%s has no DNS records yet; add a CNAME record pointing at %s", domainName, expected)
+		} else {
+			check.Message = fmt.Sprintf("DNS lookup failed, try again shortly: %v", err)
+		}
+		return check
+	}
+	if cname = canonicalHost(cname); cname != domainName {
+		check.CNAME = cname
+	}
+	check.Addresses, _ = resolver.LookupHost(ctx, domainName)
+
+	if check.CNAME == expected {
+		check.Status, check.Message = DNSPropagated, fmt.Sprintf("%s points at %s", domainName, expected)
+		return check
+	}
+	if check.CNAME != "" {
+		if target, err := resolver.LookupCNAME(ctx, expected); err == nil && canonicalHost(target) == check.CNAME {
+			check.Status, check.Message = DNSPropagated, fmt.Sprintf("%s reaches %s through %s", domainName, expected, check.CNAME)
+			return check
+		}
+		check.Status, check.Message = DNSMisconfigured, fmt.Sprintf("%s points at %s instead of %s; update its CNAME record", domainName, check.CNAME, expected)
+		return check
+	}
+
+	// No CNAME: an apex record the DNS host flattens to the target's addresses
+	targetAddrs, _ := resolver.LookupHost(ctx, expected)
+	for _, addr := range check.Addresses {
+		if slices.Contains(targetAddrs, addr) {
+			check.Status, check.Message = DNSPropagated, fmt.Sprintf("%s resolves to the addresses of %s", domainName, expected)
+			return check
+		}
+	}
+	if len(check.Addresses) == 0 {
+		check.Status, check.Message = DNSPending, fmt.Sprintf("%s doesn't resolve yet; add a CNAME record pointing at %s", domainName, expected)
+		return check
+	}
+	check.Status, check.Message = DNSMisconfigured, fmt.Sprintf("%s has A records instead of a CNAME pointing at %s", domainName, expected)
+	return check
+}
+
+// canonicalHost lower-cases a hostname and drops the root dot
+func canonicalHost(host string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+}
@@ -0,0 +1,78 @@
+package diagnostics
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+)
+
+// fakeResolver answers from canned CNAMEs and addresses; a name with neither
+// doesn't exist
+type fakeResolver struct {
+	cnames map[string]string
+	hosts  map[string][]string
+}
+
+func (f fakeResolver) LookupCNAME(_ context.Context, host string) (string, error) {
+	if cname, ok := f.cnames[host]; ok {
+		return cname + ".", nil
+	}
+	if _, ok := f.hosts[host]; ok {
+		return host + ".", nil
+	}
+	return "", &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+}
+
+func (f fakeResolver) LookupHost(_ context.Context, host string) ([]string, error) {
+	if cname, ok := f.cnames[host]; ok {
+		host = cname
+	}
+	return f.hosts[host], nil
+}
+
+func TestCheckDNS(t *testing.T) {
+	const target = "shop-1a2b3c4d.cachefly.net"
+	resolver := fakeResolver{
+		cnames: map[string]string{
+			"cdn.example.com":    target,
+			"vanity.example.com": "edge.cachefly.net",
+			target:               "edge.cachefly.net",
+			"old.example.com":    "shop.other-cdn.net",
+		},
+		hosts: map[string][]string{
+			"edge.cachefly.net":  {"203.0.113.10"},
+			"shop.other-cdn.net": {"198.51.100.7"},
+			"example.com":        {"203.0.113.10"},
+			"www.example.com":    {"192.0.2.1"},
+		},
+	}
+	tester := &Tester{resolver: resolver}
+
+	tests := []struct {
+		name        string
+		domain      string
+		wantStatus  string
+		wantMessage string
+	}{
+		{name: "direct CNAME", domain: "cdn.example.com", wantStatus: DNSPropagated, wantMessage: "points at"},
+		{name: "mixed case", domain: "CDN.example.com.", wantStatus: DNSPropagated, wantMessage: "points at"},
+		{name: "through a chain", domain: "vanity.example.com", wantStatus: DNSPropagated, wantMessage: "through"},
+		{name: "flattened apex", domain: "example.com", wantStatus: DNSPropagated, wantMessage: "addresses of"},
+		{name: "other CDN", domain: "old.example.com", wantStatus: DNSMisconfigured, wantMessage: "instead of " + target},
+		{name: "A record", domain: "www.example.com", wantStatus: DNSMisconfigured, wantMessage: "A records"},
+		{name: "no records", domain: "new.example.com", wantStatus: DNSPending, wantMessage: "no DNS records yet"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := tester.CheckDNS(context.Background(), tt.domain, target)
+			if check.Status != tt.wantStatus || !strings.Contains(check.Message, tt.wantMessage) {
+				t.Errorf("CheckDNS() = %s %q, want %s containing %q", check.Status, check.Message, tt.wantStatus, tt.wantMessage)
+			}
+			if check.Expected != target {
+				t.Errorf("expected target = %q, want %q", check.Expected, target)
+			}
+		})
+	}
+}
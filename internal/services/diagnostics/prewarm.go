@@ -0,0 +1,175 @@
+package diagnostics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// MaxPrewarmURLs bounds the URLs one prewarm fetches
+	MaxPrewarmURLs = maxSitemapURLs
+
+	// prewarmConcurrency bounds concurrent fetches, so warming doesn't
+	// look like an attack to the CDN or load the origin all at once
+	prewarmConcurrency = 4
+)
+
+// PrewarmResult is the outcome of fetching one URL
+type PrewarmResult struct {
+	URL         string `json:"url"`
+	Status      int    `json:"status,omitempty"`
+	CacheStatus string `json:"cache_status,omitempty"`
+	Error       string `json:"error,omitempty"`
+	DurationMs  int64  `json:"duration_ms"`
+}
+
+// Warmed reports whether the URL was fetched successfully, so the edge
+// that served it now holds it if it's cacheable
+func (r PrewarmResult) Warmed() bool {
+	return r.Error == "" && r.Status >= 200 && r.Status < 400
+}
+
+// PrewarmReport is the outcome of a prewarm
+type PrewarmReport struct {
+	Total   int             `json:"total"`
+	Warmed  int             `json:"warmed"`
+	Failed  int             `json:"failed"`
+	Results []PrewarmResult `json:"results"`
+}
+
+// Summary describes the report in one line
+func (r *PrewarmReport) Summary() string {
+	return fmt.Sprintf("Warmed %d of %d URLs (%d failed)", r.Warmed, r.Total, r.Failed)
+}
+
+// PrewarmURLs resolves the URLs to warm: paths are resolved against base, the
+// service's URL through the CDN, and absolute http(s) URLs are kept if their
+// host is base's or one of domains, the service's own. Other hosts are
+// rejected, so a prewarm can't be used to fetch arbitrary sites.
+func PrewarmURLs(base string, domains []string, urls []string) ([]string, error) {
+	baseURL, err := url.Parse(base)
+	if err != nil || baseURL.Host == "" {
+		return nil, fmt.Errorf("invalid base URL %q", base)
+	}
+	if len(urls) > MaxPrewarmURLs {
+		return nil, fmt.Errorf("at most %d URLs can be warmed at once", MaxPrewarmURLs)
+	}
+	hosts := map[string]bool{strings.ToLower(baseURL.Hostname()): true}
+	for _, d := range domains {
+		hosts[strings.TrimSuffix(strings.ToLower(d), ".")] = true
+	}
+
+	resolved := make([]string, 0, len(urls))
+	seen := make(map[string]bool, len(urls))
+	for _, raw := range urls {
+		raw = strings.TrimSpace(raw)
+		u, err := url.Parse(raw)
+		if err != nil || raw == "" {
+			return nil, fmt.Errorf("invalid URL %q", raw)
+		}
+		switch {
+		case u.Scheme == "http" || u.Scheme == "https":
+			if u.Host == "" {
+				return nil, fmt.Errorf("invalid URL %q", raw)
+			}
+			if !hosts[strings.ToLower(u.Hostname())] {
+				return nil, fmt.Errorf("%q isn't served by this service; only its CDN hostname and domains can be warmed", raw)
+			}
+		case u.Scheme == "" && u.Host == "" && strings.HasPrefix(u.Path, "/"):
+			u = baseURL.ResolveReference(u)
+		default:
+			return nil, fmt.Errorf("%q must be an absolute http or https URL or a path starting with /", raw)
+		}
+		if !seen[u.String()] {
+			seen[u.String()] = true
+			resolved = append(resolved, u.String())
+		}
+	}
+	return resolved, nil
+}
+
+// Prewarm fetches every URL so the edge caches them, a few at a time.
+// progress, if set, is called after each fetch with the number done. A
+// cancelled ctx stops the prewarm; URLs not yet fetched are left out.
+func (t *Tester) Prewarm(ctx context.Context, urls []string, progress func(done, total int)) *PrewarmReport {
+	report := &PrewarmReport{Total: len(urls), Results: make([]PrewarmResult, len(urls))}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	done := 0
+	sem := make(chan struct{}, prewarmConcurrency)
+	for i, u := range urls {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			result := t.warm(ctx, u)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Results[i] = result
+			done++
+			if progress != nil {
+				progress(done, len(urls))
+			}
+		}()
+	}
+	wg.Wait()
+
+	fetched := report.Results[:0]
+	for _, result := range report.Results {
+		if result.URL == "" {
+			continue
+		}
+		if result.Warmed() {
+			report.Warmed++
+		} else {
+			report.Failed++
+		}
+		fetched = append(fetched, result)
+	}
+	report.Results = fetched
+	return report
+}
+
+func (t *Tester) warm(ctx context.Context, rawURL string) (result PrewarmResult) {
+	result.URL = rawURL
+	start := time.Now()
+	defer func() { result.DurationMs = time.Since(start).Milliseconds() }()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer resp.Body.Close()
+	// Edges only cache responses read to the end
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxBodyBytes))
+
+	result.Status = resp.StatusCode
+	for _, h := range cacheStatusHeaders {
+		if v := resp.Header.Get(h); v != "" {
+			result.CacheStatus = v
+			break
+		}
+	}
+	return result
+}
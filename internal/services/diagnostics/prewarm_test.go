@@ -0,0 +1,80 @@
+package diagnostics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestPrewarmURLs(t *testing.T) {
+	const base = "https://shop-1a2b.cachefly.net"
+
+	tests := []struct {
+		name    string
+		urls    []string
+		want    []string
+		wantErr string
+	}{
+		{
+			name: "paths resolve against the test URL",
+			urls: []string{"/", "/static/app.js?v=2", "https://cdn.example.com/logo.png"},
+			want: []string{base + "/", base + "/static/app.js?v=2", "https://cdn.example.com/logo.png"},
+		},
+		{
+			name: "hosts match without case or port",
+			urls: []string{"https://CDN.Example.com:443/a", "https://SHOP-1a2b.cachefly.net/b"},
+			want: []string{"https://CDN.Example.com:443/a", "https://SHOP-1a2b.cachefly.net/b"},
+		},
+		{name: "other host", urls: []string{"https://internal.example.org/admin"}, wantErr: "isn't served by this service"},
+		{name: "lookalike host", urls: []string{"https://cdn.example.com.evil.net/"}, wantErr: "isn't served by this service"},
+		{name: "metadata address", urls: []string{"http://169.254.169.254/latest/meta-data/"}, wantErr: "isn't served by this service"},
+		{name: "duplicates are fetched once", urls: []string{"/a", "/a", base + "/a"}, want: []string{base + "/a"}},
+		{name: "relative path", urls: []string{"static/app.js"}, wantErr: "path starting with /"},
+		{name: "other scheme", urls: []string{"ftp://example.com/file"}, wantErr: "absolute http or https URL"},
+		{name: "empty", urls: []string{" "}, wantErr: "invalid URL"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := PrewarmURLs(base, []string{"cdn.example.com"}, tt.urls)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("PrewarmURLs() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("PrewarmURLs() error = %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("PrewarmURLs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("prewarm", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/missing" {
+				http.NotFound(w, r)
+				return
+			}
+			w.Header().Set("X-Cache", "MISS")
+			w.Write([]byte("asset"))
+		}))
+		defer srv.Close()
+		tester := &Tester{client: srv.Client()}
+
+		urls, _ := PrewarmURLs(srv.URL, nil, []string{"/a.css", "/b.js", "/c.png", "/missing"})
+		var calls int
+		report := tester.Prewarm(context.Background(), urls, func(done, total int) { calls++ })
+		if report.Total != 4 || report.Warmed != 3 || report.Failed != 1 || calls != 4 {
+			t.Errorf("Prewarm() = %s with %d progress calls, want 3 of 4 warmed and 4 calls", report.Summary(), calls)
+		}
+		if report.Results[0].CacheStatus != "MISS" {
+			t.Errorf("cache status = %q, want MISS", report.Results[0].CacheStatus)
+		}
+	})
+}
@@ -0,0 +1,157 @@
+package diagnostics
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// certExpiryWarning is how close to expiry a served certificate gets flagged
+const certExpiryWarning = 14 * 24 * time.Hour
+
+// probeHeaders are the response headers a probe reports, beyond the cache
+// status headers
+var probeHeaders = []string{"Age", "Cache-Control", "Via", "Server", "Vary", "Expires"}
+
+// ProbeTiming breaks a probe request down, in milliseconds
+type ProbeTiming struct {
+	DNSMs     int64 `json:"dns_ms"`
+	ConnectMs int64 `json:"connect_ms"`
+	TLSMs     int64 `json:"tls_ms"`
+	TTFBMs    int64 `json:"ttfb_ms"` // from sending the request to the first response byte
+	TotalMs   int64 `json:"total_ms"`
+}
+
+// ProbeTLS describes the connection and certificate a probe was served with
+type ProbeTLS struct {
+	Version     string    `json:"version"`
+	CipherSuite string    `json:"cipher_suite"`
+	Subject     string    `json:"subject"`
+	Issuer      string    `json:"issuer"`
+	DNSNames    []string  `json:"dns_names"`
+	NotAfter    time.Time `json:"not_after"`
+}
+
+// ProbeResult is what one request through the CDN saw
+type ProbeResult struct {
+	URL         string            `json:"url"`
+	Working     bool              `json:"working"`
+	Status      int               `json:"status,omitempty"`
+	CacheStatus string            `json:"cache_status,omitempty"` // raw header value
+	Hit         bool              `json:"hit"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Timing      ProbeTiming       `json:"timing"`
+	TLS         *ProbeTLS         `json:"tls,omitempty"`
+	Error       string            `json:"error,omitempty"`
+	Findings    []string          `json:"findings"`
+}
+
+// Probe requests rawURL once and reports the status, cache verdict, timing
+// and TLS details; a failed request is reported in the result, not returned
+func (t *Tester) Probe(ctx context.Context, rawURL string) *ProbeResult {
+	result := &ProbeResult{URL: rawURL, Headers: make(map[string]string), Findings: make([]string, 0)}
+
+	var dnsStart, connectStart, tlsStart, wroteRequest time.Time
+	trace := &httptrace.ClientTrace{
+		DNSStart:          func(httptrace.DNSStartInfo) { dnsStart = time.Now() },
+		DNSDone:           func(httptrace.DNSDoneInfo) { result.Timing.DNSMs = time.Since(dnsStart).Milliseconds() },
+		ConnectStart:      func(string, string) { connectStart = time.Now() },
+		ConnectDone:       func(string, string, error) { result.Timing.ConnectMs = time.Since(connectStart).Milliseconds() },
+		TLSHandshakeStart: func() { tlsStart = time.Now() },
+		TLSHandshakeDone:  func(tls.ConnectionState, error) { result.Timing.TLSMs = time.Since(tlsStart).Milliseconds() },
+		WroteRequest:      func(httptrace.WroteRequestInfo) { wroteRequest = time.Now() },
+		GotFirstResponseByte: func() {
+			result.Timing.TTFBMs = time.Since(wroteRequest).Milliseconds()
+		},
+	}
+
+	start := time.Now()
+	req, err := http.NewRequestWithContext(httptrace.WithClientTrace(ctx, trace), http.MethodGet, rawURL, nil)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		result.Error = err.Error()
+		result.Timing.TotalMs = time.Since(start).Milliseconds()
+		result.Findings = append(result.Findings, "the request failed; check that DNS for the test URL resolves and the certificate is valid")
+		return result
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxBodyBytes))
+	resp.Body.Close()
+	result.Timing.TotalMs = time.Since(start).Milliseconds()
+
+	result.Status = resp.StatusCode
+	for _, h := range cacheStatusHeaders {
+		if v := resp.Header.Get(h); v != "" {
+			result.Headers[h] = v
+			if result.CacheStatus == "" {
+				result.CacheStatus, result.Hit = v, isHit(v)
+			}
+		}
+	}
+	for _, h := range probeHeaders {
+		if v := resp.Header.Get(h); v != "" {
+			result.Headers[h] = v
+		}
+	}
+	if loc := resp.Header.Get("Location"); loc != "" {
+		result.Headers["Location"] = loc
+	}
+	if age := resp.Header.Get("Age"); result.CacheStatus == "" && age != "" && age != "0" {
+		result.Hit = true
+	}
+	if resp.TLS != nil && len(resp.TLS.PeerCertificates) > 0 {
+		leaf := resp.TLS.PeerCertificates[0]
+		result.TLS = &ProbeTLS{
+			Version:     tls.VersionName(resp.TLS.Version),
+			CipherSuite: tls.CipherSuiteName(resp.TLS.CipherSuite),
+			Subject:     leaf.Subject.String(),
+			Issuer:      leaf.Issuer.String(),
+			DNSNames:    leaf.DNSNames,
+			NotAfter:    leaf.NotAfter,
+		}
+	}
+
+	result.Working = resp.StatusCode < http.StatusInternalServerError
+	result.Findings = probeFindings(result)
+
+	logrus.WithFields(logrus.Fields{
+		"url":     rawURL,
+		"status":  result.Status,
+		"hit":     result.Hit,
+		"ttfb_ms": result.Timing.TTFBMs,
+	}).Info("🩺 Probe completed")
+
+	return result
+}
+
+// probeFindings explains a successful request's result in plain words
+func probeFindings(r *ProbeResult) []string {
+	findings := make([]string, 0)
+	switch {
+	case r.Status >= http.StatusInternalServerError:
+		findings = append(findings, fmt.Sprintf("the CDN answered %d; it may not be able to reach your origin", r.Status))
+	case r.Status >= http.StatusBadRequest:
+		findings = append(findings, fmt.Sprintf("the CDN answered %d; check that the path exists on your origin", r.Status))
+	case r.Status >= http.StatusMultipleChoices:
+		findings = append(findings, fmt.Sprintf("the CDN redirected (%d) to %s", r.Status, r.Headers["Location"]))
+	}
+	switch {
+	case r.CacheStatus == "" && !r.Hit:
+		findings = append(findings, "no cache status header was returned, so whether the CDN cached the response can't be told")
+	case !r.Hit:
+		findings = append(findings, "the response was a cache miss; probe again to see whether it's cached now")
+	}
+	if r.TLS != nil && time.Until(r.TLS.NotAfter) < certExpiryWarning {
+		findings = append(findings, fmt.Sprintf("the certificate expires %s", r.TLS.NotAfter.Format("2006-01-02")))
+	}
+	return findings
+}
@@ -0,0 +1,76 @@
+package diagnostics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestProbe(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/hit":
+			w.Header().Set("X-Cache", "HIT")
+			w.Header().Set("Age", "120")
+		case "/miss":
+			w.Header().Set("X-Cache", "MISS")
+		case "/aged":
+			w.Header().Set("Age", "30")
+		case "/moved":
+			http.Redirect(w, r, "/hit", http.StatusMovedPermanently)
+			return
+		case "/down":
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	client := srv.Client()
+	client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	tester := &Tester{client: client}
+
+	tests := []struct {
+		name        string
+		path        string
+		wantStatus  int
+		wantWorking bool
+		wantHit     bool
+		wantFinding string
+	}{
+		{name: "cache hit", path: "/hit", wantStatus: http.StatusOK, wantWorking: true, wantHit: true},
+		{name: "cache miss", path: "/miss", wantStatus: http.StatusOK, wantWorking: true, wantFinding: "cache miss"},
+		{name: "hit from Age", path: "/aged", wantStatus: http.StatusOK, wantWorking: true, wantHit: true},
+		{name: "no cache headers", path: "/plain", wantStatus: http.StatusOK, wantWorking: true, wantFinding: "no cache status header"},
+		{name: "redirect", path: "/moved", wantStatus: http.StatusMovedPermanently, wantWorking: true, wantFinding: "redirected (301) to /hit"},
+		{name: "origin down", path: "/down", wantStatus: http.StatusBadGateway, wantFinding: "reach your origin"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := tester.Probe(context.Background(), srv.URL+tt.path)
+			if result.Error != "" {
+				t.Fatalf("Probe() error = %s", result.Error)
+			}
+			if result.Status != tt.wantStatus || result.Working != tt.wantWorking || result.Hit != tt.wantHit {
+				t.Errorf("Probe() = %d working=%v hit=%v, want %d working=%v hit=%v", result.Status, result.Working, result.Hit, tt.wantStatus, tt.wantWorking, tt.wantHit)
+			}
+			if result.TLS == nil || result.TLS.Version == "" {
+				t.Errorf("TLS = %+v, want connection details", result.TLS)
+			}
+			if tt.wantFinding != "" && !strings.Contains(strings.Join(result.Findings, "\n"), tt.wantFinding) {
+				t.Errorf("findings = %q, want one containing %q", result.Findings, tt.wantFinding)
+			}
+		})
+	}
+
+	t.Run("unreachable", func(t *testing.T) {
+		result := tester.Probe(context.Background(), "https://127.0.0.1:1/")
+		if result.Working || result.Error == "" {
+			t.Errorf("Probe() = working=%v error=%q, want a failed request", result.Working, result.Error)
+		}
+	})
+}
@@ -0,0 +1,93 @@
+package diagnostics
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const maxSitemapURLs = 1000
+
+// sitemap covers both <urlset> and <sitemapindex> documents
+type sitemap struct {
+	URLs     []sitemapLoc `xml:"url"`
+	Sitemaps []sitemapLoc `xml:"sitemap"`
+}
+
+type sitemapLoc struct {
+	Loc string `xml:"loc"`
+}
+
+// FetchSitemap returns up to limit page URLs from a sitemap, following one
+// level of sitemap index
+func (t *Tester) FetchSitemap(ctx context.Context, sitemapURL string, limit int) ([]string, error) {
+	if limit <= 0 || limit > maxSitemapURLs {
+		limit = maxSitemapURLs
+	}
+
+	doc, err := t.fetchSitemap(ctx, sitemapURL)
+	if err != nil {
+		return nil, err
+	}
+
+	urls := make([]string, 0)
+	for _, u := range doc.URLs {
+		if len(urls) == limit {
+			return urls, nil
+		}
+		urls = append(urls, strings.TrimSpace(u.Loc))
+	}
+
+	for _, child := range doc.Sitemaps {
+		if len(urls) == limit {
+			break
+		}
+		childDoc, err := t.fetchSitemap(ctx, strings.TrimSpace(child.Loc))
+		if err != nil {
+			return nil, err
+		}
+		for _, u := range childDoc.URLs {
+			if len(urls) == limit {
+				break
+			}
+			urls = append(urls, strings.TrimSpace(u.Loc))
+		}
+	}
+
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("sitemap %s has no URLs", sitemapURL)
+	}
+	return urls, nil
+}
+
+func (t *Tester) fetchSitemap(ctx context.Context, rawURL string) (*sitemap, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("sitemap_url must be an absolute http or https URL")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sitemap request: %w", err)
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch sitemap: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch sitemap: status %d", resp.StatusCode)
+	}
+
+	var doc sitemap
+	if err := xml.NewDecoder(io.LimitReader(resp.Body, maxBodyBytes)).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse sitemap: %w", err)
+	}
+	return &doc, nil
+}
@@ -0,0 +1,513 @@
+package diagnostics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/avvvet/cdnbuddy-api/internal/lru"
+	"github.com/avvvet/cdnbuddy-api/internal/models"
+	"github.com/avvvet/cdnbuddy-api/internal/services/cdn"
+)
+
+const (
+	maxTTLSamples    = 25
+	maxDiscoverBytes = 1 << 20 // HTML read from the origin's home page to find assets
+	maxSuggestions   = 10000
+	suggestionTTL    = time.Hour
+)
+
+// Edge TTLs recommended when the origin doesn't say how long to cache
+const (
+	ttlRevalidate = 60
+	ttlPage       = 300
+	ttlDay        = 86400
+	ttlWeek       = 7 * ttlDay
+	ttlMonth      = 30 * ttlDay
+	ttlYear       = 365 * ttlDay
+)
+
+// contentClasses groups file extensions; anything else is a page
+var contentClasses = map[string]string{
+	".css": "assets", ".js": "assets", ".mjs": "assets",
+	".png": "images", ".jpg": "images", ".jpeg": "images", ".gif": "images", ".webp": "images",
+	".avif": "images", ".svg": "images", ".ico": "images",
+	".woff": "fonts", ".woff2": "fonts", ".ttf": "fonts", ".otf": "fonts", ".eot": "fonts",
+	".mp4": "media", ".webm": "media", ".mp3": "media", ".m4s": "media", ".ts": "media",
+	".m3u8": "manifests", ".mpd": "manifests",
+	".json": "data", ".xml": "data",
+}
+
+// classDefaults are used for URLs without any caching headers
+var classDefaults = map[string]int{
+	"pages":     ttlPage,
+	"assets":    ttlWeek,
+	"images":    ttlMonth,
+	"fonts":     ttlYear,
+	"media":     ttlDay,
+	"manifests": 2,
+	"data":      ttlRevalidate,
+}
+
+var (
+	// fingerprinted file names like app.3f9a1c2b.js never change content
+	fingerprintPattern = regexp.MustCompile(`[.-][0-9a-fA-F]{8,}\.[a-z0-9]+$`)
+	assetLinkPattern   = regexp.MustCompile(`(?i)(?:src|href)=["']([^"'#]+)["']`)
+)
+
+// OriginResponse is what the origin says about caching one URL, and the TTL
+// recommended for it
+type OriginResponse struct {
+	URL            string `json:"url"`
+	Status         int    `json:"status,omitempty"`
+	Class          string `json:"class"`
+	CacheControl   string `json:"cache_control,omitempty"`
+	Expires        string `json:"expires,omitempty"`
+	ETag           string `json:"etag,omitempty"`
+	LastModified   string `json:"last_modified,omitempty"`
+	SetCookie      bool   `json:"set_cookie,omitempty"`
+	OriginTTL      int    `json:"origin_ttl"` // from s-maxage, max-age or Expires; -1 = not set
+	RecommendedTTL int    `json:"recommended_ttl"`
+	Reason         string `json:"reason,omitempty"`
+	Error          string `json:"error,omitempty"`
+}
+
+// TTLRecommendation is one proposed cache rule and why
+type TTLRecommendation struct {
+	Rule   cdn.CacheRule `json:"rule"`
+	Reason string        `json:"reason"`
+	URLs   int           `json:"urls"` // sample URLs the rule is based on
+}
+
+// TTLReport is the outcome of inspecting a service's origin
+type TTLReport struct {
+	ServiceID       string                `json:"service_id"`
+	Origin          string                `json:"origin"`
+	Samples         []OriginResponse      `json:"samples"`
+	Recommendations []TTLRecommendation   `json:"recommendations"`
+	Rules           []cdn.CacheRule       `json:"rules"` // the recommendations, ready for PUT cache-rules
+	Findings        []string              `json:"findings"`
+	Simulation      *cdn.SimulationResult `json:"simulation,omitempty"` // recommended vs current rules
+}
+
+// TTLSuggestion is the last recommendation offered in a chat session
+type TTLSuggestion struct {
+	ServiceID  string          `json:"service_id"`
+	Rules      []cdn.CacheRule `json:"rules"`
+	SampleURLs []string        `json:"sample_urls"`
+}
+
+// TTLAdvisor recommends cache rules from the caching headers of a service's
+// origin. It remembers the last recommendation of each chat session so the
+// user can apply it without spelling the rules out.
+type TTLAdvisor struct {
+	tester      *Tester
+	suggestions *lru.Cache[string, TTLSuggestion]
+}
+
+// NewTTLAdvisor creates an advisor fetching through tester
+func NewTTLAdvisor(tester *Tester) *TTLAdvisor {
+	return &TTLAdvisor{
+		tester:      tester,
+		suggestions: lru.New[string, TTLSuggestion]("ttl_suggestions", maxSuggestions, suggestionTTL),
+	}
+}
+
+// Recommend samples paths from the origin of a service (its home page and the
+// assets it links to when none are given) and recommends cache rules
+func (a *TTLAdvisor) Recommend(ctx context.Context, svc *cdn.Service, serviceID string, paths []string) (*TTLReport, error) {
+	export, err := svc.ExportService(ctx, serviceID)
+	if err != nil {
+		return nil, err
+	}
+	origin := export.Spec.Origin
+	if origin.Host == "" {
+		return nil, fmt.Errorf("origin of service %s is unknown", serviceID)
+	}
+
+	scheme := origin.Protocol
+	if scheme != "http" {
+		scheme = "https"
+	}
+	host := origin.Host
+	if origin.Port != 0 && !(scheme == "https" && origin.Port == 443) && !(scheme == "http" && origin.Port == 80) {
+		host = fmt.Sprintf("%s:%d", origin.Host, origin.Port)
+	}
+
+	report, err := a.tester.RecommendTTLs(ctx, scheme+"://"+host, paths, export.Spec.Rules)
+	if err != nil {
+		return nil, err
+	}
+	report.ServiceID = export.ServiceID
+	return report, nil
+}
+
+// Remember keeps a report as the suggestion of a chat session
+func (a *TTLAdvisor) Remember(sessionID string, report *TTLReport) {
+	suggestion := TTLSuggestion{ServiceID: report.ServiceID, Rules: report.Rules, SampleURLs: make([]string, 0)}
+	for _, sample := range report.Samples {
+		if sample.Error == "" {
+			suggestion.SampleURLs = append(suggestion.SampleURLs, sample.URL)
+		}
+	}
+	a.suggestions.Put(sessionID, suggestion)
+}
+
+// Suggest offers the session's last recommendation as inferred parameters of
+// an UPDATE_CACHE_RULES intent
+func (a *TTLAdvisor) Suggest(sessionID string, intentContext *models.IntentContext) {
+	suggestion, ok := a.suggestions.Get(sessionID)
+	if !ok || intentContext == nil || len(suggestion.Rules) == 0 {
+		return
+	}
+	rules, err := json.Marshal(suggestion.Rules)
+	if err != nil {
+		return
+	}
+	if intentContext.InferredParameters == nil {
+		intentContext.InferredParameters = make(map[string]string)
+	}
+	intentContext.InferredParameters["service_id"] = suggestion.ServiceID
+	intentContext.InferredParameters["rules"] = string(rules)
+	intentContext.InferredParameters["sample_urls"] = strings.Join(suggestion.SampleURLs, ",")
+}
+
+// RecommendTTLs requests sample paths from an origin and recommends cache
+// rules: one for pages and one per static file extension, each with the
+// shortest TTL any of its samples allows
+func (t *Tester) RecommendTTLs(ctx context.Context, origin string, paths []string, current []cdn.CacheRule) (*TTLReport, error) {
+	base, err := url.Parse(origin)
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") || base.Host == "" {
+		return nil, fmt.Errorf("origin must be an absolute http or https URL")
+	}
+	if len(paths) == 0 {
+		paths = t.discoverPaths(ctx, base)
+	}
+
+	report := &TTLReport{
+		Origin:          base.String(),
+		Samples:         make([]OriginResponse, 0, len(paths)),
+		Recommendations: make([]TTLRecommendation, 0),
+		Rules:           make([]cdn.CacheRule, 0),
+		Findings:        make([]string, 0),
+	}
+
+	seen := make(map[string]bool)
+	for _, p := range paths {
+		ref, err := url.Parse(strings.TrimSpace(p))
+		if err != nil {
+			continue
+		}
+		u := base.ResolveReference(ref)
+		u.Fragment = ""
+		if u.Host != base.Host || seen[u.String()] {
+			continue
+		}
+		seen[u.String()] = true
+		report.Samples = append(report.Samples, t.inspectOrigin(ctx, u.String()))
+		if len(report.Samples) == maxTTLSamples {
+			break
+		}
+	}
+	if len(report.Samples) == 0 {
+		return nil, fmt.Errorf("no sample URL on the origin %s", base.Host)
+	}
+
+	report.Recommendations = recommendRules(report.Samples)
+	for _, rec := range report.Recommendations {
+		report.Rules = append(report.Rules, rec.Rule)
+	}
+	report.Findings = ttlFindings(report.Samples)
+
+	samples := make([]cdn.SampleURL, 0, len(report.Samples))
+	for _, sample := range report.Samples {
+		samples = append(samples, cdn.SampleURL{URL: sample.URL})
+	}
+	if len(report.Rules) > 0 {
+		if simulation, err := cdn.SimulateRules(current, report.Rules, samples); err == nil {
+			report.Simulation = simulation
+		}
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"origin":  base.Host,
+		"samples": len(report.Samples),
+		"rules":   len(report.Rules),
+	}).Info("⏱️ TTL recommendation completed")
+
+	return report, nil
+}
+
+// discoverPaths samples the home page and the same-origin assets it links to
+func (t *Tester) discoverPaths(ctx context.Context, base *url.URL) []string {
+	paths := []string{"/"}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base.ResolveReference(&url.URL{Path: "/"}).String(), nil)
+	if err != nil {
+		return paths
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return paths
+	}
+	defer resp.Body.Close()
+	if !strings.Contains(resp.Header.Get("Content-Type"), "html") {
+		return paths
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxDiscoverBytes))
+	for _, match := range assetLinkPattern.FindAllSubmatch(body, -1) {
+		paths = append(paths, string(match[1]))
+	}
+	return paths
+}
+
+func (t *Tester) inspectOrigin(ctx context.Context, rawURL string) OriginResponse {
+	result := OriginResponse{URL: rawURL, Class: classOf(rawURL), OriginTTL: -1}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxBodyBytes))
+
+	result.Status = resp.StatusCode
+	result.CacheControl = resp.Header.Get("Cache-Control")
+	result.Expires = resp.Header.Get("Expires")
+	result.ETag = resp.Header.Get("ETag")
+	result.LastModified = resp.Header.Get("Last-Modified")
+	result.SetCookie = resp.Header.Get("Set-Cookie") != ""
+
+	now := time.Now()
+	if date, err := http.ParseTime(resp.Header.Get("Date")); err == nil {
+		now = date
+	}
+	recommendTTL(&result, parseCacheControl(result.CacheControl), now)
+	return result
+}
+
+// recommendTTL decides the edge TTL of one response: origin directives first,
+// then fingerprinted names, then defaults for its class
+func recommendTTL(r *OriginResponse, cc map[string]string, now time.Time) {
+	_, noStore := cc["no-store"]
+	_, private := cc["private"]
+	_, noCache := cc["no-cache"]
+	_, immutable := cc["immutable"]
+	sMaxAge, hasSMaxAge := directiveSeconds(cc, "s-maxage")
+	maxAge, hasMaxAge := directiveSeconds(cc, "max-age")
+	validators := r.ETag != "" || r.LastModified != ""
+
+	switch {
+	case hasSMaxAge:
+		r.OriginTTL = sMaxAge
+	case hasMaxAge:
+		r.OriginTTL = maxAge
+	case r.Expires != "":
+		if expires, err := http.ParseTime(r.Expires); err == nil {
+			r.OriginTTL = max(0, int(expires.Sub(now).Seconds()))
+		} else {
+			r.OriginTTL = 0 // invalid Expires means already expired
+		}
+	}
+
+	switch {
+	case noStore || private:
+		r.RecommendedTTL, r.Reason = 0, "origin marks it no-store or private"
+	case r.OriginTTL > 0:
+		r.RecommendedTTL, r.Reason = r.OriginTTL, "origin cache lifetime"
+	case immutable || fingerprintPattern.MatchString(urlFileName(r.URL)):
+		r.RecommendedTTL, r.Reason = ttlYear, "fingerprinted file that never changes"
+	case noCache || r.OriginTTL == 0:
+		if validators {
+			r.RecommendedTTL, r.Reason = ttlRevalidate, "origin asks for revalidation and sends validators, so a short TTL revalidates cheaply"
+		} else {
+			r.RecommendedTTL, r.Reason = 0, "origin asks for revalidation but sends no ETag or Last-Modified"
+		}
+	default:
+		r.RecommendedTTL, r.Reason = classDefaults[r.Class], "no caching headers; default for "+r.Class
+	}
+}
+
+// recommendRules turns per-URL TTLs into rules: "/" for pages and "*.ext" per
+// static extension, taking the shortest TTL of the samples a rule covers.
+// Uncacheable pages get a rule for their own path.
+func recommendRules(samples []OriginResponse) []TTLRecommendation {
+	byPattern := make(map[string]*TTLRecommendation)
+	for _, s := range samples {
+		if s.Error != "" || s.Status < 200 || s.Status >= 300 {
+			continue
+		}
+		pattern := "/"
+		if s.Class != "pages" {
+			pattern = "*" + path.Ext(urlFileName(s.URL))
+		} else if u, err := url.Parse(s.URL); err == nil && s.RecommendedTTL == 0 && u.Path != "" {
+			// An uncacheable page gets its own rule instead of disabling caching for every page
+			pattern = u.Path
+		}
+
+		rec, ok := byPattern[pattern]
+		if !ok || s.RecommendedTTL < rec.Rule.TTL {
+			urls := 0
+			if ok {
+				urls = rec.URLs
+			}
+			rec = &TTLRecommendation{
+				Rule:   cdn.CacheRule{Path: pattern, TTL: s.RecommendedTTL, BrowserTTL: browserTTL(s)},
+				Reason: s.Reason,
+				URLs:   urls,
+			}
+			byPattern[pattern] = rec
+		}
+		rec.URLs++
+	}
+
+	recommendations := make([]TTLRecommendation, 0, len(byPattern))
+	for _, rec := range byPattern {
+		recommendations = append(recommendations, *rec)
+	}
+	sort.Slice(recommendations, func(i, j int) bool {
+		return recommendations[i].Rule.Path < recommendations[j].Rule.Path
+	})
+	return recommendations
+}
+
+// browserTTL keeps browsers on the origin's lifetime when it sets one; pages
+// without one are always revalidated so deploys show up at once
+func browserTTL(s OriginResponse) int {
+	if s.OriginTTL >= 0 {
+		return min(s.OriginTTL, s.RecommendedTTL)
+	}
+	if s.Class == "pages" {
+		return 0
+	}
+	return s.RecommendedTTL
+}
+
+func ttlFindings(samples []OriginResponse) []string {
+	findings := make([]string, 0)
+	var failed, noHeaders, noValidators, cookies []string
+	for _, s := range samples {
+		switch {
+		case s.Error != "" || s.Status >= 400:
+			failed = append(failed, s.URL)
+			continue
+		case s.CacheControl == "" && s.Expires == "":
+			noHeaders = append(noHeaders, s.URL)
+		}
+		if s.ETag == "" && s.LastModified == "" {
+			noValidators = append(noValidators, s.URL)
+		}
+		if s.SetCookie && s.RecommendedTTL > 0 {
+			cookies = append(cookies, s.URL)
+		}
+	}
+
+	if len(failed) > 0 {
+		findings = append(findings, fmt.Sprintf("%d sample URL(s) failed and were left out: %s", len(failed), strings.Join(failed, ", ")))
+	}
+	if len(noHeaders) > 0 {
+		findings = append(findings, fmt.Sprintf("%d URL(s) send no Cache-Control or Expires; set them at the origin so browsers and the CDN agree", len(noHeaders)))
+	}
+	if len(noValidators) > 0 {
+		findings = append(findings, fmt.Sprintf("%d URL(s) send no ETag or Last-Modified, so every revalidation downloads the full body", len(noValidators)))
+	}
+	if len(cookies) > 0 {
+		findings = append(findings, fmt.Sprintf("%d cacheable URL(s) set cookies; check they don't carry per-user state: %s", len(cookies), strings.Join(cookies, ", ")))
+	}
+	return findings
+}
+
+// parseCacheControl returns directives by lower-case name, values unquoted
+func parseCacheControl(header string) map[string]string {
+	directives := make(map[string]string)
+	for _, part := range strings.Split(header, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			directives[name] = strings.Trim(strings.TrimSpace(value), `"`)
+		}
+	}
+	return directives
+}
+
+func directiveSeconds(cc map[string]string, name string) (int, bool) {
+	value, ok := cc[name]
+	if !ok {
+		return 0, false
+	}
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return seconds, true
+}
+
+func classOf(rawURL string) string {
+	if class, ok := contentClasses[strings.ToLower(path.Ext(urlFileName(rawURL)))]; ok {
+		return class
+	}
+	return "pages"
+}
+
+func urlFileName(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return path.Base(u.Path)
+}
+
+// Summary describes the report for chat
+func (r *TTLReport) Summary() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "⏱️ Cache rule recommendations for %s (%d URLs sampled)\n", r.Origin, len(r.Samples))
+	if len(r.Recommendations) == 0 {
+		b.WriteString("\nNo sample URL answered successfully, so there's nothing to recommend yet.")
+		return b.String()
+	}
+
+	b.WriteString("\n")
+	for _, rec := range r.Recommendations {
+		fmt.Fprintf(&b, "   • %s: edge %s, browser %s (%s)\n",
+			rec.Rule.Path, formatTTL(rec.Rule.TTL), formatTTL(rec.Rule.BrowserTTL), rec.Reason)
+	}
+	if sim := r.Simulation; sim != nil {
+		fmt.Fprintf(&b, "\n📈 Estimated hit ratio: %.0f%% → %.0f%%\n", 100*sim.CurrentHitRatio, 100*sim.ProposedHitRatio)
+	}
+	for _, finding := range r.Findings {
+		fmt.Fprintf(&b, "   ℹ️ %s\n", finding)
+	}
+	b.WriteString("\n💡 Say \"apply the recommended cache rules\" to use them.")
+	return b.String()
+}
+
+func formatTTL(seconds int) string {
+	switch {
+	case seconds == 0:
+		return "no cache"
+	case seconds%ttlDay == 0:
+		return fmt.Sprintf("%dd", seconds/ttlDay)
+	case seconds%3600 == 0:
+		return fmt.Sprintf("%dh", seconds/3600)
+	case seconds%60 == 0:
+		return fmt.Sprintf("%dm", seconds/60)
+	}
+	return fmt.Sprintf("%ds", seconds)
+}
@@ -0,0 +1,326 @@
+// Package diagnostics runs live checks against URLs served through the CDN to
+// help users debug caching behavior.
+package diagnostics
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	maxVariants     = 10
+	maxRepeat       = 3
+	maxBodyBytes    = 5 << 20 // bodies are hashed, not stored
+	varyTestTimeout = 10 * time.Second
+)
+
+// cacheStatusHeaders are checked in order to find the CDN's hit/miss verdict
+var cacheStatusHeaders = []string{"X-Cache", "CF-Cache-Status", "X-Cache-Status", "X-Proxy-Cache", "Cache-Status"}
+
+// Variant is one way of requesting the URL, e.g. "logged in" with a session cookie
+type Variant struct {
+	Name    string            `json:"name"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Cookies map[string]string `json:"cookies,omitempty"`
+}
+
+// VaryTestRequest asks for each variant to be requested Repeat times (default 2,
+// so the second request shows whether the first one was cached)
+type VaryTestRequest struct {
+	URL      string    `json:"url"`
+	Variants []Variant `json:"variants"`
+	Repeat   int       `json:"repeat,omitempty"`
+}
+
+// Attempt is one request of a variant
+type Attempt struct {
+	Status      int    `json:"status"`
+	CacheStatus string `json:"cache_status,omitempty"` // raw header value
+	Hit         bool   `json:"hit"`
+	Age         string `json:"age,omitempty"`
+	BodyHash    string `json:"body_hash"`
+	DurationMs  int64  `json:"duration_ms"`
+	Error       string `json:"error,omitempty"`
+}
+
+// VariantResult is what one variant saw and the cache key derived for it
+type VariantResult struct {
+	Name     string    `json:"name"`
+	CacheKey string    `json:"cache_key"`
+	Vary     string    `json:"vary,omitempty"`
+	Attempts []Attempt `json:"attempts"`
+}
+
+// VaryTestReport is the outcome of a vary test
+type VaryTestReport struct {
+	URL      string          `json:"url"`
+	Results  []VariantResult `json:"results"`
+	Findings []string        `json:"findings"`
+}
+
+// Tester runs vary tests. Requests to private or loopback addresses are
+// refused so the endpoint can't be used to probe internal networks.
+type Tester struct {
+	client   *http.Client
+	resolver dnsResolver
+}
+
+// NewTester creates a tester that only connects to public addresses
+func NewTester() *Tester {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+				return fmt.Errorf("refusing to connect to non-public address %s", host)
+			}
+			return nil
+		},
+	}
+
+	return &Tester{
+		client: &http.Client{
+			Timeout:   varyTestTimeout,
+			Transport: &http.Transport{DialContext: dialer.DialContext, Proxy: nil},
+			// Redirects would be followed with different cache keys; report them instead
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		resolver: net.DefaultResolver,
+	}
+}
+
+// Validate checks the request and fills defaults
+func (r *VaryTestRequest) Validate() error {
+	u, err := url.Parse(r.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("url must be an absolute http or https URL")
+	}
+	if len(r.Variants) < 2 {
+		return fmt.Errorf("at least two variants are required")
+	}
+	if len(r.Variants) > maxVariants {
+		return fmt.Errorf("at most %d variants are allowed", maxVariants)
+	}
+	if r.Repeat == 0 {
+		r.Repeat = 2
+	}
+	if r.Repeat < 1 || r.Repeat > maxRepeat {
+		return fmt.Errorf("repeat must be between 1 and %d", maxRepeat)
+	}
+	for i := range r.Variants {
+		if r.Variants[i].Name == "" {
+			r.Variants[i].Name = fmt.Sprintf("variant-%d", i+1)
+		}
+	}
+	return nil
+}
+
+// Run requests every variant in turn and analyzes the responses
+func (t *Tester) Run(ctx context.Context, req VaryTestRequest) (*VaryTestReport, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	report := &VaryTestReport{
+		URL:      req.URL,
+		Results:  make([]VariantResult, 0, len(req.Variants)),
+		Findings: make([]string, 0),
+	}
+
+	for _, variant := range req.Variants {
+		result := VariantResult{Name: variant.Name, Attempts: make([]Attempt, 0, req.Repeat)}
+
+		for i := 0; i < req.Repeat; i++ {
+			attempt, vary := t.fetch(ctx, req.URL, variant)
+			result.Attempts = append(result.Attempts, attempt)
+			if vary != "" {
+				result.Vary = vary
+			}
+		}
+		result.CacheKey = deriveCacheKey(req.URL, result.Vary, variant)
+
+		report.Results = append(report.Results, result)
+	}
+
+	report.Findings = analyze(req.Variants, report.Results)
+
+	logrus.WithFields(logrus.Fields{
+		"url":      req.URL,
+		"variants": len(req.Variants),
+		"findings": len(report.Findings),
+	}).Info("🔍 Vary test completed")
+
+	return report, nil
+}
+
+func (t *Tester) fetch(ctx context.Context, rawURL string, variant Variant) (Attempt, string) {
+	start := time.Now()
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return Attempt{Error: err.Error()}, ""
+	}
+	for k, v := range variant.Headers {
+		httpReq.Header.Set(k, v)
+	}
+	for name, value := range variant.Cookies {
+		httpReq.AddCookie(&http.Cookie{Name: name, Value: value})
+	}
+
+	resp, err := t.client.Do(httpReq)
+	if err != nil {
+		return Attempt{Error: err.Error(), DurationMs: time.Since(start).Milliseconds()}, ""
+	}
+	defer resp.Body.Close()
+
+	hash := sha256.New()
+	io.Copy(hash, io.LimitReader(resp.Body, maxBodyBytes))
+
+	attempt := Attempt{
+		Status:     resp.StatusCode,
+		Age:        resp.Header.Get("Age"),
+		BodyHash:   hex.EncodeToString(hash.Sum(nil))[:16],
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	for _, h := range cacheStatusHeaders {
+		if v := resp.Header.Get(h); v != "" {
+			attempt.CacheStatus = v
+			attempt.Hit = isHit(v)
+			break
+		}
+	}
+	// No status header: a positive Age means the response came from a cache
+	if attempt.CacheStatus == "" && attempt.Age != "" && attempt.Age != "0" {
+		attempt.Hit = true
+	}
+
+	return attempt, resp.Header.Get("Vary")
+}
+
+// deriveCacheKey shows the key a standards-following cache would use: the URL
+// plus the request values of every header named in Vary
+func deriveCacheKey(rawURL, vary string, variant Variant) string {
+	parts := []string{rawURL}
+
+	for _, name := range splitHeaderList(vary) {
+		canonical := http.CanonicalHeaderKey(name)
+		if canonical == "Cookie" {
+			parts = append(parts, "Cookie="+cookieString(variant.Cookies))
+			continue
+		}
+		value := ""
+		for k, v := range variant.Headers {
+			if http.CanonicalHeaderKey(k) == canonical {
+				value = v
+			}
+		}
+		parts = append(parts, canonical+"="+value)
+	}
+
+	return strings.Join(parts, " | ")
+}
+
+// analyze flags variants that differ in request but share a cached response,
+// which is how personalized content leaks between users
+func analyze(variants []Variant, results []VariantResult) []string {
+	findings := make([]string, 0)
+
+	for i := 0; i < len(results); i++ {
+		for j := i + 1; j < len(results); j++ {
+			a, b := results[i], results[j]
+			if a.CacheKey != b.CacheKey {
+				continue
+			}
+			la, lb := lastAttempt(a), lastAttempt(b)
+			if la == nil || lb == nil || la.Error != "" || lb.Error != "" {
+				continue
+			}
+
+			if requestsDiffer(variants[i], variants[j]) && (la.Hit || lb.Hit) && la.BodyHash == lb.BodyHash {
+				findings = append(findings, fmt.Sprintf(
+					"%q and %q send different headers/cookies but map to the same cache key and got the same cached body; if the origin personalizes on them, add them to Vary or the cache key",
+					a.Name, b.Name))
+			}
+		}
+	}
+
+	for _, r := range results {
+		hits := 0
+		for _, a := range r.Attempts {
+			if a.Hit {
+				hits++
+			}
+		}
+		if len(r.Attempts) > 1 && hits == 0 {
+			findings = append(findings, fmt.Sprintf("%q was never served from cache; check Cache-Control on the origin and whether its headers/cookies bypass the cache", r.Name))
+		}
+	}
+
+	return findings
+}
+
+func lastAttempt(r VariantResult) *Attempt {
+	if len(r.Attempts) == 0 {
+		return nil
+	}
+	return &r.Attempts[len(r.Attempts)-1]
+}
+
+func requestsDiffer(a, b Variant) bool {
+	return cookieString(a.Cookies) != cookieString(b.Cookies) || headerString(a.Headers) != headerString(b.Headers)
+}
+
+func isHit(status string) bool {
+	status = strings.ToUpper(status)
+	return strings.Contains(status, "HIT") || strings.Contains(status, "STALE")
+}
+
+func splitHeaderList(v string) []string {
+	names := make([]string, 0)
+	for _, name := range strings.Split(v, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func cookieString(cookies map[string]string) string {
+	parts := make([]string, 0, len(cookies))
+	for k, v := range cookies {
+		parts = append(parts, k+"="+v)
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, "; ")
+}
+
+func headerString(headers map[string]string) string {
+	parts := make([]string, 0, len(headers))
+	for k, v := range headers {
+		parts = append(parts, http.CanonicalHeaderKey(k)+"="+v)
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, "\n")
+}
+
+func isPublicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsUnspecified() || ip.IsMulticast())
+}
@@ -0,0 +1,183 @@
+package dns
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const cloudflareBaseURL = "https://api.cloudflare.com/client/v4"
+
+// CloudflareAdapter manages records through the Cloudflare API. Zones are
+// looked up by name in the token's account; records outside any zone the
+// token can see go to the default zone.
+type CloudflareAdapter struct {
+	token         string
+	defaultZoneID string
+	baseURL       string
+	client        *http.Client
+	zoneIDs       map[string]string // zone name -> ID
+	mu            sync.Mutex
+}
+
+// NewCloudflareAdapter creates a Cloudflare DNS adapter
+func NewCloudflareAdapter(token, defaultZoneID string) (*CloudflareAdapter, error) {
+	if token == "" {
+		return nil, fmt.Errorf("cloudflare token is required")
+	}
+	return &CloudflareAdapter{
+		token:         token,
+		defaultZoneID: defaultZoneID,
+		baseURL:       cloudflareBaseURL,
+		client:        &http.Client{Timeout: 30 * time.Second},
+		zoneIDs:       make(map[string]string),
+	}, nil
+}
+
+// Name returns the adapter name
+func (a *CloudflareAdapter) Name() string {
+	return "cloudflare"
+}
+
+type cloudflareRecord struct {
+	ID      string `json:"id,omitempty"`
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Content string `json:"content"`
+	TTL     int    `json:"ttl"`
+	Proxied bool   `json:"proxied"`
+}
+
+// UpsertCNAME creates the record or points the existing one at target
+func (a *CloudflareAdapter) UpsertCNAME(ctx context.Context, zone, name, target string, ttl int) error {
+	zoneID, err := a.zoneID(ctx, zone)
+	if err != nil {
+		return err
+	}
+	record := cloudflareRecord{Type: "CNAME", Name: name, Content: target, TTL: ttl}
+
+	existing, err := a.findRecord(ctx, zoneID, name)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return a.do(ctx, http.MethodPost, "/zones/"+zoneID+"/dns_records", record, nil)
+	}
+	if existing.Type == "CNAME" && existing.Content == target {
+		return nil
+	}
+	return a.do(ctx, http.MethodPut, "/zones/"+zoneID+"/dns_records/"+existing.ID, record, nil)
+}
+
+// DeleteRecord removes a record; a missing record is not an error
+func (a *CloudflareAdapter) DeleteRecord(ctx context.Context, zone, name string) error {
+	zoneID, err := a.zoneID(ctx, zone)
+	if err != nil {
+		return err
+	}
+	existing, err := a.findRecord(ctx, zoneID, name)
+	if err != nil || existing == nil {
+		return err
+	}
+	return a.do(ctx, http.MethodDelete, "/zones/"+zoneID+"/dns_records/"+existing.ID, nil, nil)
+}
+
+// zoneID finds the zone holding records of zone: the zone itself or its
+// closest parent in the account, otherwise the default zone
+func (a *CloudflareAdapter) zoneID(ctx context.Context, zone string) (string, error) {
+	a.mu.Lock()
+	id, ok := a.zoneIDs[zone]
+	a.mu.Unlock()
+	if ok {
+		return id, nil
+	}
+
+	for name := zone; strings.Contains(name, "."); name = name[strings.Index(name, ".")+1:] {
+		var zones []struct {
+			ID string `json:"id"`
+		}
+		if err := a.do(ctx, http.MethodGet, "/zones?name="+url.QueryEscape(name), nil, &zones); err != nil {
+			return "", err
+		}
+		if len(zones) > 0 {
+			id = zones[0].ID
+			break
+		}
+	}
+	if id == "" {
+		if a.defaultZoneID == "" {
+			return "", fmt.Errorf("no cloudflare zone found for %s", zone)
+		}
+		id = a.defaultZoneID
+	}
+
+	a.mu.Lock()
+	a.zoneIDs[zone] = id
+	a.mu.Unlock()
+	return id, nil
+}
+
+func (a *CloudflareAdapter) findRecord(ctx context.Context, zoneID, name string) (*cloudflareRecord, error) {
+	var records []cloudflareRecord
+	if err := a.do(ctx, http.MethodGet, "/zones/"+zoneID+"/dns_records?name="+url.QueryEscape(name), nil, &records); err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	return &records[0], nil
+}
+
+// do sends a request and decodes the "result" of Cloudflare's response envelope into out
+func (a *CloudflareAdapter) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var payload io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		payload = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, a.baseURL+path, payload)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+a.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call cloudflare API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var envelope struct {
+		Success bool                       `json:"success"`
+		Errors  []struct{ Message string } `json:"errors"`
+		Result  json.RawMessage            `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return fmt.Errorf("failed to decode cloudflare response (%d): %w", resp.StatusCode, err)
+	}
+	if !envelope.Success || resp.StatusCode >= 300 {
+		messages := make([]string, 0, len(envelope.Errors))
+		for _, e := range envelope.Errors {
+			messages = append(messages, e.Message)
+		}
+		return fmt.Errorf("cloudflare API %s %s returned %d: %s", method, path, resp.StatusCode, strings.Join(messages, "; "))
+	}
+	if out != nil && len(envelope.Result) > 0 {
+		if err := json.Unmarshal(envelope.Result, out); err != nil {
+			return fmt.Errorf("failed to decode cloudflare result: %w", err)
+		}
+	}
+	return nil
+}
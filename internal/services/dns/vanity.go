@@ -0,0 +1,302 @@
+// Package dns manages records in DNS zones orgs delegate to CDNBuddy, and
+// the vanity CNAME chains that hide raw provider hostnames (x.cachefly.net)
+// behind an org's own zone (cdn.customer-brand.com).
+package dns
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Adapter manages records through a DNS provider's API
+type Adapter interface {
+	Name() string
+	UpsertCNAME(ctx context.Context, zone, name, target string, ttl int) error
+	DeleteRecord(ctx context.Context, zone, name string) error
+}
+
+const (
+	vanityTTL         = 300
+	certCheckTimeout  = 10 * time.Second
+	maxHostnameLabel  = 63
+	certExpiryWarning = 14 * 24 * time.Hour
+)
+
+// Chain is a vanity hostname in an org's zone pointing at a provider hostname.
+// Customer domains CNAME to Hostname instead of the provider's.
+type Chain struct {
+	ServiceID   string            `json:"service_id"`
+	Hostname    string            `json:"hostname"`
+	Target      string            `json:"target"`
+	Managed     bool              `json:"managed"` // record created through the DNS adapter; otherwise the org creates it
+	Certificate *CertificateCheck `json:"certificate,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+}
+
+// CertificateCheck tells whether the certificate the provider serves for a
+// vanity hostname covers it
+type CertificateCheck struct {
+	Covered   bool      `json:"covered"`
+	Names     []string  `json:"names,omitempty"`
+	Issuer    string    `json:"issuer,omitempty"`
+	NotAfter  time.Time `json:"not_after,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// Vanity keeps each org's vanity zone and the chains created in it
+type Vanity struct {
+	adapter Adapter                      // nil leaves records to the org
+	zones   map[string]string            // org ID -> zone
+	chains  map[string]map[string]*Chain // org ID -> service ID -> chain
+	check   func(ctx context.Context, hostname, target string) CertificateCheck
+	mu      sync.RWMutex
+}
+
+// NewVanity creates the vanity chain manager. Without an adapter the chains
+// are still shown in DNS instructions but the org creates the records.
+func NewVanity(adapter Adapter) *Vanity {
+	return &Vanity{
+		adapter: adapter,
+		zones:   make(map[string]string),
+		chains:  make(map[string]map[string]*Chain),
+		check:   CheckCertificate,
+	}
+}
+
+// Managed reports whether records are created through a DNS adapter
+func (v *Vanity) Managed() bool {
+	return v.adapter != nil
+}
+
+// SetZone sets the zone an org's vanity hostnames are created in and returns
+// it normalized. Moving to another zone requires removing the current one first.
+func (v *Vanity) SetZone(orgID, zone string) (string, error) {
+	zone = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(zone)), ".")
+	if zone == "" || strings.ContainsAny(zone, "/:@ *") || !strings.Contains(zone, ".") || net.ParseIP(zone) != nil {
+		return "", fmt.Errorf("zone must be a host name like cdn.example.com")
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if current, ok := v.zones[orgID]; ok && current != zone {
+		return "", fmt.Errorf("org already uses vanity zone %s; remove it first", current)
+	}
+	v.zones[orgID] = zone
+	return zone, nil
+}
+
+// Zone returns an org's vanity zone
+func (v *Vanity) Zone(orgID string) (string, bool) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	zone, ok := v.zones[orgID]
+	return zone, ok
+}
+
+// RemoveZone stops using an org's vanity zone and deletes the managed records.
+// Domains still pointing at the vanity hostnames must be moved first.
+func (v *Vanity) RemoveZone(ctx context.Context, orgID string) error {
+	v.mu.Lock()
+	zone, ok := v.zones[orgID]
+	chains := v.chains[orgID]
+	delete(v.zones, orgID)
+	delete(v.chains, orgID)
+	v.mu.Unlock()
+	if !ok {
+		return nil
+	}
+
+	for _, chain := range chains {
+		if !chain.Managed {
+			continue
+		}
+		if err := v.adapter.DeleteRecord(ctx, zone, chain.Hostname); err != nil {
+			return fmt.Errorf("failed to delete %s: %w", chain.Hostname, err)
+		}
+	}
+	return nil
+}
+
+// Chain returns the vanity chain for a service, creating its record if needed.
+// It returns nil when the org has no vanity zone.
+func (v *Vanity) Chain(ctx context.Context, orgID, serviceID, target string) (*Chain, error) {
+	zone, ok := v.Zone(orgID)
+	if !ok {
+		return nil, nil
+	}
+
+	v.mu.RLock()
+	var existing *Chain
+	if current := v.chains[orgID][serviceID]; current != nil {
+		chain := *current
+		existing = &chain
+	}
+	v.mu.RUnlock()
+	if existing != nil && existing.Target == target {
+		return existing, nil
+	}
+
+	chain := &Chain{
+		ServiceID: serviceID,
+		Hostname:  hostnameLabel(serviceID) + "." + zone,
+		Target:    target,
+		Managed:   v.adapter != nil,
+		CreatedAt: time.Now(),
+	}
+	if v.adapter != nil {
+		if err := v.adapter.UpsertCNAME(ctx, zone, chain.Hostname, target, vanityTTL); err != nil {
+			return nil, fmt.Errorf("failed to create vanity record %s: %w", chain.Hostname, err)
+		}
+	}
+
+	v.mu.Lock()
+	if v.chains[orgID] == nil {
+		v.chains[orgID] = make(map[string]*Chain)
+	}
+	v.chains[orgID][serviceID] = chain
+	result := *chain
+	v.mu.Unlock()
+
+	logrus.WithFields(logrus.Fields{
+		"org_id":   orgID,
+		"hostname": chain.Hostname,
+		"target":   target,
+		"managed":  chain.Managed,
+	}).Info("🏷️ Vanity CNAME chain created")
+
+	// Providers issue certificates asynchronously, so coverage is checked in the background
+	go v.checkChain(orgID, serviceID)
+
+	return &result, nil
+}
+
+// Chains returns an org's chains, sorted by hostname
+func (v *Vanity) Chains(orgID string) []Chain {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	chains := make([]Chain, 0, len(v.chains[orgID]))
+	for _, chain := range v.chains[orgID] {
+		chains = append(chains, *chain)
+	}
+	sort.Slice(chains, func(i, j int) bool { return chains[i].Hostname < chains[j].Hostname })
+	return chains
+}
+
+// CheckCertificates re-checks certificate coverage of every chain of an org
+func (v *Vanity) CheckCertificates(orgID string) []Chain {
+	for _, chain := range v.Chains(orgID) {
+		v.checkChain(orgID, chain.ServiceID)
+	}
+	return v.Chains(orgID)
+}
+
+// Resolver returns the CNAME target to show in an org's DNS instructions: the
+// vanity hostname when the org has a zone, otherwise the provider's
+func (v *Vanity) Resolver(orgID string) func(ctx context.Context, serviceID, target string) string {
+	return func(ctx context.Context, serviceID, target string) string {
+		chain, err := v.Chain(ctx, orgID, serviceID, target)
+		if err != nil {
+			logrus.WithError(err).WithField("service_id", serviceID).Warn("⚠️ Vanity CNAME unavailable, showing provider hostname")
+			return target
+		}
+		if chain == nil {
+			return target
+		}
+		return chain.Hostname
+	}
+}
+
+func (v *Vanity) checkChain(orgID, serviceID string) {
+	v.mu.RLock()
+	chain := v.chains[orgID][serviceID]
+	v.mu.RUnlock()
+	if chain == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), certCheckTimeout)
+	defer cancel()
+	check := v.check(ctx, chain.Hostname, chain.Target)
+
+	if !check.Covered {
+		logrus.WithFields(logrus.Fields{
+			"org_id":   orgID,
+			"hostname": chain.Hostname,
+			"error":    check.Error,
+		}).Warn("🔒 Provider certificate does not cover vanity hostname")
+	}
+
+	v.mu.Lock()
+	if current := v.chains[orgID][serviceID]; current == chain {
+		current.Certificate = &check
+	}
+	v.mu.Unlock()
+}
+
+// CheckCertificate connects to the provider hostname asking for the vanity
+// hostname and reports whether the certificate served covers it. Connecting
+// to the provider directly keeps the check independent of DNS propagation.
+func CheckCertificate(ctx context.Context, hostname, target string) CertificateCheck {
+	check := CertificateCheck{CheckedAt: time.Now()}
+
+	dialer := &tls.Dialer{Config: &tls.Config{
+		ServerName:         hostname,
+		InsecureSkipVerify: true, // coverage is verified below; the chain itself isn't the question
+	}}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(target, "443"))
+	if err != nil {
+		check.Error = fmt.Sprintf("failed to connect to %s: %v", target, err)
+		return check
+	}
+	defer conn.Close()
+
+	certs := conn.(*tls.Conn).ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		check.Error = "no certificate presented"
+		return check
+	}
+	leaf := certs[0]
+	check.Names = leaf.DNSNames
+	check.Issuer = leaf.Issuer.CommonName
+	check.NotAfter = leaf.NotAfter
+
+	switch {
+	case leaf.VerifyHostname(hostname) != nil:
+		check.Error = fmt.Sprintf("certificate does not cover %s", hostname)
+	case time.Now().After(leaf.NotAfter):
+		check.Error = "certificate has expired"
+	default:
+		check.Covered = true
+		if time.Until(leaf.NotAfter) < certExpiryWarning {
+			check.Error = fmt.Sprintf("certificate expires %s", leaf.NotAfter.Format("2006-01-02"))
+		}
+	}
+	return check
+}
+
+// hostnameLabel turns a service ID into a DNS label
+func hostnameLabel(serviceID string) string {
+	label := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + ('a' - 'A')
+		}
+		return '-'
+	}, serviceID)
+	if len(label) > maxHostnameLabel {
+		label = label[:maxHostnameLabel]
+	}
+	return strings.Trim(label, "-")
+}
@@ -0,0 +1,182 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+// recordingAdapter is an Adapter keeping records in memory
+type recordingAdapter struct {
+	mu      sync.Mutex
+	records map[string]string // name -> target
+	upserts int
+	err     error
+}
+
+func (a *recordingAdapter) Name() string { return "memory" }
+
+func (a *recordingAdapter) UpsertCNAME(_ context.Context, _, name, target string, _ int) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.err != nil {
+		return a.err
+	}
+	a.records[name] = target
+	a.upserts++
+	return nil
+}
+
+func (a *recordingAdapter) DeleteRecord(_ context.Context, _, name string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.records, name)
+	return nil
+}
+
+func (a *recordingAdapter) fail(err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.err = err
+}
+
+// newTestVanity returns a vanity manager whose certificate checks report
+// coverage without connecting anywhere
+func newTestVanity(adapter Adapter) *Vanity {
+	v := NewVanity(adapter)
+	v.check = func(_ context.Context, hostname, _ string) CertificateCheck {
+		return CertificateCheck{Covered: true, Names: []string{hostname}}
+	}
+	return v
+}
+
+func TestSetZone(t *testing.T) {
+	tests := []struct {
+		name    string
+		current string // zone already set for the org
+		zone    string
+		want    string
+		wantErr bool
+	}{
+		{name: "normalizes", zone: " CDN.Example.com. ", want: "cdn.example.com"},
+		{name: "same zone again", current: "cdn.example.com", zone: "cdn.example.com", want: "cdn.example.com"},
+		{name: "moving zones", current: "cdn.example.com", zone: "cdn.other.com", wantErr: true},
+		{name: "empty", zone: " ", wantErr: true},
+		{name: "single label", zone: "localhost", wantErr: true},
+		{name: "IP address", zone: "192.0.2.1", wantErr: true},
+		{name: "URL", zone: "https://cdn.example.com", wantErr: true},
+		{name: "wildcard", zone: "*.example.com", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := newTestVanity(nil)
+			if tt.current != "" {
+				if _, err := v.SetZone("org-1", tt.current); err != nil {
+					t.Fatalf("SetZone(%q) error = %v", tt.current, err)
+				}
+			}
+
+			got, err := v.SetZone("org-1", tt.zone)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SetZone(%q) error = %v, wantErr %v", tt.zone, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("SetZone(%q) = %q, want %q", tt.zone, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHostnameLabel(t *testing.T) {
+	tests := []struct {
+		serviceID string
+		want      string
+	}{
+		{serviceID: "5f1a2b3c", want: "5f1a2b3c"},
+		{serviceID: "Svc_42.prod", want: "svc-42-prod"},
+		{serviceID: "-edge-", want: "edge"},
+		{serviceID: "a123456789012345678901234567890123456789012345678901234567890123456789", want: "a12345678901234567890123456789012345678901234567890123456789012"},
+	}
+
+	for _, tt := range tests {
+		if got := hostnameLabel(tt.serviceID); got != tt.want {
+			t.Errorf("hostnameLabel(%q) = %q, want %q", tt.serviceID, got, tt.want)
+		}
+	}
+}
+
+func TestChain(t *testing.T) {
+	ctx := context.Background()
+	adapter := &recordingAdapter{records: make(map[string]string)}
+	v := newTestVanity(adapter)
+
+	if chain, err := v.Chain(ctx, "org-1", "svc-1", "x.cachefly.net"); chain != nil || err != nil {
+		t.Fatalf("Chain() without a zone = %+v, %v, want nil", chain, err)
+	}
+	if _, err := v.SetZone("org-1", "cdn.brand.com"); err != nil {
+		t.Fatalf("SetZone() error = %v", err)
+	}
+
+	chain, err := v.Chain(ctx, "org-1", "svc-1", "x.cachefly.net")
+	if err != nil {
+		t.Fatalf("Chain() error = %v", err)
+	}
+	if chain.Hostname != "svc-1.cdn.brand.com" || !chain.Managed || adapter.records[chain.Hostname] != "x.cachefly.net" {
+		t.Fatalf("chain = %+v, records = %v", chain, adapter.records)
+	}
+
+	// an unchanged target reuses the record; a new one updates it
+	v.Chain(ctx, "org-1", "svc-1", "x.cachefly.net")
+	if adapter.upserts != 1 {
+		t.Errorf("upserts after asking again = %d, want 1", adapter.upserts)
+	}
+	v.Chain(ctx, "org-1", "svc-1", "y.cachefly.net")
+	if adapter.upserts != 2 || adapter.records["svc-1.cdn.brand.com"] != "y.cachefly.net" {
+		t.Errorf("records after a target change = %v", adapter.records)
+	}
+
+	chains := v.CheckCertificates("org-1")
+	if len(chains) != 1 || chains[0].Certificate == nil || !chains[0].Certificate.Covered {
+		t.Errorf("chains after certificate check = %+v", chains)
+	}
+
+	if err := v.RemoveZone(ctx, "org-1"); err != nil {
+		t.Fatalf("RemoveZone() error = %v", err)
+	}
+	if len(adapter.records) != 0 || len(v.Chains("org-1")) != 0 {
+		t.Errorf("after RemoveZone records = %v, chains = %v", adapter.records, v.Chains("org-1"))
+	}
+	if _, ok := v.Zone("org-1"); ok {
+		t.Error("zone kept after RemoveZone")
+	}
+}
+
+func TestResolver(t *testing.T) {
+	ctx := context.Background()
+	adapter := &recordingAdapter{records: make(map[string]string)}
+	v := newTestVanity(adapter)
+	v.SetZone("org-1", "cdn.brand.com")
+
+	tests := []struct {
+		name      string
+		orgID     string
+		serviceID string
+		err       error
+		want      string
+	}{
+		{name: "org with a zone", orgID: "org-1", serviceID: "svc-1", want: "svc-1.cdn.brand.com"},
+		{name: "org without a zone", orgID: "org-2", serviceID: "svc-1", want: "x.cachefly.net"},
+		{name: "DNS provider failing", orgID: "org-1", serviceID: "svc-2", err: errors.New("api down"), want: "x.cachefly.net"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adapter.fail(tt.err)
+			if got := v.Resolver(tt.orgID)(ctx, tt.serviceID, "x.cachefly.net"); got != tt.want {
+				t.Errorf("Resolver() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
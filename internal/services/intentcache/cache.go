@@ -0,0 +1,106 @@
+package intentcache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/avvvet/cdnbuddy-api/internal/lru"
+	"github.com/avvvet/cdnbuddy-api/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+// readOnlyActions are intents that only read account state and are safe to answer from cache
+var readOnlyActions = map[string]bool{
+	"LIST_SERVICES":  true,
+	"ACCOUNT_STATUS": true,
+}
+
+// Cache stores intent service responses for read-only intents, so identical
+// questions asked within a short window don't trigger another LLM call
+type Cache struct {
+	entries *lru.Cache[string, entry]
+}
+
+// entry is a cached response with the account state it was given in
+type entry struct {
+	response  models.IntentResponse
+	stateHash string
+}
+
+// StateHashFunc fingerprints the account state; it is only called when a
+// response is cached or a cached one is about to be served
+type StateHashFunc func() (string, error)
+
+// NewCache creates a new intent response cache holding at most maxEntries responses
+func NewCache(ttl time.Duration, maxEntries int) *Cache {
+	c := &Cache{
+		entries: lru.New[string, entry]("intent_cache", maxEntries, ttl),
+	}
+
+	// Start cleanup goroutine for expired entries
+	go c.cleanupExpired()
+
+	return c
+}
+
+// Key builds a cache key from the account the message is about (e.g. an org
+// and sandbox) and the user message, so accounts never share answers
+func Key(scope, message string) string {
+	sum := sha256.Sum256([]byte(scope + "|" + normalize(message)))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsCacheable reports whether an intent response may be served from cache
+func IsCacheable(resp *models.IntentResponse) bool {
+	return resp != nil && resp.Status == "READY" && resp.Action != nil && readOnlyActions[*resp.Action]
+}
+
+// Get returns a cached response for the key, rebound to the given session.
+// A response given before the account state changed is dropped.
+func (c *Cache) Get(key, sessionID string, stateHash StateHashFunc) (*models.IntentResponse, bool) {
+	e, exists := c.entries.Get(key)
+	if !exists {
+		return nil, false
+	}
+	if hash, err := stateHash(); err != nil || hash != e.stateHash {
+		c.entries.Delete(key)
+		return nil, false
+	}
+
+	resp := e.response
+	resp.SessionID = sessionID
+	return &resp, true
+}
+
+// Put stores a response if it is cacheable, with the current account state
+func (c *Cache) Put(key string, resp *models.IntentResponse, stateHash StateHashFunc) {
+	if !IsCacheable(resp) {
+		return
+	}
+	hash, err := stateHash()
+	if err != nil {
+		return
+	}
+
+	c.entries.Put(key, entry{response: *resp, stateHash: hash})
+}
+
+// normalize lowercases, collapses whitespace and strips trailing punctuation
+func normalize(message string) string {
+	message = strings.ToLower(strings.Join(strings.Fields(message), " "))
+	return strings.TrimRight(message, "?!. ")
+}
+
+// cleanupExpired removes expired entries periodically
+func (c *Cache) cleanupExpired() {
+	ticker := time.NewTicker(1 * time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if count := c.entries.PruneExpired(); count > 0 {
+			logrus.WithField("count", count).Debug("🧹 Cleaned up expired intent cache entries")
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package intentcache_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/avvvet/cdnbuddy-api/internal/models"
+	"github.com/avvvet/cdnbuddy-api/internal/services/intentcache"
+)
+
+func TestCache(t *testing.T) {
+	listServices, setup := "LIST_SERVICES", "SETUP_CDN"
+	ready := func(action *string) *models.IntentResponse {
+		return &models.IntentResponse{SessionID: "session-1", Action: action, Status: "READY"}
+	}
+
+	tests := []struct {
+		name       string
+		put        *models.IntentResponse
+		putScope   string
+		getScope   string
+		getMessage string
+		changed    bool // account state changed between Put and Get
+		wantHit    bool
+		wantHashes int
+	}{
+		{name: "same question", put: ready(&listServices), putScope: "org-1/", getScope: "org-1/", getMessage: "List my services?", wantHit: true, wantHashes: 2},
+		{name: "other org", put: ready(&listServices), putScope: "org-1/", getScope: "org-2/", getMessage: "list my services", wantHashes: 1},
+		{name: "other sandbox", put: ready(&listServices), putScope: "org-1/", getScope: "org-1/sbx-1", getMessage: "list my services", wantHashes: 1},
+		{name: "account changed", put: ready(&listServices), putScope: "org-1/", getScope: "org-1/", getMessage: "list my services", changed: true, wantHashes: 2},
+		{name: "not cacheable isn't hashed", put: ready(&setup), putScope: "org-1/", getScope: "org-1/", getMessage: "list my services"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := intentcache.NewCache(time.Minute, 10)
+			hashes, state := 0, "state-1"
+			stateHash := func() (string, error) {
+				hashes++
+				return state, nil
+			}
+
+			cache.Put(intentcache.Key(tt.putScope, "list my services"), tt.put, stateHash)
+			if tt.changed {
+				state = "state-2"
+			}
+			got, hit := cache.Get(intentcache.Key(tt.getScope, tt.getMessage), "session-2", stateHash)
+
+			if hit != tt.wantHit {
+				t.Fatalf("Get() hit = %v, want %v", hit, tt.wantHit)
+			}
+			if hit && got.SessionID != "session-2" {
+				t.Errorf("Get() session = %q, want it rebound to session-2", got.SessionID)
+			}
+			if hashes != tt.wantHashes {
+				t.Errorf("state hashed %d times, want %d", hashes, tt.wantHashes)
+			}
+		})
+	}
+}
@@ -0,0 +1,209 @@
+// Package intentstats tracks how intents reach READY: how often the intent
+// service has to ask for more information, which parameters it asks for and
+// how many clarifications are abandoned. It feeds prompt and default tuning.
+package intentstats
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/avvvet/cdnbuddy-api/internal/models"
+)
+
+// UnknownAction is reported for responses that don't name an action yet
+const UnknownAction = "(unknown)"
+
+// ActionStats are the clarification figures of one action
+type ActionStats struct {
+	Action         string  `json:"action"`
+	Responses      int64   `json:"responses"`  // intent responses naming the action
+	NeedsInfo      int64   `json:"needs_info"` // of which asked for more information
+	NeedsInfoRate  float64 `json:"needs_info_rate"`
+	Clarifications int64   `json:"clarifications"` // conversations that needed at least one question
+	Resolved       int64   `json:"resolved"`       // ...and then reached READY
+	Abandoned      int64   `json:"abandoned"`      // ...and didn't within the abandon timeout
+	AbandonRate    float64 `json:"abandon_rate"`   // abandoned / (resolved + abandoned)
+	AvgQuestions   float64 `json:"avg_questions"`  // questions asked per resolved clarification
+	MissingParams  int64   `json:"missing_params"`
+	totalQuestions int64
+}
+
+// MissingParam counts how often a parameter was missing for an action
+type MissingParam struct {
+	Action string `json:"action"`
+	Param  string `json:"param"`
+	Count  int64  `json:"count"`
+}
+
+// Report is a snapshot of the collected figures
+type Report struct {
+	Since         time.Time      `json:"since"`
+	Pending       int64          `json:"pending"` // clarifications still waiting for an answer
+	Actions       []ActionStats  `json:"actions"`
+	MissingParams []MissingParam `json:"missing_params"` // most frequent first
+}
+
+type pending struct {
+	action    string
+	questions int64
+	lastAsked time.Time
+}
+
+type paramKey struct {
+	action string
+	param  string
+}
+
+// Tracker collects clarification figures in memory
+type Tracker struct {
+	abandonAfter time.Duration
+	since        time.Time
+	actions      map[string]*ActionStats
+	missing      map[paramKey]int64
+	pending      map[string]*pending // by session ID
+	mu           sync.Mutex
+}
+
+// NewTracker creates a tracker that counts a clarification as abandoned when
+// the user hasn't answered within abandonAfter
+func NewTracker(abandonAfter time.Duration) *Tracker {
+	return &Tracker{
+		abandonAfter: abandonAfter,
+		since:        time.Now(),
+		actions:      make(map[string]*ActionStats),
+		missing:      make(map[paramKey]int64),
+		pending:      make(map[string]*pending),
+	}
+}
+
+// Record accounts one intent service response of a session
+func (t *Tracker) Record(sessionID string, resp *models.IntentResponse) {
+	if resp == nil {
+		return
+	}
+	now := time.Now()
+	action := UnknownAction
+	if resp.Action != nil && *resp.Action != "" {
+		action = *resp.Action
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.expireLocked(now)
+
+	stats := t.statsLocked(action)
+	stats.Responses++
+
+	switch resp.Status {
+	case "NEEDS_INFO":
+		stats.NeedsInfo++
+		for param, value := range resp.Parameters {
+			if value == nil || *value == "" {
+				t.missing[paramKey{action: action, param: param}]++
+				stats.MissingParams++
+			}
+		}
+
+		p, ok := t.pending[sessionID]
+		if !ok {
+			p = &pending{action: action}
+			t.pending[sessionID] = p
+			stats.Clarifications++
+		} else if p.action == UnknownAction && action != UnknownAction {
+			// The action became known during the clarification; move it over
+			from := t.statsLocked(UnknownAction)
+			from.Clarifications--
+			stats.Clarifications++
+			p.action = action
+		}
+		p.questions++
+		p.lastAsked = now
+
+	case "READY", "ERROR":
+		p, ok := t.pending[sessionID]
+		if !ok {
+			return
+		}
+		delete(t.pending, sessionID)
+		if resp.Status == "ERROR" {
+			t.statsLocked(p.action).Abandoned++
+			return
+		}
+		if p.action != action {
+			t.statsLocked(p.action).Clarifications--
+			stats.Clarifications++
+		}
+		stats.Resolved++
+		stats.totalQuestions += p.questions
+	}
+}
+
+func (t *Tracker) statsLocked(action string) *ActionStats {
+	stats, ok := t.actions[action]
+	if !ok {
+		stats = &ActionStats{Action: action}
+		t.actions[action] = stats
+	}
+	return stats
+}
+
+// expireLocked counts clarifications without an answer in time as abandoned
+func (t *Tracker) expireLocked(now time.Time) {
+	for sessionID, p := range t.pending {
+		if now.Sub(p.lastAsked) > t.abandonAfter {
+			t.statsLocked(p.action).Abandoned++
+			delete(t.pending, sessionID)
+		}
+	}
+}
+
+// Report returns the figures collected since the tracker was created
+func (t *Tracker) Report() Report {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.expireLocked(time.Now())
+
+	report := Report{
+		Since:         t.since,
+		Pending:       int64(len(t.pending)),
+		Actions:       make([]ActionStats, 0, len(t.actions)),
+		MissingParams: make([]MissingParam, 0, len(t.missing)),
+	}
+
+	for _, stats := range t.actions {
+		s := *stats
+		if s.Responses > 0 {
+			s.NeedsInfoRate = float64(s.NeedsInfo) / float64(s.Responses)
+		}
+		if finished := s.Resolved + s.Abandoned; finished > 0 {
+			s.AbandonRate = float64(s.Abandoned) / float64(finished)
+		}
+		if s.Resolved > 0 {
+			s.AvgQuestions = float64(s.totalQuestions) / float64(s.Resolved)
+		}
+		report.Actions = append(report.Actions, s)
+	}
+	sort.Slice(report.Actions, func(i, j int) bool {
+		if report.Actions[i].NeedsInfo != report.Actions[j].NeedsInfo {
+			return report.Actions[i].NeedsInfo > report.Actions[j].NeedsInfo
+		}
+		return report.Actions[i].Action < report.Actions[j].Action
+	})
+
+	for key, count := range t.missing {
+		report.MissingParams = append(report.MissingParams, MissingParam{Action: key.action, Param: key.param, Count: count})
+	}
+	sort.Slice(report.MissingParams, func(i, j int) bool {
+		a, b := report.MissingParams[i], report.MissingParams[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		if a.Action != b.Action {
+			return a.Action < b.Action
+		}
+		return a.Param < b.Param
+	})
+
+	return report
+}
@@ -0,0 +1,213 @@
+// Package leader elects one replica to run singleton background jobs
+// (review reminders, compliance scans, log ingestion) through a lease held in
+// a shared store, with failover when the holder stops renewing it.
+package leader
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/avvvet/cdnbuddy-api/internal/metrics"
+)
+
+// Lease is a named lock with an expiry, held by at most one replica
+type Lease interface {
+	// TryAcquire takes the lease for holder or renews it if holder already
+	// has it; it returns false when another replica holds it
+	TryAcquire(ctx context.Context, holder string, ttl time.Duration) (bool, error)
+	// Release gives the lease up early if holder has it
+	Release(ctx context.Context, holder string) error
+	Close() error
+}
+
+// DefaultReplicaID identifies this process: hostname plus a random suffix so
+// restarted pods don't inherit a lease they no longer renew
+func DefaultReplicaID() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "replica"
+	}
+	return host + "-" + uuid.NewString()[:8]
+}
+
+// Elector keeps trying to hold the lease and runs singleton jobs while it does
+type Elector struct {
+	lease  Lease
+	id     string
+	ttl    time.Duration
+	leader atomic.Bool
+
+	mu      sync.Mutex
+	changed chan struct{} // closed and replaced on every leadership change
+}
+
+// NewElector creates an elector for replica id; the lease expires ttl after
+// the last renewal, which is also the longest failover takes
+func NewElector(lease Lease, id string, ttl time.Duration) *Elector {
+	return &Elector{
+		lease:   lease,
+		id:      id,
+		ttl:     ttl,
+		changed: make(chan struct{}),
+	}
+}
+
+// ID returns this replica's ID
+func (e *Elector) ID() string {
+	return e.id
+}
+
+// IsLeader reports whether this replica currently holds the lease
+func (e *Elector) IsLeader() bool {
+	return e.leader.Load()
+}
+
+// Run acquires and renews the lease every ttl/3 until ctx is cancelled, then
+// releases it so another replica takes over without waiting for expiry
+func (e *Elector) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.ttl / 3)
+	defer ticker.Stop()
+
+	for {
+		e.tick(ctx)
+
+		select {
+		case <-ctx.Done():
+			if e.IsLeader() {
+				e.setLeader(false)
+				releaseCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				if err := e.lease.Release(releaseCtx, e.id); err != nil {
+					logrus.WithError(err).Warn("⚠️ Failed to release leader lease")
+				}
+				cancel()
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (e *Elector) tick(ctx context.Context) {
+	attemptCtx, cancel := context.WithTimeout(ctx, e.ttl/3)
+	defer cancel()
+
+	held, err := e.lease.TryAcquire(attemptCtx, e.id, e.ttl)
+	if err != nil {
+		if ctx.Err() != nil {
+			return
+		}
+		// Step down: without a renewal we can't know another replica hasn't
+		// taken over once the lease expires
+		metrics.Inc("leader_lease_errors")
+		logrus.WithError(err).Warn("⚠️ Failed to renew leader lease")
+		held = false
+	}
+	e.setLeader(held)
+}
+
+func (e *Elector) setLeader(leader bool) {
+	if e.leader.Swap(leader) == leader {
+		return
+	}
+
+	if leader {
+		metrics.Inc("leader_elected")
+		logrus.WithField("replica_id", e.id).Info("👑 Acquired leadership; starting singleton jobs")
+	} else {
+		metrics.Inc("leader_lost")
+		logrus.WithField("replica_id", e.id).Warn("⚠️ Lost leadership; stopping singleton jobs")
+	}
+
+	e.mu.Lock()
+	close(e.changed)
+	e.changed = make(chan struct{})
+	e.mu.Unlock()
+}
+
+// watch returns the current leadership state and a channel closed on the next change
+func (e *Elector) watch() (bool, <-chan struct{}) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.leader.Load(), e.changed
+}
+
+// RunSingleton runs job while this replica is leader: it starts when
+// leadership is gained, its context is cancelled when it is lost and it starts
+// again on the next election. It returns once ctx is cancelled and job exits.
+func (e *Elector) RunSingleton(ctx context.Context, name string, job func(ctx context.Context)) {
+	for {
+		leader, changed := e.watch()
+		if !leader {
+			select {
+			case <-ctx.Done():
+				return
+			case <-changed:
+				continue
+			}
+		}
+
+		jobCtx, stop := context.WithCancel(ctx)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			job(jobCtx)
+		}()
+		logrus.WithField("job", name).Debug("Singleton job started")
+
+		returned := false
+		select {
+		case <-ctx.Done():
+		case <-changed:
+		case <-done:
+			returned = true
+		}
+		stop()
+		<-done
+
+		if ctx.Err() != nil {
+			return
+		}
+		if leader, _ := e.watch(); returned && leader {
+			// The job returned on its own while we're still leader; don't spin
+			return
+		}
+	}
+}
+
+// Local is the lease for single-replica deployments: always held
+type Local struct{}
+
+// TryAcquire always succeeds
+func (Local) TryAcquire(context.Context, string, time.Duration) (bool, error) {
+	return true, nil
+}
+
+// Release is a no-op
+func (Local) Release(context.Context, string) error {
+	return nil
+}
+
+// Close is a no-op
+func (Local) Close() error {
+	return nil
+}
+
+// New creates the lease for backend ("", "none", "nats" or "redis") at url
+func New(backend, url, name string) (Lease, error) {
+	switch backend {
+	case "", "none":
+		return Local{}, nil
+	case "nats":
+		return NewNATS(url, name)
+	case "redis":
+		return NewRedis(url, name)
+	}
+	return nil, fmt.Errorf("unknown leader election backend: %s", backend)
+}
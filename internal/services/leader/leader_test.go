@@ -0,0 +1,244 @@
+package leader
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/avvvet/cdnbuddy-api/internal/services/messaging"
+	"github.com/avvvet/cdnbuddy-api/internal/testutil"
+)
+
+// memoryLease is a Lease kept in memory whose expiry follows a fake clock
+type memoryLease struct {
+	now     func() time.Time
+	mu      sync.Mutex
+	holder  string
+	expires time.Time
+	err     error // returned by TryAcquire when set
+}
+
+func (l *memoryLease) TryAcquire(_ context.Context, holder string, ttl time.Duration) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.err != nil {
+		return false, l.err
+	}
+	if l.holder != "" && l.holder != holder && l.now().Before(l.expires) {
+		return false, nil
+	}
+	l.holder, l.expires = holder, l.now().Add(ttl)
+	return true, nil
+}
+
+func (l *memoryLease) Release(_ context.Context, holder string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.holder == holder {
+		l.holder = ""
+	}
+	return nil
+}
+
+func (l *memoryLease) Close() error { return nil }
+
+func (l *memoryLease) fail(err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.err = err
+}
+
+func TestElectorHandover(t *testing.T) {
+	const ttl = 30 * time.Second
+	errStore := errors.New("store unreachable")
+
+	// each step advances the clock, optionally breaks the store, then lets
+	// one replica try to acquire or renew the lease
+	type step struct {
+		advance  time.Duration
+		replica  string
+		err      error
+		wantHeld bool
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "first replica wins, the other waits",
+			steps: []step{
+				{replica: "a", wantHeld: true},
+				{replica: "b", wantHeld: false},
+				{advance: ttl - time.Second, replica: "b", wantHeld: false},
+			},
+		},
+		{
+			name: "renewals keep the lease",
+			steps: []step{
+				{replica: "a", wantHeld: true},
+				{advance: ttl / 2, replica: "a", wantHeld: true},
+				{advance: ttl / 2, replica: "b", wantHeld: false},
+			},
+		},
+		{
+			name: "takeover once the lease expires",
+			steps: []step{
+				{replica: "a", wantHeld: true},
+				{advance: ttl, replica: "b", wantHeld: true},
+				{replica: "a", wantHeld: false},
+			},
+		},
+		{
+			name: "steps down when renewal fails",
+			steps: []step{
+				{replica: "a", wantHeld: true},
+				{replica: "a", err: errStore, wantHeld: false},
+				{replica: "a", wantHeld: true},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := testutil.NewClock()
+			lease := &memoryLease{now: clock.Now}
+			electors := map[string]*Elector{
+				"a": NewElector(lease, "a", ttl),
+				"b": NewElector(lease, "b", ttl),
+			}
+
+			for i, s := range tt.steps {
+				clock.Advance(s.advance)
+				lease.fail(s.err)
+				e := electors[s.replica]
+				e.tick(context.Background())
+				if e.IsLeader() != s.wantHeld {
+					t.Fatalf("step %d: %s.IsLeader() = %v, want %v", i, s.replica, e.IsLeader(), s.wantHeld)
+				}
+			}
+		})
+	}
+}
+
+func TestElectorReleasesOnShutdown(t *testing.T) {
+	clock := testutil.NewClock()
+	lease := &memoryLease{now: clock.Now}
+	a := NewElector(lease, "a", time.Hour)
+	b := NewElector(lease, "b", time.Hour)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		a.Run(ctx)
+		close(stopped)
+	}()
+	waitLeader(t, a, true)
+
+	cancel()
+	testutil.Await(t, stopped)
+	if a.IsLeader() {
+		t.Fatal("stopped replica still reports leadership")
+	}
+
+	// b takes over at once instead of waiting for the lease to expire
+	b.tick(context.Background())
+	if !b.IsLeader() {
+		t.Error("lease wasn't released on shutdown")
+	}
+}
+
+func TestRunSingleton(t *testing.T) {
+	clock := testutil.NewClock()
+	lease := &memoryLease{now: clock.Now}
+	e := NewElector(lease, "a", time.Minute)
+
+	started := make(chan struct{}, 1)
+	stopped := make(chan struct{}, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	returned := make(chan struct{})
+	go func() {
+		e.RunSingleton(ctx, "test", func(jobCtx context.Context) {
+			started <- struct{}{}
+			<-jobCtx.Done()
+			stopped <- struct{}{}
+		})
+		close(returned)
+	}()
+
+	e.tick(context.Background())
+	testutil.Await(t, started)
+
+	lease.fail(errors.New("store unreachable"))
+	e.tick(context.Background())
+	testutil.Await(t, stopped)
+
+	lease.fail(nil)
+	e.tick(context.Background())
+	testutil.Await(t, started)
+
+	cancel()
+	testutil.Await(t, stopped)
+	testutil.Await(t, returned)
+}
+
+func TestNATSLease(t *testing.T) {
+	srv, err := messaging.StartEmbeddedServer("127.0.0.1:-1", t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to start embedded NATS: %v", err)
+	}
+	t.Cleanup(srv.Shutdown)
+
+	newLease := func() *NATS {
+		lease, err := NewNATS(srv.ClientURL(), "test")
+		if err != nil {
+			t.Fatalf("NewNATS() error = %v", err)
+		}
+		t.Cleanup(func() { lease.Close() })
+		return lease
+	}
+	a, b := newLease(), newLease()
+	ctx := context.Background()
+
+	steps := []struct {
+		name    string
+		lease   *NATS
+		holder  string
+		release bool
+		want    bool
+	}{
+		{name: "a acquires the free lease", lease: a, holder: "a", want: true},
+		{name: "b is refused while a holds it", lease: b, holder: "b", want: false},
+		{name: "a renews", lease: a, holder: "a", want: true},
+		{name: "b can't release a's lease", lease: b, holder: "b", release: true},
+		{name: "b is still refused", lease: b, holder: "b", want: false},
+		{name: "a releases", lease: a, holder: "a", release: true},
+		{name: "b takes over", lease: b, holder: "b", want: true},
+		{name: "a is refused", lease: a, holder: "a", want: false},
+	}
+	for _, s := range steps {
+		if s.release {
+			if err := s.lease.Release(ctx, s.holder); err != nil {
+				t.Fatalf("%s: Release() error = %v", s.name, err)
+			}
+			continue
+		}
+		got, err := s.lease.TryAcquire(ctx, s.holder, time.Minute)
+		if err != nil || got != s.want {
+			t.Fatalf("%s: TryAcquire() = %v, %v, want %v", s.name, got, err, s.want)
+		}
+	}
+}
+
+// waitLeader waits for the elector's leadership to become want
+func waitLeader(t *testing.T, e *Elector, want bool) {
+	t.Helper()
+	deadline := time.Now().Add(testutil.AwaitTimeout)
+	for e.IsLeader() != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("IsLeader() stayed %v", !want)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
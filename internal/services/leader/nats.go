@@ -0,0 +1,153 @@
+package leader
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+const natsBucket = "cdnbuddy_leader"
+
+// NATS holds the lease in a JetStream key-value bucket. Writes are guarded by
+// the key's revision, so two replicas can't both take an expired lease. The
+// server must have JetStream enabled; the embedded server only has it with a
+// store directory, and is per-process anyway.
+type NATS struct {
+	conn *nats.Conn
+	js   nats.JetStreamContext
+	name string
+}
+
+type natsLease struct {
+	Holder    string    `json:"holder"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// NewNATS connects to NATS at url for the lease called name
+func NewNATS(url, name string) (*NATS, error) {
+	conn, err := nats.Connect(url, nats.Name("cdnbuddy-leader"), nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+
+	js, err := conn.JetStream(nats.MaxWait(5 * time.Second))
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to open JetStream: %w", err)
+	}
+
+	return &NATS{conn: conn, js: js, name: name}, nil
+}
+
+// bucket opens the lease bucket, creating it on first use
+func (l *NATS) bucket() (nats.KeyValue, error) {
+	kv, err := l.js.KeyValue(natsBucket)
+	if errors.Is(err, nats.ErrBucketNotFound) {
+		kv, err = l.js.CreateKeyValue(&nats.KeyValueConfig{
+			Bucket:      natsBucket,
+			Description: "CDNBuddy leader leases",
+			History:     1,
+		})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lease bucket: %w", err)
+	}
+	return kv, nil
+}
+
+// TryAcquire creates the lease if it is free or expired and renews it if
+// holder already has it. Expiry is judged on our clock, so replica clocks
+// should be kept within a small fraction of the TTL.
+func (l *NATS) TryAcquire(ctx context.Context, holder string, ttl time.Duration) (bool, error) {
+	kv, err := l.bucket()
+	if err != nil {
+		return false, err
+	}
+
+	value, err := encodeLease(natsLease{Holder: holder, ExpiresAt: time.Now().Add(ttl)})
+	if err != nil {
+		return false, err
+	}
+
+	entry, err := kv.Get(l.name)
+	if errors.Is(err, nats.ErrKeyNotFound) {
+		if _, err := kv.Create(l.name, value); err != nil {
+			if isRevisionConflict(err) {
+				return false, nil // another replica got there first
+			}
+			return false, fmt.Errorf("failed to create lease: %w", err)
+		}
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read lease: %w", err)
+	}
+
+	current, err := decodeLease(entry.Value())
+	if err == nil && current.Holder != holder && time.Now().Before(current.ExpiresAt) {
+		return false, nil
+	}
+
+	if _, err := kv.Update(l.name, value, entry.Revision()); err != nil {
+		if isRevisionConflict(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to renew lease: %w", err)
+	}
+	return true, nil
+}
+
+// Release deletes the lease if holder still has it
+func (l *NATS) Release(ctx context.Context, holder string) error {
+	kv, err := l.bucket()
+	if err != nil {
+		return err
+	}
+
+	entry, err := kv.Get(l.name)
+	if errors.Is(err, nats.ErrKeyNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read lease: %w", err)
+	}
+	if current, err := decodeLease(entry.Value()); err != nil || current.Holder != holder {
+		return nil
+	}
+
+	if err := kv.Delete(l.name, nats.LastRevision(entry.Revision())); err != nil && !isRevisionConflict(err) {
+		return fmt.Errorf("failed to release lease: %w", err)
+	}
+	return nil
+}
+
+// Close closes the NATS connection
+func (l *NATS) Close() error {
+	l.conn.Close()
+	return nil
+}
+
+// isRevisionConflict reports whether a write lost a race on the key revision
+func isRevisionConflict(err error) bool {
+	var apiErr *nats.APIError
+	return errors.Is(err, nats.ErrKeyExists) ||
+		(errors.As(err, &apiErr) && apiErr.ErrorCode == nats.JSErrCodeStreamWrongLastSequence)
+}
+
+func encodeLease(lease natsLease) ([]byte, error) {
+	data, err := json.Marshal(lease)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode lease: %w", err)
+	}
+	return data, nil
+}
+
+func decodeLease(data []byte) (natsLease, error) {
+	var lease natsLease
+	err := json.Unmarshal(data, &lease)
+	return lease, err
+}
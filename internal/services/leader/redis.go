@@ -0,0 +1,82 @@
+package leader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const redisKeyPrefix = "cdnbuddy:leader:"
+
+// renewScript extends the lease only if holder still has it
+var renewScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// releaseScript deletes the lease only if holder still has it
+var releaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// Redis holds the lease as a key with a TTL; expiry uses Redis' clock
+type Redis struct {
+	client *redis.Client
+	key    string
+}
+
+// NewRedis connects to Redis at url (redis://host:port/db) for the lease called name
+func NewRedis(url, name string) (*Redis, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Redis URL: %w", err)
+	}
+
+	client := redis.NewClient(opts)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+
+	return &Redis{client: client, key: redisKeyPrefix + name}, nil
+}
+
+// TryAcquire sets the lease if it is free and renews it if holder already has it
+func (l *Redis) TryAcquire(ctx context.Context, holder string, ttl time.Duration) (bool, error) {
+	acquired, err := l.client.SetNX(ctx, l.key, holder, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire lease: %w", err)
+	}
+	if acquired {
+		return true, nil
+	}
+
+	renewed, err := renewScript.Run(ctx, l.client, []string{l.key}, holder, ttl.Milliseconds()).Int64()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return false, fmt.Errorf("failed to renew lease: %w", err)
+	}
+	return renewed == 1, nil
+}
+
+// Release deletes the lease if holder still has it
+func (l *Redis) Release(ctx context.Context, holder string) error {
+	if err := releaseScript.Run(ctx, l.client, []string{l.key}, holder).Err(); err != nil && !errors.Is(err, redis.Nil) {
+		return fmt.Errorf("failed to release lease: %w", err)
+	}
+	return nil
+}
+
+// Close closes the Redis client
+func (l *Redis) Close() error {
+	return l.client.Close()
+}
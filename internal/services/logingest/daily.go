@@ -0,0 +1,102 @@
+package logingest
+
+import (
+	"sort"
+	"time"
+)
+
+// MaxDailyRollups is how many days of per-day totals are kept per service
+const MaxDailyRollups = 90
+
+// DayTotals are one service's traffic on one UTC day, kept up to date as log
+// files are ingested so trend queries don't rescan anything
+type DayTotals struct {
+	Date        string  `json:"date"` // YYYY-MM-DD, UTC
+	Requests    int64   `json:"requests"`
+	CacheHits   int64   `json:"cache_hits"`
+	CacheMisses int64   `json:"cache_misses"`
+	HitRatio    float64 `json:"hit_ratio"`
+	Bytes       int64   `json:"bytes"`
+	Errors      int64   `json:"errors"` // 5xx responses
+}
+
+// addDay counts one log entry in the rollup of its day
+func (s *serviceStats) addDay(e entry, hit, miss bool) {
+	if e.Time.IsZero() {
+		return
+	}
+
+	date := e.Time.UTC().Format(time.DateOnly)
+	day, ok := s.days[date]
+	if !ok {
+		day = &DayTotals{Date: date}
+		s.days[date] = day
+	}
+
+	day.Requests++
+	day.Bytes += e.Bytes
+	if hit {
+		day.CacheHits++
+	}
+	if miss {
+		day.CacheMisses++
+	}
+	if e.Status >= 500 {
+		day.Errors++
+	}
+}
+
+// mergeDays adds a delta's rollups and drops days beyond the retention
+func (s *serviceStats) mergeDays(delta map[string]*DayTotals) {
+	for date, d := range delta {
+		day, ok := s.days[date]
+		if !ok {
+			day = &DayTotals{Date: date}
+			s.days[date] = day
+		}
+		day.Requests += d.Requests
+		day.CacheHits += d.CacheHits
+		day.CacheMisses += d.CacheMisses
+		day.Bytes += d.Bytes
+		day.Errors += d.Errors
+	}
+
+	if len(s.days) <= MaxDailyRollups {
+		return
+	}
+	dates := make([]string, 0, len(s.days))
+	for date := range s.days {
+		dates = append(dates, date)
+	}
+	sort.Strings(dates)
+	for _, date := range dates[:len(dates)-MaxDailyRollups] {
+		delete(s.days, date)
+	}
+}
+
+// Daily returns the per-day totals of a service for the last days days
+// (oldest first), or false if no logs were ingested for it
+func (w *Worker) Daily(serviceID string, days int) ([]DayTotals, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	stats, ok := w.stats[serviceID]
+	if !ok {
+		return nil, false
+	}
+
+	since := time.Now().UTC().AddDate(0, 0, -days+1).Format(time.DateOnly)
+	totals := make([]DayTotals, 0, len(stats.days))
+	for date, day := range stats.days {
+		if date < since {
+			continue
+		}
+		t := *day
+		if total := t.CacheHits + t.CacheMisses; total > 0 {
+			t.HitRatio = float64(t.CacheHits) / float64(total)
+		}
+		totals = append(totals, t)
+	}
+	sort.Slice(totals, func(i, j int) bool { return totals[i].Date < totals[j].Date })
+	return totals, true
+}
@@ -0,0 +1,212 @@
+package logingest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/avvvet/cdnbuddy-api/internal/domain"
+	"github.com/avvvet/cdnbuddy-api/internal/services/cdn"
+	"github.com/avvvet/cdnbuddy-api/internal/testutil"
+)
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		line   string
+		want   entry
+		wantOK bool
+	}{
+		{
+			name:   "combined with cache status",
+			format: cdn.LogFormatCombined,
+			line:   `203.0.113.7 - - [01/Jan/2025:12:00:00 +0000] "GET /img/logo.png?v=3 HTTP/1.1" 200 5120 "-" "curl/8.0" HIT`,
+			want:   entry{Time: testutil.Epoch, Path: "/img/logo.png", Status: 200, Bytes: 5120, CacheStatus: "HIT"},
+			wantOK: true,
+		},
+		{
+			name:   "combined without bytes or cache status",
+			format: cdn.LogFormatCombined,
+			line:   `203.0.113.7 - - [01/Jan/2025:13:00:00 +0100] "HEAD / HTTP/1.1" 304 -`,
+			want:   entry{Time: testutil.Epoch, Path: "/", Status: 304},
+			wantOK: true,
+		},
+		{
+			name:   "JSON with alternative field names",
+			format: cdn.LogFormatJSON,
+			line:   `{"time":"2025-01-01T12:00:00Z","uri":"/app.js?x=1","status_code":"503","bytes_sent":42,"cacheStatus":"MISS"}`,
+			want:   entry{Time: testutil.Epoch, Path: "/app.js", Status: 503, Bytes: 42, CacheStatus: "MISS"},
+			wantOK: true,
+		},
+		{name: "JSON without path or status", format: cdn.LogFormatJSON, line: `{"bytes":10}`},
+		{name: "not JSON", format: cdn.LogFormatJSON, line: `GET /`},
+		{name: "not combined", format: cdn.LogFormatCombined, line: `{"path":"/"}`},
+		{name: "blank line", format: cdn.LogFormatJSON, line: "   "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseLine(tt.format, tt.line)
+			if ok != tt.wantOK {
+				t.Fatalf("parseLine() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !got.Time.Equal(tt.want.Time) {
+				t.Errorf("time = %s, want %s", got.Time, tt.want.Time)
+			}
+			got.Time, tt.want.Time = time.Time{}, time.Time{}
+			if got != tt.want {
+				t.Errorf("parseLine() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// logProvider is a mock provider whose services have downloadable log files
+type logProvider struct {
+	*cdn.MockProvider
+	mu    sync.Mutex
+	files map[string][]byte // by name
+	list  []cdn.LogFile
+}
+
+func (p *logProvider) deliver(t *testing.T, file cdn.LogFile, lines ...string) {
+	t.Helper()
+	data := []byte(strings.Join(lines, "\n"))
+	if file.Gzipped {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		if _, err := gz.Write(data); err != nil {
+			t.Fatalf("failed to gzip %s: %v", file.Name, err)
+		}
+		gz.Close()
+		data = buf.Bytes()
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.files[file.Name] = data
+	p.list = append(p.list, file)
+}
+
+func (p *logProvider) ListLogFiles(_ context.Context, serviceID string, since time.Time) ([]cdn.LogFile, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	files := make([]cdn.LogFile, 0)
+	for _, f := range p.list {
+		if f.ServiceID == serviceID && !f.CreatedAt.Before(since) {
+			files = append(files, f)
+		}
+	}
+	return files, nil
+}
+
+func (p *logProvider) OpenLogFile(_ context.Context, file cdn.LogFile) (io.ReadCloser, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	data, ok := p.files[file.Name]
+	if !ok {
+		return nil, fmt.Errorf("log file %s not found", file.Name)
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func TestIngestAll(t *testing.T) {
+	ctx := context.Background()
+	provider := &logProvider{MockProvider: cdn.NewMockProvider(), files: make(map[string][]byte)}
+	registry := cdn.NewProviderRegistry(domain.ProviderMock)
+	registry.Register(domain.ProviderMock, provider)
+	service, err := cdn.NewServiceWithRegistry(registry, nil, nil)
+	if err != nil {
+		t.Fatalf("NewServiceWithRegistry() error = %v", err)
+	}
+	svc := testutil.SeedService(t, provider, "shop", "origin.shop.example.com")
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	yesterday := today.AddDate(0, 0, -1)
+	combined := func(at time.Time, path string, status int, cache string) string {
+		return fmt.Sprintf(`203.0.113.7 - - [%s] "GET %s HTTP/1.1" %d 100 "-" "test" %s`,
+			at.Format("02/Jan/2006:15:04:05 -0700"), path, status, cache)
+	}
+	jsonLine := func(at time.Time, path string, status int, cache string) string {
+		return fmt.Sprintf(`{"timestamp":%q,"path":%q,"status":%d,"bytes":100,"cache_status":%q}`,
+			at.Format(time.RFC3339), path, status, cache)
+	}
+
+	provider.deliver(t, cdn.LogFile{ServiceID: svc.ID, Name: "a.log", Format: cdn.LogFormatCombined, CreatedAt: testutil.Epoch},
+		combined(yesterday, "/", 200, "HIT"),
+		combined(yesterday, "/", 200, "MISS"),
+		"garbage",
+	)
+	provider.deliver(t, cdn.LogFile{ServiceID: svc.ID, Name: "b.log.gz", Gzipped: true, Format: cdn.LogFormatJSON, CreatedAt: testutil.Epoch},
+		jsonLine(today, "/app.js", 200, "TCP_HIT"),
+		jsonLine(today, "/", 502, "MISS"),
+	)
+
+	// a second pull sees the same files and must not count them again
+	worker := NewWorker(service)
+	for i := 0; i < 2; i++ {
+		if err := worker.IngestAll(ctx); err != nil {
+			t.Fatalf("IngestAll() error = %v", err)
+		}
+	}
+
+	a, ok := worker.Analytics(svc.ID)
+	if !ok {
+		t.Fatal("no analytics after ingesting")
+	}
+	if a.Files != 2 || a.Requests != 4 || a.CacheHits != 2 || a.CacheMisses != 2 || a.HitRatio != 0.5 {
+		t.Errorf("analytics = %+v, want 2 files, 4 requests, 2 hits, 2 misses", a)
+	}
+	if a.StatusCodes["2xx"] != 3 || a.StatusCodes["5xx"] != 1 {
+		t.Errorf("status codes = %v, want 3 2xx and 1 5xx", a.StatusCodes)
+	}
+	if len(a.TopPaths) == 0 || a.TopPaths[0] != (PathCount{Path: "/", Requests: 3}) {
+		t.Errorf("top paths = %v, want / first with 3 requests", a.TopPaths)
+	}
+
+	days, _ := worker.Daily(svc.ID, 2)
+	if len(days) != 2 || days[0].Date != yesterday.Format(time.DateOnly) || days[1].Errors != 1 {
+		t.Errorf("daily = %+v, want yesterday then today with one error", days)
+	}
+	if days, _ := worker.Daily(svc.ID, 1); len(days) != 1 || days[0].Requests != 2 {
+		t.Errorf("daily for one day = %+v, want today's 2 requests", days)
+	}
+
+	// a later file is picked up on the next pull
+	provider.deliver(t, cdn.LogFile{ServiceID: svc.ID, Name: "c.log", Format: cdn.LogFormatJSON, CreatedAt: testutil.Epoch.Add(time.Hour)},
+		jsonLine(today, "/app.js", 200, "HIT"),
+	)
+	if err := worker.IngestAll(ctx); err != nil {
+		t.Fatalf("IngestAll() error = %v", err)
+	}
+	if a, _ := worker.Analytics(svc.ID); a.Files != 3 || a.Requests != 5 {
+		t.Errorf("after a new file analytics = %+v, want 3 files and 5 requests", a)
+	}
+	if _, ok := worker.Analytics("unknown"); ok {
+		t.Error("analytics reported for a service without logs")
+	}
+}
+
+func TestMergeDaysRetention(t *testing.T) {
+	stats := &serviceStats{days: make(map[string]*DayTotals)}
+	delta := make(map[string]*DayTotals)
+	for i := 0; i < MaxDailyRollups+5; i++ {
+		date := testutil.Epoch.AddDate(0, 0, i).Format(time.DateOnly)
+		delta[date] = &DayTotals{Date: date, Requests: 1}
+	}
+	stats.mergeDays(delta)
+
+	if len(stats.days) != MaxDailyRollups {
+		t.Fatalf("kept %d days, want %d", len(stats.days), MaxDailyRollups)
+	}
+	if _, ok := stats.days[testutil.Epoch.Format(time.DateOnly)]; ok {
+		t.Error("the oldest day wasn't dropped")
+	}
+}
@@ -0,0 +1,103 @@
+package logingest
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/avvvet/cdnbuddy-api/internal/services/cdn"
+)
+
+// entry is the part of an access log line analytics need
+type entry struct {
+	Time        time.Time
+	Path        string
+	Status      int
+	Bytes       int64
+	CacheStatus string
+}
+
+// combinedPattern matches Apache combined format with an optional trailing cache status
+var combinedPattern = regexp.MustCompile(`^\S+ \S+ \S+ \[([^\]]+)\] "\S+ (\S+)[^"]*" (\d{3}) (\d+|-)(?: "[^"]*" "[^"]*")?(?: (\S+))?`)
+
+// parseLine parses one log line in the given format
+func parseLine(format, line string) (entry, bool) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return entry{}, false
+	}
+
+	if format == cdn.LogFormatCombined {
+		return parseCombined(line)
+	}
+	return parseJSON(line)
+}
+
+func parseCombined(line string) (entry, bool) {
+	m := combinedPattern.FindStringSubmatch(line)
+	if m == nil {
+		return entry{}, false
+	}
+
+	e := entry{Path: stripQuery(m[2]), CacheStatus: m[5]}
+	e.Time, _ = time.Parse("02/Jan/2006:15:04:05 -0700", m[1])
+	e.Status, _ = strconv.Atoi(m[3])
+	if m[4] != "-" {
+		e.Bytes, _ = strconv.ParseInt(m[4], 10, 64)
+	}
+	return e, true
+}
+
+// parseJSON accepts the common field names used by CDN JSON log formats
+func parseJSON(line string) (entry, bool) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal([]byte(line), &raw); err != nil {
+		return entry{}, false
+	}
+
+	e := entry{
+		Path:        stripQuery(firstString(raw, "path", "uri", "request_uri", "url")),
+		CacheStatus: firstString(raw, "cache_status", "cacheStatus", "cache"),
+		Status:      int(firstNumber(raw, "status", "status_code")),
+		Bytes:       int64(firstNumber(raw, "bytes", "bytes_sent", "bytesSent")),
+	}
+	if ts := firstString(raw, "timestamp", "time"); ts != "" {
+		e.Time, _ = time.Parse(time.RFC3339, ts)
+	}
+	if e.Path == "" && e.Status == 0 {
+		return entry{}, false
+	}
+	return e, true
+}
+
+func firstString(raw map[string]interface{}, keys ...string) string {
+	for _, k := range keys {
+		if v, ok := raw[k].(string); ok && v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func firstNumber(raw map[string]interface{}, keys ...string) float64 {
+	for _, k := range keys {
+		switch v := raw[k].(type) {
+		case float64:
+			return v
+		case string:
+			if n, err := strconv.ParseFloat(v, 64); err == nil {
+				return n
+			}
+		}
+	}
+	return 0
+}
+
+func stripQuery(p string) string {
+	if i := strings.IndexByte(p, '?'); i >= 0 {
+		return p[:i]
+	}
+	return p
+}
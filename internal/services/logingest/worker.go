@@ -0,0 +1,312 @@
+// Package logingest pulls access logs delivered by CDN providers and computes
+// our own traffic analytics from them.
+package logingest
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/avvvet/cdnbuddy-api/internal/services/cdn"
+)
+
+const (
+	maxTrackedPaths = 10000 // further paths are counted as "(other)"
+	topPathsLimit   = 20
+	maxLineBytes    = 64 * 1024
+)
+
+// PathCount is the number of requests for one path
+type PathCount struct {
+	Path     string `json:"path"`
+	Requests int64  `json:"requests"`
+}
+
+// Analytics are computed from the access logs ingested for one service
+type Analytics struct {
+	ServiceID   string           `json:"service_id"`
+	Requests    int64            `json:"requests"`
+	CacheHits   int64            `json:"cache_hits"`
+	CacheMisses int64            `json:"cache_misses"`
+	HitRatio    float64          `json:"hit_ratio"`
+	Bytes       int64            `json:"bytes"`
+	StatusCodes map[string]int64 `json:"status_codes"` // by class, e.g. "2xx"
+	TopPaths    []PathCount      `json:"top_paths"`
+	Files       int              `json:"files"`
+	FirstSeen   time.Time        `json:"first_seen,omitempty"`
+	LastSeen    time.Time        `json:"last_seen,omitempty"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+}
+
+type serviceStats struct {
+	analytics Analytics
+	paths     map[string]int64
+	days      map[string]*DayTotals // daily rollups by UTC date
+	cursor    time.Time             // creation time of the newest ingested file
+	ingested  map[string]bool       // files created at the cursor, to skip on the next pull
+}
+
+// Worker periodically pulls new log files for every service with log delivery
+type Worker struct {
+	service *cdn.Service
+	stats   map[string]*serviceStats
+	mu      sync.RWMutex
+}
+
+// NewWorker creates a log ingestion worker for the services managed by service
+func NewWorker(service *cdn.Service) *Worker {
+	return &Worker{
+		service: service,
+		stats:   make(map[string]*serviceStats),
+	}
+}
+
+// Start ingests logs every interval until ctx is cancelled
+func (w *Worker) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := w.IngestAll(ctx); err != nil {
+				logrus.WithError(err).Warn("⚠️ Log ingestion failed")
+			}
+		}
+	}
+}
+
+// IngestAll pulls new log files for all services; providers without log
+// download support are skipped
+func (w *Worker) IngestAll(ctx context.Context) error {
+	services, err := w.service.ListServices(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list services: %w", err)
+	}
+
+	for _, svc := range services {
+		providerService, err := w.service.ForProvider(svc.Provider)
+		if err != nil {
+			continue
+		}
+
+		files, err := w.ingestService(ctx, providerService, svc.ID)
+		if errors.Is(err, cdn.ErrNotSupported) {
+			continue
+		}
+		if err != nil {
+			logrus.WithError(err).WithField("service_id", svc.ID).Warn("⚠️ Failed to ingest access logs")
+			continue
+		}
+		if files > 0 {
+			logrus.WithFields(logrus.Fields{
+				"service_id": svc.ID,
+				"files":      files,
+			}).Info("📥 Ingested access logs")
+		}
+	}
+
+	return nil
+}
+
+// Analytics returns the analytics computed so far for a service
+func (w *Worker) Analytics(serviceID string) (*Analytics, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	stats, ok := w.stats[serviceID]
+	if !ok {
+		return nil, false
+	}
+
+	a := stats.analytics
+	a.StatusCodes = make(map[string]int64, len(stats.analytics.StatusCodes))
+	for k, v := range stats.analytics.StatusCodes {
+		a.StatusCodes[k] = v
+	}
+	a.TopPaths = topPaths(stats.paths, topPathsLimit)
+	if total := a.CacheHits + a.CacheMisses; total > 0 {
+		a.HitRatio = float64(a.CacheHits) / float64(total)
+	}
+	return &a, true
+}
+
+// ingestService pulls files created since the service's cursor and returns how many were ingested
+func (w *Worker) ingestService(ctx context.Context, service *cdn.Service, serviceID string) (int, error) {
+	w.mu.RLock()
+	var cursor time.Time
+	var seen map[string]bool
+	if stats, ok := w.stats[serviceID]; ok {
+		cursor = stats.cursor
+		seen = stats.ingested
+	}
+	w.mu.RUnlock()
+
+	files, err := service.ListLogFiles(ctx, serviceID, cursor)
+	if err != nil {
+		return 0, err
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].CreatedAt.Before(files[j].CreatedAt) })
+
+	ingested := 0
+	for _, file := range files {
+		if file.CreatedAt.Before(cursor) || (file.CreatedAt.Equal(cursor) && seen[file.Name]) {
+			continue
+		}
+
+		delta, err := w.readFile(ctx, service, file)
+		if err != nil {
+			return ingested, err
+		}
+		w.merge(serviceID, file, delta)
+		ingested++
+	}
+
+	return ingested, nil
+}
+
+// readFile parses a log file into a fresh stats delta, outside the lock
+func (w *Worker) readFile(ctx context.Context, service *cdn.Service, file cdn.LogFile) (*serviceStats, error) {
+	body, err := service.OpenLogFile(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	var reader io.Reader = body
+	if file.Gzipped {
+		gz, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress %s: %w", file.Name, err)
+		}
+		defer gz.Close()
+		reader = gz
+	}
+
+	delta := &serviceStats{
+		analytics: Analytics{StatusCodes: make(map[string]int64)},
+		paths:     make(map[string]int64),
+		days:      make(map[string]*DayTotals),
+	}
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 4096), maxLineBytes)
+	for scanner.Scan() {
+		e, ok := parseLine(file.Format, scanner.Text())
+		if !ok {
+			continue
+		}
+		delta.add(e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", file.Name, err)
+	}
+
+	return delta, nil
+}
+
+// add counts one log entry
+func (s *serviceStats) add(e entry) {
+	a := &s.analytics
+	a.Requests++
+	a.Bytes += e.Bytes
+
+	if e.Status > 0 {
+		a.StatusCodes[fmt.Sprintf("%dxx", e.Status/100)]++
+	}
+	hit := e.CacheStatus != "" && strings.Contains(strings.ToUpper(e.CacheStatus), "HIT")
+	miss := e.CacheStatus != "" && !hit
+	if hit {
+		a.CacheHits++
+	}
+	if miss {
+		a.CacheMisses++
+	}
+	if e.Path != "" {
+		s.paths[e.Path]++
+	}
+	s.addDay(e, hit, miss)
+	if !e.Time.IsZero() {
+		if a.FirstSeen.IsZero() || e.Time.Before(a.FirstSeen) {
+			a.FirstSeen = e.Time
+		}
+		if e.Time.After(a.LastSeen) {
+			a.LastSeen = e.Time
+		}
+	}
+}
+
+// merge adds a file's delta to the service's analytics and advances the cursor
+func (w *Worker) merge(serviceID string, file cdn.LogFile, delta *serviceStats) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	stats, ok := w.stats[serviceID]
+	if !ok {
+		stats = &serviceStats{
+			analytics: Analytics{ServiceID: serviceID, StatusCodes: make(map[string]int64)},
+			paths:     make(map[string]int64),
+			days:      make(map[string]*DayTotals),
+			ingested:  make(map[string]bool),
+		}
+		w.stats[serviceID] = stats
+	}
+
+	a, d := &stats.analytics, delta.analytics
+	a.Requests += d.Requests
+	a.CacheHits += d.CacheHits
+	a.CacheMisses += d.CacheMisses
+	a.Bytes += d.Bytes
+	for class, n := range d.StatusCodes {
+		a.StatusCodes[class] += n
+	}
+	for p, n := range delta.paths {
+		if _, tracked := stats.paths[p]; !tracked && len(stats.paths) >= maxTrackedPaths {
+			p = "(other)"
+		}
+		stats.paths[p] += n
+	}
+	stats.mergeDays(delta.days)
+	if !d.FirstSeen.IsZero() && (a.FirstSeen.IsZero() || d.FirstSeen.Before(a.FirstSeen)) {
+		a.FirstSeen = d.FirstSeen
+	}
+	if d.LastSeen.After(a.LastSeen) {
+		a.LastSeen = d.LastSeen
+	}
+	a.Files++
+	a.UpdatedAt = time.Now()
+
+	if file.CreatedAt.After(stats.cursor) {
+		stats.cursor = file.CreatedAt
+		stats.ingested = make(map[string]bool)
+	}
+	stats.ingested[file.Name] = true
+}
+
+func topPaths(paths map[string]int64, limit int) []PathCount {
+	counts := make([]PathCount, 0, len(paths))
+	for p, n := range paths {
+		counts = append(counts, PathCount{Path: p, Requests: n})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Requests != counts[j].Requests {
+			return counts[i].Requests > counts[j].Requests
+		}
+		return counts[i].Path < counts[j].Path
+	})
+	if len(counts) > limit {
+		counts = counts[:limit]
+	}
+	return counts
+}
@@ -0,0 +1,87 @@
+package messaging
+
+import "fmt"
+
+// Attachment types
+const (
+	AttachmentTable = "table"
+	AttachmentJSON  = "json"
+	AttachmentDiff  = "diff"
+	AttachmentFile  = "file"
+)
+
+// Attachment is structured content sent alongside a chat response. Renderer
+// is a hint for the frontend; clients that don't know it fall back to Type.
+type Attachment struct {
+	Type     string      `json:"type"`
+	Title    string      `json:"title,omitempty"`
+	Renderer string      `json:"renderer,omitempty"` // e.g. "table", "code", "diff", "download", "chart"
+	Table    *Table      `json:"table,omitempty"`
+	Data     interface{} `json:"data,omitempty"` // JSON attachments
+	Diff     string      `json:"diff,omitempty"` // unified diff
+	File     *FileRef    `json:"file,omitempty"`
+}
+
+// Table is tabular data such as metrics; all cells are preformatted strings
+type Table struct {
+	Columns []string   `json:"columns"`
+	Rows    [][]string `json:"rows"`
+}
+
+// FileRef links to a downloadable artifact
+type FileRef struct {
+	URL         string `json:"url"`
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Size        int    `json:"size"`
+}
+
+// NewTableAttachment creates a table attachment
+func NewTableAttachment(title string, columns []string, rows [][]string) Attachment {
+	return Attachment{Type: AttachmentTable, Title: title, Renderer: "table", Table: &Table{Columns: columns, Rows: rows}}
+}
+
+// NewJSONAttachment creates a JSON attachment rendered as a code block
+func NewJSONAttachment(title string, data interface{}) Attachment {
+	return Attachment{Type: AttachmentJSON, Title: title, Renderer: "code", Data: data}
+}
+
+// NewDiffAttachment creates a config diff attachment
+func NewDiffAttachment(title, diff string) Attachment {
+	return Attachment{Type: AttachmentDiff, Title: title, Renderer: "diff", Diff: diff}
+}
+
+// NewFileAttachment creates a download link attachment
+func NewFileAttachment(title string, file FileRef) Attachment {
+	return Attachment{Type: AttachmentFile, Title: title, Renderer: "download", File: &file}
+}
+
+// Validate checks an attachment carries the content its type needs
+func (a Attachment) Validate() error {
+	switch a.Type {
+	case AttachmentTable:
+		if a.Table == nil {
+			return fmt.Errorf("table attachment without table")
+		}
+		for i, row := range a.Table.Rows {
+			if len(row) != len(a.Table.Columns) {
+				return fmt.Errorf("table row %d has %d cells, expected %d", i, len(row), len(a.Table.Columns))
+			}
+		}
+	case AttachmentJSON:
+		if a.Data == nil {
+			return fmt.Errorf("json attachment without data")
+		}
+	case AttachmentDiff:
+		if a.Diff == "" {
+			return fmt.Errorf("diff attachment without diff")
+		}
+	case AttachmentFile:
+		if a.File == nil || a.File.URL == "" {
+			return fmt.Errorf("file attachment without url")
+		}
+	default:
+		return fmt.Errorf("unknown attachment type %q", a.Type)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Supported messaging backends
+const (
+	BackendNATS  = "nats"
+	BackendRedis = "redis"
+	BackendKafka = "kafka"
+
+	// inboxPrefix is the subject prefix for request replies on non-NATS backends
+	inboxPrefix = "_INBOX."
+
+	// DefaultConsumerName names durable consumers unless configured otherwise
+	DefaultConsumerName = "cdnbuddy-api"
+)
+
+// ErrRequestTimeout is returned by Bus.Request when no reply arrives in time
+var ErrRequestTimeout = errors.New("request timed out waiting for reply")
+
+// Message is a backend-independent message delivered to subscribers
+type Message struct {
+	Subject string
+	Data    []byte
+	Reply   string // subject to respond on, empty if no reply is expected
+}
+
+// Subscription is an active subscription on a Bus
+type Subscription interface {
+	Unsubscribe() error
+}
+
+// Bus is the transport the messaging layer runs on. Subjects are the NATS-style
+// dotted names in events.go; adapters map them to their own topics/streams, so
+// event contracts stay identical across backends.
+type Bus interface {
+	Publish(subject string, data interface{}) error
+	PublishWithReply(subject, reply string, data interface{}) error
+	Subscribe(subject string, handler func(msg *Message)) (Subscription, error)
+	QueueSubscribe(subject, queue string, handler func(msg *Message)) (Subscription, error)
+	Request(subject string, data interface{}, timeout time.Duration) (*Message, error)
+	Respond(msg *Message, data []byte) error
+	IsConnected() bool
+	Ping(ctx context.Context) error // round trip to the broker
+	Stats() map[string]interface{}
+	Close()
+}
+
+// DurableBus is implemented by backends that keep a subject's messages for a
+// named consumer until it acknowledges them. A message is acknowledged once
+// handler returns nil, so one received just before a crash is delivered again
+// when the consumer resubscribes. A message whose handler fails is delivered
+// again too (NATS after a retry delay, Redis on resubscribing), unless the
+// error is Permanent; Kafka can't hold back one message of a partition and
+// commits it either way.
+type DurableBus interface {
+	SubscribeDurable(subject, consumer string, handler func(msg *Message) error) (Subscription, error)
+}
+
+// ErrPermanent marks handler errors that delivering the message again won't fix
+var ErrPermanent = errors.New("permanent failure")
+
+// Permanent marks err so durable consumers drop the message instead of
+// delivering it again
+func Permanent(err error) error {
+	return fmt.Errorf("%w: %w", ErrPermanent, err)
+}
+
+// BusConfig selects and configures a messaging backend
+type BusConfig struct {
+	Backend string // nats (default), redis or kafka
+	URL     string // NATS URL, Redis URL or comma-separated Kafka brokers
+
+	// Names this replica's durable consumers (and on Redis and Kafka its other
+	// consumers and inbox); replicas need distinct names, and a restarted
+	// replica must keep its own to get what it missed
+	ConsumerName string
+
+	// Publish events in their Envelope; leave off while any consumer predates it
+	Envelopes bool
+
+	// Message encoding: json (default) or protobuf, which only NATS supports
+	Encoding string
+}
+
+// NewBus creates the Bus for the configured backend
+func NewBus(cfg BusConfig) (Bus, error) {
+	codec, err := NewCodec(cfg.Encoding)
+	if err != nil {
+		return nil, err
+	}
+
+	backend := strings.ToLower(cfg.Backend)
+	if _, ok := codec.(JSONCodec); !ok && backend != "" && backend != BackendNATS {
+		return nil, fmt.Errorf("%s encoding is not supported on %s", cfg.Encoding, backend)
+	}
+
+	switch backend {
+	case "", BackendNATS:
+		client, err := NewNATSClient(cfg.URL)
+		if err != nil {
+			return nil, err
+		}
+		client.SetCodec(codec)
+		return client, nil
+	case BackendRedis:
+		return NewRedisBus(cfg.URL, cfg.ConsumerName)
+	case BackendKafka:
+		return NewKafkaBus(strings.Split(cfg.URL, ","), cfg.ConsumerName)
+	default:
+		return nil, fmt.Errorf("unsupported messaging backend: %s", cfg.Backend)
+	}
+}
+
+// topicName maps a dotted subject to a Kafka topic / Redis stream name. Dots are
+// valid in both; NATS wildcards are not, and wildcard subscriptions are not
+// supported on those backends.
+func topicName(subject string) string {
+	return strings.NewReplacer("*", "_", ">", "_").Replace(subject)
+}
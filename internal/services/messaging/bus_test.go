@@ -0,0 +1,97 @@
+package messaging
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Smoke tests of the Redis and Kafka adapters run against the brokers in
+// TEST_REDIS_URL and TEST_KAFKA_BROKERS, and are skipped without them
+func TestBusSmoke(t *testing.T) {
+	tests := []struct {
+		name string
+		env  string
+		open func(url, consumer string) (Bus, error)
+	}{
+		{
+			name: "redis",
+			env:  "TEST_REDIS_URL",
+			open: func(url, consumer string) (Bus, error) { return NewRedisBus(url, consumer) },
+		},
+		{
+			name: "kafka",
+			env:  "TEST_KAFKA_BROKERS",
+			open: func(url, consumer string) (Bus, error) { return NewKafkaBus(strings.Split(url, ","), consumer) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url := os.Getenv(tt.env)
+			if url == "" {
+				t.Skipf("%s not set", tt.env)
+			}
+			bus, err := tt.open(url, "smoke-"+uuid.New().String()[:8])
+			if err != nil {
+				t.Fatalf("connect: %v", err)
+			}
+			defer bus.Close()
+			subject := "cdnbuddy.test." + uuid.New().String()[:8]
+
+			received := make(chan string, 16)
+			if _, err := bus.Subscribe(subject+".events", func(msg *Message) { received <- string(msg.Data) }); err != nil {
+				t.Fatalf("subscribe: %v", err)
+			}
+			// A new subscription only sees what's published after it has
+			// joined, which takes Kafka a few seconds; publish until it arrives
+			untilReceived(t, received, func(i int) error { return bus.Publish(subject+".events", i) })
+
+			if _, err := bus.QueueSubscribe(subject+".requests", "responders", func(msg *Message) {
+				bus.Respond(msg, []byte(`"pong"`))
+			}); err != nil {
+				t.Fatalf("queue subscribe: %v", err)
+			}
+			replies := make(chan string, 16)
+			untilReceived(t, replies, func(i int) error {
+				reply, err := bus.Request(subject+".requests", "ping", 2*time.Second)
+				if err == ErrRequestTimeout {
+					return nil
+				}
+				if err == nil {
+					replies <- string(reply.Data)
+				}
+				return err
+			})
+
+			if err := bus.Ping(context.Background()); err != nil {
+				t.Errorf("ping: %v", err)
+			}
+		})
+	}
+}
+
+// untilReceived calls send until a message arrives on received, failing after 30s
+func untilReceived(t *testing.T, received <-chan string, send func(i int) error) {
+	t.Helper()
+	deadline := time.After(30 * time.Second)
+	for i := 0; ; i++ {
+		if err := send(i); err != nil {
+			t.Fatalf("send: %v", err)
+		}
+		select {
+		case data := <-received:
+			if data == "" {
+				t.Fatal("received an empty message")
+			}
+			return
+		case <-time.After(500 * time.Millisecond):
+		case <-deadline:
+			t.Fatalf("nothing received after %d sends", i+1)
+		}
+	}
+}
@@ -11,26 +11,42 @@ import (
 
 // Client provides high-level messaging operations
 type Client struct {
-	nats       *NATSClient
+	bus        Bus
 	publisher  *Publisher
 	subscriber *Subscriber
 }
 
+// NewClient creates a client on a NATS connection
 func NewClient(natsURL string) (*Client, error) {
-	natsClient, err := NewNATSClient(natsURL)
+	return NewClientWithConfig(BusConfig{Backend: BackendNATS, URL: natsURL})
+}
+
+// NewClientWithConfig creates a client on the configured messaging backend
+func NewClientWithConfig(cfg BusConfig) (*Client, error) {
+	bus, err := NewBus(cfg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create NATS client: %w", err)
+		return nil, fmt.Errorf("failed to create %s bus: %w", cfg.Backend, err)
 	}
 
+	client := NewClientWithBus(bus)
+	if cfg.ConsumerName != "" {
+		client.subscriber.SetConsumerName(cfg.ConsumerName)
+	}
+	client.publisher.SetEnvelope(cfg.Envelopes)
+	return client, nil
+}
+
+// NewClientWithBus creates a client on an existing Bus
+func NewClientWithBus(bus Bus) *Client {
 	return &Client{
-		nats:       natsClient,
-		publisher:  NewPublisher(natsClient),
-		subscriber: NewSubscriber(natsClient),
-	}, nil
+		bus:        bus,
+		publisher:  NewPublisher(bus),
+		subscriber: NewSubscriber(bus),
+	}
 }
 
 func (c *Client) Close() {
-	c.nats.Close()
+	c.bus.Close()
 }
 
 func (c *Client) Publisher() *Publisher {
@@ -49,7 +65,7 @@ func (c *Client) RequestCDNStatus(ctx context.Context, userID, sessionID string)
 		Timestamp: time.Now(),
 	}
 
-	msg, err := c.nats.Request(SubjectStatusRequest, request, 5*time.Second)
+	msg, err := c.bus.Request(SubjectStatusRequest, request, 5*time.Second)
 	if err != nil {
 		return nil, fmt.Errorf("failed to request status: %w", err)
 	}
@@ -62,18 +78,19 @@ func (c *Client) RequestCDNStatus(ctx context.Context, userID, sessionID string)
 	return &response, nil
 }
 
-func (c *Client) RequestIntentAnalysis(ctx context.Context, sessionID, userMessage string) (*models.IntentResponse, error) {
-	// Intent Server now handles ALL conversation memory via Redis
-	// We just send the current message - no history needed
-	request := models.IntentRequest{
-		SessionID:           sessionID,
-		UserMessage:         userMessage,
-		ConversationHistory: []models.ConversationMessage{}, // Empty - not needed anymore
-		AvailableActions:    []models.ActionSchema{},        // Empty for now
+// RequestIntentAnalysis sends a user message to the intent service, with the
+// session's summary and recent history and, optionally, context inferred from
+// account state
+func (c *Client) RequestIntentAnalysis(ctx context.Context, request models.IntentRequest) (*models.IntentResponse, error) {
+	if request.ConversationHistory == nil {
+		request.ConversationHistory = []models.ConversationMessage{}
+	}
+	if request.AvailableActions == nil {
+		request.AvailableActions = []models.ActionSchema{} // Empty for now
 	}
 
 	// Send request to intent service
-	msg, err := c.nats.Request("intent.analyze", request, 30*time.Second)
+	msg, err := c.bus.Request("intent.analyze", request, 30*time.Second)
 	if err != nil {
 		return nil, fmt.Errorf("failed to request intent analysis: %w", err)
 	}
@@ -87,6 +104,21 @@ func (c *Client) RequestIntentAnalysis(ctx context.Context, sessionID, userMessa
 	return &response, nil
 }
 
+// RequestSummary asks the intent service to fold messages into a session's rolling summary
+func (c *Client) RequestSummary(ctx context.Context, request models.SummaryRequest) (*models.SummaryResponse, error) {
+	msg, err := c.bus.Request("intent.summarize", request, 60*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request summary: %w", err)
+	}
+
+	var response models.SummaryResponse
+	if err := json.Unmarshal(msg.Data, &response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal summary response: %w", err)
+	}
+
+	return &response, nil
+}
+
 // Send execution plan to socket service
 func (c *Client) SendExecutionPlan(ctx context.Context, event ExecutionPlanEvent) error {
 	return c.publisher.PublishExecutionPlan(ctx, event)
@@ -97,16 +129,34 @@ func (c *Client) SendAIResponse(ctx context.Context, userID, sessionID, response
 	return c.publisher.PublishAIResponse(userID, sessionID, response)
 }
 
+// Send AI response with structured attachments to socket service
+func (c *Client) SendAIResponseWithAttachments(ctx context.Context, userID, sessionID, response string, attachments []Attachment) error {
+	return c.publisher.PublishAIResponseWithAttachments(userID, sessionID, response, attachments)
+}
+
+// SetResponseRecipients routes AI responses to every session returned by fn
+func (c *Client) SetResponseRecipients(fn func(userID, sessionID string) []string) {
+	c.publisher.SetRecipients(fn)
+}
+
+// SetResponseFormatter applies fn to the text of every AI response and notification
+func (c *Client) SetResponseFormatter(fn func(orgID, message string) string) {
+	c.publisher.SetFormatter(fn)
+}
+
 // Health check
 func (c *Client) IsHealthy() bool {
-	return c.nats.IsConnected()
+	return c.bus.IsConnected()
+}
+
+// Ping makes a round trip to the message broker and reports how long it took
+func (c *Client) Ping(ctx context.Context) (time.Duration, error) {
+	start := time.Now()
+	err := c.bus.Ping(ctx)
+	return time.Since(start), err
 }
 
 // Get connection stats
 func (c *Client) GetStats() map[string]interface{} {
-	return map[string]interface{}{
-		"connected":   c.nats.IsConnected(),
-		"server_info": c.nats.conn.ConnectedServerName(),
-		"url":         c.nats.conn.ConnectedUrl(),
-	}
+	return c.bus.Stats()
 }
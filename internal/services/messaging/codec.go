@@ -0,0 +1,80 @@
+package messaging
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+)
+
+// Supported message encodings
+const (
+	EncodingJSON     = "json"
+	EncodingProtobuf = "protobuf"
+
+	jsonMediaType = "application/json"
+)
+
+// Codec encodes the messages NATSClient sends. What it receives is decoded by
+// its Content-Type header whatever the client's own codec, so peers can switch
+// encodings one at a time, and subscribers always get JSON.
+type Codec interface {
+	// MediaType identifies the codec's messages in Content-Type headers
+	MediaType() string
+
+	// Marshal encodes v and returns its Content-Type. A codec that can't
+	// encode v may fall back to JSON.
+	Marshal(v interface{}) (data []byte, contentType string, err error)
+
+	// ToJSON decodes a message, given its Content-Type parameters
+	ToJSON(data []byte, params map[string]string) ([]byte, error)
+}
+
+// JSONCodec is the default codec
+type JSONCodec struct{}
+
+func (JSONCodec) MediaType() string { return jsonMediaType }
+
+func (JSONCodec) Marshal(v interface{}) ([]byte, string, error) {
+	data, err := json.Marshal(v)
+	return data, jsonMediaType, err
+}
+
+func (JSONCodec) ToJSON(data []byte, params map[string]string) ([]byte, error) {
+	return data, nil
+}
+
+// codecs are the codecs messages are decoded with, by media type
+var codecs = map[string]Codec{
+	jsonMediaType:     JSONCodec{},
+	protobufMediaType: ProtobufCodec{},
+}
+
+// NewCodec returns the codec of an encoding
+func NewCodec(encoding string) (Codec, error) {
+	switch encoding {
+	case "", EncodingJSON:
+		return JSONCodec{}, nil
+	case EncodingProtobuf:
+		return ProtobufCodec{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported message encoding: %s", encoding)
+	}
+}
+
+// decodeToJSON decodes a message of the given Content-Type; messages without
+// one are JSON
+func decodeToJSON(data []byte, contentType string) ([]byte, error) {
+	if contentType == "" {
+		return data, nil
+	}
+
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, fmt.Errorf("invalid content type %q: %w", contentType, err)
+	}
+	codec, ok := codecs[mediaType]
+	if !ok {
+		return nil, fmt.Errorf("unsupported content type %q", mediaType)
+	}
+	return codec.ToJSON(data, params)
+}
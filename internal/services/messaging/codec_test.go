@@ -0,0 +1,129 @@
+package messaging_test
+
+import (
+	"encoding/json"
+	"mime"
+	"reflect"
+	"testing"
+
+	"github.com/avvvet/cdnbuddy-api/internal/models"
+	"github.com/avvvet/cdnbuddy-api/internal/services/messaging"
+	"github.com/avvvet/cdnbuddy-api/internal/testutil"
+)
+
+func TestProtobufCodec(t *testing.T) {
+	action, region := "SETUP_CDN", "eu"
+	chat := messaging.ChatEvent{
+		Type:        messaging.EventAIResponse,
+		UserID:      "user-1",
+		SessionID:   "session-1",
+		Message:     "Here are your services",
+		Timestamp:   testutil.Epoch,
+		Attachments: []messaging.Attachment{messaging.NewTableAttachment("Services", []string{"name"}, [][]string{{"shop"}})},
+	}
+	envelope, err := messaging.Seal(messaging.SubjectChatResponse, chat)
+	if err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+	envelope.SentAt = testutil.Epoch
+
+	tests := []struct {
+		name      string
+		message   interface{}
+		wantProto string // empty = sent as JSON
+	}{
+		{name: "chat event", message: chat, wantProto: "ChatEvent"},
+		{name: "chat event in its envelope", message: envelope, wantProto: "Envelope"},
+		{
+			name: "operation event",
+			message: messaging.OperationEvent{
+				Type: messaging.EventOperationCompleted, OperationID: "op-1", ServiceID: "svc-1", Status: "completed",
+				Params: map[string]interface{}{"paths": []interface{}{"/"}}, Timestamp: testutil.Epoch,
+			},
+			wantProto: "OperationEvent",
+		},
+		{
+			name: "status response",
+			message: messaging.StatusResponse{
+				Provider: "cachefly",
+				Domains:  []messaging.Domain{{Name: "cdn.example.com", Status: "active", Regions: 12}},
+				Metrics:  messaging.Metrics{CacheHitRatio: "94%"},
+			},
+			wantProto: "StatusResponse",
+		},
+		{
+			name: "intent request",
+			message: models.IntentRequest{
+				SessionID:           "session-1",
+				UserMessage:         "set up a CDN",
+				ConversationHistory: []models.ConversationMessage{{Role: "user", Message: "hi", Timestamp: testutil.Epoch}},
+				AvailableActions:    []models.ActionSchema{},
+				Context: &models.IntentContext{
+					InferredParameters: map[string]string{"origin": "origin.example.com"},
+					Candidates:         map[string][]string{"service_id": {"svc-1", "svc-2"}},
+				},
+			},
+			wantProto: "IntentRequest",
+		},
+		{
+			name: "intent response with a missing parameter",
+			message: models.IntentResponse{
+				SessionID:  "session-1",
+				Action:     &action,
+				Status:     "NEEDS_INFO",
+				Parameters: map[string]*string{"region": &region, "domain": nil},
+				Usage:      &models.IntentUsage{PromptTokens: 120, TotalTokens: 150},
+			},
+			wantProto: "IntentResponse",
+		},
+		{
+			name:    "message without a schema",
+			message: messaging.NotificationEvent{Type: "notification.test", Title: "Hi"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, contentType, err := messaging.ProtobufCodec{}.Marshal(tt.message)
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+			mediaType, params, err := mime.ParseMediaType(contentType)
+			if err != nil {
+				t.Fatalf("content type %q: %v", contentType, err)
+			}
+			if tt.wantProto == "" {
+				if mediaType != "application/json" {
+					t.Fatalf("content type = %q, want JSON", contentType)
+				}
+				return
+			}
+			if want := "cdnbuddy.messaging.v1." + tt.wantProto; params["proto"] != want {
+				t.Fatalf("content type = %q, want proto %s", contentType, want)
+			}
+
+			got, err := messaging.ProtobufCodec{}.ToJSON(data, params)
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			want, _ := json.Marshal(tt.message)
+			if !reflect.DeepEqual(decodeJSON(t, got), decodeJSON(t, want)) {
+				t.Errorf("decoded = %s\nwant %s", got, want)
+			}
+			if len(data) >= len(want) {
+				t.Errorf("protobuf is %d bytes, JSON %d", len(data), len(want))
+			}
+		})
+	}
+}
+
+// decodeJSON decodes data into generic values, to compare it regardless of formatting
+func decodeJSON(t *testing.T, data []byte) interface{} {
+	t.Helper()
+
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		t.Fatalf("invalid JSON %s: %v", data, err)
+	}
+	return v
+}
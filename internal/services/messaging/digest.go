@@ -0,0 +1,218 @@
+package messaging
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	minDigestInterval = time.Second
+	maxDigestInterval = 10 * time.Minute
+)
+
+// DigestSettings control how a user's progress updates are batched
+type DigestSettings struct {
+	Enabled  bool          `json:"enabled"`  // false sends every update as it arrives
+	Interval time.Duration `json:"interval"` // how often a session gets a consolidated message
+}
+
+// Validate checks digest settings are usable
+func (s DigestSettings) Validate() error {
+	if s.Enabled && (s.Interval < minDigestInterval || s.Interval > maxDigestInterval) {
+		return fmt.Errorf("interval must be between %s and %s", minDigestInterval, maxDigestInterval)
+	}
+	return nil
+}
+
+// pendingProgress is the newest unsent update of one operation
+type pendingProgress struct {
+	opType   string
+	progress string
+	updates  int
+	seq      int
+}
+
+// sessionDigest collects unsent updates for one chat session
+type sessionDigest struct {
+	userID     string
+	sessionID  string
+	operations map[string]*pendingProgress
+	lastSent   map[string]string // operation -> last progress sent, to skip repeats
+	timer      *time.Timer
+	seq        int
+}
+
+// Digester batches rapid-fire operation progress into one message per session
+// per interval. Only the newest update of each operation is sent, and only if
+// it changed since the last digest. Start, completion and failure messages
+// bypass the batching.
+type Digester struct {
+	send     func(userID, sessionID, message string) error
+	defaults DigestSettings
+	users    map[string]DigestSettings
+	sessions map[string]*sessionDigest
+	mu       sync.Mutex
+}
+
+// NewDigester creates a digester that delivers messages through send
+func NewDigester(send func(userID, sessionID, message string) error, defaults DigestSettings) *Digester {
+	return &Digester{
+		send:     send,
+		defaults: defaults,
+		users:    make(map[string]DigestSettings),
+		sessions: make(map[string]*sessionDigest),
+	}
+}
+
+// Settings returns a user's digest settings
+func (d *Digester) Settings(userID string) DigestSettings {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if s, ok := d.users[userID]; ok {
+		return s
+	}
+	return d.defaults
+}
+
+// SetSettings overrides a user's digest settings; a zero interval uses the default
+func (d *Digester) SetSettings(userID string, settings DigestSettings) error {
+	if settings.Interval == 0 {
+		settings.Interval = d.defaults.Interval
+	}
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	d.users[userID] = settings
+	d.mu.Unlock()
+	return nil
+}
+
+// Progress queues a progress update for the next digest of the session
+func (d *Digester) Progress(userID, sessionID, operationID, opType, progress string) error {
+	settings := d.Settings(userID)
+	if !settings.Enabled {
+		return d.send(userID, sessionID, "📊 Progress: "+progress)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	key := userID + "/" + sessionID
+	sd, ok := d.sessions[key]
+	if !ok {
+		sd = &sessionDigest{
+			userID:     userID,
+			sessionID:  sessionID,
+			operations: make(map[string]*pendingProgress),
+			lastSent:   make(map[string]string),
+		}
+		d.sessions[key] = sd
+	}
+
+	sd.seq++
+	p, ok := sd.operations[operationID]
+	if !ok {
+		p = &pendingProgress{seq: sd.seq}
+		sd.operations[operationID] = p
+	}
+	p.opType = opType
+	p.progress = progress
+	p.updates++
+
+	if sd.timer == nil {
+		sd.timer = time.AfterFunc(settings.Interval, func() { d.flush(key) })
+	}
+	return nil
+}
+
+// Immediate sends a message right away. Pending progress of the same
+// operation is dropped as superseded; other pending progress is flushed first
+// so messages stay in order.
+func (d *Digester) Immediate(userID, sessionID, operationID, message string) error {
+	key := userID + "/" + sessionID
+
+	d.mu.Lock()
+	if sd, ok := d.sessions[key]; ok {
+		delete(sd.operations, operationID)
+		delete(sd.lastSent, operationID)
+	}
+	d.mu.Unlock()
+
+	d.flush(key)
+	return d.send(userID, sessionID, message)
+}
+
+// Close flushes every pending digest
+func (d *Digester) Close() {
+	d.mu.Lock()
+	keys := make([]string, 0, len(d.sessions))
+	for key := range d.sessions {
+		keys = append(keys, key)
+	}
+	d.mu.Unlock()
+
+	for _, key := range keys {
+		d.flush(key)
+	}
+}
+
+// flush sends the consolidated message for one session, if anything changed
+func (d *Digester) flush(key string) {
+	d.mu.Lock()
+	sd, ok := d.sessions[key]
+	if !ok {
+		d.mu.Unlock()
+		return
+	}
+	if sd.timer != nil {
+		sd.timer.Stop()
+		sd.timer = nil
+	}
+
+	ids := make([]string, 0, len(sd.operations))
+	for id, p := range sd.operations {
+		if sd.lastSent[id] != p.progress {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return sd.operations[ids[i]].seq < sd.operations[ids[j]].seq })
+
+	lines := make([]string, 0, len(ids))
+	for _, id := range ids {
+		p := sd.operations[id]
+		line := "• " + p.progress
+		if p.opType != "" {
+			line = fmt.Sprintf("• %s: %s", p.opType, p.progress)
+		}
+		if p.updates > 1 {
+			line += fmt.Sprintf(" (%d updates)", p.updates)
+		}
+		lines = append(lines, line)
+		sd.lastSent[id] = p.progress
+	}
+	sd.operations = make(map[string]*pendingProgress)
+	if len(sd.lastSent) == 0 {
+		delete(d.sessions, key)
+	}
+	userID, sessionID := sd.userID, sd.sessionID
+	d.mu.Unlock()
+
+	if len(lines) == 0 {
+		return
+	}
+
+	message := "📊 Progress: " + strings.TrimPrefix(lines[0], "• ")
+	if len(lines) > 1 {
+		message = "📊 Progress update:\n" + strings.Join(lines, "\n")
+	}
+	if err := d.send(userID, sessionID, message); err != nil {
+		log.Printf("❌ Failed to send progress digest to %s: %v", userID, err)
+	}
+}
@@ -0,0 +1,71 @@
+package messaging
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+)
+
+// EmbeddedURL is the NATS_URL value that runs an in-process NATS server
+// instead of connecting to external messaging infrastructure
+const EmbeddedURL = "embedded"
+
+// EmbeddedServer is an in-process NATS server for single-binary deployments
+type EmbeddedServer struct {
+	server *server.Server
+}
+
+// StartEmbeddedServer starts an in-process NATS server listening on listenAddr
+// (host:port). Other services (socket, intent) can still connect to it over TCP.
+// A storeDir enables JetStream, keeping durable subscriptions' messages there.
+func StartEmbeddedServer(listenAddr, storeDir string) (*EmbeddedServer, error) {
+	host, portStr, err := net.SplitHostPort(listenAddr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid embedded NATS listen address %q: %w", listenAddr, err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid embedded NATS port %q: %w", portStr, err)
+	}
+
+	opts := &server.Options{
+		ServerName: "cdnbuddy-embedded",
+		Host:       host,
+		Port:       port,
+		NoSigs:     true, // the API process owns signal handling
+		NoLog:      true,
+		JetStream:  storeDir != "",
+		StoreDir:   storeDir,
+	}
+
+	srv, err := server.NewServer(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create embedded NATS server: %w", err)
+	}
+
+	go srv.Start()
+
+	if !srv.ReadyForConnections(10 * time.Second) {
+		srv.Shutdown()
+		return nil, fmt.Errorf("embedded NATS server not ready on %s", listenAddr)
+	}
+
+	log.Printf("🧩 Embedded NATS server listening on %s", srv.ClientURL())
+	return &EmbeddedServer{server: srv}, nil
+}
+
+// ClientURL returns the URL clients use to connect to the embedded server
+func (e *EmbeddedServer) ClientURL() string {
+	return e.server.ClientURL()
+}
+
+// Shutdown stops the embedded server and waits for it to exit
+func (e *EmbeddedServer) Shutdown() {
+	e.server.Shutdown()
+	e.server.WaitForShutdown()
+	log.Printf("🔒 Embedded NATS server stopped")
+}
@@ -0,0 +1,167 @@
+package messaging
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Envelope wraps every published event, so the socket server, the intent
+// service and this API can change their events independently: a consumer
+// knows what it got and whether it can read it before decoding the payload.
+//
+// A schema version is only bumped for incompatible changes; adding a field
+// keeps it. Payloads without an envelope, from peers that predate it, are
+// read as the current version.
+type Envelope struct {
+	EventType     string          `json:"event_type"`
+	SchemaVersion int             `json:"schema_version"`
+	CorrelationID string          `json:"correlation_id,omitempty"` // e.g. the plan or session the event belongs to
+	SentAt        time.Time       `json:"sent_at"`
+	Payload       json.RawMessage `json:"payload"`
+}
+
+// Schema describes the events of one subject
+type Schema struct {
+	EventType  string
+	Version    int // written by this build
+	MinVersion int // oldest version still read
+
+	check     func(payload []byte) error
+	correlate func(event interface{}) string
+}
+
+var (
+	ErrUnknownEventType   = errors.New("unexpected event type")
+	ErrUnsupportedVersion = errors.New("unsupported schema version")
+)
+
+// schemaOf builds the schema of events of type T; check reports a decoded
+// event that is missing what consumers rely on
+func schemaOf[T any](eventType string, version, minVersion int, check func(T) error, correlate func(T) string) Schema {
+	return Schema{
+		EventType:  eventType,
+		Version:    version,
+		MinVersion: minVersion,
+		check: func(payload []byte) error {
+			var event T
+			if err := json.Unmarshal(payload, &event); err != nil {
+				return fmt.Errorf("failed to decode %s: %w", eventType, err)
+			}
+			return check(event)
+		},
+		correlate: func(event interface{}) string {
+			if e, ok := event.(T); ok {
+				return correlate(e)
+			}
+			return ""
+		},
+	}
+}
+
+// required reports the first of fields (name, value pairs) that is empty
+func required(fields ...string) error {
+	for i := 0; i+1 < len(fields); i += 2 {
+		if fields[i+1] == "" {
+			return fmt.Errorf("%s is required", fields[i])
+		}
+	}
+	return nil
+}
+
+var (
+	chatSchema = schemaOf("chat", 1, 1,
+		func(e ChatEvent) error { return required("user_id", e.UserID, "session_id", e.SessionID) },
+		func(e ChatEvent) string { return e.SessionID })
+	executionPlanSchema = schemaOf("execution_plan", 1, 1,
+		func(e ExecutionPlanEvent) error { return required("plan.id", e.Plan.ID) },
+		func(e ExecutionPlanEvent) string { return e.Plan.ID })
+	statusRequestSchema = schemaOf("status_request", 1, 1,
+		func(e StatusRequestEvent) error { return required("session_id", e.SessionID) },
+		func(e StatusRequestEvent) string { return e.SessionID })
+)
+
+// schemas is the registry of event schemas by subject
+var schemas = map[string]Schema{
+	SubjectCDNService: schemaOf("cdn_service", 1, 1,
+		func(e CDNServiceEvent) error { return required("type", e.Type, "service_id", e.ServiceID) },
+		func(e CDNServiceEvent) string { return e.ServiceID }),
+	SubjectDomain: schemaOf("domain", 1, 1,
+		func(e DomainEvent) error { return required("type", e.Type, "cdn_service_id", e.CDNServiceID) },
+		func(e DomainEvent) string { return e.CDNServiceID }),
+	SubjectCache: schemaOf("cache", 1, 1,
+		func(e CacheEvent) error { return required("type", e.Type, "service_id", e.ServiceID) },
+		func(e CacheEvent) string { return e.ServiceID }),
+	SubjectMetrics: schemaOf("metrics", 1, 1,
+		func(e MetricsEvent) error { return required("service_id", e.ServiceID) },
+		func(e MetricsEvent) string { return e.ServiceID }),
+	SubjectOperation: schemaOf("operation", 1, 1,
+		func(e OperationEvent) error { return required("type", e.Type, "operation_id", e.OperationID) },
+		func(e OperationEvent) string { return e.OperationID }),
+	SubjectChat:         chatSchema,
+	SubjectChatResponse: chatSchema,
+	SubjectNotification: schemaOf("notification", 1, 1,
+		func(e NotificationEvent) error { return required("type", e.Type) },
+		func(e NotificationEvent) string { return e.ServiceID }),
+	SubjectExecutionPlan:      executionPlanSchema,
+	"cdnbuddy.execution.plan": executionPlanSchema, // where PublishExecutionPlan sends them
+	SubjectExecute: schemaOf("execute_command", 1, 1,
+		func(e ExecuteCommand) error { return required("plan_id", e.PlanID) },
+		func(e ExecuteCommand) string { return cmp.Or(e.OperationID, e.PlanID) }),
+	SubjectStatusRequest: statusRequestSchema,
+	"cdn.status.request": statusRequestSchema, // where the socket server sends them
+	SubjectStatusResponse: schemaOf("status_response", 1, 1,
+		func(e StatusResponseEvent) error { return required("session_id", e.SessionID) },
+		func(e StatusResponseEvent) string { return e.SessionID }),
+	SubjectIntentValidation: schemaOf("intent_validation", 1, 1,
+		func(e IntentValidationEvent) error { return required("session_id", e.SessionID) },
+		func(e IntentValidationEvent) string { return e.SessionID }),
+	SubjectSessionReset: schemaOf("session_reset", 1, 1,
+		func(e SessionResetEvent) error { return required("session_id", e.SessionID) },
+		func(e SessionResetEvent) string { return e.SessionID }),
+}
+
+// Seal wraps an event published on subject in an envelope. Events of subjects
+// without a schema are typed by their subject.
+func Seal(subject string, event interface{}) (Envelope, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return Envelope{}, fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	env := Envelope{EventType: subject, SchemaVersion: 1, SentAt: time.Now(), Payload: payload}
+	if s, ok := schemas[subject]; ok {
+		env.EventType, env.SchemaVersion = s.EventType, s.Version
+		env.CorrelationID = s.correlate(event)
+	}
+	return env, nil
+}
+
+// Open returns the payload of a message received on subject, checked against
+// the subject's schema. data may be an envelope or a bare event.
+func Open(subject string, data []byte) ([]byte, Envelope, error) {
+	var env Envelope
+	if err := json.Unmarshal(data, &env); err != nil || env.SchemaVersion == 0 || env.EventType == "" || len(env.Payload) == 0 {
+		// No envelope: a peer that predates it
+		env = Envelope{Payload: data}
+	}
+
+	s, ok := schemas[subject]
+	if !ok {
+		return env.Payload, env, nil
+	}
+	if env.EventType != "" {
+		if env.EventType != s.EventType {
+			return nil, env, fmt.Errorf("%w %q, want %q", ErrUnknownEventType, env.EventType, s.EventType)
+		}
+		if env.SchemaVersion < s.MinVersion || env.SchemaVersion > s.Version {
+			return nil, env, fmt.Errorf("%w %d of %s (supported %d-%d)", ErrUnsupportedVersion, env.SchemaVersion, s.EventType, s.MinVersion, s.Version)
+		}
+	}
+	if err := s.check(env.Payload); err != nil {
+		return nil, env, fmt.Errorf("invalid %s event: %w", s.EventType, err)
+	}
+	return env.Payload, env, nil
+}
@@ -0,0 +1,97 @@
+package messaging_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/avvvet/cdnbuddy-api/internal/services/messaging"
+	"github.com/avvvet/cdnbuddy-api/internal/testutil"
+)
+
+func TestOpenEnvelope(t *testing.T) {
+	cmd := testutil.ExecuteCommand("user-1", "session-1", "plan-1")
+	bare, _ := json.Marshal(cmd)
+	sealed, err := messaging.Seal(messaging.SubjectExecute, cmd)
+	if err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+	envelope := func(mutate func(env *messaging.Envelope)) []byte {
+		env := sealed
+		mutate(&env)
+		data, _ := json.Marshal(env)
+		return data
+	}
+
+	tests := []struct {
+		name            string
+		subject         string
+		data            []byte
+		wantErr         error // nil = any error when wantRejected
+		wantRejected    bool
+		wantCorrelation string
+	}{
+		{
+			name:            "current version",
+			subject:         messaging.SubjectExecute,
+			data:            envelope(func(env *messaging.Envelope) {}),
+			wantCorrelation: "plan-1",
+		},
+		{
+			name:    "bare event from a peer without envelopes",
+			subject: messaging.SubjectExecute,
+			data:    bare,
+		},
+		{
+			name:         "newer version",
+			subject:      messaging.SubjectExecute,
+			data:         envelope(func(env *messaging.Envelope) { env.SchemaVersion = 2 }),
+			wantErr:      messaging.ErrUnsupportedVersion,
+			wantRejected: true,
+		},
+		{
+			name:         "another event type",
+			subject:      messaging.SubjectExecute,
+			data:         envelope(func(env *messaging.Envelope) { env.EventType = "chat" }),
+			wantErr:      messaging.ErrUnknownEventType,
+			wantRejected: true,
+		},
+		{
+			name:         "missing plan",
+			subject:      messaging.SubjectExecute,
+			data:         []byte(`{"user_id": "user-1", "session_id": "session-1"}`),
+			wantRejected: true,
+		},
+		{
+			name:    "subject without a schema",
+			subject: "cdnbuddy.custom",
+			data:    bare,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload, env, err := messaging.Open(tt.subject, tt.data)
+			if tt.wantRejected {
+				if err == nil || tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("open: %v", err)
+			}
+
+			var got messaging.ExecuteCommand
+			if err := json.Unmarshal(payload, &got); err != nil {
+				t.Fatalf("decode payload: %v", err)
+			}
+			if got.PlanID != cmd.PlanID || got.UserID != cmd.UserID {
+				t.Errorf("payload = %+v, want %+v", got, cmd)
+			}
+			if env.CorrelationID != tt.wantCorrelation {
+				t.Errorf("correlation ID = %q, want %q", env.CorrelationID, tt.wantCorrelation)
+			}
+		})
+	}
+}
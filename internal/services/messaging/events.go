@@ -12,12 +12,15 @@ const (
 	SubjectChat       = "cdnbuddy.chat"
 
 	SubjectExecutionPlan  = "cdnbuddy.execution_plan"
+	SubjectExecute        = "cdnbuddy.execute" // confirmed plans to run
 	SubjectStatusRequest  = "cdnbuddy.status.request"
 	SubjectStatusResponse = "cdnbuddy.status.response"
 
 	SubjectChatResponse = "cdnbuddy.chat.response" // For AI responses
 	SubjectNotification = "cdnbuddy.notification"  // For notifications
 
+	SubjectIntentValidation = "intent.validation_error" // READY intents whose parameters failed validation
+	SubjectSessionReset     = "intent.session_reset"    // conversations cleared by their user
 )
 
 // Event Types
@@ -33,8 +36,9 @@ const (
 	EventDomainStatusChanged = "domain.status_changed"
 
 	// Cache Events
-	EventCachePurged       = "cache.purged"
-	EventCacheRulesUpdated = "cache.rules_updated"
+	EventCachePurged         = "cache.purged"
+	EventCacheRulesUpdated   = "cache.rules_updated"
+	EventCachePurgeCompleted = "cache.purge_completed" // the provider reported the purge finished
 
 	// Metrics Events
 	EventMetricsUpdated = "metrics.updated"
@@ -51,6 +55,14 @@ const (
 
 	// Execution Plan Events
 	EventExecutionPlan = "execution_plan.created"
+
+	// Notification Events
+	EventTTLReviewReminder        = "notification.ttl_review"
+	EventScheduledChangeReminder  = "notification.scheduled_change.reminder"
+	EventScheduledChangeCompleted = "notification.scheduled_change.completed"
+	EventScheduledChangeFailed    = "notification.scheduled_change.failed"
+	EventComplianceDrift          = "notification.compliance_drift"
+	EventChangesPaused            = "notification.changes_paused"
 )
 
 // CDN Service Events
@@ -103,6 +115,7 @@ type OperationEvent struct {
 	OperationID string                 `json:"operation_id"`
 	ServiceID   string                 `json:"service_id"`
 	UserID      string                 `json:"user_id"`
+	SessionID   string                 `json:"session_id,omitempty"`
 	OpType      string                 `json:"op_type"`
 	Status      string                 `json:"status"`
 	Progress    string                 `json:"progress,omitempty"`
@@ -117,14 +130,26 @@ type ChatEvent struct {
 	Type      string    `json:"type"`
 	UserID    string    `json:"user_id"`
 	SessionID string    `json:"session_id"`
+	SandboxID string    `json:"sandbox_id,omitempty"` // set when chatting with a demo tenant
 	Message   string    `json:"message"`
+	Source    string    `json:"source,omitempty"` // "voice" for transcribed voice notes
 	Timestamp time.Time `json:"timestamp"`
+
+	// Set by the socket server for messages from the embedded chat widget
+	WidgetToken string `json:"widget_token,omitempty"`
+	Origin      string `json:"origin,omitempty"` // page the widget is embedded on
+
+	// Structured content shown with the message (AI responses only)
+	Attachments []Attachment `json:"attachments,omitempty"`
 }
 
 // StatusRequestEvent is received from Socket Server
 type StatusRequestEvent struct {
 	UserID    string    `json:"user_id"`
 	SessionID string    `json:"session_id"`
+	SandboxID string    `json:"sandbox_id,omitempty"`
+	Provider  string    `json:"provider,omitempty"` // only list this provider's services
+	Status    string    `json:"status,omitempty"`   // ACTIVE (default), INACTIVE or ALL
 	Timestamp time.Time `json:"timestamp"`
 }
 
@@ -0,0 +1,302 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/segmentio/kafka-go"
+)
+
+const (
+	kafkaReplyHeader = "reply"
+	// kafkaReplySep separates the inbox topic from the correlation ID in a reply
+	// subject; it is not a valid topic character so the split is unambiguous
+	kafkaReplySep = "|"
+)
+
+// KafkaBus is the Kafka implementation of Bus. Each subject maps to one topic.
+// Plain subscriptions get a consumer group of this replica (fan-out), named
+// after its consumer name so a restart rejoins it instead of leaving an
+// abandoned group; queue subscriptions share a consumer group named after the
+// queue. Requests are answered on the replica's inbox topic and matched by key.
+type KafkaBus struct {
+	brokers      []string
+	writer       *kafka.Writer
+	consumerName string
+	ctx          context.Context
+	cancel       context.CancelFunc
+	wg           sync.WaitGroup
+
+	inbox     string
+	pending   map[string]chan *Message
+	pendingMu sync.Mutex
+}
+
+var (
+	_ Bus        = (*KafkaBus)(nil)
+	_ DurableBus = (*KafkaBus)(nil)
+)
+
+type kafkaSubscription struct {
+	cancel context.CancelFunc
+}
+
+func (s *kafkaSubscription) Unsubscribe() error {
+	s.cancel()
+	return nil
+}
+
+// NewKafkaBus creates a Kafka bus on the given brokers. consumerName
+// (DefaultConsumerName if empty) names this replica's consumer groups and
+// inbox; like BusConfig.ConsumerName it must be distinct per replica.
+func NewKafkaBus(brokers []string, consumerName string) (*KafkaBus, error) {
+	if len(brokers) == 0 || brokers[0] == "" {
+		return nil, errors.New("at least one Kafka broker is required")
+	}
+
+	conn, err := kafka.Dial("tcp", brokers[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Kafka: %w", err)
+	}
+	conn.Close()
+
+	if consumerName == "" {
+		consumerName = DefaultConsumerName
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	b := &KafkaBus{
+		brokers: brokers,
+		writer: &kafka.Writer{
+			Addr:                   kafka.TCP(brokers...),
+			Balancer:               &kafka.Hash{},
+			AllowAutoTopicCreation: true,
+			BatchTimeout:           10 * time.Millisecond,
+		},
+		consumerName: consumerName,
+		ctx:          ctx,
+		cancel:       cancel,
+		inbox:        inboxPrefix + consumerName,
+		pending:      make(map[string]chan *Message),
+	}
+
+	// Join the inbox group up front: a new group starts at the log end, so a
+	// reader started lazily on the first request could miss its reply
+	if _, err := b.consume(b.inbox, b.inbox, b.dispatchReply); err != nil {
+		b.Close()
+		return nil, fmt.Errorf("failed to start Kafka inbox: %w", err)
+	}
+
+	log.Printf("✅ Connected to Kafka at %s", strings.Join(brokers, ","))
+	return b, nil
+}
+
+func (b *KafkaBus) Close() {
+	b.cancel()
+	b.wg.Wait()
+	b.writer.Close()
+	log.Printf("🔒 Kafka bus closed")
+}
+
+func (b *KafkaBus) Publish(subject string, data interface{}) error {
+	return b.PublishWithReply(subject, "", data)
+}
+
+func (b *KafkaBus) PublishWithReply(subject, reply string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return b.write(topicName(subject), "", reply, payload)
+}
+
+func (b *KafkaBus) Subscribe(subject string, handler func(msg *Message)) (Subscription, error) {
+	return b.consume(topicName(subject), b.subscriptionGroup(subject), func(m kafka.Message) {
+		handler(kafkaMessage(subject, m))
+	})
+}
+
+// subscriptionGroup names the consumer group of this replica's plain
+// subscription to subject; it is the same after a restart
+func (b *KafkaBus) subscriptionGroup(subject string) string {
+	return b.consumerName + "-" + topicName(subject)
+}
+
+func (b *KafkaBus) QueueSubscribe(subject, queue string, handler func(msg *Message)) (Subscription, error) {
+	return b.consume(topicName(subject), queue, func(m kafka.Message) {
+		handler(kafkaMessage(subject, m))
+	})
+}
+
+// SubscribeDurable reads the subject in a consumer group named after the
+// consumer; its offsets are committed after handling, so a restarted consumer
+// resumes at the first message it didn't finish. Messages whose handler
+// failed are committed as well, since later ones of the partition would
+// commit past them anyway.
+func (b *KafkaBus) SubscribeDurable(subject, consumer string, handler func(msg *Message) error) (Subscription, error) {
+	return b.consume(topicName(subject), consumer, func(m kafka.Message) {
+		if err := handler(kafkaMessage(subject, m)); err != nil {
+			log.Printf("❌ Handler failed on %s, not retried on Kafka: %v", subject, err)
+		}
+	})
+}
+
+// Request publishes with this process's inbox as reply subject and waits for the matching reply
+func (b *KafkaBus) Request(subject string, data interface{}, timeout time.Duration) (*Message, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	correlationID := uuid.New().String()
+	replyCh := make(chan *Message, 1)
+
+	b.pendingMu.Lock()
+	b.pending[correlationID] = replyCh
+	b.pendingMu.Unlock()
+
+	defer func() {
+		b.pendingMu.Lock()
+		delete(b.pending, correlationID)
+		b.pendingMu.Unlock()
+	}()
+
+	if err := b.write(topicName(subject), "", b.inbox+kafkaReplySep+correlationID, payload); err != nil {
+		return nil, err
+	}
+
+	select {
+	case msg := <-replyCh:
+		return msg, nil
+	case <-time.After(timeout):
+		return nil, ErrRequestTimeout
+	case <-b.ctx.Done():
+		return nil, b.ctx.Err()
+	}
+}
+
+func (b *KafkaBus) Respond(msg *Message, data []byte) error {
+	if msg.Reply == "" {
+		return errors.New("message has no reply subject")
+	}
+
+	topic, key, _ := strings.Cut(msg.Reply, kafkaReplySep)
+	return b.write(topicName(topic), key, "", data)
+}
+
+func (b *KafkaBus) IsConnected() bool {
+	conn, err := kafka.DialContext(b.ctx, "tcp", b.brokers[0])
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+// Ping asks the first broker for the cluster controller, a metadata round trip
+func (b *KafkaBus) Ping(ctx context.Context) error {
+	conn, err := kafka.DialContext(ctx, "tcp", b.brokers[0])
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	_, err = conn.Controller()
+	return err
+}
+
+func (b *KafkaBus) Stats() map[string]interface{} {
+	stats := b.writer.Stats()
+	return map[string]interface{}{
+		"backend":   BackendKafka,
+		"connected": b.IsConnected(),
+		"url":       strings.Join(b.brokers, ","),
+		"writes":    stats.Writes,
+		"errors":    stats.Errors,
+	}
+}
+
+func (b *KafkaBus) write(topic, key, reply string, payload []byte) error {
+	msg := kafka.Message{Topic: topic, Value: payload}
+	if key != "" {
+		msg.Key = []byte(key)
+	}
+	if reply != "" {
+		msg.Headers = []kafka.Header{{Key: kafkaReplyHeader, Value: []byte(reply)}}
+	}
+
+	return b.writer.WriteMessages(b.ctx, msg)
+}
+
+// consume reads a topic in the given consumer group until unsubscribed or
+// closed, committing each message's offset once handled
+func (b *KafkaBus) consume(topic, groupID string, handler func(m kafka.Message)) (Subscription, error) {
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:     b.brokers,
+		GroupID:     groupID,
+		Topic:       topic,
+		StartOffset: kafka.LastOffset,
+		MaxWait:     time.Second,
+	})
+
+	ctx, cancel := context.WithCancel(b.ctx)
+
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		defer reader.Close()
+		for {
+			m, err := reader.FetchMessage(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				log.Printf("❌ Kafka read failed on %s: %v", topic, err)
+				time.Sleep(time.Second)
+				continue
+			}
+			handler(m)
+			if err := reader.CommitMessages(ctx, m); err != nil && ctx.Err() == nil {
+				log.Printf("❌ Kafka commit failed on %s: %v", topic, err)
+			}
+		}
+	}()
+
+	log.Printf("📥 Kafka topic subscribed: %s (group: %s)", topic, groupID)
+	return &kafkaSubscription{cancel: cancel}, nil
+}
+
+// dispatchReply hands an inbox message to the request waiting on its correlation ID
+func (b *KafkaBus) dispatchReply(m kafka.Message) {
+	b.pendingMu.Lock()
+	replyCh, ok := b.pending[string(m.Key)]
+	b.pendingMu.Unlock()
+	if !ok {
+		return
+	}
+
+	// Only the first reply is delivered; late or duplicate replies are dropped
+	select {
+	case replyCh <- &Message{Subject: b.inbox, Data: m.Value}:
+	default:
+	}
+}
+
+func kafkaMessage(subject string, m kafka.Message) *Message {
+	msg := &Message{Subject: subject, Data: m.Value}
+	for _, h := range m.Headers {
+		if h.Key == kafkaReplyHeader {
+			msg.Reply = string(h.Value)
+		}
+	}
+	return msg
+}
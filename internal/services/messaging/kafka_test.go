@@ -0,0 +1,77 @@
+package messaging
+
+import (
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+)
+
+func TestKafkaSubscriptionGroup(t *testing.T) {
+	tests := []struct {
+		name      string
+		consumer  string
+		other     string // consumer name of the replica compared with
+		wantSame  bool
+		wantGroup string
+	}{
+		{name: "restarted replica rejoins its group", consumer: "replica-1", other: "replica-1", wantSame: true, wantGroup: "replica-1-cdnbuddy.chat"},
+		{name: "replicas get their own groups", consumer: "replica-1", other: "replica-2", wantSame: false, wantGroup: "replica-1-cdnbuddy.chat"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			group := (&KafkaBus{consumerName: tt.consumer}).subscriptionGroup(SubjectChat)
+			other := (&KafkaBus{consumerName: tt.other}).subscriptionGroup(SubjectChat)
+			if group != tt.wantGroup {
+				t.Errorf("group = %q, want %q", group, tt.wantGroup)
+			}
+			if (group == other) != tt.wantSame {
+				t.Errorf("groups %q and %q, want same %v", group, other, tt.wantSame)
+			}
+		})
+	}
+}
+
+func TestKafkaReplies(t *testing.T) {
+	msg := kafkaMessage(SubjectStatusRequest, kafka.Message{
+		Value:   []byte(`{"session_id":"s-1"}`),
+		Headers: []kafka.Header{{Key: kafkaReplyHeader, Value: []byte("_INBOX.replica-1|req-1")}},
+	})
+	if msg.Subject != SubjectStatusRequest || msg.Reply != "_INBOX.replica-1|req-1" || string(msg.Data) != `{"session_id":"s-1"}` {
+		t.Fatalf("message = %+v", msg)
+	}
+
+	b := &KafkaBus{inbox: "_INBOX.replica-1", pending: make(map[string]chan *Message)}
+	waiting := make(chan *Message, 1)
+	b.pending["req-1"] = waiting
+
+	// The request hasn't read its reply yet, so the channel stays full
+	tests := []struct {
+		name       string
+		key        string
+		wantQueued int
+	}{
+		{name: "reply to a waiting request", key: "req-1", wantQueued: 1},
+		{name: "duplicate reply is dropped", key: "req-1", wantQueued: 1},
+		{name: "reply to an unknown request is dropped", key: "req-2", wantQueued: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b.dispatchReply(kafka.Message{Key: []byte(tt.key), Value: []byte(`"pong"`)})
+			if len(waiting) != tt.wantQueued {
+				t.Errorf("queued replies = %d, want %d", len(waiting), tt.wantQueued)
+			}
+		})
+	}
+	if reply := <-waiting; string(reply.Data) != `"pong"` || reply.Subject != b.inbox {
+		t.Errorf("reply = %+v", reply)
+	}
+}
+
+func TestNewKafkaBusRequiresBrokers(t *testing.T) {
+	for _, brokers := range [][]string{nil, {""}} {
+		if _, err := NewKafkaBus(brokers, "replica-1"); err == nil {
+			t.Errorf("NewKafkaBus(%q) succeeded", brokers)
+		}
+	}
+}
@@ -0,0 +1,1327 @@
+// Protobuf encoding of the messages exchanged with the socket server and the
+// intent service, used when MESSAGING_ENCODING=protobuf (NATS only).
+//
+// A message is sent with the header
+//   Content-Type: application/x-protobuf; proto=cdnbuddy.messaging.v1.<Message>
+// and anything without one is JSON. Go types are generated into ../messagingpb
+// (go generate ./internal/services/messaging); never reuse a field number.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        (unknown)
+// source: messaging.proto
+
+package messagingpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Envelope wraps published events; see Envelope in envelope.go
+type Envelope struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	EventType     string                 `protobuf:"bytes,1,opt,name=event_type,json=eventType,proto3" json:"event_type,omitempty"`
+	SchemaVersion int64                  `protobuf:"varint,2,opt,name=schema_version,json=schemaVersion,proto3" json:"schema_version,omitempty"`
+	CorrelationId string                 `protobuf:"bytes,3,opt,name=correlation_id,json=correlationId,proto3" json:"correlation_id,omitempty"`
+	SentAt        *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=sent_at,json=sentAt,proto3" json:"sent_at,omitempty"`
+	// Types that are valid to be assigned to Payload:
+	//
+	//	*Envelope_Json
+	//	*Envelope_Chat
+	//	*Envelope_Operation
+	Payload       isEnvelope_Payload `protobuf_oneof:"payload"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Envelope) Reset() {
+	*x = Envelope{}
+	mi := &file_messaging_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Envelope) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Envelope) ProtoMessage() {}
+
+func (x *Envelope) ProtoReflect() protoreflect.Message {
+	mi := &file_messaging_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Envelope.ProtoReflect.Descriptor instead.
+func (*Envelope) Descriptor() ([]byte, []int) {
+	return file_messaging_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Envelope) GetEventType() string {
+	if x != nil {
+		return x.EventType
+	}
+	return ""
+}
+
+func (x *Envelope) GetSchemaVersion() int64 {
+	if x != nil {
+		return x.SchemaVersion
+	}
+	return 0
+}
+
+func (x *Envelope) GetCorrelationId() string {
+	if x != nil {
+		return x.CorrelationId
+	}
+	return ""
+}
+
+func (x *Envelope) GetSentAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.SentAt
+	}
+	return nil
+}
+
+func (x *Envelope) GetPayload() isEnvelope_Payload {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *Envelope) GetJson() []byte {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_Json); ok {
+			return x.Json
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetChat() *ChatEvent {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_Chat); ok {
+			return x.Chat
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetOperation() *OperationEvent {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_Operation); ok {
+			return x.Operation
+		}
+	}
+	return nil
+}
+
+type isEnvelope_Payload interface {
+	isEnvelope_Payload()
+}
+
+type Envelope_Json struct {
+	Json []byte `protobuf:"bytes,5,opt,name=json,proto3,oneof"` // events without a message below, as JSON
+}
+
+type Envelope_Chat struct {
+	Chat *ChatEvent `protobuf:"bytes,6,opt,name=chat,proto3,oneof"`
+}
+
+type Envelope_Operation struct {
+	Operation *OperationEvent `protobuf:"bytes,7,opt,name=operation,proto3,oneof"`
+}
+
+func (*Envelope_Json) isEnvelope_Payload() {}
+
+func (*Envelope_Chat) isEnvelope_Payload() {}
+
+func (*Envelope_Operation) isEnvelope_Payload() {}
+
+type ChatEvent struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Type            string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	UserId          string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	SessionId       string                 `protobuf:"bytes,3,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	SandboxId       string                 `protobuf:"bytes,4,opt,name=sandbox_id,json=sandboxId,proto3" json:"sandbox_id,omitempty"`
+	Message         string                 `protobuf:"bytes,5,opt,name=message,proto3" json:"message,omitempty"`
+	Source          string                 `protobuf:"bytes,6,opt,name=source,proto3" json:"source,omitempty"`
+	Timestamp       *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	WidgetToken     string                 `protobuf:"bytes,8,opt,name=widget_token,json=widgetToken,proto3" json:"widget_token,omitempty"`
+	Origin          string                 `protobuf:"bytes,9,opt,name=origin,proto3" json:"origin,omitempty"`
+	AttachmentsJson []byte                 `protobuf:"bytes,10,opt,name=attachments_json,json=attachmentsJson,proto3" json:"attachments_json,omitempty"` // []Attachment as JSON; their data is free-form
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *ChatEvent) Reset() {
+	*x = ChatEvent{}
+	mi := &file_messaging_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChatEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChatEvent) ProtoMessage() {}
+
+func (x *ChatEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_messaging_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChatEvent.ProtoReflect.Descriptor instead.
+func (*ChatEvent) Descriptor() ([]byte, []int) {
+	return file_messaging_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ChatEvent) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *ChatEvent) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *ChatEvent) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *ChatEvent) GetSandboxId() string {
+	if x != nil {
+		return x.SandboxId
+	}
+	return ""
+}
+
+func (x *ChatEvent) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *ChatEvent) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *ChatEvent) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *ChatEvent) GetWidgetToken() string {
+	if x != nil {
+		return x.WidgetToken
+	}
+	return ""
+}
+
+func (x *ChatEvent) GetOrigin() string {
+	if x != nil {
+		return x.Origin
+	}
+	return ""
+}
+
+func (x *ChatEvent) GetAttachmentsJson() []byte {
+	if x != nil {
+		return x.AttachmentsJson
+	}
+	return nil
+}
+
+type OperationEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	OperationId   string                 `protobuf:"bytes,2,opt,name=operation_id,json=operationId,proto3" json:"operation_id,omitempty"`
+	ServiceId     string                 `protobuf:"bytes,3,opt,name=service_id,json=serviceId,proto3" json:"service_id,omitempty"`
+	UserId        string                 `protobuf:"bytes,4,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	SessionId     string                 `protobuf:"bytes,5,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	OpType        string                 `protobuf:"bytes,6,opt,name=op_type,json=opType,proto3" json:"op_type,omitempty"`
+	Status        string                 `protobuf:"bytes,7,opt,name=status,proto3" json:"status,omitempty"`
+	Progress      string                 `protobuf:"bytes,8,opt,name=progress,proto3" json:"progress,omitempty"`
+	Error         string                 `protobuf:"bytes,9,opt,name=error,proto3" json:"error,omitempty"`
+	ParamsJson    []byte                 `protobuf:"bytes,10,opt,name=params_json,json=paramsJson,proto3" json:"params_json,omitempty"` // free-form JSON object
+	ResultJson    []byte                 `protobuf:"bytes,11,opt,name=result_json,json=resultJson,proto3" json:"result_json,omitempty"` // free-form JSON object
+	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,12,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OperationEvent) Reset() {
+	*x = OperationEvent{}
+	mi := &file_messaging_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OperationEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OperationEvent) ProtoMessage() {}
+
+func (x *OperationEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_messaging_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OperationEvent.ProtoReflect.Descriptor instead.
+func (*OperationEvent) Descriptor() ([]byte, []int) {
+	return file_messaging_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *OperationEvent) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *OperationEvent) GetOperationId() string {
+	if x != nil {
+		return x.OperationId
+	}
+	return ""
+}
+
+func (x *OperationEvent) GetServiceId() string {
+	if x != nil {
+		return x.ServiceId
+	}
+	return ""
+}
+
+func (x *OperationEvent) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *OperationEvent) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *OperationEvent) GetOpType() string {
+	if x != nil {
+		return x.OpType
+	}
+	return ""
+}
+
+func (x *OperationEvent) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *OperationEvent) GetProgress() string {
+	if x != nil {
+		return x.Progress
+	}
+	return ""
+}
+
+func (x *OperationEvent) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *OperationEvent) GetParamsJson() []byte {
+	if x != nil {
+		return x.ParamsJson
+	}
+	return nil
+}
+
+func (x *OperationEvent) GetResultJson() []byte {
+	if x != nil {
+		return x.ResultJson
+	}
+	return nil
+}
+
+func (x *OperationEvent) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+type StatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	SessionId     string                 `protobuf:"bytes,2,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatusRequest) Reset() {
+	*x = StatusRequest{}
+	mi := &file_messaging_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatusRequest) ProtoMessage() {}
+
+func (x *StatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_messaging_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatusRequest.ProtoReflect.Descriptor instead.
+func (*StatusRequest) Descriptor() ([]byte, []int) {
+	return file_messaging_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *StatusRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *StatusRequest) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *StatusRequest) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+type StatusResponse struct {
+	state         protoimpl.MessageState   `protogen:"open.v1"`
+	Provider      string                   `protobuf:"bytes,1,opt,name=provider,proto3" json:"provider,omitempty"`
+	Domains       []*StatusResponse_Domain `protobuf:"bytes,2,rep,name=domains,proto3" json:"domains,omitempty"`
+	Metrics       *StatusResponse_Metrics  `protobuf:"bytes,3,opt,name=metrics,proto3" json:"metrics,omitempty"`
+	Error         string                   `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatusResponse) Reset() {
+	*x = StatusResponse{}
+	mi := &file_messaging_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatusResponse) ProtoMessage() {}
+
+func (x *StatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_messaging_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatusResponse.ProtoReflect.Descriptor instead.
+func (*StatusResponse) Descriptor() ([]byte, []int) {
+	return file_messaging_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *StatusResponse) GetProvider() string {
+	if x != nil {
+		return x.Provider
+	}
+	return ""
+}
+
+func (x *StatusResponse) GetDomains() []*StatusResponse_Domain {
+	if x != nil {
+		return x.Domains
+	}
+	return nil
+}
+
+func (x *StatusResponse) GetMetrics() *StatusResponse_Metrics {
+	if x != nil {
+		return x.Metrics
+	}
+	return nil
+}
+
+func (x *StatusResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type IntentRequest struct {
+	state               protoimpl.MessageState               `protogen:"open.v1"`
+	SessionId           string                               `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	UserMessage         string                               `protobuf:"bytes,2,opt,name=user_message,json=userMessage,proto3" json:"user_message,omitempty"`
+	ConversationHistory []*IntentRequest_ConversationMessage `protobuf:"bytes,3,rep,name=conversation_history,json=conversationHistory,proto3" json:"conversation_history,omitempty"`
+	Summary             string                               `protobuf:"bytes,4,opt,name=summary,proto3" json:"summary,omitempty"`
+	AvailableActions    []*IntentRequest_ActionSchema        `protobuf:"bytes,5,rep,name=available_actions,json=availableActions,proto3" json:"available_actions,omitempty"`
+	Context             *IntentRequest_Context               `protobuf:"bytes,6,opt,name=context,proto3" json:"context,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *IntentRequest) Reset() {
+	*x = IntentRequest{}
+	mi := &file_messaging_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IntentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IntentRequest) ProtoMessage() {}
+
+func (x *IntentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_messaging_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IntentRequest.ProtoReflect.Descriptor instead.
+func (*IntentRequest) Descriptor() ([]byte, []int) {
+	return file_messaging_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *IntentRequest) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *IntentRequest) GetUserMessage() string {
+	if x != nil {
+		return x.UserMessage
+	}
+	return ""
+}
+
+func (x *IntentRequest) GetConversationHistory() []*IntentRequest_ConversationMessage {
+	if x != nil {
+		return x.ConversationHistory
+	}
+	return nil
+}
+
+func (x *IntentRequest) GetSummary() string {
+	if x != nil {
+		return x.Summary
+	}
+	return ""
+}
+
+func (x *IntentRequest) GetAvailableActions() []*IntentRequest_ActionSchema {
+	if x != nil {
+		return x.AvailableActions
+	}
+	return nil
+}
+
+func (x *IntentRequest) GetContext() *IntentRequest_Context {
+	if x != nil {
+		return x.Context
+	}
+	return nil
+}
+
+type IntentResponse struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	SessionId         string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	Action            *string                `protobuf:"bytes,2,opt,name=action,proto3,oneof" json:"action,omitempty"`
+	Status            string                 `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`                                                                                   // NEEDS_INFO, READY or ERROR
+	Parameters        map[string]string      `protobuf:"bytes,4,rep,name=parameters,proto3" json:"parameters,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // known parameters
+	MissingParameters []string               `protobuf:"bytes,5,rep,name=missing_parameters,json=missingParameters,proto3" json:"missing_parameters,omitempty"`                                    // parameters still without a value
+	UserMessage       string                 `protobuf:"bytes,6,opt,name=user_message,json=userMessage,proto3" json:"user_message,omitempty"`
+	ErrorCode         *string                `protobuf:"bytes,7,opt,name=error_code,json=errorCode,proto3,oneof" json:"error_code,omitempty"`
+	ErrorMessage      *string                `protobuf:"bytes,8,opt,name=error_message,json=errorMessage,proto3,oneof" json:"error_message,omitempty"`
+	Usage             *IntentResponse_Usage  `protobuf:"bytes,9,opt,name=usage,proto3" json:"usage,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *IntentResponse) Reset() {
+	*x = IntentResponse{}
+	mi := &file_messaging_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IntentResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IntentResponse) ProtoMessage() {}
+
+func (x *IntentResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_messaging_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IntentResponse.ProtoReflect.Descriptor instead.
+func (*IntentResponse) Descriptor() ([]byte, []int) {
+	return file_messaging_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *IntentResponse) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *IntentResponse) GetAction() string {
+	if x != nil && x.Action != nil {
+		return *x.Action
+	}
+	return ""
+}
+
+func (x *IntentResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *IntentResponse) GetParameters() map[string]string {
+	if x != nil {
+		return x.Parameters
+	}
+	return nil
+}
+
+func (x *IntentResponse) GetMissingParameters() []string {
+	if x != nil {
+		return x.MissingParameters
+	}
+	return nil
+}
+
+func (x *IntentResponse) GetUserMessage() string {
+	if x != nil {
+		return x.UserMessage
+	}
+	return ""
+}
+
+func (x *IntentResponse) GetErrorCode() string {
+	if x != nil && x.ErrorCode != nil {
+		return *x.ErrorCode
+	}
+	return ""
+}
+
+func (x *IntentResponse) GetErrorMessage() string {
+	if x != nil && x.ErrorMessage != nil {
+		return *x.ErrorMessage
+	}
+	return ""
+}
+
+func (x *IntentResponse) GetUsage() *IntentResponse_Usage {
+	if x != nil {
+		return x.Usage
+	}
+	return nil
+}
+
+type StatusResponse_Domain struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Status        string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	Regions       int64                  `protobuf:"varint,3,opt,name=regions,proto3" json:"regions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatusResponse_Domain) Reset() {
+	*x = StatusResponse_Domain{}
+	mi := &file_messaging_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatusResponse_Domain) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatusResponse_Domain) ProtoMessage() {}
+
+func (x *StatusResponse_Domain) ProtoReflect() protoreflect.Message {
+	mi := &file_messaging_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatusResponse_Domain.ProtoReflect.Descriptor instead.
+func (*StatusResponse_Domain) Descriptor() ([]byte, []int) {
+	return file_messaging_proto_rawDescGZIP(), []int{4, 0}
+}
+
+func (x *StatusResponse_Domain) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *StatusResponse_Domain) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *StatusResponse_Domain) GetRegions() int64 {
+	if x != nil {
+		return x.Regions
+	}
+	return 0
+}
+
+type StatusResponse_Metrics struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	CacheHitRatio   string                 `protobuf:"bytes,1,opt,name=cache_hit_ratio,json=cacheHitRatio,proto3" json:"cache_hit_ratio,omitempty"`
+	AvgResponseTime string                 `protobuf:"bytes,2,opt,name=avg_response_time,json=avgResponseTime,proto3" json:"avg_response_time,omitempty"`
+	TotalRequests   string                 `protobuf:"bytes,3,opt,name=total_requests,json=totalRequests,proto3" json:"total_requests,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *StatusResponse_Metrics) Reset() {
+	*x = StatusResponse_Metrics{}
+	mi := &file_messaging_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatusResponse_Metrics) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatusResponse_Metrics) ProtoMessage() {}
+
+func (x *StatusResponse_Metrics) ProtoReflect() protoreflect.Message {
+	mi := &file_messaging_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatusResponse_Metrics.ProtoReflect.Descriptor instead.
+func (*StatusResponse_Metrics) Descriptor() ([]byte, []int) {
+	return file_messaging_proto_rawDescGZIP(), []int{4, 1}
+}
+
+func (x *StatusResponse_Metrics) GetCacheHitRatio() string {
+	if x != nil {
+		return x.CacheHitRatio
+	}
+	return ""
+}
+
+func (x *StatusResponse_Metrics) GetAvgResponseTime() string {
+	if x != nil {
+		return x.AvgResponseTime
+	}
+	return ""
+}
+
+func (x *StatusResponse_Metrics) GetTotalRequests() string {
+	if x != nil {
+		return x.TotalRequests
+	}
+	return ""
+}
+
+type IntentRequest_ConversationMessage struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Role          string                 `protobuf:"bytes,1,opt,name=role,proto3" json:"role,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IntentRequest_ConversationMessage) Reset() {
+	*x = IntentRequest_ConversationMessage{}
+	mi := &file_messaging_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IntentRequest_ConversationMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IntentRequest_ConversationMessage) ProtoMessage() {}
+
+func (x *IntentRequest_ConversationMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_messaging_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IntentRequest_ConversationMessage.ProtoReflect.Descriptor instead.
+func (*IntentRequest_ConversationMessage) Descriptor() ([]byte, []int) {
+	return file_messaging_proto_rawDescGZIP(), []int{5, 0}
+}
+
+func (x *IntentRequest_ConversationMessage) GetRole() string {
+	if x != nil {
+		return x.Role
+	}
+	return ""
+}
+
+func (x *IntentRequest_ConversationMessage) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *IntentRequest_ConversationMessage) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+type IntentRequest_ActionSchema struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Action        string                 `protobuf:"bytes,1,opt,name=action,proto3" json:"action,omitempty"`
+	Parameters    []string               `protobuf:"bytes,2,rep,name=parameters,proto3" json:"parameters,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IntentRequest_ActionSchema) Reset() {
+	*x = IntentRequest_ActionSchema{}
+	mi := &file_messaging_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IntentRequest_ActionSchema) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IntentRequest_ActionSchema) ProtoMessage() {}
+
+func (x *IntentRequest_ActionSchema) ProtoReflect() protoreflect.Message {
+	mi := &file_messaging_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IntentRequest_ActionSchema.ProtoReflect.Descriptor instead.
+func (*IntentRequest_ActionSchema) Descriptor() ([]byte, []int) {
+	return file_messaging_proto_rawDescGZIP(), []int{5, 1}
+}
+
+func (x *IntentRequest_ActionSchema) GetAction() string {
+	if x != nil {
+		return x.Action
+	}
+	return ""
+}
+
+func (x *IntentRequest_ActionSchema) GetParameters() []string {
+	if x != nil {
+		return x.Parameters
+	}
+	return nil
+}
+
+type IntentRequest_StringList struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Values        []string               `protobuf:"bytes,1,rep,name=values,proto3" json:"values,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IntentRequest_StringList) Reset() {
+	*x = IntentRequest_StringList{}
+	mi := &file_messaging_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IntentRequest_StringList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IntentRequest_StringList) ProtoMessage() {}
+
+func (x *IntentRequest_StringList) ProtoReflect() protoreflect.Message {
+	mi := &file_messaging_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IntentRequest_StringList.ProtoReflect.Descriptor instead.
+func (*IntentRequest_StringList) Descriptor() ([]byte, []int) {
+	return file_messaging_proto_rawDescGZIP(), []int{5, 2}
+}
+
+func (x *IntentRequest_StringList) GetValues() []string {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+type IntentRequest_Context struct {
+	state              protoimpl.MessageState               `protogen:"open.v1"`
+	InferredParameters map[string]string                    `protobuf:"bytes,1,rep,name=inferred_parameters,json=inferredParameters,proto3" json:"inferred_parameters,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Candidates         map[string]*IntentRequest_StringList `protobuf:"bytes,2,rep,name=candidates,proto3" json:"candidates,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *IntentRequest_Context) Reset() {
+	*x = IntentRequest_Context{}
+	mi := &file_messaging_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IntentRequest_Context) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IntentRequest_Context) ProtoMessage() {}
+
+func (x *IntentRequest_Context) ProtoReflect() protoreflect.Message {
+	mi := &file_messaging_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IntentRequest_Context.ProtoReflect.Descriptor instead.
+func (*IntentRequest_Context) Descriptor() ([]byte, []int) {
+	return file_messaging_proto_rawDescGZIP(), []int{5, 3}
+}
+
+func (x *IntentRequest_Context) GetInferredParameters() map[string]string {
+	if x != nil {
+		return x.InferredParameters
+	}
+	return nil
+}
+
+func (x *IntentRequest_Context) GetCandidates() map[string]*IntentRequest_StringList {
+	if x != nil {
+		return x.Candidates
+	}
+	return nil
+}
+
+type IntentResponse_Usage struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	PromptTokens     int64                  `protobuf:"varint,1,opt,name=prompt_tokens,json=promptTokens,proto3" json:"prompt_tokens,omitempty"`
+	CompletionTokens int64                  `protobuf:"varint,2,opt,name=completion_tokens,json=completionTokens,proto3" json:"completion_tokens,omitempty"`
+	TotalTokens      int64                  `protobuf:"varint,3,opt,name=total_tokens,json=totalTokens,proto3" json:"total_tokens,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *IntentResponse_Usage) Reset() {
+	*x = IntentResponse_Usage{}
+	mi := &file_messaging_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IntentResponse_Usage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IntentResponse_Usage) ProtoMessage() {}
+
+func (x *IntentResponse_Usage) ProtoReflect() protoreflect.Message {
+	mi := &file_messaging_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IntentResponse_Usage.ProtoReflect.Descriptor instead.
+func (*IntentResponse_Usage) Descriptor() ([]byte, []int) {
+	return file_messaging_proto_rawDescGZIP(), []int{6, 0}
+}
+
+func (x *IntentResponse_Usage) GetPromptTokens() int64 {
+	if x != nil {
+		return x.PromptTokens
+	}
+	return 0
+}
+
+func (x *IntentResponse_Usage) GetCompletionTokens() int64 {
+	if x != nil {
+		return x.CompletionTokens
+	}
+	return 0
+}
+
+func (x *IntentResponse_Usage) GetTotalTokens() int64 {
+	if x != nil {
+		return x.TotalTokens
+	}
+	return 0
+}
+
+var File_messaging_proto protoreflect.FileDescriptor
+
+const file_messaging_proto_rawDesc = "" +
+	"\n" +
+	"\x0fmessaging.proto\x12\x15cdnbuddy.messaging.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xcc\x02\n" +
+	"\bEnvelope\x12\x1d\n" +
+	"\n" +
+	"event_type\x18\x01 \x01(\tR\teventType\x12%\n" +
+	"\x0eschema_version\x18\x02 \x01(\x03R\rschemaVersion\x12%\n" +
+	"\x0ecorrelation_id\x18\x03 \x01(\tR\rcorrelationId\x123\n" +
+	"\asent_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\x06sentAt\x12\x14\n" +
+	"\x04json\x18\x05 \x01(\fH\x00R\x04json\x126\n" +
+	"\x04chat\x18\x06 \x01(\v2 .cdnbuddy.messaging.v1.ChatEventH\x00R\x04chat\x12E\n" +
+	"\toperation\x18\a \x01(\v2%.cdnbuddy.messaging.v1.OperationEventH\x00R\toperationB\t\n" +
+	"\apayload\"\xc8\x02\n" +
+	"\tChatEvent\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x03 \x01(\tR\tsessionId\x12\x1d\n" +
+	"\n" +
+	"sandbox_id\x18\x04 \x01(\tR\tsandboxId\x12\x18\n" +
+	"\amessage\x18\x05 \x01(\tR\amessage\x12\x16\n" +
+	"\x06source\x18\x06 \x01(\tR\x06source\x128\n" +
+	"\ttimestamp\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12!\n" +
+	"\fwidget_token\x18\b \x01(\tR\vwidgetToken\x12\x16\n" +
+	"\x06origin\x18\t \x01(\tR\x06origin\x12)\n" +
+	"\x10attachments_json\x18\n" +
+	" \x01(\fR\x0fattachmentsJson\"\xfd\x02\n" +
+	"\x0eOperationEvent\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12!\n" +
+	"\foperation_id\x18\x02 \x01(\tR\voperationId\x12\x1d\n" +
+	"\n" +
+	"service_id\x18\x03 \x01(\tR\tserviceId\x12\x17\n" +
+	"\auser_id\x18\x04 \x01(\tR\x06userId\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x05 \x01(\tR\tsessionId\x12\x17\n" +
+	"\aop_type\x18\x06 \x01(\tR\x06opType\x12\x16\n" +
+	"\x06status\x18\a \x01(\tR\x06status\x12\x1a\n" +
+	"\bprogress\x18\b \x01(\tR\bprogress\x12\x14\n" +
+	"\x05error\x18\t \x01(\tR\x05error\x12\x1f\n" +
+	"\vparams_json\x18\n" +
+	" \x01(\fR\n" +
+	"paramsJson\x12\x1f\n" +
+	"\vresult_json\x18\v \x01(\fR\n" +
+	"resultJson\x128\n" +
+	"\ttimestamp\x18\f \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\"\x81\x01\n" +
+	"\rStatusRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x02 \x01(\tR\tsessionId\x128\n" +
+	"\ttimestamp\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\"\xaa\x03\n" +
+	"\x0eStatusResponse\x12\x1a\n" +
+	"\bprovider\x18\x01 \x01(\tR\bprovider\x12F\n" +
+	"\adomains\x18\x02 \x03(\v2,.cdnbuddy.messaging.v1.StatusResponse.DomainR\adomains\x12G\n" +
+	"\ametrics\x18\x03 \x01(\v2-.cdnbuddy.messaging.v1.StatusResponse.MetricsR\ametrics\x12\x14\n" +
+	"\x05error\x18\x04 \x01(\tR\x05error\x1aN\n" +
+	"\x06Domain\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\x12\x18\n" +
+	"\aregions\x18\x03 \x01(\x03R\aregions\x1a\x84\x01\n" +
+	"\aMetrics\x12&\n" +
+	"\x0fcache_hit_ratio\x18\x01 \x01(\tR\rcacheHitRatio\x12*\n" +
+	"\x11avg_response_time\x18\x02 \x01(\tR\x0favgResponseTime\x12%\n" +
+	"\x0etotal_requests\x18\x03 \x01(\tR\rtotalRequests\"\x85\b\n" +
+	"\rIntentRequest\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x12!\n" +
+	"\fuser_message\x18\x02 \x01(\tR\vuserMessage\x12k\n" +
+	"\x14conversation_history\x18\x03 \x03(\v28.cdnbuddy.messaging.v1.IntentRequest.ConversationMessageR\x13conversationHistory\x12\x18\n" +
+	"\asummary\x18\x04 \x01(\tR\asummary\x12^\n" +
+	"\x11available_actions\x18\x05 \x03(\v21.cdnbuddy.messaging.v1.IntentRequest.ActionSchemaR\x10availableActions\x12F\n" +
+	"\acontext\x18\x06 \x01(\v2,.cdnbuddy.messaging.v1.IntentRequest.ContextR\acontext\x1a}\n" +
+	"\x13ConversationMessage\x12\x12\n" +
+	"\x04role\x18\x01 \x01(\tR\x04role\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x128\n" +
+	"\ttimestamp\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x1aF\n" +
+	"\fActionSchema\x12\x16\n" +
+	"\x06action\x18\x01 \x01(\tR\x06action\x12\x1e\n" +
+	"\n" +
+	"parameters\x18\x02 \x03(\tR\n" +
+	"parameters\x1a$\n" +
+	"\n" +
+	"StringList\x12\x16\n" +
+	"\x06values\x18\x01 \x03(\tR\x06values\x1a\x95\x03\n" +
+	"\aContext\x12u\n" +
+	"\x13inferred_parameters\x18\x01 \x03(\v2D.cdnbuddy.messaging.v1.IntentRequest.Context.InferredParametersEntryR\x12inferredParameters\x12\\\n" +
+	"\n" +
+	"candidates\x18\x02 \x03(\v2<.cdnbuddy.messaging.v1.IntentRequest.Context.CandidatesEntryR\n" +
+	"candidates\x1aE\n" +
+	"\x17InferredParametersEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\x1an\n" +
+	"\x0fCandidatesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12E\n" +
+	"\x05value\x18\x02 \x01(\v2/.cdnbuddy.messaging.v1.IntentRequest.StringListR\x05value:\x028\x01\"\x87\x05\n" +
+	"\x0eIntentResponse\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x1b\n" +
+	"\x06action\x18\x02 \x01(\tH\x00R\x06action\x88\x01\x01\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\x12U\n" +
+	"\n" +
+	"parameters\x18\x04 \x03(\v25.cdnbuddy.messaging.v1.IntentResponse.ParametersEntryR\n" +
+	"parameters\x12-\n" +
+	"\x12missing_parameters\x18\x05 \x03(\tR\x11missingParameters\x12!\n" +
+	"\fuser_message\x18\x06 \x01(\tR\vuserMessage\x12\"\n" +
+	"\n" +
+	"error_code\x18\a \x01(\tH\x01R\terrorCode\x88\x01\x01\x12(\n" +
+	"\rerror_message\x18\b \x01(\tH\x02R\ferrorMessage\x88\x01\x01\x12A\n" +
+	"\x05usage\x18\t \x01(\v2+.cdnbuddy.messaging.v1.IntentResponse.UsageR\x05usage\x1a|\n" +
+	"\x05Usage\x12#\n" +
+	"\rprompt_tokens\x18\x01 \x01(\x03R\fpromptTokens\x12+\n" +
+	"\x11completion_tokens\x18\x02 \x01(\x03R\x10completionTokens\x12!\n" +
+	"\ftotal_tokens\x18\x03 \x01(\x03R\vtotalTokens\x1a=\n" +
+	"\x0fParametersEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01B\t\n" +
+	"\a_actionB\r\n" +
+	"\v_error_codeB\x10\n" +
+	"\x0e_error_messageBHZFgithub.com/avvvet/cdnbuddy-api/internal/services/messaging/messagingpbb\x06proto3"
+
+var (
+	file_messaging_proto_rawDescOnce sync.Once
+	file_messaging_proto_rawDescData []byte
+)
+
+func file_messaging_proto_rawDescGZIP() []byte {
+	file_messaging_proto_rawDescOnce.Do(func() {
+		file_messaging_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_messaging_proto_rawDesc), len(file_messaging_proto_rawDesc)))
+	})
+	return file_messaging_proto_rawDescData
+}
+
+var file_messaging_proto_msgTypes = make([]protoimpl.MessageInfo, 17)
+var file_messaging_proto_goTypes = []any{
+	(*Envelope)(nil),                          // 0: cdnbuddy.messaging.v1.Envelope
+	(*ChatEvent)(nil),                         // 1: cdnbuddy.messaging.v1.ChatEvent
+	(*OperationEvent)(nil),                    // 2: cdnbuddy.messaging.v1.OperationEvent
+	(*StatusRequest)(nil),                     // 3: cdnbuddy.messaging.v1.StatusRequest
+	(*StatusResponse)(nil),                    // 4: cdnbuddy.messaging.v1.StatusResponse
+	(*IntentRequest)(nil),                     // 5: cdnbuddy.messaging.v1.IntentRequest
+	(*IntentResponse)(nil),                    // 6: cdnbuddy.messaging.v1.IntentResponse
+	(*StatusResponse_Domain)(nil),             // 7: cdnbuddy.messaging.v1.StatusResponse.Domain
+	(*StatusResponse_Metrics)(nil),            // 8: cdnbuddy.messaging.v1.StatusResponse.Metrics
+	(*IntentRequest_ConversationMessage)(nil), // 9: cdnbuddy.messaging.v1.IntentRequest.ConversationMessage
+	(*IntentRequest_ActionSchema)(nil),        // 10: cdnbuddy.messaging.v1.IntentRequest.ActionSchema
+	(*IntentRequest_StringList)(nil),          // 11: cdnbuddy.messaging.v1.IntentRequest.StringList
+	(*IntentRequest_Context)(nil),             // 12: cdnbuddy.messaging.v1.IntentRequest.Context
+	nil,                                       // 13: cdnbuddy.messaging.v1.IntentRequest.Context.InferredParametersEntry
+	nil,                                       // 14: cdnbuddy.messaging.v1.IntentRequest.Context.CandidatesEntry
+	(*IntentResponse_Usage)(nil),              // 15: cdnbuddy.messaging.v1.IntentResponse.Usage
+	nil,                                       // 16: cdnbuddy.messaging.v1.IntentResponse.ParametersEntry
+	(*timestamppb.Timestamp)(nil),             // 17: google.protobuf.Timestamp
+}
+var file_messaging_proto_depIdxs = []int32{
+	17, // 0: cdnbuddy.messaging.v1.Envelope.sent_at:type_name -> google.protobuf.Timestamp
+	1,  // 1: cdnbuddy.messaging.v1.Envelope.chat:type_name -> cdnbuddy.messaging.v1.ChatEvent
+	2,  // 2: cdnbuddy.messaging.v1.Envelope.operation:type_name -> cdnbuddy.messaging.v1.OperationEvent
+	17, // 3: cdnbuddy.messaging.v1.ChatEvent.timestamp:type_name -> google.protobuf.Timestamp
+	17, // 4: cdnbuddy.messaging.v1.OperationEvent.timestamp:type_name -> google.protobuf.Timestamp
+	17, // 5: cdnbuddy.messaging.v1.StatusRequest.timestamp:type_name -> google.protobuf.Timestamp
+	7,  // 6: cdnbuddy.messaging.v1.StatusResponse.domains:type_name -> cdnbuddy.messaging.v1.StatusResponse.Domain
+	8,  // 7: cdnbuddy.messaging.v1.StatusResponse.metrics:type_name -> cdnbuddy.messaging.v1.StatusResponse.Metrics
+	9,  // 8: cdnbuddy.messaging.v1.IntentRequest.conversation_history:type_name -> cdnbuddy.messaging.v1.IntentRequest.ConversationMessage
+	10, // 9: cdnbuddy.messaging.v1.IntentRequest.available_actions:type_name -> cdnbuddy.messaging.v1.IntentRequest.ActionSchema
+	12, // 10: cdnbuddy.messaging.v1.IntentRequest.context:type_name -> cdnbuddy.messaging.v1.IntentRequest.Context
+	16, // 11: cdnbuddy.messaging.v1.IntentResponse.parameters:type_name -> cdnbuddy.messaging.v1.IntentResponse.ParametersEntry
+	15, // 12: cdnbuddy.messaging.v1.IntentResponse.usage:type_name -> cdnbuddy.messaging.v1.IntentResponse.Usage
+	17, // 13: cdnbuddy.messaging.v1.IntentRequest.ConversationMessage.timestamp:type_name -> google.protobuf.Timestamp
+	13, // 14: cdnbuddy.messaging.v1.IntentRequest.Context.inferred_parameters:type_name -> cdnbuddy.messaging.v1.IntentRequest.Context.InferredParametersEntry
+	14, // 15: cdnbuddy.messaging.v1.IntentRequest.Context.candidates:type_name -> cdnbuddy.messaging.v1.IntentRequest.Context.CandidatesEntry
+	11, // 16: cdnbuddy.messaging.v1.IntentRequest.Context.CandidatesEntry.value:type_name -> cdnbuddy.messaging.v1.IntentRequest.StringList
+	17, // [17:17] is the sub-list for method output_type
+	17, // [17:17] is the sub-list for method input_type
+	17, // [17:17] is the sub-list for extension type_name
+	17, // [17:17] is the sub-list for extension extendee
+	0,  // [0:17] is the sub-list for field type_name
+}
+
+func init() { file_messaging_proto_init() }
+func file_messaging_proto_init() {
+	if File_messaging_proto != nil {
+		return
+	}
+	file_messaging_proto_msgTypes[0].OneofWrappers = []any{
+		(*Envelope_Json)(nil),
+		(*Envelope_Chat)(nil),
+		(*Envelope_Operation)(nil),
+	}
+	file_messaging_proto_msgTypes[6].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_messaging_proto_rawDesc), len(file_messaging_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   17,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_messaging_proto_goTypes,
+		DependencyIndexes: file_messaging_proto_depIdxs,
+		MessageInfos:      file_messaging_proto_msgTypes,
+	}.Build()
+	File_messaging_proto = out.File
+	file_messaging_proto_goTypes = nil
+	file_messaging_proto_depIdxs = nil
+}
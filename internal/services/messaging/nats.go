@@ -1,15 +1,49 @@
 package messaging
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
+	"fmt"
 	"log"
+	"strings"
 	"time"
 
 	"github.com/nats-io/nats.go"
 )
 
+// Durable subscriptions use a JetStream stream per subject
+var (
+	natsAckWait     = 30 * time.Second // default redelivery delay of unacknowledged messages; handlers report progress meanwhile
+	natsRetryDelay  = 10 * time.Second // redelivery delay of messages whose handler failed
+	natsFetchWait   = time.Second      // how long a fetch blocks before re-checking for shutdown
+	natsStreamAge   = 24 * time.Hour   // messages no consumer acknowledged are dropped after this
+	natsMaxDeliver  = 5                // deliveries of a message before it is given up on
+	natsStreamNamer = strings.NewReplacer(".", "_", "*", "_", ">", "_")
+)
+
+// NATSClient is the NATS implementation of Bus
 type NATSClient struct {
-	conn *nats.Conn
+	conn       *nats.Conn
+	codec      Codec
+	ackWait    time.Duration // of durable consumers this client creates
+	retryDelay time.Duration // before a failed durable message is delivered again
+}
+
+var (
+	_ Bus        = (*NATSClient)(nil)
+	_ DurableBus = (*NATSClient)(nil)
+)
+
+// natsDurableSubscription stops a durable consumer's fetch loop; the consumer
+// itself stays on the server so it resumes where it left off
+type natsDurableSubscription struct {
+	sub    *nats.Subscription
+	cancel context.CancelFunc
+}
+
+func (s *natsDurableSubscription) Unsubscribe() error {
+	s.cancel()
+	return s.sub.Unsubscribe()
 }
 
 func NewNATSClient(url string) (*NATSClient, error) {
@@ -33,7 +67,37 @@ func NewNATSClient(url string) (*NATSClient, error) {
 	}
 
 	log.Printf("✅ Connected to NATS at %s", url)
-	return &NATSClient{conn: conn}, nil
+	return &NATSClient{conn: conn, codec: JSONCodec{}, ackWait: natsAckWait, retryDelay: natsRetryDelay}, nil
+}
+
+// SetCodec sets how messages are encoded; JSON unless set
+func (n *NATSClient) SetCodec(codec Codec) {
+	n.codec = codec
+}
+
+// encode builds a message of data; anything but JSON is labelled with its
+// Content-Type, so peers without the header keep reading JSON
+func (n *NATSClient) encode(subject string, data interface{}) (*nats.Msg, error) {
+	payload, contentType, err := n.codec.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := nats.NewMsg(subject)
+	msg.Data = payload
+	if contentType != jsonMediaType {
+		msg.Header.Set("Content-Type", contentType)
+	}
+	return msg, nil
+}
+
+// natsMessage converts a received message, decoding it to JSON
+func natsMessage(msg *nats.Msg) (*Message, error) {
+	data, err := decodeToJSON(msg.Data, msg.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode message on %s: %w", msg.Subject, err)
+	}
+	return &Message{Subject: msg.Subject, Data: data, Reply: msg.Reply}, nil
 }
 
 func (n *NATSClient) Close() {
@@ -43,40 +107,208 @@ func (n *NATSClient) Close() {
 }
 
 func (n *NATSClient) Publish(subject string, data interface{}) error {
-	payload, err := json.Marshal(data)
+	msg, err := n.encode(subject, data)
 	if err != nil {
 		return err
 	}
 
-	return n.conn.Publish(subject, payload)
+	return n.conn.PublishMsg(msg)
 }
 
 func (n *NATSClient) PublishWithReply(subject, reply string, data interface{}) error {
-	payload, err := json.Marshal(data)
+	msg, err := n.encode(subject, data)
 	if err != nil {
 		return err
 	}
 
-	return n.conn.PublishRequest(subject, reply, payload)
+	msg.Reply = reply
+	return n.conn.PublishMsg(msg)
 }
 
-func (n *NATSClient) Subscribe(subject string, handler func(msg *nats.Msg)) (*nats.Subscription, error) {
-	return n.conn.Subscribe(subject, handler)
+func (n *NATSClient) Subscribe(subject string, handler func(msg *Message)) (Subscription, error) {
+	sub, err := n.conn.Subscribe(subject, natsHandler(handler))
+	if err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+func (n *NATSClient) QueueSubscribe(subject, queue string, handler func(msg *Message)) (Subscription, error) {
+	sub, err := n.conn.QueueSubscribe(subject, queue, natsHandler(handler))
+	if err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// SubscribeDurable pulls a subject through a JetStream consumer, creating its
+// stream and the consumer on first use. Needs a server with JetStream enabled.
+func (n *NATSClient) SubscribeDurable(subject, consumer string, handler func(msg *Message) error) (Subscription, error) {
+	js, err := n.conn.JetStream()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open JetStream: %w", err)
+	}
+
+	stream := strings.ToUpper(natsStreamNamer.Replace(subject))
+	if _, err = js.StreamInfo(stream); errors.Is(err, nats.ErrStreamNotFound) {
+		_, err = js.AddStream(&nats.StreamConfig{
+			Name:      stream,
+			Subjects:  []string{subject},
+			Retention: nats.InterestPolicy, // kept until every consumer acknowledged
+			MaxAge:    natsStreamAge,
+		})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stream %s: %w", stream, err)
+	}
+
+	// Created here rather than by the subscription, which would delete it on unsubscribe
+	if _, err = js.ConsumerInfo(stream, consumer); errors.Is(err, nats.ErrConsumerNotFound) {
+		_, err = js.AddConsumer(stream, &nats.ConsumerConfig{
+			Durable:       consumer,
+			AckPolicy:     nats.AckExplicitPolicy,
+			AckWait:       n.ackWait,
+			MaxDeliver:    natsMaxDeliver,
+			DeliverPolicy: nats.DeliverNewPolicy,
+		})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create consumer %s: %w", consumer, err)
+	}
+
+	sub, err := js.PullSubscribe(subject, consumer, nats.Bind(stream, consumer))
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		for ctx.Err() == nil {
+			msgs, err := sub.Fetch(1, nats.MaxWait(natsFetchWait))
+			switch {
+			case errors.Is(err, nats.ErrTimeout):
+				continue
+			case errors.Is(err, nats.ErrConnectionClosed), errors.Is(err, nats.ErrBadSubscription):
+				return
+			case err != nil:
+				if ctx.Err() == nil {
+					log.Printf("❌ JetStream fetch failed on %s: %v", subject, err)
+					time.Sleep(natsFetchWait)
+				}
+				continue
+			}
+			for _, msg := range msgs {
+				n.handleDurable(msg, handler)
+			}
+		}
+	}()
+
+	return &natsDurableSubscription{sub: sub, cancel: cancel}, nil
 }
 
-func (n *NATSClient) QueueSubscribe(subject, queue string, handler func(msg *nats.Msg)) (*nats.Subscription, error) {
-	return n.conn.QueueSubscribe(subject, queue, handler)
+// handleDurable runs handler on a JetStream message, holding off redelivery
+// while it runs. The message is acknowledged if handler succeeds, dropped if
+// it fails permanently and delivered again after the retry delay otherwise.
+func (n *NATSClient) handleDurable(msg *nats.Msg, handler func(msg *Message) error) {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(n.ackWait / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				msg.InProgress()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	// msg.Reply is the acknowledgement subject, not a reply to respond on
+	m, err := natsMessage(msg)
+	if err != nil {
+		err = Permanent(err)
+	} else {
+		m.Reply = ""
+		err = handler(m)
+	}
+	close(done)
+
+	switch {
+	case err == nil:
+		err = msg.AckSync()
+	case errors.Is(err, ErrPermanent):
+		log.Printf("❌ Dropping message on %s: %v", msg.Subject, err)
+		err = msg.Term()
+	default:
+		log.Printf("⚠️ Handler failed on %s, will retry: %v", msg.Subject, err)
+		err = msg.NakWithDelay(n.retryDelay)
+	}
+	if err != nil {
+		log.Printf("❌ Failed to acknowledge message on %s: %v", msg.Subject, err)
+	}
 }
 
-func (n *NATSClient) Request(subject string, data interface{}, timeout time.Duration) (*nats.Msg, error) {
-	payload, err := json.Marshal(data)
+func (n *NATSClient) Request(subject string, data interface{}, timeout time.Duration) (*Message, error) {
+	request, err := n.encode(subject, data)
+	if err != nil {
+		return nil, err
+	}
+
+	msg, err := n.conn.RequestMsg(request, timeout)
 	if err != nil {
+		if errors.Is(err, nats.ErrTimeout) {
+			return nil, ErrRequestTimeout
+		}
 		return nil, err
 	}
 
-	return n.conn.Request(subject, payload, timeout)
+	return natsMessage(msg)
+}
+
+func (n *NATSClient) Respond(msg *Message, data []byte) error {
+	if msg.Reply == "" {
+		return nats.ErrMsgNoReply
+	}
+	return n.conn.Publish(msg.Reply, data)
 }
 
 func (n *NATSClient) IsConnected() bool {
 	return n.conn != nil && n.conn.IsConnected()
 }
+
+// Ping flushes the connection, which waits for the server's PONG
+func (n *NATSClient) Ping(ctx context.Context) error {
+	if _, ok := ctx.Deadline(); !ok {
+		return n.conn.Flush()
+	}
+	return n.conn.FlushWithContext(ctx)
+}
+
+func (n *NATSClient) Stats() map[string]interface{} {
+	traffic := n.conn.Stats()
+	return map[string]interface{}{
+		"backend":       BackendNATS,
+		"connected":     n.IsConnected(),
+		"server_info":   n.conn.ConnectedServerName(),
+		"url":           n.conn.ConnectedUrl(),
+		"subscriptions": n.conn.NumSubscriptions(),
+		"in_msgs":       traffic.InMsgs,
+		"out_msgs":      traffic.OutMsgs,
+		"in_bytes":      traffic.InBytes,
+		"out_bytes":     traffic.OutBytes,
+		"reconnects":    traffic.Reconnects,
+	}
+}
+
+// natsHandler adapts a Bus handler to a NATS message handler
+func natsHandler(handler func(msg *Message)) nats.MsgHandler {
+	return func(msg *nats.Msg) {
+		m, err := natsMessage(msg)
+		if err != nil {
+			log.Printf("❌ %v", err)
+			return
+		}
+		handler(m)
+	}
+}
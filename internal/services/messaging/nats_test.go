@@ -0,0 +1,104 @@
+package messaging
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestNATSClient connects to url with durable timings short enough for tests
+func newTestNATSClient(t *testing.T, url string) *NATSClient {
+	t.Helper()
+	client, err := NewNATSClient(url)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	client.ackWait = 300 * time.Millisecond
+	client.retryDelay = 100 * time.Millisecond
+	return client
+}
+
+func TestSubscribeDurableRedelivery(t *testing.T) {
+	tests := []struct {
+		name          string
+		crash         bool  // the first consumer dies while handling
+		fail          error // the first delivery's handler error
+		wantRedeliver bool
+	}{
+		{name: "acknowledged message isn't redelivered", wantRedeliver: false},
+		{name: "message of a crashed consumer is redelivered", crash: true, wantRedeliver: true},
+		{name: "failed message is redelivered", fail: errors.New("provider unavailable"), wantRedeliver: true},
+		{name: "permanently failed message is dropped", fail: Permanent(errors.New("plan not found")), wantRedeliver: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, err := StartEmbeddedServer("127.0.0.1:-1", t.TempDir())
+			if err != nil {
+				t.Fatalf("failed to start embedded NATS: %v", err)
+			}
+			defer srv.Shutdown()
+
+			deliveries := make(chan string, 2)
+			var handled atomic.Int32
+			handler := func(msg *Message) error {
+				deliveries <- string(msg.Data)
+				if handled.Add(1) > 1 {
+					return nil
+				}
+				return tt.fail
+			}
+
+			first := newTestNATSClient(t, srv.ClientURL())
+			release := make(chan struct{})
+			defer close(release)
+			if _, err := first.SubscribeDurable(SubjectExecute, "replica-1", func(msg *Message) error {
+				if tt.crash {
+					deliveries <- string(msg.Data)
+					handled.Add(1)
+					<-release
+					return nil
+				}
+				return handler(msg)
+			}); err != nil {
+				t.Fatalf("SubscribeDurable() unexpected error: %v", err)
+			}
+
+			if err := first.Publish(SubjectExecute, "plan-1"); err != nil {
+				t.Fatalf("Publish() unexpected error: %v", err)
+			}
+			select {
+			case <-deliveries:
+			case <-time.After(5 * time.Second):
+				t.Fatal("first consumer got nothing")
+			}
+
+			// A crashed consumer's message goes to its replacement; otherwise the
+			// first consumer stays subscribed and would get any redelivery itself
+			if tt.crash {
+				first.Close()
+				restarted := newTestNATSClient(t, srv.ClientURL())
+				defer restarted.Close()
+				if _, err := restarted.SubscribeDurable(SubjectExecute, "replica-1", handler); err != nil {
+					t.Fatalf("SubscribeDurable() after restart unexpected error: %v", err)
+				}
+			} else {
+				defer first.Close()
+			}
+
+			select {
+			case data := <-deliveries:
+				if !tt.wantRedeliver {
+					t.Errorf("message %s was delivered again", data)
+				} else if data != `"plan-1"` {
+					t.Errorf("redelivered %s, want \"plan-1\"", data)
+				}
+			case <-time.After(2 * time.Second):
+				if tt.wantRedeliver {
+					t.Error("message wasn't redelivered")
+				}
+			}
+		})
+	}
+}
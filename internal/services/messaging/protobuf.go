@@ -0,0 +1,454 @@
+package messaging
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"sort"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/avvvet/cdnbuddy-api/internal/models"
+	pb "github.com/avvvet/cdnbuddy-api/internal/services/messaging/messagingpb"
+)
+
+//go:generate protoc -I proto --go_out=. --go_opt=module=github.com/avvvet/cdnbuddy-api/internal/services/messaging proto/messaging.proto
+
+// Protobuf encoding of the messages in proto/messaging.proto. Messages are
+// converted to and from the types generated into messagingpb.
+
+const (
+	protobufMediaType = "application/x-protobuf"
+	protoPackage      = "cdnbuddy.messaging.v1."
+)
+
+// ProtobufCodec encodes the messages in proto/messaging.proto as protobuf,
+// and everything else as JSON
+type ProtobufCodec struct{}
+
+func (ProtobufCodec) MediaType() string { return protobufMediaType }
+
+func (ProtobufCodec) Marshal(v interface{}) ([]byte, string, error) {
+	var m proto.Message
+	var err error
+	switch v := v.(type) {
+	case Envelope:
+		m, err = toProtoEnvelope(v)
+	case ChatEvent:
+		m, err = toProtoChatEvent(v)
+	case OperationEvent:
+		m, err = toProtoOperationEvent(v)
+	case StatusRequest:
+		m = toProtoStatusRequest(v)
+	case StatusResponse:
+		m = toProtoStatusResponse(v)
+	case models.IntentRequest:
+		m = toProtoIntentRequest(v)
+	case models.IntentResponse:
+		m = toProtoIntentResponse(v)
+	default:
+		return JSONCodec{}.Marshal(v)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Deterministic, so equal messages encode to equal bytes whatever the map order
+	name := string(m.ProtoReflect().Descriptor().FullName())
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(m)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to marshal %s: %w", name, err)
+	}
+	return data, mime.FormatMediaType(protobufMediaType, map[string]string{"proto": name}), nil
+}
+
+// protoDecoders decode each message to its Go type, by full name
+var protoDecoders = map[string]func(data []byte) (interface{}, error){
+	protoPackage + "Envelope":       decodeAs(fromProtoEnvelope),
+	protoPackage + "ChatEvent":      decodeAs(fromProtoChatEvent),
+	protoPackage + "OperationEvent": decodeAs(fromProtoOperationEvent),
+	protoPackage + "StatusRequest":  decodeAs(infallible(fromProtoStatusRequest)),
+	protoPackage + "StatusResponse": decodeAs(infallible(fromProtoStatusResponse)),
+	protoPackage + "IntentRequest":  decodeAs(infallible(fromProtoIntentRequest)),
+	protoPackage + "IntentResponse": decodeAs(infallible(fromProtoIntentResponse)),
+}
+
+// decodeAs unmarshals a message of type M and converts it to its Go type
+func decodeAs[M any, PM interface {
+	*M
+	proto.Message
+}, T any](convert func(m PM) (T, error)) func(data []byte) (interface{}, error) {
+	return func(data []byte) (interface{}, error) {
+		m := PM(new(M))
+		if err := proto.Unmarshal(data, m); err != nil {
+			return nil, err
+		}
+		return convert(m)
+	}
+}
+
+// infallible adapts a conversion that can't fail to decodeAs
+func infallible[PM, T any](convert func(m PM) T) func(m PM) (T, error) {
+	return func(m PM) (T, error) { return convert(m), nil }
+}
+
+func (ProtobufCodec) ToJSON(data []byte, params map[string]string) ([]byte, error) {
+	decode, ok := protoDecoders[params["proto"]]
+	if !ok {
+		return nil, fmt.Errorf("unknown protobuf message %q", params["proto"])
+	}
+	v, err := decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", params["proto"], err)
+	}
+	return json.Marshal(v)
+}
+
+// toTimestamp leaves zero times out, as JSON peers do
+func toTimestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+func fromTimestamp(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
+
+// toJSON encodes the free-form data of a bytes field
+func toJSON(field string, v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s: %w", field, err)
+	}
+	return data, nil
+}
+
+// fromJSON decodes a free-form bytes field into v, if it is set
+func fromJSON(data []byte, v interface{}) error {
+	if len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, v)
+}
+
+func toProtoEnvelope(env Envelope) (*pb.Envelope, error) {
+	m := &pb.Envelope{
+		EventType:     env.EventType,
+		SchemaVersion: int64(env.SchemaVersion),
+		CorrelationId: env.CorrelationID,
+		SentAt:        toTimestamp(env.SentAt),
+	}
+
+	switch env.EventType {
+	case schemas[SubjectChat].EventType:
+		var event ChatEvent
+		if json.Unmarshal(env.Payload, &event) == nil {
+			chat, err := toProtoChatEvent(event)
+			if err != nil {
+				return nil, err
+			}
+			m.Payload = &pb.Envelope_Chat{Chat: chat}
+			return m, nil
+		}
+	case schemas[SubjectOperation].EventType:
+		var event OperationEvent
+		if json.Unmarshal(env.Payload, &event) == nil {
+			operation, err := toProtoOperationEvent(event)
+			if err != nil {
+				return nil, err
+			}
+			m.Payload = &pb.Envelope_Operation{Operation: operation}
+			return m, nil
+		}
+	}
+	m.Payload = &pb.Envelope_Json{Json: env.Payload}
+	return m, nil
+}
+
+func fromProtoEnvelope(m *pb.Envelope) (Envelope, error) {
+	env := Envelope{
+		EventType:     m.GetEventType(),
+		SchemaVersion: int(m.GetSchemaVersion()),
+		CorrelationID: m.GetCorrelationId(),
+		SentAt:        fromTimestamp(m.GetSentAt()),
+	}
+
+	var err error
+	switch payload := m.GetPayload().(type) {
+	case *pb.Envelope_Json:
+		env.Payload = json.RawMessage(payload.Json)
+	case *pb.Envelope_Chat:
+		env.Payload, err = reencode(fromProtoChatEvent, payload.Chat)
+	case *pb.Envelope_Operation:
+		env.Payload, err = reencode(fromProtoOperationEvent, payload.Operation)
+	}
+	return env, err
+}
+
+// reencode converts a protobuf message to its Go type and encodes it as JSON
+func reencode[PM, T any](convert func(m PM) (T, error), m PM) (json.RawMessage, error) {
+	v, err := convert(m)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+func toProtoChatEvent(v ChatEvent) (*pb.ChatEvent, error) {
+	m := &pb.ChatEvent{
+		Type:        v.Type,
+		UserId:      v.UserID,
+		SessionId:   v.SessionID,
+		SandboxId:   v.SandboxID,
+		Message:     v.Message,
+		Source:      v.Source,
+		Timestamp:   toTimestamp(v.Timestamp),
+		WidgetToken: v.WidgetToken,
+		Origin:      v.Origin,
+	}
+
+	var err error
+	if len(v.Attachments) > 0 {
+		m.AttachmentsJson, err = toJSON("attachments", v.Attachments)
+	}
+	return m, err
+}
+
+func fromProtoChatEvent(m *pb.ChatEvent) (ChatEvent, error) {
+	v := ChatEvent{
+		Type:        m.GetType(),
+		UserID:      m.GetUserId(),
+		SessionID:   m.GetSessionId(),
+		SandboxID:   m.GetSandboxId(),
+		Message:     m.GetMessage(),
+		Source:      m.GetSource(),
+		Timestamp:   fromTimestamp(m.GetTimestamp()),
+		WidgetToken: m.GetWidgetToken(),
+		Origin:      m.GetOrigin(),
+	}
+	return v, fromJSON(m.GetAttachmentsJson(), &v.Attachments)
+}
+
+func toProtoOperationEvent(v OperationEvent) (*pb.OperationEvent, error) {
+	m := &pb.OperationEvent{
+		Type:        v.Type,
+		OperationId: v.OperationID,
+		ServiceId:   v.ServiceID,
+		UserId:      v.UserID,
+		SessionId:   v.SessionID,
+		OpType:      v.OpType,
+		Status:      v.Status,
+		Progress:    v.Progress,
+		Error:       v.Error,
+		Timestamp:   toTimestamp(v.Timestamp),
+	}
+
+	var err error
+	if len(v.Params) > 0 {
+		if m.ParamsJson, err = toJSON("params", v.Params); err != nil {
+			return nil, err
+		}
+	}
+	if len(v.Result) > 0 {
+		if m.ResultJson, err = toJSON("result", v.Result); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func fromProtoOperationEvent(m *pb.OperationEvent) (OperationEvent, error) {
+	v := OperationEvent{
+		Type:        m.GetType(),
+		OperationID: m.GetOperationId(),
+		ServiceID:   m.GetServiceId(),
+		UserID:      m.GetUserId(),
+		SessionID:   m.GetSessionId(),
+		OpType:      m.GetOpType(),
+		Status:      m.GetStatus(),
+		Progress:    m.GetProgress(),
+		Error:       m.GetError(),
+		Timestamp:   fromTimestamp(m.GetTimestamp()),
+	}
+	if err := fromJSON(m.GetParamsJson(), &v.Params); err != nil {
+		return v, err
+	}
+	return v, fromJSON(m.GetResultJson(), &v.Result)
+}
+
+func toProtoStatusRequest(v StatusRequest) *pb.StatusRequest {
+	return &pb.StatusRequest{
+		UserId:    v.UserID,
+		SessionId: v.SessionID,
+		Timestamp: toTimestamp(v.Timestamp),
+	}
+}
+
+func fromProtoStatusRequest(m *pb.StatusRequest) StatusRequest {
+	return StatusRequest{
+		UserID:    m.GetUserId(),
+		SessionID: m.GetSessionId(),
+		Timestamp: fromTimestamp(m.GetTimestamp()),
+	}
+}
+
+func toProtoStatusResponse(v StatusResponse) *pb.StatusResponse {
+	m := &pb.StatusResponse{Provider: v.Provider, Error: v.Error}
+	for _, d := range v.Domains {
+		m.Domains = append(m.Domains, &pb.StatusResponse_Domain{Name: d.Name, Status: d.Status, Regions: int64(d.Regions)})
+	}
+	if v.Metrics != (Metrics{}) {
+		m.Metrics = &pb.StatusResponse_Metrics{
+			CacheHitRatio:   v.Metrics.CacheHitRatio,
+			AvgResponseTime: v.Metrics.AvgResponseTime,
+			TotalRequests:   v.Metrics.TotalRequests,
+		}
+	}
+	return m
+}
+
+func fromProtoStatusResponse(m *pb.StatusResponse) StatusResponse {
+	v := StatusResponse{
+		Provider: m.GetProvider(),
+		Metrics: Metrics{
+			CacheHitRatio:   m.GetMetrics().GetCacheHitRatio(),
+			AvgResponseTime: m.GetMetrics().GetAvgResponseTime(),
+			TotalRequests:   m.GetMetrics().GetTotalRequests(),
+		},
+		Error: m.GetError(),
+	}
+	for _, d := range m.GetDomains() {
+		v.Domains = append(v.Domains, Domain{Name: d.GetName(), Status: d.GetStatus(), Regions: int(d.GetRegions())})
+	}
+	return v
+}
+
+func toProtoIntentRequest(v models.IntentRequest) *pb.IntentRequest {
+	m := &pb.IntentRequest{
+		SessionId:   v.SessionID,
+		UserMessage: v.UserMessage,
+		Summary:     v.Summary,
+	}
+	for _, msg := range v.ConversationHistory {
+		m.ConversationHistory = append(m.ConversationHistory, &pb.IntentRequest_ConversationMessage{
+			Role:      msg.Role,
+			Message:   msg.Message,
+			Timestamp: toTimestamp(msg.Timestamp),
+		})
+	}
+	for _, action := range v.AvailableActions {
+		m.AvailableActions = append(m.AvailableActions, &pb.IntentRequest_ActionSchema{Action: action.Action, Parameters: action.Parameters})
+	}
+	if c := v.Context; c != nil {
+		m.Context = &pb.IntentRequest_Context{InferredParameters: c.InferredParameters}
+		for k, values := range c.Candidates {
+			if m.Context.Candidates == nil {
+				m.Context.Candidates = make(map[string]*pb.IntentRequest_StringList, len(c.Candidates))
+			}
+			m.Context.Candidates[k] = &pb.IntentRequest_StringList{Values: values}
+		}
+	}
+	return m
+}
+
+func fromProtoIntentRequest(m *pb.IntentRequest) models.IntentRequest {
+	// Always lists, as in JSON requests
+	v := models.IntentRequest{
+		SessionID:           m.GetSessionId(),
+		UserMessage:         m.GetUserMessage(),
+		ConversationHistory: []models.ConversationMessage{},
+		Summary:             m.GetSummary(),
+		AvailableActions:    []models.ActionSchema{},
+	}
+	for _, msg := range m.GetConversationHistory() {
+		v.ConversationHistory = append(v.ConversationHistory, models.ConversationMessage{
+			Role:      msg.GetRole(),
+			Message:   msg.GetMessage(),
+			Timestamp: fromTimestamp(msg.GetTimestamp()),
+		})
+	}
+	for _, action := range m.GetAvailableActions() {
+		v.AvailableActions = append(v.AvailableActions, models.ActionSchema{Action: action.GetAction(), Parameters: action.GetParameters()})
+	}
+	if c := m.GetContext(); c != nil {
+		v.Context = &models.IntentContext{InferredParameters: c.GetInferredParameters()}
+		for k, list := range c.GetCandidates() {
+			if v.Context.Candidates == nil {
+				v.Context.Candidates = make(map[string][]string, len(c.GetCandidates()))
+			}
+			v.Context.Candidates[k] = list.GetValues()
+		}
+	}
+	return v
+}
+
+func toProtoIntentResponse(v models.IntentResponse) *pb.IntentResponse {
+	m := &pb.IntentResponse{
+		SessionId:    v.SessionID,
+		Action:       v.Action,
+		Status:       v.Status,
+		UserMessage:  v.UserMessage,
+		ErrorCode:    v.ErrorCode,
+		ErrorMessage: v.ErrorMessage,
+	}
+
+	// Parameters without a value are listed as missing
+	for name, value := range v.Parameters {
+		if value == nil {
+			m.MissingParameters = append(m.MissingParameters, name)
+			continue
+		}
+		if m.Parameters == nil {
+			m.Parameters = make(map[string]string)
+		}
+		m.Parameters[name] = *value
+	}
+	sort.Strings(m.MissingParameters)
+
+	if u := v.Usage; u != nil {
+		m.Usage = &pb.IntentResponse_Usage{
+			PromptTokens:     u.PromptTokens,
+			CompletionTokens: u.CompletionTokens,
+			TotalTokens:      u.TotalTokens,
+		}
+	}
+	return m
+}
+
+func fromProtoIntentResponse(m *pb.IntentResponse) models.IntentResponse {
+	v := models.IntentResponse{
+		SessionID:    m.GetSessionId(),
+		Action:       m.Action,
+		Status:       m.GetStatus(),
+		UserMessage:  m.GetUserMessage(),
+		ErrorCode:    m.ErrorCode,
+		ErrorMessage: m.ErrorMessage,
+	}
+	for name, value := range m.GetParameters() {
+		if v.Parameters == nil {
+			v.Parameters = make(map[string]*string)
+		}
+		v.Parameters[name] = &value
+	}
+	for _, name := range m.GetMissingParameters() {
+		if v.Parameters == nil {
+			v.Parameters = make(map[string]*string)
+		}
+		v.Parameters[name] = nil
+	}
+	if u := m.GetUsage(); u != nil {
+		v.Usage = &models.IntentUsage{
+			PromptTokens:     u.GetPromptTokens(),
+			CompletionTokens: u.GetCompletionTokens(),
+			TotalTokens:      u.GetTotalTokens(),
+		}
+	}
+	return v
+}
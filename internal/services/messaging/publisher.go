@@ -2,6 +2,7 @@ package messaging
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/avvvet/cdnbuddy-api/internal/domain"
@@ -9,13 +10,41 @@ import (
 )
 
 type Publisher struct {
-	client *NATSClient
+	client     Bus
+	envelope   bool // publish events in their envelope
+	recipients func(userID, sessionID string) []string
+	format     func(orgID, message string) string
 }
 
-func NewPublisher(client *NATSClient) *Publisher {
+func NewPublisher(client Bus) *Publisher {
 	return &Publisher{client: client}
 }
 
+// SetEnvelope sets whether events are published in their Envelope. It is
+// off by default, until every consumer can unwrap them.
+func (p *Publisher) SetEnvelope(enabled bool) {
+	p.envelope = enabled
+}
+
+// publish sends an event on subject, in its envelope when enabled
+func (p *Publisher) publish(subject string, event interface{}) error {
+	if !p.envelope {
+		return p.client.Publish(subject, event)
+	}
+
+	env, err := Seal(subject, event)
+	if err != nil {
+		return err
+	}
+	return p.client.Publish(subject, env)
+}
+
+// SetRecipients sets how AI responses are routed: fn returns every session a
+// response to sessionID is delivered to. Without it only sessionID gets it.
+func (p *Publisher) SetRecipients(fn func(userID, sessionID string) []string) {
+	p.recipients = fn
+}
+
 // CDN Service Events
 func (p *Publisher) PublishCDNServiceCreated(service *domain.CDNService) error {
 	event := CDNServiceEvent{
@@ -28,7 +57,7 @@ func (p *Publisher) PublishCDNServiceCreated(service *domain.CDNService) error {
 		Timestamp: time.Now(),
 	}
 
-	return p.client.Publish(SubjectCDNService, event)
+	return p.publish(SubjectCDNService, event)
 }
 
 func (p *Publisher) PublishCDNServiceUpdated(service *domain.CDNService) error {
@@ -42,7 +71,7 @@ func (p *Publisher) PublishCDNServiceUpdated(service *domain.CDNService) error {
 		Timestamp: time.Now(),
 	}
 
-	return p.client.Publish(SubjectCDNService, event)
+	return p.publish(SubjectCDNService, event)
 }
 
 func (p *Publisher) PublishCDNServiceDeleted(serviceID, userID string) error {
@@ -53,7 +82,7 @@ func (p *Publisher) PublishCDNServiceDeleted(serviceID, userID string) error {
 		Timestamp: time.Now(),
 	}
 
-	return p.client.Publish(SubjectCDNService, event)
+	return p.publish(SubjectCDNService, event)
 }
 
 // Domain Events
@@ -68,7 +97,7 @@ func (p *Publisher) PublishDomainAdded(domain *domain.Domain) error {
 		Timestamp:    time.Now(),
 	}
 
-	return p.client.Publish(SubjectDomain, event)
+	return p.publish(SubjectDomain, event)
 }
 
 func (p *Publisher) PublishDomainRemoved(domain *domain.Domain) error {
@@ -82,7 +111,7 @@ func (p *Publisher) PublishDomainRemoved(domain *domain.Domain) error {
 		Timestamp:    time.Now(),
 	}
 
-	return p.client.Publish(SubjectDomain, event)
+	return p.publish(SubjectDomain, event)
 }
 
 func (p *Publisher) PublishDomainStatusChanged(domain *domain.Domain, oldStatus string) error {
@@ -97,7 +126,7 @@ func (p *Publisher) PublishDomainStatusChanged(domain *domain.Domain, oldStatus
 		Timestamp:    time.Now(),
 	}
 
-	return p.client.Publish(SubjectDomain, event)
+	return p.publish(SubjectDomain, event)
 }
 
 // Cache Events
@@ -110,7 +139,20 @@ func (p *Publisher) PublishCachePurged(serviceID, userID string, paths []string)
 		Timestamp: time.Now(),
 	}
 
-	return p.client.Publish(SubjectCache, event)
+	return p.publish(SubjectCache, event)
+}
+
+// PublishCachePurgeCompleted reports that a provider finished purging paths
+// (none = everything)
+func (p *Publisher) PublishCachePurgeCompleted(serviceID string, paths []string) error {
+	event := CacheEvent{
+		Type:      EventCachePurgeCompleted,
+		ServiceID: serviceID,
+		Paths:     paths,
+		Timestamp: time.Now(),
+	}
+
+	return p.publish(SubjectCache, event)
 }
 
 func (p *Publisher) PublishCacheRulesUpdated(serviceID, userID string, rules interface{}) error {
@@ -122,7 +164,7 @@ func (p *Publisher) PublishCacheRulesUpdated(serviceID, userID string, rules int
 		Timestamp: time.Now(),
 	}
 
-	return p.client.Publish(SubjectCache, event)
+	return p.publish(SubjectCache, event)
 }
 
 // Metrics Events
@@ -136,7 +178,7 @@ func (p *Publisher) PublishMetricsUpdated(metrics *domain.Metrics) error {
 		Timestamp:       time.Now(),
 	}
 
-	return p.client.Publish(SubjectMetrics, event)
+	return p.publish(SubjectMetrics, event)
 }
 
 // Operation Events (for execution plans)
@@ -152,7 +194,7 @@ func (p *Publisher) PublishOperationStarted(operation *domain.CDNOperation) erro
 		Timestamp:   time.Now(),
 	}
 
-	return p.client.Publish(SubjectOperation, event)
+	return p.publish(SubjectOperation, event)
 }
 
 func (p *Publisher) PublishOperationProgress(operation *domain.CDNOperation, progress string) error {
@@ -168,7 +210,7 @@ func (p *Publisher) PublishOperationProgress(operation *domain.CDNOperation, pro
 		Timestamp:   time.Now(),
 	}
 
-	return p.client.Publish(SubjectOperation, event)
+	return p.publish(SubjectOperation, event)
 }
 
 func (p *Publisher) PublishOperationCompleted(operation *domain.CDNOperation) error {
@@ -184,7 +226,7 @@ func (p *Publisher) PublishOperationCompleted(operation *domain.CDNOperation) er
 		Timestamp:   time.Now(),
 	}
 
-	return p.client.Publish(SubjectOperation, event)
+	return p.publish(SubjectOperation, event)
 }
 
 func (p *Publisher) PublishOperationFailed(operation *domain.CDNOperation, errorMsg string) error {
@@ -200,7 +242,7 @@ func (p *Publisher) PublishOperationFailed(operation *domain.CDNOperation, error
 		Timestamp:   time.Now(),
 	}
 
-	return p.client.Publish(SubjectOperation, event)
+	return p.publish(SubjectOperation, event)
 }
 
 // Chat Events (for socket service integration)
@@ -213,7 +255,22 @@ func (p *Publisher) PublishChatMessage(userID, sessionID, message string) error
 		Timestamp: time.Now(),
 	}
 
-	return p.client.Publish(SubjectChat, event)
+	return p.publish(SubjectChat, event)
+}
+
+// PublishVoiceMessage feeds a transcribed voice note into the chat pipeline
+func (p *Publisher) PublishVoiceMessage(userID, sessionID, sandboxID, transcript string) error {
+	event := ChatEvent{
+		Type:      EventChatMessage,
+		UserID:    userID,
+		SessionID: sessionID,
+		SandboxID: sandboxID,
+		Message:   transcript,
+		Source:    "voice",
+		Timestamp: time.Now(),
+	}
+
+	return p.publish(SubjectChat, event)
 }
 
 func (p *Publisher) PublishAIResponse(userID, sessionID, response string) error {
@@ -225,7 +282,69 @@ func (p *Publisher) PublishAIResponse(userID, sessionID, response string) error
 		Timestamp: time.Now(),
 	}
 
-	return p.client.Publish(SubjectChatResponse, event)
+	return p.publishResponse(event)
+}
+
+// PublishAIResponseWithAttachments sends an AI response with structured
+// attachments; invalid attachments are rejected rather than sent half-rendered
+func (p *Publisher) PublishAIResponseWithAttachments(userID, sessionID, response string, attachments []Attachment) error {
+	for i, a := range attachments {
+		if err := a.Validate(); err != nil {
+			return fmt.Errorf("invalid attachment %d: %w", i, err)
+		}
+	}
+
+	event := ChatEvent{
+		Type:        EventAIResponse,
+		UserID:      userID,
+		SessionID:   sessionID,
+		Message:     response,
+		Attachments: attachments,
+		Timestamp:   time.Now(),
+	}
+
+	return p.publishResponse(event)
+}
+
+// SetFormatter sets a function applied to the text of every AI response and
+// notification before it is sent, e.g. an org's branding
+func (p *Publisher) SetFormatter(fn func(orgID, message string) string) {
+	p.format = fn
+}
+
+// publishResponse delivers a response to every recipient session. Only a
+// failure to reach the originating session is returned; the others are best effort.
+func (p *Publisher) publishResponse(event ChatEvent) error {
+	if p.format != nil {
+		event.Message = p.format("", event.Message)
+	}
+	if p.recipients == nil {
+		return p.publish(SubjectChatResponse, event)
+	}
+
+	origin := event.SessionID
+	for _, sessionID := range p.recipients(event.UserID, origin) {
+		event.SessionID = sessionID
+		if err := p.publish(SubjectChatResponse, event); err != nil {
+			if sessionID == origin {
+				return err
+			}
+			logrus.WithError(err).WithField("session_id", sessionID).Warn("⚠️ Failed to deliver response to linked session")
+		}
+	}
+	return nil
+}
+
+// PublishNotification sends a user-facing notification
+func (p *Publisher) PublishNotification(event NotificationEvent) error {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+	if p.format != nil {
+		event.Message = p.format(event.OrgID, event.Message)
+	}
+
+	return p.publish(SubjectNotification, event)
 }
 
 // Remove manual marshaling, let client.Publish handle it
@@ -237,7 +356,32 @@ func (p *Publisher) PublishExecutionPlan(ctx context.Context, event ExecutionPla
 		"user_id": event.UserID,
 	}).Info("📤 Publishing execution plan")
 
-	return p.client.Publish(subject, event) // Pass event, not data
+	return p.publish(subject, event)
+}
+
+// PublishExecuteCommand confirms a plan, as the chat UI does, so it is executed
+// (or scheduled) by the execute command handler
+func (p *Publisher) PublishExecuteCommand(cmd ExecuteCommand) error {
+	if cmd.Timestamp.IsZero() {
+		cmd.Timestamp = time.Now()
+	}
+	return p.publish(SubjectExecute, cmd)
+}
+
+// PublishIntentValidation sends the parameter problems of a READY intent to the intent service
+func (p *Publisher) PublishIntentValidation(event IntentValidationEvent) error {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+	return p.publish(SubjectIntentValidation, event)
+}
+
+// PublishSessionReset tells the intent service a conversation was cleared
+func (p *Publisher) PublishSessionReset(event SessionResetEvent) error {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+	return p.publish(SubjectSessionReset, event)
 }
 
 // PublishStatusResponse sends CDN status back to Socket Server
@@ -249,7 +393,7 @@ func (p *Publisher) PublishStatusResponse(userID, sessionID string, services []S
 		Timestamp: time.Now(),
 	}
 
-	return p.client.Publish(SubjectStatusResponse, event)
+	return p.publish(SubjectStatusResponse, event)
 }
 
 // Helper functions to extract IDs from operation params
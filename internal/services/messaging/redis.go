@@ -0,0 +1,284 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	redisStreamMaxLen = 10000           // approximate cap per stream
+	redisBlockTimeout = time.Second     // how long a reader blocks before re-checking for shutdown
+	redisInboxTTL     = 1 * time.Minute // reply streams are removed after this
+)
+
+// RedisBus is the Redis Streams implementation of Bus. Each subject maps to one
+// stream; plain subscriptions read the stream from the tail, queue subscriptions
+// use a consumer group named after the queue and durable ones a group named
+// after the consumer. This replica reads queue groups under its consumer name,
+// so after a restart it picks up the entries it left pending.
+type RedisBus struct {
+	client       *redis.Client
+	consumerName string
+	ctx          context.Context
+	cancel       context.CancelFunc
+	wg           sync.WaitGroup
+}
+
+var (
+	_ Bus        = (*RedisBus)(nil)
+	_ DurableBus = (*RedisBus)(nil)
+)
+
+type redisSubscription struct {
+	cancel context.CancelFunc
+}
+
+func (s *redisSubscription) Unsubscribe() error {
+	s.cancel()
+	return nil
+}
+
+// NewRedisBus connects to Redis at url (redis://host:port/db); consumerName
+// (DefaultConsumerName if empty) must be distinct per replica
+func NewRedisBus(url, consumerName string) (*RedisBus, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Redis URL: %w", err)
+	}
+
+	client := redis.NewClient(opts)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	if err := client.Ping(ctx).Err(); err != nil {
+		cancel()
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+
+	if consumerName == "" {
+		consumerName = DefaultConsumerName
+	}
+
+	log.Printf("✅ Connected to Redis Streams at %s", opts.Addr)
+	return &RedisBus{
+		client:       client,
+		consumerName: consumerName,
+		ctx:          ctx,
+		cancel:       cancel,
+	}, nil
+}
+
+func (b *RedisBus) Close() {
+	b.cancel()
+	b.wg.Wait()
+	b.client.Close()
+	log.Printf("🔒 Redis bus closed")
+}
+
+func (b *RedisBus) Publish(subject string, data interface{}) error {
+	return b.PublishWithReply(subject, "", data)
+}
+
+func (b *RedisBus) PublishWithReply(subject, reply string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return b.add(subject, reply, payload)
+}
+
+func (b *RedisBus) Subscribe(subject string, handler func(msg *Message)) (Subscription, error) {
+	ctx, cancel := context.WithCancel(b.ctx)
+	stream := topicName(subject)
+
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		lastID := "$" // only messages published after subscribing, like NATS
+		for ctx.Err() == nil {
+			res, err := b.client.XRead(ctx, &redis.XReadArgs{
+				Streams: []string{stream, lastID},
+				Block:   redisBlockTimeout,
+			}).Result()
+			if err != nil {
+				if !errors.Is(err, redis.Nil) && ctx.Err() == nil {
+					log.Printf("❌ Redis read failed on %s: %v", stream, err)
+					time.Sleep(redisBlockTimeout)
+				}
+				continue
+			}
+			for _, s := range res {
+				for _, m := range s.Messages {
+					lastID = m.ID
+					handler(redisMessage(subject, m))
+				}
+			}
+		}
+	}()
+
+	log.Printf("📥 Redis stream subscribed: %s", stream)
+	return &redisSubscription{cancel: cancel}, nil
+}
+
+func (b *RedisBus) QueueSubscribe(subject, queue string, handler func(msg *Message)) (Subscription, error) {
+	return b.readGroup(subject, queue, b.consumerName, func(msg *Message) error {
+		handler(msg)
+		return nil
+	})
+}
+
+// SubscribeDurable reads the subject in a consumer group of its own. Entries
+// are acknowledged once handled; ones this consumer read but never
+// acknowledged, because it crashed or the handler failed, are handled first
+// on resubscribing.
+func (b *RedisBus) SubscribeDurable(subject, consumer string, handler func(msg *Message) error) (Subscription, error) {
+	return b.readGroup(subject, consumer, consumer, handler)
+}
+
+// readGroup reads a stream as one consumer of a group, acknowledging each entry
+// once handled without a retryable error, starting with the consumer's pending
+// entries
+func (b *RedisBus) readGroup(subject, group, consumer string, handler func(msg *Message) error) (Subscription, error) {
+	stream := topicName(subject)
+
+	err := b.client.XGroupCreateMkStream(b.ctx, stream, group, "$").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return nil, fmt.Errorf("failed to create consumer group %s: %w", group, err)
+	}
+
+	ctx, cancel := context.WithCancel(b.ctx)
+
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		start := "0" // pending entries first, then new ones
+		for ctx.Err() == nil {
+			res, err := b.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+				Group:    group,
+				Consumer: consumer,
+				Streams:  []string{stream, start},
+				Block:    redisBlockTimeout,
+			}).Result()
+			if err != nil {
+				if !errors.Is(err, redis.Nil) && ctx.Err() == nil {
+					log.Printf("❌ Redis group read failed on %s: %v", stream, err)
+					time.Sleep(redisBlockTimeout)
+				}
+				continue
+			}
+			acked := 0
+			for _, s := range res {
+				for _, m := range s.Messages {
+					if err := handler(redisMessage(subject, m)); err != nil && !errors.Is(err, ErrPermanent) {
+						continue // stays pending for the next subscription
+					}
+					b.client.XAck(ctx, stream, group, m.ID)
+					acked++
+				}
+			}
+			// Pending entries are done once a pass acknowledges none of them
+			if start == "0" && acked == 0 {
+				start = ">"
+			}
+		}
+	}()
+
+	log.Printf("📥 Redis stream group subscribed: %s (group: %s)", stream, group)
+	return &redisSubscription{cancel: cancel}, nil
+}
+
+// Request publishes with a one-off inbox stream as reply subject and waits for the first reply
+func (b *RedisBus) Request(subject string, data interface{}, timeout time.Duration) (*Message, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	inbox := inboxPrefix + uuid.New().String()
+	defer b.client.Del(context.Background(), inbox)
+
+	if err := b.add(subject, inbox, payload); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(b.ctx, timeout)
+	defer cancel()
+
+	// Reading from ID 0 catches a reply that arrived before we started blocking
+	res, err := b.client.XRead(ctx, &redis.XReadArgs{
+		Streams: []string{inbox, "0"},
+		Count:   1,
+		Block:   timeout,
+	}).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) || errors.Is(err, context.DeadlineExceeded) {
+			return nil, ErrRequestTimeout
+		}
+		return nil, fmt.Errorf("failed to read reply: %w", err)
+	}
+	if len(res) == 0 || len(res[0].Messages) == 0 {
+		return nil, ErrRequestTimeout
+	}
+
+	return redisMessage(inbox, res[0].Messages[0]), nil
+}
+
+func (b *RedisBus) Respond(msg *Message, data []byte) error {
+	if msg.Reply == "" {
+		return errors.New("message has no reply subject")
+	}
+	if err := b.add(msg.Reply, "", data); err != nil {
+		return err
+	}
+	return b.client.Expire(b.ctx, topicName(msg.Reply), redisInboxTTL).Err()
+}
+
+func (b *RedisBus) Ping(ctx context.Context) error {
+	return b.client.Ping(ctx).Err()
+}
+
+func (b *RedisBus) IsConnected() bool {
+	return b.client.Ping(b.ctx).Err() == nil
+}
+
+func (b *RedisBus) Stats() map[string]interface{} {
+	return map[string]interface{}{
+		"backend":   BackendRedis,
+		"connected": b.IsConnected(),
+		"url":       b.client.Options().Addr,
+	}
+}
+
+func (b *RedisBus) add(subject, reply string, payload []byte) error {
+	values := map[string]interface{}{"data": payload}
+	if reply != "" {
+		values["reply"] = reply
+	}
+
+	return b.client.XAdd(b.ctx, &redis.XAddArgs{
+		Stream: topicName(subject),
+		MaxLen: redisStreamMaxLen,
+		Approx: true,
+		Values: values,
+	}).Err()
+}
+
+func redisMessage(subject string, m redis.XMessage) *Message {
+	msg := &Message{Subject: subject}
+	if data, ok := m.Values["data"].(string); ok {
+		msg.Data = []byte(data)
+	}
+	if reply, ok := m.Values["reply"].(string); ok {
+		msg.Reply = reply
+	}
+	return msg
+}
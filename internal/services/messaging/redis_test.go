@@ -0,0 +1,50 @@
+package messaging
+
+import (
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestRedisMessage(t *testing.T) {
+	tests := []struct {
+		name      string
+		values    map[string]interface{}
+		wantData  string
+		wantReply string
+	}{
+		{name: "event", values: map[string]interface{}{"data": `{"id":"svc-1"}`}, wantData: `{"id":"svc-1"}`},
+		{name: "request", values: map[string]interface{}{"data": `"ping"`, "reply": "_INBOX.1"}, wantData: `"ping"`, wantReply: "_INBOX.1"},
+		{name: "entry without data", values: map[string]interface{}{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := redisMessage(SubjectCDNService, redis.XMessage{ID: "1-0", Values: tt.values})
+			if msg.Subject != SubjectCDNService || string(msg.Data) != tt.wantData || msg.Reply != tt.wantReply {
+				t.Errorf("message = %+v", msg)
+			}
+		})
+	}
+}
+
+func TestNewBusRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  BusConfig
+	}{
+		{name: "invalid Redis URL", cfg: BusConfig{Backend: BackendRedis, URL: "localhost:6379"}},
+		{name: "protobuf on Redis", cfg: BusConfig{Backend: BackendRedis, URL: "redis://localhost:6379", Encoding: "protobuf"}},
+		{name: "Kafka without brokers", cfg: BusConfig{Backend: BackendKafka}},
+		{name: "unknown backend", cfg: BusConfig{Backend: "amqp"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if bus, err := NewBus(tt.cfg); err == nil {
+				bus.Close()
+				t.Error("NewBus succeeded")
+			}
+		})
+	}
+}
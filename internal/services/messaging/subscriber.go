@@ -2,25 +2,38 @@ package messaging
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
-
-	"github.com/nats-io/nats.go"
+	"sync"
 )
 
+// maxHandlersPerSubject caps the handler registry so repeated registration
+// (e.g. on reconnect loops) can't grow it without bound
+const maxHandlersPerSubject = 32
+
 type Subscriber struct {
-	client   *NATSClient
+	client   Bus
+	consumer string // name of durable consumers
 	handlers map[string][]MessageHandler
+	mu       sync.RWMutex
 }
 
 type MessageHandler func(data []byte) error
 
-func NewSubscriber(client *NATSClient) *Subscriber {
+func NewSubscriber(client Bus) *Subscriber {
 	return &Subscriber{
 		client:   client,
+		consumer: DefaultConsumerName,
 		handlers: make(map[string][]MessageHandler),
 	}
 }
 
+// SetConsumerName names the durable consumers subscribed after the call
+func (s *Subscriber) SetConsumerName(name string) {
+	s.consumer = name
+}
+
 // Register handlers for different message types
 func (s *Subscriber) RegisterCDNServiceHandler(handler func(event CDNServiceEvent) error) error {
 	messageHandler := func(data []byte) error {
@@ -98,12 +111,13 @@ func (s *Subscriber) RegisterExecutionPlanHandler(handler func(event ExecutionPl
 	messageHandler := func(data []byte) error {
 		var event ExecutionPlanEvent
 		if err := json.Unmarshal(data, &event); err != nil {
-			return err
+			return Permanent(err)
 		}
 		return handler(event)
 	}
 
-	return s.subscribe(SubjectExecutionPlan, messageHandler)
+	// A lost plan means a lost request, so plans survive a crash
+	return s.subscribeDurable(SubjectExecutionPlan, messageHandler)
 }
 
 // RegisterStatusRequestHandler registers handler for CDN status requests
@@ -119,22 +133,71 @@ func (s *Subscriber) RegisterStatusRequestHandler(handler func(event StatusReque
 	return s.subscribe("cdn.status.request", messageHandler)
 }
 
-// Generic subscription method
+// Generic subscription method. The bus subscription is created once per subject;
+// further handlers for the same subject are added to the registry only.
 func (s *Subscriber) subscribe(subject string, handler MessageHandler) error {
-	// Add handler to registry
+	return s.register(subject, handler, false)
+}
+
+// subscribeDurable is subscribe on a durable consumer, where the bus has them:
+// messages are acknowledged once every handler ran. Without one (e.g. NATS
+// without JetStream) it falls back to a plain subscription.
+func (s *Subscriber) subscribeDurable(subject string, handler MessageHandler) error {
+	return s.register(subject, handler, true)
+}
+
+func (s *Subscriber) register(subject string, handler MessageHandler, durable bool) error {
+	s.mu.Lock()
+	existing := len(s.handlers[subject])
+	if existing >= maxHandlersPerSubject {
+		s.mu.Unlock()
+		return fmt.Errorf("too many handlers for subject %s (max %d)", subject, maxHandlersPerSubject)
+	}
 	s.handlers[subject] = append(s.handlers[subject], handler)
+	s.mu.Unlock()
+
+	if existing > 0 {
+		log.Printf("📥 Added handler for subject: %s", subject)
+		return nil
+	}
+
+	// Returns the handlers' errors, so a durable consumer can deliver the
+	// message again
+	dispatch := func(msg *Message) error {
+		data, ok := open(subject, msg.Data)
+		if !ok {
+			return nil
+		}
+		s.mu.RLock()
+		handlers := s.handlers[subject]
+		s.mu.RUnlock()
 
-	// Subscribe to NATS subject
-	_, err := s.client.Subscribe(subject, func(msg *nats.Msg) {
 		// Process message with all registered handlers for this subject
-		for _, h := range s.handlers[subject] {
-			if err := h(msg.Data); err != nil {
+		var errs []error
+		for _, h := range handlers {
+			if err := h(data); err != nil {
 				log.Printf("❌ Error processing message on subject %s: %v", subject, err)
+				errs = append(errs, err)
 			}
 		}
-	})
+		return errors.Join(errs...)
+	}
 
+	if durableBus, ok := s.client.(DurableBus); ok && durable {
+		_, err := durableBus.SubscribeDurable(subject, s.consumer, dispatch)
+		if err == nil {
+			log.Printf("📥 Durably subscribed to subject: %s (consumer: %s)", subject, s.consumer)
+			return nil
+		}
+		log.Printf("⚠️ Durable subscription to %s unavailable, messages may be lost on a crash: %v", subject, err)
+	}
+
+	// Subscribe to subject on the bus
+	_, err := s.client.Subscribe(subject, func(msg *Message) { dispatch(msg) })
 	if err != nil {
+		s.mu.Lock()
+		delete(s.handlers, subject)
+		s.mu.Unlock()
 		return err
 	}
 
@@ -142,10 +205,25 @@ func (s *Subscriber) subscribe(subject string, handler MessageHandler) error {
 	return nil
 }
 
+// open unwraps a message received on subject. A message its schema rejects
+// is logged and dropped; redelivering it wouldn't help.
+func open(subject string, data []byte) ([]byte, bool) {
+	payload, env, err := Open(subject, data)
+	if err != nil {
+		log.Printf("❌ Rejected message on subject %s (correlation %q): %v", subject, env.CorrelationID, err)
+		return nil, false
+	}
+	return payload, true
+}
+
 // Queue subscription for load balancing
 func (s *Subscriber) QueueSubscribe(subject, queue string, handler MessageHandler) error {
-	_, err := s.client.QueueSubscribe(subject, queue, func(msg *nats.Msg) {
-		if err := handler(msg.Data); err != nil {
+	_, err := s.client.QueueSubscribe(subject, queue, func(msg *Message) {
+		data, ok := open(subject, msg.Data)
+		if !ok {
+			return
+		}
+		if err := handler(data); err != nil {
 			log.Printf("❌ Error processing queued message on subject %s: %v", subject, err)
 		}
 	})
@@ -160,21 +238,25 @@ func (s *Subscriber) QueueSubscribe(subject, queue string, handler MessageHandle
 
 // Request-Reply pattern
 func (s *Subscriber) RegisterRequestHandler(subject string, handler func(data []byte) (interface{}, error)) error {
-	_, err := s.client.Subscribe(subject, func(msg *nats.Msg) {
-		response, err := handler(msg.Data)
+	_, err := s.client.Subscribe(subject, func(msg *Message) {
+		data, _, err := Open(subject, msg.Data)
+		var response interface{}
+		if err == nil {
+			response, err = handler(data)
+		}
 		if err != nil {
 			log.Printf("❌ Error processing request on subject %s: %v", subject, err)
 			// Send error response
 			errorResponse := map[string]string{"error": err.Error()}
 			if responseData, marshalErr := json.Marshal(errorResponse); marshalErr == nil {
-				msg.Respond(responseData)
+				s.client.Respond(msg, responseData)
 			}
 			return
 		}
 
 		// Send successful response
 		if responseData, err := json.Marshal(response); err == nil {
-			msg.Respond(responseData)
+			s.client.Respond(msg, responseData)
 		} else {
 			log.Printf("❌ Error marshaling response: %v", err)
 		}
@@ -188,15 +270,17 @@ func (s *Subscriber) RegisterRequestHandler(subject string, handler func(data []
 	return nil
 }
 
-// RegisterExecuteCommandHandler registers handler for execution commands
+// RegisterExecuteCommandHandler registers handler for execution commands.
+// A command whose handler fails is delivered again unless the error is
+// Permanent.
 func (s *Subscriber) RegisterExecuteCommandHandler(handler func(event ExecuteCommand) error) error {
 	messageHandler := func(data []byte) error {
 		var event ExecuteCommand
 		if err := json.Unmarshal(data, &event); err != nil {
-			return err
+			return Permanent(err)
 		}
 		return handler(event)
 	}
 
-	return s.subscribe("cdnbuddy.execute", messageHandler)
+	return s.subscribeDurable(SubjectExecute, messageHandler)
 }
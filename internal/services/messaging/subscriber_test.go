@@ -0,0 +1,147 @@
+package messaging_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/avvvet/cdnbuddy-api/internal/services/messaging"
+	"github.com/avvvet/cdnbuddy-api/internal/testutil"
+)
+
+func TestSubscriberDeliversEvents(t *testing.T) {
+	svc := testutil.NewServiceBuilder().WithName("shop.example.com").Build()
+	dom := testutil.NewDomainBuilder().WithService(svc.ID).WithName("shop.example.com").Build()
+
+	tests := []struct {
+		name     string
+		register func(s *messaging.Subscriber, got chan<- interface{}) error
+		publish  func(bus messaging.Bus) error
+		want     interface{}
+	}{
+		{
+			name: "service created",
+			register: func(s *messaging.Subscriber, got chan<- interface{}) error {
+				return s.RegisterCDNServiceHandler(func(e messaging.CDNServiceEvent) error {
+					e.Timestamp = testutil.Epoch
+					got <- e
+					return nil
+				})
+			},
+			publish: func(bus messaging.Bus) error { return messaging.NewPublisher(bus).PublishCDNServiceCreated(&svc) },
+			want:    testutil.ServiceEvent(messaging.EventCDNServiceCreated, svc),
+		},
+		{
+			name: "domain added",
+			register: func(s *messaging.Subscriber, got chan<- interface{}) error {
+				return s.RegisterDomainHandler(func(e messaging.DomainEvent) error {
+					e.Timestamp = testutil.Epoch
+					got <- e
+					return nil
+				})
+			},
+			publish: func(bus messaging.Bus) error { return messaging.NewPublisher(bus).PublishDomainAdded(&dom) },
+			want:    testutil.DomainEvent(messaging.EventDomainAdded, dom),
+		},
+		{
+			name: "service created in its envelope",
+			register: func(s *messaging.Subscriber, got chan<- interface{}) error {
+				return s.RegisterCDNServiceHandler(func(e messaging.CDNServiceEvent) error {
+					e.Timestamp = testutil.Epoch
+					got <- e
+					return nil
+				})
+			},
+			publish: func(bus messaging.Bus) error {
+				publisher := messaging.NewPublisher(bus)
+				publisher.SetEnvelope(true)
+				return publisher.PublishCDNServiceCreated(&svc)
+			},
+			want: testutil.ServiceEvent(messaging.EventCDNServiceCreated, svc),
+		},
+		{
+			name: "cache purged",
+			register: func(s *messaging.Subscriber, got chan<- interface{}) error {
+				return s.RegisterCacheHandler(func(e messaging.CacheEvent) error {
+					e.Timestamp = testutil.Epoch
+					got <- e
+					return nil
+				})
+			},
+			publish: func(bus messaging.Bus) error {
+				return messaging.NewPublisher(bus).PublishCachePurged(svc.ID, "user-1", []string{"/index.html"})
+			},
+			want: testutil.PurgeEvent(svc.ID, "user-1", "/index.html"),
+		},
+		{
+			name: "execute command",
+			register: func(s *messaging.Subscriber, got chan<- interface{}) error {
+				return s.RegisterExecuteCommandHandler(func(e messaging.ExecuteCommand) error {
+					e.Timestamp = testutil.Epoch
+					got <- e
+					return nil
+				})
+			},
+			publish: func(bus messaging.Bus) error {
+				return bus.Publish(messaging.SubjectExecute, testutil.ExecuteCommand("user-1", "session-1", "plan-1"))
+			},
+			want: testutil.ExecuteCommand("user-1", "session-1", "plan-1"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bus := testutil.StartNATS(t)
+			got := make(chan interface{}, 1)
+			if err := tt.register(messaging.NewSubscriber(bus), got); err != nil {
+				t.Fatalf("register handler: %v", err)
+			}
+			if err := tt.publish(bus); err != nil {
+				t.Fatalf("publish: %v", err)
+			}
+
+			// Published events are stamped with the current time; handlers reset it to Epoch
+			if event := testutil.Await(t, got); !reflect.DeepEqual(event, tt.want) {
+				t.Errorf("received %+v, want %+v", event, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubscriberFansOutToAllHandlers(t *testing.T) {
+	bus := testutil.StartNATS(t)
+	client := messaging.NewClientWithBus(bus)
+
+	got := make(chan string, 2)
+	for _, name := range []string{"first", "second"} {
+		if err := client.Subscriber().RegisterCacheHandler(func(e messaging.CacheEvent) error {
+			got <- name
+			return nil
+		}); err != nil {
+			t.Fatalf("register %s handler: %v", name, err)
+		}
+	}
+
+	if err := client.Publisher().PublishCachePurged("svc-1", "user-1", []string{"/"}); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+
+	seen := map[string]bool{testutil.Await(t, got): true, testutil.Await(t, got): true}
+	if !seen["first"] || !seen["second"] {
+		t.Errorf("handlers called = %v, want first and second", seen)
+	}
+}
+
+func TestSubscriberLimitsHandlersPerSubject(t *testing.T) {
+	bus := testutil.StartNATS(t)
+	subscriber := messaging.NewSubscriber(bus)
+
+	noop := func(messaging.MetricsEvent) error { return nil }
+	for i := 0; i < 32; i++ {
+		if err := subscriber.RegisterMetricsHandler(noop); err != nil {
+			t.Fatalf("register handler %d: %v", i+1, err)
+		}
+	}
+	if err := subscriber.RegisterMetricsHandler(noop); err == nil {
+		t.Error("registering handler 33 succeeded, want an error")
+	}
+}
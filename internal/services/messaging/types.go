@@ -39,10 +39,32 @@ type ExecutionPlan struct {
 	EstimatedDuration string             `json:"estimated_duration"`
 	Action            string             `json:"action"`
 	Parameters        map[string]*string `json:"parameters"`
+	Preview           interface{}        `json:"preview,omitempty"`
+	Changes           interface{}        `json:"changes,omitempty"`
 	CreatedAt         time.Time          `json:"created_at"`
 	ExpiresAt         time.Time          `json:"expires_at"`
 }
 
+// IntentValidationEvent tells the intent service which parameters of a READY
+// intent are missing or invalid, so it can ask the user for them
+type IntentValidationEvent struct {
+	UserID     string             `json:"user_id"`
+	SessionID  string             `json:"session_id"`
+	Action     string             `json:"action"`
+	Parameters map[string]*string `json:"parameters"`
+	Problems   interface{}        `json:"problems"`
+	Timestamp  time.Time          `json:"timestamp"`
+}
+
+// SessionResetEvent tells the intent service a conversation was cleared, so
+// it drops the state it keeps for it
+type SessionResetEvent struct {
+	UserID         string    `json:"user_id"`
+	SessionID      string    `json:"session_id"`
+	ConversationID string    `json:"conversation_id"`
+	Timestamp      time.Time `json:"timestamp"`
+}
+
 // ExecutionPlanEvent represents an execution plan sent to the user
 type ExecutionPlanEvent struct {
 	UserID    string        `json:"user_id"`
@@ -55,8 +77,16 @@ type ExecutionPlanEvent struct {
 type ExecuteCommand struct {
 	UserID    string    `json:"user_id"`
 	SessionID string    `json:"session_id"`
+	SandboxID string    `json:"sandbox_id,omitempty"`
 	PlanID    string    `json:"plan_id"`
 	Timestamp time.Time `json:"timestamp"`
+
+	// Set when the API already accepted the execution as this operation
+	OperationID string `json:"operation_id,omitempty"`
+
+	// Set to run the plan later in a maintenance window instead of now
+	RunAt         string `json:"run_at,omitempty"`         // RFC 3339 or "HH:MM" UTC
+	WindowMinutes int    `json:"window_minutes,omitempty"` // 0 = one hour
 }
 
 type PlanStep struct {
@@ -207,7 +237,9 @@ type AnalyticsResponse struct {
 // Notification types
 type NotificationEvent struct {
 	Type      string                 `json:"type"`
+	OrgID     string                 `json:"org_id,omitempty"`
 	UserID    string                 `json:"user_id"`
+	ServiceID string                 `json:"service_id,omitempty"`
 	Title     string                 `json:"title"`
 	Message   string                 `json:"message"`
 	Level     string                 `json:"level"` // info, warning, error, success
@@ -0,0 +1,178 @@
+package operations
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// defaultDuration is assumed for actions without enough finished operations
+const defaultDuration = 30 * time.Second
+
+// minSamples is how many finished operations a key needs before its
+// percentiles are trusted for ETAs and stuck thresholds
+const minSamples = 5
+
+// durationKey groups durations by action and provider
+type durationKey struct {
+	action   string
+	provider string
+}
+
+type durationSamples struct {
+	samples  []time.Duration // ring buffer of the most recent durations
+	next     int
+	count    int64 // all-time, including evicted samples
+	failures int64
+}
+
+// DurationStats summarizes the recent durations of one action on one provider
+type DurationStats struct {
+	Action   string        `json:"action"`
+	Provider string        `json:"provider,omitempty"`
+	Count    int64         `json:"count"`
+	Failures int64         `json:"failures"`
+	Samples  int           `json:"samples"` // recent durations the percentiles are based on
+	Mean     time.Duration `json:"mean"`
+	P50      time.Duration `json:"p50"`
+	P90      time.Duration `json:"p90"`
+	P99      time.Duration `json:"p99"`
+	Max      time.Duration `json:"max"`
+}
+
+// Durations keeps execution duration distributions per action and provider
+type Durations struct {
+	maxSamples int
+	keys       map[durationKey]*durationSamples
+	mu         sync.RWMutex
+}
+
+// NewDurations creates a collector keeping the last maxSamples durations per key
+func NewDurations(maxSamples int) *Durations {
+	if maxSamples <= 0 {
+		maxSamples = 500
+	}
+	return &Durations{maxSamples: maxSamples, keys: make(map[durationKey]*durationSamples)}
+}
+
+// Record adds the duration of a finished operation
+func (d *Durations) Record(action, provider string, duration time.Duration, success bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	key := durationKey{action: action, provider: provider}
+	s, ok := d.keys[key]
+	if !ok {
+		s = &durationSamples{samples: make([]time.Duration, 0, d.maxSamples)}
+		d.keys[key] = s
+	}
+	if len(s.samples) < d.maxSamples {
+		s.samples = append(s.samples, duration)
+	} else {
+		s.samples[s.next] = duration
+		s.next = (s.next + 1) % d.maxSamples
+	}
+	s.count++
+	if !success {
+		s.failures++
+	}
+}
+
+// Estimate is the expected duration of an action on a provider: the median
+// of its recent runs, of the action on any provider, or defaultDuration
+func (d *Durations) Estimate(action, provider string) time.Duration {
+	if stats, ok := d.stats(action, provider); ok {
+		return stats.P50
+	}
+	return defaultDuration
+}
+
+// StuckAfter is how long an operation may run before it's considered stuck:
+// twice the 99th percentile of recent runs, or fallback without enough history
+func (d *Durations) StuckAfter(action, provider string, fallback time.Duration) time.Duration {
+	if stats, ok := d.stats(action, provider); ok && 2*stats.P99 > 0 {
+		return 2 * stats.P99
+	}
+	return fallback
+}
+
+// stats summarizes the key, falling back to the action across providers
+func (d *Durations) stats(action, provider string) (DurationStats, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if s, ok := d.keys[durationKey{action: action, provider: provider}]; ok && len(s.samples) >= minSamples {
+		return summarize(action, provider, s), true
+	}
+
+	merged := &durationSamples{}
+	for key, s := range d.keys {
+		if key.action == action {
+			merged.samples = append(merged.samples, s.samples...)
+		}
+	}
+	if len(merged.samples) < minSamples {
+		return DurationStats{}, false
+	}
+	return summarize(action, "", merged), true
+}
+
+// Report summarizes every action and provider, slowest median first
+func (d *Durations) Report() []DurationStats {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	report := make([]DurationStats, 0, len(d.keys))
+	for key, s := range d.keys {
+		report = append(report, summarize(key.action, key.provider, s))
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].P50 != report[j].P50 {
+			return report[i].P50 > report[j].P50
+		}
+		if report[i].Action != report[j].Action {
+			return report[i].Action < report[j].Action
+		}
+		return report[i].Provider < report[j].Provider
+	})
+	return report
+}
+
+func summarize(action, provider string, s *durationSamples) DurationStats {
+	stats := DurationStats{
+		Action:   action,
+		Provider: provider,
+		Count:    s.count,
+		Failures: s.failures,
+		Samples:  len(s.samples),
+	}
+	if len(s.samples) == 0 {
+		return stats
+	}
+
+	sorted := append([]time.Duration(nil), s.samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+	}
+	stats.Mean = total / time.Duration(len(sorted))
+	stats.P50 = percentile(sorted, 0.50)
+	stats.P90 = percentile(sorted, 0.90)
+	stats.P99 = percentile(sorted, 0.99)
+	stats.Max = sorted[len(sorted)-1]
+	return stats
+}
+
+// percentile returns the nearest-rank percentile of sorted durations
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(p*float64(len(sorted))+0.5) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
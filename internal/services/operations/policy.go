@@ -0,0 +1,152 @@
+package operations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Policy controls how the executor runs one action. Provider calls inside an
+// action are retried by the provider layer; these retries repeat the whole
+// action and only apply to idempotent ones.
+type Policy struct {
+	Timeout    time.Duration `json:"timeout"`    // per attempt
+	Retries    int           `json:"retries"`    // attempts after the first
+	Backoff    time.Duration `json:"backoff"`    // before the first retry, doubled each time
+	Idempotent bool          `json:"idempotent"` // running the action twice has the same effect as once
+}
+
+// DefaultPolicy applies to actions missing from the policy table
+var DefaultPolicy = Policy{Timeout: 2 * time.Minute}
+
+// DefaultPolicies reflect each action's provider latency: creating a service
+// provisions config across the network, reads and purges return quickly.
+// Settings updates write the full desired state, so they're safe to repeat.
+var DefaultPolicies = map[string]Policy{
+	"SETUP_CDN":               {Timeout: 5 * time.Minute, Retries: 1, Backoff: 5 * time.Second, Idempotent: true}, // re-runs reconcile the existing service
+	"ADD_DOMAIN":              {Timeout: time.Minute},
+	"DELETE_SERVICE":          {Timeout: time.Minute},
+	"IMPORT_SERVICES":         {Timeout: 10 * time.Minute},
+	"PURGE_CACHE":             {Timeout: 30 * time.Second, Retries: 2, Backoff: time.Second, Idempotent: true},
+	"PURGE_ALL":               {Timeout: 30 * time.Second, Retries: 2, Backoff: time.Second, Idempotent: true},
+	"LIST_SERVICES":           {Timeout: 30 * time.Second, Retries: 2, Backoff: time.Second, Idempotent: true},
+	"FIND_SERVICE":            {Timeout: 30 * time.Second, Retries: 2, Backoff: time.Second, Idempotent: true},
+	"ACCOUNT_STATUS":          {Timeout: 30 * time.Second, Retries: 2, Backoff: time.Second, Idempotent: true},
+	"UPDATE_CACHE_RULES":      {Timeout: time.Minute, Retries: 1, Backoff: 2 * time.Second, Idempotent: true},
+	"SET_STALE_POLICY":        {Timeout: time.Minute, Retries: 1, Backoff: 2 * time.Second, Idempotent: true},
+	"CONFIGURE_ORIGIN_SHIELD": {Timeout: time.Minute, Retries: 1, Backoff: 2 * time.Second, Idempotent: true},
+	"SECURE_SERVICE":          {Timeout: time.Minute, Retries: 1, Backoff: 2 * time.Second, Idempotent: true},
+	"ADD_HEADER":              {Timeout: time.Minute, Retries: 1, Backoff: 2 * time.Second, Idempotent: true},
+	"PROTECT_HOTLINKS":        {Timeout: time.Minute, Retries: 1, Backoff: 2 * time.Second, Idempotent: true},
+	"UNDO":                    {Timeout: time.Minute, Retries: 1, Backoff: 2 * time.Second, Idempotent: true}, // restores the same options until it succeeds
+}
+
+// Policies is the policy table the executor consults
+type Policies struct {
+	overrides map[string]Policy
+}
+
+// NewPolicies creates a policy table with overrides on top of the defaults
+func NewPolicies(overrides map[string]Policy) *Policies {
+	return &Policies{overrides: overrides}
+}
+
+// For returns the policy of an action
+func (p *Policies) For(action string) Policy {
+	if policy, ok := p.overrides[action]; ok {
+		return policy
+	}
+	if policy, ok := DefaultPolicies[action]; ok {
+		return policy
+	}
+	return DefaultPolicy
+}
+
+// ParsePolicies parses "SETUP_CDN=10m,PURGE_CACHE=20s:3:500ms" (timeout,
+// optional retries and backoff); omitted fields keep the action's default.
+// Retries can't be configured for actions that aren't idempotent.
+func ParsePolicies(spec string) (map[string]Policy, error) {
+	policies := make(map[string]Policy)
+	for _, pair := range strings.Split(spec, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid operation policy %q (expected ACTION=timeout[:retries[:backoff]])", pair)
+		}
+		action := strings.ToUpper(strings.TrimSpace(name))
+		policy, known := DefaultPolicies[action]
+		if !known {
+			policy = DefaultPolicy
+		}
+
+		parts := strings.Split(value, ":")
+		timeout, err := time.ParseDuration(strings.TrimSpace(parts[0]))
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("invalid timeout in %q", pair)
+		}
+		policy.Timeout = timeout
+		if len(parts) > 1 {
+			retries, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+			if err != nil || retries < 0 {
+				return nil, fmt.Errorf("invalid retries in %q", pair)
+			}
+			if retries > 0 && !policy.Idempotent {
+				return nil, fmt.Errorf("%s is not idempotent and can't be retried", action)
+			}
+			policy.Retries = retries
+		}
+		if len(parts) > 2 {
+			if policy.Backoff, err = time.ParseDuration(strings.TrimSpace(parts[2])); err != nil {
+				return nil, fmt.Errorf("invalid backoff in %q", pair)
+			}
+		}
+
+		policies[action] = policy
+	}
+	return policies, nil
+}
+
+// Run runs fn under the policy: each attempt gets its own timeout, and
+// idempotent actions are retried when an attempt times out or retryable
+// reports the error as transient
+func Run(ctx context.Context, action string, policy Policy, retryable func(error) bool, fn func(ctx context.Context) (string, error)) (string, error) {
+	backoff := policy.Backoff
+	for attempt := 0; ; attempt++ {
+		attemptCtx, cancel := context.WithTimeout(ctx, policy.Timeout)
+		result, err := fn(attemptCtx)
+		timedOut := errors.Is(attemptCtx.Err(), context.DeadlineExceeded)
+		cancel()
+		if err == nil {
+			return result, nil
+		}
+		if timedOut {
+			err = fmt.Errorf("%s timed out after %s: %w", action, policy.Timeout, err)
+		}
+
+		if !policy.Idempotent || attempt >= policy.Retries || ctx.Err() != nil || !(timedOut || retryable(err)) {
+			return result, err
+		}
+
+		logrus.WithError(err).WithFields(logrus.Fields{
+			"action":  action,
+			"attempt": attempt + 1,
+			"delay":   backoff,
+		}).Warn("🔁 Retrying operation")
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return result, err
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+}
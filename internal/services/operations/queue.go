@@ -0,0 +1,267 @@
+package operations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrNotCancellable is returned when cancelling an operation that already
+// finished or can't be interrupted
+var ErrNotCancellable = errors.New("operation can no longer be cancelled")
+
+// Queue runs operations on the same service one at a time: provider settings
+// are read, modified and written back, so concurrent changes would overwrite
+// each other. Operations without a service never wait.
+type Queue struct {
+	store     *Store
+	durations *Durations
+	lanes     map[string][]string      // service ID -> operation IDs, the running one first
+	ready     map[string]chan struct{} // operation ID -> closed when it may start
+	cancels   map[string]context.CancelCauseFunc
+	mu        sync.Mutex
+}
+
+// NewQueue creates a queue recording its operations in store and estimating
+// their durations from durations
+func NewQueue(store *Store, durations *Durations) *Queue {
+	return &Queue{
+		store:     store,
+		durations: durations,
+		lanes:     make(map[string][]string),
+		ready:     make(map[string]chan struct{}),
+		cancels:   make(map[string]context.CancelCauseFunc),
+	}
+}
+
+// Accept records an operation that another worker will run, so clients can
+// follow it before it reaches the queue. It stays queued until that worker
+// enqueues it under the same ID (see WithID), and can be cancelled meanwhile.
+func (q *Queue) Accept(op Operation) Operation {
+	if op.ID == "" {
+		op.ID = uuid.New().String()
+	}
+	op.Status = StatusQueued
+	op.StartedAt = time.Now()
+	q.store.ops.Put(op.ID, op)
+	return op
+}
+
+// Enqueue records an operation, running when its service is free and queued
+// behind the others otherwise, with its position and estimated start and finish.
+// An accepted operation cancelled before it got here is returned as is.
+func (q *Queue) Enqueue(op Operation) Operation {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if accepted, err := q.store.Get(op.ID); op.ID != "" && err == nil && accepted.Status == StatusCancelled {
+		return accepted
+	}
+	op = q.store.Start(op)
+	if op.ServiceID == "" {
+		return op
+	}
+
+	lane := append(q.lanes[op.ServiceID], op.ID)
+	q.lanes[op.ServiceID] = lane
+	if len(lane) == 1 {
+		return op
+	}
+
+	q.ready[op.ID] = make(chan struct{})
+	op.Status = StatusQueued
+	q.store.ops.Put(op.ID, op)
+	q.estimateLocked(op.ServiceID)
+
+	op, _ = q.store.Get(op.ID)
+	return op
+}
+
+type idKey struct{}
+
+// WithID carries the ID of an accepted operation to the worker that runs it
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, idKey{}, id)
+}
+
+// IDFrom returns the ID set by WithID, or ""
+func IDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(idKey{}).(string)
+	return id
+}
+
+// Cancellable returns a context that Cancel stops while the operation runs;
+// operations run without one can only be cancelled while queued
+func (q *Queue) Cancellable(ctx context.Context, id string) context.Context {
+	ctx, cancel := context.WithCancelCause(ctx)
+
+	q.mu.Lock()
+	q.cancels[id] = cancel
+	q.mu.Unlock()
+	return ctx
+}
+
+// Wait blocks until a queued operation may start and marks it running. It
+// returns ErrCancelled if the operation was cancelled while waiting.
+func (q *Queue) Wait(ctx context.Context, id string) error {
+	q.mu.Lock()
+	ready, queued := q.ready[id]
+	q.mu.Unlock()
+
+	if queued {
+		select {
+		case <-ready:
+		case <-ctx.Done():
+			q.Done(id)
+			return ctx.Err()
+		}
+	}
+	if op, err := q.store.Get(id); err == nil && op.Status == StatusCancelled {
+		return ErrCancelled
+	}
+	return nil
+}
+
+// Cancel stops an operation of a user (any user if userID is empty). Queued
+// operations leave the queue at once; running ones are interrupted and marked
+// cancelled when they return.
+func (q *Queue) Cancel(id, userID string) (Operation, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	op, err := q.store.Get(id)
+	if err != nil || (userID != "" && op.UserID != userID) {
+		return Operation{}, fmt.Errorf("operation not found: %s", id)
+	}
+
+	switch op.Status {
+	case StatusQueued:
+		now := time.Now()
+		op.Status = StatusCancelled
+		op.Error = ErrCancelled.Error()
+		op.FinishedAt = &now
+		op.QueuePosition, op.EstimatedStart, op.EstimatedFinish = 0, nil, nil
+		q.store.ops.Put(id, op)
+
+		q.removeLocked(op.ServiceID, id)
+		if ready, ok := q.ready[id]; ok {
+			delete(q.ready, id)
+			close(ready)
+		}
+		q.estimateLocked(op.ServiceID)
+	case StatusRunning:
+		cancel, ok := q.cancels[id]
+		if !ok || op.CancelRequested {
+			return Operation{}, fmt.Errorf("%w: it is already running", ErrNotCancellable)
+		}
+		op.CancelRequested = true
+		q.store.ops.Put(id, op)
+		cancel(ErrCancelled)
+	default:
+		return Operation{}, fmt.Errorf("%w: it is %s", ErrNotCancellable, op.Status)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"operation_id": id,
+		"status":       op.Status,
+	}).Info("🚫 Operation cancelled")
+	return op, nil
+}
+
+// Finish records the result of an operation that ran, and its duration
+func (q *Queue) Finish(id, result string, err error) {
+	op, finishErr := q.store.Finish(id, result, err)
+	if finishErr != nil || op.QueuePosition > 0 || op.Status == StatusCancelled {
+		// Gone from the store, abandoned while still queued, or cut short
+		return
+	}
+	q.durations.Record(op.Action, op.Provider, op.FinishedAt.Sub(op.StartedAt), err == nil)
+}
+
+// Done removes a finished (or abandoned) operation, starting the next one of its service
+func (q *Queue) Done(id string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	delete(q.ready, id)
+	if cancel, ok := q.cancels[id]; ok {
+		delete(q.cancels, id)
+		cancel(nil)
+	}
+	op, err := q.store.Get(id)
+	if err != nil || op.ServiceID == "" {
+		return
+	}
+
+	lane := q.removeLocked(op.ServiceID, id)
+	if len(lane) == 0 {
+		return
+	}
+
+	// Start the head of the lane if it was waiting
+	if ready, ok := q.ready[lane[0]]; ok {
+		delete(q.ready, lane[0])
+		if next, err := q.store.Get(lane[0]); err == nil {
+			next.Status = StatusRunning
+			next.StartedAt = time.Now()
+			next.QueuePosition = 0
+			next.EstimatedStart = nil
+			q.store.ops.Put(next.ID, next)
+		}
+		close(ready)
+	}
+	q.estimateLocked(op.ServiceID)
+}
+
+// removeLocked takes an operation out of its service's lane and returns what's left
+func (q *Queue) removeLocked(serviceID, id string) []string {
+	lane := q.lanes[serviceID]
+	for i, queuedID := range lane {
+		if queuedID == id {
+			lane = append(lane[:i:i], lane[i+1:]...)
+			break
+		}
+	}
+	if len(lane) == 0 {
+		delete(q.lanes, serviceID)
+		return nil
+	}
+	q.lanes[serviceID] = lane
+	return lane
+}
+
+// estimateLocked refreshes the positions and ETAs of a service's queued
+// operations from the durations of earlier operations
+func (q *Queue) estimateLocked(serviceID string) {
+	now := time.Now()
+	start := now
+	for i, id := range q.lanes[serviceID] {
+		op, err := q.store.Get(id)
+		if err != nil {
+			continue
+		}
+		duration := q.durations.Estimate(op.Action, op.Provider)
+
+		if op.Status == StatusRunning {
+			// Whatever is left of the running operation, at least a moment
+			finish := op.StartedAt.Add(duration)
+			if finish.Before(now) {
+				finish = now
+			}
+			op.EstimatedFinish = &finish
+			start = finish
+		} else {
+			estimatedStart, finish := start, start.Add(duration)
+			op.QueuePosition = i
+			op.EstimatedStart = &estimatedStart
+			op.EstimatedFinish = &finish
+			start = finish
+		}
+		q.store.ops.Put(id, op)
+	}
+}
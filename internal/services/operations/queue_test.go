@@ -0,0 +1,159 @@
+package operations
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestQueueCancel(t *testing.T) {
+	tests := []struct {
+		name       string
+		setup      func(q *Queue) (id string, wait <-chan error)
+		userID     string
+		wantStatus Status
+		wantErr    error
+		wantWait   error
+	}{
+		{
+			name: "queued operation leaves the queue",
+			setup: func(q *Queue) (string, <-chan error) {
+				q.Enqueue(Operation{UserID: "user-1", ServiceID: "svc-1"})
+				op := q.Enqueue(Operation{UserID: "user-1", ServiceID: "svc-1"})
+				wait := make(chan error, 1)
+				go func() { wait <- q.Wait(context.Background(), op.ID) }()
+				return op.ID, wait
+			},
+			wantStatus: StatusCancelled,
+			wantWait:   ErrCancelled,
+		},
+		{
+			name: "running operation is interrupted",
+			setup: func(q *Queue) (string, <-chan error) {
+				op := q.Enqueue(Operation{UserID: "user-1", ServiceID: "svc-1"})
+				ctx := q.Cancellable(context.Background(), op.ID)
+				wait := make(chan error, 1)
+				go func() {
+					<-ctx.Done()
+					wait <- context.Cause(ctx)
+				}()
+				return op.ID, wait
+			},
+			wantStatus: StatusRunning,
+			wantWait:   ErrCancelled,
+		},
+		{
+			name: "running operation without a cancellable context",
+			setup: func(q *Queue) (string, <-chan error) {
+				return q.Enqueue(Operation{UserID: "user-1"}).ID, nil
+			},
+			wantErr: ErrNotCancellable,
+		},
+		{
+			name: "finished operation",
+			setup: func(q *Queue) (string, <-chan error) {
+				op := q.Enqueue(Operation{UserID: "user-1"})
+				q.Finish(op.ID, "done", nil)
+				return op.ID, nil
+			},
+			wantErr: ErrNotCancellable,
+		},
+		{
+			name: "another user's operation",
+			setup: func(q *Queue) (string, <-chan error) {
+				return q.Enqueue(Operation{UserID: "user-1"}).ID, nil
+			},
+			userID:  "user-2",
+			wantErr: errors.New("operation not found"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := NewQueue(NewStore(10), NewDurations(10))
+			id, wait := tt.setup(q)
+
+			op, err := q.Cancel(id, tt.userID)
+			if tt.wantErr != nil {
+				if err == nil || (errors.Is(tt.wantErr, ErrNotCancellable) && !errors.Is(err, ErrNotCancellable)) {
+					t.Fatalf("Cancel() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Cancel() unexpected error: %v", err)
+			}
+			if op.Status != tt.wantStatus {
+				t.Errorf("Cancel() status = %s, want %s", op.Status, tt.wantStatus)
+			}
+
+			select {
+			case got := <-wait:
+				if !errors.Is(got, tt.wantWait) {
+					t.Errorf("waiter got %v, want %v", got, tt.wantWait)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("waiter was not released")
+			}
+		})
+	}
+}
+
+func TestFinishAfterCancelRequest(t *testing.T) {
+	q := NewQueue(NewStore(10), NewDurations(10))
+	op := q.Enqueue(Operation{UserID: "user-1", ServiceID: "svc-1"})
+	ctx := q.Cancellable(context.Background(), op.ID)
+
+	if _, err := q.Cancel(op.ID, ""); err != nil {
+		t.Fatalf("Cancel() unexpected error: %v", err)
+	}
+	q.Finish(op.ID, "", ctx.Err())
+	q.Done(op.ID)
+
+	got, err := q.store.Get(op.ID)
+	if err != nil {
+		t.Fatalf("Get() unexpected error: %v", err)
+	}
+	if got.Status != StatusCancelled {
+		t.Errorf("status after finishing = %s, want %s", got.Status, StatusCancelled)
+	}
+	if len(q.store.Query(Filter{Status: StatusCancelled})) != 1 {
+		t.Error("Query() by status didn't return the cancelled operation")
+	}
+}
+
+func TestEnqueueAccepted(t *testing.T) {
+	tests := []struct {
+		name       string
+		cancel     bool
+		wantStatus Status
+	}{
+		{name: "runs under the accepted ID", wantStatus: StatusRunning},
+		{name: "cancelled before it was enqueued", cancel: true, wantStatus: StatusCancelled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := NewQueue(NewStore(10), NewDurations(10))
+			accepted := q.Accept(Operation{UserID: "user-1", ServiceID: "svc-1", Action: "PURGE_CACHE"})
+			if accepted.ID == "" || accepted.Status != StatusQueued {
+				t.Fatalf("Accept() = %q %s, want an ID and %s", accepted.ID, accepted.Status, StatusQueued)
+			}
+			if tt.cancel {
+				if _, err := q.Cancel(accepted.ID, "user-1"); err != nil {
+					t.Fatalf("Cancel() unexpected error: %v", err)
+				}
+			}
+
+			id := IDFrom(WithID(context.Background(), accepted.ID))
+			op := q.Enqueue(Operation{ID: id, UserID: "user-1", ServiceID: "svc-1", Action: "PURGE_CACHE"})
+			if op.ID != accepted.ID || op.Status != tt.wantStatus {
+				t.Errorf("Enqueue() = %q %s, want %q %s", op.ID, op.Status, accepted.ID, tt.wantStatus)
+			}
+			if got := len(q.store.List(0)); got != 1 {
+				t.Errorf("store holds %d operations, want 1", got)
+			}
+		})
+	}
+}
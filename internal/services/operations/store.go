@@ -0,0 +1,149 @@
+// Package operations records executed plans so their status and results can be
+// looked up after the chat response is gone.
+package operations
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/avvvet/cdnbuddy-api/internal/lru"
+)
+
+// Status of an operation
+type Status string
+
+const (
+	StatusQueued    Status = "queued" // waiting for other operations on the same service
+	StatusRunning   Status = "running"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+	StatusCancelled Status = "cancelled"
+)
+
+// ErrCancelled is the error of an operation cancelled before it finished
+var ErrCancelled = errors.New("operation cancelled")
+
+// Operation is one execution of a plan against a provider
+type Operation struct {
+	ID         string            `json:"id"`
+	PlanID     string            `json:"plan_id,omitempty"`
+	UserID     string            `json:"user_id"`
+	SessionID  string            `json:"session_id,omitempty"`
+	Action     string            `json:"action"`
+	Provider   string            `json:"provider,omitempty"`
+	Title      string            `json:"title,omitempty"`
+	ServiceID  string            `json:"service_id,omitempty"`
+	Domain     string            `json:"domain,omitempty"`
+	Parameters map[string]string `json:"parameters,omitempty"`
+	Status     Status            `json:"status"`
+	Result     string            `json:"result,omitempty"`
+	Error      string            `json:"error,omitempty"`
+	StartedAt  time.Time         `json:"started_at"` // queued at, until the operation starts
+	FinishedAt *time.Time        `json:"finished_at,omitempty"`
+
+	// Set when a running operation was asked to stop; it is cancelled once it does
+	CancelRequested bool `json:"cancel_requested,omitempty"`
+
+	// Set while queued (position 1 = next) and estimated from past durations
+	QueuePosition   int        `json:"queue_position,omitempty"`
+	EstimatedStart  *time.Time `json:"estimated_start,omitempty"`
+	EstimatedFinish *time.Time `json:"estimated_finish,omitempty"`
+}
+
+// Store keeps the most recent operations in memory
+type Store struct {
+	ops *lru.Cache[string, Operation]
+}
+
+// NewStore creates a store holding at most maxOperations operations
+func NewStore(maxOperations int) *Store {
+	return &Store{
+		ops: lru.New[string, Operation]("operations", maxOperations, 0),
+	}
+}
+
+// Start records a running operation, filling ID and start time
+func (s *Store) Start(op Operation) Operation {
+	if op.ID == "" {
+		op.ID = uuid.New().String()
+	}
+	op.Status = StatusRunning
+	op.StartedAt = time.Now()
+
+	s.ops.Put(op.ID, op)
+	logrus.WithFields(logrus.Fields{
+		"operation_id": op.ID,
+		"action":       op.Action,
+	}).Debug("⚙️ Operation started")
+	return op
+}
+
+// Finish marks an operation succeeded or failed
+func (s *Store) Finish(id, result string, err error) (Operation, error) {
+	op, ok := s.ops.Get(id)
+	if !ok {
+		return Operation{}, fmt.Errorf("operation not found: %s", id)
+	}
+
+	now := time.Now()
+	op.FinishedAt = &now
+	op.EstimatedFinish = nil
+	op.Status = StatusSucceeded
+	op.Result = result
+	switch {
+	case err == nil:
+	case errors.Is(err, ErrCancelled) || op.CancelRequested:
+		op.Status = StatusCancelled
+		op.Error = ErrCancelled.Error()
+	default:
+		op.Status = StatusFailed
+		op.Error = err.Error()
+	}
+
+	s.ops.Put(id, op)
+	return op, nil
+}
+
+// Get returns an operation by ID
+func (s *Store) Get(id string) (Operation, error) {
+	op, ok := s.ops.Get(id)
+	if !ok {
+		return Operation{}, fmt.Errorf("operation not found: %s", id)
+	}
+	return op, nil
+}
+
+// List returns operations, most recently updated first (0 = no limit)
+func (s *Store) List(limit int) []Operation {
+	return s.Query(Filter{Limit: limit})
+}
+
+// Filter selects operations; empty fields match everything
+type Filter struct {
+	UserID    string
+	ServiceID string
+	Status    Status
+	Limit     int
+}
+
+func (f Filter) matches(op Operation) bool {
+	return (f.UserID == "" || op.UserID == f.UserID) &&
+		(f.ServiceID == "" || op.ServiceID == f.ServiceID) &&
+		(f.Status == "" || op.Status == f.Status)
+}
+
+// Query returns the operations matching f, most recently updated first
+func (s *Store) Query(f Filter) []Operation {
+	ops := make([]Operation, 0)
+	s.ops.Range(func(_ string, op Operation) bool {
+		if f.matches(op) {
+			ops = append(ops, op)
+		}
+		return f.Limit <= 0 || len(ops) < f.Limit
+	})
+	return ops
+}
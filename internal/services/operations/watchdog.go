@@ -0,0 +1,99 @@
+package operations
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/avvvet/cdnbuddy-api/internal/metrics"
+)
+
+// StuckOperation is a running operation that has exceeded its threshold
+type StuckOperation struct {
+	Operation Operation     `json:"operation"`
+	Running   time.Duration `json:"running"`
+	Threshold time.Duration `json:"threshold"`
+}
+
+// Watchdog flags running operations that take much longer than the same
+// action usually does
+type Watchdog struct {
+	store     *Store
+	durations *Durations
+	policies  *Policies
+	stuck     map[string]StuckOperation
+	mu        sync.RWMutex
+}
+
+// NewWatchdog creates a watchdog over the operations in store
+func NewWatchdog(store *Store, durations *Durations, policies *Policies) *Watchdog {
+	return &Watchdog{
+		store:     store,
+		durations: durations,
+		policies:  policies,
+		stuck:     make(map[string]StuckOperation),
+	}
+}
+
+// Run checks running operations every interval until ctx is done
+func (w *Watchdog) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.Check()
+		}
+	}
+}
+
+// Check flags operations running past their threshold: twice the p99 of
+// the action's recent runs, or the policy's total time budget without history
+func (w *Watchdog) Check() {
+	now := time.Now()
+	stuck := make(map[string]StuckOperation)
+	for _, op := range w.store.List(0) {
+		if op.Status != StatusRunning {
+			continue
+		}
+		policy := w.policies.For(op.Action)
+		threshold := w.durations.StuckAfter(op.Action, op.Provider, policy.Timeout*time.Duration(policy.Retries+1))
+		running := now.Sub(op.StartedAt)
+		if running > threshold {
+			stuck[op.ID] = StuckOperation{Operation: op, Running: running, Threshold: threshold}
+		}
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for id, s := range stuck {
+		if _, flagged := w.stuck[id]; !flagged {
+			metrics.Inc("operations_stuck")
+			logrus.WithFields(logrus.Fields{
+				"operation_id": id,
+				"action":       s.Operation.Action,
+				"provider":     s.Operation.Provider,
+				"running":      s.Running.Round(time.Second),
+				"threshold":    s.Threshold.Round(time.Second),
+			}).Warn("🐢 Operation looks stuck")
+		}
+	}
+	w.stuck = stuck
+}
+
+// Stuck returns the operations flagged by the last check
+func (w *Watchdog) Stuck() []StuckOperation {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	stuck := make([]StuckOperation, 0, len(w.stuck))
+	for _, s := range w.stuck {
+		stuck = append(stuck, s)
+	}
+	return stuck
+}
@@ -0,0 +1,241 @@
+package ownership
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/avvvet/cdnbuddy-api/internal/domain"
+	"github.com/avvvet/cdnbuddy-api/internal/models"
+	"github.com/avvvet/cdnbuddy-api/internal/services/cdn"
+)
+
+// Candidate is a provider service found by discovery
+type Candidate struct {
+	Service domain.CDNService `json:"service"`
+	Domains []string          `json:"domains"`
+	Adopted bool              `json:"adopted"`
+	OwnerID string            `json:"owner_org_id,omitempty"` // org that adopted it
+}
+
+// Skipped is a service an adoption left alone
+type Skipped struct {
+	ServiceID string `json:"service_id"`
+	Reason    string `json:"reason"`
+}
+
+// AdoptResult is the outcome of an adoption
+type AdoptResult struct {
+	Adopted []Record  `json:"adopted"`
+	Skipped []Skipped `json:"skipped"`
+}
+
+// Importer discovers services that exist in provider accounts and adopts them
+type Importer struct {
+	store *Store
+}
+
+// NewImporter creates an importer recording into store
+func NewImporter(store *Store) *Importer {
+	return &Importer{store: store}
+}
+
+// Discover lists the active services of the account, marking ones already adopted
+func (i *Importer) Discover(ctx context.Context, svc *cdn.Service) ([]Candidate, error) {
+	services, err := svc.ListServices(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services: %w", err)
+	}
+
+	candidates := make([]Candidate, 0, len(services))
+	for _, service := range services {
+		c := Candidate{Service: service, Domains: make([]string, 0)}
+		if r, ok := i.store.Get(service.Provider, service.ID); ok {
+			c.Adopted = true
+			c.OwnerID = r.OrgID
+		}
+
+		domains, err := svc.ListServiceDomains(ctx, service)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list domains of %s: %w", service.ID, err)
+		}
+		for _, d := range domains {
+			c.Domains = append(c.Domains, d.Name)
+		}
+		candidates = append(candidates, c)
+	}
+
+	return candidates, nil
+}
+
+// Adopt brings the given services under management for orgID, backfilling
+// our config JSON from the provider's current configuration. Services
+// adopted by another org are skipped; re-adopting refreshes the config.
+func (i *Importer) Adopt(ctx context.Context, svc *cdn.Service, orgID, userID string, serviceIDs []string) (*AdoptResult, error) {
+	services, err := svc.ListServices(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services: %w", err)
+	}
+	byID := make(map[string]domain.CDNService, len(services))
+	for _, s := range services {
+		byID[s.ID] = s
+	}
+
+	result := &AdoptResult{Adopted: make([]Record, 0), Skipped: make([]Skipped, 0)}
+	for _, id := range serviceIDs {
+		service, ok := byID[id]
+		if !ok {
+			result.Skipped = append(result.Skipped, Skipped{ServiceID: id, Reason: "no active service with this ID"})
+			continue
+		}
+		if r, ok := i.store.Get(service.Provider, id); ok && r.OrgID != orgID {
+			result.Skipped = append(result.Skipped, Skipped{ServiceID: id, Reason: "already managed by another organization"})
+			continue
+		}
+
+		scoped, err := svc.ForProvider(service.Provider)
+		if err != nil {
+			scoped = svc
+		}
+		export, err := scoped.ExportService(ctx, id)
+		if err != nil {
+			result.Skipped = append(result.Skipped, Skipped{ServiceID: id, Reason: err.Error()})
+			continue
+		}
+
+		record := i.store.Put(Record{
+			ServiceID: id,
+			Provider:  service.Provider,
+			Name:      service.Name,
+			OrgID:     orgID,
+			UserID:    userID,
+			Source:    SourceImported,
+			Config:    backfillConfig(service.Config, export.Spec),
+			Warnings:  export.Warnings,
+		})
+		result.Adopted = append(result.Adopted, record)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"org_id":  orgID,
+		"adopted": len(result.Adopted),
+		"skipped": len(result.Skipped),
+	}).Info("📦 Imported provider services")
+
+	return result, nil
+}
+
+// backfillConfig merges the provider's config JSON with the spec read from
+// the provider, so imported services carry what created ones do
+func backfillConfig(providerConfig string, spec cdn.ServiceSpec) string {
+	config := make(map[string]interface{})
+	json.Unmarshal([]byte(providerConfig), &config)
+
+	config["origin"] = map[string]interface{}{
+		"host":     spec.Origin.Host,
+		"protocol": spec.Origin.Protocol,
+	}
+	config["domains"] = spec.Domains
+	if spec.Rules != nil {
+		config["rules"] = spec.Rules
+	}
+	config["imported"] = true
+
+	data, _ := json.Marshal(config)
+	return string(data)
+}
+
+// ExecuteIntent handles the IMPORT_SERVICES chat action: without service_ids
+// it lists what can be imported, otherwise it adopts the listed services
+// ("all" adopts every service not managed yet)
+func (i *Importer) ExecuteIntent(ctx context.Context, svc *cdn.Service, orgID, userID string, params map[string]*string) (string, error) {
+	candidates, err := i.Discover(ctx, svc)
+	if err != nil {
+		return "", err
+	}
+
+	var ids []string
+	if p := params["service_ids"]; p != nil {
+		ids = splitIDs(*p)
+	}
+
+	if len(ids) == 0 {
+		response := "📦 Services in your provider account:\n\n"
+		available := 0
+		for _, c := range candidates {
+			status := "can be imported"
+			if c.Adopted {
+				status = "already managed"
+			} else {
+				available++
+			}
+			response += fmt.Sprintf("• %s [%s] (ID: %s) — %s\n", c.Service.Name, c.Service.Provider, c.Service.ID, status)
+		}
+		if available == 0 {
+			return "All services in your provider account are already managed by CDNBuddy.", nil
+		}
+		return response + "\nTell me which ones to import, or say \"import all\".", nil
+	}
+
+	if len(ids) == 1 && strings.EqualFold(ids[0], "all") {
+		ids = ids[:0]
+		for _, c := range candidates {
+			if !c.Adopted {
+				ids = append(ids, c.Service.ID)
+			}
+		}
+	}
+
+	if models.IsDryRun(params) {
+		return describeDryRun(candidates, orgID, ids), nil
+	}
+
+	result, err := i.Adopt(ctx, svc, orgID, userID, ids)
+	if err != nil {
+		return "", err
+	}
+
+	response := fmt.Sprintf("✅ Imported %d service(s) into CDNBuddy.\n", len(result.Adopted))
+	for _, r := range result.Adopted {
+		response += fmt.Sprintf("   • %s (ID: %s)\n", r.Name, r.ServiceID)
+	}
+	for _, s := range result.Skipped {
+		response += fmt.Sprintf("⚠️ Skipped %s: %s\n", s.ServiceID, s.Reason)
+	}
+	return response, nil
+}
+
+// describeDryRun reports what adopting ids would do without recording anything
+func describeDryRun(candidates []Candidate, orgID string, ids []string) string {
+	byID := make(map[string]Candidate, len(candidates))
+	for _, c := range candidates {
+		byID[c.Service.ID] = c
+	}
+
+	response := "🧪 Dry run: nothing was imported.\n\n"
+	for _, id := range ids {
+		c, ok := byID[id]
+		switch {
+		case !ok:
+			response += fmt.Sprintf("⚠️ Would skip %s: no active service with this ID\n", id)
+		case c.Adopted && c.OwnerID != orgID:
+			response += fmt.Sprintf("⚠️ Would skip %s: already managed by another organization\n", id)
+		default:
+			response += fmt.Sprintf("   • Would import %s (ID: %s)\n", c.Service.Name, id)
+		}
+	}
+	return response
+}
+
+func splitIDs(s string) []string {
+	ids := make([]string, 0)
+	for _, id := range strings.Split(s, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,95 @@
+// Package ownership records which provider services CDNBuddy manages and for
+// whom, and adopts services that already exist in a provider account.
+package ownership
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/avvvet/cdnbuddy-api/internal/domain"
+)
+
+// Source is how a service came under management
+type Source string
+
+const (
+	SourceCreated  Source = "created"
+	SourceImported Source = "imported"
+)
+
+// Record is one managed service
+type Record struct {
+	ServiceID string             `json:"service_id"`
+	Provider  domain.CDNProvider `json:"provider"`
+	Name      string             `json:"name"`
+	OrgID     string             `json:"org_id"`
+	UserID    string             `json:"user_id"`
+	Source    Source             `json:"source"`
+	Config    string             `json:"config"` // our config JSON, backfilled on import
+	Warnings  []string           `json:"warnings,omitempty"`
+	AdoptedAt time.Time          `json:"adopted_at"`
+}
+
+// Store keeps ownership records in memory
+type Store struct {
+	records map[string]Record
+	mu      sync.RWMutex
+}
+
+// NewStore creates an empty ownership store
+func NewStore() *Store {
+	return &Store{records: make(map[string]Record)}
+}
+
+func key(provider domain.CDNProvider, serviceID string) string {
+	return string(provider) + "/" + serviceID
+}
+
+// Put stores a record, replacing any previous one for the service
+func (s *Store) Put(r Record) Record {
+	if r.AdoptedAt.IsZero() {
+		r.AdoptedAt = time.Now()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[key(r.Provider, r.ServiceID)] = r
+	return r
+}
+
+// Get returns the record of a service
+func (s *Store) Get(provider domain.CDNProvider, serviceID string) (Record, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	r, ok := s.records[key(provider, serviceID)]
+	return r, ok
+}
+
+// List returns the records of an org, oldest first
+func (s *Store) List(orgID string) []Record {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	records := make([]Record, 0)
+	for _, r := range s.records {
+		if r.OrgID == orgID {
+			records = append(records, r)
+		}
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].AdoptedAt.Before(records[j].AdoptedAt) })
+	return records
+}
+
+// CountByOrg returns how many services each org manages
+func (s *Store) CountByOrg() map[string]int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	counts := make(map[string]int)
+	for _, r := range s.records {
+		counts[r.OrgID]++
+	}
+	return counts
+}
@@ -1,24 +1,31 @@
 package planstorage
 
 import (
+	"errors"
 	"fmt"
-	"sync"
 	"time"
 
+	"github.com/avvvet/cdnbuddy-api/internal/lru"
 	"github.com/avvvet/cdnbuddy-api/internal/models"
 	"github.com/sirupsen/logrus"
 )
 
-// Storage manages pending execution plans in memory
+// ErrFull is returned by Store while the storage holds its maximum of
+// pending plans; none is dropped to make room, so their confirmations still work
+var ErrFull = errors.New("too many pending execution plans")
+
+// Storage manages pending execution plans in memory. They don't survive a
+// restart: an execute command redelivered to a restarted replica finds no plan,
+// and the user is asked to make a new one.
 type Storage struct {
-	plans map[string]*models.ExecutionPlan
-	mu    sync.RWMutex
+	plans *lru.Cache[string, *models.ExecutionPlan]
 }
 
-// NewStorage creates a new plan storage
-func NewStorage() *Storage {
+// NewStorage creates a new plan storage holding at most maxPlans pending plans
+// (0 = unbounded)
+func NewStorage(maxPlans int) *Storage {
 	s := &Storage{
-		plans: make(map[string]*models.ExecutionPlan),
+		plans: lru.New[string, *models.ExecutionPlan]("plans", maxPlans, 0),
 	}
 
 	// Start cleanup goroutine for expired plans
@@ -27,40 +34,40 @@ func NewStorage() *Storage {
 	return s
 }
 
-// Store saves an execution plan
+// Store saves an execution plan, or returns ErrFull when the storage is full
+// of plans that haven't expired
 func (s *Storage) Store(plan models.ExecutionPlan) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	s.plans[plan.ID] = &plan
+	if !s.plans.TryPutUntil(plan.ID, &plan, plan.ExpiresAt) {
+		logrus.WithField("plan_id", plan.ID).Warn("⚠️ Plan storage full, rejected execution plan")
+		return ErrFull
+	}
 	logrus.WithField("plan_id", plan.ID).Info("📦 Stored execution plan")
 	return nil
 }
 
 // Get retrieves a plan by ID
 func (s *Storage) Get(planID string) (*models.ExecutionPlan, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	plan, exists := s.plans[planID]
+	plan, exists := s.plans.Get(planID)
 	if !exists {
-		return nil, fmt.Errorf("plan not found: %s", planID)
-	}
-
-	// Check if expired
-	if time.Now().After(plan.ExpiresAt) {
-		return nil, fmt.Errorf("plan expired: %s", planID)
+		return nil, fmt.Errorf("plan not found or expired: %s", planID)
 	}
 
 	return plan, nil
 }
 
+// Count returns the number of pending plans that haven't expired
+func (s *Storage) Count() int {
+	count := 0
+	s.plans.Range(func(string, *models.ExecutionPlan) bool {
+		count++
+		return true
+	})
+	return count
+}
+
 // Delete removes a plan by ID
 func (s *Storage) Delete(planID string) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	delete(s.plans, planID)
+	s.plans.Delete(planID)
 	logrus.WithField("plan_id", planID).Info("🗑️ Deleted execution plan")
 }
 
@@ -70,20 +77,8 @@ func (s *Storage) cleanupExpired() {
 	defer ticker.Stop()
 
 	for range ticker.C {
-		s.mu.Lock()
-		now := time.Now()
-		count := 0
-
-		for id, plan := range s.plans {
-			if now.After(plan.ExpiresAt) {
-				delete(s.plans, id)
-				count++
-			}
-		}
-
-		if count > 0 {
+		if count := s.plans.PruneExpired(); count > 0 {
 			logrus.WithField("count", count).Info("🧹 Cleaned up expired plans")
 		}
-		s.mu.Unlock()
 	}
 }
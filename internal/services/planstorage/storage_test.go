@@ -0,0 +1,63 @@
+package planstorage
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/avvvet/cdnbuddy-api/internal/models"
+)
+
+func TestStorageFull(t *testing.T) {
+	pending := func(id string, expiresIn time.Duration) models.ExecutionPlan {
+		return models.ExecutionPlan{ID: id, ExpiresAt: time.Now().Add(expiresIn)}
+	}
+
+	tests := []struct {
+		name     string
+		stored   []models.ExecutionPlan
+		plan     models.ExecutionPlan
+		wantErr  error
+		wantKept []string // plans that must still be retrievable
+	}{
+		{
+			name:     "room left",
+			stored:   []models.ExecutionPlan{pending("p1", time.Hour)},
+			plan:     pending("p2", time.Hour),
+			wantKept: []string{"p1", "p2"},
+		},
+		{
+			name:     "full of pending plans",
+			stored:   []models.ExecutionPlan{pending("p1", time.Hour), pending("p2", time.Hour)},
+			plan:     pending("p3", time.Hour),
+			wantErr:  ErrFull,
+			wantKept: []string{"p1", "p2"},
+		},
+		{
+			name:     "expired plans make room",
+			stored:   []models.ExecutionPlan{pending("p1", -time.Minute), pending("p2", time.Hour)},
+			plan:     pending("p3", time.Hour),
+			wantKept: []string{"p2", "p3"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewStorage(2)
+			for _, plan := range tt.stored {
+				if err := s.Store(plan); err != nil {
+					t.Fatalf("store %s: %v", plan.ID, err)
+				}
+			}
+
+			if err := s.Store(tt.plan); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("store = %v, want %v", err, tt.wantErr)
+			}
+			for _, id := range tt.wantKept {
+				if _, err := s.Get(id); err != nil {
+					t.Errorf("plan %s: %v", id, err)
+				}
+			}
+		})
+	}
+}
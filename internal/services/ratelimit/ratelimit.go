@@ -0,0 +1,144 @@
+// Package ratelimit keeps provider API calls within each provider's rate
+// budget. Callers queue until their request fits; budgets are shared by every
+// worker in the process and, with Redis, across replicas.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/avvvet/cdnbuddy-api/internal/metrics"
+)
+
+// Budget is the allowed request rate of one provider API
+type Budget struct {
+	Rate  float64 `json:"rate"`  // sustained requests per second
+	Burst int     `json:"burst"` // requests allowed at once after idling
+}
+
+// Limiter blocks until a call to a provider fits its budget
+type Limiter interface {
+	Wait(ctx context.Context, provider string) error
+}
+
+// ParseBudgets parses "cachefly=10:20,keycdn=5" (rate per second, optional burst)
+func ParseBudgets(spec string) (map[string]Budget, error) {
+	budgets := make(map[string]Budget)
+	for _, pair := range strings.Split(spec, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid rate budget %q (expected provider=rate[:burst])", pair)
+		}
+
+		rateValue, burstValue, hasBurst := strings.Cut(value, ":")
+		rate, err := strconv.ParseFloat(strings.TrimSpace(rateValue), 64)
+		if err != nil || rate <= 0 {
+			return nil, fmt.Errorf("invalid rate in %q", pair)
+		}
+		burst := int(math.Ceil(rate))
+		if hasBurst {
+			if burst, err = strconv.Atoi(strings.TrimSpace(burstValue)); err != nil || burst < 1 {
+				return nil, fmt.Errorf("invalid burst in %q", pair)
+			}
+		}
+
+		budgets[strings.ToLower(strings.TrimSpace(name))] = Budget{Rate: rate, Burst: burst}
+	}
+	return budgets, nil
+}
+
+// bucket is a token bucket. Waiters reserve tokens in arrival order, so the
+// queue drains fairly at the budgeted rate.
+type bucket struct {
+	budget Budget
+	tokens float64
+	last   time.Time
+	mu     sync.Mutex
+}
+
+// reserve takes a token and returns how long the caller must wait for it
+func (b *bucket) reserve(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens = math.Min(float64(b.budget.Burst), b.tokens+now.Sub(b.last).Seconds()*b.budget.Rate)
+	b.last = now
+	b.tokens--
+
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.budget.Rate * float64(time.Second))
+}
+
+// cancel returns a reserved token when the caller gives up waiting
+func (b *bucket) cancel() {
+	b.mu.Lock()
+	b.tokens++
+	b.mu.Unlock()
+}
+
+// Local limits provider calls within this process
+type Local struct {
+	buckets map[string]*bucket
+}
+
+// NewLocal creates a process-wide limiter; providers without a budget are not limited
+func NewLocal(budgets map[string]Budget) *Local {
+	l := &Local{buckets: make(map[string]*bucket, len(budgets))}
+	now := time.Now()
+	for name, budget := range budgets {
+		l.buckets[name] = &bucket{budget: budget, tokens: float64(budget.Burst), last: now}
+	}
+	return l
+}
+
+// Wait blocks until a call to provider fits its budget or ctx is done
+func (l *Local) Wait(ctx context.Context, provider string) error {
+	b, ok := l.buckets[provider]
+	if !ok {
+		return nil
+	}
+
+	delay := b.reserve(time.Now())
+	if delay == 0 {
+		metrics.Inc("provider_calls_" + provider)
+		return nil
+	}
+
+	if err := queue(ctx, provider, delay); err != nil {
+		b.cancel()
+		return err
+	}
+	metrics.Inc("provider_calls_" + provider)
+	return nil
+}
+
+// queue sleeps for delay as a throttled call, recording the wait
+func queue(ctx context.Context, provider string, delay time.Duration) error {
+	metrics.Inc("provider_throttled_" + provider)
+	metrics.Add("provider_queued_"+provider, 1)
+	defer metrics.Add("provider_queued_"+provider, -1)
+
+	start := time.Now()
+	defer metrics.Since("provider_throttle_wait_"+provider, start)
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		metrics.Inc("provider_throttle_cancelled_" + provider)
+		return fmt.Errorf("waiting for %s rate budget: %w", provider, ctx.Err())
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,111 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/avvvet/cdnbuddy-api/internal/testutil"
+)
+
+func TestParseBudgets(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    map[string]Budget
+		wantErr bool
+	}{
+		{name: "empty", spec: "", want: map[string]Budget{}},
+		{
+			name: "rate and burst",
+			spec: "cachefly=10:20, KeyCDN = 5",
+			want: map[string]Budget{
+				"cachefly": {Rate: 10, Burst: 20},
+				"keycdn":   {Rate: 5, Burst: 5},
+			},
+		},
+		{name: "fractional rate rounds burst up", spec: "cdn77=0.5", want: map[string]Budget{"cdn77": {Rate: 0.5, Burst: 1}}},
+		{name: "missing rate", spec: "cachefly", wantErr: true},
+		{name: "zero rate", spec: "cachefly=0", wantErr: true},
+		{name: "bad burst", spec: "cachefly=10:0", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseBudgets(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseBudgets(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseBudgets(%q) = %v, want %v", tt.spec, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBucketReserve(t *testing.T) {
+	// each call advances the clock and reserves a token
+	type call struct {
+		advance   time.Duration
+		wantDelay time.Duration
+	}
+	tests := []struct {
+		name  string
+		calls []call
+	}{
+		{
+			name:  "burst goes out at once, then callers queue",
+			calls: []call{{}, {}, {wantDelay: 500 * time.Millisecond}, {wantDelay: time.Second}},
+		},
+		{
+			name: "tokens refill at the budgeted rate",
+			calls: []call{
+				{}, {}, {wantDelay: 500 * time.Millisecond},
+				{advance: time.Second, wantDelay: 0},
+			},
+		},
+		{
+			name:  "idling refills no more than the burst",
+			calls: []call{{advance: time.Hour}, {}, {wantDelay: 500 * time.Millisecond}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := testutil.NewClock()
+			b := &bucket{budget: Budget{Rate: 2, Burst: 2}, tokens: 2, last: clock.Now()}
+
+			for i, c := range tt.calls {
+				if got := b.reserve(clock.Advance(c.advance)); got != c.wantDelay {
+					t.Errorf("call %d: delay = %s, want %s", i, got, c.wantDelay)
+				}
+			}
+		})
+	}
+}
+
+func TestLocalWait(t *testing.T) {
+	limiter := NewLocal(map[string]Budget{"cachefly": {Rate: 1, Burst: 1}})
+
+	if err := limiter.Wait(context.Background(), "unbudgeted"); err != nil {
+		t.Fatalf("Wait() for a provider without budget = %v", err)
+	}
+	if err := limiter.Wait(context.Background(), "cachefly"); err != nil {
+		t.Fatalf("Wait() within burst = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := limiter.Wait(ctx, "cachefly"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Wait() with cancelled context = %v, want context.Canceled", err)
+	}
+
+	// the cancelled caller gave its token back, so the next one waits about
+	// a second rather than two
+	b := limiter.buckets["cachefly"]
+	if delay := b.reserve(time.Now()); delay > time.Second {
+		t.Errorf("delay after cancelled wait = %s, want at most 1s", delay)
+	}
+}
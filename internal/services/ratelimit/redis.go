@@ -0,0 +1,101 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+
+	"github.com/avvvet/cdnbuddy-api/internal/metrics"
+)
+
+const redisKeyPrefix = "cdnbuddy:ratelimit:"
+
+// reserveScript takes a token from a bucket stored in Redis and returns how
+// many milliseconds the caller must wait for it. Redis' clock is used so
+// replicas with skewed clocks share one bucket fairly.
+var reserveScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) + tonumber(t[2]) / 1000000
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'last')
+local tokens = tonumber(state[1]) or burst
+local last = tonumber(state[2]) or now
+
+tokens = math.min(burst, tokens + (now - last) * rate) - 1
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'last', tostring(now))
+redis.call('EXPIRE', KEYS[1], math.ceil(burst / rate) + 60)
+
+if tokens >= 0 then
+	return 0
+end
+return math.ceil(-tokens / rate * 1000)
+`)
+
+// Redis limits provider calls across all replicas sharing a Redis instance.
+// When Redis is unreachable it falls back to a per-process budget.
+type Redis struct {
+	client   *redis.Client
+	budgets  map[string]Budget
+	fallback *Local
+}
+
+// NewRedis connects to Redis at url (redis://host:port/db)
+func NewRedis(url string, budgets map[string]Budget) (*Redis, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Redis URL: %w", err)
+	}
+
+	client := redis.NewClient(opts)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+
+	return &Redis{
+		client:   client,
+		budgets:  budgets,
+		fallback: NewLocal(budgets),
+	}, nil
+}
+
+// Wait blocks until a call to provider fits the shared budget or ctx is done
+func (l *Redis) Wait(ctx context.Context, provider string) error {
+	budget, ok := l.budgets[provider]
+	if !ok {
+		return nil
+	}
+
+	key := redisKeyPrefix + provider
+	waitMs, err := reserveScript.Run(ctx, l.client, []string{key}, budget.Rate, budget.Burst).Int64()
+	if err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("waiting for %s rate budget: %w", provider, ctx.Err())
+		}
+		logrus.WithError(err).WithField("provider", provider).Warn("⚠️ Shared rate budget unavailable, using local budget")
+		metrics.Inc("provider_budget_fallback_" + provider)
+		return l.fallback.Wait(ctx, provider)
+	}
+
+	if waitMs > 0 {
+		if err := queue(ctx, provider, time.Duration(waitMs)*time.Millisecond); err != nil {
+			// Give the token back so other replicas aren't held up by it
+			l.client.HIncrByFloat(context.Background(), key, "tokens", 1)
+			return err
+		}
+	}
+	metrics.Inc("provider_calls_" + provider)
+	return nil
+}
+
+// Close closes the Redis connection
+func (l *Redis) Close() error {
+	return l.client.Close()
+}
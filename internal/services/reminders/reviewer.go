@@ -0,0 +1,259 @@
+package reminders
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/avvvet/cdnbuddy-api/internal/domain"
+	"github.com/avvvet/cdnbuddy-api/internal/services/audit"
+	"github.com/avvvet/cdnbuddy-api/internal/services/cdn"
+	"github.com/avvvet/cdnbuddy-api/internal/services/messaging"
+)
+
+// DefaultOrgID is the org of the deployment's own CDN account
+const DefaultOrgID = "default"
+
+// Settings control TTL review reminders for one org
+type Settings struct {
+	Enabled         bool    `json:"enabled"`
+	StaleAfterDays  int     `json:"stale_after_days"`  // rules untouched for this long are reviewed
+	MinHitRatio     float64 `json:"min_hit_ratio"`     // only services below this hit ratio (0-1)
+	RepeatAfterDays int     `json:"repeat_after_days"` // don't remind about the same service more often
+	NotifyUserID    string  `json:"notify_user_id"`    // recipient of reminder notifications
+}
+
+// DefaultSettings apply to orgs without their own settings
+var DefaultSettings = Settings{
+	Enabled:         true,
+	StaleAfterDays:  90,
+	MinHitRatio:     0.80,
+	RepeatAfterDays: 30,
+}
+
+// Validate checks settings are usable
+func (s Settings) Validate() error {
+	if s.StaleAfterDays <= 0 {
+		return fmt.Errorf("stale_after_days must be positive")
+	}
+	if s.MinHitRatio < 0 || s.MinHitRatio > 1 {
+		return fmt.Errorf("min_hit_ratio must be between 0 and 1")
+	}
+	if s.RepeatAfterDays < 0 {
+		return fmt.Errorf("repeat_after_days must not be negative")
+	}
+	return nil
+}
+
+// Reminder is a suggestion to review the caching setup of one service
+type Reminder struct {
+	OrgID         string    `json:"org_id"`
+	ServiceID     string    `json:"service_id"`
+	ServiceName   string    `json:"service_name"`
+	LastChangedAt time.Time `json:"last_changed_at"`
+	HitRatio      float64   `json:"hit_ratio"`
+	Message       string    `json:"message"`
+}
+
+type org struct {
+	service  *cdn.Service
+	settings Settings
+}
+
+// Reviewer periodically looks for services with stale cache rules and low hit
+// ratios and suggests a review via notification
+type Reviewer struct {
+	orgs         map[string]*org
+	lastReminded map[string]time.Time // orgID/serviceID -> last reminder
+	publisher    *messaging.Publisher
+	auditLog     *audit.Log
+	mu           sync.RWMutex
+}
+
+// NewReviewer creates a new TTL review reminder subsystem
+func NewReviewer(publisher *messaging.Publisher, auditLog *audit.Log) *Reviewer {
+	return &Reviewer{
+		orgs:         make(map[string]*org),
+		lastReminded: make(map[string]time.Time),
+		publisher:    publisher,
+		auditLog:     auditLog,
+	}
+}
+
+// RegisterOrg adds an org whose services should be reviewed
+func (r *Reviewer) RegisterOrg(orgID string, service *cdn.Service, settings Settings) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.orgs[orgID] = &org{service: service, settings: settings}
+}
+
+// Settings returns an org's reminder settings
+func (r *Reviewer) Settings(orgID string) (Settings, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	o, ok := r.orgs[orgID]
+	if !ok {
+		return Settings{}, fmt.Errorf("org not found: %s", orgID)
+	}
+	return o.settings, nil
+}
+
+// SetSettings updates an org's reminder settings
+func (r *Reviewer) SetSettings(orgID string, settings Settings) error {
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	o, ok := r.orgs[orgID]
+	if !ok {
+		return fmt.Errorf("org not found: %s", orgID)
+	}
+	o.settings = settings
+	return nil
+}
+
+// Start reviews all orgs every interval until ctx is cancelled
+func (r *Reviewer) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.mu.RLock()
+			orgIDs := make([]string, 0, len(r.orgs))
+			for id := range r.orgs {
+				orgIDs = append(orgIDs, id)
+			}
+			r.mu.RUnlock()
+
+			for _, orgID := range orgIDs {
+				if _, err := r.ReviewOrg(ctx, orgID, true); err != nil {
+					logrus.WithError(err).WithField("org_id", orgID).Warn("⚠️ TTL review failed")
+				}
+			}
+		}
+	}
+}
+
+// ReviewOrg finds services due for a TTL review. When notify is set, reminders
+// are sent (respecting RepeatAfterDays); otherwise they are only returned.
+func (r *Reviewer) ReviewOrg(ctx context.Context, orgID string, notify bool) ([]Reminder, error) {
+	r.mu.RLock()
+	o, ok := r.orgs[orgID]
+	var settings Settings
+	if ok {
+		settings = o.settings
+	}
+	r.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("org not found: %s", orgID)
+	}
+	if !settings.Enabled {
+		return []Reminder{}, nil
+	}
+
+	overview, err := o.service.GetAccountOverview(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load services: %w", err)
+	}
+
+	staleBefore := time.Now().AddDate(0, 0, -settings.StaleAfterDays)
+	reminders := make([]Reminder, 0)
+
+	for _, item := range overview.Services {
+		if item.Metrics == nil || item.Metrics.CacheHitRatio >= settings.MinHitRatio {
+			continue
+		}
+
+		lastChanged := r.lastChanged(item.Service)
+		if lastChanged.IsZero() || lastChanged.After(staleBefore) {
+			continue
+		}
+
+		days := int(time.Since(lastChanged).Hours() / 24)
+		reminders = append(reminders, Reminder{
+			OrgID:         orgID,
+			ServiceID:     item.Service.ID,
+			ServiceName:   item.Service.Name,
+			LastChangedAt: lastChanged,
+			HitRatio:      item.Metrics.CacheHitRatio,
+			Message: fmt.Sprintf("Cache rules for %s haven't changed in %d days and the hit ratio is %.0f%%. Consider reviewing TTLs and cache rules.",
+				item.Service.Name, days, item.Metrics.CacheHitRatio*100),
+		})
+	}
+
+	if notify {
+		r.notify(orgID, settings, reminders)
+	}
+
+	return reminders, nil
+}
+
+// lastChanged returns when a service's configuration last changed, preferring
+// the audit log over the provider's timestamps
+func (r *Reviewer) lastChanged(svc domain.CDNService) time.Time {
+	if r.auditLog != nil {
+		for _, f := range []audit.Filter{{ServiceID: svc.ID, Limit: 1}, {Domain: svc.Name, Limit: 1}} {
+			if entries := r.auditLog.Query(f); len(entries) > 0 {
+				return entries[0].Timestamp
+			}
+		}
+	}
+
+	if !svc.UpdatedAt.IsZero() {
+		return svc.UpdatedAt
+	}
+	return svc.CreatedAt
+}
+
+func (r *Reviewer) notify(orgID string, settings Settings, reminders []Reminder) {
+	repeatAfter := time.Duration(settings.RepeatAfterDays) * 24 * time.Hour
+
+	for _, rem := range reminders {
+		key := orgID + "/" + rem.ServiceID
+
+		r.mu.Lock()
+		last, reminded := r.lastReminded[key]
+		if reminded && time.Since(last) < repeatAfter {
+			r.mu.Unlock()
+			continue
+		}
+		r.lastReminded[key] = time.Now()
+		r.mu.Unlock()
+
+		err := r.publisher.PublishNotification(messaging.NotificationEvent{
+			Type:      messaging.EventTTLReviewReminder,
+			OrgID:     orgID,
+			UserID:    settings.NotifyUserID,
+			ServiceID: rem.ServiceID,
+			Title:     "Time to review cache TTLs for " + rem.ServiceName,
+			Message:   rem.Message,
+			Level:     "info",
+			Data: map[string]interface{}{
+				"hit_ratio":       rem.HitRatio,
+				"last_changed_at": rem.LastChangedAt,
+			},
+		})
+		if err != nil {
+			logrus.WithError(err).WithField("service_id", rem.ServiceID).Error("❌ Failed to send TTL review reminder")
+			continue
+		}
+
+		logrus.WithFields(logrus.Fields{
+			"org_id":     orgID,
+			"service_id": rem.ServiceID,
+		}).Info("⏰ Sent TTL review reminder")
+	}
+}
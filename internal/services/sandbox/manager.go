@@ -0,0 +1,94 @@
+package sandbox
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/avvvet/cdnbuddy-api/internal/lru"
+	"github.com/avvvet/cdnbuddy-api/internal/services/cdn"
+)
+
+// Sandbox is a throwaway demo tenant backed by the mock provider
+type Sandbox struct {
+	ID        string       `json:"sandbox_id"`
+	CreatedAt time.Time    `json:"created_at"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	Service   *cdn.Service `json:"-"`
+}
+
+// Manager creates, looks up and tears down sandbox tenants
+type Manager struct {
+	sandboxes *lru.Cache[string, *Sandbox]
+	ttl       time.Duration
+}
+
+// NewManager creates a new sandbox manager; sandboxes are removed ttl after creation,
+// and the least recently used one is torn down when more than maxSandboxes exist
+func NewManager(ttl time.Duration, maxSandboxes int) *Manager {
+	m := &Manager{
+		sandboxes: lru.New[string, *Sandbox]("sandboxes", maxSandboxes, 0),
+		ttl:       ttl,
+	}
+
+	// Start teardown goroutine for expired sandboxes
+	go m.cleanupExpired()
+
+	return m
+}
+
+// Create provisions a new sandbox seeded with demo services and metrics
+func (m *Manager) Create(ctx context.Context) (*Sandbox, error) {
+	provider := cdn.NewMockProvider()
+	if err := provider.SeedDemoData(ctx); err != nil {
+		return nil, fmt.Errorf("failed to seed sandbox: %w", err)
+	}
+
+	now := time.Now()
+	sb := &Sandbox{
+		ID:        "sbx_" + uuid.New().String(),
+		CreatedAt: now,
+		ExpiresAt: now.Add(m.ttl),
+		Service:   cdn.NewService(provider),
+	}
+
+	m.sandboxes.PutUntil(sb.ID, sb, sb.ExpiresAt)
+
+	logrus.WithFields(logrus.Fields{
+		"sandbox_id": sb.ID,
+		"expires_at": sb.ExpiresAt,
+	}).Info("🏖️ Created sandbox tenant")
+
+	return sb, nil
+}
+
+// Get retrieves a sandbox by ID
+func (m *Manager) Get(sandboxID string) (*Sandbox, error) {
+	sb, exists := m.sandboxes.Get(sandboxID)
+	if !exists {
+		return nil, fmt.Errorf("sandbox not found or expired: %s", sandboxID)
+	}
+
+	return sb, nil
+}
+
+// Delete tears down a sandbox
+func (m *Manager) Delete(sandboxID string) {
+	m.sandboxes.Delete(sandboxID)
+	logrus.WithField("sandbox_id", sandboxID).Info("🗑️ Deleted sandbox tenant")
+}
+
+// cleanupExpired tears down expired sandboxes periodically
+func (m *Manager) cleanupExpired() {
+	ticker := time.NewTicker(1 * time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if count := m.sandboxes.PruneExpired(); count > 0 {
+			logrus.WithField("count", count).Info("🧹 Tore down expired sandboxes")
+		}
+	}
+}
@@ -0,0 +1,359 @@
+// Package scheduler runs confirmed plans later, inside a maintenance window
+// ("apply tonight at 2am UTC"). Users are reminded before a change runs, can
+// cancel it until then, and get verification checks once it has run.
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/avvvet/cdnbuddy-api/internal/models"
+	"github.com/avvvet/cdnbuddy-api/internal/services/messaging"
+)
+
+const (
+	// DefaultWindow is how long after RunAt a job may still start
+	DefaultWindow = time.Hour
+	maxWindow     = 12 * time.Hour
+
+	// maxAhead bounds how far in the future a change can be scheduled
+	maxAhead = 30 * 24 * time.Hour
+
+	// finished jobs are kept this long for status lookups
+	retention = 7 * 24 * time.Hour
+)
+
+// Status of a scheduled job
+type Status string
+
+const (
+	StatusScheduled Status = "scheduled"
+	StatusRunning   Status = "running"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+	StatusCancelled Status = "cancelled"
+	StatusMissed    Status = "missed" // the window closed before the job could start
+)
+
+// Check is one post-change verification result
+type Check struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Job is a plan scheduled to run in a maintenance window
+type Job struct {
+	ID         string                `json:"id"`
+	PlanID     string                `json:"plan_id"`
+	UserID     string                `json:"user_id"`
+	SessionID  string                `json:"session_id,omitempty"`
+	SandboxID  string                `json:"sandbox_id,omitempty"`
+	Title      string                `json:"title"`
+	Action     string                `json:"action"`
+	ServiceID  string                `json:"service_id,omitempty"`
+	Domain     string                `json:"domain,omitempty"`
+	RunAt      time.Time             `json:"run_at"`
+	WindowEnd  time.Time             `json:"window_end"`
+	Status     Status                `json:"status"`
+	RemindedAt *time.Time            `json:"reminded_at,omitempty"`
+	StartedAt  *time.Time            `json:"started_at,omitempty"`
+	FinishedAt *time.Time            `json:"finished_at,omitempty"`
+	Result     string                `json:"result,omitempty"`
+	Error      string                `json:"error,omitempty"`
+	Checks     []Check               `json:"checks,omitempty"`
+	CreatedAt  time.Time             `json:"created_at"`
+	Plan       *models.ExecutionPlan `json:"-"`
+}
+
+// RunFunc executes a job's plan and returns the result message
+type RunFunc func(ctx context.Context, job Job) (string, error)
+
+// VerifyFunc checks the outcome of a job that ran successfully
+type VerifyFunc func(ctx context.Context, job Job) []Check
+
+// Scheduler holds scheduled jobs and runs them when their window opens
+type Scheduler struct {
+	jobs         map[string]*Job
+	run          RunFunc
+	verify       VerifyFunc
+	publisher    *messaging.Publisher
+	reminderLead time.Duration
+	now          func() time.Time
+	mu           sync.Mutex
+}
+
+// NewScheduler creates a scheduler; users are reminded reminderLead before a
+// job runs. A nil verify skips post-change checks.
+func NewScheduler(publisher *messaging.Publisher, run RunFunc, verify VerifyFunc, reminderLead time.Duration) *Scheduler {
+	return &Scheduler{
+		jobs:         make(map[string]*Job),
+		run:          run,
+		verify:       verify,
+		publisher:    publisher,
+		reminderLead: reminderLead,
+		now:          time.Now,
+	}
+}
+
+// ParseRunAt parses an RFC 3339 time, or "HH:MM" for the next occurrence of
+// that time of day in UTC
+func ParseRunAt(v string, now time.Time) (time.Time, error) {
+	v = strings.TrimSpace(v)
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t.UTC(), nil
+	}
+
+	clock, err := time.Parse("15:04", v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid run_at %q (expected RFC 3339 or HH:MM UTC)", v)
+	}
+	now = now.UTC()
+	t := time.Date(now.Year(), now.Month(), now.Day(), clock.Hour(), clock.Minute(), 0, 0, time.UTC)
+	if !t.After(now) {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// Schedule queues a plan to run at runAt; it may start until window has passed
+// (0 = DefaultWindow)
+func (s *Scheduler) Schedule(job Job, runAt time.Time, window time.Duration) (*Job, error) {
+	if job.Plan == nil || job.Plan.IntentResponse == nil {
+		return nil, fmt.Errorf("plan is required")
+	}
+	if window == 0 {
+		window = DefaultWindow
+	}
+	if window < time.Minute || window > maxWindow {
+		return nil, fmt.Errorf("window must be between 1m and %s", maxWindow)
+	}
+
+	now := s.now()
+	if runAt.Before(now.Add(-time.Minute)) {
+		return nil, fmt.Errorf("run_at must be in the future")
+	}
+	if runAt.After(now.Add(maxAhead)) {
+		return nil, fmt.Errorf("run_at must be within %d days", int(maxAhead.Hours()/24))
+	}
+
+	job.ID = uuid.New().String()
+	job.PlanID = job.Plan.ID
+	job.Title = job.Plan.Title
+	job.Action = job.Plan.Action
+	job.RunAt = runAt.UTC()
+	job.WindowEnd = job.RunAt.Add(window)
+	job.Status = StatusScheduled
+	job.CreatedAt = now
+
+	s.mu.Lock()
+	s.jobs[job.ID] = &job
+	s.mu.Unlock()
+
+	logrus.WithFields(logrus.Fields{
+		"job_id":  job.ID,
+		"plan_id": job.PlanID,
+		"run_at":  job.RunAt,
+	}).Info("🗓️ Plan scheduled")
+
+	return s.Get(job.ID)
+}
+
+// Cancel stops a job that hasn't started yet
+func (s *Scheduler) Cancel(id, userID string) (*Job, error) {
+	s.mu.Lock()
+	job, ok := s.jobs[id]
+	if !ok || (userID != "" && job.UserID != userID) {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("scheduled job not found: %s", id)
+	}
+	if job.Status != StatusScheduled {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("job is %s and can no longer be cancelled", job.Status)
+	}
+	now := s.now()
+	job.Status = StatusCancelled
+	job.FinishedAt = &now
+	snapshot := *job
+	s.mu.Unlock()
+
+	logrus.WithField("job_id", id).Info("🚫 Scheduled plan cancelled")
+	return &snapshot, nil
+}
+
+// Get returns a copy of a job
+func (s *Scheduler) Get(id string) (*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, fmt.Errorf("scheduled job not found: %s", id)
+	}
+	snapshot := *job
+	return &snapshot, nil
+}
+
+// List returns a user's jobs (all jobs for an empty userID), soonest first
+func (s *Scheduler) List(userID string) []Job {
+	s.mu.Lock()
+	jobs := make([]Job, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		if userID == "" || job.UserID == userID {
+			jobs = append(jobs, *job)
+		}
+	}
+	s.mu.Unlock()
+
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].RunAt.Before(jobs[j].RunAt) })
+	return jobs
+}
+
+// Start checks for due reminders and jobs every interval until ctx is cancelled
+func (s *Scheduler) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.tick(ctx, s.now())
+		}
+	}
+}
+
+// tick sends due reminders, starts due jobs and marks missed ones
+func (s *Scheduler) tick(ctx context.Context, now time.Time) {
+	remind := make([]Job, 0)
+	due := make([]Job, 0)
+	missed := make([]Job, 0)
+
+	s.mu.Lock()
+	for id, job := range s.jobs {
+		if job.FinishedAt != nil && now.Sub(*job.FinishedAt) > retention {
+			delete(s.jobs, id)
+			continue
+		}
+		if job.Status != StatusScheduled {
+			continue
+		}
+
+		switch {
+		case now.After(job.WindowEnd):
+			job.Status = StatusMissed
+			job.FinishedAt = &now
+			missed = append(missed, *job)
+		case !now.Before(job.RunAt):
+			job.Status = StatusRunning
+			job.StartedAt = &now
+			due = append(due, *job)
+		case job.RemindedAt == nil && s.reminderLead > 0 && !now.Before(job.RunAt.Add(-s.reminderLead)):
+			job.RemindedAt = &now
+			remind = append(remind, *job)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, job := range remind {
+		s.notify(job, messaging.EventScheduledChangeReminder, "info",
+			"Scheduled change coming up: "+job.Title,
+			fmt.Sprintf("%s will run at %s UTC. Cancel it before then if it's no longer wanted.", job.Title, job.RunAt.Format("2006-01-02 15:04")))
+	}
+	for _, job := range missed {
+		logrus.WithField("job_id", job.ID).Warn("⚠️ Scheduled plan missed its window")
+		s.notify(job, messaging.EventScheduledChangeFailed, "warning",
+			"Scheduled change skipped: "+job.Title,
+			fmt.Sprintf("The maintenance window closed at %s UTC before the change could start. Nothing was changed.", job.WindowEnd.Format("2006-01-02 15:04")))
+	}
+	for _, job := range due {
+		go s.execute(ctx, job)
+	}
+}
+
+// execute runs a due job, verifies the outcome and notifies the user
+func (s *Scheduler) execute(ctx context.Context, job Job) {
+	logrus.WithFields(logrus.Fields{
+		"job_id":  job.ID,
+		"plan_id": job.PlanID,
+	}).Info("🚀 Running scheduled plan")
+
+	result, err := s.run(ctx, job)
+
+	var checks []Check
+	if err == nil && s.verify != nil {
+		checks = s.verify(ctx, job)
+	}
+
+	now := s.now()
+	s.mu.Lock()
+	if stored, ok := s.jobs[job.ID]; ok {
+		stored.FinishedAt = &now
+		stored.Result = result
+		stored.Checks = checks
+		stored.Status = StatusSucceeded
+		if err != nil {
+			stored.Status = StatusFailed
+			stored.Error = err.Error()
+		}
+		job = *stored
+	}
+	s.mu.Unlock()
+
+	if err != nil {
+		logrus.WithError(err).WithField("job_id", job.ID).Error("❌ Scheduled plan failed")
+		s.notify(job, messaging.EventScheduledChangeFailed, "error",
+			"Scheduled change failed: "+job.Title, err.Error())
+		return
+	}
+
+	failed := make([]string, 0)
+	for _, c := range checks {
+		if !c.Passed {
+			failed = append(failed, c.Name)
+		}
+	}
+	if len(failed) > 0 {
+		s.notify(job, messaging.EventScheduledChangeCompleted, "warning",
+			"Scheduled change applied, verification failed: "+job.Title,
+			fmt.Sprintf("%s. Failed checks: %s", result, strings.Join(failed, ", ")))
+		return
+	}
+
+	logrus.WithField("job_id", job.ID).Info("✅ Scheduled plan completed")
+	s.notify(job, messaging.EventScheduledChangeCompleted, "success",
+		"Scheduled change applied: "+job.Title, result)
+}
+
+func (s *Scheduler) notify(job Job, eventType, level, title, message string) {
+	if s.publisher == nil {
+		return
+	}
+
+	err := s.publisher.PublishNotification(messaging.NotificationEvent{
+		Type:      eventType,
+		UserID:    job.UserID,
+		ServiceID: job.ServiceID,
+		Title:     title,
+		Message:   message,
+		Level:     level,
+		Data: map[string]interface{}{
+			"job_id":  job.ID,
+			"plan_id": job.PlanID,
+			"run_at":  job.RunAt,
+			"status":  job.Status,
+			"checks":  job.Checks,
+		},
+	})
+	if err != nil {
+		logrus.WithError(err).WithField("job_id", job.ID).Error("❌ Failed to send scheduled change notification")
+	}
+}
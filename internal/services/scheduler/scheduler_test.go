@@ -0,0 +1,213 @@
+package scheduler
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/avvvet/cdnbuddy-api/internal/models"
+	"github.com/avvvet/cdnbuddy-api/internal/testutil"
+)
+
+func TestParseRunAt(t *testing.T) {
+	now := testutil.Epoch // 12:00 UTC
+	plus2 := time.FixedZone("UTC+2", 2*60*60)
+
+	tests := []struct {
+		name    string
+		value   string
+		now     time.Time
+		want    time.Time
+		wantErr bool
+	}{
+		{name: "rfc 3339 in UTC", value: "2025-01-02T02:00:00Z", now: now, want: time.Date(2025, 1, 2, 2, 0, 0, 0, time.UTC)},
+		{name: "rfc 3339 with offset is converted to UTC", value: "2025-01-02T04:00:00+02:00", now: now, want: time.Date(2025, 1, 2, 2, 0, 0, 0, time.UTC)},
+		{name: "time of day later today", value: "18:30", now: now, want: time.Date(2025, 1, 1, 18, 30, 0, 0, time.UTC)},
+		{name: "time of day already past is tomorrow", value: "02:00", now: now, want: time.Date(2025, 1, 2, 2, 0, 0, 0, time.UTC)},
+		{name: "time of day right now is tomorrow", value: "12:00", now: now, want: time.Date(2025, 1, 2, 12, 0, 0, 0, time.UTC)},
+		{
+			// 23:30 at UTC+2 is 21:30 UTC, so 22:00 UTC is still today
+			name:  "time of day is UTC whatever the caller's zone",
+			value: "22:00",
+			now:   time.Date(2025, 1, 1, 23, 30, 0, 0, plus2),
+			want:  time.Date(2025, 1, 1, 22, 0, 0, 0, time.UTC),
+		},
+		{name: "surrounding space", value: " 18:30 ", now: now, want: time.Date(2025, 1, 1, 18, 30, 0, 0, time.UTC)},
+		{name: "past rfc 3339 parses; Schedule rejects it", value: "2024-12-31T00:00:00Z", now: now, want: time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)},
+		{name: "words", value: "tonight at 2am", now: now, wantErr: true},
+		{name: "hour out of range", value: "25:00", now: now, wantErr: true},
+		{name: "date without time", value: "2025-01-02", now: now, wantErr: true},
+		{name: "empty", value: "", now: now, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRunAt(tt.value, tt.now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRunAt() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !got.Equal(tt.want) {
+				t.Errorf("ParseRunAt() = %s, want %s", got, tt.want)
+			}
+			if !tt.wantErr && got.Location() != time.UTC {
+				t.Errorf("ParseRunAt() location = %s, want UTC", got.Location())
+			}
+		})
+	}
+}
+
+// newTestScheduler returns a scheduler on a fake clock whose runs are sent to
+// the returned channel
+func newTestScheduler(reminderLead time.Duration) (*Scheduler, *testutil.Clock, chan Job) {
+	clock := testutil.NewClock()
+	runs := make(chan Job, 1)
+	s := NewScheduler(nil, func(ctx context.Context, job Job) (string, error) {
+		runs <- job
+		return "Cache purged", nil
+	}, nil, reminderLead)
+	s.now = clock.Now
+	return s, clock, runs
+}
+
+func testJob() Job {
+	plan := models.BuildExecutionPlan(testutil.NewIntentBuilder("PURGE_ALL").WithParam("service_id", "svc-1").Build())
+	return Job{UserID: "user-1", ServiceID: "svc-1", Plan: &plan}
+}
+
+func TestSchedule(t *testing.T) {
+	tests := []struct {
+		name    string
+		job     Job
+		runAt   time.Duration // from now
+		window  time.Duration
+		wantErr string
+	}{
+		{name: "tonight", job: testJob(), runAt: 14 * time.Hour},
+		{name: "a moment ago still counts as now", job: testJob(), runAt: -30 * time.Second},
+		{name: "in the past", job: testJob(), runAt: -time.Hour, wantErr: "in the future"},
+		{name: "too far ahead", job: testJob(), runAt: 31 * 24 * time.Hour, wantErr: "within 30 days"},
+		{name: "window too short", job: testJob(), runAt: time.Hour, window: time.Second, wantErr: "window must be"},
+		{name: "window too long", job: testJob(), runAt: time.Hour, window: 13 * time.Hour, wantErr: "window must be"},
+		{name: "no plan", job: Job{UserID: "user-1"}, runAt: time.Hour, wantErr: "plan is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, clock, _ := newTestScheduler(0)
+			job, err := s.Schedule(tt.job, clock.Now().Add(tt.runAt), tt.window)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Schedule() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Schedule() error = %v", err)
+			}
+			if job.Status != StatusScheduled || !job.WindowEnd.Equal(job.RunAt.Add(DefaultWindow)) {
+				t.Errorf("Schedule() = %s until %s, want scheduled with the default window", job.Status, job.WindowEnd)
+			}
+		})
+	}
+}
+
+func TestSchedulerTick(t *testing.T) {
+	t.Run("reminds, then runs in the window", func(t *testing.T) {
+		s, clock, runs := newTestScheduler(15 * time.Minute)
+		job, err := s.Schedule(testJob(), clock.Now().Add(time.Hour), 0)
+		if err != nil {
+			t.Fatalf("Schedule() error = %v", err)
+		}
+
+		s.tick(context.Background(), clock.Advance(30*time.Minute))
+		if got, _ := s.Get(job.ID); got.RemindedAt != nil {
+			t.Fatalf("reminded 30m ahead with a 15m lead")
+		}
+		s.tick(context.Background(), clock.Advance(20*time.Minute))
+		got, _ := s.Get(job.ID)
+		if got.RemindedAt == nil || got.Status != StatusScheduled {
+			t.Fatalf("10m ahead: reminded = %v, status = %s; want a reminder and still scheduled", got.RemindedAt, got.Status)
+		}
+
+		s.tick(context.Background(), clock.Advance(10*time.Minute))
+		if ran := testutil.Await(t, runs); ran.ID != job.ID {
+			t.Fatalf("ran job %s, want %s", ran.ID, job.ID)
+		}
+		deadline := time.Now().Add(testutil.AwaitTimeout)
+		for got, _ = s.Get(job.ID); got.Status == StatusRunning && time.Now().Before(deadline); got, _ = s.Get(job.ID) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if got.Status != StatusSucceeded || got.Result != "Cache purged" {
+			t.Errorf("after running: status = %s, result = %q; want succeeded", got.Status, got.Result)
+		}
+	})
+
+	t.Run("cancelled before the run never runs", func(t *testing.T) {
+		s, clock, runs := newTestScheduler(0)
+		job, err := s.Schedule(testJob(), clock.Now().Add(time.Hour), 0)
+		if err != nil {
+			t.Fatalf("Schedule() error = %v", err)
+		}
+
+		if _, err := s.Cancel(job.ID, "user-2"); err == nil {
+			t.Fatal("another user cancelled the job")
+		}
+		cancelled, err := s.Cancel(job.ID, "user-1")
+		if err != nil || cancelled.Status != StatusCancelled {
+			t.Fatalf("Cancel() = %v, %v; want cancelled", cancelled, err)
+		}
+		if _, err := s.Cancel(job.ID, "user-1"); err == nil {
+			t.Error("cancelled the same job twice")
+		}
+
+		s.tick(context.Background(), clock.Advance(time.Hour))
+		select {
+		case <-runs:
+			t.Fatal("a cancelled job ran")
+		case <-time.After(50 * time.Millisecond):
+		}
+		if got, _ := s.Get(job.ID); got.Status != StatusCancelled {
+			t.Errorf("status = %s, want cancelled", got.Status)
+		}
+	})
+
+	t.Run("missed window is skipped", func(t *testing.T) {
+		s, clock, runs := newTestScheduler(0)
+		job, err := s.Schedule(testJob(), clock.Now().Add(time.Hour), 30*time.Minute)
+		if err != nil {
+			t.Fatalf("Schedule() error = %v", err)
+		}
+
+		// The replica was down from before the window opened until after it closed
+		s.tick(context.Background(), clock.Advance(2*time.Hour))
+		select {
+		case <-runs:
+			t.Fatal("a job ran after its window closed")
+		case <-time.After(50 * time.Millisecond):
+		}
+		got, _ := s.Get(job.ID)
+		if got.Status != StatusMissed || got.FinishedAt == nil {
+			t.Errorf("status = %s, want missed and finished", got.Status)
+		}
+		if _, err := s.Cancel(job.ID, ""); err == nil {
+			t.Error("cancelled a missed job")
+		}
+	})
+
+	t.Run("finished jobs are forgotten after retention", func(t *testing.T) {
+		s, clock, _ := newTestScheduler(0)
+		job, err := s.Schedule(testJob(), clock.Now().Add(time.Hour), 0)
+		if err != nil {
+			t.Fatalf("Schedule() error = %v", err)
+		}
+		if _, err := s.Cancel(job.ID, ""); err != nil {
+			t.Fatalf("Cancel() error = %v", err)
+		}
+
+		s.tick(context.Background(), clock.Advance(retention+time.Minute))
+		if _, err := s.Get(job.ID); err == nil {
+			t.Error("job kept past retention")
+		}
+	})
+}
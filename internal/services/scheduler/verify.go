@@ -0,0 +1,76 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/avvvet/cdnbuddy-api/internal/domain"
+	"github.com/avvvet/cdnbuddy-api/internal/services/cdn"
+)
+
+var verifyClient = &http.Client{Timeout: 15 * time.Second}
+
+// VerifyService checks that the service a change touched is still active and
+// that its edge URL answers without a server error
+func VerifyService(ctx context.Context, svc *cdn.Service, serviceID, domainName string) []Check {
+	if serviceID == "" && domainName == "" {
+		return []Check{}
+	}
+
+	services, err := svc.ListServices(ctx)
+	if err != nil {
+		return []Check{{Name: "service_active", Detail: fmt.Sprintf("failed to list services: %v", err)}}
+	}
+
+	var found *domain.CDNService
+	for i := range services {
+		if (serviceID != "" && services[i].ID == serviceID) || (serviceID == "" && services[i].Name == domainName) {
+			found = &services[i]
+			break
+		}
+	}
+	if found == nil {
+		return []Check{{Name: "service_active", Detail: "service not found after the change"}}
+	}
+
+	checks := []Check{{
+		Name:   "service_active",
+		Passed: strings.EqualFold(found.Status, "active"),
+		Detail: "status " + found.Status,
+	}}
+
+	var config struct {
+		TestURL string `json:"test_url"`
+	}
+	json.Unmarshal([]byte(found.Config), &config)
+	if config.TestURL != "" {
+		checks = append(checks, checkURL(ctx, config.TestURL))
+	}
+
+	return checks
+}
+
+// checkURL requests the edge URL and passes on any non-5xx response
+func checkURL(ctx context.Context, url string) Check {
+	check := Check{Name: "edge_responds"}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		check.Detail = err.Error()
+		return check
+	}
+	resp, err := verifyClient.Do(req)
+	if err != nil {
+		check.Detail = err.Error()
+		return check
+	}
+	resp.Body.Close()
+
+	check.Passed = resp.StatusCode < 500
+	check.Detail = fmt.Sprintf("%s returned %d", url, resp.StatusCode)
+	return check
+}
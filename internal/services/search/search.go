@@ -0,0 +1,358 @@
+// Package search finds services, domains, operations and audit entries by
+// name, ID, config value or keyword, ranked by how closely they match.
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/avvvet/cdnbuddy-api/internal/domain"
+	"github.com/avvvet/cdnbuddy-api/internal/services/audit"
+	"github.com/avvvet/cdnbuddy-api/internal/services/operations"
+)
+
+const (
+	defaultLimit = 20
+	maxLimit     = 100
+
+	// domainConcurrency bounds concurrent domain listings per search
+	domainConcurrency = 8
+
+	// recentRecords caps how many operations/audit entries are scanned
+	recentRecords = 5000
+)
+
+// Type of a search result
+type Type string
+
+const (
+	TypeService   Type = "service"
+	TypeDomain    Type = "domain"
+	TypeOperation Type = "operation"
+	TypeAudit     Type = "audit"
+)
+
+// Result is one ranked match
+type Result struct {
+	Type      Type   `json:"type"`
+	ID        string `json:"id"`
+	Title     string `json:"title"`
+	Subtitle  string `json:"subtitle,omitempty"`
+	ServiceID string `json:"service_id,omitempty"`
+	Score     int    `json:"score"`
+}
+
+// Response is the outcome of a search; Errors lists sources that failed
+type Response struct {
+	Query   string   `json:"query"`
+	Results []Result `json:"results"`
+	Partial bool     `json:"partial"`
+	Errors  []string `json:"errors,omitempty"`
+}
+
+// Options narrow a search; empty Types searches everything
+type Options struct {
+	Types []Type
+	Limit int
+}
+
+// Catalog lists the services and domains to search; *cdn.Service implements it
+type Catalog interface {
+	ListServices(ctx context.Context) ([]domain.CDNService, error)
+	ListServiceDomains(ctx context.Context, svc domain.CDNService) ([]domain.Domain, error)
+}
+
+// Searcher searches the account and the operation and audit history
+type Searcher struct {
+	operations *operations.Store
+	audit      *audit.Log
+}
+
+// NewSearcher creates a searcher; nil stores are skipped
+func NewSearcher(ops *operations.Store, auditLog *audit.Log) *Searcher {
+	return &Searcher{operations: ops, audit: auditLog}
+}
+
+// ParseTypes parses a comma-separated list of result types
+func ParseTypes(v string) ([]Type, error) {
+	types := make([]Type, 0)
+	for _, name := range strings.Split(v, ",") {
+		switch t := Type(strings.TrimSpace(strings.ToLower(name))); t {
+		case "":
+		case TypeService, TypeDomain, TypeOperation, TypeAudit:
+			types = append(types, t)
+		default:
+			return nil, fmt.Errorf("invalid type %q (expected service, domain, operation or audit)", name)
+		}
+	}
+	return types, nil
+}
+
+// Search returns results ranked by score, best first. A nil catalog skips
+// services and domains.
+func (s *Searcher) Search(ctx context.Context, catalog Catalog, query string, opts Options) (*Response, error) {
+	query = normalize(query)
+	if query == "" {
+		return nil, fmt.Errorf("query is required")
+	}
+
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	if limit > maxLimit {
+		limit = maxLimit
+	}
+
+	want := func(t Type) bool {
+		if len(opts.Types) == 0 {
+			return true
+		}
+		for _, wanted := range opts.Types {
+			if wanted == t {
+				return true
+			}
+		}
+		return false
+	}
+
+	resp := &Response{Query: query, Results: make([]Result, 0)}
+
+	if catalog != nil && (want(TypeService) || want(TypeDomain)) {
+		results, errs := s.searchCatalog(ctx, catalog, query, want(TypeService), want(TypeDomain))
+		resp.Results = append(resp.Results, results...)
+		resp.Errors = append(resp.Errors, errs...)
+	}
+	if s.operations != nil && want(TypeOperation) {
+		resp.Results = append(resp.Results, s.searchOperations(query)...)
+	}
+	if s.audit != nil && want(TypeAudit) {
+		resp.Results = append(resp.Results, s.searchAudit(query)...)
+	}
+
+	sort.SliceStable(resp.Results, func(i, j int) bool {
+		return resp.Results[i].Score > resp.Results[j].Score
+	})
+	if len(resp.Results) > limit {
+		resp.Results = resp.Results[:limit]
+	}
+	resp.Partial = len(resp.Errors) > 0
+
+	return resp, nil
+}
+
+func (s *Searcher) searchCatalog(ctx context.Context, catalog Catalog, query string, services, domains bool) ([]Result, []string) {
+	list, err := catalog.ListServices(ctx)
+	if err != nil {
+		return nil, []string{fmt.Sprintf("services: %v", err)}
+	}
+
+	results := make([]Result, 0)
+	errs := make([]string, 0)
+	var mu sync.Mutex
+
+	if services {
+		for _, svc := range list {
+			fields := append([]string{svc.ID, svc.Name}, configFields(svc.Config)...)
+			if score := Score(query, append(fields, string(svc.Provider))...); score > 0 {
+				results = append(results, Result{
+					Type:      TypeService,
+					ID:        svc.ID,
+					Title:     svc.Name,
+					Subtitle:  fmt.Sprintf("%s service (%s)", svc.Provider, svc.Status),
+					ServiceID: svc.ID,
+					Score:     score,
+				})
+			}
+		}
+	}
+
+	if !domains {
+		return results, errs
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(domainConcurrency)
+	for _, svc := range list {
+		g.Go(func() error {
+			serviceDomains, err := catalog.ListServiceDomains(gctx, svc)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("domains of %s: %v", svc.ID, err))
+				return nil
+			}
+			for _, d := range serviceDomains {
+				if score := Score(query, d.Name, d.ID); score > 0 {
+					results = append(results, Result{
+						Type:      TypeDomain,
+						ID:        d.ID,
+						Title:     d.Name,
+						Subtitle:  fmt.Sprintf("served by %s", svc.Name),
+						ServiceID: svc.ID,
+						Score:     score,
+					})
+				}
+			}
+			return nil
+		})
+	}
+	g.Wait()
+
+	return results, errs
+}
+
+func (s *Searcher) searchOperations(query string) []Result {
+	results := make([]Result, 0)
+	for _, op := range s.operations.List(recentRecords) {
+		score := Score(query, op.ID, op.PlanID, op.Domain, op.ServiceID, op.Title, op.Action)
+		if score == 0 {
+			continue
+		}
+		results = append(results, Result{
+			Type:      TypeOperation,
+			ID:        op.ID,
+			Title:     firstNonEmpty(op.Title, op.Action),
+			Subtitle:  fmt.Sprintf("%s, %s", op.Status, op.StartedAt.Format("2006-01-02 15:04")),
+			ServiceID: op.ServiceID,
+			Score:     score,
+		})
+	}
+	return results
+}
+
+func (s *Searcher) searchAudit(query string) []Result {
+	results := make([]Result, 0)
+	for _, e := range s.audit.Query(audit.Filter{Limit: recentRecords}) {
+		score := Score(query, e.ID, e.Domain, e.ServiceID, e.Setting, e.Action, e.UserID)
+		if score == 0 {
+			continue
+		}
+		title := e.Action
+		if e.Domain != "" {
+			title += " on " + e.Domain
+		}
+		results = append(results, Result{
+			Type:      TypeAudit,
+			ID:        e.ID,
+			Title:     title,
+			Subtitle:  fmt.Sprintf("by %s, %s", e.UserID, e.Timestamp.Format("2006-01-02 15:04")),
+			ServiceID: e.ServiceID,
+			Score:     score,
+		})
+	}
+	return results
+}
+
+// Score rates how well fields match a normalized query (0 = no match). Earlier
+// fields weigh more, so pass identifiers and names first.
+func Score(query string, fields ...string) int {
+	best := 0
+	tokens := strings.Fields(query)
+
+	for i, field := range fields {
+		field = strings.ToLower(field)
+		if field == "" {
+			continue
+		}
+
+		score := 0
+		switch {
+		case field == query:
+			score = 100
+		case strings.HasPrefix(field, query):
+			score = 80
+		case strings.HasSuffix(field, "."+query):
+			score = 70 // parent domain, e.g. "example.com" finds "blog.example.com"
+		case strings.Contains(field, query):
+			score = 60
+		case len(tokens) > 1 && containsAll(field, tokens):
+			score = 40
+		}
+		if score == 0 {
+			continue
+		}
+
+		if score -= 5 * i; score < 1 {
+			score = 1
+		}
+		if score > best {
+			best = score
+		}
+	}
+
+	return best
+}
+
+// normalize lowercases the query and strips URL decoration so pasted links
+// ("https://blog.example.com/") match domain names
+func normalize(q string) string {
+	q = strings.ToLower(strings.TrimSpace(q))
+	q = strings.TrimPrefix(q, "https://")
+	q = strings.TrimPrefix(q, "http://")
+	return strings.TrimSuffix(q, "/")
+}
+
+// configFields returns the searchable values of a service's JSON config: its
+// unique name and CNAME target first, then every other string value, e.g. the
+// origin host
+func configFields(config string) []string {
+	var data map[string]interface{}
+	if json.Unmarshal([]byte(config), &data) != nil {
+		return nil
+	}
+
+	uniqueName, _ := data["unique_name"].(string)
+	cnameTarget, _ := data["cname_target"].(string)
+	fields := []string{uniqueName, cnameTarget}
+	delete(data, "unique_name")
+	delete(data, "cname_target")
+	return appendStrings(fields, data)
+}
+
+// appendStrings appends the string values of v, in key order for maps
+func appendStrings(fields []string, v interface{}) []string {
+	switch v := v.(type) {
+	case string:
+		return append(fields, v)
+	case []interface{}:
+		for _, item := range v {
+			fields = appendStrings(fields, item)
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fields = appendStrings(fields, v[k])
+		}
+	}
+	return fields
+}
+
+func containsAll(field string, tokens []string) bool {
+	for _, t := range tokens {
+		if !strings.Contains(field, t) {
+			return false
+		}
+	}
+	return true
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
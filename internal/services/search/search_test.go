@@ -0,0 +1,51 @@
+package search_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/avvvet/cdnbuddy-api/internal/services/search"
+	"github.com/avvvet/cdnbuddy-api/internal/testutil"
+)
+
+func TestSearchCatalog(t *testing.T) {
+	service, provider := testutil.NewMockService(t)
+	shop := testutil.SeedService(t, provider, "shop", "origin.shop.example.com", "cdn.shop.example.com")
+	blog := testutil.SeedService(t, provider, "blog", "blog-origin.example.net", "static.blog.example.org")
+
+	tests := []struct {
+		name      string
+		query     string
+		wantFirst string // ID of the best result
+		wantType  search.Type
+	}{
+		{name: "service name", query: "shop", wantFirst: shop.ID, wantType: search.TypeService},
+		{name: "domain", query: "https://static.blog.example.org/", wantFirst: "", wantType: search.TypeDomain},
+		{name: "origin host in config", query: "blog-origin.example.net", wantFirst: blog.ID, wantType: search.TypeService},
+		{name: "unique name in config", query: "sandbox.cdnbuddy.dev", wantType: search.TypeService},
+	}
+
+	searcher := search.NewSearcher(nil, nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := searcher.Search(context.Background(), service, tt.query, search.Options{})
+			if err != nil {
+				t.Fatalf("Search() error = %v", err)
+			}
+			if len(resp.Results) == 0 {
+				t.Fatalf("Search(%q) found nothing", tt.query)
+			}
+			best := resp.Results[0]
+			if best.Type != tt.wantType || (tt.wantFirst != "" && best.ID != tt.wantFirst) {
+				t.Errorf("best result = %s %s, want %s %s", best.Type, best.ID, tt.wantType, tt.wantFirst)
+			}
+		})
+	}
+
+	t.Run("no match", func(t *testing.T) {
+		resp, err := searcher.Search(context.Background(), service, "nothing-like-this", search.Options{})
+		if err != nil || len(resp.Results) != 0 {
+			t.Errorf("Search() = %v, %v, want no results", resp, err)
+		}
+	})
+}
@@ -0,0 +1,169 @@
+// Package sessions tracks the chat sessions of each user so a conversation
+// can be resumed on another device and, for users who opt in, AI responses
+// reach every device they have open.
+package sessions
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/avvvet/cdnbuddy-api/internal/lru"
+)
+
+const (
+	maxSessions = 50000          // bounds the sessions tracked in memory
+	retention   = 24 * time.Hour // how long an idle session keeps its conversation link
+)
+
+// Session is one chat session, typically one device or browser tab
+type Session struct {
+	SessionID      string    `json:"session_id"`
+	UserID         string    `json:"user_id"`
+	Source         string    `json:"source,omitempty"`
+	ConversationID string    `json:"conversation_id"` // the session whose conversation this one continues
+	LastSeen       time.Time `json:"last_seen"`
+}
+
+// Registry tracks sessions per user
+type Registry struct {
+	activeFor time.Duration
+	sessions  *lru.Cache[string, Session]
+	sync      map[string]bool // user ID -> deliver responses to every active session
+	mu        sync.RWMutex
+}
+
+// NewRegistry creates a registry treating sessions seen within activeFor as connected
+func NewRegistry(activeFor time.Duration) *Registry {
+	return &Registry{
+		activeFor: activeFor,
+		sessions:  lru.New[string, Session]("sessions", maxSessions, 0),
+		sync:      make(map[string]bool),
+	}
+}
+
+// Touch records activity of a user's session
+func (r *Registry) Touch(userID, sessionID, source string) {
+	if userID == "" || sessionID == "" {
+		return
+	}
+	s, ok := r.sessions.Get(sessionID)
+	if !ok || s.UserID != userID {
+		s = Session{SessionID: sessionID, UserID: userID, ConversationID: sessionID}
+	}
+	if source != "" {
+		s.Source = source
+	}
+	s.LastSeen = time.Now()
+	r.sessions.PutUntil(sessionID, s, s.LastSeen.Add(retention))
+}
+
+// Link makes a session continue the conversation of another session of the
+// same user. Linking to the session itself starts a conversation of its own.
+func (r *Registry) Link(userID, sessionID, conversationID string) (Session, error) {
+	target, ok := r.sessions.Get(conversationID)
+	if !ok || target.UserID != userID {
+		return Session{}, fmt.Errorf("session %s not found", conversationID)
+	}
+
+	r.Touch(userID, sessionID, "")
+	s, _ := r.sessions.Get(sessionID)
+	s.ConversationID = target.ConversationID
+	if conversationID == sessionID {
+		s.ConversationID = sessionID
+	}
+	r.sessions.PutUntil(sessionID, s, s.LastSeen.Add(retention))
+	return s, nil
+}
+
+// Conversation returns the conversation a session belongs to, which is the
+// session ID itself unless it was linked
+func (r *Registry) Conversation(sessionID string) string {
+	if s, ok := r.sessions.Get(sessionID); ok && s.ConversationID != "" {
+		return s.ConversationID
+	}
+	return sessionID
+}
+
+// Active returns a user's connected sessions, most recently seen first
+func (r *Registry) Active(userID string) []Session {
+	active := make([]Session, 0)
+	cutoff := time.Now().Add(-r.activeFor)
+	r.sessions.Range(func(_ string, s Session) bool {
+		if s.UserID == userID && s.LastSeen.After(cutoff) {
+			active = append(active, s)
+		}
+		return true
+	})
+	sort.Slice(active, func(i, j int) bool { return active[i].LastSeen.After(active[j].LastSeen) })
+	return active
+}
+
+// List returns every session of a user still retained, most recently seen first
+func (r *Registry) List(userID string) []Session {
+	list := make([]Session, 0)
+	r.sessions.Range(func(_ string, s Session) bool {
+		if s.UserID == userID {
+			list = append(list, s)
+		}
+		return true
+	})
+	sort.Slice(list, func(i, j int) bool { return list[i].LastSeen.After(list[j].LastSeen) })
+	return list
+}
+
+// Get returns a session of a user
+func (r *Registry) Get(userID, sessionID string) (Session, error) {
+	s, ok := r.sessions.Get(sessionID)
+	if !ok || s.UserID != userID {
+		return Session{}, fmt.Errorf("session %s not found", sessionID)
+	}
+	return s, nil
+}
+
+// Reset detaches a session from the conversation it continued, so its next
+// message starts a new one; it returns the session as it was
+func (r *Registry) Reset(userID, sessionID string) (Session, error) {
+	s, err := r.Get(userID, sessionID)
+	if err != nil {
+		return Session{}, err
+	}
+	reset := s
+	reset.ConversationID = sessionID
+	r.sessions.PutUntil(sessionID, reset, s.LastSeen.Add(retention))
+	return s, nil
+}
+
+// SetSync turns delivery to every connected session on or off for a user
+func (r *Registry) SetSync(userID string, enabled bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if enabled {
+		r.sync[userID] = true
+	} else {
+		delete(r.sync, userID)
+	}
+}
+
+// SyncEnabled reports whether a user opted in to delivery on every session
+func (r *Registry) SyncEnabled(userID string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.sync[userID]
+}
+
+// Recipients returns the sessions a response to sessionID is delivered to:
+// the session itself, plus the user's other connected sessions if they opted in
+func (r *Registry) Recipients(userID, sessionID string) []string {
+	recipients := []string{sessionID}
+	if !r.SyncEnabled(userID) {
+		return recipients
+	}
+	for _, s := range r.Active(userID) {
+		if s.SessionID != sessionID {
+			recipients = append(recipients, s.SessionID)
+		}
+	}
+	return recipients
+}
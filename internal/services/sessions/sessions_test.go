@@ -0,0 +1,56 @@
+package sessions_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/avvvet/cdnbuddy-api/internal/services/sessions"
+)
+
+func TestReset(t *testing.T) {
+	registry := sessions.NewRegistry(time.Minute)
+	registry.Touch("u1", "laptop", "web")
+	registry.Touch("u1", "phone", "mobile")
+	registry.Touch("u2", "other", "web")
+	if _, err := registry.Link("u1", "phone", "laptop"); err != nil {
+		t.Fatalf("Link() error = %v", err)
+	}
+
+	if list := registry.List("u1"); len(list) != 2 || list[0].SessionID != "phone" {
+		t.Fatalf("List() = %+v, want phone then laptop", list)
+	}
+
+	tests := []struct {
+		name         string
+		userID       string
+		sessionID    string
+		wantPrevious string
+		wantErr      bool
+	}{
+		{name: "linked session leaves the conversation", userID: "u1", sessionID: "phone", wantPrevious: "laptop"},
+		{name: "own conversation", userID: "u1", sessionID: "laptop", wantPrevious: "laptop"},
+		{name: "another user's session", userID: "u1", sessionID: "other", wantErr: true},
+		{name: "unknown session", userID: "u1", sessionID: "tablet", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previous, err := registry.Reset(tt.userID, tt.sessionID)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("Reset() succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Reset() error = %v", err)
+			}
+			if previous.ConversationID != tt.wantPrevious {
+				t.Errorf("previous conversation = %q, want %q", previous.ConversationID, tt.wantPrevious)
+			}
+			if got := registry.Conversation(tt.sessionID); got != tt.sessionID {
+				t.Errorf("Conversation() after reset = %q, want the session's own", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+// Package share issues expiring signed links to a read-only status page of
+// one service, for clients or teammates without an account. Links are
+// stateless: the token carries the service and expiry, signed with HMAC.
+package share
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/avvvet/cdnbuddy-api/internal/domain"
+	"github.com/avvvet/cdnbuddy-api/internal/signedtoken"
+)
+
+// MaxTTL bounds how long a share link stays valid
+const MaxTTL = 30 * 24 * time.Hour
+
+var (
+	// ErrInvalidToken is returned for tokens that weren't issued by this signer
+	ErrInvalidToken = errors.New("invalid share link")
+	// ErrExpired is returned for tokens past their expiry
+	ErrExpired = errors.New("share link has expired")
+)
+
+// Claims are what a share link grants access to
+type Claims struct {
+	ID        string             `json:"id"`
+	OrgID     string             `json:"org_id"`
+	ServiceID string             `json:"service_id"`
+	Provider  domain.CDNProvider `json:"provider,omitempty"`
+	ExpiresAt time.Time          `json:"expires_at"`
+}
+
+// Link is an issued share link
+type Link struct {
+	ID        string    `json:"id"`
+	ServiceID string    `json:"service_id"`
+	Token     string    `json:"token"`
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Signer issues and verifies share links
+type Signer struct {
+	signer     *signedtoken.Signer
+	baseURL    string
+	defaultTTL time.Duration
+}
+
+// NewSigner creates a signer; links are baseURL + "/" + token. The secret
+// must be dedicated to share links (see signedtoken.New).
+func NewSigner(secret, baseURL string, defaultTTL time.Duration) (*Signer, error) {
+	signer, err := signedtoken.New(secret, "share")
+	if err != nil {
+		return nil, fmt.Errorf("invalid share link secret: %w", err)
+	}
+	if defaultTTL <= 0 || defaultTTL > MaxTTL {
+		defaultTTL = MaxTTL
+	}
+	return &Signer{
+		signer:     signer,
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		defaultTTL: defaultTTL,
+	}, nil
+}
+
+// Issue creates a link to a service's status valid for ttl (0 = default)
+func (s *Signer) Issue(orgID, serviceID string, provider domain.CDNProvider, ttl time.Duration) (*Link, error) {
+	if serviceID == "" {
+		return nil, fmt.Errorf("service_id is required")
+	}
+	if ttl == 0 {
+		ttl = s.defaultTTL
+	}
+	if ttl < time.Minute || ttl > MaxTTL {
+		return nil, fmt.Errorf("ttl must be between 1m and %s", MaxTTL)
+	}
+
+	claims := Claims{
+		ID:        uuid.New().String(),
+		OrgID:     orgID,
+		ServiceID: serviceID,
+		Provider:  provider,
+		ExpiresAt: time.Now().Add(ttl).UTC().Truncate(time.Second),
+	}
+	token, err := s.signer.Sign(claims)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign share link: %w", err)
+	}
+	return &Link{
+		ID:        claims.ID,
+		ServiceID: serviceID,
+		Token:     token,
+		URL:       s.baseURL + "/" + token,
+		ExpiresAt: claims.ExpiresAt,
+	}, nil
+}
+
+// Verify checks a token's signature and expiry and returns its claims
+func (s *Signer) Verify(token string) (*Claims, error) {
+	var claims Claims
+	switch err := s.signer.Verify(token, &claims); {
+	case errors.Is(err, signedtoken.ErrExpired):
+		return nil, ErrExpired
+	case err != nil || claims.ServiceID == "":
+		return nil, ErrInvalidToken
+	}
+	return &claims, nil
+}
+
+// View is the public status of a shared service. It leaves out the provider
+// configuration, origin and anything else only account members should see.
+type View struct {
+	Name        string          `json:"name"`
+	Status      string          `json:"status"`
+	Provider    string          `json:"provider,omitempty"`
+	Domains     []DomainStatus  `json:"domains"`
+	Metrics     *domain.Metrics `json:"metrics,omitempty"`
+	GeneratedAt time.Time       `json:"generated_at"`
+	ExpiresAt   time.Time       `json:"link_expires_at"`
+}
+
+// DomainStatus is the public status of a domain
+type DomainStatus struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+}
+
+// NewView builds the public view of a service
+func NewView(svc domain.CDNService, domains []domain.Domain, metrics *domain.Metrics, claims *Claims) View {
+	view := View{
+		Name:        svc.Name,
+		Status:      svc.Status,
+		Provider:    string(svc.Provider),
+		Domains:     make([]DomainStatus, 0, len(domains)),
+		GeneratedAt: time.Now(),
+		ExpiresAt:   claims.ExpiresAt,
+	}
+	for _, d := range domains {
+		view.Domains = append(view.Domains, DomainStatus{Name: d.Name, Status: d.Status})
+	}
+	if metrics != nil {
+		m := *metrics
+		m.ID = ""
+		view.Metrics = &m
+	}
+	return view
+}
@@ -0,0 +1,88 @@
+package share_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/avvvet/cdnbuddy-api/internal/domain"
+	"github.com/avvvet/cdnbuddy-api/internal/services/share"
+	"github.com/avvvet/cdnbuddy-api/internal/signedtoken"
+)
+
+const secret = "0123456789abcdef0123456789abcdef"
+
+func TestNewSignerRejectsPlaceholderSecret(t *testing.T) {
+	for _, s := range []string{"", "your-secret-key", "short"} {
+		if _, err := share.NewSigner(s, "https://app.example.com/share", 0); err == nil {
+			t.Errorf("NewSigner(%q) error = nil, want an error", s)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	signer, err := share.NewSigner(secret, "https://app.example.com/share/", 0)
+	if err != nil {
+		t.Fatalf("NewSigner() error = %v", err)
+	}
+	link, err := signer.Issue("org-1", "svc-1", domain.ProviderCacheFly, time.Hour)
+	if err != nil {
+		t.Fatalf("Issue() error = %v", err)
+	}
+	if link.URL != "https://app.example.com/share/"+link.Token {
+		t.Errorf("URL = %q, want the base URL plus the token", link.URL)
+	}
+	other, err := share.NewSigner(strings.ToUpper(secret), "https://app.example.com/share", 0)
+	if err != nil {
+		t.Fatalf("NewSigner() error = %v", err)
+	}
+	otherLink, err := other.Issue("org-1", "svc-1", domain.ProviderCacheFly, time.Hour)
+	if err != nil {
+		t.Fatalf("Issue() error = %v", err)
+	}
+	// Same scheme the signer uses, to mint a link that has already expired
+	raw, err := signedtoken.New(secret, "share")
+	if err != nil {
+		t.Fatalf("signedtoken.New() error = %v", err)
+	}
+	expired, err := raw.Sign(share.Claims{ServiceID: "svc-1", ExpiresAt: time.Now().Add(-time.Minute)})
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	// A widget token is signed with the same helper but must not open a share link
+	widgetToken, err := func() (string, error) {
+		w, err := signedtoken.New(secret, "widget")
+		if err != nil {
+			return "", err
+		}
+		return w.Sign(share.Claims{ServiceID: "svc-1", ExpiresAt: time.Now().Add(time.Hour)})
+	}()
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{name: "issued link", token: link.Token},
+		{name: "expired", token: expired, wantErr: share.ErrExpired},
+		{name: "tampered", token: "x" + link.Token, wantErr: share.ErrInvalidToken},
+		{name: "wrong secret", token: otherLink.Token, wantErr: share.ErrInvalidToken},
+		{name: "widget token", token: widgetToken, wantErr: share.ErrInvalidToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := signer.Verify(tt.token)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && claims.ServiceID != "svc-1" {
+				t.Errorf("ServiceID = %q, want svc-1", claims.ServiceID)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+// Package speech turns short voice notes into text so they can go through the
+// normal chat pipeline. The speech-to-text backend is pluggable.
+package speech
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"strings"
+	"time"
+
+	"github.com/avvvet/cdnbuddy-api/internal/metrics"
+)
+
+// MaxAudioBytes bounds a single voice note
+const MaxAudioBytes = 8 << 20
+
+// ErrNoSpeech is returned when a recording contains nothing to transcribe
+var ErrNoSpeech = errors.New("no speech detected in the recording")
+
+// Audio is one uploaded voice note
+type Audio struct {
+	Data        []byte
+	Filename    string
+	ContentType string
+	Language    string // optional ISO-639-1 hint, e.g. "en"
+}
+
+// Transcriber converts audio to text
+type Transcriber interface {
+	Name() string
+	Transcribe(ctx context.Context, audio Audio) (string, error)
+}
+
+// supportedTypes are the audio formats mobile recorders produce that STT
+// backends accept
+var supportedTypes = map[string]bool{
+	"audio/mpeg":   true,
+	"audio/mp3":    true,
+	"audio/mp4":    true,
+	"audio/m4a":    true,
+	"audio/x-m4a":  true,
+	"audio/aac":    true,
+	"audio/wav":    true,
+	"audio/x-wav":  true,
+	"audio/webm":   true,
+	"audio/ogg":    true,
+	"audio/flac":   true,
+	"video/webm":   true, // browsers label audio-only MediaRecorder output this way
+	"video/mp4":    true,
+	"audio/3gpp":   true,
+	"audio/amr-wb": true,
+}
+
+// Validate checks a voice note can be sent for transcription
+func (a Audio) Validate() error {
+	if len(a.Data) == 0 {
+		return fmt.Errorf("audio is empty")
+	}
+	if len(a.Data) > MaxAudioBytes {
+		return fmt.Errorf("audio is larger than %d MB", MaxAudioBytes>>20)
+	}
+	mediaType, _, err := mime.ParseMediaType(a.ContentType)
+	if err != nil || !supportedTypes[mediaType] {
+		return fmt.Errorf("unsupported audio type %q", a.ContentType)
+	}
+	return nil
+}
+
+// New returns the transcriber for a configured provider name
+func New(provider, baseURL, apiKey, model string) (Transcriber, error) {
+	switch strings.ToLower(strings.TrimSpace(provider)) {
+	case "whisper", "openai":
+		if apiKey == "" {
+			return nil, fmt.Errorf("STT provider %q requires an API key", provider)
+		}
+		return NewWhisper(baseURL, apiKey, model), nil
+	default:
+		return nil, fmt.Errorf("unknown STT provider %q (expected whisper)", provider)
+	}
+}
+
+// Transcribe validates audio, transcribes it and records metrics
+func Transcribe(ctx context.Context, t Transcriber, audio Audio) (string, error) {
+	if err := audio.Validate(); err != nil {
+		return "", err
+	}
+
+	start := time.Now()
+	text, err := t.Transcribe(ctx, audio)
+	metrics.Since("speech_transcribe_"+t.Name(), start)
+	if err != nil {
+		metrics.Inc("speech_transcribe_errors_" + t.Name())
+		return "", fmt.Errorf("failed to transcribe audio: %w", err)
+	}
+	metrics.Inc("speech_transcriptions_" + t.Name())
+
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", ErrNoSpeech
+	}
+	return text, nil
+}
@@ -0,0 +1,103 @@
+package speech
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	defaultWhisperURL   = "https://api.openai.com/v1"
+	defaultWhisperModel = "whisper-1"
+)
+
+// Whisper transcribes through an OpenAI-compatible /audio/transcriptions API
+// (OpenAI, Groq, or a self-hosted faster-whisper server)
+type Whisper struct {
+	baseURL string
+	apiKey  string
+	model   string
+	client  *http.Client
+}
+
+// NewWhisper creates a Whisper transcriber; empty baseURL and model use OpenAI's
+func NewWhisper(baseURL, apiKey, model string) *Whisper {
+	if baseURL == "" {
+		baseURL = defaultWhisperURL
+	}
+	if model == "" {
+		model = defaultWhisperModel
+	}
+	return &Whisper{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		model:   model,
+		client:  &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+// Name returns the provider name
+func (w *Whisper) Name() string {
+	return "whisper"
+}
+
+// Transcribe uploads the audio and returns the recognized text
+func (w *Whisper) Transcribe(ctx context.Context, audio Audio) (string, error) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+
+	filename := audio.Filename
+	if filename == "" {
+		filename = "voice-note"
+	}
+	part, err := form.CreateFormFile("file", filename)
+	if err != nil {
+		return "", fmt.Errorf("failed to build upload: %w", err)
+	}
+	if _, err := part.Write(audio.Data); err != nil {
+		return "", fmt.Errorf("failed to build upload: %w", err)
+	}
+	form.WriteField("model", w.model)
+	form.WriteField("response_format", "json")
+	if audio.Language != "" {
+		form.WriteField("language", audio.Language)
+	}
+	if err := form.Close(); err != nil {
+		return "", fmt.Errorf("failed to build upload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.baseURL+"/audio/transcriptions", &body)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+w.apiKey)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("transcription request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", fmt.Errorf("failed to read transcription: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("transcription API returned %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	var result struct {
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return "", fmt.Errorf("failed to decode transcription: %w", err)
+	}
+	return result.Text, nil
+}
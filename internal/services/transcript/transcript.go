@@ -0,0 +1,126 @@
+// Package transcript keeps the chat history of each session and folds older
+// messages into a rolling summary, so intent requests carry the context of a
+// long conversation at a bounded token cost.
+package transcript
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/avvvet/cdnbuddy-api/internal/lru"
+	"github.com/avvvet/cdnbuddy-api/internal/models"
+)
+
+const (
+	// maxSessions bounds the transcripts kept in memory
+	maxSessions = 10000
+	// sessionTTL drops transcripts of sessions idle this long
+	sessionTTL = 24 * time.Hour
+	// summarizeTimeout bounds one summarization request
+	summarizeTimeout = time.Minute
+)
+
+// SummarizeFunc folds messages into the previous summary, returning the new one
+type SummarizeFunc func(ctx context.Context, sessionID, previous string, messages []models.ConversationMessage) (string, error)
+
+type session struct {
+	summary     string
+	messages    []models.ConversationMessage // not yet summarized, oldest first
+	summarizing bool
+	mu          sync.Mutex
+}
+
+// Store keeps session transcripts. Once a session has more than threshold
+// unsummarized messages, all but the keepRecent most recent are summarized.
+type Store struct {
+	threshold  int
+	keepRecent int
+	summarize  SummarizeFunc
+	sessions   *lru.Cache[string, *session]
+}
+
+// NewStore creates a transcript store; threshold 0 disables summarization
+// and only the keepRecent most recent messages are kept
+func NewStore(threshold, keepRecent int, summarize SummarizeFunc) *Store {
+	return &Store{
+		threshold:  threshold,
+		keepRecent: keepRecent,
+		summarize:  summarize,
+		sessions:   lru.New[string, *session]("transcripts", maxSessions, sessionTTL),
+	}
+}
+
+// Append adds a message to a session's transcript, starting a background
+// summarization when the session has grown past the threshold
+func (s *Store) Append(sessionID, role, message string) {
+	sess, ok := s.sessions.Get(sessionID)
+	if !ok {
+		sess = &session{}
+	}
+	s.sessions.Put(sessionID, sess)
+
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	sess.messages = append(sess.messages, models.ConversationMessage{Role: role, Message: message, Timestamp: time.Now()})
+
+	if s.threshold <= 0 {
+		if len(sess.messages) > s.keepRecent {
+			sess.messages = append([]models.ConversationMessage(nil), sess.messages[len(sess.messages)-s.keepRecent:]...)
+		}
+		return
+	}
+	if len(sess.messages) > s.threshold && len(sess.messages) > s.keepRecent && !sess.summarizing {
+		sess.summarizing = true
+		older := append([]models.ConversationMessage(nil), sess.messages[:len(sess.messages)-s.keepRecent]...)
+		go s.fold(sessionID, sess, sess.summary, older)
+	}
+}
+
+// fold summarizes older messages and drops them from the transcript
+func (s *Store) fold(sessionID string, sess *session, previous string, older []models.ConversationMessage) {
+	ctx, cancel := context.WithTimeout(context.Background(), summarizeTimeout)
+	defer cancel()
+	summary, err := s.summarize(ctx, sessionID, previous, older)
+
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	sess.summarizing = false
+	if err != nil {
+		// Keep the raw messages; the next message retries
+		logrus.WithError(err).WithField("session_id", sessionID).Warn("⚠️ Failed to summarize transcript")
+		return
+	}
+
+	sess.summary = summary
+	sess.messages = append([]models.ConversationMessage(nil), sess.messages[len(older):]...)
+	logrus.WithFields(logrus.Fields{
+		"session_id": sessionID,
+		"summarized": len(older),
+	}).Debug("📝 Transcript summarized")
+}
+
+// Context returns the rolling summary of a session and the messages since.
+// While a summarization is pending, only the most recent messages are returned
+// so the history stays bounded.
+func (s *Store) Context(sessionID string) (string, []models.ConversationMessage) {
+	sess, ok := s.sessions.Get(sessionID)
+	if !ok {
+		return "", []models.ConversationMessage{}
+	}
+
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	messages := sess.messages
+	if limit := max(s.threshold, s.keepRecent); len(messages) > limit {
+		messages = messages[len(messages)-limit:]
+	}
+	return sess.summary, append([]models.ConversationMessage{}, messages...)
+}
+
+// Clear drops a session's transcript and summary
+func (s *Store) Clear(sessionID string) {
+	s.sessions.Delete(sessionID)
+}
@@ -0,0 +1,288 @@
+package usage
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/avvvet/cdnbuddy-api/internal/lru"
+)
+
+// ErrQuotaExceeded is returned when a user has used up their daily AI quota
+var ErrQuotaExceeded = errors.New("AI usage quota exceeded")
+
+// Tier names
+const (
+	TierFree       = "free"
+	TierPro        = "pro"
+	TierEnterprise = "enterprise"
+)
+
+// Quota limits AI usage per user per UTC day; zero means unlimited
+type Quota struct {
+	RequestsPerDay int64 `json:"requests_per_day"`
+	TokensPerDay   int64 `json:"tokens_per_day"`
+}
+
+// DefaultQuotas are applied when no quota is configured for a tier
+var DefaultQuotas = map[string]Quota{
+	TierFree:       {RequestsPerDay: 50, TokensPerDay: 100000},
+	TierPro:        {RequestsPerDay: 1000, TokensPerDay: 2000000},
+	TierEnterprise: {},
+}
+
+// retentionDays is how long usage records are kept
+const retentionDays = 31
+
+// Record is one row of the usage table: AI usage of a user session on one day
+type Record struct {
+	OrgID        string    `json:"org_id"`
+	UserID       string    `json:"user_id"`
+	SessionID    string    `json:"session_id"`
+	Day          string    `json:"day"` // YYYY-MM-DD (UTC)
+	Requests     int64     `json:"requests"`
+	Tokens       int64     `json:"tokens"`
+	TotalLatency int64     `json:"total_latency_ms"`
+	LastUsedAt   time.Time `json:"last_used_at"`
+}
+
+// Summary is a user's usage for one day together with their quota
+type Summary struct {
+	OrgID    string   `json:"org_id"`
+	UserID   string   `json:"user_id"`
+	Tier     string   `json:"tier"`
+	Day      string   `json:"day"`
+	Requests int64    `json:"requests"`
+	Tokens   int64    `json:"tokens"`
+	Quota    Quota    `json:"quota"`
+	Sessions []Record `json:"sessions"`
+}
+
+type recordKey struct {
+	orgID     string
+	userID    string
+	sessionID string
+	day       string
+}
+
+// userDay identifies the daily totals of a user in an org
+type userDay struct {
+	orgID  string
+	userID string
+	day    string
+}
+
+type totals struct {
+	requests int64
+	tokens   int64
+}
+
+// Tracker accounts intent-service usage per user session and enforces the
+// quota of the tier of the user's org
+type Tracker struct {
+	records *lru.Cache[recordKey, *Record]
+	totals  map[userDay]*totals // quotas are checked against these, not the records
+	tiers   map[string]string   // orgID -> tier
+	quotas  map[string]Quota
+	mu      sync.Mutex
+}
+
+// NewTracker creates a new usage tracker; quotas override DefaultQuotas per
+// tier and tiers assigns orgs their tier. At most maxRecords session-day
+// records are kept; the least recently used go first.
+func NewTracker(quotas map[string]Quota, tiers map[string]string, maxRecords int) (*Tracker, error) {
+	merged := make(map[string]Quota, len(DefaultQuotas))
+	for tier, q := range DefaultQuotas {
+		merged[tier] = q
+	}
+	for tier, q := range quotas {
+		merged[tier] = q
+	}
+
+	t := &Tracker{
+		records: lru.New[recordKey, *Record]("usage_records", maxRecords, 0),
+		totals:  make(map[userDay]*totals),
+		tiers:   make(map[string]string),
+		quotas:  merged,
+	}
+	for orgID, tier := range tiers {
+		if err := t.SetTier(orgID, tier); err != nil {
+			return nil, err
+		}
+	}
+
+	// Start cleanup goroutine for old usage records
+	go t.cleanupOld()
+
+	return t, nil
+}
+
+// ParseTiers parses org tiers like "org-1=pro,org-2=enterprise"
+func ParseTiers(spec string) (map[string]string, error) {
+	tiers := make(map[string]string)
+	for _, pair := range strings.Split(spec, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		orgID, tier, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid org tier %q (expected org=tier)", pair)
+		}
+		tiers[strings.TrimSpace(orgID)] = strings.TrimSpace(tier)
+	}
+	return tiers, nil
+}
+
+// SetTier assigns a tier to an org; its users get the tier's quota
+func (t *Tracker) SetTier(orgID, tier string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.quotas[tier]; !ok {
+		return fmt.Errorf("unknown tier %q (expected %s, %s or %s)", tier, TierFree, TierPro, TierEnterprise)
+	}
+	t.tiers[orgID] = tier
+	return nil
+}
+
+// Reserve counts an AI request of a user session against today's quota, or
+// returns ErrQuotaExceeded when none is left. Checking and counting happen
+// together, so concurrent requests can't overrun the quota. Tokens are only
+// known after the request and are added by Record.
+func (t *Tracker) Reserve(orgID, userID, sessionID string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	day := today()
+	quota := t.quotas[t.tierOf(orgID)]
+	total := t.totalsOf(userDay{orgID: orgID, userID: userID, day: day})
+
+	if quota.RequestsPerDay > 0 && total.requests >= quota.RequestsPerDay {
+		return ErrQuotaExceeded
+	}
+	if quota.TokensPerDay > 0 && total.tokens >= quota.TokensPerDay {
+		return ErrQuotaExceeded
+	}
+
+	total.requests++
+	rec := t.recordOf(recordKey{orgID: orgID, userID: userID, sessionID: sessionID, day: day})
+	rec.Requests++
+	rec.LastUsedAt = time.Now()
+	return nil
+}
+
+// Record accounts the tokens and latency of a request counted by Reserve;
+// tokens is zero when the service doesn't report it
+func (t *Tracker) Record(orgID, userID, sessionID string, tokens int64, latency time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	day := today()
+	t.totalsOf(userDay{orgID: orgID, userID: userID, day: day}).tokens += tokens
+
+	rec := t.recordOf(recordKey{orgID: orgID, userID: userID, sessionID: sessionID, day: day})
+	rec.Tokens += tokens
+	rec.TotalLatency += latency.Milliseconds()
+	rec.LastUsedAt = time.Now()
+
+	logrus.WithFields(logrus.Fields{
+		"org_id":     orgID,
+		"user_id":    userID,
+		"session_id": sessionID,
+		"tokens":     tokens,
+		"latency":    latency,
+	}).Debug("🧮 Recorded AI usage")
+}
+
+// Summary returns the user's usage in an org for today
+func (t *Tracker) Summary(orgID, userID string) Summary {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	day := today()
+	tier := t.tierOf(orgID)
+	summary := Summary{
+		OrgID:    orgID,
+		UserID:   userID,
+		Tier:     tier,
+		Day:      day,
+		Quota:    t.quotas[tier],
+		Sessions: make([]Record, 0),
+	}
+	if total, ok := t.totals[userDay{orgID: orgID, userID: userID, day: day}]; ok {
+		summary.Requests, summary.Tokens = total.requests, total.tokens
+	}
+
+	t.records.Range(func(key recordKey, rec *Record) bool {
+		if key.orgID == orgID && key.userID == userID && key.day == day {
+			summary.Sessions = append(summary.Sessions, *rec)
+		}
+		return true
+	})
+
+	sort.Slice(summary.Sessions, func(i, j int) bool {
+		return summary.Sessions[i].LastUsedAt.After(summary.Sessions[j].LastUsedAt)
+	})
+
+	return summary
+}
+
+// tierOf returns the org's tier; callers must hold the lock
+func (t *Tracker) tierOf(orgID string) string {
+	if tier, ok := t.tiers[orgID]; ok {
+		return tier
+	}
+	return TierFree
+}
+
+// totalsOf returns the daily totals of a user, creating them; callers must hold the lock
+func (t *Tracker) totalsOf(key userDay) *totals {
+	total, ok := t.totals[key]
+	if !ok {
+		total = &totals{}
+		t.totals[key] = total
+	}
+	return total
+}
+
+// recordOf returns a session-day record, creating it; callers must hold the lock
+func (t *Tracker) recordOf(key recordKey) *Record {
+	rec, ok := t.records.Get(key)
+	if !ok {
+		rec = &Record{OrgID: key.orgID, UserID: key.userID, SessionID: key.sessionID, Day: key.day}
+		t.records.Put(key, rec)
+	}
+	return rec
+}
+
+// cleanupOld drops usage records past the retention window and the totals
+// of past days periodically
+func (t *Tracker) cleanupOld() {
+	ticker := time.NewTicker(1 * time.Hour)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		cutoff := time.Now().UTC().AddDate(0, 0, -retentionDays).Format("2006-01-02")
+		day := today()
+
+		t.mu.Lock()
+		t.records.PruneFunc(func(key recordKey, _ *Record) bool {
+			return key.day < cutoff
+		})
+		for key := range t.totals {
+			if key.day < day {
+				delete(t.totals, key)
+			}
+		}
+		t.mu.Unlock()
+	}
+}
+
+func today() string {
+	return time.Now().UTC().Format("2006-01-02")
+}
@@ -0,0 +1,129 @@
+package usage_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/avvvet/cdnbuddy-api/internal/services/usage"
+)
+
+func TestTrackerQuotas(t *testing.T) {
+	quotas := map[string]usage.Quota{
+		usage.TierFree: {RequestsPerDay: 2},
+		usage.TierPro:  {RequestsPerDay: 5, TokensPerDay: 100},
+	}
+
+	tests := []struct {
+		name     string
+		tier     string
+		tokens   int64 // recorded per request
+		wantSent int
+	}{
+		{name: "free tier by default", wantSent: 2},
+		{name: "pro tier", tier: usage.TierPro, wantSent: 5},
+		{name: "pro tier out of tokens", tier: usage.TierPro, tokens: 40, wantSent: 3},
+		{name: "enterprise is unlimited", tier: usage.TierEnterprise, wantSent: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tiers := map[string]string{}
+			if tt.tier != "" {
+				tiers["org-1"] = tt.tier
+			}
+			tracker, err := usage.NewTracker(quotas, tiers, 100)
+			if err != nil {
+				t.Fatalf("NewTracker() unexpected error: %v", err)
+			}
+
+			sent := 0
+			for range 10 {
+				if err := tracker.Reserve("org-1", "user-1", "session-1"); err != nil {
+					if !errors.Is(err, usage.ErrQuotaExceeded) {
+						t.Fatalf("Reserve() error = %v, want ErrQuotaExceeded", err)
+					}
+					break
+				}
+				tracker.Record("org-1", "user-1", "session-1", tt.tokens, time.Millisecond)
+				sent++
+			}
+			if sent != tt.wantSent {
+				t.Errorf("sent %d requests, want %d", sent, tt.wantSent)
+			}
+
+			summary := tracker.Summary("org-1", "user-1")
+			if summary.Requests != int64(sent) || summary.Tokens != int64(sent)*tt.tokens || len(summary.Sessions) != 1 {
+				t.Errorf("Summary() = %+v, want %d requests in one session", summary, sent)
+			}
+		})
+	}
+}
+
+func TestTrackerReserveIsAtomic(t *testing.T) {
+	tracker, err := usage.NewTracker(map[string]usage.Quota{usage.TierFree: {RequestsPerDay: 20}}, nil, 100)
+	if err != nil {
+		t.Fatalf("NewTracker() unexpected error: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	allowed := 0
+	for i := range 100 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if tracker.Reserve("org-1", "user-1", fmt.Sprintf("session-%d", i%5)) == nil {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if allowed != 20 {
+		t.Errorf("allowed %d concurrent requests, want 20", allowed)
+	}
+}
+
+func TestTrackerScopesUsageToOrg(t *testing.T) {
+	tracker, err := usage.NewTracker(nil, nil, 100)
+	if err != nil {
+		t.Fatalf("NewTracker() unexpected error: %v", err)
+	}
+	if err := tracker.Reserve("org-1", "user-1", "session-1"); err != nil {
+		t.Fatalf("Reserve() unexpected error: %v", err)
+	}
+
+	if got := tracker.Summary("org-2", "user-1"); got.Requests != 0 || len(got.Sessions) != 0 {
+		t.Errorf("Summary() in another org = %+v, want no usage", got)
+	}
+	if got := tracker.Summary("org-1", "user-1"); got.Requests != 1 {
+		t.Errorf("Summary() = %+v, want 1 request", got)
+	}
+}
+
+func TestSetTier(t *testing.T) {
+	tiers, err := usage.ParseTiers("org-1=pro, org-2=platinum")
+	if err != nil {
+		t.Fatalf("ParseTiers() unexpected error: %v", err)
+	}
+	if _, err := usage.NewTracker(nil, tiers, 100); err == nil || !strings.Contains(err.Error(), `unknown tier "platinum"`) {
+		t.Fatalf("NewTracker() error = %v, want unknown tier", err)
+	}
+	if _, err := usage.ParseTiers("org-1"); err == nil {
+		t.Fatalf("ParseTiers() accepted a pair without a tier")
+	}
+
+	tracker, _ := usage.NewTracker(nil, nil, 100)
+	if err := tracker.SetTier("org-1", usage.TierPro); err != nil {
+		t.Fatalf("SetTier() unexpected error: %v", err)
+	}
+	if got := tracker.Summary("org-1", "user-1"); got.Tier != usage.TierPro || got.Quota != usage.DefaultQuotas[usage.TierPro] {
+		t.Errorf("Summary() = %+v, want the pro quota", got)
+	}
+}
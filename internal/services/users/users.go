@@ -0,0 +1,159 @@
+// Package users holds each user's profile and settings: contact details,
+// notification preferences and the defaults new CDN setups start from.
+package users
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/avvvet/cdnbuddy-api/internal/models"
+	"github.com/avvvet/cdnbuddy-api/internal/services/cdn"
+)
+
+const maxNameLength = 100
+
+// Notifications are the messages a user wants and where
+type Notifications struct {
+	Email            bool `json:"email"`
+	Chat             bool `json:"chat"`
+	OperationUpdates bool `json:"operation_updates"` // progress of running operations
+	Reminders        bool `json:"reminders"`         // configuration review reminders
+}
+
+// Defaults are what a user's new setups use unless they say otherwise
+type Defaults struct {
+	Provider string `json:"provider,omitempty"`
+	Profile  string `json:"profile,omitempty"` // best-practices profile, e.g. spa
+}
+
+// Profile is one user's profile and settings
+type Profile struct {
+	UserID        string        `json:"user_id"`
+	Name          string        `json:"name,omitempty"`
+	Email         string        `json:"email,omitempty"`
+	Timezone      string        `json:"timezone,omitempty"` // IANA name, e.g. Europe/Berlin
+	Notifications Notifications `json:"notifications"`
+	Defaults      Defaults      `json:"defaults"`
+	UpdatedAt     time.Time     `json:"updated_at,omitempty"`
+}
+
+// DefaultNotifications apply to users who haven't chosen any
+var DefaultNotifications = Notifications{Email: true, Chat: true, OperationUpdates: true, Reminders: true}
+
+// Validate checks and normalizes the contact details
+func (p *Profile) Validate() error {
+	p.Name = strings.TrimSpace(p.Name)
+	if len(p.Name) > maxNameLength {
+		return fmt.Errorf("name must be at most %d characters", maxNameLength)
+	}
+
+	p.Email = strings.TrimSpace(p.Email)
+	if p.Email != "" {
+		addr, err := mail.ParseAddress(p.Email)
+		if err != nil || addr.Address != p.Email {
+			return fmt.Errorf("email must be an address like jane@example.com")
+		}
+	}
+
+	if p.Timezone != "" {
+		if _, err := time.LoadLocation(p.Timezone); err != nil {
+			return fmt.Errorf("unknown timezone %q", p.Timezone)
+		}
+	}
+	return nil
+}
+
+// Validate checks and normalizes the defaults; the profile is stored by its
+// canonical name, so "wp" is kept as wordpress
+func (d *Defaults) Validate() error {
+	d.Provider = string(cdn.ParseProvider(d.Provider))
+	if strings.TrimSpace(d.Profile) == "" {
+		d.Profile = ""
+		return nil
+	}
+	profile, err := cdn.ParseProfile(d.Profile)
+	if err != nil {
+		return err
+	}
+	d.Profile = string(profile)
+	return nil
+}
+
+// Enrich offers the user's defaults as inferred intent parameters, so the
+// assistant doesn't ask for a provider or profile the user already chose.
+// Parameters inferred from the account take precedence.
+func (d Defaults) Enrich(intentContext *models.IntentContext) {
+	if intentContext == nil {
+		return
+	}
+	if intentContext.InferredParameters == nil {
+		intentContext.InferredParameters = make(map[string]string)
+	}
+	for name, value := range map[string]string{"provider": d.Provider, "profile": d.Profile} {
+		if _, ok := intentContext.InferredParameters[name]; !ok && value != "" {
+			intentContext.InferredParameters[name] = value
+		}
+	}
+}
+
+// Store keeps user profiles in memory
+type Store struct {
+	profiles map[string]Profile // by user ID
+	mu       sync.RWMutex
+}
+
+// NewStore creates an empty profile store
+func NewStore() *Store {
+	return &Store{profiles: make(map[string]Profile)}
+}
+
+// Get returns a user's profile, or a default one for users without a profile
+func (s *Store) Get(userID string) Profile {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if profile, ok := s.profiles[userID]; ok {
+		return profile
+	}
+	return Profile{UserID: userID, Notifications: DefaultNotifications}
+}
+
+// SetContact validates and stores a user's name, email and timezone
+func (s *Store) SetContact(userID string, contact Profile) (Profile, error) {
+	if err := contact.Validate(); err != nil {
+		return Profile{}, err
+	}
+	return s.update(userID, func(p *Profile) {
+		p.Name, p.Email, p.Timezone = contact.Name, contact.Email, contact.Timezone
+	}), nil
+}
+
+// SetNotifications stores a user's notification preferences
+func (s *Store) SetNotifications(userID string, notifications Notifications) Profile {
+	return s.update(userID, func(p *Profile) { p.Notifications = notifications })
+}
+
+// SetDefaults validates and stores a user's setup defaults
+func (s *Store) SetDefaults(userID string, defaults Defaults) (Profile, error) {
+	if err := defaults.Validate(); err != nil {
+		return Profile{}, err
+	}
+	return s.update(userID, func(p *Profile) { p.Defaults = defaults }), nil
+}
+
+func (s *Store) update(userID string, apply func(p *Profile)) Profile {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	profile, ok := s.profiles[userID]
+	if !ok {
+		profile = Profile{UserID: userID, Notifications: DefaultNotifications}
+	}
+	apply(&profile)
+	profile.UpdatedAt = time.Now()
+	s.profiles[userID] = profile
+	return profile
+}
@@ -0,0 +1,93 @@
+package users_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/avvvet/cdnbuddy-api/internal/models"
+	"github.com/avvvet/cdnbuddy-api/internal/services/users"
+)
+
+func TestStore(t *testing.T) {
+	store := users.NewStore()
+
+	if p := store.Get("u1"); p.UserID != "u1" || p.Notifications != users.DefaultNotifications {
+		t.Fatalf("Get() of a new user = %+v, want the default notifications", p)
+	}
+
+	tests := []struct {
+		name    string
+		set     func() (users.Profile, error)
+		check   func(p users.Profile) bool
+		wantErr string
+	}{
+		{
+			name: "contact details",
+			set: func() (users.Profile, error) {
+				return store.SetContact("u1", users.Profile{Name: " Sam ", Email: "sam@example.com", Timezone: "Europe/Berlin"})
+			},
+			check: func(p users.Profile) bool { return p.Name == "Sam" && p.Email == "sam@example.com" },
+		},
+		{
+			name: "invalid email",
+			set: func() (users.Profile, error) {
+				return store.SetContact("u1", users.Profile{Email: "Sam <sam@example.com>"})
+			},
+			wantErr: "email must be an address",
+		},
+		{
+			name:    "unknown timezone",
+			set:     func() (users.Profile, error) { return store.SetContact("u1", users.Profile{Timezone: "Mars/Olympus"}) },
+			wantErr: "unknown timezone",
+		},
+		{
+			name: "defaults are normalized",
+			set: func() (users.Profile, error) {
+				return store.SetDefaults("u1", users.Defaults{Provider: " CacheFly", Profile: "wp"})
+			},
+			check: func(p users.Profile) bool {
+				return p.Defaults.Provider == "cachefly" && p.Defaults.Profile == "wordpress" && p.Email == "sam@example.com"
+			},
+		},
+		{
+			name:    "unknown profile",
+			set:     func() (users.Profile, error) { return store.SetDefaults("u1", users.Defaults{Profile: "blog"}) },
+			wantErr: "unknown profile",
+		},
+		{
+			name: "notifications keep the rest",
+			set: func() (users.Profile, error) {
+				return store.SetNotifications("u1", users.Notifications{Email: true}), nil
+			},
+			check: func(p users.Profile) bool {
+				return !p.Notifications.Chat && p.Notifications.Email && p.Defaults.Profile == "wordpress"
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := tt.set()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			if !tt.check(p) || store.Get("u1").UpdatedAt.IsZero() {
+				t.Errorf("profile = %+v", p)
+			}
+		})
+	}
+
+	t.Run("enrich keeps inferred parameters", func(t *testing.T) {
+		intentContext := &models.IntentContext{InferredParameters: map[string]string{"provider": "keycdn"}}
+		store.Get("u1").Defaults.Enrich(intentContext)
+		if got := intentContext.InferredParameters; got["provider"] != "keycdn" || got["profile"] != "wordpress" {
+			t.Errorf("inferred = %v, want provider keycdn and profile wordpress", got)
+		}
+	})
+}
@@ -0,0 +1,302 @@
+package webhooks
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/avvvet/cdnbuddy-api/internal/services/messaging"
+)
+
+// Delivery headers. The signature is "t=<unix seconds>,v1=<hex>", where v1 is
+// the HMAC-SHA256 of "<t>.<body>" keyed with the endpoint secret.
+const (
+	SignatureHeader = "X-CDNBuddy-Signature"
+	EventHeader     = "X-CDNBuddy-Event"
+	DeliveryHeader  = "X-CDNBuddy-Delivery"
+)
+
+const (
+	defaultMaxAttempts = 5
+	defaultBackoff     = 2 * time.Second
+	deliveryTimeout    = 10 * time.Second
+	historyPerEndpoint = 50
+
+	// queueGroup makes one API instance deliver each event
+	queueGroup = "webhooks"
+)
+
+// DeliveryStatus is the state of a delivery
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	DeliveryFailed    DeliveryStatus = "failed"
+)
+
+// Delivery is one event sent to one endpoint, with the outcome of its last attempt
+type Delivery struct {
+	ID         string         `json:"id"`
+	EndpointID string         `json:"endpoint_id"`
+	Event      string         `json:"event"`
+	ServiceID  string         `json:"service_id,omitempty"`
+	Status     DeliveryStatus `json:"status"`
+	Attempts   int            `json:"attempts"`
+	StatusCode int            `json:"status_code,omitempty"`
+	Error      string         `json:"error,omitempty"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+}
+
+// Payload is the body POSTed to endpoints
+type Payload struct {
+	ID        string      `json:"id"` // the delivery ID; stable across retries
+	Type      string      `json:"type"`
+	ServiceID string      `json:"service_id,omitempty"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// Dispatcher sends events to the endpoints that want them. Deliveries run in
+// the background; failed attempts are retried with doubling backoff.
+type Dispatcher struct {
+	store       *Store
+	client      *http.Client
+	orgOf       func(serviceID string) string
+	maxAttempts int
+	backoff     time.Duration
+
+	history map[string][]Delivery // by endpoint ID, oldest first
+	mu      sync.Mutex
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewDispatcher creates a dispatcher; orgOf resolves the org owning a service
+func NewDispatcher(store *Store, orgOf func(serviceID string) string) *Dispatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Dispatcher{
+		store:       store,
+		client:      deliveryClient(),
+		orgOf:       orgOf,
+		maxAttempts: defaultMaxAttempts,
+		backoff:     defaultBackoff,
+		history:     make(map[string][]Delivery),
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+}
+
+// Subscribe forwards service, domain, cache and operation events from the bus
+func (d *Dispatcher) Subscribe(subscriber *messaging.Subscriber) error {
+	for _, subject := range []string{messaging.SubjectCDNService, messaging.SubjectDomain, messaging.SubjectCache, messaging.SubjectOperation} {
+		if err := subscriber.QueueSubscribe(subject, queueGroup, d.forward); err != nil {
+			return fmt.Errorf("failed to subscribe webhooks to %s: %w", subject, err)
+		}
+	}
+	return nil
+}
+
+// forward dispatches a bus event as-is; domain events name their service cdn_service_id
+func (d *Dispatcher) forward(data []byte) error {
+	var event struct {
+		Type         string `json:"type"`
+		ServiceID    string `json:"service_id"`
+		CDNServiceID string `json:"cdn_service_id"`
+	}
+	if err := json.Unmarshal(data, &event); err != nil {
+		return fmt.Errorf("failed to decode event for webhooks: %w", err)
+	}
+	if !slices.Contains(EventTypes, event.Type) {
+		return nil
+	}
+	d.Dispatch(event.Type, cmp.Or(event.ServiceID, event.CDNServiceID), json.RawMessage(data))
+	return nil
+}
+
+// Dispatch sends an event of a service to the owning org's endpoints and
+// returns the number of deliveries started
+func (d *Dispatcher) Dispatch(eventType, serviceID string, data interface{}) int {
+	endpoints := d.store.matching(d.orgOf(serviceID), eventType, serviceID)
+	for _, e := range endpoints {
+		d.send(e, eventType, serviceID, data)
+	}
+	return len(endpoints)
+}
+
+// Ping sends a ping event to an endpoint, whatever events it subscribes to
+func (d *Dispatcher) Ping(endpointID string) (Delivery, error) {
+	e, ok := d.store.Get(endpointID)
+	if !ok {
+		return Delivery{}, ErrUnknownEndpoint
+	}
+	return d.send(e, EventPing, e.ServiceID, map[string]string{"endpoint_id": e.ID}), nil
+}
+
+// Deliveries returns an endpoint's recent deliveries, newest first
+func (d *Dispatcher) Deliveries(endpointID string) []Delivery {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	deliveries := slices.Clone(d.history[endpointID])
+	slices.Reverse(deliveries)
+	if deliveries == nil {
+		deliveries = make([]Delivery, 0)
+	}
+	return deliveries
+}
+
+// Forget drops the delivery history of a deleted endpoint
+func (d *Dispatcher) Forget(endpointID string) {
+	d.mu.Lock()
+	delete(d.history, endpointID)
+	d.mu.Unlock()
+}
+
+// Close stops retrying and waits for attempts in flight
+func (d *Dispatcher) Close() {
+	d.cancel()
+	d.wg.Wait()
+}
+
+// send starts delivering an event to one endpoint
+func (d *Dispatcher) send(e Endpoint, eventType, serviceID string, data interface{}) Delivery {
+	now := time.Now()
+	delivery := Delivery{
+		ID:         uuid.New().String(),
+		EndpointID: e.ID,
+		Event:      eventType,
+		ServiceID:  serviceID,
+		Status:     DeliveryPending,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+
+	body, err := json.Marshal(Payload{ID: delivery.ID, Type: eventType, ServiceID: serviceID, CreatedAt: now, Data: data})
+	if err != nil {
+		delivery.Status, delivery.Error = DeliveryFailed, fmt.Sprintf("failed to encode payload: %v", err)
+		d.record(delivery)
+		return delivery
+	}
+
+	d.record(delivery)
+	d.wg.Add(1)
+	go d.deliver(e, delivery, body)
+	return delivery
+}
+
+// deliver POSTs body until the endpoint accepts it, attempts run out or the
+// dispatcher closes
+func (d *Dispatcher) deliver(e Endpoint, delivery Delivery, body []byte) {
+	defer d.wg.Done()
+
+	backoff := d.backoff
+	for {
+		delivery.Attempts++
+		code, retryable, err := d.post(e, delivery, body)
+		delivery.StatusCode, delivery.UpdatedAt = code, time.Now()
+		if err == nil {
+			delivery.Status, delivery.Error = DeliverySucceeded, ""
+			d.record(delivery)
+			logrus.WithFields(logrus.Fields{
+				"endpoint_id": e.ID,
+				"event":       delivery.Event,
+				"attempts":    delivery.Attempts,
+			}).Debug("📤 Webhook delivered")
+			return
+		}
+
+		delivery.Error = err.Error()
+		if !retryable || delivery.Attempts >= d.maxAttempts {
+			delivery.Status = DeliveryFailed
+			d.record(delivery)
+			logrus.WithError(err).WithFields(logrus.Fields{
+				"endpoint_id": e.ID,
+				"event":       delivery.Event,
+				"attempts":    delivery.Attempts,
+			}).Warn("⚠️ Webhook delivery failed")
+			return
+		}
+		d.record(delivery)
+
+		select {
+		case <-time.After(backoff):
+			backoff *= 2
+		case <-d.ctx.Done():
+			delivery.Status, delivery.Error = DeliveryFailed, "dispatcher stopped before the delivery succeeded: "+delivery.Error
+			d.record(delivery)
+			return
+		}
+	}
+}
+
+// post makes one attempt; network errors, 5xx, 408 and 429 are retryable.
+// Attempts in flight when the dispatcher closes run to their timeout.
+func (d *Dispatcher) post(e Endpoint, delivery Delivery, body []byte) (int, bool, error) {
+	req, err := http.NewRequest(http.MethodPost, e.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "CDNBuddy-Webhooks/1.0")
+	req.Header.Set(EventHeader, delivery.Event)
+	req.Header.Set(DeliveryHeader, delivery.ID)
+	req.Header.Set(SignatureHeader, Sign(e.Secret, time.Now(), body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, true, fmt.Errorf("failed to reach webhook endpoint: %w", err)
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return resp.StatusCode, false, nil
+	case resp.StatusCode >= 500, resp.StatusCode == http.StatusRequestTimeout, resp.StatusCode == http.StatusTooManyRequests:
+		return resp.StatusCode, true, fmt.Errorf("webhook endpoint answered %d", resp.StatusCode)
+	default:
+		return resp.StatusCode, false, fmt.Errorf("webhook endpoint answered %d", resp.StatusCode)
+	}
+}
+
+// record stores the latest state of a delivery in its endpoint's history
+func (d *Dispatcher) record(delivery Delivery) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	history := d.history[delivery.EndpointID]
+	if i := slices.IndexFunc(history, func(h Delivery) bool { return h.ID == delivery.ID }); i >= 0 {
+		history[i] = delivery
+		return
+	}
+	history = append(history, delivery)
+	if len(history) > historyPerEndpoint {
+		history = history[len(history)-historyPerEndpoint:]
+	}
+	d.history[delivery.EndpointID] = history
+}
+
+// Sign returns the signature header value for a body sent at timestamp
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	t := strconv.FormatInt(timestamp.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(t + "."))
+	mac.Write(body)
+	return "t=" + t + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,83 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// ErrPrivateTarget is returned for endpoints that resolve to an address of
+// this network rather than the internet
+var ErrPrivateTarget = errors.New("webhook url must resolve to a public address")
+
+const resolveTimeout = 5 * time.Second
+
+var (
+	// lookupIP resolves endpoint hosts; replaced by tests
+	lookupIP = net.DefaultResolver.LookupIP
+
+	// allowPrivateTargets lets tests deliver to receivers on loopback
+	allowPrivateTargets = false
+)
+
+// publicIP reports whether ip is routable on the internet: not loopback,
+// private, link-local (which includes cloud metadata endpoints) or unspecified
+func publicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast())
+}
+
+// checkHost resolves host and rejects it when any of its addresses isn't public
+func checkHost(host string) error {
+	if allowPrivateTargets {
+		return nil
+	}
+
+	ips := []net.IP{net.ParseIP(host)}
+	if ips[0] == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), resolveTimeout)
+		defer cancel()
+
+		var err error
+		if ips, err = lookupIP(ctx, "ip", host); err != nil {
+			return fmt.Errorf("failed to resolve webhook host %s: %w", host, err)
+		}
+	}
+	for _, ip := range ips {
+		if !publicIP(ip) {
+			return fmt.Errorf("%w; %s resolves to %s", ErrPrivateTarget, host, ip)
+		}
+	}
+	return nil
+}
+
+// deliveryClient is the HTTP client deliveries are sent with. Its dialer checks
+// the address it actually connects to, so a host re-resolving to a private
+// address after registration (DNS rebinding), or a redirect to one, is refused.
+func deliveryClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: deliveryTimeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			if allowPrivateTargets {
+				return nil
+			}
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+				return fmt.Errorf("%w; refusing to connect to %s", ErrPrivateTarget, host)
+			}
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	transport.Proxy = nil // the proxy would connect on our behalf, unchecked
+	return &http.Client{Timeout: deliveryTimeout, Transport: transport}
+}
@@ -0,0 +1,215 @@
+// Package webhooks delivers CDN service, domain, cache and operation events
+// to URLs registered by an org, signed with a per-endpoint secret and retried
+// with backoff until the receiver accepts them.
+package webhooks
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/avvvet/cdnbuddy-api/internal/services/messaging"
+)
+
+// ErrUnknownEndpoint is returned for endpoints that don't exist
+var ErrUnknownEndpoint = errors.New("webhook endpoint not found")
+
+// EventPing is sent by Ping to check an endpoint
+const EventPing = "webhook.ping"
+
+// EventTypes are the event types endpoints can subscribe to
+var EventTypes = []string{
+	messaging.EventCDNServiceCreated, messaging.EventCDNServiceUpdated, messaging.EventCDNServiceDeleted,
+	messaging.EventDomainAdded, messaging.EventDomainRemoved, messaging.EventDomainStatusChanged,
+	messaging.EventCachePurged, messaging.EventCachePurgeCompleted, messaging.EventCacheRulesUpdated,
+	messaging.EventOperationStarted, messaging.EventOperationProgress, messaging.EventOperationCompleted, messaging.EventOperationFailed,
+}
+
+const maxEndpointsPerOrg = 20
+
+// Endpoint is a URL an org receives events on. Events lists event types or
+// prefixes like "domain.*"; empty means every event. ServiceID narrows the
+// events to one service.
+type Endpoint struct {
+	ID          string    `json:"id"`
+	OrgID       string    `json:"org_id"`
+	URL         string    `json:"url"`
+	Description string    `json:"description,omitempty"`
+	Events      []string  `json:"events,omitempty"`
+	ServiceID   string    `json:"service_id,omitempty"`
+	Disabled    bool      `json:"disabled,omitempty"`
+	Secret      string    `json:"secret,omitempty"` // only returned when the endpoint is created
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Validate checks an endpoint's URL and event filters. The URL must resolve
+// to public addresses, so webhooks can't reach services inside our network.
+func (e *Endpoint) Validate() error {
+	u, err := url.Parse(e.URL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return fmt.Errorf("url must be an absolute http or https URL")
+	}
+	if u.User != nil {
+		return fmt.Errorf("url can't contain credentials; verify deliveries with the signature instead")
+	}
+	if err := checkHost(u.Hostname()); err != nil {
+		return err
+	}
+
+	for _, event := range e.Events {
+		if event == "*" || slices.Contains(EventTypes, event) {
+			continue
+		}
+		if prefix, ok := strings.CutSuffix(event, ".*"); ok && slices.ContainsFunc(EventTypes, func(t string) bool { return strings.HasPrefix(t, prefix+".") }) {
+			continue
+		}
+		return fmt.Errorf("unknown event %q; events are %s or a prefix like domain.*", event, strings.Join(EventTypes, ", "))
+	}
+	return nil
+}
+
+// Wants reports whether the endpoint receives an event of a service
+func (e Endpoint) Wants(eventType, serviceID string) bool {
+	if e.Disabled || (e.ServiceID != "" && e.ServiceID != serviceID) {
+		return false
+	}
+	if len(e.Events) == 0 || eventType == EventPing {
+		return true
+	}
+	for _, event := range e.Events {
+		if event == "*" || event == eventType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(event, "*"); ok && strings.HasPrefix(eventType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Store keeps webhook endpoints in memory
+type Store struct {
+	endpoints map[string]Endpoint // by ID
+	mu        sync.RWMutex
+}
+
+// NewStore creates an empty endpoint store
+func NewStore() *Store {
+	return &Store{endpoints: make(map[string]Endpoint)}
+}
+
+// Create validates and stores a new endpoint. Without a secret one is
+// generated; it is returned only here.
+func (s *Store) Create(e Endpoint) (Endpoint, error) {
+	if err := e.Validate(); err != nil {
+		return Endpoint{}, err
+	}
+	if e.Secret == "" {
+		secret := make([]byte, 24)
+		if _, err := rand.Read(secret); err != nil {
+			return Endpoint{}, fmt.Errorf("failed to generate webhook secret: %w", err)
+		}
+		e.Secret = "whsec_" + hex.EncodeToString(secret)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count := 0
+	for _, existing := range s.endpoints {
+		if existing.OrgID == e.OrgID {
+			count++
+		}
+	}
+	if count >= maxEndpointsPerOrg {
+		return Endpoint{}, fmt.Errorf("an org can have at most %d webhook endpoints", maxEndpointsPerOrg)
+	}
+
+	e.ID = uuid.New().String()
+	e.CreatedAt = time.Now()
+	e.UpdatedAt = e.CreatedAt
+	s.endpoints[e.ID] = e
+	return e, nil
+}
+
+// Update replaces an endpoint's settings; its ID, org and secret are kept
+// unless a new secret is given
+func (s *Store) Update(id string, e Endpoint) (Endpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, ok := s.endpoints[id]
+	if !ok {
+		return Endpoint{}, ErrUnknownEndpoint
+	}
+	e.ID, e.OrgID, e.CreatedAt, e.UpdatedAt = current.ID, current.OrgID, current.CreatedAt, time.Now()
+	if e.Secret == "" {
+		e.Secret = current.Secret
+	}
+	if err := e.Validate(); err != nil {
+		return Endpoint{}, err
+	}
+
+	s.endpoints[id] = e
+	e.Secret = ""
+	return e, nil
+}
+
+// Delete removes an endpoint; it reports whether the endpoint existed
+func (s *Store) Delete(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.endpoints[id]
+	delete(s.endpoints, id)
+	return ok
+}
+
+// Get returns an endpoint including its secret
+func (s *Store) Get(id string) (Endpoint, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	e, ok := s.endpoints[id]
+	return e, ok
+}
+
+// List returns an org's endpoints, oldest first and without secrets
+func (s *Store) List(orgID string) []Endpoint {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	endpoints := make([]Endpoint, 0)
+	for _, e := range s.endpoints {
+		if e.OrgID != orgID {
+			continue
+		}
+		e.Secret = ""
+		endpoints = append(endpoints, e)
+	}
+	sort.Slice(endpoints, func(i, j int) bool { return endpoints[i].CreatedAt.Before(endpoints[j].CreatedAt) })
+	return endpoints
+}
+
+// matching returns the org's endpoints, with secrets, that want an event
+func (s *Store) matching(orgID, eventType, serviceID string) []Endpoint {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	endpoints := make([]Endpoint, 0)
+	for _, e := range s.endpoints {
+		if e.OrgID == orgID && e.Wants(eventType, serviceID) {
+			endpoints = append(endpoints, e)
+		}
+	}
+	return endpoints
+}
@@ -0,0 +1,32 @@
+package widget
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/avvvet/cdnbuddy-api/internal/domain"
+	"github.com/avvvet/cdnbuddy-api/internal/services/ownership"
+)
+
+// ErrOutOfScope is returned for intents reaching beyond the token's org
+var ErrOutOfScope = errors.New("widget token doesn't cover this service")
+
+// CheckScope reports whether a widget user of orgID may act on the service
+// intent parameters target on provider. Widget users only reach services
+// their org owns, named by service_id; account-wide actions and services
+// named only by domain are out of scope.
+func CheckScope(owners *ownership.Store, orgID string, provider domain.CDNProvider, params map[string]*string) error {
+	var serviceID string
+	if v := params["service_id"]; v != nil {
+		serviceID = *v
+	}
+	if serviceID == "" {
+		return fmt.Errorf("%w: name one of your org's services by ID", ErrOutOfScope)
+	}
+
+	record, ok := owners.Get(provider, serviceID)
+	if !ok || record.OrgID != orgID {
+		return fmt.Errorf("%w: %s", ErrOutOfScope, serviceID)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package widget_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/avvvet/cdnbuddy-api/internal/domain"
+	"github.com/avvvet/cdnbuddy-api/internal/services/ownership"
+	"github.com/avvvet/cdnbuddy-api/internal/services/widget"
+)
+
+func TestCheckScope(t *testing.T) {
+	owners := ownership.NewStore()
+	owners.Put(ownership.Record{ServiceID: "svc-1", Provider: domain.ProviderCacheFly, OrgID: "org-1"})
+	owners.Put(ownership.Record{ServiceID: "svc-2", Provider: domain.ProviderCacheFly, OrgID: "org-2"})
+
+	str := func(s string) *string { return &s }
+	tests := []struct {
+		name     string
+		provider domain.CDNProvider
+		params   map[string]*string
+		wantErr  error
+	}{
+		{
+			name:     "service the org owns",
+			provider: domain.ProviderCacheFly,
+			params:   map[string]*string{"service_id": str("svc-1")},
+		},
+		{
+			name:     "another org's service",
+			provider: domain.ProviderCacheFly,
+			params:   map[string]*string{"service_id": str("svc-2")},
+			wantErr:  widget.ErrOutOfScope,
+		},
+		{
+			name:     "unmanaged service",
+			provider: domain.ProviderCacheFly,
+			params:   map[string]*string{"service_id": str("svc-3")},
+			wantErr:  widget.ErrOutOfScope,
+		},
+		{
+			name:     "same ID on another provider",
+			provider: domain.ProviderKeyCDN,
+			params:   map[string]*string{"service_id": str("svc-1")},
+			wantErr:  widget.ErrOutOfScope,
+		},
+		{
+			name:     "service named only by domain",
+			provider: domain.ProviderCacheFly,
+			params:   map[string]*string{"domain": str("cdn.example.com")},
+			wantErr:  widget.ErrOutOfScope,
+		},
+		{
+			name:     "account-wide action",
+			provider: domain.ProviderCacheFly,
+			params:   map[string]*string{},
+			wantErr:  widget.ErrOutOfScope,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := widget.CheckScope(owners, "org-1", tt.provider, tt.params)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CheckScope() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+// Package widget issues the short-lived tokens that let customers embed the
+// chat assistant on their own admin panels. A token is bound to one org, one
+// page origin and one widget user, and is checked on every chat message.
+package widget
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/avvvet/cdnbuddy-api/internal/signedtoken"
+)
+
+const (
+	// DefaultTTL is how long a token is valid unless asked otherwise
+	DefaultTTL = 15 * time.Minute
+	// MaxTTL bounds token lifetime; embedding pages mint a new one before it runs out
+	MaxTTL = time.Hour
+)
+
+var (
+	// ErrInvalidToken is returned for tokens that weren't issued by this issuer
+	ErrInvalidToken = errors.New("invalid widget token")
+	// ErrExpired is returned for tokens past their expiry
+	ErrExpired = errors.New("widget token has expired")
+	// ErrOriginMismatch is returned when a token is used from another page origin
+	ErrOriginMismatch = errors.New("widget token was issued for another origin")
+)
+
+// Claims are what a widget token grants
+type Claims struct {
+	ID        string    `json:"id"`
+	OrgID     string    `json:"org_id"`
+	Origin    string    `json:"origin"`
+	UserID    string    `json:"user_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Token is an issued widget token
+type Token struct {
+	Token string `json:"token"`
+	Claims
+}
+
+// Issuer mints and verifies widget tokens
+type Issuer struct {
+	signer *signedtoken.Signer
+}
+
+// NewIssuer creates a token issuer; the secret must be dedicated to widget
+// tokens (see signedtoken.New)
+func NewIssuer(secret string) (*Issuer, error) {
+	signer, err := signedtoken.New(secret, "widget")
+	if err != nil {
+		return nil, fmt.Errorf("invalid widget token secret: %w", err)
+	}
+	return &Issuer{signer: signer}, nil
+}
+
+// Issue mints a token for an org's page origin. Without a user ID the widget
+// user gets a generated one, so anonymous embeds don't share a chat history.
+func (i *Issuer) Issue(orgID, origin, userID string, ttl time.Duration) (*Token, error) {
+	if orgID == "" || origin == "" {
+		return nil, fmt.Errorf("org_id and origin are required")
+	}
+	if ttl == 0 {
+		ttl = DefaultTTL
+	}
+	if ttl < time.Minute || ttl > MaxTTL {
+		return nil, fmt.Errorf("ttl must be between 1m and %s", MaxTTL)
+	}
+
+	claims := Claims{
+		ID:        uuid.New().String(),
+		OrgID:     orgID,
+		Origin:    origin,
+		UserID:    userID,
+		ExpiresAt: time.Now().Add(ttl).UTC().Truncate(time.Second),
+	}
+	if claims.UserID == "" {
+		claims.UserID = "widget:" + claims.ID
+	}
+
+	token, err := i.signer.Sign(claims)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign widget token: %w", err)
+	}
+	return &Token{Token: token, Claims: claims}, nil
+}
+
+// Verify checks a token's signature and expiry, and that it is used from the
+// origin it was issued for
+func (i *Issuer) Verify(token, origin string) (*Claims, error) {
+	var claims Claims
+	switch err := i.signer.Verify(token, &claims); {
+	case errors.Is(err, signedtoken.ErrExpired):
+		return nil, ErrExpired
+	case err != nil || claims.OrgID == "":
+		return nil, ErrInvalidToken
+	}
+	if !strings.EqualFold(strings.TrimSuffix(origin, "/"), claims.Origin) {
+		return nil, ErrOriginMismatch
+	}
+	return &claims, nil
+}
@@ -0,0 +1,79 @@
+package widget_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/avvvet/cdnbuddy-api/internal/services/widget"
+	"github.com/avvvet/cdnbuddy-api/internal/signedtoken"
+)
+
+const secret = "0123456789abcdef0123456789abcdef"
+
+func TestNewIssuerRejectsPlaceholderSecret(t *testing.T) {
+	for _, s := range []string{"", "your-secret-key", "short"} {
+		if _, err := widget.NewIssuer(s); err == nil {
+			t.Errorf("NewIssuer(%q) error = nil, want an error", s)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	issuer, err := widget.NewIssuer(secret)
+	if err != nil {
+		t.Fatalf("NewIssuer() error = %v", err)
+	}
+	token, err := issuer.Issue("org-1", "https://shop.example.com", "", 0)
+	if err != nil {
+		t.Fatalf("Issue() error = %v", err)
+	}
+	other, err := widget.NewIssuer(strings.ToUpper(secret))
+	if err != nil {
+		t.Fatalf("NewIssuer() error = %v", err)
+	}
+	otherToken, err := other.Issue("org-1", "https://shop.example.com", "", 0)
+	if err != nil {
+		t.Fatalf("Issue() error = %v", err)
+	}
+	// Same scheme the issuer uses, to mint a token that has already expired
+	signer, err := signedtoken.New(secret, "widget")
+	if err != nil {
+		t.Fatalf("signedtoken.New() error = %v", err)
+	}
+	expired, err := signer.Sign(widget.Claims{
+		OrgID:     "org-1",
+		Origin:    "https://shop.example.com",
+		ExpiresAt: time.Now().Add(-time.Minute),
+	})
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		origin  string
+		wantErr error
+	}{
+		{name: "same origin", token: token.Token, origin: "https://shop.example.com"},
+		{name: "origin with trailing slash and case", token: token.Token, origin: "https://Shop.Example.com/"},
+		{name: "other origin", token: token.Token, origin: "https://evil.example.com", wantErr: widget.ErrOriginMismatch},
+		{name: "expired", token: expired, origin: "https://shop.example.com", wantErr: widget.ErrExpired},
+		{name: "tampered", token: token.Token[:len(token.Token)-2] + "xx", origin: "https://shop.example.com", wantErr: widget.ErrInvalidToken},
+		{name: "wrong secret", token: otherToken.Token, origin: "https://shop.example.com", wantErr: widget.ErrInvalidToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := issuer.Verify(tt.token, tt.origin)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && claims.OrgID != "org-1" {
+				t.Errorf("OrgID = %q, want org-1", claims.OrgID)
+			}
+		})
+	}
+}
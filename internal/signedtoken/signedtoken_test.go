@@ -0,0 +1,122 @@
+package signedtoken_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/avvvet/cdnbuddy-api/internal/signedtoken"
+)
+
+const secret = "0123456789abcdef0123456789abcdef"
+
+type claims struct {
+	Subject   string    `json:"subject"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		secret  string
+		wantErr bool
+	}{
+		{name: "dedicated secret", secret: secret},
+		{name: "empty", secret: "", wantErr: true},
+		{name: "config placeholder", secret: "your-secret-key", wantErr: true},
+		{name: "placeholder in other case", secret: "CHANGEME", wantErr: true},
+		{name: "too short", secret: strings.Repeat("x", signedtoken.MinSecretLength-1), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := signedtoken.New(tt.secret, "test")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	signer, err := signedtoken.New(secret, "test")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	sign := func(c claims) string {
+		token, err := signer.Sign(c)
+		if err != nil {
+			t.Fatalf("Sign() error = %v", err)
+		}
+		return token
+	}
+	signWith := func(secret, purpose string, c claims) string {
+		other, err := signedtoken.New(secret, purpose)
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		token, err := other.Sign(c)
+		if err != nil {
+			t.Fatalf("Sign() error = %v", err)
+		}
+		return token
+	}
+	valid := claims{Subject: "svc-1", ExpiresAt: time.Now().Add(time.Hour)}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{name: "valid", token: sign(valid)},
+		{
+			name:    "expired",
+			token:   sign(claims{Subject: "svc-1", ExpiresAt: time.Now().Add(-time.Second)}),
+			wantErr: signedtoken.ErrExpired,
+		},
+		{
+			name:    "no expiry",
+			token:   sign(claims{Subject: "svc-1"}),
+			wantErr: signedtoken.ErrInvalid,
+		},
+		{
+			name: "tampered claims",
+			token: func() string {
+				_, signature, _ := strings.Cut(sign(valid), ".")
+				forged, _, _ := strings.Cut(sign(claims{Subject: "svc-2", ExpiresAt: valid.ExpiresAt}), ".")
+				return forged + "." + signature
+			}(),
+			wantErr: signedtoken.ErrInvalid,
+		},
+		{
+			name:    "tampered signature",
+			token:   sign(valid) + "x",
+			wantErr: signedtoken.ErrInvalid,
+		},
+		{
+			name:    "wrong secret",
+			token:   signWith(strings.ToUpper(secret), "test", valid),
+			wantErr: signedtoken.ErrInvalid,
+		},
+		{
+			name:    "other purpose",
+			token:   signWith(secret, "other", valid),
+			wantErr: signedtoken.ErrInvalid,
+		},
+		{name: "not a token", token: "garbage", wantErr: signedtoken.ErrInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got claims
+			err := signer.Verify(tt.token, &got)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && got.Subject != valid.Subject {
+				t.Errorf("Subject = %q, want %q", got.Subject, valid.Subject)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package testutil
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/avvvet/cdnbuddy-api/internal/domain"
+	"github.com/avvvet/cdnbuddy-api/internal/services/cdn"
+	"github.com/avvvet/cdnbuddy-api/internal/services/messaging"
+)
+
+// AwaitTimeout bounds how long Await waits for a value
+const AwaitTimeout = 5 * time.Second
+
+// StartNATS runs an embedded NATS server with JetStream on a random local port
+// and returns a bus connected to it. Both are shut down when the test ends.
+func StartNATS(t testing.TB) messaging.Bus {
+	t.Helper()
+
+	srv, err := messaging.StartEmbeddedServer("127.0.0.1:-1", t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to start embedded NATS: %v", err)
+	}
+	t.Cleanup(srv.Shutdown)
+
+	bus, err := messaging.NewNATSClient(srv.ClientURL())
+	if err != nil {
+		t.Fatalf("failed to connect to embedded NATS: %v", err)
+	}
+	t.Cleanup(bus.Close)
+	return bus
+}
+
+// Await returns the next value from ch, failing the test after AwaitTimeout
+func Await[T any](t testing.TB, ch <-chan T) T {
+	t.Helper()
+
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(AwaitTimeout):
+		var zero T
+		t.Fatalf("timed out after %s waiting for %T", AwaitTimeout, zero)
+		return zero
+	}
+}
+
+// NewMockService returns a cdn.Service backed by an empty in-memory provider;
+// the provider is the store of record, so seed it with SeedService
+func NewMockService(t testing.TB) (*cdn.Service, *cdn.MockProvider) {
+	t.Helper()
+	provider := cdn.NewMockProvider()
+	return cdn.NewService(provider), provider
+}
+
+// SeedService creates a service on the provider with an HTTPS origin and the
+// given domains
+func SeedService(t testing.TB, provider cdn.CDNProvider, name, origin string, domains ...string) domain.CDNService {
+	t.Helper()
+	ctx := context.Background()
+
+	svc, err := provider.CreateService(ctx, &cdn.ServiceConfig{
+		Name:   name,
+		Origin: cdn.OriginConfig{Host: origin, Protocol: "https"},
+		SSL:    cdn.SSLConfig{Enabled: true},
+	})
+	if err != nil {
+		t.Fatalf("failed to seed service %s: %v", name, err)
+	}
+	for _, d := range domains {
+		if err := provider.AddDomain(ctx, svc.ID, d); err != nil {
+			t.Fatalf("failed to seed domain %s: %v", d, err)
+		}
+	}
+	return *svc
+}
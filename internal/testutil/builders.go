@@ -0,0 +1,182 @@
+package testutil
+
+import (
+	"time"
+
+	"github.com/avvvet/cdnbuddy-api/internal/domain"
+	"github.com/avvvet/cdnbuddy-api/internal/models"
+)
+
+// ServiceBuilder builds a domain.CDNService with sensible defaults
+type ServiceBuilder struct {
+	service domain.CDNService
+}
+
+// NewServiceBuilder starts an active mock-provider service named example.com
+func NewServiceBuilder() *ServiceBuilder {
+	return &ServiceBuilder{service: domain.CDNService{
+		ID:        "svc-1",
+		UserID:    "user-1",
+		Provider:  domain.ProviderMock,
+		Name:      "example.com",
+		Status:    "ACTIVE",
+		Config:    "{}",
+		CreatedAt: Epoch,
+		UpdatedAt: Epoch,
+	}}
+}
+
+func (b *ServiceBuilder) WithID(id string) *ServiceBuilder {
+	b.service.ID = id
+	return b
+}
+
+func (b *ServiceBuilder) WithUser(userID string) *ServiceBuilder {
+	b.service.UserID = userID
+	return b
+}
+
+func (b *ServiceBuilder) WithProvider(provider domain.CDNProvider) *ServiceBuilder {
+	b.service.Provider = provider
+	return b
+}
+
+func (b *ServiceBuilder) WithName(name string) *ServiceBuilder {
+	b.service.Name = name
+	return b
+}
+
+func (b *ServiceBuilder) WithStatus(status string) *ServiceBuilder {
+	b.service.Status = status
+	return b
+}
+
+func (b *ServiceBuilder) WithConfig(config string) *ServiceBuilder {
+	b.service.Config = config
+	return b
+}
+
+// At sets both timestamps
+func (b *ServiceBuilder) At(t time.Time) *ServiceBuilder {
+	b.service.CreatedAt, b.service.UpdatedAt = t, t
+	return b
+}
+
+// Build returns a copy of the service; the builder can be reused
+func (b *ServiceBuilder) Build() domain.CDNService {
+	return b.service
+}
+
+// DomainBuilder builds a domain.Domain with sensible defaults
+type DomainBuilder struct {
+	domain domain.Domain
+}
+
+// NewDomainBuilder starts an active www.example.com domain of svc-1
+func NewDomainBuilder() *DomainBuilder {
+	return &DomainBuilder{domain: domain.Domain{
+		ID:           "dom-1",
+		CDNServiceID: "svc-1",
+		Name:         "www.example.com",
+		Status:       "ACTIVE",
+		Regions:      1,
+		CreatedAt:    Epoch,
+		UpdatedAt:    Epoch,
+	}}
+}
+
+func (b *DomainBuilder) WithID(id string) *DomainBuilder {
+	b.domain.ID = id
+	return b
+}
+
+func (b *DomainBuilder) WithService(serviceID string) *DomainBuilder {
+	b.domain.CDNServiceID = serviceID
+	return b
+}
+
+func (b *DomainBuilder) WithName(name string) *DomainBuilder {
+	b.domain.Name = name
+	return b
+}
+
+func (b *DomainBuilder) WithStatus(status string) *DomainBuilder {
+	b.domain.Status = status
+	return b
+}
+
+func (b *DomainBuilder) WithRegions(regions int) *DomainBuilder {
+	b.domain.Regions = regions
+	return b
+}
+
+// At sets both timestamps
+func (b *DomainBuilder) At(t time.Time) *DomainBuilder {
+	b.domain.CreatedAt, b.domain.UpdatedAt = t, t
+	return b
+}
+
+// Build returns a copy of the domain; the builder can be reused
+func (b *DomainBuilder) Build() domain.Domain {
+	return b.domain
+}
+
+// IntentBuilder builds a models.IntentResponse as the intent service sends it
+type IntentBuilder struct {
+	intent models.IntentResponse
+}
+
+// NewIntentBuilder starts a READY intent for action without parameters
+func NewIntentBuilder(action string) *IntentBuilder {
+	return &IntentBuilder{intent: models.IntentResponse{
+		SessionID:  "session-1",
+		Action:     &action,
+		Status:     "READY",
+		Parameters: make(map[string]*string),
+	}}
+}
+
+// WithParam sets a parameter
+func (b *IntentBuilder) WithParam(name, value string) *IntentBuilder {
+	b.intent.Parameters[name] = &value
+	return b
+}
+
+// WithMissing adds a parameter the intent service couldn't fill (null in JSON)
+func (b *IntentBuilder) WithMissing(name string) *IntentBuilder {
+	b.intent.Parameters[name] = nil
+	return b
+}
+
+// NeedsInfo marks the intent as waiting for the user, with the question to ask
+func (b *IntentBuilder) NeedsInfo(question string) *IntentBuilder {
+	b.intent.Status = "NEEDS_INFO"
+	b.intent.UserMessage = question
+	return b
+}
+
+func (b *IntentBuilder) WithSession(sessionID string) *IntentBuilder {
+	b.intent.SessionID = sessionID
+	return b
+}
+
+func (b *IntentBuilder) WithUserMessage(message string) *IntentBuilder {
+	b.intent.UserMessage = message
+	return b
+}
+
+// Build returns the intent with its own copy of the parameters
+func (b *IntentBuilder) Build() *models.IntentResponse {
+	intent := b.intent
+	action := *b.intent.Action
+	intent.Action = &action
+	intent.Parameters = make(map[string]*string, len(b.intent.Parameters))
+	for name, value := range b.intent.Parameters {
+		if value != nil {
+			v := *value
+			value = &v
+		}
+		intent.Parameters[name] = value
+	}
+	return &intent
+}
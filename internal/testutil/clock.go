@@ -0,0 +1,50 @@
+// Package testutil provides builders for domain objects, events and intents,
+// a fake clock, and helpers that start messaging and CDN backends for tests.
+package testutil
+
+import (
+	"sync"
+	"time"
+)
+
+// Epoch is the time builders and new clocks start at, so test output is stable
+var Epoch = time.Date(2025, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+// Clock is a fake clock that only moves when told to. Its Now method can be
+// passed wherever a func() time.Time is expected.
+type Clock struct {
+	now time.Time
+	mu  sync.Mutex
+}
+
+// NewClock creates a clock at Epoch
+func NewClock() *Clock {
+	return &Clock{now: Epoch}
+}
+
+// Now returns the clock's current time
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance moves the clock forward by d and returns the new time
+func (c *Clock) Advance(d time.Duration) time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	return c.now
+}
+
+// Set moves the clock to t
+func (c *Clock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = t
+}
+
+// Since returns the time elapsed on the clock since t
+func (c *Clock) Since(t time.Time) time.Duration {
+	return c.Now().Sub(t)
+}
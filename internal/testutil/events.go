@@ -0,0 +1,53 @@
+package testutil
+
+import (
+	"github.com/avvvet/cdnbuddy-api/internal/domain"
+	"github.com/avvvet/cdnbuddy-api/internal/services/messaging"
+)
+
+// ServiceEvent builds the event the publisher sends for svc, stamped at Epoch
+func ServiceEvent(eventType string, svc domain.CDNService) messaging.CDNServiceEvent {
+	return messaging.CDNServiceEvent{
+		Type:      eventType,
+		ServiceID: svc.ID,
+		UserID:    svc.UserID,
+		Provider:  string(svc.Provider),
+		Name:      svc.Name,
+		Status:    svc.Status,
+		Timestamp: Epoch,
+	}
+}
+
+// DomainEvent builds the event the publisher sends for d, stamped at Epoch
+func DomainEvent(eventType string, d domain.Domain) messaging.DomainEvent {
+	return messaging.DomainEvent{
+		Type:         eventType,
+		DomainID:     d.ID,
+		CDNServiceID: d.CDNServiceID,
+		Name:         d.Name,
+		Status:       d.Status,
+		Regions:      d.Regions,
+		Timestamp:    Epoch,
+	}
+}
+
+// PurgeEvent builds a cache purge event, stamped at Epoch
+func PurgeEvent(serviceID, userID string, paths ...string) messaging.CacheEvent {
+	return messaging.CacheEvent{
+		Type:      messaging.EventCachePurged,
+		ServiceID: serviceID,
+		UserID:    userID,
+		Paths:     paths,
+		Timestamp: Epoch,
+	}
+}
+
+// ExecuteCommand builds a command to run a plan now, stamped at Epoch
+func ExecuteCommand(userID, sessionID, planID string) messaging.ExecuteCommand {
+	return messaging.ExecuteCommand{
+		UserID:    userID,
+		SessionID: sessionID,
+		PlanID:    planID,
+		Timestamp: Epoch,
+	}
+}